@@ -3,13 +3,19 @@ package main
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/AlexeySalamakhin/GophKeeper/internal/audit"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/config"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/logger"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/server"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
@@ -27,6 +33,75 @@ func main() {
 
 	viper.AutomaticEnv()
 
+	rootCmd := &cobra.Command{
+		Use:   "gophkeeper-server",
+		Short: "Сервер GophKeeper",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			serve()
+		},
+	}
+
+	rotateCmd := &cobra.Command{
+		Use:   "rotate-keys",
+		Short: "Перешифровать данные активным ключом шифрования",
+		Long: "Перешифровывает данные, сохраненные неактивными ключами связки, активным ключом.\n" +
+			"Команду можно выполнять при работающем сервере; прерванный запуск продолжается повторным.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			batchSize, _ := cmd.Flags().GetInt("batch-size")
+			rotateKeys(batchSize)
+		},
+	}
+	rotateCmd.Flags().Int("batch-size", server.DefaultRotationBatchSize, "Число записей, читаемых за один запрос")
+
+	keyfileCmd := &cobra.Command{
+		Use:   "keyfile",
+		Short: "Управление файлом ключей шифрования",
+	}
+	addKeyCmd := &cobra.Command{
+		Use:   "add-key",
+		Short: "Добавить в файл ключей новый ключ и сделать его текущим",
+		Long: "Добавляет в файл CRYPTO_KEYFILE случайный ключ и делает его текущим.\n" +
+			"Если файла еще нет, он создается из ключей CRYPTO_KEY и CRYPTO_KEYS.\n" +
+			"После добавления ключа выполните rotate-keys.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			id, _ := cmd.Flags().GetString("id")
+			addKeyfileKey(id)
+		},
+	}
+	addKeyCmd.Flags().String("id", "", "Идентификатор нового ключа")
+	addKeyCmd.MarkFlagRequired("id")
+	keyfileCmd.AddCommand(addKeyCmd)
+
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Проверка журнала аудита",
+	}
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Проверить цепочку хешей журнала аудита и подписи контрольных точек",
+		Long: "Проходит журнал аудита от первого события и сообщает о первом нарушении цепочки:\n" +
+			"измененном, удаленном или пропущенном событии и неверной контрольной точке.\n" +
+			"Если журнал нарушен, команда завершается с ненулевым кодом.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			batchSize, _ := cmd.Flags().GetInt("batch-size")
+			verifyAudit(batchSize)
+		},
+	}
+	verifyCmd.Flags().Int("batch-size", audit.DefaultVerifyBatchSize, "Число событий, читаемых за один запрос")
+	auditCmd.AddCommand(verifyCmd)
+
+	rootCmd.AddCommand(rotateCmd, keyfileCmd, auditCmd)
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// serve запускает сервер и дожидается сигнала о завершении работы.
+func serve() {
 	// Создаем контекст для graceful shutdown
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
@@ -61,3 +136,90 @@ func main() {
 
 	logger.Logger.Info("Сервер успешно завершил работу")
 }
+
+// rotateKeys перешифровывает данные активным ключом шифрования.
+func rotateKeys(batchSize int) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	stats, err := server.New().RotateKeys(ctx, batchSize)
+	fields := []zap.Field{
+		zap.Int("data", stats.Data),
+		zap.Int("revisions", stats.Revisions),
+		zap.Int("users", stats.Users),
+		zap.Int("vaults", stats.Vaults),
+		zap.Int("changed", stats.Changed),
+	}
+	if err != nil {
+		logger.Logger.Fatal("Перешифрование прервано, повторный запуск продолжит его",
+			append(fields, zap.Error(err))...,
+		)
+	}
+
+	if stats.Changed > 0 {
+		logger.Logger.Warn("Часть значений изменилась во время перешифрования, выполните команду повторно", fields...)
+		return
+	}
+	logger.Logger.Info("Перешифрование завершено", fields...)
+}
+
+// verifyAudit проверяет цепочку журнала аудита.
+func verifyAudit(batchSize int) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	result, err := server.New().VerifyAudit(ctx, batchSize)
+	fields := []zap.Field{
+		zap.Int64("events", result.Events),
+		zap.Int("checkpoints", result.Checkpoints),
+	}
+	if err != nil {
+		logger.Logger.Fatal("Ошибка проверки журнала аудита", append(fields, zap.Error(err))...)
+	}
+
+	if result.Break != nil {
+		fields = append(fields, zap.Int64("seq", result.Break.Seq), zap.String("reason", result.Break.Reason))
+		if result.Break.EventID != uuid.Nil {
+			fields = append(fields, zap.String("event_id", result.Break.EventID.String()))
+		}
+		logger.Logger.Fatal("Цепочка журнала аудита нарушена", fields...)
+	}
+	logger.Logger.Info("Журнал аудита не изменен", fields...)
+}
+
+// addKeyfileKey добавляет в файл ключей новый текущий ключ id.
+func addKeyfileKey(id string) {
+	cfg := config.Load().Crypto
+	if cfg.Keyfile == "" {
+		logger.Logger.Fatal("CRYPTO_KEYFILE не установлен")
+	}
+
+	var keys *crypto.Keyring
+	_, err := os.Stat(cfg.Keyfile)
+	switch {
+	case err == nil:
+		keys, err = crypto.LoadKeyfile(cfg.Keyfile, cfg.KeyfilePassphrase)
+	case errors.Is(err, os.ErrNotExist) && (cfg.Key != "" || cfg.Keys != ""):
+		// Ключи из окружения переносятся в файл, чтобы прежние данные остались доступны
+		keys, err = cfg.Keyring()
+	case errors.Is(err, os.ErrNotExist):
+		keys, err = crypto.NewRandomKeyring(id)
+	}
+	if err != nil {
+		logger.Logger.Fatal("Ошибка чтения ключей шифрования", zap.Error(err))
+	}
+
+	if keys.CurrentKeyID() != id {
+		if keys, err = keys.WithRandomKey(id); err != nil {
+			logger.Logger.Fatal("Ошибка добавления ключа", zap.Error(err))
+		}
+	}
+	if err := crypto.SaveKeyfile(cfg.Keyfile, cfg.KeyfilePassphrase, keys); err != nil {
+		logger.Logger.Fatal("Ошибка записи файла ключей", zap.Error(err))
+	}
+
+	logger.Logger.Info("Ключ добавлен в файл ключей, перешифруйте данные командой rotate-keys",
+		zap.String("key_id", id),
+		zap.String("keyfile", cfg.Keyfile),
+	)
+}
@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/google/uuid"
+)
+
+// newTestLog записывает count событий и возвращает журнал и ключи его контрольных точек.
+func newTestLog(t *testing.T, count, checkpointInterval int) (*memoryLog, crypto.KeyProvider) {
+	t.Helper()
+	keys, err := crypto.NewKeyring(crypto.DefaultKeyID, map[string]string{crypto.DefaultKeyID: "audit-key"})
+	if err != nil {
+		t.Fatalf("Ошибка создания ключей: %v", err)
+	}
+
+	log := &memoryLog{}
+	recorder := NewRecorder(log, keys, checkpointInterval)
+	actorID := uuid.New()
+	for i := 0; i < count; i++ {
+		recorder.Record(&models.AuditEvent{ActorID: &actorID, Action: models.AuditRead, IP: "10.0.0.1"})
+	}
+	return log, keys
+}
+
+func TestVerify_IntactChain(t *testing.T) {
+	log, keys := newTestLog(t, 7, 3)
+
+	result, err := Verify(context.Background(), log, keys, 2)
+	if err != nil {
+		t.Fatalf("Ошибка проверки: %v", err)
+	}
+	if result.Break != nil {
+		t.Fatalf("Цепочка не должна быть нарушена: %+v", result.Break)
+	}
+	if result.Events != 7 || result.Checkpoints != 2 {
+		t.Errorf("Ожидалось 7 событий и 2 контрольные точки, получено %+v", result)
+	}
+	if log.events[0].PrevHash != "" || log.events[1].PrevHash != log.events[0].Hash {
+		t.Error("Событие должно ссылаться на хеш предыдущего")
+	}
+}
+
+func TestVerify_ReportsFirstBreak(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(log *memoryLog)
+		seq    int64
+		reason string
+	}{
+		{
+			name:   "измененное событие",
+			tamper: func(log *memoryLog) { log.events[4].IP = "192.168.0.1" },
+			seq:    5,
+			reason: "событие изменено",
+		},
+		{
+			name:   "удаленное событие",
+			tamper: func(log *memoryLog) { log.events = append(log.events[:3], log.events[4:]...) },
+			seq:    4,
+			reason: "пропущены события",
+		},
+		{
+			name:   "усеченный журнал",
+			tamper: func(log *memoryLog) { log.events = log.events[:5] },
+			seq:    6,
+			reason: "журнал усечен",
+		},
+		{
+			name: "цепочка пересчитана без ключа сервера",
+			tamper: func(log *memoryLog) {
+				log.events[1].Details = "подмена"
+				for i := 1; i < len(log.events); i++ {
+					log.events[i].PrevHash = log.events[i-1].Hash
+					log.events[i].Hash = EventHash(&log.events[i])
+				}
+			},
+			seq:    3,
+			reason: "не совпадает с подписанной контрольной точкой",
+		},
+		{
+			name: "подделанная контрольная точка",
+			tamper: func(log *memoryLog) {
+				log.checkpoints[0].Signature = log.checkpoints[1].Signature
+			},
+			seq:    3,
+			reason: "подпись контрольной точки неверна",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, keys := newTestLog(t, 7, 3)
+			tt.tamper(log)
+
+			result, err := Verify(context.Background(), log, keys, 2)
+			if err != nil {
+				t.Fatalf("Ошибка проверки: %v", err)
+			}
+			if result.Break == nil {
+				t.Fatal("Нарушение цепочки не обнаружено")
+			}
+			if result.Break.Seq != tt.seq || !strings.Contains(result.Break.Reason, tt.reason) {
+				t.Errorf("Ожидалось нарушение %q на событии %d, получено %+v", tt.reason, tt.seq, result.Break)
+			}
+		})
+	}
+}
+
+func TestRecorder_ContinuesExistingChain(t *testing.T) {
+	log, keys := newTestLog(t, 2, 0)
+
+	// Новый экземпляр сервера продолжает цепочку с последнего события
+	NewRecorder(log, keys, 0).Record(&models.AuditEvent{Action: models.AuditLogin})
+
+	if last := log.events[len(log.events)-1]; last.Seq != 3 || last.PrevHash != log.events[1].Hash {
+		t.Errorf("Событие должно продолжить цепочку, получено %+v", last)
+	}
+	if result, _ := Verify(context.Background(), log, keys, 0); result.Break != nil {
+		t.Errorf("Цепочка не должна быть нарушена: %+v", result.Break)
+	}
+}
+
+// memoryLog хранит журнал аудита в памяти и позволяет тестам изменять его напрямую.
+type memoryLog struct {
+	events      []models.AuditEvent
+	checkpoints []models.AuditCheckpoint
+}
+
+var _ repository.AuditRepositoryInterface = (*memoryLog)(nil)
+
+func (l *memoryLog) Create(event *models.AuditEvent) error {
+	l.events = append(l.events, *event)
+	return nil
+}
+
+func (l *memoryLog) GetByActorID(actorID uuid.UUID, limit, offset int) ([]models.AuditEvent, int64, error) {
+	return nil, 0, nil
+}
+
+func (l *memoryLog) GetLast() (*models.AuditEvent, error) {
+	if len(l.events) == 0 {
+		return nil, nil
+	}
+	event := l.events[len(l.events)-1]
+	return &event, nil
+}
+
+func (l *memoryLog) GetAfter(seq int64, limit int) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	for _, event := range l.events {
+		if event.Seq > seq && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (l *memoryLog) CreateCheckpoint(checkpoint *models.AuditCheckpoint) error {
+	l.checkpoints = append(l.checkpoints, *checkpoint)
+	return nil
+}
+
+func (l *memoryLog) GetCheckpoints() ([]models.AuditCheckpoint, error) {
+	return l.checkpoints, nil
+}
+
+func (l *memoryLog) ReplaceCheckpointSignature(id uuid.UUID, oldSignature, newSignature string) error {
+	return nil
+}
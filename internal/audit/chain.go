@@ -0,0 +1,54 @@
+// Package audit содержит запись событий в журнал аудита.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/google/uuid"
+)
+
+// EventHash вычисляет хеш события вместе с хешем предыдущего события цепочки.
+// Поля кодируются с длиной, поэтому перенос текста между полями меняет хеш.
+func EventHash(event *models.AuditEvent) string {
+	sum := sha256.Sum256(crypto.BindAAD(
+		"audit-event",
+		strconv.FormatInt(event.Seq, 10),
+		event.PrevHash,
+		optionalID(event.ActorID),
+		event.Username,
+		string(event.Action),
+		optionalID(event.DataID),
+		event.Details,
+		event.IP,
+		event.UserAgent,
+		event.CreatedAt.UTC().Format(time.RFC3339Nano),
+	))
+	return hex.EncodeToString(sum[:])
+}
+
+// optionalID возвращает строковое представление ID или пустую строку.
+func optionalID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+// signCheckpoint подписывает хеш события seq ключом сервера.
+func signCheckpoint(keys crypto.KeyProvider, seq int64, hash string) (string, error) {
+	return keys.Wrap([]byte(hash), crypto.AuditCheckpointAAD(seq))
+}
+
+// signedHash возвращает хеш, подписанный контрольной точкой, или ошибку, если подпись неверна.
+func signedHash(keys crypto.KeyProvider, checkpoint *models.AuditCheckpoint) (string, error) {
+	hash, err := keys.Unwrap(checkpoint.Signature, crypto.AuditCheckpointAAD(checkpoint.Seq))
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
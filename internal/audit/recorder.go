@@ -0,0 +1,111 @@
+// Package audit содержит запись событий в журнал аудита.
+package audit
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/logger"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Recorder записывает события в журнал аудита, продолжая цепочку хешей,
+// и через каждые checkpointInterval событий ставит подписанную контрольную точку.
+// Нулевой *Recorder допустим и ничего не записывает.
+type Recorder struct {
+	repo               repository.AuditRepositoryInterface
+	keys               crypto.KeyProvider
+	checkpointInterval int64
+
+	mutex  sync.Mutex
+	head   *models.AuditEvent // Последнее событие цепочки, nil для пустого журнала
+	loaded bool               // head прочитан из репозитория
+}
+
+// NewRecorder создает запись событий в журнал repo. Контрольные точки подписываются
+// ключом сервера из keys; если keys не задан или checkpointInterval не больше нуля,
+// они не ставятся.
+func NewRecorder(repo repository.AuditRepositoryInterface, keys crypto.KeyProvider, checkpointInterval int) *Recorder {
+	return &Recorder{repo: repo, keys: keys, checkpointInterval: int64(checkpointInterval)}
+}
+
+// Record добавляет событие в конец цепочки. Ошибка записи журнала не должна
+// прерывать действие пользователя, поэтому она только логируется.
+func (r *Recorder) Record(event *models.AuditEvent) {
+	if r == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	err := r.append(event)
+	if err != nil {
+		// Цепочку мог продолжить другой экземпляр сервера: перечитываем ее конец и повторяем
+		r.loaded = false
+		err = r.append(event)
+	}
+	if err != nil {
+		logger.Logger.Error("Ошибка записи журнала аудита",
+			zap.String("action", string(event.Action)),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if r.keys != nil && r.checkpointInterval > 0 && event.Seq%r.checkpointInterval == 0 {
+		if err := r.checkpoint(event); err != nil {
+			logger.Logger.Error("Ошибка записи контрольной точки журнала аудита",
+				zap.Int64("seq", event.Seq),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// append связывает событие с концом цепочки и сохраняет его.
+func (r *Recorder) append(event *models.AuditEvent) error {
+	if !r.loaded {
+		head, err := r.repo.GetLast()
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		r.head, r.loaded = head, true
+	}
+
+	event.Seq, event.PrevHash = 1, ""
+	if r.head != nil {
+		event.Seq, event.PrevHash = r.head.Seq+1, r.head.Hash
+	}
+	if event.CreatedAt.IsZero() {
+		// База данных хранит время с точностью до микросекунд, и хеш должен совпасть после чтения
+		event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	}
+	event.Hash = EventHash(event)
+
+	if err := r.repo.Create(event); err != nil {
+		return err
+	}
+
+	head := *event
+	r.head = &head
+	return nil
+}
+
+// checkpoint подписывает хеш события и сохраняет контрольную точку.
+func (r *Recorder) checkpoint(event *models.AuditEvent) error {
+	signature, err := signCheckpoint(r.keys, event.Seq, event.Hash)
+	if err != nil {
+		return err
+	}
+	return r.repo.CreateCheckpoint(&models.AuditCheckpoint{
+		Seq:       event.Seq,
+		Hash:      event.Hash,
+		Signature: signature,
+	})
+}
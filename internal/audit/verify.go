@@ -0,0 +1,126 @@
+// Package audit содержит запись событий в журнал аудита.
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/google/uuid"
+)
+
+// DefaultVerifyBatchSize используется, если размер пакета проверки не задан.
+const DefaultVerifyBatchSize = 1000
+
+// ChainBreak описывает первое нарушение цепочки журнала аудита.
+type ChainBreak struct {
+	Seq     int64     // Номер события, на котором цепочка нарушена
+	EventID uuid.UUID // ID события, если оно есть в журнале
+	Reason  string
+}
+
+// VerifyResult содержит итоги проверки журнала аудита.
+type VerifyResult struct {
+	Events      int64 // Проверено событий
+	Checkpoints int   // Проверено контрольных точек
+	Break       *ChainBreak
+}
+
+// Verify проходит цепочку журнала аудита от первого события и проверяет номера,
+// ссылки на предыдущие события, хеши и подписи контрольных точек ключами из keys.
+// Проверка останавливается на первом нарушении, которое возвращается в Break.
+// Изменение событий после последней контрольной точки с пересчетом хешей
+// обнаружить нельзя, поэтому интервал контрольных точек ограничивает такое окно.
+func Verify(ctx context.Context, repo repository.AuditRepositoryInterface, keys crypto.KeyProvider, batchSize int) (VerifyResult, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultVerifyBatchSize
+	}
+
+	var result VerifyResult
+	checkpoints, err := repo.GetCheckpoints()
+	if err != nil {
+		return result, err
+	}
+	next := 0 // Индекс следующей непроверенной контрольной точки
+
+	var prev *models.AuditEvent
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		batch, err := repo.GetAfter(lastSeq(prev), batchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for i := range batch {
+			event := &batch[i]
+			if result.Break = checkEvent(prev, event); result.Break != nil {
+				return result, nil
+			}
+			if next < len(checkpoints) && checkpoints[next].Seq == event.Seq {
+				if result.Break = checkCheckpoint(keys, &checkpoints[next], event); result.Break != nil {
+					return result, nil
+				}
+				result.Checkpoints++
+				next++
+			}
+
+			result.Events++
+			prev = event
+		}
+	}
+
+	// Контрольная точка после конца цепочки означает, что журнал усечен
+	if next < len(checkpoints) {
+		result.Break = &ChainBreak{
+			Seq:    lastSeq(prev) + 1,
+			Reason: fmt.Sprintf("журнал усечен: контрольная точка указывает на событие %d", checkpoints[next].Seq),
+		}
+	}
+	return result, nil
+}
+
+// checkEvent проверяет, что событие продолжает цепочку после prev и его хеш не изменен.
+func checkEvent(prev, event *models.AuditEvent) *ChainBreak {
+	wantSeq, wantPrev := int64(1), ""
+	if prev != nil {
+		wantSeq, wantPrev = prev.Seq+1, prev.Hash
+	}
+
+	switch {
+	case event.Seq != wantSeq:
+		return &ChainBreak{Seq: wantSeq, Reason: fmt.Sprintf("пропущены события с %d по %d", wantSeq, event.Seq-1)}
+	case event.PrevHash != wantPrev:
+		return &ChainBreak{Seq: event.Seq, EventID: event.ID, Reason: "хеш предыдущего события не совпадает"}
+	case EventHash(event) != event.Hash:
+		return &ChainBreak{Seq: event.Seq, EventID: event.ID, Reason: "событие изменено"}
+	}
+	return nil
+}
+
+// checkCheckpoint проверяет подпись контрольной точки и совпадение подписанного хеша с событием.
+func checkCheckpoint(keys crypto.KeyProvider, checkpoint *models.AuditCheckpoint, event *models.AuditEvent) *ChainBreak {
+	hash, err := signedHash(keys, checkpoint)
+	if err != nil {
+		return &ChainBreak{Seq: event.Seq, EventID: event.ID, Reason: "подпись контрольной точки неверна"}
+	}
+	if hash != event.Hash {
+		return &ChainBreak{Seq: event.Seq, EventID: event.ID, Reason: "цепочка не совпадает с подписанной контрольной точкой"}
+	}
+	return nil
+}
+
+// lastSeq возвращает номер события или 0, если события нет.
+func lastSeq(event *models.AuditEvent) int64 {
+	if event == nil {
+		return 0
+	}
+	return event.Seq
+}
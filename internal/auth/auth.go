@@ -2,6 +2,10 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
@@ -9,10 +13,26 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// AccessTokenTTL задает срок действия токена доступа.
+// Токены живут недолго, а для продолжения работы клиент обменивает токен обновления.
+const AccessTokenTTL = 15 * time.Minute
+
+// ChallengeTokenTTL задает время на ввод одноразового пароля после проверки основного пароля.
+const ChallengeTokenTTL = 5 * time.Minute
+
+// purposeTwoFactor отмечает токены, подтверждающие только первый шаг входа.
+const purposeTwoFactor = "2fa"
+
+// refreshTokenSize задает длину токена обновления в байтах.
+const refreshTokenSize = 32
+
 // Claims представляет JWT claims.
 type Claims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	SessionID string `json:"sid"`
+	// Purpose отличает токены второго шага входа от токенов доступа.
+	Purpose string `json:"purpose,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -26,24 +46,64 @@ func NewJWTManager(secretKey string) *JWTManager {
 	return &JWTManager{secretKey: secretKey}
 }
 
-// GenerateToken генерирует JWT токен для пользователя.
-func (jm *JWTManager) GenerateToken(userID, username string) (string, error) {
-	claims := &Claims{
+// GenerateToken генерирует токен доступа пользователя в рамках сессии sessionID.
+func (jm *JWTManager) GenerateToken(userID, username, sessionID string) (string, error) {
+	return jm.sign(&Claims{
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
+	}, AccessTokenTTL)
+}
+
+// GenerateChallengeToken генерирует токен, подтверждающий, что пользователь ввел верный пароль.
+// Токен обменивается на токены доступа только вместе с одноразовым паролем.
+func (jm *JWTManager) GenerateChallengeToken(userID, username string) (string, error) {
+	return jm.sign(&Claims{
 		UserID:   userID,
 		Username: username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+		Purpose:  purposeTwoFactor,
+	}, ChallengeTokenTTL)
+}
+
+// ValidateToken валидирует токен доступа и возвращает claims.
+func (jm *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := jm.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != "" {
+		return nil, errors.New("токен не является токеном доступа")
+	}
+	return claims, nil
+}
+
+// ValidateChallengeToken валидирует токен второго шага входа и возвращает claims.
+func (jm *JWTManager) ValidateChallengeToken(tokenString string) (*Claims, error) {
+	claims, err := jm.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purposeTwoFactor {
+		return nil, errors.New("токен не является токеном второго шага входа")
+	}
+	return claims, nil
+}
+
+// sign подписывает claims со сроком действия ttl.
+func (jm *JWTManager) sign(claims *Claims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(jm.secretKey))
 }
 
-// ValidateToken валидирует JWT токен и возвращает claims.
-func (jm *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
+// parse проверяет подпись и срок действия токена.
+func (jm *JWTManager) parse(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("неверный метод подписи токена")
@@ -73,3 +133,21 @@ func CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// NewRefreshToken генерирует случайный токен обновления и возвращает его вместе с хешем.
+// На сервере хранится только хеш, сам токен передается клиенту один раз.
+func NewRefreshToken() (token, hash string, err error) {
+	buf := make([]byte, refreshTokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken возвращает хеш токена обновления для поиска сессии.
+// Токен содержит 256 бит случайных данных, поэтому соль и медленный хеш не нужны.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
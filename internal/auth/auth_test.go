@@ -49,7 +49,7 @@ func TestJWTManager_GenerateToken(t *testing.T) {
 	userID := "test-user-id"
 	username := "testuser"
 
-	token, err := jm.GenerateToken(userID, username)
+	token, err := jm.GenerateToken(userID, username, "test-session-id")
 	if err != nil {
 		t.Fatalf("Ошибка генерации токена: %v", err)
 	}
@@ -67,7 +67,7 @@ func TestJWTManager_ValidateToken(t *testing.T) {
 	username := "testuser"
 
 	// Генерация токена
-	token, err := jm.GenerateToken(userID, username)
+	token, err := jm.GenerateToken(userID, username, "test-session-id")
 	if err != nil {
 		t.Fatalf("Ошибка генерации токена: %v", err)
 	}
@@ -85,6 +85,10 @@ func TestJWTManager_ValidateToken(t *testing.T) {
 	if claims.Username != username {
 		t.Errorf("Ожидался Username %s, получен %s", username, claims.Username)
 	}
+
+	if claims.SessionID != "test-session-id" {
+		t.Errorf("Ожидался SessionID %s, получен %s", "test-session-id", claims.SessionID)
+	}
 }
 
 func TestJWTManager_ValidateInvalidToken(t *testing.T) {
@@ -125,3 +129,52 @@ func TestJWTManager_ValidateExpiredToken(t *testing.T) {
 		t.Error("Валидация истекшего токена должна возвращать ошибку")
 	}
 }
+
+func TestNewRefreshToken(t *testing.T) {
+	token, hash, err := NewRefreshToken()
+	if err != nil {
+		t.Fatalf("Ошибка генерации токена обновления: %v", err)
+	}
+
+	if token == "" || hash == "" || token == hash {
+		t.Fatalf("Ожидались непустые и различные токен и хеш, получено %q и %q", token, hash)
+	}
+
+	if HashRefreshToken(token) != hash {
+		t.Error("Хеш токена обновления должен совпадать с возвращенным")
+	}
+
+	other, _, err := NewRefreshToken()
+	if err != nil {
+		t.Fatalf("Ошибка генерации токена обновления: %v", err)
+	}
+	if other == token {
+		t.Error("Токены обновления не должны повторяться")
+	}
+}
+
+func TestJWTManager_ChallengeToken(t *testing.T) {
+	jm := NewJWTManager("test-secret-key")
+
+	challenge, err := jm.GenerateChallengeToken("test-user-id", "testuser")
+	if err != nil {
+		t.Fatalf("Ошибка генерации токена: %v", err)
+	}
+
+	claims, err := jm.ValidateChallengeToken(challenge)
+	if err != nil {
+		t.Fatalf("Ошибка валидации токена: %v", err)
+	}
+	if claims.UserID != "test-user-id" {
+		t.Errorf("Ожидался UserID %s, получен %s", "test-user-id", claims.UserID)
+	}
+
+	if _, err := jm.ValidateToken(challenge); err == nil {
+		t.Error("Токен второго шага входа не должен приниматься как токен доступа")
+	}
+
+	access, _ := jm.GenerateToken("test-user-id", "testuser", "test-session-id")
+	if _, err := jm.ValidateChallengeToken(access); err == nil {
+		t.Error("Токен доступа не должен приниматься как токен второго шага входа")
+	}
+}
@@ -0,0 +1,131 @@
+// Package auth содержит логику аутентификации и авторизации.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Параметры одноразовых паролей по RFC 6238. Они совпадают со значениями
+// по умолчанию приложений-аутентификаторов, поэтому передаются в URI только для наглядности.
+const (
+	totpPeriod     = 30
+	totpDigits     = 6
+	totpSkew       = 1 // Допустимое расхождение часов в шагах до и после текущего
+	totpSecretSize = 20
+)
+
+// recoveryCodeSize задает длину кода восстановления в байтах.
+const recoveryCodeSize = 5
+
+// totpEncoding кодирует секреты в base32 без выравнивания, как ожидают приложения-аутентификаторы.
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTOTPSecret генерирует секрет одноразовых паролей в base32.
+func NewTOTPSecret() (string, error) {
+	buf := make([]byte, totpSecretSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(buf), nil
+}
+
+// TOTPProvisioningURI формирует otpauth:// URI для добавления секрета в приложение-аутентификатор.
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// TOTPCode возвращает одноразовый пароль для момента t.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, totpStep(t)), nil
+}
+
+// ValidateTOTP проверяет одноразовый пароль с учетом расхождения часов и возвращает его шаг.
+// Пароли с шагом не больше lastStep отклоняются, чтобы один код нельзя было использовать дважды.
+func ValidateTOTP(secret, code string, t time.Time, lastStep int64) (int64, bool) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := totpStep(t)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hotp(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// NewRecoveryCodes генерирует n одноразовых кодов восстановления вида xxxx-xxxx.
+func NewRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	buf := make([]byte, recoveryCodeSize)
+	for i := 0; i < n; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(buf))
+		codes = append(codes, code[:4]+"-"+code[4:])
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode возвращает хеш кода восстановления без учета регистра и дефисов.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return HashRefreshToken(normalized)
+}
+
+// decodeTOTPSecret декодирует секрет, допуская нижний регистр и выравнивание.
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	normalized := strings.TrimRight(strings.ToUpper(strings.ReplaceAll(secret, " ", "")), "=")
+	return totpEncoding.DecodeString(normalized)
+}
+
+// totpStep возвращает номер шага времени для момента t.
+func totpStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// hotp вычисляет одноразовый пароль по RFC 4226 для счетчика counter.
+func hotp(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
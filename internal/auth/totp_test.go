@@ -0,0 +1,94 @@
+// Package auth содержит тесты для одноразовых паролей.
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret содержит ключ из тестовых векторов RFC 6238 для SHA1.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode_RFCVectors(t *testing.T) {
+	// Последние шесть цифр восьмизначных значений из приложения B RFC 6238
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, want := range vectors {
+		code, err := TOTPCode(rfcSecret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatalf("Ошибка вычисления кода: %v", err)
+		}
+		if code != want {
+			t.Errorf("Для времени %d ожидался код %s, получен %s", unix, want, code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatalf("Ошибка генерации секрета: %v", err)
+	}
+
+	now := time.Now()
+	code, _ := TOTPCode(secret, now.Add(-totpPeriod*time.Second))
+
+	step, ok := ValidateTOTP(secret, code, now, 0)
+	if !ok {
+		t.Fatal("Код предыдущего шага должен приниматься с учетом расхождения часов")
+	}
+
+	if _, ok := ValidateTOTP(secret, code, now, step); ok {
+		t.Error("Уже использованный код не должен приниматься повторно")
+	}
+
+	old, _ := TOTPCode(secret, now.Add(-5*totpPeriod*time.Second))
+	if _, ok := ValidateTOTP(secret, old, now, 0); ok {
+		t.Error("Устаревший код не должен приниматься")
+	}
+
+	if _, ok := ValidateTOTP(secret, "12345", now, 0); ok {
+		t.Error("Код неверной длины не должен приниматься")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("GophKeeper", "user@example.com", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/GophKeeper:user@example.com?") {
+		t.Errorf("Неожиданный URI %s", uri)
+	}
+	if !strings.Contains(uri, "secret=ABC") || !strings.Contains(uri, "issuer=GophKeeper") {
+		t.Errorf("URI должен содержать секрет и издателя: %s", uri)
+	}
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, err := NewRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("Ошибка генерации кодов: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 9 || code[4] != '-' {
+			t.Errorf("Неожиданный формат кода %q", code)
+		}
+		if seen[code] {
+			t.Errorf("Код %q повторяется", code)
+		}
+		seen[code] = true
+	}
+
+	if HashRecoveryCode(codes[0]) != HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))) {
+		t.Error("Хеш кода не должен зависеть от регистра и дефисов")
+	}
+}
@@ -0,0 +1,171 @@
+// Package backup содержит формат зашифрованных резервных копий хранилища пользователя.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/google/uuid"
+)
+
+// Файлы внутри архива резервной копии.
+const (
+	manifestEntry = "manifest.json"
+	snapshotEntry = "vault.json"
+	filesPrefix   = "files/"
+)
+
+// Manifest описывает резервную копию.
+type Manifest struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Records   int       `json:"records"`
+	Files     int       `json:"files"`
+}
+
+// Writer записывает резервную копию: tar архив, сжатый gzip и зашифрованный паролем.
+// Первыми в архив записываются manifest.json и vault.json со снимком хранилища,
+// за ними содержимое файлов files/<ID записи>.
+type Writer struct {
+	seal io.WriteCloser
+	gz   *gzip.Writer
+	tw   *tar.Writer
+}
+
+// NewWriter начинает резервную копию хранилища со снимком snapshot.
+func NewWriter(w io.Writer, password string, snapshot *Snapshot) (*Writer, error) {
+	seal, err := newSealWriter(w, password)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(seal)
+	bw := &Writer{seal: seal, gz: gz, tw: tar.NewWriter(gz)}
+
+	manifest := Manifest{
+		Format:    Format,
+		Version:   Version,
+		CreatedAt: time.Now().UTC(),
+		Records:   len(snapshot.Records),
+	}
+	for _, record := range snapshot.Records {
+		if record.Type == models.DataTypeFile {
+			manifest.Files++
+		}
+	}
+
+	if err := bw.writeJSON(manifestEntry, manifest); err != nil {
+		return nil, err
+	}
+	if err := bw.writeJSON(snapshotEntry, snapshot); err != nil {
+		return nil, err
+	}
+	return bw, nil
+}
+
+// AddFile добавляет содержимое файла записи recordID размером size байт.
+func (bw *Writer) AddFile(recordID uuid.UUID, size int64, r io.Reader) error {
+	if err := bw.tw.WriteHeader(&tar.Header{Name: filesPrefix + recordID.String(), Mode: 0600, Size: size}); err != nil {
+		return err
+	}
+	_, err := io.Copy(bw.tw, r)
+	return err
+}
+
+// Close завершает архив. Резервная копия без завершения не читается.
+func (bw *Writer) Close() error {
+	if err := bw.tw.Close(); err != nil {
+		return err
+	}
+	if err := bw.gz.Close(); err != nil {
+		return err
+	}
+	return bw.seal.Close()
+}
+
+// writeJSON записывает в архив файл name с value в формате JSON.
+func (bw *Writer) writeJSON(name string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := bw.tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(raw))}); err != nil {
+		return err
+	}
+	_, err = bw.tw.Write(raw)
+	return err
+}
+
+// Reader читает резервную копию, записанную Writer.
+type Reader struct {
+	Manifest Manifest
+	Snapshot Snapshot
+	gz       *gzip.Reader
+	tr       *tar.Reader
+}
+
+// NewReader расшифровывает резервную копию паролем password и читает ее описание
+// и снимок хранилища. Содержимое файлов читается затем через NextFile.
+func NewReader(r io.Reader, password string) (*Reader, error) {
+	plain, err := newOpenReader(r, password)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(plain)
+	if err != nil {
+		return nil, ErrCorrupted
+	}
+	br := &Reader{gz: gz, tr: tar.NewReader(gz)}
+
+	if err := br.readJSON(manifestEntry, &br.Manifest); err != nil {
+		return nil, err
+	}
+	if br.Manifest.Format != Format || br.Manifest.Version != Version {
+		return nil, fmt.Errorf("неподдерживаемая версия резервной копии %d", br.Manifest.Version)
+	}
+	if err := br.readJSON(snapshotEntry, &br.Snapshot); err != nil {
+		return nil, err
+	}
+	return br, nil
+}
+
+// NextFile возвращает ID записи и содержимое следующего файла. Содержимое нужно прочитать
+// до следующего вызова. После последнего файла проверяет целостность копии до конца
+// и возвращает io.EOF.
+func (br *Reader) NextFile() (uuid.UUID, io.Reader, error) {
+	hdr, err := br.tr.Next()
+	if errors.Is(err, io.EOF) {
+		if _, err := io.Copy(io.Discard, br.gz); err != nil {
+			return uuid.Nil, nil, ErrCorrupted
+		}
+		return uuid.Nil, nil, io.EOF
+	}
+	if err != nil {
+		return uuid.Nil, nil, ErrCorrupted
+	}
+
+	id, err := uuid.Parse(strings.TrimPrefix(hdr.Name, filesPrefix))
+	if !strings.HasPrefix(hdr.Name, filesPrefix) || err != nil {
+		return uuid.Nil, nil, fmt.Errorf("%w: неожиданный файл %s", ErrCorrupted, hdr.Name)
+	}
+	return id, br.tr, nil
+}
+
+// readJSON читает из архива файл name в формате JSON.
+func (br *Reader) readJSON(name string, target interface{}) error {
+	hdr, err := br.tr.Next()
+	if err != nil || hdr.Name != name {
+		return ErrCorrupted
+	}
+	if err := json.NewDecoder(br.tr).Decode(target); err != nil {
+		return ErrCorrupted
+	}
+	return nil
+}
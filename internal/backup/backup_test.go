@@ -0,0 +1,123 @@
+// Package backup содержит тесты для формата резервных копий.
+package backup
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/google/uuid"
+)
+
+// writeTestBackup записывает резервную копию с одной записью и файлом content.
+func writeTestBackup(t *testing.T, password string, content []byte) ([]byte, *Snapshot, uuid.UUID) {
+	t.Helper()
+	fileID := uuid.New()
+	snapshot := &Snapshot{
+		Folders: []Folder{{ID: uuid.New(), Name: "Work"}},
+		Records: []Record{
+			{ID: uuid.New(), Content: Content{Type: models.DataTypeLoginPassword, Name: "Gmail", Login: "user", Password: "secret"}, Version: 2,
+				Revisions: []Revision{{Version: 1, Action: models.RevisionCreate, Content: Content{Type: models.DataTypeLoginPassword, Name: "Gmail", Login: "user", Password: "old"}}}},
+			{ID: fileID, Content: Content{Type: models.DataTypeFile, Name: "photo.jpg"}, Version: 1},
+		},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, password, snapshot)
+	if err != nil {
+		t.Fatalf("Ошибка создания резервной копии: %v", err)
+	}
+	if err := w.AddFile(fileID, int64(len(content)), bytes.NewReader(content)); err != nil {
+		t.Fatalf("Ошибка добавления файла: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Ошибка завершения резервной копии: %v", err)
+	}
+	return buf.Bytes(), snapshot, fileID
+}
+
+func TestBackup_RoundTrip(t *testing.T) {
+	// Файл больше сегмента, чтобы копия состояла из нескольких сегментов
+	content := make([]byte, 3*segmentSize+123)
+	rand.Read(content)
+	raw, snapshot, fileID := writeTestBackup(t, "backup-password", content)
+
+	r, err := NewReader(bytes.NewReader(raw), "backup-password")
+	if err != nil {
+		t.Fatalf("Ошибка чтения резервной копии: %v", err)
+	}
+	if r.Manifest.Records != 2 || r.Manifest.Files != 1 {
+		t.Errorf("Неверное описание копии: %+v", r.Manifest)
+	}
+	if len(r.Snapshot.Records) != 2 || r.Snapshot.Records[0].Password != "secret" ||
+		r.Snapshot.Records[0].Revisions[0].Password != "old" || r.Snapshot.Folders[0].Name != snapshot.Folders[0].Name {
+		t.Errorf("Снимок не совпадает с записанным: %+v", r.Snapshot)
+	}
+
+	id, file, err := r.NextFile()
+	if err != nil || id != fileID {
+		t.Fatalf("Ожидался файл %s, получено %s: %v", fileID, id, err)
+	}
+	if got, _ := io.ReadAll(file); !bytes.Equal(got, content) {
+		t.Error("Содержимое файла не совпадает с записанным")
+	}
+	if _, _, err := r.NextFile(); !errors.Is(err, io.EOF) {
+		t.Errorf("Ожидался конец копии, получено %v", err)
+	}
+}
+
+func TestBackup_WrongPassword(t *testing.T) {
+	raw, _, _ := writeTestBackup(t, "backup-password", []byte("file"))
+
+	if _, err := NewReader(bytes.NewReader(raw), "wrong"); !errors.Is(err, ErrPassword) {
+		t.Errorf("Ожидалась ошибка %v, получено %v", ErrPassword, err)
+	}
+}
+
+// lastSegmentOffset возвращает смещение последнего зашифрованного сегмента копии.
+func lastSegmentOffset(raw []byte) int {
+	offset := bytes.IndexByte(raw, '\n') + 1
+	last := offset
+	for offset < len(raw) {
+		last = offset
+		offset += 4 + int(binary.BigEndian.Uint32(raw[offset:]))
+	}
+	return last
+}
+
+func TestBackup_Damaged(t *testing.T) {
+	content := make([]byte, 2*segmentSize)
+	rand.Read(content)
+	raw, _, _ := writeTestBackup(t, "backup-password", content)
+
+	tampered := bytes.Clone(raw)
+	tampered[len(tampered)/2] ^= 1
+
+	tests := []struct {
+		name string
+		raw  []byte
+	}{
+		{"обрезанная копия", raw[:len(raw)-100]},
+		{"без последнего сегмента", raw[:lastSegmentOffset(raw)]},
+		{"измененный байт", tampered},
+		{"лишние данные", append(bytes.Clone(raw), 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewReader(bytes.NewReader(tt.raw), "backup-password")
+			for err == nil {
+				var file io.Reader
+				if _, file, err = r.NextFile(); err == nil {
+					_, err = io.Copy(io.Discard, file)
+				}
+			}
+			if errors.Is(err, io.EOF) {
+				t.Error("Поврежденная копия прочитана без ошибки")
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Package backup содержит формат зашифрованных резервных копий хранилища пользователя.
+package backup
+
+import (
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/google/uuid"
+)
+
+// Snapshot представляет содержимое личного хранилища пользователя: папки и записи
+// с расшифрованными секретами и историей изменений. Содержимое файлов в снимок
+// не входит и хранится в архиве отдельно.
+type Snapshot struct {
+	Folders []Folder `json:"folders"`
+	Records []Record `json:"records"`
+}
+
+// Folder представляет папку в снимке.
+type Folder struct {
+	ID       uuid.UUID  `json:"id"`
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
+	Name     string     `json:"name"`
+}
+
+// Content представляет содержимое записи или ревизии. Секреты записей со сквозным
+// шифрованием хранятся в EncryptedPayload в том виде, в каком их зашифровал клиент.
+type Content struct {
+	Type             models.DataType  `json:"type"`
+	Name             string           `json:"name"`
+	Metadata         string           `json:"metadata,omitempty"`
+	Login            string           `json:"login,omitempty"`
+	Password         string           `json:"password,omitempty"`
+	Text             string           `json:"text,omitempty"`
+	Card             *models.BankCard `json:"card,omitempty"`
+	Binary           []byte           `json:"binary,omitempty"`
+	EncryptedPayload string           `json:"encrypted_payload,omitempty"`
+}
+
+// Record представляет запись в снимке вместе с ее историей изменений
+// от старых ревизий к новым.
+type Record struct {
+	ID uuid.UUID `json:"id"`
+	Content
+	FolderID  *uuid.UUID `json:"folder_id,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	Favorite  bool       `json:"favorite,omitempty"`
+	Version   int64      `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Revisions []Revision `json:"revisions,omitempty"`
+}
+
+// Revision представляет ревизию записи в снимке.
+type Revision struct {
+	Version  int64                 `json:"version"`
+	Action   models.RevisionAction `json:"action"`
+	ClientID string                `json:"client_id,omitempty"`
+	Content
+	CreatedAt time.Time `json:"created_at"`
+}
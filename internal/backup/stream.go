@@ -0,0 +1,237 @@
+// Package backup содержит формат зашифрованных резервных копий хранилища пользователя.
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+)
+
+// Идентификация формата резервной копии.
+const (
+	Format  = "gophkeeper-backup"
+	Version = 1
+)
+
+// segmentSize задает размер открытого текста в одном зашифрованном сегменте.
+const segmentSize = 64 << 10
+
+// maxHeaderSize ограничивает длину строки заголовка при чтении.
+const maxHeaderSize = 4 << 10
+
+// Ошибки чтения резервной копии.
+var (
+	ErrPassword  = errors.New("неверный пароль резервной копии")
+	ErrCorrupted = errors.New("резервная копия повреждена или обрезана")
+)
+
+// header описывает первую строку файла резервной копии. Содержимое шифруется ключом,
+// полученным из пароля и соли Salt с помощью Argon2id.
+type header struct {
+	Format      string `json:"format"`
+	Version     int    `json:"version"`
+	KDF         string `json:"kdf"`
+	Salt        string `json:"salt"`
+	SegmentSize int    `json:"segment_size"`
+}
+
+// sealWriter шифрует поток сегментами. Каждый сегмент записывается как длина
+// в 4 байта big-endian и шифротекст SealChunk.
+type sealWriter struct {
+	w      io.Writer
+	key    []byte
+	header []byte
+	buf    []byte
+	index  int
+}
+
+// newSealWriter записывает заголовок и возвращает поток, шифрующий содержимое паролем password.
+// Сегменты дописываются по мере заполнения, последний - при закрытии потока.
+func newSealWriter(w io.Writer, password string) (io.WriteCloser, error) {
+	if password == "" {
+		return nil, errors.New("пароль резервной копии не задан")
+	}
+
+	salt, err := crypto.NewSalt()
+	if err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(header{
+		Format:      Format,
+		Version:     Version,
+		KDF:         "argon2id",
+		Salt:        base64.StdEncoding.EncodeToString(salt),
+		SegmentSize: segmentSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(line, '\n')); err != nil {
+		return nil, err
+	}
+
+	return &sealWriter{
+		w:      w,
+		key:    crypto.DeriveVaultKey(password, salt),
+		header: line,
+		buf:    make([]byte, 0, segmentSize),
+	}, nil
+}
+
+// Write добавляет данные в поток.
+func (sw *sealWriter) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		// Полный сегмент записывается только когда есть следующие данные,
+		// потому что последний сегмент шифруется с отдельной пометкой
+		if len(sw.buf) == segmentSize {
+			if err := sw.flush(false); err != nil {
+				return 0, err
+			}
+		}
+		n := copy(sw.buf[len(sw.buf):segmentSize], p)
+		sw.buf = sw.buf[:len(sw.buf)+n]
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// Close записывает последний сегмент. Без него резервная копия не читается.
+func (sw *sealWriter) Close() error {
+	return sw.flush(true)
+}
+
+// flush шифрует и записывает накопленный сегмент.
+func (sw *sealWriter) flush(last bool) error {
+	sealed, err := crypto.SealChunk(sw.buf, sw.key, crypto.BackupSegmentAAD(sw.header, sw.index, last))
+	if err != nil {
+		return err
+	}
+
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(sealed)))
+	if _, err := sw.w.Write(size[:]); err != nil {
+		return err
+	}
+	if _, err := sw.w.Write(sealed); err != nil {
+		return err
+	}
+
+	sw.index++
+	sw.buf = sw.buf[:0]
+	return nil
+}
+
+// openReader расшифровывает поток, записанный sealWriter.
+type openReader struct {
+	r      *bufio.Reader
+	key    []byte
+	header []byte
+	buf    []byte
+	index  int
+	done   bool
+}
+
+// newOpenReader читает заголовок и возвращает поток расшифрованного содержимого.
+// Неверный пароль обнаруживается сразу, по первому сегменту.
+func newOpenReader(r io.Reader, password string) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	line, err := readHeaderLine(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var h header
+	if err := json.Unmarshal(line, &h); err != nil || h.Format != Format {
+		return nil, errors.New("файл не является резервной копией GophKeeper")
+	}
+	if h.Version != Version || h.KDF != "argon2id" || h.SegmentSize != segmentSize {
+		return nil, fmt.Errorf("неподдерживаемая версия резервной копии %d", h.Version)
+	}
+	salt, err := base64.StdEncoding.DecodeString(h.Salt)
+	if err != nil {
+		return nil, ErrCorrupted
+	}
+
+	or := &openReader{r: br, key: crypto.DeriveVaultKey(password, salt), header: line}
+	if err := or.next(); err != nil {
+		if errors.Is(err, ErrCorrupted) && or.index == 0 {
+			return nil, ErrPassword
+		}
+		return nil, err
+	}
+	return or, nil
+}
+
+// readHeaderLine читает строку заголовка без перевода строки.
+func readHeaderLine(r *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		part, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return nil, errors.New("файл не является резервной копией GophKeeper")
+		}
+		line = append(line, part...)
+		if len(line) > maxHeaderSize {
+			return nil, errors.New("файл не является резервной копией GophKeeper")
+		}
+		if !isPrefix {
+			return bytes.Clone(line), nil
+		}
+	}
+}
+
+// Read возвращает расшифрованное содержимое.
+func (or *openReader) Read(p []byte) (int, error) {
+	for len(or.buf) == 0 {
+		if or.done {
+			return 0, io.EOF
+		}
+		if err := or.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, or.buf)
+	or.buf = or.buf[n:]
+	return n, nil
+}
+
+// next читает и расшифровывает следующий сегмент. Сегмент, помеченный последним,
+// завершает поток; конец файла до него означает, что копия обрезана.
+func (or *openReader) next() error {
+	var size [4]byte
+	if _, err := io.ReadFull(or.r, size[:]); err != nil {
+		return ErrCorrupted
+	}
+	length := binary.BigEndian.Uint32(size[:])
+	if length > segmentSize+crypto.ChunkOverhead {
+		return ErrCorrupted
+	}
+	sealed := make([]byte, length)
+	if _, err := io.ReadFull(or.r, sealed); err != nil {
+		return ErrCorrupted
+	}
+
+	for _, last := range []bool{false, true} {
+		plain, err := crypto.OpenChunk(sealed, or.key, crypto.BackupSegmentAAD(or.header, or.index, last))
+		if err != nil {
+			continue
+		}
+		if last {
+			if _, err := or.r.ReadByte(); !errors.Is(err, io.EOF) {
+				return ErrCorrupted
+			}
+		}
+		or.buf, or.done = plain, last
+		or.index++
+		return nil
+	}
+	return ErrCorrupted
+}
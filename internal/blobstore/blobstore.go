@@ -0,0 +1,38 @@
+// Package blobstore содержит хранилища зашифрованных фрагментов файлов.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrNotFound возвращается, если объект с указанным ключом не найден.
+var ErrNotFound = errors.New("объект не найден")
+
+// BlobStore определяет интерфейс хранилища двоичных объектов.
+// Ключи имеют вид "<префикс>/<имя>", например "<id файла>/<номер фрагмента>".
+type BlobStore interface {
+	// Put сохраняет объект, заменяя существующий с тем же ключом.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get открывает объект для чтения. Вызывающий должен закрыть его.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete удаляет объект. Удаление отсутствующего объекта не является ошибкой.
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix удаляет все объекты с ключами вида "<prefix>/...".
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// validateKey проверяет, что ключ не выходит за пределы хранилища.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return errors.New("недопустимый ключ объекта")
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return errors.New("недопустимый ключ объекта")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Package blobstore содержит хранилища зашифрованных фрагментов файлов.
+package blobstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FilesystemStore хранит объекты в файлах внутри корневой директории.
+type FilesystemStore struct {
+	root string
+}
+
+// NewFilesystem создает хранилище в директории root, при необходимости создавая ее.
+func NewFilesystem(root string) (*FilesystemStore, error) {
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, fmt.Errorf("не удалось создать директорию хранилища: %w", err)
+	}
+	return &FilesystemStore{root: root}, nil
+}
+
+// Put сохраняет объект. Данные сначала записываются во временный файл,
+// поэтому прерванная запись не оставляет поврежденный объект.
+func (fs *FilesystemStore) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := fs.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get открывает объект для чтения.
+func (fs *FilesystemStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := fs.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete удаляет объект.
+func (fs *FilesystemStore) Delete(ctx context.Context, key string) error {
+	path, err := fs.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// DeletePrefix удаляет директорию с объектами префикса.
+func (fs *FilesystemStore) DeletePrefix(ctx context.Context, prefix string) error {
+	path, err := fs.path(prefix)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+// path возвращает путь к файлу объекта.
+func (fs *FilesystemStore) path(key string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(fs.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,55 @@
+// Package blobstore содержит тесты для файлового хранилища.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFilesystemStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFilesystem(t.TempDir())
+	if err != nil {
+		t.Fatalf("Ошибка создания хранилища: %v", err)
+	}
+
+	if err := store.Put(ctx, "file/0", strings.NewReader("first")); err != nil {
+		t.Fatalf("Ошибка сохранения объекта: %v", err)
+	}
+	if err := store.Put(ctx, "file/0", strings.NewReader("replaced")); err != nil {
+		t.Fatalf("Ошибка замены объекта: %v", err)
+	}
+	store.Put(ctx, "file/1", strings.NewReader("second"))
+
+	r, err := store.Get(ctx, "file/0")
+	if err != nil {
+		t.Fatalf("Ошибка чтения объекта: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+
+	if string(data) != "replaced" {
+		t.Errorf("Ожидалось %q, получено %q", "replaced", data)
+	}
+
+	if err := store.DeletePrefix(ctx, "file"); err != nil {
+		t.Fatalf("Ошибка удаления объектов: %v", err)
+	}
+
+	if _, err := store.Get(ctx, "file/1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Ожидалась ошибка ErrNotFound, получено %v", err)
+	}
+}
+
+func TestFilesystemStore_InvalidKey(t *testing.T) {
+	store, _ := NewFilesystem(t.TempDir())
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "file/../../outside"} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x")); err == nil {
+			t.Errorf("Ожидалась ошибка для ключа %q", key)
+		}
+	}
+}
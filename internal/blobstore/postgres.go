@@ -0,0 +1,141 @@
+// Package blobstore содержит хранилища зашифрованных фрагментов файлов.
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// largeObject связывает ключ объекта с large object PostgreSQL.
+type largeObject struct {
+	Key       string    `gorm:"primary_key"`
+	OID       uint32    `gorm:"column:oid;not null"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// TableName возвращает имя таблицы для модели largeObject.
+func (largeObject) TableName() string {
+	return "blob_objects"
+}
+
+// PostgresStore хранит объекты как large objects PostgreSQL.
+// Объект целиком читается в память, поэтому хранилище рассчитано
+// на объекты ограниченного размера, такие как фрагменты файлов.
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+// NewPostgres создает хранилище в базе данных db и выполняет миграцию таблицы объектов.
+func NewPostgres(db *gorm.DB) (*PostgresStore, error) {
+	if err := db.AutoMigrate(&largeObject{}); err != nil {
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Put сохраняет объект, удаляя large object, который ранее хранился под тем же ключом.
+func (ps *PostgresStore) Put(ctx context.Context, key string, r io.Reader) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	return ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var previous largeObject
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&previous).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var oid uint32
+		if err := tx.Raw("SELECT lo_from_bytea(0, ?)", data).Row().Scan(&oid); err != nil {
+			return err
+		}
+
+		if previous.OID != 0 {
+			if err := tx.Exec("SELECT lo_unlink(?)", previous.OID).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"oid", "created_at"}),
+		}).Create(&largeObject{Key: key, OID: oid, CreatedAt: time.Now()}).Error
+	})
+}
+
+// Get читает объект.
+func (ps *PostgresStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	err := ps.db.WithContext(ctx).Raw("SELECT lo_get(oid) FROM blob_objects WHERE key = ?", key).Row().Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Delete удаляет объект.
+func (ps *PostgresStore) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	return ps.deleteWhere(ctx, "key = ?", key)
+}
+
+// DeletePrefix удаляет все объекты префикса.
+func (ps *PostgresStore) DeletePrefix(ctx context.Context, prefix string) error {
+	if err := validateKey(prefix); err != nil {
+		return err
+	}
+	return ps.deleteWhere(ctx, "key LIKE ?", escapeLike(prefix)+"/%")
+}
+
+// deleteWhere удаляет large objects и записи о них по условию.
+func (ps *PostgresStore) deleteWhere(ctx context.Context, query string, args ...interface{}) error {
+	return ps.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var objects []largeObject
+		if err := tx.Where(query, args...).Find(&objects).Error; err != nil {
+			return err
+		}
+
+		for _, object := range objects {
+			if err := tx.Exec("SELECT lo_unlink(?)", object.OID).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Where(query, args...).Delete(&largeObject{}).Error
+	})
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE.
+func escapeLike(s string) string {
+	var b bytes.Buffer
+	for _, r := range s {
+		if r == '%' || r == '_' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// auditEvent представляет событие журнала аудита.
+type auditEvent struct {
+	ID        string    `json:"id"`
+	Action    string    `json:"action"`
+	DataID    string    `json:"data_id"`
+	Details   string    `json:"details"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// auditPage представляет страницу журнала аудита.
+type auditPage struct {
+	Events []auditEvent `json:"events"`
+	Total  int64        `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
+
+// createAuditCommand создает команду просмотра журнала аудита.
+func (c *Client) createAuditCommand() *cobra.Command {
+	var limit, offset int
+
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Журнал входов и действий с записями",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			c.showAudit(limit, offset)
+		},
+	}
+	auditCmd.Flags().IntVar(&limit, "limit", 50, "Число событий на странице")
+	auditCmd.Flags().IntVar(&offset, "offset", 0, "Сколько последних событий пропустить")
+
+	return auditCmd
+}
+
+// showAudit выводит страницу журнала аудита, начиная с самых новых событий.
+func (c *Client) showAudit(limit, offset int) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	page, err := c.fetchAudit(limit, offset)
+	if err != nil {
+		fmt.Printf("Ошибка получения журнала аудита: %v\n", err)
+		return
+	}
+
+	if len(page.Events) == 0 {
+		fmt.Println("Событий нет")
+		return
+	}
+
+	fmt.Printf("События %d-%d из %d:\n", page.Offset+1, page.Offset+len(page.Events), page.Total)
+	for _, event := range page.Events {
+		line := fmt.Sprintf("- %s %s", event.CreatedAt.Local().Format("2006-01-02 15:04:05"), event.Action)
+		if event.DataID != "" {
+			line += ", запись: " + event.DataID
+		}
+		if event.Details != "" {
+			line += ", " + event.Details
+		}
+		fmt.Printf("%s, IP: %s, клиент: %s\n", line, event.IP, event.UserAgent)
+	}
+}
+
+// fetchAudit загружает страницу журнала аудита.
+func (c *Client) fetchAudit(limit, offset int) (*auditPage, error) {
+	resp, err := c.makeRequest("GET", fmt.Sprintf("/api/v1/audit?limit=%d&offset=%d", limit, offset), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s", string(body))
+	}
+
+	var page auditPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга ответа: %w", err)
+	}
+	return &page, nil
+}
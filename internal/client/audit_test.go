@@ -0,0 +1,32 @@
+// Package client содержит тесты для журнала аудита.
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_fetchAudit(t *testing.T) {
+	var gotQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Path + "?" + r.URL.RawQuery
+		w.Write([]byte(`{"events":[{"action":"read","data_id":"data-id","ip":"10.0.0.1"}],"total":21,"limit":10,"offset":20}`))
+	}))
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+
+	page, err := client.fetchAudit(10, 20)
+	if err != nil {
+		t.Fatalf("Ошибка получения журнала: %v", err)
+	}
+	if gotQuery != "/api/v1/audit?limit=10&offset=20" {
+		t.Errorf("Неожиданный запрос %s", gotQuery)
+	}
+	if page.Total != 21 || len(page.Events) != 1 || page.Events[0].Action != "read" {
+		t.Errorf("Неожиданная страница %+v", page)
+	}
+}
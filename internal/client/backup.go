@@ -0,0 +1,445 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/backup"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// backupPasswordEnv задает переменную окружения с паролем резервной копии.
+const backupPasswordEnv = "GOPHKEEPER_BACKUP_PASSWORD"
+
+// restoreUpload описывает файл, который нужно загрузить после восстановления копии.
+type restoreUpload struct {
+	ID       uuid.UUID `json:"id"`
+	FolderID string    `json:"folder_id"`
+}
+
+// restoreResponse представляет ответ сервера на запрос восстановления копии.
+type restoreResponse struct {
+	Created int             `json:"created"`
+	Updated int             `json:"updated"`
+	Skipped int             `json:"skipped"`
+	Trashed int             `json:"trashed"`
+	Uploads []restoreUpload `json:"uploads"`
+}
+
+// createExportCommand создает команду резервного копирования хранилища.
+func (c *Client) createExportCommand() *cobra.Command {
+	exportCmd := &cobra.Command{
+		Use:   "export [file]",
+		Short: "Сохранить зашифрованную резервную копию хранилища",
+		Long: "Сохраняет личные записи, содержимое файлов, папки, метки и историю изменений в файл, " +
+			"зашифрованный паролем резервной копии. Восстановление: import --from-backup [file]",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			password, _ := cmd.Flags().GetString("password")
+			c.exportBackup(args[0], password)
+		},
+	}
+	exportCmd.Flags().String("password", "", "Пароль резервной копии (или "+backupPasswordEnv+")")
+	return exportCmd
+}
+
+// exportBackup сохраняет резервную копию хранилища в файл path. Копия пишется
+// во временный файл и заменяет path только после успешного завершения.
+func (c *Client) exportBackup(path, password string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	password, err := c.backupPassword(password, true)
+	if err != nil {
+		fmt.Printf("Ошибка резервного копирования: %v\n", err)
+		return
+	}
+
+	snapshot, files, err := c.fetchBackup()
+	if err != nil {
+		fmt.Printf("Ошибка резервного копирования: %v\n", err)
+		return
+	}
+
+	partPath := path + ".part"
+	f, err := os.OpenFile(partPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		fmt.Printf("Ошибка создания файла: %v\n", err)
+		return
+	}
+	err = c.writeBackup(f, password, snapshot, files)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(partPath, path)
+	}
+	if err != nil {
+		os.Remove(partPath)
+		fmt.Printf("Ошибка резервного копирования: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Резервная копия сохранена в %s: записей %d, файлов %d\n", path, len(snapshot.Records), len(files))
+}
+
+// backupPassword возвращает пароль резервной копии из флага, переменной окружения
+// или запрашивает его. Новый пароль запрашивается дважды.
+func (c *Client) backupPassword(password string, confirm bool) (string, error) {
+	if password == "" {
+		password = os.Getenv(backupPasswordEnv)
+	}
+	if password == "" {
+		password = c.prompt("Пароль резервной копии: ")
+		if confirm && password != "" && c.prompt("Повторите пароль: ") != password {
+			return "", errors.New("пароли не совпадают")
+		}
+	}
+	if password == "" {
+		return "", errors.New("пароль резервной копии не задан")
+	}
+	return password, nil
+}
+
+// fetchBackup получает снимок хранилища и состояние его файлов. Записи со сквозным
+// шифрованием расшифровываются, чтобы копию можно было восстановить в любую учетную
+// запись. Файлы с незавершенной загрузкой в копию не входят.
+func (c *Client) fetchBackup() (*backup.Snapshot, map[uuid.UUID]*fileStatus, error) {
+	resp, err := c.makeRequest("GET", "/api/v1/backup", nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", errOffline, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, nil, errors.New(string(body))
+	}
+
+	var snapshot backup.Snapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		return nil, nil, fmt.Errorf("ошибка парсинга ответа: %w", err)
+	}
+
+	files := make(map[uuid.UUID]*fileStatus)
+	records := snapshot.Records[:0]
+	for _, record := range snapshot.Records {
+		if record.Type == models.DataTypeFile {
+			status, err := c.fileStatus(record.ID.String())
+			if err != nil {
+				return nil, nil, fmt.Errorf("файл %s: %w", record.Name, err)
+			}
+			if !status.Complete {
+				fmt.Printf("Предупреждение: загрузка файла %s не завершена, файл пропущен\n", record.Name)
+				continue
+			}
+			if status.ClientEncrypted && c.vaultKey == nil {
+				return nil, nil, fmt.Errorf("файл %s зашифрован на клиенте, войдите с мастер-паролем", record.Name)
+			}
+			files[record.ID] = status
+		}
+
+		if err := c.openBackupContent(record.ID, &record.Content); err != nil {
+			return nil, nil, fmt.Errorf("запись %s: %w", record.Name, err)
+		}
+		for i := range record.Revisions {
+			if err := c.openBackupContent(record.ID, &record.Revisions[i].Content); err != nil {
+				return nil, nil, fmt.Errorf("запись %s: %w", record.Name, err)
+			}
+		}
+		records = append(records, record)
+	}
+	snapshot.Records = records
+	return &snapshot, files, nil
+}
+
+// writeBackup записывает в w резервную копию снимка вместе с содержимым файлов.
+// Файлы скачиваются фрагментами и целиком в памяти не хранятся.
+func (c *Client) writeBackup(w io.Writer, password string, snapshot *backup.Snapshot, files map[uuid.UUID]*fileStatus) error {
+	bw, err := backup.NewWriter(w, password, snapshot)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range snapshot.Records {
+		status, ok := files[record.ID]
+		if !ok {
+			continue
+		}
+		size := status.Size
+		if status.ClientEncrypted {
+			size -= int64(status.ChunkCount) * crypto.ChunkOverhead
+		}
+		if err := bw.AddFile(record.ID, size, &chunkReader{c: c, status: status}); err != nil {
+			return fmt.Errorf("файл %s: %w", record.Name, err)
+		}
+	}
+	return bw.Close()
+}
+
+// chunkReader читает содержимое файла, скачивая его по одному фрагменту.
+type chunkReader struct {
+	c      *Client
+	status *fileStatus
+	index  int
+	buf    []byte
+}
+
+// Read возвращает следующую часть содержимого файла.
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.index >= r.status.ChunkCount {
+			return 0, io.EOF
+		}
+		chunk, err := r.c.downloadChunk(r.status, r.index)
+		if err != nil {
+			return 0, err
+		}
+		r.buf = chunk
+		r.index++
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// openBackupContent расшифровывает содержимое записи id, зашифрованное ключом хранилища.
+func (c *Client) openBackupContent(id uuid.UUID, content *backup.Content) error {
+	if content.EncryptedPayload == "" {
+		return nil
+	}
+	if c.vaultKey == nil {
+		return errors.New("запись зашифрована на клиенте, войдите с мастер-паролем")
+	}
+
+	plaintext, err := openRecordBlob(content.EncryptedPayload, c.vaultKey, id.String(), string(content.Type))
+	if err != nil {
+		return errors.New("не удалось расшифровать запись, проверьте мастер-пароль")
+	}
+	content.EncryptedPayload = ""
+	return json.Unmarshal(plaintext, content)
+}
+
+// sealBackupContent шифрует секретные поля содержимого записи id ключом хранилища так же,
+// как при создании записи. Без ключа хранилища содержимое шифрует сервер.
+func (c *Client) sealBackupContent(id uuid.UUID, content *backup.Content) error {
+	if c.vaultKey == nil || content.Type == models.DataTypeFile {
+		return nil
+	}
+
+	req := make(map[string]interface{})
+	if content.Login != "" {
+		req["login"] = content.Login
+	}
+	if content.Password != "" {
+		req["password"] = content.Password
+	}
+	if content.Text != "" {
+		req["text"] = content.Text
+	}
+	if content.Card != nil {
+		req["card"] = content.Card
+	}
+	if content.Binary != nil {
+		req["binary"] = content.Binary
+	}
+	if err := c.sealSecrets(req, id.String(), string(content.Type)); err != nil {
+		return err
+	}
+
+	content.Login, content.Password, content.Text, content.Card, content.Binary = "", "", "", nil, nil
+	content.EncryptedPayload, _ = req["encrypted_payload"].(string)
+	return nil
+}
+
+// restoreBackup восстанавливает хранилище из резервной копии path в режиме mode
+// (merge или replace). Копия проверяется целиком до того, как что-либо изменить.
+func (c *Client) restoreBackup(path, mode, password string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+	if mode != "merge" && mode != "replace" {
+		fmt.Println("Режим восстановления должен быть merge или replace")
+		return
+	}
+
+	password, err := c.backupPassword(password, false)
+	if err != nil {
+		fmt.Printf("Ошибка восстановления: %v\n", err)
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("Ошибка открытия файла: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	if err := verifyBackup(f, password); err != nil {
+		fmt.Printf("Ошибка чтения резервной копии: %v\n", err)
+		return
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		fmt.Printf("Ошибка чтения резервной копии: %v\n", err)
+		return
+	}
+	r, err := backup.NewReader(f, password)
+	if err != nil {
+		fmt.Printf("Ошибка чтения резервной копии: %v\n", err)
+		return
+	}
+
+	records := make(map[uuid.UUID]*backup.Record, len(r.Snapshot.Records))
+	for i := range r.Snapshot.Records {
+		record := &r.Snapshot.Records[i]
+		records[record.ID] = record
+	}
+
+	// Запрос отправляется с копией записей, чтобы зашифровать их ключом хранилища
+	snapshot := r.Snapshot
+	snapshot.Records = make([]backup.Record, len(r.Snapshot.Records))
+	for i, record := range r.Snapshot.Records {
+		record.Revisions = append([]backup.Revision(nil), record.Revisions...)
+		if err := c.sealBackupContent(record.ID, &record.Content); err != nil {
+			fmt.Printf("Ошибка шифрования данных: %v\n", err)
+			return
+		}
+		for j := range record.Revisions {
+			if err := c.sealBackupContent(record.ID, &record.Revisions[j].Content); err != nil {
+				fmt.Printf("Ошибка шифрования данных: %v\n", err)
+				return
+			}
+		}
+		snapshot.Records[i] = record
+	}
+
+	resp, err := c.uploadRestore(mode, &snapshot)
+	if err != nil {
+		fmt.Printf("Ошибка восстановления: %v\n", err)
+		return
+	}
+
+	uploads := make(map[uuid.UUID]restoreUpload, len(resp.Uploads))
+	for _, upload := range resp.Uploads {
+		uploads[upload.ID] = upload
+	}
+	restored := 0
+	for {
+		id, content, err := r.NextFile()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			fmt.Printf("Ошибка чтения резервной копии: %v\n", err)
+			return
+		}
+		upload, ok := uploads[id]
+		if !ok || records[id] == nil {
+			continue
+		}
+		if err := c.restoreFile(records[id], upload.FolderID, content); err != nil {
+			fmt.Printf("Ошибка восстановления файла %s: %v\n", records[id].Name, err)
+			continue
+		}
+		restored++
+	}
+
+	fmt.Printf("Восстановлено записей: %d, перезаписано: %d, пропущено: %d, перемещено в корзину: %d, загружено файлов: %d\n",
+		resp.Created, resp.Updated, resp.Skipped, resp.Trashed, restored)
+}
+
+// verifyBackup читает резервную копию до конца, проверяя пароль и целостность.
+func verifyBackup(f io.Reader, password string) error {
+	r, err := backup.NewReader(f, password)
+	if err != nil {
+		return err
+	}
+	for {
+		_, content, err := r.NextFile()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(io.Discard, content); err != nil {
+			return err
+		}
+	}
+}
+
+// uploadRestore отправляет снимок хранилища на восстановление.
+func (c *Client) uploadRestore(mode string, snapshot *backup.Snapshot) (*restoreResponse, error) {
+	resp, err := c.makeRequest("POST", "/api/v1/backup/restore", map[string]interface{}{"mode": mode, "snapshot": snapshot})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errOffline, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, errors.New(string(body))
+	}
+
+	var result restoreResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга ответа: %w", err)
+	}
+	return &result, nil
+}
+
+// restoreFile загружает содержимое файла из копии как новый файл и переносит
+// в него папку, метки и избранное записи record.
+func (c *Client) restoreFile(record *backup.Record, folderID string, content io.Reader) error {
+	tmp, err := os.CreateTemp("", "gophkeeper-restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, content)
+	if err != nil {
+		return err
+	}
+
+	status, err := c.createFile(record.Name, record.Name, record.Metadata, size, defaultChunkSize)
+	if err != nil {
+		return err
+	}
+	if err := c.uploadChunks(tmp, status); err != nil {
+		return err
+	}
+	if err := c.completeFile(status.ID); err != nil {
+		return err
+	}
+
+	if folderID != "" {
+		if _, err := c.organize(status.ID, "folder", map[string]interface{}{"folder_id": folderID}); err != nil {
+			return err
+		}
+	}
+	if len(record.Tags) > 0 {
+		if _, err := c.organize(status.ID, "tags", map[string]interface{}{"tags": record.Tags}); err != nil {
+			return err
+		}
+	}
+	if record.Favorite {
+		if _, err := c.organize(status.ID, "favorite", map[string]interface{}{"favorite": true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
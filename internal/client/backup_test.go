@@ -0,0 +1,137 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/backup"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/google/uuid"
+)
+
+// fakeBackupServer отдает снимок с записью со сквозным шифрованием и одним файлом
+// и фиксирует запросы восстановления.
+type fakeBackupServer struct {
+	t        *testing.T
+	snapshot backup.Snapshot
+	fileID   uuid.UUID
+	content  []byte
+	restored []backup.Snapshot
+	uploaded []byte
+	folder   string
+}
+
+func (f *fakeBackupServer) serve(w http.ResponseWriter, r *http.Request) {
+	const chunkSize = 4
+	filePath := "/api/v1/files/" + f.fileID.String()
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/api/v1/backup":
+		json.NewEncoder(w).Encode(f.snapshot)
+	case r.Method == "GET" && r.URL.Path == filePath:
+		json.NewEncoder(w).Encode(fileStatus{ID: f.fileID.String(), Size: int64(len(f.content)), ChunkSize: chunkSize,
+			ChunkCount: (len(f.content) + chunkSize - 1) / chunkSize, Complete: true})
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, filePath+"/chunks/"):
+		index, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, filePath+"/chunks/"))
+		w.Write(f.content[index*chunkSize : min((index+1)*chunkSize, len(f.content))])
+	case r.Method == "POST" && r.URL.Path == "/api/v1/backup/restore":
+		var req struct {
+			Mode     string          `json:"mode"`
+			Snapshot backup.Snapshot `json:"snapshot"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		f.restored = append(f.restored, req.Snapshot)
+		json.NewEncoder(w).Encode(restoreResponse{Created: 1, Uploads: []restoreUpload{{ID: f.fileID, FolderID: "folder-1"}}})
+	case r.Method == "POST" && r.URL.Path == "/api/v1/files":
+		var req fileStatus
+		json.NewDecoder(r.Body).Decode(&req)
+		req.ID = "new-file"
+		req.ChunkCount = int((req.Size + req.ChunkSize - 1) / req.ChunkSize)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(req)
+	case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/api/v1/files/new-file/chunks/"):
+		chunk, _ := io.ReadAll(r.Body)
+		f.uploaded = append(f.uploaded, chunk...)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "POST" && r.URL.Path == "/api/v1/files/new-file/complete":
+		w.Write([]byte(`{}`))
+	case r.Method == "PUT" && r.URL.Path == "/api/v1/data/new-file/folder":
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		f.folder = req["folder_id"]
+		w.Write([]byte(`{"id":"new-file"}`))
+	default:
+		f.t.Errorf("Неожиданный запрос: %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestClient_exportRestoreBackup(t *testing.T) {
+	vaultKey := bytes.Repeat([]byte{7}, 32)
+	recordID := uuid.New()
+	secrets, _ := crypto.SealRecordBlob([]byte(`{"login":"user","password":"secret"}`), vaultKey, recordID.String(), "login_password")
+	fake := &fakeBackupServer{t: t, fileID: uuid.New(), content: []byte("file content")}
+	fake.snapshot = backup.Snapshot{Records: []backup.Record{
+		{ID: recordID, Content: backup.Content{Type: models.DataTypeLoginPassword, Name: "Gmail", EncryptedPayload: secrets}},
+		{ID: fake.fileID, Content: backup.Content{Type: models.DataTypeFile, Name: "notes.txt"}},
+	}}
+	server := httptest.NewServer(http.HandlerFunc(fake.serve))
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+	client.vaultKey = vaultKey
+
+	path := filepath.Join(t.TempDir(), "vault.gkb")
+	client.exportBackup(path, "backup-password")
+
+	// Копия не зависит от ключа хранилища: секреты хранятся расшифрованными
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Резервная копия не сохранена: %v", err)
+	}
+	defer f.Close()
+	r, err := backup.NewReader(f, "backup-password")
+	if err != nil {
+		t.Fatalf("Ошибка чтения резервной копии: %v", err)
+	}
+	if record := r.Snapshot.Records[0]; record.Login != "user" || record.Password != "secret" || record.EncryptedPayload != "" {
+		t.Errorf("Ожидалась расшифрованная запись, получено %+v", record.Content)
+	}
+	if id, file, err := r.NextFile(); err != nil || id != fake.fileID {
+		t.Fatalf("Ожидался файл %s: %v", fake.fileID, err)
+	} else if got, _ := io.ReadAll(file); !bytes.Equal(got, fake.content) {
+		t.Errorf("Содержимое файла в копии: %q", got)
+	}
+
+	client.restoreBackup(path, "merge", "wrong-password")
+	if len(fake.restored) != 0 {
+		t.Fatal("Копия с неверным паролем не должна восстанавливаться")
+	}
+
+	client.restoreBackup(path, "merge", "backup-password")
+	if len(fake.restored) != 1 {
+		t.Fatalf("Ожидался 1 запрос восстановления, получено %d", len(fake.restored))
+	}
+	record := fake.restored[0].Records[0]
+	if record.Login != "" || record.EncryptedPayload == "" {
+		t.Errorf("Секреты должны шифроваться ключом хранилища перед отправкой, получено %+v", record.Content)
+	}
+	if _, err := crypto.OpenRecordBlob(record.EncryptedPayload, vaultKey, recordID.String(), "login_password"); err != nil || crypto.IsLegacyRecordBlob(record.EncryptedPayload) {
+		t.Errorf("Секреты должны быть привязаны к записи: %v", err)
+	}
+	// Файл помещается в один фрагмент и шифруется ключом хранилища, как при загрузке
+	uploaded, err := crypto.OpenChunk(fake.uploaded, vaultKey, crypto.ChunkAAD("new-file", 0))
+	if err != nil || !bytes.Equal(uploaded, fake.content) || fake.folder != "folder-1" {
+		t.Errorf("Файл восстановлен неверно: %q в папку %q", uploaded, fake.folder)
+	}
+}
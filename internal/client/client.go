@@ -2,25 +2,40 @@
 package client
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// dataTypes содержит поддерживаемые типы данных.
+var dataTypes = []string{"login_password", "text", "card", "binary"}
+
 // Client представляет CLI клиент.
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
 	token      string
-	configPath string
+	// refreshToken обменивается на новый токен доступа, когда прежний истекает.
+	refreshToken string
+	configPath   string
+	vaultKey     []byte
+	clientID     string
+	in           *bufio.Reader
 }
 
 // New создает новый экземпляр клиента.
@@ -31,12 +46,15 @@ func New() *Client {
 			Timeout: 30 * time.Second,
 		},
 		configPath: viper.GetString("config.path"),
+		in:         bufio.NewReader(os.Stdin),
 	}
 }
 
 // Execute запускает CLI клиент.
 func (c *Client) Execute() error {
 	c.loadToken()
+	c.loadVaultKey()
+	c.loadClientID()
 
 	rootCmd := &cobra.Command{
 		Use:   "gophkeeper",
@@ -50,6 +68,24 @@ func (c *Client) Execute() error {
 	// Команды для работы с данными
 	rootCmd.AddCommand(c.createDataCommands())
 
+	// Команды корзины
+	rootCmd.AddCommand(c.createTrashCommands())
+
+	// Журнал аудита
+	rootCmd.AddCommand(c.createAuditCommand())
+
+	// Импорт из других менеджеров паролей и восстановление резервной копии
+	rootCmd.AddCommand(c.createImportCommand())
+
+	// Резервное копирование
+	rootCmd.AddCommand(c.createExportCommand())
+
+	// Команда синхронизации
+	rootCmd.AddCommand(c.createSyncCommand())
+
+	// Изменения записей в реальном времени
+	rootCmd.AddCommand(c.createWatchCommand())
+
 	// Команда версии
 	rootCmd.AddCommand(c.createVersionCommand())
 
@@ -69,9 +105,11 @@ func (c *Client) createAuthCommands() *cobra.Command {
 		Short: "Регистрация нового пользователя",
 		Args:  cobra.ExactArgs(3),
 		Run: func(cmd *cobra.Command, args []string) {
-			c.register(args[0], args[1], args[2])
+			masterPassword, _ := cmd.Flags().GetString("master-password")
+			c.register(args[0], args[1], args[2], masterPassword)
 		},
 	}
+	registerCmd.Flags().String("master-password", "", "Мастер-пароль для сквозного шифрования (или "+masterPasswordEnv+")")
 
 	// Команда входа
 	loginCmd := &cobra.Command{
@@ -79,9 +117,13 @@ func (c *Client) createAuthCommands() *cobra.Command {
 		Short: "Вход в систему",
 		Args:  cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
-			c.login(args[0], args[1])
+			masterPassword, _ := cmd.Flags().GetString("master-password")
+			otp, _ := cmd.Flags().GetString("otp")
+			c.login(args[0], args[1], masterPassword, otp)
 		},
 	}
+	loginCmd.Flags().String("master-password", "", "Мастер-пароль для сквозного шифрования (или "+masterPasswordEnv+")")
+	loginCmd.Flags().String("otp", "", "Одноразовый пароль или код восстановления; без флага код запрашивается при необходимости")
 
 	// Команда выхода
 	logoutCmd := &cobra.Command{
@@ -92,7 +134,35 @@ func (c *Client) createAuthCommands() *cobra.Command {
 		},
 	}
 
-	authCmd.AddCommand(registerCmd, loginCmd, logoutCmd)
+	// Команда списка сессий
+	sessionsCmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Список активных сессий",
+		Run: func(cmd *cobra.Command, args []string) {
+			c.listSessions()
+		},
+	}
+
+	// Команда отзыва сессий
+	revokeCmd := &cobra.Command{
+		Use:   "revoke [id]",
+		Short: "Отзыв сессии по ID или всех сессий с флагом --all",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			all, _ := cmd.Flags().GetBool("all")
+			switch {
+			case all && len(args) == 0:
+				c.revokeAllSessions()
+			case !all && len(args) == 1:
+				c.revokeSession(args[0])
+			default:
+				fmt.Println("Укажите ID сессии или флаг --all")
+			}
+		},
+	}
+	revokeCmd.Flags().Bool("all", false, "Отозвать все сессии, включая текущую")
+
+	authCmd.AddCommand(registerCmd, loginCmd, logoutCmd, sessionsCmd, revokeCmd, c.createTwoFactorCommands())
 	return authCmd
 }
 
@@ -105,25 +175,106 @@ func (c *Client) createDataCommands() *cobra.Command {
 
 	// Команда списка данных
 	listCmd := &cobra.Command{
-		Use:   "list",
-		Short: "Список всех данных",
-		Args:  cobra.NoArgs,
+		Use:       "list [type]",
+		Short:     "Список всех данных или данных определенного типа",
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: dataTypes,
 		Run: func(cmd *cobra.Command, args []string) {
-			c.listData()
+			var opts listOptions
+			if len(args) == 1 {
+				opts.Type = args[0]
+			}
+			opts.Prefix, _ = cmd.Flags().GetString("prefix")
+			opts.Sort, _ = cmd.Flags().GetString("sort")
+			opts.Tags, _ = cmd.Flags().GetStringSlice("tag")
+			opts.Favorites, _ = cmd.Flags().GetBool("favorites")
+			opts.Tree, _ = cmd.Flags().GetBool("tree")
+			c.listData(opts)
 		},
 	}
+	listCmd.Flags().String("prefix", "", "Только записи, название которых начинается с этой строки")
+	listCmd.Flags().String("sort", "", "Порядок: name, updated_at или created_at, с префиксом - для обратного")
+	listCmd.Flags().StringSlice("tag", nil, "Только записи со всеми указанными метками")
+	listCmd.Flags().Bool("favorites", false, "Только избранные записи")
+	listCmd.Flags().Bool("tree", false, "Показать записи деревом папок")
 
 	// Команда добавления данных
 	addCmd := &cobra.Command{
-		Use:   "add [name] [login] [password]",
+		Use:   "add",
 		Short: "Добавить новые данные",
+	}
+	addCmd.PersistentFlags().String("metadata", "", "Метаданные в формате JSON")
+
+	addLoginCmd := &cobra.Command{
+		Use:   "login_password [name] [login] [password]",
+		Short: "Добавить пару логин/пароль",
 		Args:  cobra.ExactArgs(3),
 		Run: func(cmd *cobra.Command, args []string) {
 			metadata, _ := cmd.Flags().GetString("metadata")
-			c.addData(args[0], args[1], args[2], metadata)
+			c.addData(map[string]interface{}{
+				"type":     "login_password",
+				"name":     args[0],
+				"login":    args[1],
+				"password": args[2],
+			}, metadata)
+		},
+	}
+
+	addTextCmd := &cobra.Command{
+		Use:   "text [name] [text]",
+		Short: "Добавить текстовую заметку",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			metadata, _ := cmd.Flags().GetString("metadata")
+			c.addData(map[string]interface{}{
+				"type": "text",
+				"name": args[0],
+				"text": args[1],
+			}, metadata)
+		},
+	}
+
+	addCardCmd := &cobra.Command{
+		Use:   "card [name] [number] [expiry MM/YY] [cvv]",
+		Short: "Добавить банковскую карту",
+		Args:  cobra.ExactArgs(4),
+		Run: func(cmd *cobra.Command, args []string) {
+			metadata, _ := cmd.Flags().GetString("metadata")
+			holder, _ := cmd.Flags().GetString("holder")
+			c.addData(map[string]interface{}{
+				"type": "card",
+				"name": args[0],
+				"card": map[string]string{
+					"number": args[1],
+					"expiry": args[2],
+					"cvv":    args[3],
+					"holder": holder,
+				},
+			}, metadata)
 		},
 	}
-	addCmd.Flags().String("metadata", "", "Метаданные в формате JSON")
+	addCardCmd.Flags().String("holder", "", "Имя держателя карты")
+
+	addBinaryCmd := &cobra.Command{
+		Use:   "binary [name] [file]",
+		Short: "Добавить бинарные данные из файла",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			metadata, _ := cmd.Flags().GetString("metadata")
+			content, err := os.ReadFile(args[1])
+			if err != nil {
+				fmt.Printf("Ошибка чтения файла: %v\n", err)
+				return
+			}
+			c.addData(map[string]interface{}{
+				"type":   "binary",
+				"name":   args[0],
+				"binary": content,
+			}, metadata)
+		},
+	}
+
+	addCmd.AddCommand(addLoginCmd, addTextCmd, addCardCmd, addBinaryCmd)
 
 	// Команда получения данных
 	getCmd := &cobra.Command{
@@ -131,11 +282,106 @@ func (c *Client) createDataCommands() *cobra.Command {
 		Short: "Получить данные по ID",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			c.getData(args[0])
+			output, _ := cmd.Flags().GetString("output")
+			c.getData(args[0], output)
 		},
 	}
+	getCmd.Flags().StringP("output", "o", "", "Файл для сохранения бинарных данных")
 
-	dataCmd.AddCommand(listCmd, addCmd, getCmd)
+	// Команда обновления данных
+	updateCmd := &cobra.Command{
+		Use:   "update [id]",
+		Short: "Обновить данные по ID",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			req := make(map[string]interface{})
+			for _, field := range []string{"name", "login", "password", "text"} {
+				if value, _ := cmd.Flags().GetString(field); value != "" {
+					req[field] = value
+				}
+			}
+			metadata, _ := cmd.Flags().GetString("metadata")
+			c.updateData(args[0], req, metadata)
+		},
+	}
+	updateCmd.Flags().String("name", "", "Новое название")
+	updateCmd.Flags().String("login", "", "Новый логин")
+	updateCmd.Flags().String("password", "", "Новый пароль")
+	updateCmd.Flags().String("text", "", "Новый текст заметки")
+	updateCmd.Flags().String("metadata", "", "Метаданные в формате JSON")
+
+	// Команда удаления данных
+	deleteCmd := &cobra.Command{
+		Use:   "delete [id]",
+		Short: "Удалить данные по ID",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c.deleteData(args[0])
+		},
+	}
+
+	// Команда загрузки файла
+	uploadCmd := &cobra.Command{
+		Use:   "upload [file]",
+		Short: "Загрузить файл",
+		Long:  "Загружает файл фрагментами, не читая его в память целиком. Прерванную загрузку можно продолжить флагом --resume",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name, _ := cmd.Flags().GetString("name")
+			metadata, _ := cmd.Flags().GetString("metadata")
+			resume, _ := cmd.Flags().GetString("resume")
+			chunkSize, _ := cmd.Flags().GetInt64("chunk-size")
+			c.uploadFile(args[0], name, metadata, resume, chunkSize)
+		},
+	}
+	uploadCmd.Flags().String("name", "", "Название записи (по умолчанию имя файла)")
+	uploadCmd.Flags().String("metadata", "", "Метаданные в формате JSON")
+	uploadCmd.Flags().String("resume", "", "ID файла, загрузку которого нужно продолжить")
+	uploadCmd.Flags().Int64("chunk-size", defaultChunkSize, "Размер фрагмента в байтах")
+
+	// Команда скачивания файла
+	downloadCmd := &cobra.Command{
+		Use:   "download [id]",
+		Short: "Скачать файл",
+		Long:  "Скачивает файл фрагментами. Если скачивание было прервано, повторный запуск продолжает его",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			output, _ := cmd.Flags().GetString("output")
+			c.downloadFile(args[0], output)
+		},
+	}
+	downloadCmd.Flags().StringP("output", "o", "", "Файл для сохранения")
+	downloadCmd.MarkFlagRequired("output")
+
+	// Команда истории изменений
+	historyCmd := &cobra.Command{
+		Use:   "history [id] [revision-id]",
+		Short: "Показать историю изменений записи или содержимое ревизии",
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 2 {
+				c.showRevision(args[0], args[1])
+				return
+			}
+			c.showHistory(args[0])
+		},
+	}
+
+	// Команда восстановления из ревизии
+	restoreCmd := &cobra.Command{
+		Use:   "restore [id] [revision-id]",
+		Short: "Восстановить запись из ревизии",
+		Long:  "Восстанавливает содержимое записи из ревизии, в том числе для удаленной записи",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			c.restoreRevision(args[0], args[1])
+		},
+	}
+
+	dataCmd.AddCommand(listCmd, addCmd, getCmd, updateCmd, deleteCmd, uploadCmd, downloadCmd, historyCmd, restoreCmd)
+	dataCmd.AddCommand(c.createShareCommands()...)
+	dataCmd.AddCommand(c.createSearchCommand())
+	dataCmd.AddCommand(c.createOrganizeCommands()...)
 	return dataCmd
 }
 
@@ -152,11 +398,12 @@ func (c *Client) createVersionCommand() *cobra.Command {
 }
 
 // register выполняет регистрацию пользователя.
-func (c *Client) register(username, email, password string) {
+func (c *Client) register(username, email, password, masterPassword string) {
 	req := map[string]string{
-		"username": username,
-		"email":    email,
-		"password": password,
+		"username":    username,
+		"email":       email,
+		"password":    password,
+		"device_name": deviceName(),
 	}
 
 	resp, err := c.makeRequest("POST", "/api/v1/register", req)
@@ -167,19 +414,11 @@ func (c *Client) register(username, email, password string) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
-		var authResp struct {
-			Token string `json:"token"`
-			User  struct {
-				ID       string `json:"id"`
-				Username string `json:"username"`
-				Email    string `json:"email"`
-			} `json:"user"`
-		}
-
+		var authResp authResponse
 		if err := json.NewDecoder(resp.Body).Decode(&authResp); err == nil {
-			c.token = authResp.Token
-			if err := c.saveToken(); err != nil {
-				fmt.Printf("Предупреждение: не удалось сохранить токен: %v\n", err)
+			c.startSession(&authResp)
+			if err := c.unlockVault(masterPassword, authResp.KDFSalt, authResp.KDFCheck); err != nil {
+				fmt.Printf("Предупреждение: %v\n", err)
 			}
 			fmt.Printf("Успешная регистрация! Добро пожаловать, %s!\n", authResp.User.Username)
 		}
@@ -190,10 +429,12 @@ func (c *Client) register(username, email, password string) {
 }
 
 // login выполняет вход пользователя.
-func (c *Client) login(username, password string) {
+// Если у пользователя включена 2FA, вход завершается одноразовым паролем otp или запрошенным у пользователя.
+func (c *Client) login(username, password, masterPassword, otp string) {
 	req := map[string]string{
-		"username": username,
-		"password": password,
+		"username":    username,
+		"password":    password,
+		"device_name": deviceName(),
 	}
 
 	resp, err := c.makeRequest("POST", "/api/v1/login", req)
@@ -204,19 +445,17 @@ func (c *Client) login(username, password string) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusOK {
-		var authResp struct {
-			Token string `json:"token"`
-			User  struct {
-				ID       string `json:"id"`
-				Username string `json:"username"`
-				Email    string `json:"email"`
-			} `json:"user"`
-		}
-
+		var authResp authResponse
 		if err := json.NewDecoder(resp.Body).Decode(&authResp); err == nil {
-			c.token = authResp.Token
-			if err := c.saveToken(); err != nil {
-				fmt.Printf("Предупреждение: не удалось сохранить токен: %v\n", err)
+			if authResp.TwoFactorRequired {
+				if err := c.verifyTwoFactor(&authResp, otp); err != nil {
+					fmt.Printf("Ошибка входа: %v\n", err)
+					return
+				}
+			}
+			c.startSession(&authResp)
+			if err := c.unlockVault(masterPassword, authResp.KDFSalt, authResp.KDFCheck); err != nil {
+				fmt.Printf("Предупреждение: %v\n", err)
 			}
 			fmt.Printf("Успешный вход! Добро пожаловать, %s!\n", authResp.User.Username)
 		}
@@ -226,62 +465,162 @@ func (c *Client) login(username, password string) {
 	}
 }
 
-// logout выполняет выход пользователя.
+// logout завершает сессию на сервере и удаляет токены и ключ хранилища.
 func (c *Client) logout() {
-	c.token = ""
-	if err := c.saveToken(); err != nil {
-		fmt.Printf("Предупреждение: не удалось сохранить токен: %v\n", err)
+	if c.token != "" {
+		if err := c.endSession(); err != nil {
+			fmt.Printf("Предупреждение: не удалось завершить сессию на сервере: %v\n", err)
+		}
+	}
+
+	c.clearTokens()
+	if err := c.forgetVaultKey(); err != nil {
+		fmt.Printf("Предупреждение: не удалось удалить ключ хранилища: %v\n", err)
 	}
 	fmt.Println("Выход выполнен успешно")
 }
 
-// listData выводит список данных.
-func (c *Client) listData() {
+// listOptions описывает фильтры и вид списка записей.
+type listOptions struct {
+	Type      string
+	Prefix    string
+	Sort      string
+	Tags      []string
+	Favorites bool
+	Tree      bool // Вывести записи деревом папок
+}
+
+// listData выводит список данных, при необходимости отфильтрованных по типу, началу
+// названия, меткам и избранному. Сервер отдает список страницами, и они загружаются
+// все подряд. Без подключения к серверу список берется из локального кэша.
+func (c *Client) listData(opts listOptions) {
 	if c.token == "" {
 		fmt.Println("Необходимо войти в систему")
 		return
 	}
 
-	resp, err := c.makeRequest("GET", "/api/v1/data", nil)
+	params := url.Values{}
+	if opts.Type != "" {
+		params.Set("type", opts.Type)
+	}
+	if opts.Prefix != "" {
+		params.Set("name_prefix", opts.Prefix)
+	}
+	if opts.Sort != "" {
+		params.Set("sort", opts.Sort)
+	}
+	for _, tag := range opts.Tags {
+		params.Add("tag", tag)
+	}
+	if opts.Favorites {
+		params.Set("favorite", "true")
+	}
+	path := "/api/v1/data"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	data, err := c.fetchDataPages(path)
+	if errors.Is(err, errOffline) {
+		c.listCachedData(opts)
+		return
+	}
 	if err != nil {
 		fmt.Printf("Ошибка получения данных: %v\n", err)
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusOK {
-		var data []map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&data); err == nil {
-			if len(data) == 0 {
-				fmt.Println("Данные не найдены")
-				return
-			}
+	if opts.Tree {
+		folders, err := c.fetchFolders()
+		if err != nil {
+			fmt.Printf("Ошибка получения папок: %v\n", err)
+			return
+		}
+		printDataTree(folders, data)
+		return
+	}
+	printDataList(data)
+}
 
-			fmt.Printf("Найдено %d записей:\n", len(data))
-			for _, item := range data {
-				fmt.Printf("- ID: %v, Название: %v, Логин: %v\n",
-					item["id"], item["name"], item["login"])
-			}
+// fetchDataPages загружает список записей, переходя по ссылкам на следующие страницы
+// из заголовка Link, пока они есть.
+func (c *Client) fetchDataPages(path string) ([]dataItem, error) {
+	var data []dataItem
+	for path != "" {
+		resp, err := c.makeRequest("GET", path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errOffline, err)
 		}
-	} else {
-		body, _ := io.ReadAll(resp.Body)
-		fmt.Printf("Ошибка получения данных: %s\n", string(body))
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, errors.New(string(body))
+		}
+
+		var page []dataItem
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка парсинга ответа: %w", err)
+		}
+
+		data = append(data, page...)
+		path = nextLink(resp.Header.Get("Link"))
 	}
+	return data, nil
 }
 
-// addData добавляет новые данные.
-func (c *Client) addData(name, login, password, metadata string) {
+// nextLink возвращает ссылку с rel="next" из заголовка Link или пустую строку.
+func nextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(target), "<>")
+	}
+	return ""
+}
+
+// listCachedData выводит список данных из локального кэша. Папки в кэше не хранятся,
+// поэтому список выводится без дерева.
+func (c *Client) listCachedData(opts listOptions) {
+	store, err := c.loadStore()
+	if err != nil {
+		fmt.Printf("Ошибка получения данных: %v\n", err)
+		return
+	}
+
+	var data []dataItem
+	for _, item := range store.Records {
+		if opts.Type != "" && item.Type != opts.Type {
+			continue
+		}
+		if !strings.HasPrefix(strings.ToLower(item.Name), strings.ToLower(opts.Prefix)) {
+			continue
+		}
+		if opts.Favorites && !item.Favorite {
+			continue
+		}
+		if slices.ContainsFunc(opts.Tags, func(tag string) bool { return !slices.Contains(item.Tags, tag) }) {
+			continue
+		}
+		data = append(data, *item)
+	}
+	sort.Slice(data, func(i, j int) bool { return data[i].Name < data[j].Name })
+
+	fmt.Println("Сервер недоступен, показаны данные из локального кэша")
+	printDataList(data)
+}
+
+// addData добавляет новые данные. Без подключения к серверу запись ставится в очередь синхронизации.
+func (c *Client) addData(req map[string]interface{}, metadata string) {
 	if c.token == "" {
 		fmt.Println("Необходимо войти в систему")
 		return
 	}
 
-	// Сборка запроса
-	req := map[string]interface{}{
-		"name":     name,
-		"login":    login,
-		"password": password,
-	}
 	if metadata != "" {
 		var metaObj map[string]interface{}
 		if err := json.Unmarshal([]byte(metadata), &metaObj); err == nil {
@@ -291,9 +630,22 @@ func (c *Client) addData(name, login, password, metadata string) {
 		}
 	}
 
+	// ID назначается на клиенте, потому что зашифрованное содержимое привязано к записи
+	id := uuid.NewString()
+	req["id"] = id
+	dataType, _ := req["type"].(string)
+	if err := c.sealSecrets(req, id, dataType); err != nil {
+		fmt.Printf("Ошибка шифрования данных: %v\n", err)
+		return
+	}
+
 	resp, err := c.makeRequest("POST", "/api/v1/data", req)
 	if err != nil {
-		fmt.Printf("Ошибка добавления данных: %v\n", err)
+		if err := c.queueOffline(pendingOp{Action: opCreate, RecordID: newLocalID(id), Request: req}); err != nil {
+			fmt.Printf("Ошибка добавления данных: %v\n", err)
+			return
+		}
+		fmt.Println("Сервер недоступен, данные сохранены локально и будут отправлены командой sync")
 		return
 	}
 	defer resp.Body.Close()
@@ -306,59 +658,224 @@ func (c *Client) addData(name, login, password, metadata string) {
 	}
 }
 
-// getData получает данные по ID.
-func (c *Client) getData(id string) {
+// updateData обновляет данные. Без подключения к серверу изменение ставится в очередь синхронизации.
+func (c *Client) updateData(id string, req map[string]interface{}, metadata string) {
 	if c.token == "" {
 		fmt.Println("Необходимо войти в систему")
 		return
 	}
 
-	resp, err := c.makeRequest("GET", "/api/v1/data/"+id, nil)
+	if metadata != "" {
+		var metaObj map[string]interface{}
+		if err := json.Unmarshal([]byte(metadata), &metaObj); err == nil {
+			req["metadata"] = metaObj
+		} else {
+			fmt.Println("Предупреждение: метаданные должны быть JSON, игнорируются")
+		}
+	}
+
+	if isLocalID(id) {
+		if err := c.updateLocal(id, req); err != nil {
+			fmt.Printf("Ошибка обновления данных: %v\n", err)
+			return
+		}
+		fmt.Println("Данные успешно обновлены")
+		return
+	}
+
+	base, _, err := c.fetchItem(id)
 	if err != nil {
 		fmt.Printf("Ошибка получения данных: %v\n", err)
 		return
 	}
-	defer resp.Body.Close()
+	if err := c.openSecrets(base); err != nil {
+		fmt.Printf("Ошибка расшифровки данных: %v\n", err)
+		return
+	}
 
-	if resp.StatusCode == http.StatusOK {
-		var data map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&data); err == nil {
-			fmt.Printf("Данные: %+v\n", data)
+	applied, err := c.pushUpdate(id, base, req)
+	switch {
+	case errors.Is(err, errOffline):
+		if err := c.queueUpdate(id, base, req); err != nil {
+			fmt.Printf("Ошибка обновления данных: %v\n", err)
+			return
+		}
+		fmt.Println("Сервер недоступен, изменения сохранены локально и будут отправлены командой sync")
+	case err != nil:
+		fmt.Printf("Ошибка обновления данных: %v\n", err)
+	case applied:
+		fmt.Println("Данные успешно обновлены")
+	default:
+		fmt.Println("Изменения отменены, сохранена версия сервера")
+	}
+}
+
+// deleteData удаляет данные. Без подключения к серверу удаление ставится в очередь синхронизации.
+func (c *Client) deleteData(id string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	if isLocalID(id) {
+		if err := c.queueOffline(pendingOp{Action: opDelete, RecordID: id}); err != nil {
+			fmt.Printf("Ошибка удаления данных: %v\n", err)
+			return
 		}
+		fmt.Println("Данные успешно удалены")
+		return
+	}
+
+	resp, err := c.makeRequest("DELETE", "/api/v1/data/"+id, nil)
+	if err != nil {
+		if err := c.queueOffline(pendingOp{Action: opDelete, RecordID: id}); err != nil {
+			fmt.Printf("Ошибка удаления данных: %v\n", err)
+			return
+		}
+		fmt.Println("Сервер недоступен, удаление сохранено локально и будет отправлено командой sync")
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusOK {
+		fmt.Println("Данные успешно удалены")
 	} else {
 		body, _ := io.ReadAll(resp.Body)
-		fmt.Printf("Ошибка получения данных: %s\n", string(body))
+		fmt.Printf("Ошибка удаления данных: %s\n", string(body))
+	}
+}
+
+// getData получает данные по ID. Бинарные данные сохраняются в файл output, если он указан.
+func (c *Client) getData(id, output string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	data, offline, err := c.fetchItem(id)
+	if err != nil {
+		fmt.Printf("Ошибка получения данных: %v\n", err)
+		return
+	}
+	if offline {
+		fmt.Println("Сервер недоступен, показаны данные из локального кэша")
+	}
+
+	if err := c.openSecrets(data); err != nil {
+		fmt.Printf("Ошибка расшифровки данных: %v\n", err)
+		return
+	}
+	if data.Type == "binary" && output != "" {
+		if err := os.WriteFile(output, data.Binary, 0600); err != nil {
+			fmt.Printf("Ошибка сохранения файла: %v\n", err)
+			return
+		}
+		fmt.Printf("Бинарные данные сохранены в %s\n", output)
+		return
+	}
+	printDataItem(data)
+}
+
+// fetchItem получает запись с сервера, а без подключения к нему из локального кэша.
+// Второе значение сообщает, что запись взята из кэша.
+func (c *Client) fetchItem(id string) (*dataItem, bool, error) {
+	resp, err := c.makeRequest("GET", "/api/v1/data/"+id, nil)
+	if err != nil {
+		store, storeErr := c.loadStore()
+		if storeErr != nil {
+			return nil, true, storeErr
+		}
+		item, ok := store.Records[id]
+		if !ok {
+			return nil, true, fmt.Errorf("сервер недоступен, а запись %s отсутствует в локальном кэше", id)
+		}
+		return item, true, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, false, fmt.Errorf("%s", string(body))
+	}
+
+	var item dataItem
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		return nil, false, err
 	}
+	return &item, false, nil
 }
 
-// makeRequest выполняет HTTP запрос.
+// makeRequest выполняет HTTP запрос с телом в формате JSON.
 func (c *Client) makeRequest(method, path string, body interface{}) (*http.Response, error) {
+	if body == nil {
+		return c.send(method, path, nil, "")
+	}
+
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(method, path, jsonData, "application/json")
+}
+
+// makeStreamRequest выполняет HTTP запрос с двоичным телом.
+func (c *Client) makeStreamRequest(method, path string, body []byte) (*http.Response, error) {
+	return c.send(method, path, body, "application/octet-stream")
+}
+
+// send выполняет запрос, а если токен доступа истек, обновляет его и повторяет запрос один раз.
+// Тело передается срезом, чтобы его можно было отправить повторно.
+func (c *Client) send(method, path string, body []byte, contentType string) (*http.Response, error) {
+	resp, err := c.do(method, path, body, contentType)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || c.refreshToken == "" || publicPaths[path] {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	if err := c.refreshSession(); err != nil {
+		return nil, err
+	}
+	return c.do(method, path, body, contentType)
+}
+
+// do выполняет один HTTP запрос.
+func (c *Client) do(method, path string, body []byte, contentType string) (*http.Response, error) {
 	var reqBody io.Reader
 	if body != nil {
-		jsonData, err := json.Marshal(body)
-		if err != nil {
-			return nil, err
-		}
-		reqBody = bytes.NewBuffer(jsonData)
+		reqBody = bytes.NewReader(body)
 	}
 
-	req, err := http.NewRequest(method, c.baseURL+path, reqBody)
+	req, err := c.newRequest(method, path, reqBody)
 	if err != nil {
 		return nil, err
 	}
 
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// newRequest создает HTTP запрос с заголовками авторизации и устройства.
+func (c *Client) newRequest(method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
 	}
 
 	if c.token != "" {
 		req.Header.Set("Authorization", "Bearer "+c.token)
 	}
 
-	return c.httpClient.Do(req)
+	if c.clientID != "" {
+		req.Header.Set(clientIDHeader, c.clientID)
+	}
+
+	return req, nil
 }
 
-// saveToken сохраняет токен в файл.
+// saveToken сохраняет токены доступа и обновления в файлы.
 func (c *Client) saveToken() error {
 	if err := os.MkdirAll(c.configPath, 0755); err != nil {
 		return fmt.Errorf("не удалось создать директорию конфигурации: %w", err)
@@ -369,13 +886,23 @@ func (c *Client) saveToken() error {
 		return fmt.Errorf("не удалось сохранить токен: %w", err)
 	}
 
+	refreshFile := filepath.Join(c.configPath, "refresh_token")
+	if err := os.WriteFile(refreshFile, []byte(c.refreshToken), 0600); err != nil {
+		return fmt.Errorf("не удалось сохранить токен обновления: %w", err)
+	}
+
 	return nil
 }
 
-// loadToken загружает токен из файла.
+// loadToken загружает токены доступа и обновления из файлов.
 func (c *Client) loadToken() {
 	tokenFile := filepath.Join(c.configPath, "token")
 	if data, err := os.ReadFile(tokenFile); err == nil {
 		c.token = string(data)
 	}
+
+	refreshFile := filepath.Join(c.configPath, "refresh_token")
+	if data, err := os.ReadFile(refreshFile); err == nil {
+		c.refreshToken = string(data)
+	}
 }
@@ -2,6 +2,8 @@
 package client
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -91,3 +93,48 @@ func TestClient_createVersionCommand(t *testing.T) {
 		t.Errorf("Ожидался Use 'version', получен '%s'", versionCmd.Use)
 	}
 }
+
+func TestClient_createDataCommands_AddSubcommands(t *testing.T) {
+	client := New()
+	dataCmd := client.createDataCommands()
+
+	addCmd, _, err := dataCmd.Find([]string{"add"})
+	if err != nil {
+		t.Fatalf("Команда add не найдена: %v", err)
+	}
+
+	for _, dataType := range dataTypes {
+		if cmd, _, err := addCmd.Find([]string{dataType}); err != nil || cmd == addCmd {
+			t.Errorf("Подкоманда add %s не найдена", dataType)
+		}
+	}
+}
+
+func TestClient_fetchDataPages(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		if r.URL.Query().Get("cursor") == "" {
+			w.Header().Set("Link", `</api/v1/data?cursor=next&type=text>; rel="next"`)
+			w.Write([]byte(`[{"id":"1","name":"a"},{"id":"2","name":"b"}]`))
+			return
+		}
+		w.Write([]byte(`[{"id":"3","name":"c"}]`))
+	}))
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+
+	data, err := client.fetchDataPages("/api/v1/data?type=text")
+	if err != nil {
+		t.Fatalf("Ошибка получения данных: %v", err)
+	}
+	if len(data) != 3 || data[2].Name != "c" {
+		t.Errorf("Ожидались записи со всех страниц, получено %+v", data)
+	}
+	if len(requests) != 2 || requests[1] != "/api/v1/data?cursor=next&type=text" {
+		t.Errorf("Клиент должен перейти по ссылке на следующую страницу, запросы: %v", requests)
+	}
+}
@@ -0,0 +1,250 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// errOffline возвращается, если сервер недоступен.
+var errOffline = errors.New("сервер недоступен")
+
+// serverError описывает ответ сервера с ошибкой на отправленное изменение.
+type serverError struct {
+	Status  int
+	Message string
+}
+
+// Error возвращает текст ошибки.
+func (e *serverError) Error() string {
+	return e.Message
+}
+
+// rejected сообщает, что сервер окончательно отклонил изменение и повторять его бессмысленно.
+// Остальные ошибки, например 5xx, считаются временными.
+func (e *serverError) rejected() bool {
+	switch e.Status {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity:
+		return true
+	}
+	return false
+}
+
+// maxConflictRetries ограничивает число повторных отправок после разрешения конфликта.
+const maxConflictRetries = 3
+
+// conflictResponse представляет ответ сервера при конфликте версий.
+type conflictResponse struct {
+	Error   string    `json:"error"`
+	Current *dataItem `json:"current"`
+}
+
+// Варианты разрешения конфликта.
+const (
+	resolveMine   = "m"
+	resolveTheirs = "t"
+	resolveMerge  = "e"
+)
+
+// pushUpdate отправляет изменения записи с версией, на которой они основаны.
+// Если запись была изменена на другом устройстве, пользователь выбирает, какую версию оставить.
+// Возвращает false, если изменения отменены в пользу версии сервера.
+func (c *Client) pushUpdate(id string, base *dataItem, changes map[string]interface{}) (bool, error) {
+	req := copyRequest(changes)
+	// Blob, зашифрованный на клиенте, заменяется целиком, поэтому изменения
+	// накладываются на текущее содержимое записи.
+	if c.resealSecrets(req, base) {
+		mergeSecrets(req, base)
+	}
+	version := base.Version
+
+	for attempt := 0; ; attempt++ {
+		sealed := copyRequest(req)
+		if err := c.sealSecrets(sealed, id, base.Type); err != nil {
+			return false, err
+		}
+		sealed["version"] = version
+
+		resp, err := c.makeRequest("PUT", "/api/v1/data/"+id, sealed)
+		if err != nil {
+			return false, fmt.Errorf("%w: %v", errOffline, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return true, nil
+		case http.StatusConflict:
+		default:
+			return false, &serverError{Status: resp.StatusCode, Message: string(body)}
+		}
+
+		if attempt == maxConflictRetries {
+			return false, errors.New("запись продолжает изменяться на другом устройстве, повторите позже")
+		}
+
+		var conflict conflictResponse
+		if err := json.Unmarshal(body, &conflict); err != nil || conflict.Current == nil {
+			return false, fmt.Errorf("%s", string(body))
+		}
+		current := conflict.Current
+		if err := c.openSecrets(current); err != nil {
+			return false, err
+		}
+
+		req = c.resolveConflict(base, changes, current)
+		if req == nil {
+			return false, nil
+		}
+		version = current.Version
+	}
+}
+
+// resolveConflict предлагает пользователю разрешить конфликт версий и возвращает
+// запрос, который нужно отправить, или nil, если сохраняется версия сервера.
+func (c *Client) resolveConflict(base *dataItem, changes map[string]interface{}, current *dataItem) map[string]interface{} {
+	fmt.Printf("Запись %s была изменена на другом устройстве.\n", current.ID)
+	fmt.Println("Версия на сервере:")
+	printDataItem(current)
+	fmt.Println("Ваши изменения:")
+	for _, field := range sortedFields(changes) {
+		fmt.Printf("  %s: %s\n", field, formatValue(changes[field]))
+	}
+
+	choice := c.ask("Оставить [m] мою версию, [t] версию сервера или [e] объединить? ",
+		resolveMine, resolveTheirs, resolveMerge)
+
+	switch choice {
+	case resolveMine:
+		req := requestFromItem(base)
+		for field, value := range changes {
+			req[field] = value
+		}
+		return req
+	case resolveMerge:
+		return c.mergeChanges(base, changes, current)
+	default:
+		return nil
+	}
+}
+
+// mergeChanges накладывает изменения на версию сервера. Для полей, измененных
+// на обоих устройствах по-разному, пользователь выбирает значение.
+func (c *Client) mergeChanges(base *dataItem, changes map[string]interface{}, current *dataItem) map[string]interface{} {
+	req := requestFromItem(current)
+	original := requestFromItem(base)
+
+	for _, field := range sortedFields(changes) {
+		mine := changes[field]
+		theirs, ok := req[field]
+		if !ok || sameValue(theirs, original[field]) || sameValue(theirs, mine) {
+			req[field] = mine
+			continue
+		}
+
+		question := fmt.Sprintf("Поле %s изменено на обоих устройствах: [m] %s, [t] %s? ",
+			field, formatValue(mine), formatValue(theirs))
+		if c.ask(question, resolveMine, resolveTheirs) == resolveMine {
+			req[field] = mine
+		}
+	}
+
+	return req
+}
+
+// ask задает вопрос и возвращает один из допустимых ответов.
+// Если ввод закончился, сохраняется версия сервера.
+func (c *Client) ask(question string, options ...string) string {
+	if c.in == nil {
+		c.in = bufio.NewReader(os.Stdin)
+	}
+
+	for {
+		fmt.Print(question)
+		line, err := c.in.ReadString('\n')
+		answer := strings.ToLower(strings.TrimSpace(line))
+		for _, option := range options {
+			if answer == option {
+				return option
+			}
+		}
+		if err != nil {
+			fmt.Println()
+			return resolveTheirs
+		}
+	}
+}
+
+// requestFromItem строит тело запроса обновления, описывающее запись целиком.
+func requestFromItem(item *dataItem) map[string]interface{} {
+	req := map[string]interface{}{"name": item.Name}
+
+	switch item.Type {
+	case "text":
+		req["text"] = item.Text
+	case "card":
+		if item.Card != nil {
+			req["card"] = item.Card
+		}
+	case "binary":
+		req["binary"] = item.Binary
+	default:
+		req["login"] = item.Login
+		req["password"] = item.Password
+	}
+
+	if strings.TrimSpace(item.Metadata) != "" {
+		var metadata interface{}
+		if err := json.Unmarshal([]byte(item.Metadata), &metadata); err == nil {
+			req["metadata"] = metadata
+		}
+	}
+
+	return req
+}
+
+// copyRequest возвращает поверхностную копию тела запроса.
+func copyRequest(req map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(req))
+	for key, value := range req {
+		result[key] = value
+	}
+	return result
+}
+
+// sameValue сравнивает значения полей по их JSON представлению.
+func sameValue(a, b interface{}) bool {
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(rawA) == string(rawB)
+}
+
+// formatValue возвращает значение поля для вывода пользователю.
+func formatValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(raw)
+}
+
+// sortedFields возвращает имена полей запроса в алфавитном порядке.
+func sortedFields(req map[string]interface{}) []string {
+	fields := make([]string, 0, len(req))
+	for field := range req {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,176 @@
+// Package client содержит тесты для разрешения конфликтов версий.
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+)
+
+// newConflictServer создает сервер, который отклоняет обновления устаревшей версии записи.
+func newConflictServer(t *testing.T, current dataItem, updates *[]map[string]interface{}) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /api/v1/data/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		*updates = append(*updates, req)
+
+		if int64(req["version"].(float64)) != current.Version {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(conflictResponse{Error: "conflict", Current: &current})
+			return
+		}
+		json.NewEncoder(w).Encode(current)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestClient_pushUpdate_Conflict(t *testing.T) {
+	base := &dataItem{ID: "id", Type: "login_password", Name: "Mail", Login: "user", Password: "old", Version: 1}
+	current := dataItem{ID: "id", Type: "login_password", Name: "Work mail", Login: "user", Password: "other", Version: 2}
+
+	tests := []struct {
+		name     string
+		input    string
+		applied  bool
+		requests int
+		want     map[string]interface{}
+	}{
+		{
+			name:     "keep mine",
+			input:    "m\n",
+			applied:  true,
+			requests: 2,
+			want:     map[string]interface{}{"name": "Mail", "password": "new"},
+		},
+		{
+			name:     "keep theirs",
+			input:    "t\n",
+			applied:  false,
+			requests: 1,
+		},
+		{
+			name:     "merge",
+			input:    "e\nm\n",
+			applied:  true,
+			requests: 2,
+			want:     map[string]interface{}{"name": "Work mail", "password": "new"},
+		},
+		{
+			name:     "no input",
+			input:    "",
+			applied:  false,
+			requests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updates []map[string]interface{}
+			server := newConflictServer(t, current, &updates)
+			defer server.Close()
+
+			client := New()
+			client.baseURL = server.URL
+			client.token = "test-token"
+			client.in = bufio.NewReader(strings.NewReader(tt.input))
+
+			applied, err := client.pushUpdate("id", base, map[string]interface{}{"password": "new"})
+			if err != nil {
+				t.Fatalf("Неожиданная ошибка: %v", err)
+			}
+
+			if applied != tt.applied {
+				t.Errorf("Ожидалось applied=%v, получено %v", tt.applied, applied)
+			}
+
+			if len(updates) != tt.requests {
+				t.Fatalf("Ожидалось %d запросов, получено %d", tt.requests, len(updates))
+			}
+
+			if tt.want == nil {
+				return
+			}
+			last := updates[len(updates)-1]
+			if last["version"] != float64(current.Version) {
+				t.Errorf("Ожидалась версия %d, получено %v", current.Version, last["version"])
+			}
+			for field, value := range tt.want {
+				if last[field] != value {
+					t.Errorf("Ожидалось %s=%v, получено %v", field, value, last[field])
+				}
+			}
+		})
+	}
+}
+
+func TestClient_sync_ResolvesConflict(t *testing.T) {
+	var updates []map[string]interface{}
+	current := dataItem{ID: "id", Type: "text", Name: "Renamed", Text: "draft", Version: 2}
+	server := newConflictServer(t, current, &updates)
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+	client.configPath = t.TempDir()
+	client.in = bufio.NewReader(strings.NewReader("e\n"))
+
+	store := newLocalStore()
+	base := &dataItem{ID: "id", Type: "text", Name: "Note", Text: "draft", Version: 1}
+	store.apply(*base)
+	store.queue(pendingOp{Action: opUpdate, RecordID: "id", Request: map[string]interface{}{"text": "final"},
+		Changes: map[string]interface{}{"text": "final"}, Base: base})
+
+	pushed, err := client.pushPending(store)
+	if err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+
+	if pushed != 1 || len(store.Pending) != 0 {
+		t.Errorf("Ожидалось одно отправленное изменение и пустая очередь, получено %d и %+v", pushed, store.Pending)
+	}
+
+	if len(updates) != 2 || updates[1]["name"] != "Renamed" || updates[1]["text"] != "final" {
+		t.Errorf("Ожидалось объединение изменений, получено %+v", updates)
+	}
+}
+
+func TestClient_pushUpdate_ResealsLegacyBlob(t *testing.T) {
+	key := make([]byte, crypto.VaultKeySize)
+	legacy, _ := crypto.SealBlob([]byte(`{"password":"secret"}`), key, nil)
+	base := &dataItem{ID: "id", Type: "login_password", Name: "Mail", EncryptedPayload: legacy, Version: 1}
+
+	var updates []map[string]interface{}
+	server := newConflictServer(t, dataItem{ID: "id", Version: 1}, &updates)
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+	client.vaultKey = key
+
+	if err := client.openSecrets(base); err != nil {
+		t.Fatalf("Ошибка расшифровки: %v", err)
+	}
+	if _, err := client.pushUpdate("id", base, map[string]interface{}{"name": "Work mail"}); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+
+	// Изменение названия заново шифрует blob старого формата с привязкой к записи
+	blob, _ := updates[0]["encrypted_payload"].(string)
+	if crypto.IsLegacyRecordBlob(blob) {
+		t.Fatalf("Ожидался blob текущего формата, получено %q", blob)
+	}
+	plaintext, err := crypto.OpenRecordBlob(blob, key, "id", "login_password")
+	if err != nil || !strings.Contains(string(plaintext), "secret") {
+		t.Errorf("Blob должен содержать прежний пароль, получено %q (ошибка %v)", plaintext, err)
+	}
+}
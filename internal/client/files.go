@@ -0,0 +1,329 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+)
+
+// defaultChunkSize задает размер фрагмента файла по умолчанию.
+const defaultChunkSize int64 = 1 << 20
+
+// fileStatus представляет состояние загрузки файла в ответе сервера.
+type fileStatus struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Size            int64  `json:"size"`
+	ChunkSize       int64  `json:"chunk_size"`
+	ChunkCount      int    `json:"chunk_count"`
+	Uploaded        []int  `json:"uploaded"`
+	Complete        bool   `json:"complete"`
+	ClientEncrypted bool   `json:"client_encrypted"`
+}
+
+// plainChunkSize возвращает размер фрагмента исходного файла.
+func (s *fileStatus) plainChunkSize() int64 {
+	if s.ClientEncrypted {
+		return s.ChunkSize - crypto.ChunkOverhead
+	}
+	return s.ChunkSize
+}
+
+// uploadFile загружает файл фрагментами. Если resumeID задан, продолжает
+// загрузку этого файла, пропуская уже полученные сервером фрагменты.
+func (c *Client) uploadFile(path, name, metadata, resumeID string, chunkSize int64) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("Ошибка открытия файла: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		fmt.Printf("Ошибка открытия файла: %v\n", err)
+		return
+	}
+
+	var status *fileStatus
+	if resumeID != "" {
+		status, err = c.fileStatus(resumeID)
+	} else {
+		status, err = c.createFile(filepath.Base(path), name, metadata, info.Size(), chunkSize)
+	}
+	if err != nil {
+		fmt.Printf("Ошибка загрузки файла: %v\n", err)
+		return
+	}
+
+	if expected := c.uploadSize(info.Size(), status.plainChunkSize()); status.Size != expected || status.ClientEncrypted != (c.vaultKey != nil) {
+		fmt.Println("Ошибка загрузки файла: файл не совпадает с начатой загрузкой")
+		return
+	}
+
+	if err := c.uploadChunks(f, status); err != nil {
+		fmt.Printf("Ошибка загрузки файла: %v\n", err)
+		fmt.Printf("Продолжите загрузку командой: data upload %s --resume %s\n", path, status.ID)
+		return
+	}
+
+	if err := c.completeFile(status.ID); err != nil {
+		fmt.Printf("Ошибка завершения загрузки: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Файл успешно загружен, ID: %s\n", status.ID)
+}
+
+// completeFile завершает загрузку файла id после отправки всех фрагментов.
+func (c *Client) completeFile(id string) error {
+	resp, err := c.makeRequest("POST", "/api/v1/files/"+id+"/complete", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.New(string(body))
+	}
+	return nil
+}
+
+// createFile начинает загрузку нового файла.
+func (c *Client) createFile(fileName, name, metadata string, size, chunkSize int64) (*fileStatus, error) {
+	if name == "" {
+		name = fileName
+	}
+
+	uploadChunkSize := chunkSize
+	if c.vaultKey != nil {
+		uploadChunkSize += crypto.ChunkOverhead
+	}
+
+	req := map[string]interface{}{
+		"name":             name,
+		"size":             c.uploadSize(size, chunkSize),
+		"chunk_size":       uploadChunkSize,
+		"client_encrypted": c.vaultKey != nil,
+	}
+	if metadata != "" {
+		var metaObj map[string]interface{}
+		if err := json.Unmarshal([]byte(metadata), &metaObj); err == nil {
+			req["metadata"] = metaObj
+		} else {
+			fmt.Println("Предупреждение: метаданные должны быть JSON, игнорируются")
+		}
+	}
+
+	resp, err := c.makeRequest("POST", "/api/v1/files", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s", string(body))
+	}
+
+	var status fileStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// fileStatus получает состояние загрузки файла.
+func (c *Client) fileStatus(id string) (*fileStatus, error) {
+	resp, err := c.makeRequest("GET", "/api/v1/files/"+id, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s", string(body))
+	}
+
+	var status fileStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// uploadSize возвращает размер загружаемых данных с учетом шифрования фрагментов на клиенте.
+func (c *Client) uploadSize(size, chunkSize int64) int64 {
+	if c.vaultKey == nil || chunkSize <= 0 {
+		return size
+	}
+	chunks := (size + chunkSize - 1) / chunkSize
+	return size + chunks*crypto.ChunkOverhead
+}
+
+// uploadChunks отправляет фрагменты файла, которые еще не получены сервером.
+// В памяти одновременно находится только один фрагмент.
+func (c *Client) uploadChunks(f io.ReaderAt, status *fileStatus) error {
+	uploaded := make(map[int]bool, len(status.Uploaded))
+	for _, index := range status.Uploaded {
+		uploaded[index] = true
+	}
+
+	plainSize := status.plainChunkSize()
+	buf := make([]byte, plainSize)
+
+	for index := 0; index < status.ChunkCount; index++ {
+		if uploaded[index] {
+			continue
+		}
+
+		n, err := f.ReadAt(buf, int64(index)*plainSize)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		chunk := buf[:n]
+		if status.ClientEncrypted {
+			chunk, err = crypto.SealChunk(chunk, c.vaultKey, crypto.ChunkAAD(status.ID, index))
+			if err != nil {
+				return err
+			}
+		}
+
+		resp, err := c.makeStreamRequest("PUT", "/api/v1/files/"+status.ID+"/chunks/"+strconv.Itoa(index), chunk)
+		if err != nil {
+			return err
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("фрагмент %d: %s", index, string(body))
+		}
+		fmt.Printf("\rЗагружено фрагментов: %d/%d", index+1, status.ChunkCount)
+	}
+	if status.ChunkCount > 0 {
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// downloadFile скачивает файл фрагментами во временный файл рядом с output.
+// Если временный файл уже есть, скачивание продолжается с первого неполного фрагмента.
+func (c *Client) downloadFile(id, output string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	status, err := c.fileStatus(id)
+	if err != nil {
+		fmt.Printf("Ошибка скачивания файла: %v\n", err)
+		return
+	}
+	if !status.Complete {
+		fmt.Println("Ошибка скачивания файла: загрузка файла не завершена")
+		return
+	}
+	if status.ClientEncrypted && c.vaultKey == nil {
+		fmt.Println("Ошибка скачивания файла: файл зашифрован на клиенте, войдите с мастер-паролем")
+		return
+	}
+
+	partPath := output + ".part"
+	if err := c.downloadChunks(status, partPath); err != nil {
+		fmt.Printf("Ошибка скачивания файла: %v\n", err)
+		fmt.Printf("Повторите команду, чтобы продолжить скачивание\n")
+		return
+	}
+
+	if err := os.Rename(partPath, output); err != nil {
+		fmt.Printf("Ошибка сохранения файла: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Файл сохранен в %s\n", output)
+}
+
+// downloadChunks дописывает во временный файл недостающие фрагменты.
+func (c *Client) downloadChunks(status *fileStatus, partPath string) error {
+	f, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// Неполный последний фрагмент скачивается заново
+	plainSize := status.plainChunkSize()
+	start := int(info.Size() / plainSize)
+	if err := f.Truncate(int64(start) * plainSize); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+
+	for index := start; index < status.ChunkCount; index++ {
+		chunk, err := c.downloadChunk(status, index)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(chunk); err != nil {
+			return err
+		}
+		fmt.Printf("\rСкачано фрагментов: %d/%d", index+1, status.ChunkCount)
+	}
+	if status.ChunkCount > start {
+		fmt.Println()
+	}
+
+	return f.Sync()
+}
+
+// downloadChunk скачивает и при необходимости расшифровывает фрагмент файла.
+func (c *Client) downloadChunk(status *fileStatus, index int) ([]byte, error) {
+	resp, err := c.makeRequest("GET", "/api/v1/files/"+status.ID+"/chunks/"+strconv.Itoa(index), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, status.ChunkSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("фрагмент %d: %s", index, string(body))
+	}
+
+	if !status.ClientEncrypted {
+		return body, nil
+	}
+
+	chunk, err := crypto.OpenChunk(body, c.vaultKey, crypto.ChunkAAD(status.ID, index))
+	if err != nil {
+		return nil, errors.New("не удалось расшифровать файл, проверьте мастер-пароль")
+	}
+	return chunk, nil
+}
@@ -0,0 +1,125 @@
+// Package client содержит тесты для загрузки файлов.
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeFileServer хранит фрагменты одного файла так же, как сервер /api/v1/files.
+type fakeFileServer struct {
+	mu       sync.Mutex
+	status   fileStatus
+	chunks   map[int][]byte
+	failFrom int // Начиная с этого фрагмента загрузка завершается ошибкой
+}
+
+func newFakeFileServer(t *testing.T) (*httptest.Server, *fakeFileServer) {
+	fake := &fakeFileServer{chunks: make(map[int][]byte), failFrom: -1}
+	server := httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(server.Close)
+	return server, fake
+}
+
+func (f *fakeFileServer) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/files")
+	switch {
+	case r.Method == "POST" && path == "":
+		json.NewDecoder(r.Body).Decode(&f.status)
+		f.status.ID = "file-id"
+		f.status.ChunkCount = int((f.status.Size + f.status.ChunkSize - 1) / f.status.ChunkSize)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(f.status)
+	case r.Method == "GET" && path == "/file-id":
+		f.status.Uploaded = f.status.Uploaded[:0]
+		for index := range f.chunks {
+			f.status.Uploaded = append(f.status.Uploaded, index)
+		}
+		json.NewEncoder(w).Encode(f.status)
+	case r.Method == "POST" && path == "/file-id/complete":
+		f.status.Complete = len(f.chunks) == f.status.ChunkCount
+		json.NewEncoder(w).Encode(f.status)
+	case strings.HasPrefix(path, "/file-id/chunks/"):
+		index, _ := strconv.Atoi(strings.TrimPrefix(path, "/file-id/chunks/"))
+		if r.Method == "GET" {
+			w.Write(f.chunks[index])
+			return
+		}
+		if f.failFrom >= 0 && index >= f.failFrom {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		f.chunks[index], _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestClient_uploadDownloadFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		vaultKey []byte
+	}{
+		{name: "без шифрования на клиенте"},
+		{name: "с шифрованием на клиенте", vaultKey: bytes.Repeat([]byte{7}, 32)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, fake := newFakeFileServer(t)
+
+			client := New()
+			client.baseURL = server.URL
+			client.token = "test-token"
+			client.vaultKey = tt.vaultKey
+
+			dir := t.TempDir()
+			content := bytes.Repeat([]byte("0123456789"), 250)
+			source := filepath.Join(dir, "source.bin")
+			os.WriteFile(source, content, 0600)
+
+			// Первая попытка обрывается на третьем фрагменте
+			fake.failFrom = 2
+			client.uploadFile(source, "", "", "", 1024)
+			if len(fake.chunks) != 2 || fake.status.Complete {
+				t.Fatalf("Ожидалось 2 загруженных фрагмента, получено %d", len(fake.chunks))
+			}
+
+			fake.failFrom = -1
+			client.uploadFile(source, "", "", "file-id", 1024)
+			if !fake.status.Complete {
+				t.Fatal("Загрузка должна быть завершена")
+			}
+
+			if tt.vaultKey != nil && bytes.Contains(fake.chunks[0], content[:100]) {
+				t.Error("Фрагменты должны передаваться на сервер в зашифрованном виде")
+			}
+
+			output := filepath.Join(dir, "output.bin")
+			// Остаток прерванного скачивания дописывается, а не начинается заново
+			os.WriteFile(output+".part", content[:1500], 0600)
+			client.downloadFile("file-id", output)
+
+			got, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatalf("Файл должен быть сохранен: %v", err)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("Содержимое файла не совпадает: получено %d байт из %d", len(got), len(content))
+			}
+		})
+	}
+}
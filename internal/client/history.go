@@ -0,0 +1,158 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// clientIDHeader передает серверу идентификатор устройства для истории изменений.
+const clientIDHeader = "X-Client-ID"
+
+// revisionItem представляет ревизию записи в ответе сервера.
+type revisionItem struct {
+	ID        string    `json:"id"`
+	DataID    string    `json:"data_id"`
+	Version   int64     `json:"version"`
+	Action    string    `json:"action"`
+	ClientID  string    `json:"client_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// revisionResponse представляет ревизию вместе с ее содержимым.
+type revisionResponse struct {
+	Revision revisionItem `json:"revision"`
+	Data     *dataItem    `json:"data"`
+}
+
+// loadClientID загружает идентификатор устройства, при первом запуске создавая его.
+func (c *Client) loadClientID() {
+	idFile := filepath.Join(c.configPath, "client.id")
+	if data, err := os.ReadFile(idFile); err == nil {
+		c.clientID = strings.TrimSpace(string(data))
+		return
+	}
+
+	c.clientID = uuid.NewString()
+	if err := os.MkdirAll(c.configPath, 0755); err == nil {
+		_ = os.WriteFile(idFile, []byte(c.clientID), 0600)
+	}
+}
+
+// showHistory выводит историю изменений записи.
+func (c *Client) showHistory(id string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	resp, err := c.makeRequest("GET", "/api/v1/data/"+id+"/history", nil)
+	if err != nil {
+		fmt.Printf("Ошибка получения истории: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Ошибка получения истории: %s\n", string(body))
+		return
+	}
+
+	var revisions []revisionItem
+	if err := json.NewDecoder(resp.Body).Decode(&revisions); err != nil {
+		fmt.Printf("Ошибка парсинга ответа: %v\n", err)
+		return
+	}
+
+	fmt.Printf("%-36s %-8s %-8s %-20s %-20s %s\n", "ID ревизии", "Версия", "Действие", "Время", "Устройство", "Название")
+	fmt.Println(strings.Repeat("-", 120))
+	for _, revision := range revisions {
+		fmt.Printf("%-36s %-8d %-8s %-20s %-20s %s\n",
+			revision.ID,
+			revision.Version,
+			revision.Action,
+			revision.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+			shorten(revision.ClientID, 20),
+			revision.Name)
+	}
+}
+
+// showRevision выводит содержимое ревизии записи.
+func (c *Client) showRevision(id, revisionID string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	resp, err := c.makeRequest("GET", "/api/v1/data/"+id+"/history/"+revisionID, nil)
+	if err != nil {
+		fmt.Printf("Ошибка получения ревизии: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Ошибка получения ревизии: %s\n", string(body))
+		return
+	}
+
+	var revision revisionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&revision); err != nil || revision.Data == nil {
+		fmt.Printf("Ошибка парсинга ответа: %v\n", err)
+		return
+	}
+
+	if err := c.openSecrets(revision.Data); err != nil {
+		fmt.Printf("Ошибка расшифровки данных: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Ревизия: %s (версия %d, %s, %s)\n",
+		revision.Revision.ID,
+		revision.Revision.Version,
+		revision.Revision.Action,
+		revision.Revision.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	printDataItem(revision.Data)
+}
+
+// restoreRevision восстанавливает запись из ревизии.
+func (c *Client) restoreRevision(id, revisionID string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	resp, err := c.makeRequest("POST", "/api/v1/data/"+id+"/history/"+revisionID+"/restore", nil)
+	if err != nil {
+		fmt.Printf("Ошибка восстановления данных: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		fmt.Println("Данные успешно восстановлены")
+	} else {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Ошибка восстановления данных: %s\n", string(body))
+	}
+}
+
+// shorten обрезает строку до указанной длины.
+func shorten(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,51 @@
+// Package client содержит тесты для истории изменений.
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_loadClientID(t *testing.T) {
+	client := New()
+	client.configPath = t.TempDir()
+	client.loadClientID()
+
+	if client.clientID == "" {
+		t.Fatal("Идентификатор устройства должен быть создан")
+	}
+
+	other := New()
+	other.configPath = client.configPath
+	other.loadClientID()
+
+	if other.clientID != client.clientID {
+		t.Errorf("Ожидался сохраненный идентификатор %s, получен %s", client.clientID, other.clientID)
+	}
+}
+
+func TestClient_restoreRevision(t *testing.T) {
+	var gotPath, gotClientID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.Method + " " + r.URL.Path
+		gotClientID = r.Header.Get(clientIDHeader)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+	client.clientID = "laptop"
+
+	client.restoreRevision("data-id", "revision-id")
+
+	if gotPath != "POST /api/v1/data/data-id/history/revision-id/restore" {
+		t.Errorf("Неожиданный запрос %s", gotPath)
+	}
+
+	if gotClientID != "laptop" {
+		t.Errorf("Ожидался заголовок %s laptop, получено %s", clientIDHeader, gotClientID)
+	}
+}
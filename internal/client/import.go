@@ -0,0 +1,284 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/importer"
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// importBatchSize ограничивает количество записей в одном запросе импорта.
+// Совпадает с ограничением сервера.
+const importBatchSize = 5000
+
+// importResponse представляет ответ сервера на запрос импорта.
+type importResponse struct {
+	Created int `json:"created"`
+	Failed  int `json:"failed"`
+	Results []struct {
+		Index int    `json:"index"`
+		Error string `json:"error"`
+	} `json:"results"`
+}
+
+// createImportCommand создает команду импорта записей из других менеджеров паролей.
+func (c *Client) createImportCommand() *cobra.Command {
+	formats := make([]string, 0, len(importer.Formats))
+	for _, format := range importer.Formats {
+		formats = append(formats, string(format))
+	}
+
+	importCmd := &cobra.Command{
+		Use:   "import [format] [file]",
+		Short: "Импортировать записи из другого менеджера паролей",
+		Long: "Импортирует записи из экспорта другого менеджера паролей. Форматы: " + strings.Join(formats, ", ") + ". " +
+			"Записи, совпадающие с существующими по типу, названию и логину, пропускаются. " +
+			"С флагом --from-backup восстанавливает хранилище из резервной копии, сохраненной командой export",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if path, _ := cmd.Flags().GetString("from-backup"); path != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
+		ValidArgs: formats,
+		Run: func(cmd *cobra.Command, args []string) {
+			if path, _ := cmd.Flags().GetString("from-backup"); path != "" {
+				mode, _ := cmd.Flags().GetString("mode")
+				password, _ := cmd.Flags().GetString("password")
+				c.restoreBackup(path, mode, password)
+				return
+			}
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			keepDuplicates, _ := cmd.Flags().GetBool("keep-duplicates")
+			c.importData(importer.Format(args[0]), args[1], dryRun, keepDuplicates)
+		},
+	}
+	importCmd.Flags().Bool("dry-run", false, "Показать, что будет импортировано, ничего не изменяя")
+	importCmd.Flags().Bool("keep-duplicates", false, "Импортировать и записи, которые уже есть")
+	importCmd.Flags().String("from-backup", "", "Восстановить хранилище из резервной копии")
+	importCmd.Flags().String("mode", "merge", "Режим восстановления копии: merge - добавить недостающие записи, replace - заменить хранилище копией")
+	importCmd.Flags().String("password", "", "Пароль резервной копии (или "+backupPasswordEnv+")")
+	return importCmd
+}
+
+// importData импортирует записи из файла path в формате format. Папки, которых еще нет,
+// создаются, а записи загружаются на сервер пакетами.
+func (c *Client) importData(format importer.Format, path string, dryRun, keepDuplicates bool) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Printf("Ошибка чтения файла: %v\n", err)
+		return
+	}
+	items, err := importer.Parse(format, content)
+	if err != nil {
+		fmt.Printf("Ошибка разбора файла: %v\n", err)
+		return
+	}
+
+	existing, err := c.fetchDataPages("/api/v1/data")
+	if errors.Is(err, errOffline) && dryRun {
+		existing, err = c.cachedItems()
+	}
+	if err != nil {
+		fmt.Printf("Ошибка получения данных: %v\n", err)
+		return
+	}
+
+	var duplicates []importer.Item
+	if !keepDuplicates {
+		// Логин записей со сквозным шифрованием хранится в зашифрованном содержимом
+		for i := range existing {
+			c.openSecrets(&existing[i])
+		}
+		items, duplicates = splitDuplicates(items, existing)
+	}
+
+	if dryRun {
+		printImportPlan(items, duplicates)
+		return
+	}
+	if len(items) == 0 {
+		fmt.Printf("Новых записей нет, пропущено дубликатов: %d\n", len(duplicates))
+		return
+	}
+
+	requests, err := c.importRequests(items)
+	if err != nil {
+		fmt.Printf("Ошибка импорта: %v\n", err)
+		return
+	}
+
+	created, failed := 0, 0
+	for start := 0; start < len(requests); start += importBatchSize {
+		end := min(start+importBatchSize, len(requests))
+		resp, err := c.uploadImport(requests[start:end])
+		if err != nil {
+			fmt.Printf("Ошибка импорта: %v\n", err)
+			break
+		}
+		created += resp.Created
+		failed += resp.Failed
+		for _, result := range resp.Results {
+			if result.Error != "" {
+				fmt.Printf("- %s: %s\n", items[start+result.Index].Name, result.Error)
+			}
+		}
+	}
+
+	fmt.Printf("Импортировано записей: %d, с ошибками: %d, пропущено дубликатов: %d\n", created, failed, len(duplicates))
+}
+
+// importRequests создает недостающие папки и собирает запросы создания записей.
+// При сквозном шифровании секретные поля шифруются ключом хранилища.
+func (c *Client) importRequests(items []importer.Item) ([]map[string]interface{}, error) {
+	folders, err := c.fetchFolders()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения папок: %w", err)
+	}
+
+	folderIDs := make(map[string]string)
+	requests := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		req := map[string]interface{}{
+			"type": item.Type,
+			"name": item.Name,
+		}
+		if item.Login != "" {
+			req["login"] = item.Login
+		}
+		if item.Password != "" {
+			req["password"] = item.Password
+		}
+		if item.Text != "" {
+			req["text"] = item.Text
+		}
+		if item.Card != nil {
+			req["card"] = item.Card
+		}
+		if len(item.Metadata) > 0 {
+			req["metadata"] = item.Metadata
+		}
+		if len(item.Tags) > 0 {
+			req["tags"] = item.Tags
+		}
+		if item.Favorite {
+			req["favorite"] = true
+		}
+
+		if folder := displayPath(item.Folder); folder != "/" {
+			id, ok := folderIDs[folder]
+			if !ok {
+				id, folders, err = c.ensureFolder(folders, folder)
+				if err != nil {
+					return nil, fmt.Errorf("ошибка создания папки %s: %w", folder, err)
+				}
+				folderIDs[folder] = id
+			}
+			req["folder_id"] = id
+		}
+
+		id := uuid.NewString()
+		req["id"] = id
+		if err := c.sealSecrets(req, id, string(item.Type)); err != nil {
+			return nil, fmt.Errorf("ошибка шифрования данных: %w", err)
+		}
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
+
+// uploadImport отправляет пакет записей на сервер.
+func (c *Client) uploadImport(requests []map[string]interface{}) (*importResponse, error) {
+	resp, err := c.makeRequest("POST", "/api/v1/data/import", map[string]interface{}{"items": requests})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errOffline, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, errors.New(string(body))
+	}
+
+	var result importResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга ответа: %w", err)
+	}
+	return &result, nil
+}
+
+// cachedItems возвращает записи из локального кэша.
+func (c *Client) cachedItems() ([]dataItem, error) {
+	store, err := c.loadStore()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]dataItem, 0, len(store.Records))
+	for _, item := range store.Records {
+		items = append(items, *item)
+	}
+	return items, nil
+}
+
+// splitDuplicates отделяет записи, которые совпадают по типу, названию и логину
+// с собственными записями пользователя или с записями выше в том же файле.
+func splitDuplicates(items []importer.Item, existing []dataItem) ([]importer.Item, []importer.Item) {
+	seen := make(map[string]bool, len(existing)+len(items))
+	for _, item := range existing {
+		if item.Shared == nil {
+			seen[duplicateKey(item.Type, item.Name, item.Login)] = true
+		}
+	}
+
+	var unique, duplicates []importer.Item
+	for _, item := range items {
+		key := duplicateKey(string(item.Type), item.Name, item.Login)
+		if seen[key] {
+			duplicates = append(duplicates, item)
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, item)
+	}
+	return unique, duplicates
+}
+
+// duplicateKey возвращает ключ сравнения записей без учета регистра названия и логина.
+func duplicateKey(dataType, name, login string) string {
+	return dataType + "\x00" + strings.ToLower(strings.TrimSpace(name)) + "\x00" + strings.ToLower(strings.TrimSpace(login))
+}
+
+// printImportPlan выводит записи, которые будут импортированы, и пропущенные дубликаты.
+func printImportPlan(items, duplicates []importer.Item) {
+	fmt.Printf("Будет импортировано записей: %d\n", len(items))
+	for _, item := range items {
+		fmt.Printf("- %s: %s%s в %s\n", item.Type, item.Name, importLoginLabel(item), displayPath(item.Folder))
+	}
+	if len(duplicates) > 0 {
+		fmt.Printf("Будет пропущено дубликатов: %d\n", len(duplicates))
+		for _, item := range duplicates {
+			fmt.Printf("- %s: %s%s\n", item.Type, item.Name, importLoginLabel(item))
+		}
+	}
+}
+
+// importLoginLabel возвращает логин записи для вывода плана импорта.
+func importLoginLabel(item importer.Item) string {
+	if item.Login == "" {
+		return ""
+	}
+	return " (" + item.Login + ")"
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/importer"
+)
+
+// newImportServer создает сервер с одной существующей записью Gmail и фиксирует
+// запросы импорта и создания папок.
+func newImportServer(t *testing.T, imported *[]map[string]interface{}, folders *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/data":
+			w.Write([]byte(`[{"id":"1","type":"login_password","name":"Gmail","login":"user@gmail.com"}]`))
+		case r.Method == "GET" && r.URL.Path == "/api/v1/folders":
+			w.Write([]byte(`[]`))
+		case r.Method == "POST" && r.URL.Path == "/api/v1/folders":
+			var req map[string]string
+			json.NewDecoder(r.Body).Decode(&req)
+			*folders = append(*folders, req["name"])
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"folder-` + req["name"] + `","name":"` + req["name"] + `"}`))
+		case r.Method == "POST" && r.URL.Path == "/api/v1/data/import":
+			var req struct {
+				Items []map[string]interface{} `json:"items"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			*imported = append(*imported, req.Items...)
+			w.Write([]byte(`{"created":1,"failed":0,"results":[{"index":0,"id":"2"}]}`))
+		default:
+			t.Errorf("Неожиданный запрос: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+}
+
+func TestClient_importData(t *testing.T) {
+	var imported []map[string]interface{}
+	var folders []string
+	server := newImportServer(t, &imported, &folders)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "bitwarden.json")
+	os.WriteFile(path, []byte(`{"folders": [{"id": "f1", "name": "Work"}], "items": [
+		{"type": 1, "name": "gmail", "login": {"username": "USER@gmail.com", "password": "secret"}},
+		{"type": 1, "name": "GitHub", "folderId": "f1", "favorite": true, "login": {"username": "octocat", "password": "secret"}},
+		{"type": 1, "name": "GitHub", "folderId": "f1", "login": {"username": "octocat", "password": "other"}}
+	]}`), 0600)
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+
+	client.importData("bitwarden", path, true, false)
+	if len(imported) != 0 || len(folders) != 0 {
+		t.Fatalf("Пробный запуск не должен ничего изменять: %v, %v", imported, folders)
+	}
+
+	// Gmail уже есть на сервере, а вторая запись GitHub повторяет первую
+	client.importData("bitwarden", path, false, false)
+	if len(imported) != 1 || imported[0]["name"] != "GitHub" || imported[0]["folder_id"] != "folder-Work" || imported[0]["favorite"] != true {
+		t.Fatalf("Ожидался импорт одной записи GitHub в папку Work, получено %v", imported)
+	}
+	if len(folders) != 1 || folders[0] != "Work" {
+		t.Errorf("Ожидалось создание папки Work, создано %v", folders)
+	}
+}
+
+func TestSplitDuplicates_SharedRecords(t *testing.T) {
+	existing := []dataItem{{Type: "login_password", Name: "Gmail", Login: "user", Shared: &sharedAccess{Owner: "alice"}}}
+	items := []importer.Item{{Type: "login_password", Name: "Gmail", Login: "user"}}
+
+	// Записи, открытые другими пользователями, не считаются дубликатами
+	unique, duplicates := splitDuplicates(items, existing)
+	if len(unique) != 1 || len(duplicates) != 0 {
+		t.Errorf("Ожидалась 1 новая запись, получено %d новых и %d дубликатов", len(unique), len(duplicates))
+	}
+}
@@ -0,0 +1,102 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dataCard представляет данные банковской карты в ответе сервера.
+type dataCard struct {
+	Number string `json:"number"`
+	Expiry string `json:"expiry"`
+	CVV    string `json:"cvv"`
+	Holder string `json:"holder"`
+}
+
+// dataItem представляет запись данных в ответе сервера.
+type dataItem struct {
+	ID       string    `json:"id"`
+	Type     string    `json:"type"`
+	Name     string    `json:"name"`
+	Login    string    `json:"login"`
+	Password string    `json:"password"`
+	Text     string    `json:"text"`
+	Card     *dataCard `json:"card"`
+	Binary   []byte    `json:"binary"`
+	Metadata string    `json:"metadata"`
+	FolderID string    `json:"folder_id"`
+	Tags     []string  `json:"tags"`
+	Favorite bool      `json:"favorite"`
+	VaultID  string    `json:"vault_id,omitempty"` // Командное хранилище записи, пусто для личных записей
+
+	Version          int64         `json:"version"`
+	EncryptedPayload string        `json:"encrypted_payload"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+	Deleted          bool          `json:"deleted"`
+	Shared           *sharedAccess `json:"shared,omitempty"`
+}
+
+// vaultLabel возвращает пометку записи командного хранилища для списка.
+func (item *dataItem) vaultLabel() string {
+	if item.VaultID == "" {
+		return ""
+	}
+	return " [хранилище " + item.VaultID + "]"
+}
+
+// printDataList выводит краткий список записей.
+func printDataList(data []dataItem) {
+	if len(data) == 0 {
+		fmt.Println("Данные не найдены")
+		return
+	}
+
+	fmt.Printf("Найдено %d записей:\n", len(data))
+	for _, item := range data {
+		fmt.Printf("- ID: %s, Тип: %s, Название: %s, Логин: %s%s%s%s\n",
+			item.ID, item.Type, item.Name, item.Login, item.vaultLabel(), item.Shared.label(), item.organizeLabel())
+	}
+}
+
+// printDataItem выводит запись данных в зависимости от ее типа.
+func printDataItem(item *dataItem) {
+	fmt.Printf("ID: %s\n", item.ID)
+	fmt.Printf("Название: %s\n", item.Name)
+	fmt.Printf("Тип: %s\n", item.Type)
+	if item.Shared != nil {
+		fmt.Printf("Владелец: %s (%s)\n", item.Shared.Owner, item.Shared.describe())
+	}
+	if item.Favorite {
+		fmt.Println("В избранном")
+	}
+	if len(item.Tags) > 0 {
+		fmt.Printf("Метки: %s\n", strings.Join(item.Tags, ", "))
+	}
+
+	switch item.Type {
+	case "text":
+		fmt.Printf("Текст: %s\n", item.Text)
+	case "card":
+		if item.Card != nil {
+			fmt.Printf("Номер: %s\n", item.Card.Number)
+			fmt.Printf("Срок действия: %s\n", item.Card.Expiry)
+			fmt.Printf("CVV: %s\n", item.Card.CVV)
+			if item.Card.Holder != "" {
+				fmt.Printf("Держатель: %s\n", item.Card.Holder)
+			}
+		}
+	case "binary":
+		fmt.Printf("Размер: %d байт (используйте -o для сохранения в файл)\n", len(item.Binary))
+	case "file":
+		fmt.Printf("Файл: используйте data download %s -o <path> для скачивания\n", item.ID)
+	default:
+		fmt.Printf("Логин: %s\n", item.Login)
+		fmt.Printf("Пароль: %s\n", item.Password)
+	}
+
+	if strings.TrimSpace(item.Metadata) != "" {
+		fmt.Printf("Метаданные: %s\n", item.Metadata)
+	}
+}
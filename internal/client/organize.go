@@ -0,0 +1,359 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// folderItem представляет папку в ответе сервера.
+type folderItem struct {
+	ID       string `json:"id"`
+	ParentID string `json:"parent_id"`
+	Name     string `json:"name"`
+}
+
+// createOrganizeCommands создает команды раскладывания записей по меткам, папкам и избранному.
+func (c *Client) createOrganizeCommands() []*cobra.Command {
+	tagCmd := &cobra.Command{
+		Use:   "tag [id] [tag...]",
+		Short: "Добавить записи метки или показать ее метки",
+		Long:  "Добавляет записи метки. С флагом --remove снимает указанные метки. Без меток выводит метки записи",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			remove, _ := cmd.Flags().GetBool("remove")
+			c.tagData(args[0], args[1:], remove)
+		},
+	}
+	tagCmd.Flags().Bool("remove", false, "Снять указанные метки")
+
+	mvCmd := &cobra.Command{
+		Use:   "mv [id] [folder]",
+		Short: "Перенести запись в папку",
+		Long:  "Переносит запись в папку по пути вида Work/Servers, недостающие папки создаются. Путь / переносит запись в корень",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			c.moveData(args[0], args[1])
+		},
+	}
+
+	favoriteCmd := &cobra.Command{
+		Use:   "favorite [id]",
+		Short: "Добавить запись в избранное",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			remove, _ := cmd.Flags().GetBool("remove")
+			c.setFavorite(args[0], !remove)
+		},
+	}
+	favoriteCmd.Flags().Bool("remove", false, "Убрать запись из избранного")
+
+	folderCmd := &cobra.Command{
+		Use:   "folder",
+		Short: "Команды для работы с папками",
+	}
+	folderRmCmd := &cobra.Command{
+		Use:   "rm [folder]",
+		Short: "Удалить пустую папку",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c.removeFolder(args[0])
+		},
+	}
+	folderCmd.AddCommand(folderRmCmd)
+
+	return []*cobra.Command{tagCmd, mvCmd, favoriteCmd, folderCmd}
+}
+
+// tagData добавляет записи id метки tags или снимает их, если remove установлен.
+// Без меток выводит текущие метки записи.
+func (c *Client) tagData(id string, tags []string, remove bool) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	item, offline, err := c.fetchItem(id)
+	if err != nil {
+		fmt.Printf("Ошибка получения данных: %v\n", err)
+		return
+	}
+	if len(tags) == 0 {
+		if len(item.Tags) == 0 {
+			fmt.Println("У записи нет меток")
+			return
+		}
+		fmt.Printf("Метки: %s\n", strings.Join(item.Tags, ", "))
+		return
+	}
+	if offline {
+		fmt.Println("Сервер недоступен, метки можно изменить только при подключении")
+		return
+	}
+
+	result := slices.Clone(item.Tags)
+	for _, tag := range tags {
+		if remove {
+			result = slices.DeleteFunc(result, func(t string) bool { return t == tag })
+		} else if !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	if result == nil {
+		result = []string{}
+	}
+
+	updated, err := c.organize(id, "tags", map[string]interface{}{"tags": result})
+	if err != nil {
+		fmt.Printf("Ошибка изменения меток: %v\n", err)
+		return
+	}
+	if len(updated.Tags) == 0 {
+		fmt.Println("Метки сняты")
+		return
+	}
+	fmt.Printf("Метки записи: %s\n", strings.Join(updated.Tags, ", "))
+}
+
+// moveData переносит запись id в папку по пути path, создавая недостающие папки.
+func (c *Client) moveData(id, path string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	folders, err := c.fetchFolders()
+	if err != nil {
+		fmt.Printf("Ошибка получения папок: %v\n", err)
+		return
+	}
+	folderID, _, err := c.ensureFolder(folders, path)
+	if err != nil {
+		fmt.Printf("Ошибка создания папки: %v\n", err)
+		return
+	}
+
+	var target interface{}
+	if folderID != "" {
+		target = folderID
+	}
+	if _, err := c.organize(id, "folder", map[string]interface{}{"folder_id": target}); err != nil {
+		fmt.Printf("Ошибка переноса записи: %v\n", err)
+		return
+	}
+	fmt.Printf("Запись перенесена в %s\n", displayPath(path))
+}
+
+// setFavorite добавляет запись id в избранное или убирает из него.
+func (c *Client) setFavorite(id string, favorite bool) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	if _, err := c.organize(id, "favorite", map[string]interface{}{"favorite": favorite}); err != nil {
+		fmt.Printf("Ошибка изменения избранного: %v\n", err)
+		return
+	}
+	if favorite {
+		fmt.Println("Запись добавлена в избранное")
+		return
+	}
+	fmt.Println("Запись убрана из избранного")
+}
+
+// removeFolder удаляет пустую папку по пути path.
+func (c *Client) removeFolder(path string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	folders, err := c.fetchFolders()
+	if err != nil {
+		fmt.Printf("Ошибка получения папок: %v\n", err)
+		return
+	}
+	folderID, missing := findFolder(folders, path)
+	if folderID == "" || len(missing) > 0 {
+		fmt.Printf("Папка %s не найдена\n", displayPath(path))
+		return
+	}
+
+	c.trashRequest("DELETE", "/api/v1/folders/"+folderID, "Папка удалена", "Ошибка удаления папки")
+}
+
+// organize отправляет изменение меток, папки или избранного записи id и возвращает обновленную запись.
+func (c *Client) organize(id, field string, body map[string]interface{}) (*dataItem, error) {
+	resp, err := c.makeRequest("PUT", "/api/v1/data/"+id+"/"+field, body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errOffline, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, errors.New(string(respBody))
+	}
+
+	var item dataItem
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга ответа: %w", err)
+	}
+	return &item, nil
+}
+
+// fetchFolders загружает все папки пользователя.
+func (c *Client) fetchFolders() ([]folderItem, error) {
+	resp, err := c.makeRequest("GET", "/api/v1/folders", nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errOffline, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, errors.New(string(body))
+	}
+
+	var folders []folderItem
+	if err := json.NewDecoder(resp.Body).Decode(&folders); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга ответа: %w", err)
+	}
+	return folders, nil
+}
+
+// ensureFolder возвращает ID папки по пути path, создавая недостающие папки,
+// и список папок вместе с созданными. Для корня возвращает пустой ID.
+func (c *Client) ensureFolder(folders []folderItem, path string) (string, []folderItem, error) {
+	parentID, missing := findFolder(folders, path)
+	for _, name := range missing {
+		req := map[string]interface{}{"name": name}
+		if parentID != "" {
+			req["parent_id"] = parentID
+		}
+
+		resp, err := c.makeRequest("POST", "/api/v1/folders", req)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", errOffline, err)
+		}
+		var folder folderItem
+		if resp.StatusCode != http.StatusCreated {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return "", nil, errors.New(string(body))
+		}
+		err = json.NewDecoder(resp.Body).Decode(&folder)
+		resp.Body.Close()
+		if err != nil {
+			return "", nil, fmt.Errorf("ошибка парсинга ответа: %w", err)
+		}
+		folders = append(folders, folder)
+		parentID = folder.ID
+	}
+	return parentID, folders, nil
+}
+
+// findFolder находит самую глубокую существующую папку на пути path и возвращает ее ID
+// вместе с именами недостающих папок. Для корня возвращает пустой ID.
+func findFolder(folders []folderItem, path string) (string, []string) {
+	parts := splitPath(path)
+	parentID := ""
+	for i, name := range parts {
+		found := false
+		for _, folder := range folders {
+			if folder.ParentID == parentID && folder.Name == name {
+				parentID, found = folder.ID, true
+				break
+			}
+		}
+		if !found {
+			return parentID, parts[i:]
+		}
+	}
+	return parentID, nil
+}
+
+// splitPath разбивает путь папки на имена, пропуская пустые части.
+func splitPath(path string) []string {
+	var parts []string
+	for _, part := range strings.Split(path, "/") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// displayPath возвращает путь папки для вывода.
+func displayPath(path string) string {
+	return "/" + strings.Join(splitPath(path), "/")
+}
+
+// printDataTree выводит папки и записи деревом. Записи из неизвестных папок,
+// например удаленных, выводятся в корне.
+func printDataTree(folders []folderItem, data []dataItem) {
+	known := make(map[string]bool, len(folders))
+	for _, folder := range folders {
+		known[folder.ID] = true
+	}
+
+	children := make(map[string][]folderItem)
+	for _, folder := range folders {
+		parent := folder.ParentID
+		if !known[parent] {
+			parent = ""
+		}
+		children[parent] = append(children[parent], folder)
+	}
+	records := make(map[string][]dataItem)
+	for _, item := range data {
+		folder := item.FolderID
+		if !known[folder] {
+			folder = ""
+		}
+		records[folder] = append(records[folder], item)
+	}
+
+	var walk func(parent string, depth int)
+	walk = func(parent string, depth int) {
+		indent := strings.Repeat("  ", depth)
+		sub := children[parent]
+		sort.Slice(sub, func(i, j int) bool { return sub[i].Name < sub[j].Name })
+		for _, folder := range sub {
+			fmt.Printf("%s%s/\n", indent, folder.Name)
+			walk(folder.ID, depth+1)
+		}
+		items := records[parent]
+		sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+		for _, item := range items {
+			fmt.Printf("%s- %s (ID: %s, Тип: %s)%s\n", indent, item.Name, item.ID, item.Type, item.organizeLabel())
+		}
+	}
+
+	if len(data) == 0 && len(folders) == 0 {
+		fmt.Println("Данные не найдены")
+		return
+	}
+	walk("", 0)
+}
+
+// organizeLabel возвращает отметки избранного и метки записи для списка записей.
+func (item *dataItem) organizeLabel() string {
+	label := ""
+	if item.Favorite {
+		label += " [избранное]"
+	}
+	if len(item.Tags) > 0 {
+		label += " #" + strings.Join(item.Tags, " #")
+	}
+	return label
+}
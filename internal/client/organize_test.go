@@ -0,0 +1,104 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestFindFolder(t *testing.T) {
+	folders := []folderItem{
+		{ID: "work", Name: "Work"},
+		{ID: "servers", ParentID: "work", Name: "Servers"},
+		{ID: "home-servers", ParentID: "home", Name: "Servers"},
+	}
+
+	tests := []struct {
+		path    string
+		id      string
+		missing []string
+	}{
+		{"/", "", nil},
+		{"Work", "work", nil},
+		{"/Work/Servers/", "servers", nil},
+		{"Work/Servers/Prod", "servers", []string{"Prod"}},
+		{"Home/Servers", "", []string{"Home", "Servers"}},
+	}
+	for _, tt := range tests {
+		id, missing := findFolder(folders, tt.path)
+		if id != tt.id || !slices.Equal(missing, tt.missing) {
+			t.Errorf("findFolder(%q) = %q, %v, ожидалось %q, %v", tt.path, id, missing, tt.id, tt.missing)
+		}
+	}
+}
+
+func TestClient_moveData_CreatesFolders(t *testing.T) {
+	var created []map[string]string
+	var moved map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/folders":
+			w.Write([]byte(`[{"id":"work","name":"Work"}]`))
+		case r.Method == "POST" && r.URL.Path == "/api/v1/folders":
+			var req map[string]string
+			json.NewDecoder(r.Body).Decode(&req)
+			created = append(created, req)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"new-` + req["name"] + `","name":"` + req["name"] + `"}`))
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/data/1/folder":
+			json.NewDecoder(r.Body).Decode(&moved)
+			w.Write([]byte(`{"id":"1"}`))
+		default:
+			t.Errorf("Неожиданный запрос: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+
+	client.moveData("1", "Work/Servers/Prod")
+
+	if len(created) != 2 || created[0]["name"] != "Servers" || created[0]["parent_id"] != "work" ||
+		created[1]["name"] != "Prod" || created[1]["parent_id"] != "new-Servers" {
+		t.Errorf("Ожидалось создание папок Servers и Prod, создано: %v", created)
+	}
+	if moved["folder_id"] != "new-Prod" {
+		t.Errorf("Ожидался перенос в папку new-Prod, получено: %v", moved)
+	}
+}
+
+func TestClient_tagData(t *testing.T) {
+	var sent struct {
+		Tags []string `json:"tags"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/data/1":
+			w.Write([]byte(`{"id":"1","name":"Gmail","tags":["mail","work"]}`))
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/data/1/tags":
+			json.NewDecoder(r.Body).Decode(&sent)
+			w.Write([]byte(`{"id":"1","tags":["mail","personal"]}`))
+		default:
+			t.Errorf("Неожиданный запрос: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+
+	client.tagData("1", []string{"work"}, true)
+	if !slices.Equal(sent.Tags, []string{"mail"}) {
+		t.Errorf("Ожидалось снятие метки work, отправлено: %v", sent.Tags)
+	}
+
+	client.tagData("1", []string{"personal", "mail"}, false)
+	if !slices.Equal(sent.Tags, []string{"mail", "work", "personal"}) {
+		t.Errorf("Ожидалось добавление метки personal, отправлено: %v", sent.Tags)
+	}
+}
@@ -0,0 +1,182 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+// searchResult представляет найденную запись в ответе сервера.
+type searchResult struct {
+	dataItem
+	Rank float64 `json:"rank"`
+}
+
+// createSearchCommand создает команду поиска записей.
+func (c *Client) createSearchCommand() *cobra.Command {
+	searchCmd := &cobra.Command{
+		Use:   "search [query]",
+		Short: "Найти записи по названию, логину и метаданным",
+		Long: "Ищет записи на сервере по словам запроса. Если сервер ничего не нашел или недоступен, " +
+			"записи подбираются по неточному совпадению: символы запроса должны встречаться в названии " +
+			"или логине в том же порядке, например \"gml\" найдет \"Gmail\"",
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			limit, _ := cmd.Flags().GetInt("limit")
+			c.searchData(strings.Join(args, " "), limit)
+		},
+	}
+	searchCmd.Flags().Int("limit", 20, "Максимальное количество результатов")
+	return searchCmd
+}
+
+// searchData выводит записи, подходящие под запрос. Сначала используется поиск на сервере,
+// а если он ничего не нашел, записи подбираются неточным совпадением по полному списку.
+// Без подключения к серверу поиск выполняется по локальному кэшу.
+func (c *Client) searchData(query string, limit int) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	results, err := c.fetchSearch(query, limit)
+	if err != nil && !errors.Is(err, errOffline) {
+		fmt.Printf("Ошибка поиска: %v\n", err)
+		return
+	}
+	if err == nil && len(results) > 0 {
+		data := make([]dataItem, 0, len(results))
+		for _, result := range results {
+			data = append(data, result.dataItem)
+		}
+		printDataList(data)
+		return
+	}
+
+	var data []dataItem
+	if err == nil {
+		data, err = c.fetchDataPages("/api/v1/data")
+	}
+	if errors.Is(err, errOffline) {
+		store, storeErr := c.loadStore()
+		if storeErr != nil {
+			fmt.Printf("Ошибка поиска: %v\n", storeErr)
+			return
+		}
+		data = data[:0]
+		for _, item := range store.Records {
+			data = append(data, *item)
+		}
+		fmt.Println("Сервер недоступен, поиск выполнен по локальному кэшу")
+	} else if err != nil {
+		fmt.Printf("Ошибка поиска: %v\n", err)
+		return
+	}
+
+	printDataList(fuzzyFilter(data, query, limit))
+}
+
+// fetchSearch запрашивает поиск записей на сервере.
+func (c *Client) fetchSearch(query string, limit int) ([]searchResult, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	resp, err := c.makeRequest("GET", "/api/v1/data/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errOffline, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, errors.New(string(body))
+	}
+
+	var results []searchResult
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга ответа: %w", err)
+	}
+	return results, nil
+}
+
+// fuzzyFilter возвращает не больше limit записей, название или логин которых неточно
+// совпадают с запросом, начиная с лучших совпадений. Совпадение в названии важнее,
+// чем в логине.
+func fuzzyFilter(data []dataItem, query string, limit int) []dataItem {
+	type match struct {
+		item  dataItem
+		score int
+	}
+
+	var matches []match
+	for _, item := range data {
+		score, ok := fuzzyScore(query, item.Name)
+		if loginScore, loginOK := fuzzyScore(query, item.Login); loginOK && (!ok || loginScore/2 > score) {
+			score, ok = loginScore/2, true
+		}
+		if ok {
+			matches = append(matches, match{item: item, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].item.Name < matches[j].item.Name
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	result := make([]dataItem, 0, len(matches))
+	for _, m := range matches {
+		result = append(result, m.item)
+	}
+	return result
+}
+
+// fuzzyScore проверяет, что символы pattern без учета регистра и пробелов встречаются
+// в text в том же порядке, и оценивает совпадение. Символы, идущие подряд или с начала
+// слова, повышают оценку.
+func fuzzyScore(pattern, text string) (int, bool) {
+	p := []rune(strings.ToLower(strings.Join(strings.Fields(pattern), "")))
+	t := []rune(strings.ToLower(text))
+	if len(p) == 0 {
+		return 0, false
+	}
+
+	score, pi := 0, 0
+	prev := -2
+	for ti := 0; ti < len(t) && pi < len(p); ti++ {
+		if t[ti] != p[pi] {
+			continue
+		}
+		score++
+		if ti == prev+1 {
+			score += 5
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 3
+		}
+		prev = ti
+		pi++
+	}
+	if pi < len(p) {
+		return 0, false
+	}
+	return score, true
+}
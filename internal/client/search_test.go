@@ -0,0 +1,77 @@
+// Package client содержит тесты для поиска записей.
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		ok            bool
+	}{
+		{"gml", "Gmail", true},
+		{"GH", "github", true},
+		{"work mail", "Workmail", true},
+		{"lmg", "Gmail", false},
+		{"", "Gmail", false},
+	}
+	for _, tt := range tests {
+		if _, ok := fuzzyScore(tt.pattern, tt.text); ok != tt.ok {
+			t.Errorf("fuzzyScore(%q, %q) = %v, ожидалось %v", tt.pattern, tt.text, ok, tt.ok)
+		}
+	}
+
+	// Символы подряд и с начала слов оцениваются выше разрозненных
+	exact, _ := fuzzyScore("bank", "My bank")
+	scattered, _ := fuzzyScore("bank", "Bitbucket and kanban")
+	if exact <= scattered {
+		t.Errorf("Совпадение подряд должно оцениваться выше: %d <= %d", exact, scattered)
+	}
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	data := []dataItem{
+		{Name: "Bitbucket and kanban"},
+		{Name: "Notes"},
+		{Name: "My bank"},
+		{Name: "Mail", Login: "bank-user"},
+	}
+
+	got := fuzzyFilter(data, "bank", 0)
+	if len(got) != 3 || got[0].Name != "My bank" {
+		t.Fatalf("Ожидались 3 записи, начиная с My bank, получено %+v", got)
+	}
+	if got = fuzzyFilter(data, "bank", 1); len(got) != 1 {
+		t.Errorf("Ожидалась 1 запись по лимиту, получено %d", len(got))
+	}
+}
+
+func TestClient_searchData_FuzzyFallback(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		if r.URL.Path == "/api/v1/data/search" {
+			if r.URL.Query().Get("q") != "gml" || r.URL.Query().Get("limit") != "5" {
+				t.Errorf("Неожиданные параметры поиска: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"id":"1","name":"Gmail"}]`))
+	}))
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+
+	// Сервер ничего не нашел, и клиент подбирает записи по полному списку
+	client.searchData("gml", 5)
+
+	if len(requests) != 2 || requests[0] != "/api/v1/data/search" || requests[1] != "/api/v1/data" {
+		t.Errorf("Ожидался поиск на сервере и загрузка списка, запросы: %v", requests)
+	}
+}
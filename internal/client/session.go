@@ -0,0 +1,209 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// publicPaths содержит запросы, не требующие токена доступа.
+// При ответе 401 на них токен не обновляется.
+var publicPaths = map[string]bool{
+	"/api/v1/register":  true,
+	"/api/v1/login":     true,
+	"/api/v1/login/2fa": true,
+	"/api/v1/refresh":   true,
+}
+
+// errSessionExpired возвращается, когда сессию не удалось продлить и нужно войти заново.
+var errSessionExpired = errors.New("сессия истекла или отозвана, войдите снова")
+
+// authResponse представляет ответ аутентификации.
+type authResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	SessionID    string `json:"session_id"`
+	KDFSalt      string `json:"kdf_salt"`
+	KDFCheck     string `json:"kdf_check"`
+	// TwoFactorRequired означает, что для завершения входа нужен одноразовый пароль.
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	User              struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+		Email    string `json:"email"`
+	} `json:"user"`
+}
+
+// sessionItem представляет сессию пользователя.
+type sessionItem struct {
+	ID         string    `json:"id"`
+	DeviceName string    `json:"device_name"`
+	IP         string    `json:"ip"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+// deviceName возвращает имя устройства для списка сессий.
+func deviceName() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return hostname
+}
+
+// startSession запоминает токены новой сессии.
+func (c *Client) startSession(authResp *authResponse) {
+	c.token = authResp.Token
+	c.refreshToken = authResp.RefreshToken
+	if err := c.saveToken(); err != nil {
+		fmt.Printf("Предупреждение: не удалось сохранить токен: %v\n", err)
+	}
+}
+
+// clearTokens удаляет токены из памяти и с диска.
+func (c *Client) clearTokens() {
+	c.token = ""
+	c.refreshToken = ""
+	if err := c.saveToken(); err != nil {
+		fmt.Printf("Предупреждение: не удалось сохранить токен: %v\n", err)
+	}
+}
+
+// refreshSession обменивает токен обновления на новую пару токенов.
+// Если сервер отказал в обновлении, токены удаляются и нужно войти заново.
+func (c *Client) refreshSession() error {
+	resp, err := c.makeRequest("POST", "/api/v1/refresh", map[string]string{"refresh_token": c.refreshToken})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		c.clearTokens()
+		return errSessionExpired
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("не удалось обновить токен: %s", string(body))
+	}
+
+	var authResp authResponse
+	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
+		return fmt.Errorf("не удалось обновить токен: %w", err)
+	}
+	c.startSession(&authResp)
+	return nil
+}
+
+// endSession отзывает текущую сессию на сервере.
+func (c *Client) endSession() error {
+	resp, err := c.makeRequest("POST", "/api/v1/logout", nil)
+	if err != nil {
+		if errors.Is(err, errSessionExpired) {
+			return nil
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.New(string(body))
+	}
+	return nil
+}
+
+// listSessions выводит активные сессии пользователя.
+func (c *Client) listSessions() {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	resp, err := c.makeRequest("GET", "/api/v1/sessions", nil)
+	if err != nil {
+		fmt.Printf("Ошибка получения сессий: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Ошибка получения сессий: %s\n", string(body))
+		return
+	}
+
+	var sessions []sessionItem
+	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+		fmt.Printf("Ошибка парсинга ответа: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Активных сессий: %d\n", len(sessions))
+	for _, session := range sessions {
+		current := ""
+		if session.Current {
+			current = " (текущая)"
+		}
+		fmt.Printf("- ID: %s, Устройство: %s, IP: %s, Активность: %s%s\n",
+			session.ID, session.DeviceName, session.IP, session.LastSeenAt.Local().Format("2006-01-02 15:04:05"), current)
+	}
+}
+
+// revokeSession отзывает сессию по ID.
+func (c *Client) revokeSession(id string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	resp, err := c.makeRequest("DELETE", "/api/v1/sessions/"+id, nil)
+	if err != nil {
+		fmt.Printf("Ошибка отзыва сессии: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Ошибка отзыва сессии: %s\n", string(body))
+		return
+	}
+	fmt.Println("Сессия отозвана")
+}
+
+// revokeAllSessions отзывает все сессии пользователя, включая текущую.
+func (c *Client) revokeAllSessions() {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	resp, err := c.makeRequest("DELETE", "/api/v1/sessions", nil)
+	if err != nil {
+		fmt.Printf("Ошибка отзыва сессий: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Ошибка отзыва сессий: %s\n", string(body))
+		return
+	}
+
+	var result struct {
+		Revoked int64 `json:"revoked"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	c.clearTokens()
+	fmt.Printf("Отозвано сессий: %d. Для продолжения работы войдите снова\n", result.Revoked)
+}
@@ -0,0 +1,89 @@
+// Package client содержит тесты для обновления токенов.
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newRefreshServer создает сервер, принимающий только токен fresh-token
+// и выдающий его в обмен на токен обновления refresh-1.
+func newRefreshServer(t *testing.T, bodies *[]string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/refresh", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["refresh_token"] != "refresh-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(authResponse{Token: "fresh-token", RefreshToken: "refresh-2"})
+	})
+	mux.HandleFunc("PUT /api/v1/files/id/chunks/0", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*bodies = append(*bodies, string(body))
+		if r.Header.Get("Authorization") != "Bearer fresh-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestClient_send_RefreshesExpiredToken(t *testing.T) {
+	var bodies []string
+	server := newRefreshServer(t, &bodies)
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.configPath = t.TempDir()
+	client.token = "expired-token"
+	client.refreshToken = "refresh-1"
+
+	resp, err := client.makeStreamRequest("PUT", "/api/v1/files/id/chunks/0", []byte("chunk"))
+	if err != nil {
+		t.Fatalf("Ошибка запроса: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Ожидался статус %d после обновления токена, получен %d", http.StatusNoContent, resp.StatusCode)
+	}
+
+	// Тело повторного запроса должно совпадать с исходным
+	if len(bodies) != 2 || bodies[0] != "chunk" || bodies[1] != "chunk" {
+		t.Errorf("Ожидались два запроса с телом chunk, получено %q", bodies)
+	}
+
+	reloaded := New()
+	reloaded.configPath = client.configPath
+	reloaded.loadToken()
+	if reloaded.token != "fresh-token" || reloaded.refreshToken != "refresh-2" {
+		t.Errorf("Ожидались сохраненные токены fresh-token и refresh-2, получено %q и %q", reloaded.token, reloaded.refreshToken)
+	}
+}
+
+func TestClient_send_ClearsRevokedSession(t *testing.T) {
+	var bodies []string
+	server := newRefreshServer(t, &bodies)
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.configPath = t.TempDir()
+	client.token = "expired-token"
+	client.refreshToken = "revoked"
+
+	if _, err := client.makeStreamRequest("PUT", "/api/v1/files/id/chunks/0", []byte("chunk")); err != errSessionExpired {
+		t.Errorf("Ожидалась ошибка %v, получено %v", errSessionExpired, err)
+	}
+
+	if client.token != "" || client.refreshToken != "" {
+		t.Error("Токены отозванной сессии должны удаляться")
+	}
+}
@@ -0,0 +1,164 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// sharedAccess описывает доступ к записи, которую пользователю открыл ее владелец.
+type sharedAccess struct {
+	Owner      string     `json:"owner"`
+	Permission string     `json:"permission"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// shareItem представляет доступ к записи, выданный другому пользователю.
+type shareItem struct {
+	ID         string     `json:"id"`
+	Username   string     `json:"username"`
+	Permission string     `json:"permission"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// describe возвращает права и срок доступа в виде текста.
+func (s *sharedAccess) describe() string {
+	text := "чтение и запись"
+	if s.Permission != "write" {
+		text = "только чтение"
+	}
+	if s.ExpiresAt != nil {
+		text += ", до " + s.ExpiresAt.Local().Format("2006-01-02 15:04")
+	}
+	return text
+}
+
+// label возвращает отметку открытой записи для списка записей.
+func (s *sharedAccess) label() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf(" [открыта %s, %s]", s.Owner, s.describe())
+}
+
+// createShareCommands создает команды управления доступом к записям.
+func (c *Client) createShareCommands() []*cobra.Command {
+	shareCmd := &cobra.Command{
+		Use:   "share [id] [username]",
+		Short: "Открыть запись другому пользователю",
+		Long:  "Открывает запись пользователю на чтение и запись. Повторный вызов заменяет права и срок доступа",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			readOnly, _ := cmd.Flags().GetBool("read-only")
+			expires, _ := cmd.Flags().GetDuration("expires")
+			c.shareData(args[0], args[1], readOnly, expires)
+		},
+	}
+	shareCmd.Flags().Bool("read-only", false, "Открыть запись только для чтения")
+	shareCmd.Flags().Duration("expires", 0, "Срок доступа, например 72h (по умолчанию бессрочно)")
+
+	sharesCmd := &cobra.Command{
+		Use:   "shares [id]",
+		Short: "Показать, кому открыта запись",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c.listShares(args[0])
+		},
+	}
+
+	unshareCmd := &cobra.Command{
+		Use:   "unshare [id] [share-id]",
+		Short: "Отозвать доступ к записи",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			c.trashRequest("DELETE", "/api/v1/data/"+args[0]+"/shares/"+args[1], "Доступ отозван", "Ошибка отзыва доступа")
+		},
+	}
+
+	return []*cobra.Command{shareCmd, sharesCmd, unshareCmd}
+}
+
+// shareData открывает запись id пользователю username.
+func (c *Client) shareData(id, username string, readOnly bool, expires time.Duration) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	req := map[string]interface{}{"username": username, "permission": "write"}
+	if readOnly {
+		req["permission"] = "read"
+	}
+	if expires > 0 {
+		req["expires_at"] = time.Now().Add(expires).UTC()
+	}
+
+	resp, err := c.makeRequest("POST", "/api/v1/data/"+id+"/shares", req)
+	if err != nil {
+		fmt.Printf("Ошибка открытия доступа: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Ошибка открытия доступа: %s\n", string(body))
+		return
+	}
+
+	var share shareItem
+	if err := json.NewDecoder(resp.Body).Decode(&share); err != nil {
+		fmt.Printf("Ошибка парсинга ответа: %v\n", err)
+		return
+	}
+
+	access := sharedAccess{Permission: share.Permission, ExpiresAt: share.ExpiresAt}
+	fmt.Printf("Запись открыта пользователю %s: %s (ID доступа: %s)\n", share.Username, access.describe(), share.ID)
+}
+
+// listShares выводит доступы к записи id.
+func (c *Client) listShares(id string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	resp, err := c.makeRequest("GET", "/api/v1/data/"+id+"/shares", nil)
+	if err != nil {
+		fmt.Printf("Ошибка получения доступов: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Ошибка получения доступов: %s\n", string(body))
+		return
+	}
+
+	var shares []shareItem
+	if err := json.NewDecoder(resp.Body).Decode(&shares); err != nil {
+		fmt.Printf("Ошибка парсинга ответа: %v\n", err)
+		return
+	}
+
+	if len(shares) == 0 {
+		fmt.Println("Запись никому не открыта")
+		return
+	}
+
+	for _, share := range shares {
+		access := sharedAccess{Permission: share.Permission, ExpiresAt: share.ExpiresAt}
+		status := ""
+		if share.ExpiresAt != nil && share.ExpiresAt.Before(time.Now()) {
+			status = " (истек)"
+		}
+		fmt.Printf("- ID: %s, Пользователь: %s, Доступ: %s%s\n", share.ID, share.Username, access.describe(), status)
+	}
+}
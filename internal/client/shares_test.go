@@ -0,0 +1,50 @@
+// Package client содержит тесты для совместного доступа к записям.
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_shareData(t *testing.T) {
+	var gotPath string
+	var gotBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.Method + " " + r.URL.Path
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"share-id","username":"friend","permission":"read"}`))
+	}))
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+
+	client.shareData("data-id", "friend", true, time.Hour)
+
+	if gotPath != "POST /api/v1/data/data-id/shares" {
+		t.Errorf("Неожиданный запрос %s", gotPath)
+	}
+	if gotBody["username"] != "friend" || gotBody["permission"] != "read" {
+		t.Errorf("Ожидался доступ на чтение для friend, получено %v", gotBody)
+	}
+	if _, ok := gotBody["expires_at"]; !ok {
+		t.Error("Срок доступа должен передаваться серверу")
+	}
+}
+
+func TestSharedAccess_label(t *testing.T) {
+	var own *sharedAccess
+	if own.label() != "" {
+		t.Errorf("Собственная запись не должна отмечаться, получено %q", own.label())
+	}
+
+	shared := &sharedAccess{Owner: "alice", Permission: "write"}
+	if got := shared.label(); got != " [открыта alice, чтение и запись]" {
+		t.Errorf("Неожиданная отметка %q", got)
+	}
+}
@@ -0,0 +1,270 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+)
+
+// Действия, которые ставятся в очередь при работе без сети.
+const (
+	opCreate = "create"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// localIDPrefix отмечает записи, созданные без сети и еще не получившие ID на сервере.
+const localIDPrefix = "local-"
+
+// localStore представляет локальную копию записей пользователя.
+// На диске хранится в файле vault.db, зашифрованном ключом из соседнего файла cache.key.
+// Шифрование не защищает кэш от того, кто может читать директорию конфигурации:
+// от него кэш, как и токен, защищают только права доступа к файлам.
+type localStore struct {
+	Records  map[string]*dataItem `json:"records"`
+	Pending  []pendingOp          `json:"pending"`
+	LastSync time.Time            `json:"last_sync"`
+}
+
+// pendingOp представляет изменение, сделанное без подключения к серверу.
+// Request хранится в том виде, в котором будет отправлен на сервер.
+// Для обновлений Changes содержит открытые изменения, а Base - версию записи,
+// на которой они основаны; они нужны для разрешения конфликта при синхронизации.
+type pendingOp struct {
+	Action   string                 `json:"action"`
+	RecordID string                 `json:"record_id"`
+	Request  map[string]interface{} `json:"request,omitempty"`
+	Changes  map[string]interface{} `json:"changes,omitempty"`
+	Base     *dataItem              `json:"base,omitempty"`
+	QueuedAt time.Time              `json:"queued_at"`
+}
+
+// newLocalStore создает пустое локальное хранилище.
+func newLocalStore() *localStore {
+	return &localStore{Records: make(map[string]*dataItem)}
+}
+
+// storeKey возвращает ключ шифрования локального хранилища - случайный ключ,
+// сохраненный в директории конфигурации. Ключ не зависит от мастер-пароля,
+// поэтому кэш остается читаемым при входе с мастер-паролем и без него.
+func (c *Client) storeKey() ([]byte, error) {
+	keyFile := filepath.Join(c.configPath, "cache.key")
+	if key, err := os.ReadFile(keyFile); err == nil && len(key) == crypto.VaultKeySize {
+		return key, nil
+	}
+
+	key := make([]byte, crypto.VaultKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(c.configPath, 0755); err != nil {
+		return nil, fmt.Errorf("не удалось создать директорию конфигурации: %w", err)
+	}
+	if err := os.WriteFile(keyFile, key, 0600); err != nil {
+		return nil, fmt.Errorf("не удалось сохранить ключ кэша: %w", err)
+	}
+	return key, nil
+}
+
+// loadStore загружает и расшифровывает локальное хранилище. Кэш, зашифрованный
+// раньше ключом хранилища, перешифровывается ключом кэша.
+func (c *Client) loadStore() (*localStore, error) {
+	blob, err := os.ReadFile(filepath.Join(c.configPath, "vault.db"))
+	if os.IsNotExist(err) {
+		return newLocalStore(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := c.storeKey()
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := crypto.OpenBlob(string(blob), key, nil)
+	reseal := false
+	if err != nil && c.vaultKey != nil {
+		plaintext, err = crypto.OpenBlob(string(blob), c.vaultKey, nil)
+		reseal = err == nil
+	}
+	if err != nil {
+		return nil, errors.New("не удалось расшифровать локальный кэш, выполните sync --reset")
+	}
+
+	store := newLocalStore()
+	if err := json.Unmarshal(plaintext, store); err != nil {
+		return nil, err
+	}
+	if store.Records == nil {
+		store.Records = make(map[string]*dataItem)
+	}
+
+	if reseal {
+		if err := c.saveStore(store); err != nil {
+			return nil, fmt.Errorf("не удалось перешифровать локальный кэш: %w", err)
+		}
+	}
+	return store, nil
+}
+
+// saveStore шифрует и сохраняет локальное хранилище.
+func (c *Client) saveStore(store *localStore) error {
+	plaintext, err := json.Marshal(store)
+	if err != nil {
+		return err
+	}
+
+	key, err := c.storeKey()
+	if err != nil {
+		return err
+	}
+
+	blob, err := crypto.SealBlob(plaintext, key, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.configPath, 0755); err != nil {
+		return fmt.Errorf("не удалось создать директорию конфигурации: %w", err)
+	}
+	return os.WriteFile(filepath.Join(c.configPath, "vault.db"), []byte(blob), 0600)
+}
+
+// resetStore удаляет локальное хранилище.
+func (c *Client) resetStore() error {
+	err := os.Remove(filepath.Join(c.configPath, "vault.db"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// queue добавляет изменение в очередь и применяет его к локальной копии.
+func (s *localStore) queue(op pendingOp) {
+	op.QueuedAt = time.Now()
+
+	switch op.Action {
+	case opCreate:
+		s.Records[op.RecordID] = itemFromRequest(op.RecordID, op.Request)
+	case opUpdate:
+		if item, ok := s.Records[op.RecordID]; ok {
+			applyRequest(item, op.Request)
+		}
+		// Несколько изменений одной записи отправляются одним запросом
+		if s.foldPending(op) {
+			return
+		}
+	case opDelete:
+		delete(s.Records, op.RecordID)
+		// Запись, еще не отправленная на сервер, удаляется вместе с ее изменениями
+		if isLocalID(op.RecordID) {
+			s.dropPending(op.RecordID)
+			return
+		}
+	}
+
+	s.Pending = append(s.Pending, op)
+}
+
+// foldPending объединяет обновление с ожидающими отправки созданием или обновлением
+// той же записи. Возвращает false, если таких изменений в очереди нет.
+func (s *localStore) foldPending(op pendingOp) bool {
+	for i := len(s.Pending) - 1; i >= 0; i-- {
+		pending := &s.Pending[i]
+		if pending.RecordID != op.RecordID {
+			continue
+		}
+		if pending.Action == opDelete {
+			return false
+		}
+
+		for key, value := range op.Request {
+			pending.Request[key] = value
+		}
+		if pending.Action == opUpdate && op.Changes != nil {
+			if pending.Changes == nil {
+				pending.Changes = make(map[string]interface{})
+			}
+			for key, value := range op.Changes {
+				pending.Changes[key] = value
+			}
+		}
+		pending.QueuedAt = op.QueuedAt
+		return true
+	}
+	return false
+}
+
+// dropPending удаляет из очереди все изменения записи.
+func (s *localStore) dropPending(recordID string) {
+	pending := s.Pending[:0]
+	for _, op := range s.Pending {
+		if op.RecordID != recordID {
+			pending = append(pending, op)
+		}
+	}
+	s.Pending = pending
+}
+
+// apply применяет изменение, полученное с сервера, к локальной копии.
+func (s *localStore) apply(item dataItem) {
+	if item.Deleted {
+		delete(s.Records, item.ID)
+		return
+	}
+	s.Records[item.ID] = &item
+}
+
+// newLocalID возвращает временный ID записи id, созданной без сети.
+func newLocalID(id string) string {
+	return localIDPrefix + id
+}
+
+// recordID возвращает ID, под которым запись хранится на сервере. Запись, созданная
+// без сети, получает на сервере ID из своего временного ID.
+func recordID(id string) string {
+	return strings.TrimPrefix(id, localIDPrefix)
+}
+
+// isLocalID проверяет, является ли ID временным.
+func isLocalID(id string) bool {
+	return len(id) > len(localIDPrefix) && id[:len(localIDPrefix)] == localIDPrefix
+}
+
+// itemFromRequest строит локальную запись из тела запроса создания.
+func itemFromRequest(id string, req map[string]interface{}) *dataItem {
+	item := &dataItem{ID: id}
+	applyRequest(item, req)
+	return item
+}
+
+// applyRequest применяет поля тела запроса к локальной записи.
+func applyRequest(item *dataItem, req map[string]interface{}) {
+	fields := make(map[string]interface{}, len(req))
+	for key, value := range req {
+		if key == "metadata" {
+			if raw, err := json.Marshal(value); err == nil {
+				item.Metadata = string(raw)
+			}
+			continue
+		}
+		fields[key] = value
+	}
+
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(raw, item)
+	item.UpdatedAt = time.Now()
+}
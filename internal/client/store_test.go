@@ -0,0 +1,153 @@
+// Package client содержит тесты для локального кэша.
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/google/uuid"
+)
+
+func TestClient_saveLoadStore(t *testing.T) {
+	client := New()
+	client.configPath = t.TempDir()
+
+	store := newLocalStore()
+	store.apply(dataItem{ID: "1", Type: "text", Name: "Note", Text: "secret"})
+
+	if err := client.saveStore(store); err != nil {
+		t.Fatalf("Ошибка сохранения кэша: %v", err)
+	}
+
+	loaded, err := client.loadStore()
+	if err != nil {
+		t.Fatalf("Ошибка загрузки кэша: %v", err)
+	}
+
+	if loaded.Records["1"] == nil || loaded.Records["1"].Text != "secret" {
+		t.Errorf("Ожидалась запись с текстом secret, получено %+v", loaded.Records["1"])
+	}
+}
+
+func TestClient_loadStore_WrongKey(t *testing.T) {
+	client := New()
+	client.configPath = t.TempDir()
+
+	if err := client.saveStore(newLocalStore()); err != nil {
+		t.Fatalf("Ошибка сохранения кэша: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(client.configPath, "cache.key"), make([]byte, crypto.VaultKeySize), 0600); err != nil {
+		t.Fatalf("Ошибка замены ключа кэша: %v", err)
+	}
+	if _, err := client.loadStore(); err == nil {
+		t.Error("Загрузка кэша с другим ключом должна возвращать ошибку")
+	}
+}
+
+func TestClient_loadStore_MasterPasswordSwitch(t *testing.T) {
+	client := New()
+	client.configPath = t.TempDir()
+
+	store := newLocalStore()
+	store.queue(pendingOp{Action: opDelete, RecordID: "1"})
+	if err := client.saveStore(store); err != nil {
+		t.Fatalf("Ошибка сохранения кэша: %v", err)
+	}
+
+	client.vaultKey = make([]byte, crypto.VaultKeySize)
+	loaded, err := client.loadStore()
+	if err != nil {
+		t.Fatalf("Кэш должен загружаться после входа с мастер-паролем: %v", err)
+	}
+
+	if len(loaded.Pending) != 1 {
+		t.Errorf("Ожидалось одно изменение в очереди, получено %d", len(loaded.Pending))
+	}
+}
+
+func TestClient_loadStore_LegacyVaultKey(t *testing.T) {
+	client := New()
+	client.configPath = t.TempDir()
+	client.vaultKey = make([]byte, crypto.VaultKeySize)
+
+	store := newLocalStore()
+	store.queue(pendingOp{Action: opDelete, RecordID: "1"})
+	plaintext, err := json.Marshal(store)
+	if err != nil {
+		t.Fatalf("Ошибка сериализации кэша: %v", err)
+	}
+	blob, err := crypto.SealBlob(plaintext, client.vaultKey, nil)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования кэша: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(client.configPath, "vault.db"), []byte(blob), 0600); err != nil {
+		t.Fatalf("Ошибка записи кэша: %v", err)
+	}
+
+	if _, err := client.loadStore(); err != nil {
+		t.Fatalf("Кэш, зашифрованный ключом хранилища, должен загружаться: %v", err)
+	}
+
+	// Кэш перешифрован ключом кэша и доступен без мастер-пароля
+	client.vaultKey = nil
+	loaded, err := client.loadStore()
+	if err != nil {
+		t.Fatalf("Ошибка загрузки перешифрованного кэша: %v", err)
+	}
+
+	if len(loaded.Pending) != 1 {
+		t.Errorf("Ожидалось одно изменение в очереди, получено %d", len(loaded.Pending))
+	}
+}
+
+func TestLocalStore_queue(t *testing.T) {
+	store := newLocalStore()
+
+	localID := newLocalID(uuid.NewString())
+	store.queue(pendingOp{Action: opCreate, RecordID: localID, Request: map[string]interface{}{
+		"type":     "text",
+		"name":     "Offline note",
+		"text":     "draft",
+		"metadata": map[string]interface{}{"folder": "work"},
+	}})
+	store.queue(pendingOp{Action: opUpdate, RecordID: localID, Request: map[string]interface{}{"text": "final"}})
+
+	item := store.Records[localID]
+	if item == nil || item.Text != "final" || item.Name != "Offline note" {
+		t.Fatalf("Ожидалась обновленная локальная запись, получено %+v", item)
+	}
+
+	if item.Metadata != `{"folder":"work"}` {
+		t.Errorf("Ожидались метаданные в виде JSON строки, получено %s", item.Metadata)
+	}
+
+	// Обновление записи, еще не отправленной на сервер, объединяется с ее созданием
+	if len(store.Pending) != 1 || store.Pending[0].Request["text"] != "final" {
+		t.Errorf("Ожидалось одно создание с обновленным текстом, получено %+v", store.Pending)
+	}
+
+	// Удаление записи, еще не отправленной на сервер, очищает ее изменения
+	store.queue(pendingOp{Action: opDelete, RecordID: localID})
+
+	if len(store.Pending) != 0 {
+		t.Errorf("Ожидалась пустая очередь, получено %d изменений", len(store.Pending))
+	}
+
+	if _, ok := store.Records[localID]; ok {
+		t.Error("Локальная запись должна быть удалена")
+	}
+}
+
+func TestLocalStore_applyDeleted(t *testing.T) {
+	store := newLocalStore()
+	store.apply(dataItem{ID: "1", Name: "Record"})
+	store.apply(dataItem{ID: "1", Deleted: true})
+
+	if _, ok := store.Records["1"]; ok {
+		t.Error("Удаленная на сервере запись должна быть удалена из кэша")
+	}
+}
@@ -0,0 +1,240 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// changesResponse представляет ответ сервера со списком изменений.
+type changesResponse struct {
+	Changes    []dataItem `json:"changes"`
+	ServerTime time.Time  `json:"server_time"`
+}
+
+// createSyncCommand создает команду синхронизации с сервером.
+func (c *Client) createSyncCommand() *cobra.Command {
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Синхронизировать локальный кэш с сервером",
+		Long:  "Отправляет на сервер изменения, сделанные без сети, и загружает изменения с сервера",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			reset, _ := cmd.Flags().GetBool("reset")
+			c.sync(reset)
+		},
+	}
+	syncCmd.Flags().Bool("reset", false, "Удалить локальный кэш и загрузить все записи заново")
+	return syncCmd
+}
+
+// sync выполняет двустороннюю синхронизацию локального кэша с сервером.
+func (c *Client) sync(reset bool) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	if reset {
+		if err := c.resetStore(); err != nil {
+			fmt.Printf("Ошибка удаления локального кэша: %v\n", err)
+			return
+		}
+	}
+
+	store, err := c.loadStore()
+	if err != nil {
+		fmt.Printf("Ошибка загрузки локального кэша: %v\n", err)
+		return
+	}
+
+	pushed, err := c.pushPending(store)
+	if saveErr := c.saveStore(store); saveErr != nil {
+		fmt.Printf("Ошибка сохранения локального кэша: %v\n", saveErr)
+		return
+	}
+	if err != nil {
+		fmt.Printf("Ошибка отправки изменений: %v\n", err)
+		return
+	}
+
+	pulled, err := c.pullChanges(store)
+	if err != nil {
+		fmt.Printf("Ошибка получения изменений: %v\n", err)
+		return
+	}
+
+	if err := c.saveStore(store); err != nil {
+		fmt.Printf("Ошибка сохранения локального кэша: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Синхронизация завершена: отправлено %d, получено %d изменений\n", pushed, pulled)
+}
+
+// pushPending отправляет на сервер изменения из очереди в порядке их появления.
+// Из очереди удаляются отправленные изменения и изменения, которые сервер окончательно
+// отклонил. При потере сети или временной ошибке сервера оставшиеся изменения сохраняются в очереди.
+func (c *Client) pushPending(store *localStore) (int, error) {
+	idMap := make(map[string]string)
+	pushed := 0
+
+	for i, op := range store.Pending {
+		// Запись, созданная без сети, получает на сервере ID из временного,
+		// даже если создание отправлено при прошлой синхронизации
+		recordID := recordID(op.RecordID)
+		if serverID, ok := idMap[op.RecordID]; ok {
+			recordID = serverID
+		}
+
+		if op.Action == opUpdate && op.Base != nil {
+			applied, err := c.pushUpdate(recordID, op.Base, op.Changes)
+			var serverErr *serverError
+			switch {
+			case errors.As(err, &serverErr) && serverErr.rejected():
+				fmt.Printf("Изменение %s записи %s отклонено сервером: %v\n", op.Action, op.RecordID, err)
+			case err != nil:
+				store.Pending = store.Pending[i:]
+				return pushed, err
+			case applied:
+				pushed++
+			default:
+				fmt.Printf("Изменения записи %s отменены, сохранена версия сервера\n", op.RecordID)
+			}
+			continue
+		}
+
+		var resp *http.Response
+		var err error
+		switch op.Action {
+		case opCreate:
+			resp, err = c.makeRequest("POST", "/api/v1/data", op.Request)
+		case opUpdate:
+			resp, err = c.makeRequest("PUT", "/api/v1/data/"+recordID, op.Request)
+		case opDelete:
+			resp, err = c.makeRequest("DELETE", "/api/v1/data/"+recordID, nil)
+		}
+		if err != nil {
+			store.Pending = store.Pending[i:]
+			return pushed, err
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			pushed++
+			if op.Action == opCreate {
+				var created dataItem
+				if err := json.Unmarshal(body, &created); err == nil {
+					idMap[op.RecordID] = created.ID
+				}
+				delete(store.Records, op.RecordID)
+			}
+		case op.Action == opDelete && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden):
+			// Запись уже удалена на сервере
+			pushed++
+		default:
+			serverErr := &serverError{Status: resp.StatusCode, Message: string(body)}
+			if !serverErr.rejected() {
+				store.Pending = store.Pending[i:]
+				return pushed, serverErr
+			}
+			fmt.Printf("Изменение %s записи %s отклонено сервером: %s\n", op.Action, op.RecordID, string(body))
+		}
+	}
+
+	store.Pending = nil
+	return pushed, nil
+}
+
+// pullChanges загружает с сервера изменения, сделанные после последней синхронизации.
+func (c *Client) pullChanges(store *localStore) (int, error) {
+	path := "/api/v1/data/changes"
+	if !store.LastSync.IsZero() {
+		path += "?since=" + url.QueryEscape(store.LastSync.Format(time.RFC3339Nano))
+	}
+
+	resp, err := c.makeRequest("GET", path, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("%s", string(body))
+	}
+
+	var changes changesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&changes); err != nil {
+		return 0, err
+	}
+
+	for _, item := range changes.Changes {
+		store.apply(item)
+	}
+	store.LastSync = changes.ServerTime
+
+	return len(changes.Changes), nil
+}
+
+// queueUpdate сохраняет обновление, сделанное без сети, вместе с версией записи,
+// на которой оно основано.
+func (c *Client) queueUpdate(id string, base *dataItem, changes map[string]interface{}) error {
+	req := copyRequest(changes)
+	if c.resealSecrets(req, base) {
+		mergeSecrets(req, base)
+	}
+	if err := c.sealSecrets(req, id, base.Type); err != nil {
+		return err
+	}
+
+	return c.queueOffline(pendingOp{Action: opUpdate, RecordID: id, Request: req, Changes: changes, Base: base})
+}
+
+// updateLocal применяет обновление к записи, созданной без сети и еще не отправленной на сервер.
+func (c *Client) updateLocal(id string, changes map[string]interface{}) error {
+	store, err := c.loadStore()
+	if err != nil {
+		return err
+	}
+
+	cached, ok := store.Records[id]
+	if !ok {
+		return fmt.Errorf("запись %s отсутствует в локальном кэше", id)
+	}
+	current := *cached
+	if err := c.openSecrets(&current); err != nil {
+		return err
+	}
+
+	req := copyRequest(changes)
+	if c.vaultKey != nil && hasSecretFields(req) {
+		mergeSecrets(req, &current)
+	}
+	if err := c.sealSecrets(req, id, current.Type); err != nil {
+		return err
+	}
+
+	store.queue(pendingOp{Action: opUpdate, RecordID: id, Request: req})
+	return c.saveStore(store)
+}
+
+// queueOffline сохраняет изменение, сделанное без сети, в локальный кэш.
+func (c *Client) queueOffline(op pendingOp) error {
+	store, err := c.loadStore()
+	if err != nil {
+		return err
+	}
+	store.queue(op)
+	return c.saveStore(store)
+}
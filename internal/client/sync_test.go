@@ -0,0 +1,175 @@
+// Package client содержит тесты для синхронизации с сервером.
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestClient_sync(t *testing.T) {
+	var created map[string]interface{}
+	serverTime := time.Now().UTC()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/data", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&created)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "server-id", "name": created["name"]})
+	})
+	mux.HandleFunc("GET /api/v1/data/changes", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(changesResponse{
+			Changes: []dataItem{
+				{ID: "server-id", Type: "text", Name: "Offline note", Text: "draft"},
+				{ID: "removed", Deleted: true},
+			},
+			ServerTime: serverTime,
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+	client.configPath = t.TempDir()
+
+	store := newLocalStore()
+	store.apply(dataItem{ID: "removed", Name: "Removed on server"})
+	localID := newLocalID(uuid.NewString())
+	store.queue(pendingOp{Action: opCreate, RecordID: localID, Request: map[string]interface{}{
+		"type": "text",
+		"name": "Offline note",
+		"text": "draft",
+	}})
+	if err := client.saveStore(store); err != nil {
+		t.Fatalf("Ошибка сохранения кэша: %v", err)
+	}
+
+	client.sync(false)
+
+	if created["name"] != "Offline note" {
+		t.Errorf("Ожидалась отправка записи, созданной без сети, получено %+v", created)
+	}
+
+	synced, err := client.loadStore()
+	if err != nil {
+		t.Fatalf("Ошибка загрузки кэша: %v", err)
+	}
+
+	if len(synced.Pending) != 0 {
+		t.Errorf("Ожидалась пустая очередь, получено %d изменений", len(synced.Pending))
+	}
+
+	if _, ok := synced.Records[localID]; ok {
+		t.Error("Временная запись должна быть заменена записью с сервера")
+	}
+
+	if synced.Records["server-id"] == nil {
+		t.Error("Запись с сервера должна быть в кэше")
+	}
+
+	if _, ok := synced.Records["removed"]; ok {
+		t.Error("Удаленная на сервере запись должна быть удалена из кэша")
+	}
+
+	if !synced.LastSync.Equal(serverTime) {
+		t.Errorf("Ожидалось время синхронизации %v, получено %v", serverTime, synced.LastSync)
+	}
+}
+
+func TestClient_sync_ServerError(t *testing.T) {
+	failures := 1
+	created := 0
+	deleted := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/data", func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Внутренняя ошибка сервера"})
+			return
+		}
+		created++
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "server-id"})
+	})
+	mux.HandleFunc("DELETE /api/v1/data/{id}", func(w http.ResponseWriter, r *http.Request) {
+		deleted++
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("GET /api/v1/data/changes", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(changesResponse{ServerTime: time.Now().UTC()})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+	client.configPath = t.TempDir()
+
+	store := newLocalStore()
+	store.apply(dataItem{ID: "removed", Name: "Removed offline"})
+	store.queue(pendingOp{Action: opCreate, RecordID: newLocalID(uuid.NewString()), Request: map[string]interface{}{
+		"type": "text",
+		"name": "Offline note",
+	}})
+	store.queue(pendingOp{Action: opDelete, RecordID: "removed"})
+	if err := client.saveStore(store); err != nil {
+		t.Fatalf("Ошибка сохранения кэша: %v", err)
+	}
+
+	client.sync(false)
+
+	failed, err := client.loadStore()
+	if err != nil {
+		t.Fatalf("Ошибка загрузки кэша: %v", err)
+	}
+
+	if len(failed.Pending) != 2 {
+		t.Fatalf("После ошибки сервера изменения должны остаться в очереди, получено %d", len(failed.Pending))
+	}
+
+	client.sync(false)
+
+	synced, err := client.loadStore()
+	if err != nil {
+		t.Fatalf("Ошибка загрузки кэша: %v", err)
+	}
+
+	if len(synced.Pending) != 0 {
+		t.Errorf("Ожидалась пустая очередь, получено %d изменений", len(synced.Pending))
+	}
+
+	if created != 1 || deleted != 1 {
+		t.Errorf("Ожидалось одно создание и одно удаление, получено %d и %d", created, deleted)
+	}
+}
+
+func TestClient_addData_Offline(t *testing.T) {
+	client := New()
+	client.baseURL = "http://127.0.0.1:1"
+	client.token = "test-token"
+	client.configPath = t.TempDir()
+
+	client.addData(map[string]interface{}{"type": "text", "name": "Offline", "text": "note"}, "")
+
+	store, err := client.loadStore()
+	if err != nil {
+		t.Fatalf("Ошибка загрузки кэша: %v", err)
+	}
+
+	if len(store.Pending) != 1 || store.Pending[0].Action != opCreate {
+		t.Fatalf("Ожидалось одно создание в очереди, получено %+v", store.Pending)
+	}
+
+	if item := store.Records[store.Pending[0].RecordID]; item == nil || item.Name != "Offline" {
+		t.Errorf("Ожидалась локальная запись Offline, получено %+v", item)
+	}
+}
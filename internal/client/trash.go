@@ -0,0 +1,118 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// trashItem представляет удаленную запись в корзине.
+type trashItem struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// createTrashCommands создает команды для работы с корзиной.
+func (c *Client) createTrashCommands() *cobra.Command {
+	trashCmd := &cobra.Command{
+		Use:   "trash",
+		Short: "Команды для работы с корзиной удаленных записей",
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "Список удаленных записей",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			c.listTrash()
+		},
+	}
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore [id]",
+		Short: "Восстановить запись из корзины",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c.trashRequest("POST", "/api/v1/trash/"+args[0]+"/restore", "Запись восстановлена", "Ошибка восстановления данных")
+		},
+	}
+
+	purgeCmd := &cobra.Command{
+		Use:   "purge [id]",
+		Short: "Удалить запись окончательно вместе с историей изменений",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			c.trashRequest("DELETE", "/api/v1/trash/"+args[0], "Запись удалена окончательно", "Ошибка удаления данных")
+		},
+	}
+
+	trashCmd.AddCommand(listCmd, restoreCmd, purgeCmd)
+	return trashCmd
+}
+
+// listTrash выводит удаленные записи.
+func (c *Client) listTrash() {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	resp, err := c.makeRequest("GET", "/api/v1/trash", nil)
+	if err != nil {
+		fmt.Printf("Ошибка получения корзины: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Ошибка получения корзины: %s\n", string(body))
+		return
+	}
+
+	var items []trashItem
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		fmt.Printf("Ошибка парсинга ответа: %v\n", err)
+		return
+	}
+
+	if len(items) == 0 {
+		fmt.Println("Корзина пуста")
+		return
+	}
+
+	fmt.Printf("В корзине %d записей:\n", len(items))
+	for _, item := range items {
+		fmt.Printf("- ID: %s, Тип: %s, Название: %s, Удалено: %s\n",
+			item.ID, item.Type, item.Name, item.DeletedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+}
+
+// trashRequest выполняет запрос к корзине, не возвращающий данных.
+func (c *Client) trashRequest(method, path, success, failure string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	resp, err := c.makeRequest(method, path, nil)
+	if err != nil {
+		fmt.Printf("%s: %v\n", failure, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		fmt.Println(success)
+	} else {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("%s: %s\n", failure, string(body))
+	}
+}
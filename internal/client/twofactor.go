@@ -0,0 +1,174 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// createTwoFactorCommands создает команды управления двухфакторной аутентификацией.
+func (c *Client) createTwoFactorCommands() *cobra.Command {
+	twoFactorCmd := &cobra.Command{
+		Use:   "2fa",
+		Short: "Двухфакторная аутентификация",
+	}
+
+	enableCmd := &cobra.Command{
+		Use:   "enable",
+		Short: "Подключить приложение-аутентификатор",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			code, _ := cmd.Flags().GetString("code")
+			c.enableTwoFactor(code)
+		},
+	}
+	enableCmd.Flags().String("code", "", "Код из приложения; без флага запрашивается после вывода секрета")
+
+	disableCmd := &cobra.Command{
+		Use:   "disable",
+		Short: "Отключить двухфакторную аутентификацию",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			code, _ := cmd.Flags().GetString("code")
+			c.disableTwoFactor(code)
+		},
+	}
+	disableCmd.Flags().String("code", "", "Код из приложения или код восстановления")
+
+	twoFactorCmd.AddCommand(enableCmd, disableCmd)
+	return twoFactorCmd
+}
+
+// verifyTwoFactor завершает вход одноразовым паролем и заменяет authResp ответом сервера.
+func (c *Client) verifyTwoFactor(authResp *authResponse, otp string) error {
+	if otp == "" {
+		otp = c.prompt("Код из приложения-аутентификатора или код восстановления: ")
+	}
+
+	resp, err := c.makeRequest("POST", "/api/v1/login/2fa", map[string]string{
+		"challenge_token": authResp.ChallengeToken,
+		"code":            otp,
+		"device_name":     deviceName(),
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.New(string(body))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(authResp)
+}
+
+// enableTwoFactor получает секрет, выводит его и подтверждает подключение кодом из приложения.
+func (c *Client) enableTwoFactor(code string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	resp, err := c.makeRequest("POST", "/api/v1/2fa/enable", nil)
+	if err != nil {
+		fmt.Printf("Ошибка подключения 2FA: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Ошибка подключения 2FA: %s\n", string(body))
+		return
+	}
+
+	var setup struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&setup); err != nil {
+		fmt.Printf("Ошибка парсинга ответа: %v\n", err)
+		return
+	}
+
+	fmt.Println("Добавьте учетную запись в приложение-аутентификатор по ссылке или секрету:")
+	fmt.Println(setup.ProvisioningURI)
+	fmt.Println("Секрет:", setup.Secret)
+
+	if code == "" {
+		code = c.prompt("Код из приложения: ")
+	}
+
+	confirmResp, err := c.makeRequest("POST", "/api/v1/2fa/confirm", map[string]string{"code": code})
+	if err != nil {
+		fmt.Printf("Ошибка подключения 2FA: %v\n", err)
+		return
+	}
+	defer confirmResp.Body.Close()
+
+	if confirmResp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(confirmResp.Body)
+		fmt.Printf("Ошибка подключения 2FA: %s\n", string(body))
+		return
+	}
+
+	var recovery struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	if err := json.NewDecoder(confirmResp.Body).Decode(&recovery); err != nil {
+		fmt.Printf("Ошибка парсинга ответа: %v\n", err)
+		return
+	}
+
+	fmt.Println("Двухфакторная аутентификация включена.")
+	fmt.Println("Сохраните коды восстановления, каждый из них действует один раз:")
+	for _, recoveryCode := range recovery.RecoveryCodes {
+		fmt.Println("  " + recoveryCode)
+	}
+}
+
+// disableTwoFactor отключает двухфакторную аутентификацию.
+func (c *Client) disableTwoFactor(code string) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	if code == "" {
+		code = c.prompt("Код из приложения или код восстановления: ")
+	}
+
+	resp, err := c.makeRequest("POST", "/api/v1/2fa/disable", map[string]string{"code": code})
+	if err != nil {
+		fmt.Printf("Ошибка отключения 2FA: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("Ошибка отключения 2FA: %s\n", string(body))
+		return
+	}
+	fmt.Println("Двухфакторная аутентификация отключена")
+}
+
+// prompt запрашивает строку у пользователя.
+func (c *Client) prompt(question string) string {
+	if c.in == nil {
+		c.in = bufio.NewReader(os.Stdin)
+	}
+
+	fmt.Print(question)
+	line, _ := c.in.ReadString('\n')
+	return strings.TrimSpace(line)
+}
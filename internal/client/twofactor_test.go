@@ -0,0 +1,46 @@
+// Package client содержит тесты для двухфакторной аутентификации.
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_login_TwoFactor(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/login", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(authResponse{TwoFactorRequired: true, ChallengeToken: "challenge"})
+	})
+	mux.HandleFunc("POST /api/v1/login/2fa", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["challenge_token"] != "challenge" || req["code"] != "123456" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(authResponse{Token: "access", RefreshToken: "refresh"})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.configPath = t.TempDir()
+
+	// Неверный код не открывает сессию
+	client.login("user", "password", "", "000000")
+	if client.token != "" {
+		t.Fatalf("Токен не должен сохраняться при неверном коде, получен %q", client.token)
+	}
+
+	// Без флага --otp код запрашивается у пользователя
+	client.in = bufio.NewReader(strings.NewReader("123456\n"))
+	client.login("user", "password", "", "")
+	if client.token != "access" || client.refreshToken != "refresh" {
+		t.Errorf("Ожидались токены access и refresh, получено %q и %q", client.token, client.refreshToken)
+	}
+}
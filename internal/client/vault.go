@@ -0,0 +1,222 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+)
+
+// masterPasswordEnv задает переменную окружения с мастер-паролем.
+const masterPasswordEnv = "GOPHKEEPER_MASTER_PASSWORD"
+
+// secretFields содержит поля запроса, которые шифруются на клиенте.
+var secretFields = []string{"login", "password", "text", "card", "binary"}
+
+// errWrongMasterPassword возвращается, когда ключ из мастер-пароля не совпадает с ключом хранилища.
+var errWrongMasterPassword = errors.New("неверный мастер-пароль, сквозное шифрование отключено")
+
+// unlockVault получает ключ хранилища из мастер-пароля и соли, выданной сервером,
+// проверяет его по проверочному значению kdfCheck и сохраняет рядом с токеном.
+// Если проверочное значение еще не задано, оно сохраняется на сервере.
+func (c *Client) unlockVault(masterPassword, kdfSalt, kdfCheck string) error {
+	if masterPassword == "" {
+		masterPassword = os.Getenv(masterPasswordEnv)
+	}
+	if masterPassword == "" {
+		if err := c.forgetVaultKey(); err != nil {
+			return err
+		}
+		return errors.New("мастер-пароль не задан, сквозное шифрование отключено")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(kdfSalt)
+	if err != nil || len(salt) == 0 {
+		return errors.New("сервер не вернул соль для получения ключа")
+	}
+
+	key := crypto.DeriveVaultKey(masterPassword, salt)
+	if kdfCheck == "" {
+		err = c.saveKDFCheck(key)
+	} else if !hmac.Equal([]byte(crypto.VaultKeyCheck(key)), []byte(kdfCheck)) {
+		err = errWrongMasterPassword
+	}
+	if err != nil {
+		if forgetErr := c.forgetVaultKey(); forgetErr != nil {
+			return forgetErr
+		}
+		return err
+	}
+
+	c.vaultKey = key
+	return c.saveVaultKey()
+}
+
+// saveKDFCheck сохраняет на сервере проверочное значение ключа хранилища key. Записи,
+// зашифрованные до появления проверочного значения, проверяются этим ключом заранее,
+// чтобы не закрепить на сервере ключ из неверного мастер-пароля.
+func (c *Client) saveKDFCheck(key []byte) error {
+	data, err := c.fetchDataPages("/api/v1/data")
+	if err != nil {
+		return fmt.Errorf("не удалось проверить мастер-пароль: %w", err)
+	}
+	for i := range data {
+		if data[i].EncryptedPayload == "" {
+			continue
+		}
+		if _, err := openRecordBlob(data[i].EncryptedPayload, key, data[i].ID, data[i].Type); err != nil {
+			return errWrongMasterPassword
+		}
+		break
+	}
+
+	resp, err := c.makeRequest("PUT", "/api/v1/kdf-check", map[string]string{"kdf_check": crypto.VaultKeyCheck(key)})
+	if err != nil {
+		return fmt.Errorf("не удалось сохранить проверочное значение мастер-пароля: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("не удалось сохранить проверочное значение мастер-пароля: %s", string(body))
+	}
+	return nil
+}
+
+// saveVaultKey сохраняет ключ хранилища в файл vault.key, чтобы мастер-пароль не приходилось
+// вводить для каждой команды. Файл не зашифрован, и ключ защищают только права доступа к нему.
+func (c *Client) saveVaultKey() error {
+	if err := os.MkdirAll(c.configPath, 0755); err != nil {
+		return fmt.Errorf("не удалось создать директорию конфигурации: %w", err)
+	}
+
+	keyFile := filepath.Join(c.configPath, "vault.key")
+	encoded := base64.StdEncoding.EncodeToString(c.vaultKey)
+	if err := os.WriteFile(keyFile, []byte(encoded), 0600); err != nil {
+		return fmt.Errorf("не удалось сохранить ключ хранилища: %w", err)
+	}
+
+	return nil
+}
+
+// loadVaultKey загружает ключ хранилища из файла.
+func (c *Client) loadVaultKey() {
+	keyFile := filepath.Join(c.configPath, "vault.key")
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return
+	}
+	if key, err := base64.StdEncoding.DecodeString(string(data)); err == nil && len(key) == crypto.VaultKeySize {
+		c.vaultKey = key
+	}
+}
+
+// forgetVaultKey удаляет ключ хранилища из памяти и с диска.
+func (c *Client) forgetVaultKey() error {
+	c.vaultKey = nil
+	err := os.Remove(filepath.Join(c.configPath, "vault.key"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// sealSecrets переносит секретные поля запроса в blob, зашифрованный ключом хранилища
+// и привязанный к записи id типа dataType. Без ключа хранилища запрос не изменяется
+// и шифрование выполняет сервер.
+func (c *Client) sealSecrets(req map[string]interface{}, id, dataType string) error {
+	if c.vaultKey == nil {
+		return nil
+	}
+
+	secrets := make(map[string]interface{})
+	for _, field := range secretFields {
+		if value, ok := req[field]; ok {
+			secrets[field] = value
+			delete(req, field)
+		}
+	}
+
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	blob, err := crypto.SealRecordBlob(plaintext, c.vaultKey, recordID(id), dataType)
+	if err != nil {
+		return err
+	}
+
+	req["encrypted_payload"] = blob
+	return nil
+}
+
+// openSecrets расшифровывает blob записи и заполняет ее секретные поля.
+func (c *Client) openSecrets(item *dataItem) error {
+	if item.EncryptedPayload == "" {
+		return nil
+	}
+	if c.vaultKey == nil {
+		return errors.New("запись зашифрована на клиенте, войдите с мастер-паролем")
+	}
+
+	plaintext, err := openRecordBlob(item.EncryptedPayload, c.vaultKey, item.ID, item.Type)
+	if err != nil {
+		return errors.New("не удалось расшифровать запись, проверьте мастер-пароль")
+	}
+
+	return json.Unmarshal(plaintext, item)
+}
+
+// openRecordBlob расшифровывает blob записи id типа dataType. Без привязки к записи
+// расшифровываются только blob старого формата, созданные до ее появления.
+func openRecordBlob(blob string, key []byte, id, dataType string) ([]byte, error) {
+	return crypto.OpenRecordBlob(blob, key, recordID(id), dataType)
+}
+
+// resealSecrets сообщает, что при изменении записи item ее секретные поля нужно
+// зашифровать заново, даже если изменение их не затрагивает: blob старого формата
+// так привязывается к записи.
+func (c *Client) resealSecrets(req map[string]interface{}, item *dataItem) bool {
+	return c.vaultKey != nil && (hasSecretFields(req) || crypto.IsLegacyRecordBlob(item.EncryptedPayload))
+}
+
+// hasSecretFields проверяет, содержит ли запрос секретные поля.
+func hasSecretFields(req map[string]interface{}) bool {
+	for _, field := range secretFields {
+		if _, ok := req[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSecrets дополняет запрос секретными полями текущей записи,
+// которые не изменяются этим запросом.
+func mergeSecrets(req map[string]interface{}, current *dataItem) {
+	existing := map[string]interface{}{
+		"login":    current.Login,
+		"password": current.Password,
+		"text":     current.Text,
+	}
+	if current.Card != nil {
+		existing["card"] = current.Card
+	}
+	if current.Binary != nil {
+		existing["binary"] = current.Binary
+	}
+
+	for field, value := range existing {
+		if _, ok := req[field]; !ok && value != "" {
+			req[field] = value
+		}
+	}
+}
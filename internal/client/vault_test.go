@@ -0,0 +1,179 @@
+// Package client содержит тесты для шифрования на стороне клиента.
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+)
+
+// vaultSalt возвращает соль в base64 и проверочное значение ключа из мастер-пароля master.
+func vaultSalt(master string) (string, string) {
+	salt, _ := crypto.NewSalt()
+	return base64.StdEncoding.EncodeToString(salt), crypto.VaultKeyCheck(crypto.DeriveVaultKey(master, salt))
+}
+
+func TestClient_sealOpenSecrets(t *testing.T) {
+	client := New()
+	client.configPath = t.TempDir()
+
+	salt, check := vaultSalt("master")
+	if err := client.unlockVault("master", salt, check); err != nil {
+		t.Fatalf("Ошибка получения ключа хранилища: %v", err)
+	}
+
+	req := map[string]interface{}{
+		"type":     "login_password",
+		"name":     "site",
+		"login":    "user",
+		"password": "secret",
+	}
+
+	if err := client.sealSecrets(req, "record-1", "login_password"); err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+
+	if _, ok := req["password"]; ok {
+		t.Error("Пароль не должен передаваться в открытом виде")
+	}
+
+	if req["name"] != "site" {
+		t.Error("Несекретные поля должны оставаться в запросе")
+	}
+
+	item := &dataItem{ID: "record-1", Type: "login_password", EncryptedPayload: req["encrypted_payload"].(string)}
+	if err := client.openSecrets(item); err != nil {
+		t.Fatalf("Ошибка расшифровки: %v", err)
+	}
+
+	if item.Login != "user" || item.Password != "secret" {
+		t.Errorf("Ожидались логин user и пароль secret, получены %s и %s", item.Login, item.Password)
+	}
+
+	// Blob, перенесенный сервером в другую запись, не расшифровывается
+	moved := &dataItem{ID: "record-2", Type: "login_password", EncryptedPayload: item.EncryptedPayload}
+	if err := client.openSecrets(moved); err == nil {
+		t.Error("Blob другой записи не должен расшифровываться")
+	}
+
+	// Запись, созданная без сети, шифруется под ID, который получит на сервере
+	if err := client.sealSecrets(req, newLocalID("record-3"), "text"); err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if err := client.openSecrets(&dataItem{ID: "record-3", Type: "text", EncryptedPayload: req["encrypted_payload"].(string)}); err != nil {
+		t.Errorf("Ошибка расшифровки записи, созданной без сети: %v", err)
+	}
+}
+
+func TestClient_openSecrets_LegacyBlob(t *testing.T) {
+	client := New()
+	client.vaultKey = crypto.DeriveVaultKey("master", []byte("0123456789abcdef"))
+
+	blob, _ := crypto.SealBlob([]byte(`{"password":"secret"}`), client.vaultKey, nil)
+	item := &dataItem{ID: "record-1", Type: "login_password", EncryptedPayload: blob}
+	if err := client.openSecrets(item); err != nil || item.Password != "secret" {
+		t.Errorf("Blob без привязки к записи должен расшифровываться: %v", err)
+	}
+}
+
+func TestClient_unlockVault_WrongPassword(t *testing.T) {
+	client := New()
+	client.configPath = t.TempDir()
+
+	salt, check := vaultSalt("master")
+	if err := client.unlockVault("wrong", salt, check); !errors.Is(err, errWrongMasterPassword) {
+		t.Fatalf("Ожидалась ошибка неверного мастер-пароля, получено %v", err)
+	}
+	if client.vaultKey != nil {
+		t.Error("Ключ из неверного мастер-пароля не должен сохраняться")
+	}
+}
+
+func TestClient_unlockVault_SavesCheck(t *testing.T) {
+	var records []dataItem
+	var saved string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/data":
+			json.NewEncoder(w).Encode(records)
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/kdf-check":
+			var req map[string]string
+			json.NewDecoder(r.Body).Decode(&req)
+			saved = req["kdf_check"]
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Неожиданный запрос: %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+	client.configPath = t.TempDir()
+
+	salt, check := vaultSalt("master")
+	rawSalt, _ := base64.StdEncoding.DecodeString(salt)
+
+	// Запись, зашифрованная до появления проверочного значения, защищает от неверного пароля
+	blob, _ := crypto.SealBlob([]byte(`{"password":"secret"}`), crypto.DeriveVaultKey("master", rawSalt), nil)
+	records = []dataItem{{ID: "record-1", Type: "login_password", EncryptedPayload: blob}}
+	if err := client.unlockVault("wrong", salt, ""); !errors.Is(err, errWrongMasterPassword) {
+		t.Fatalf("Ожидалась ошибка неверного мастер-пароля, получено %v", err)
+	}
+	if saved != "" {
+		t.Fatal("Проверочное значение неверного мастер-пароля не должно сохраняться")
+	}
+
+	if err := client.unlockVault("master", salt, ""); err != nil {
+		t.Fatalf("Ошибка получения ключа хранилища: %v", err)
+	}
+	if saved != check {
+		t.Errorf("Ожидалось проверочное значение %q, сохранено %q", check, saved)
+	}
+}
+
+func TestClient_loadVaultKey(t *testing.T) {
+	client := New()
+	client.configPath = t.TempDir()
+
+	salt, check := vaultSalt("master")
+	if err := client.unlockVault("master", salt, check); err != nil {
+		t.Fatalf("Ошибка получения ключа хранилища: %v", err)
+	}
+
+	restored := New()
+	restored.configPath = client.configPath
+	restored.loadVaultKey()
+
+	if string(restored.vaultKey) != string(client.vaultKey) {
+		t.Error("Загруженный ключ хранилища должен совпадать с сохраненным")
+	}
+
+	if err := restored.forgetVaultKey(); err != nil {
+		t.Fatalf("Ошибка удаления ключа хранилища: %v", err)
+	}
+
+	restored.loadVaultKey()
+	if restored.vaultKey != nil {
+		t.Error("Ключ хранилища должен быть удален")
+	}
+}
+
+func TestClient_sealSecrets_WithoutVaultKey(t *testing.T) {
+	client := New()
+
+	req := map[string]interface{}{"password": "secret"}
+	if err := client.sealSecrets(req, "record-1", "login_password"); err != nil {
+		t.Fatalf("Неожиданная ошибка: %v", err)
+	}
+
+	if req["password"] != "secret" {
+		t.Error("Без ключа хранилища запрос не должен изменяться")
+	}
+}
@@ -0,0 +1,205 @@
+// Package client содержит CLI клиент GophKeeper.
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// watchRetryDelay задает паузу перед повторным подключением к потоку изменений.
+const watchRetryDelay = 5 * time.Second
+
+// errStreamReset означает, что сервер закрыл поток, потому что клиент не успевал читать события.
+var errStreamReset = errors.New("часть изменений пропущена")
+
+// errStreamExpired означает, что сервер закрыл поток, потому что токен доступа истек или сессия отозвана.
+var errStreamExpired = errors.New("токен доступа истек")
+
+// watchEvent представляет событие об изменении записи.
+type watchEvent struct {
+	Action   string   `json:"action"`
+	Data     dataItem `json:"data"`
+	ClientID string   `json:"client_id"`
+}
+
+// watchActions содержит описания действий с записями.
+var watchActions = map[string]string{
+	"create":  "создана",
+	"update":  "изменена",
+	"delete":  "удалена",
+	"restore": "восстановлена",
+}
+
+// createWatchCommand создает команду вывода изменений записей в реальном времени.
+func (c *Client) createWatchCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "watch",
+		Short: "Показывать изменения записей в реальном времени",
+		Long:  "Подписывается на изменения записей с любых устройств и выводит их, пока команда не будет прервана",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			c.watch(ctx)
+		},
+	}
+}
+
+// watch выводит изменения записей до отмены ctx. При потере соединения
+// клиент подключается к серверу заново.
+func (c *Client) watch(ctx context.Context) {
+	if c.token == "" {
+		fmt.Println("Необходимо войти в систему")
+		return
+	}
+
+	fmt.Println("Ожидание изменений, для выхода нажмите Ctrl+C")
+	for {
+		err := c.streamEvents(ctx, c.printWatchEvent)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case errors.Is(err, errStreamReset):
+			fmt.Println("Часть изменений пропущена, актуальный список записей покажет команда data list")
+		case errors.Is(err, errStreamExpired):
+			// При повторном подключении токен доступа обновляется, а отозванная сессия завершает команду
+		case errors.Is(err, errOffline):
+			fmt.Printf("Соединение с сервером потеряно, повторное подключение через %s\n", watchRetryDelay)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(watchRetryDelay):
+			}
+		default:
+			fmt.Printf("Ошибка получения изменений: %v\n", err)
+			return
+		}
+	}
+}
+
+// printWatchEvent выводит событие об изменении записи.
+func (c *Client) printWatchEvent(event *watchEvent) {
+	action, ok := watchActions[event.Action]
+	if !ok {
+		action = event.Action
+	}
+
+	device := event.ClientID
+	if device == c.clientID {
+		device += " (это устройство)"
+	}
+	fmt.Printf("%s Запись %s: %s (ID: %s, тип: %s), устройство: %s\n",
+		time.Now().Format("15:04:05"), action, event.Data.Name, event.Data.ID, event.Data.Type, device)
+}
+
+// streamEvents подписывается на изменения записей и передает события handle,
+// пока сервер не закроет поток или не будет отменен ctx.
+func (c *Client) streamEvents(ctx context.Context, handle func(event *watchEvent)) error {
+	resp, err := c.openEventStream(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.New(string(body))
+	}
+
+	err = readEvents(resp.Body, func(name, data string) error {
+		switch name {
+		case "reset":
+			return errStreamReset
+		case "unauthorized":
+			return errStreamExpired
+		}
+
+		var event watchEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("неверный формат события: %w", err)
+		}
+		handle(&event)
+		return nil
+	})
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %v", errOffline, err)
+	}
+	return err
+}
+
+// openEventStream открывает поток изменений, а если токен доступа истек, обновляет его
+// и повторяет запрос один раз.
+func (c *Client) openEventStream(ctx context.Context) (*http.Response, error) {
+	// Поток не ограничен по времени, поэтому таймаут запросов к нему не применяется
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+
+	for refreshed := false; ; refreshed = true {
+		req, err := c.newRequest("GET", "/api/v1/events", nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "text/event-stream")
+
+		resp, err := streamClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errOffline, err)
+		}
+		if resp.StatusCode != http.StatusUnauthorized || c.refreshToken == "" || refreshed {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		if err := c.refreshSession(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readEvents разбирает поток Server-Sent Events и передает handle имя и данные каждого события.
+// Комментарии пропускаются. Если поток обрывается, возвращается io.ErrUnexpectedEOF.
+func readEvents(r io.Reader, handle func(name, data string) error) error {
+	reader := bufio.NewReader(r)
+	var name string
+	var data []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("%w: %v", io.ErrUnexpectedEOF, err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if name != "" || len(data) > 0 {
+				if err := handle(name, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			name, data = "", nil
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			name = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+}
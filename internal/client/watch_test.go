@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_streamEvents(t *testing.T) {
+	connections := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/events" || r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("Неожиданный запрос: %s %s", r.Method, r.URL.Path)
+		}
+		connections++
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event:create\ndata:{\"action\":\"create\",\"data\":{\"id\":\"1\",\"name\":\"Mail\"},\"client_id\":\"phone\"}\n\n")
+		fmt.Fprint(w, ": ping\n\n")
+		fmt.Fprint(w, "event: delete\ndata: {\"action\":\"delete\",\"data\":{\"id\":\"1\",\"name\":\"Mail\"},\n")
+		fmt.Fprint(w, "data: \"client_id\":\"laptop\"}\n\n")
+		switch connections {
+		case 1:
+			fmt.Fprint(w, "event:reset\ndata:{\"error\":\"Подпишитесь заново\"}\n\n")
+		case 3:
+			fmt.Fprint(w, "event:unauthorized\ndata:{\"error\":\"Срок действия токена истек\"}\n\n")
+		}
+	}))
+	defer server.Close()
+
+	client := New()
+	client.baseURL = server.URL
+	client.token = "test-token"
+
+	var received []watchEvent
+	handle := func(event *watchEvent) { received = append(received, *event) }
+
+	if err := client.streamEvents(context.Background(), handle); !errors.Is(err, errStreamReset) {
+		t.Errorf("Ожидалась ошибка %v, получено %v", errStreamReset, err)
+	}
+	if len(received) != 2 || received[0].Action != "create" || received[1].Action != "delete" ||
+		received[1].ClientID != "laptop" || received[1].Data.Name != "Mail" {
+		t.Fatalf("Неожиданные события: %+v", received)
+	}
+
+	// Обрыв потока считается потерей соединения
+	if err := client.streamEvents(context.Background(), handle); !errors.Is(err, errOffline) {
+		t.Errorf("Ожидалась ошибка %v, получено %v", errOffline, err)
+	}
+	if len(received) != 4 {
+		t.Errorf("Ожидалось 4 события, получено %d", len(received))
+	}
+
+	if err := client.streamEvents(context.Background(), handle); !errors.Is(err, errStreamExpired) {
+		t.Errorf("Ожидалась ошибка %v, получено %v", errStreamExpired, err)
+	}
+}
@@ -2,8 +2,12 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"time"
 
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/logger"
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -15,12 +19,18 @@ type Config struct {
 	Database DatabaseConfig `mapstructure:"database"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
 	Crypto   CryptoConfig   `mapstructure:"crypto"`
+	Trash    TrashConfig    `mapstructure:"trash"`
+	Storage  StorageConfig  `mapstructure:"storage"`
+	Audit    AuditConfig    `mapstructure:"audit"`
+	Events   EventsConfig   `mapstructure:"events"`
 }
 
-// ServerConfig содержит настройки HTTP сервера.
+// ServerConfig содержит настройки HTTP и gRPC серверов.
 type ServerConfig struct {
 	Host string `mapstructure:"host"`
 	Port string `mapstructure:"port"`
+	// GRPCPort задает порт gRPC сервера; пустое значение отключает gRPC.
+	GRPCPort string `mapstructure:"grpc_port"`
 }
 
 // DatabaseConfig содержит настройки базы данных.
@@ -36,11 +46,89 @@ type DatabaseConfig struct {
 // JWTConfig содержит настройки JWT токенов.
 type JWTConfig struct {
 	Secret string `mapstructure:"secret"`
+	// RefreshTTL задает срок действия токена обновления; сессия без активности дольше этого срока истекает.
+	RefreshTTL time.Duration `mapstructure:"refresh_ttl"`
 }
 
 // CryptoConfig содержит настройки шифрования.
 type CryptoConfig struct {
+	// Key задает ключ с идентификатором default, которым зашифрованы данные,
+	// сохраненные до появления связки ключей.
 	Key string `mapstructure:"key"`
+	// Keys задает дополнительные ключи в виде "id1:секрет1,id2:секрет2".
+	Keys string `mapstructure:"keys"`
+	// ActiveKeyID задает ключ, которым шифруются новые данные; по умолчанию default.
+	ActiveKeyID string `mapstructure:"active_key_id"`
+	// Keyfile задает путь к файлу ключей, защищенному парольной фразой.
+	// Если файл задан, ключи из Key и Keys не используются.
+	Keyfile string `mapstructure:"keyfile"`
+	// KeyfilePassphrase задает парольную фразу файла ключей.
+	KeyfilePassphrase string `mapstructure:"keyfile_passphrase"`
+}
+
+// KeyProvider создает источник ключей шифрования: файл ключей, если он задан,
+// иначе связку ключей из переменных окружения.
+func (c CryptoConfig) KeyProvider() (crypto.KeyProvider, error) {
+	if c.Keyfile == "" {
+		return c.Keyring()
+	}
+	if c.Key != "" || c.Keys != "" {
+		return nil, errors.New("ключи заданы и в CRYPTO_KEYFILE, и в CRYPTO_KEY или CRYPTO_KEYS")
+	}
+	return crypto.LoadKeyfile(c.Keyfile, c.KeyfilePassphrase)
+}
+
+// Keyring создает связку ключей шифрования из переменных окружения.
+func (c CryptoConfig) Keyring() (*crypto.Keyring, error) {
+	keys, err := crypto.ParseKeys(c.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Key != "" {
+		if _, exists := keys[crypto.DefaultKeyID]; exists {
+			return nil, fmt.Errorf("ключ %q задан и в CRYPTO_KEY, и в CRYPTO_KEYS", crypto.DefaultKeyID)
+		}
+		keys[crypto.DefaultKeyID] = c.Key
+	}
+
+	activeID := c.ActiveKeyID
+	if activeID == "" {
+		activeID = crypto.DefaultKeyID
+	}
+	return crypto.NewKeyring(activeID, keys)
+}
+
+// TrashConfig содержит настройки корзины удаленных записей.
+type TrashConfig struct {
+	// Retention задает срок хранения удаленных записей; 0 отключает их окончательное удаление.
+	Retention time.Duration `mapstructure:"retention"`
+	// JanitorInterval задает период проверки корзины на просроченные записи.
+	JanitorInterval time.Duration `mapstructure:"janitor_interval"`
+}
+
+// AuditConfig содержит настройки журнала аудита.
+type AuditConfig struct {
+	// CheckpointInterval задает, через сколько событий ставится подписанная контрольная точка;
+	// 0 отключает контрольные точки.
+	CheckpointInterval int `mapstructure:"checkpoint_interval"`
+}
+
+// StorageConfig содержит настройки хранилища фрагментов файлов.
+type StorageConfig struct {
+	// Backend задает хранилище: postgres (large objects) или filesystem.
+	Backend string `mapstructure:"backend"`
+	// Path задает директорию файлового хранилища.
+	Path string `mapstructure:"path"`
+	// MaxFileSize задает наибольший размер загружаемого файла в байтах.
+	MaxFileSize int64 `mapstructure:"max_file_size"`
+}
+
+// EventsConfig содержит настройки рассылки изменений записей.
+type EventsConfig struct {
+	// Backend задает рассылку: memory (в пределах экземпляра сервера) или postgres
+	// (LISTEN/NOTIFY, для нескольких экземпляров с общей базой данных).
+	Backend string `mapstructure:"backend"`
 }
 
 // Load загружает конфигурацию из переменных окружения и файлов.
@@ -54,15 +142,25 @@ func Load() *Config {
 
 	viper.SetDefault("server.host", "localhost")
 	viper.SetDefault("server.port", "8080")
+	viper.SetDefault("server.grpc_port", "9090")
 	viper.SetDefault("database.host", "localhost")
 	viper.SetDefault("database.port", 5432)
 	viper.SetDefault("database.sslmode", "disable")
+	viper.SetDefault("jwt.refresh_ttl", "720h")
+	viper.SetDefault("trash.retention", "720h")
+	viper.SetDefault("trash.janitor_interval", "1h")
+	viper.SetDefault("storage.backend", "postgres")
+	viper.SetDefault("storage.path", "data/blobs")
+	viper.SetDefault("storage.max_file_size", 1<<30)
+	viper.SetDefault("audit.checkpoint_interval", 100)
+	viper.SetDefault("events.backend", "memory")
 
 	viper.AutomaticEnv()
 
 	viper.SetEnvPrefix("")
 	viper.BindEnv("server.host", "SERVER_HOST")
 	viper.BindEnv("server.port", "SERVER_PORT")
+	viper.BindEnv("server.grpc_port", "GRPC_PORT")
 	viper.BindEnv("database.host", "DB_HOST")
 	viper.BindEnv("database.port", "DB_PORT")
 	viper.BindEnv("database.user", "DB_USER")
@@ -70,7 +168,19 @@ func Load() *Config {
 	viper.BindEnv("database.dbname", "DB_NAME")
 	viper.BindEnv("database.sslmode", "DB_SSLMODE")
 	viper.BindEnv("jwt.secret", "JWT_SECRET")
+	viper.BindEnv("jwt.refresh_ttl", "JWT_REFRESH_TTL")
 	viper.BindEnv("crypto.key", "CRYPTO_KEY")
+	viper.BindEnv("crypto.keys", "CRYPTO_KEYS")
+	viper.BindEnv("crypto.active_key_id", "CRYPTO_ACTIVE_KEY_ID")
+	viper.BindEnv("crypto.keyfile", "CRYPTO_KEYFILE")
+	viper.BindEnv("crypto.keyfile_passphrase", "CRYPTO_KEYFILE_PASSPHRASE")
+	viper.BindEnv("trash.retention", "TRASH_RETENTION")
+	viper.BindEnv("trash.janitor_interval", "TRASH_JANITOR_INTERVAL")
+	viper.BindEnv("storage.backend", "STORAGE_BACKEND")
+	viper.BindEnv("storage.path", "STORAGE_PATH")
+	viper.BindEnv("storage.max_file_size", "STORAGE_MAX_FILE_SIZE")
+	viper.BindEnv("audit.checkpoint_interval", "AUDIT_CHECKPOINT_INTERVAL")
+	viper.BindEnv("events.backend", "EVENTS_BACKEND")
 
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
@@ -103,7 +213,7 @@ func validateConfig(cfg *Config) {
 		}
 	}
 
-	if cfg.Crypto.Key == "" {
+	if cfg.Crypto.Key == "" && cfg.Crypto.Keys == "" && cfg.Crypto.Keyfile == "" {
 		if logger.Logger != nil {
 			logger.Logger.Fatal("КРИТИЧЕСКАЯ ОШИБКА: CRYPTO_KEY не установлен")
 		} else {
@@ -111,6 +221,25 @@ func validateConfig(cfg *Config) {
 		}
 	}
 
+	if cfg.Crypto.Keyfile != "" && cfg.Crypto.KeyfilePassphrase == "" {
+		if logger.Logger != nil {
+			logger.Logger.Fatal("КРИТИЧЕСКАЯ ОШИБКА: CRYPTO_KEYFILE_PASSPHRASE не установлен")
+		} else {
+			panic("КРИТИЧЕСКАЯ ОШИБКА: CRYPTO_KEYFILE_PASSPHRASE не установлен")
+		}
+	}
+
+	// Файл ключей расшифровывается при запуске сервера, здесь проверяются только ключи из окружения
+	if cfg.Crypto.Keyfile == "" {
+		if _, err := cfg.Crypto.Keyring(); err != nil {
+			if logger.Logger != nil {
+				logger.Logger.Fatal("КРИТИЧЕСКАЯ ОШИБКА: неверная связка ключей шифрования: " + err.Error())
+			} else {
+				panic("КРИТИЧЕСКАЯ ОШИБКА: неверная связка ключей шифрования: " + err.Error())
+			}
+		}
+	}
+
 	if cfg.Database.DBName == "" {
 		if logger.Logger != nil {
 			logger.Logger.Fatal("КРИТИЧЕСКАЯ ОШИБКА: DB_NAME не установлен")
@@ -3,7 +3,11 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
 )
 
 func TestConfig_Load_DefaultValues(t *testing.T) {
@@ -102,6 +106,13 @@ func TestConfig_Load_DefaultValues(t *testing.T) {
 	if config.Crypto.Key == "" {
 		t.Error("Crypto Key не должен быть пустым")
 	}
+
+	if config.Audit.CheckpointInterval != 100 {
+		t.Errorf("Ожидался интервал контрольных точек 100, получен %d", config.Audit.CheckpointInterval)
+	}
+	if config.Trash.Retention != 720*time.Hour {
+		t.Errorf("Ожидался срок хранения корзины 720h, получен %v", config.Trash.Retention)
+	}
 }
 
 func TestConfig_Load_EnvironmentVariables(t *testing.T) {
@@ -218,3 +229,58 @@ crypto:
 		t.Errorf("Ожидался crypto key 'file-crypto-key', получен '%s'", config.Crypto.Key)
 	}
 }
+
+func TestCryptoConfig_Keyring(t *testing.T) {
+	cfg := CryptoConfig{Key: "old-key", Keys: "2025:new-key", ActiveKeyID: "2025"}
+
+	keyring, err := cfg.Keyring()
+	if err != nil {
+		t.Fatalf("Ошибка создания связки ключей: %v", err)
+	}
+	if keyring.CurrentKeyID() != "2025" {
+		t.Errorf("Ожидался активный ключ '2025', получен '%s'", keyring.CurrentKeyID())
+	}
+
+	keyring, err = CryptoConfig{Key: "old-key"}.Keyring()
+	if err != nil {
+		t.Fatalf("Ошибка создания связки ключей: %v", err)
+	}
+	if keyring.CurrentKeyID() != "default" {
+		t.Errorf("Без CRYPTO_ACTIVE_KEY_ID активным должен быть ключ 'default', получен '%s'", keyring.CurrentKeyID())
+	}
+
+	if _, err := (CryptoConfig{Key: "old-key", Keys: "default:other"}).Keyring(); err == nil {
+		t.Error("Ожидалась ошибка при повторном задании ключа default")
+	}
+	if _, err := (CryptoConfig{Keys: "2025:new-key"}).Keyring(); err == nil {
+		t.Error("Ожидалась ошибка, если активный ключ отсутствует в связке")
+	}
+}
+
+func TestCryptoConfig_KeyProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	keys, _ := crypto.NewRandomKeyring("2026")
+	if err := crypto.SaveKeyfile(path, "passphrase", keys); err != nil {
+		t.Fatalf("Ошибка записи файла ключей: %v", err)
+	}
+
+	provider, err := CryptoConfig{Keyfile: path, KeyfilePassphrase: "passphrase"}.KeyProvider()
+	if err != nil {
+		t.Fatalf("Ошибка загрузки файла ключей: %v", err)
+	}
+	if provider.CurrentKeyID() != "2026" {
+		t.Errorf("Ожидался текущий ключ '2026', получен '%s'", provider.CurrentKeyID())
+	}
+
+	provider, err = CryptoConfig{Key: "env-key"}.KeyProvider()
+	if err != nil || provider.CurrentKeyID() != crypto.DefaultKeyID {
+		t.Errorf("Без CRYPTO_KEYFILE ожидались ключи из окружения, получено %v", err)
+	}
+
+	if _, err := (CryptoConfig{Key: "env-key", Keyfile: path, KeyfilePassphrase: "passphrase"}).KeyProvider(); err == nil {
+		t.Error("Ожидалась ошибка, если ключи заданы и в окружении, и в файле")
+	}
+	if _, err := (CryptoConfig{Keyfile: path, KeyfilePassphrase: "wrong"}).KeyProvider(); err == nil {
+		t.Error("Ожидалась ошибка для неверной парольной фразы")
+	}
+}
@@ -0,0 +1,61 @@
+// Package crypto содержит функции для шифрования и расшифровки данных.
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ChunkOverhead определяет, на сколько байт зашифрованный фрагмент больше исходного:
+// 12 байт nonce и 16 байт тега аутентификации AES-GCM.
+const ChunkOverhead = 12 + 16
+
+// KeyFromSecret получает 256-битный ключ из секрета сервера.
+func KeyFromSecret(secret string) []byte {
+	hash := sha256.Sum256([]byte(secret))
+	return hash[:]
+}
+
+// ChunkAAD формирует дополнительные аутентифицированные данные фрагмента файла.
+// Они привязывают фрагмент к файлу и его позиции, поэтому фрагменты нельзя
+// переставить или перенести в другой файл незаметно.
+func ChunkAAD(fileID string, index int) []byte {
+	aad := make([]byte, len(fileID)+8)
+	copy(aad, fileID)
+	binary.BigEndian.PutUint64(aad[len(fileID):], uint64(index))
+	return aad
+}
+
+// SealChunk шифрует фрагмент файла с использованием AES-256-GCM.
+func SealChunk(plaintext, key, aad []byte) ([]byte, error) {
+	gcm, err := newVaultGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// OpenChunk расшифровывает фрагмент файла, зашифрованный SealChunk.
+func OpenChunk(sealed, key, aad []byte) ([]byte, error) {
+	gcm, err := newVaultGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, ciphertext := sealed[:nonceSize], sealed[nonceSize:]
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
@@ -4,67 +4,199 @@ package crypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hkdf"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
 )
 
-// EncryptPassword шифрует пароль с использованием AES-256-GCM.
-func EncryptPassword(password, key string) (string, error) {
-	hash := sha256.Sum256([]byte(key))
+// ciphertextVersion открывает шифротекст, созданный Seal: версия, соль KDF, nonce
+// и шифротекст AES-256-GCM. Шифротексты старого формата начинаются сразу с nonce.
+const ciphertextVersion byte = 2
 
-	block, err := aes.NewCipher(hash[:])
-	if err != nil {
-		return "", err
+// kdfSaltSize определяет размер соли, из которой HKDF получает ключ шифротекста.
+const kdfSaltSize = 16
+
+// kdfInfo отделяет ключи шифротекстов от других ключей, полученных из того же секрета.
+const kdfInfo = "gophkeeper ciphertext v2"
+
+// Seal шифрует данные ключом, полученным HKDF-SHA256 из secret и случайной соли,
+// и привязывает шифротекст к дополнительным данным aad. Расшифровать его можно
+// только с теми же aad, поэтому шифротекст нельзя незаметно перенести в другую
+// запись или к другому пользователю.
+func Seal(plaintext, secret, aad []byte) ([]byte, error) {
+	salt := make([]byte, kdfSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
 	}
 
-	gcm, err := cipher.NewGCM(block)
+	gcm, err := newSealGCM(secret, salt)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
+	header := make([]byte, 0, 1+kdfSaltSize+gcm.NonceSize())
+	header = append(header, ciphertextVersion)
+	header = append(header, salt...)
 	nonce := make([]byte, gcm.NonceSize())
-	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	header = append(header, nonce...)
+
+	return gcm.Seal(header, nonce, plaintext, aad), nil
+}
+
+// Open расшифровывает данные, зашифрованные Seal, и проверяет дополнительные данные aad.
+func Open(sealed, secret, aad []byte) ([]byte, error) {
+	if len(sealed) < 1+kdfSaltSize || sealed[0] != ciphertextVersion {
+		return nil, errors.New("неподдерживаемый формат шифротекста")
+	}
+	salt, rest := sealed[1:1+kdfSaltSize], sealed[1+kdfSaltSize:]
+
+	gcm, err := newSealGCM(secret, salt)
+	if err != nil {
+		return nil, err
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, []byte(password), nil)
+	nonceSize := gcm.NonceSize()
+	if len(rest) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
 
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	nonce, ciphertext := rest[:nonceSize], rest[nonceSize:]
+	return gcm.Open(nil, nonce, ciphertext, aad)
 }
 
-// DecryptPassword расшифровывает пароль.
-func DecryptPassword(encryptedPassword, key string) (string, error) {
-	ciphertext, err := base64.StdEncoding.DecodeString(encryptedPassword)
+// BindAAD собирает дополнительные данные шифротекста из частей. Каждая часть
+// предваряется длиной, поэтому разные наборы частей не дают одинаковых данных.
+func BindAAD(parts ...string) []byte {
+	var aad []byte
+	for _, part := range parts {
+		aad = binary.BigEndian.AppendUint32(aad, uint32(len(part)))
+		aad = append(aad, part...)
+	}
+	return aad
+}
+
+// RecordAAD привязывает шифротекст к записи recordID пользователя userID.
+func RecordAAD(userID, recordID string) []byte {
+	return BindAAD("record", userID, recordID)
+}
+
+// RecordBlobAAD привязывает blob, зашифрованный на клиенте ключом хранилища, к записи recordID типа dataType.
+func RecordBlobAAD(recordID, dataType string) []byte {
+	return BindAAD("record-blob", recordID, dataType)
+}
+
+// VaultRecordAAD привязывает шифротекст к записи recordID командного хранилища vaultID.
+func VaultRecordAAD(vaultID, recordID string) []byte {
+	return BindAAD("vault-record", vaultID, recordID)
+}
+
+// DataKeyAAD привязывает зашифрованный ключ данных к пользователю userID.
+func DataKeyAAD(userID string) []byte {
+	return BindAAD("data-key", userID)
+}
+
+// VaultKeyAAD привязывает зашифрованный ключ данных к командному хранилищу vaultID.
+func VaultKeyAAD(vaultID string) []byte {
+	return BindAAD("vault-key", vaultID)
+}
+
+// AuditCheckpointAAD привязывает подпись контрольной точки журнала аудита к ее номеру seq.
+func AuditCheckpointAAD(seq int64) []byte {
+	return BindAAD("audit-checkpoint", strconv.FormatInt(seq, 10))
+}
+
+// BackupSegmentAAD привязывает сегмент резервной копии к ее заголовку header и номеру index.
+// Последний сегмент помечается отдельно, поэтому обрезанную копию нельзя выдать за целую.
+func BackupSegmentAAD(header []byte, index int, last bool) []byte {
+	marker := "more"
+	if last {
+		marker = "last"
+	}
+	return BindAAD("backup-segment", string(header), strconv.Itoa(index), marker)
+}
+
+// TOTPSecretAAD привязывает зашифрованный секрет одноразовых паролей к пользователю userID.
+func TOTPSecretAAD(userID string) []byte {
+	return BindAAD("totp-secret", userID)
+}
+
+// EncryptPassword шифрует пароль с использованием AES-256-GCM и привязывает его к aad.
+func EncryptPassword(password, key string, aad []byte) (string, error) {
+	sealed, err := Seal([]byte(password), KeyFromSecret(key), aad)
 	if err != nil {
 		return "", err
 	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
 
-	hash := sha256.Sum256([]byte(key))
+// DecryptPassword расшифровывает пароль. Пароли старого формата, зашифрованные
+// без KDF и дополнительных данных, расшифровываются без проверки aad.
+func DecryptPassword(encryptedPassword, key string, aad []byte) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encryptedPassword)
+	if err != nil {
+		return "", err
+	}
 
-	block, err := aes.NewCipher(hash[:])
+	plaintext, err := openAnyFormat(ciphertext, KeyFromSecret(key), aad)
 	if err != nil {
 		return "", err
 	}
+	return string(plaintext), nil
+}
+
+// openAnyFormat расшифровывает шифротекст Seal или шифротекст старого формата.
+func openAnyFormat(ciphertext, key, aad []byte) ([]byte, error) {
+	// Старый шифротекст начинается со случайного nonce и может совпасть по первому
+	// байту с версией, поэтому при ошибке пробуется и старый формат
+	if len(ciphertext) > 0 && ciphertext[0] == ciphertextVersion {
+		if plaintext, err := Open(ciphertext, key, aad); err == nil {
+			return plaintext, nil
+		}
+	}
+	return openLegacy(ciphertext, key)
+}
+
+// openLegacy расшифровывает шифротекст старого формата: nonce и шифротекст
+// AES-256-GCM без дополнительных данных.
+func openLegacy(ciphertext, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
 
 	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	nonceSize := gcm.NonceSize()
 	if len(ciphertext) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
+		return nil, fmt.Errorf("ciphertext too short")
 	}
 
 	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
 
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+// newSealGCM создает AEAD шифр с ключом, полученным HKDF-SHA256 из secret и salt.
+func newSealGCM(secret, salt []byte) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, secret, salt, kdfInfo, 32)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return string(plaintext), nil
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
 }
@@ -2,6 +2,7 @@
 package crypto
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -10,7 +11,7 @@ func TestEncryptDecryptPassword(t *testing.T) {
 	key := "test_encryption_key"
 
 	// Шифрование пароля
-	encrypted, err := EncryptPassword(password, key)
+	encrypted, err := EncryptPassword(password, key, nil)
 	if err != nil {
 		t.Fatalf("Ошибка шифрования пароля: %v", err)
 	}
@@ -24,7 +25,7 @@ func TestEncryptDecryptPassword(t *testing.T) {
 	}
 
 	// Расшифровка пароля
-	decrypted, err := DecryptPassword(encrypted, key)
+	decrypted, err := DecryptPassword(encrypted, key, nil)
 	if err != nil {
 		t.Fatalf("Ошибка расшифровки пароля: %v", err)
 	}
@@ -40,13 +41,13 @@ func TestEncryptDecryptPassword_DifferentKeys(t *testing.T) {
 	key2 := "key2"
 
 	// Шифрование с первым ключом
-	encrypted, err := EncryptPassword(password, key1)
+	encrypted, err := EncryptPassword(password, key1, nil)
 	if err != nil {
 		t.Fatalf("Ошибка шифрования пароля: %v", err)
 	}
 
 	// Попытка расшифровки с другим ключом должна вернуть ошибку
-	_, err = DecryptPassword(encrypted, key2)
+	_, err = DecryptPassword(encrypted, key2, nil)
 	if err == nil {
 		t.Error("Расшифровка с неправильным ключом должна возвращать ошибку")
 	}
@@ -55,12 +56,12 @@ func TestEncryptDecryptPassword_DifferentKeys(t *testing.T) {
 func TestEncryptPassword_EmptyPassword(t *testing.T) {
 	key := "test_key"
 
-	encrypted, err := EncryptPassword("", key)
+	encrypted, err := EncryptPassword("", key, nil)
 	if err != nil {
 		t.Fatalf("Ошибка шифрования пустого пароля: %v", err)
 	}
 
-	decrypted, err := DecryptPassword(encrypted, key)
+	decrypted, err := DecryptPassword(encrypted, key, nil)
 	if err != nil {
 		t.Fatalf("Ошибка расшифровки пустого пароля: %v", err)
 	}
@@ -69,3 +70,54 @@ func TestEncryptPassword_EmptyPassword(t *testing.T) {
 		t.Errorf("Ожидался пустой пароль, получен %s", decrypted)
 	}
 }
+
+func TestEncryptPassword_BindsAAD(t *testing.T) {
+	aad := RecordAAD("user-1", "record-1")
+
+	encrypted, err := EncryptPassword("secret", "key", aad)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования пароля: %v", err)
+	}
+
+	if decrypted, err := DecryptPassword(encrypted, "key", aad); err != nil || decrypted != "secret" {
+		t.Errorf("Ожидалось 'secret', получено %q (ошибка %v)", decrypted, err)
+	}
+
+	// Шифротекст нельзя перенести в другую запись или к другому пользователю
+	for _, other := range [][]byte{RecordAAD("user-1", "record-2"), RecordAAD("user-2", "record-1"), nil} {
+		if _, err := DecryptPassword(encrypted, "key", other); err == nil {
+			t.Errorf("Расшифровка с дополнительными данными %q должна возвращать ошибку", other)
+		}
+	}
+}
+
+func TestDecryptPassword_LegacyFormat(t *testing.T) {
+	// Старый формат: AES-256-GCM с ключом SHA-256 от секрета без дополнительных данных
+	legacy, err := SealBlob([]byte("secret"), KeyFromSecret("key"), nil)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+
+	decrypted, err := DecryptPassword(legacy, "key", RecordAAD("user-1", "record-1"))
+	if err != nil || decrypted != "secret" {
+		t.Errorf("Ожидалось 'secret', получено %q (ошибка %v)", decrypted, err)
+	}
+}
+
+func TestSeal_UniqueSalt(t *testing.T) {
+	first, _ := Seal([]byte("secret"), []byte("key"), nil)
+	second, _ := Seal([]byte("secret"), []byte("key"), nil)
+
+	if first[0] != ciphertextVersion {
+		t.Errorf("Шифротекст должен начинаться с версии %d, получено %d", ciphertextVersion, first[0])
+	}
+	if bytes.Equal(first[1:1+kdfSaltSize], second[1:1+kdfSaltSize]) {
+		t.Error("Каждый шифротекст должен использовать свою соль")
+	}
+}
+
+func TestBindAAD_Unambiguous(t *testing.T) {
+	if bytes.Equal(BindAAD("ab", "c"), BindAAD("a", "bc")) {
+		t.Error("Разные наборы частей должны давать разные дополнительные данные")
+	}
+}
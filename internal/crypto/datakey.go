@@ -0,0 +1,146 @@
+// Package crypto содержит функции для шифрования и расшифровки данных.
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+// DataKeySize определяет размер ключа данных пользователя в байтах.
+const DataKeySize = 32
+
+// dataKeyVersion предваряет шифротекст Seal, созданный ключом данных пользователя.
+const dataKeyVersion = "dk2:"
+
+// vaultKeyVersion предваряет шифротекст Seal, созданный ключом данных командного хранилища.
+const vaultKeyVersion = "vk1:"
+
+// dataKeyLegacyVersion предваряет шифротекст ключа данных, созданный без KDF
+// и дополнительных данных.
+const dataKeyLegacyVersion = "dk1:"
+
+// NewDataKey генерирует случайный ключ данных пользователя.
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, DataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// WrapDataKey шифрует ключ данных пользователя userID текущим ключом провайдера.
+func WrapDataKey(provider KeyProvider, userID string, dataKey []byte) (string, error) {
+	return provider.Wrap([]byte(base64.StdEncoding.EncodeToString(dataKey)), DataKeyAAD(userID))
+}
+
+// UnwrapDataKey расшифровывает ключ данных пользователя userID, зашифрованный WrapDataKey.
+func UnwrapDataKey(provider KeyProvider, userID, wrapped string) ([]byte, error) {
+	return unwrapKey(provider, wrapped, DataKeyAAD(userID))
+}
+
+// WrapVaultKey шифрует ключ данных командного хранилища vaultID текущим ключом провайдера.
+func WrapVaultKey(provider KeyProvider, vaultID string, vaultKey []byte) (string, error) {
+	return provider.Wrap([]byte(base64.StdEncoding.EncodeToString(vaultKey)), VaultKeyAAD(vaultID))
+}
+
+// UnwrapVaultKey расшифровывает ключ данных командного хранилища vaultID, зашифрованный WrapVaultKey.
+func UnwrapVaultKey(provider KeyProvider, vaultID, wrapped string) ([]byte, error) {
+	return unwrapKey(provider, wrapped, VaultKeyAAD(vaultID))
+}
+
+// unwrapKey расшифровывает ключ данных, зашифрованный ключом провайдера и привязанный к aad.
+func unwrapKey(provider KeyProvider, wrapped string, aad []byte) ([]byte, error) {
+	encoded, err := provider.Unwrap(wrapped, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return nil, err
+	}
+	if len(dataKey) != DataKeySize {
+		return nil, errors.New("неверный размер ключа данных")
+	}
+	return dataKey, nil
+}
+
+// EncryptWithDataKey шифрует строку ключом данных пользователя и привязывает ее к aad.
+func EncryptWithDataKey(plaintext string, dataKey, aad []byte) (string, error) {
+	sealed, err := Seal([]byte(plaintext), dataKey, aad)
+	if err != nil {
+		return "", err
+	}
+	return dataKeyVersion + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// EncryptWithVaultKey шифрует строку ключом данных командного хранилища и привязывает ее к aad.
+func EncryptWithVaultKey(plaintext string, vaultKey, aad []byte) (string, error) {
+	sealed, err := Seal([]byte(plaintext), vaultKey, aad)
+	if err != nil {
+		return "", err
+	}
+	return vaultKeyVersion + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptWithVaultKey расшифровывает строку, зашифрованную EncryptWithVaultKey.
+func DecryptWithVaultKey(ciphertext string, vaultKey, aad []byte) (string, error) {
+	body, ok := strings.CutPrefix(ciphertext, vaultKeyVersion)
+	if !ok {
+		return "", errors.New("шифротекст создан не ключом хранилища")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := Open(sealed, vaultKey, aad)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// IsVaultKeyCiphertext сообщает, что шифротекст создан ключом данных командного хранилища.
+func IsVaultKeyCiphertext(ciphertext string) bool {
+	return strings.HasPrefix(ciphertext, vaultKeyVersion)
+}
+
+// DecryptWithDataKey расшифровывает строку, зашифрованную EncryptWithDataKey.
+// Шифротексты старого формата расшифровываются без проверки aad.
+func DecryptWithDataKey(ciphertext string, dataKey, aad []byte) (string, error) {
+	var (
+		plaintext []byte
+		err       error
+	)
+	if body, ok := strings.CutPrefix(ciphertext, dataKeyVersion); ok {
+		var sealed []byte
+		if sealed, err = base64.StdEncoding.DecodeString(body); err != nil {
+			return "", err
+		}
+		plaintext, err = Open(sealed, dataKey, aad)
+	} else if body, ok := strings.CutPrefix(ciphertext, dataKeyLegacyVersion); ok {
+		plaintext, err = OpenBlob(body, dataKey, nil)
+	} else {
+		return "", errors.New("шифротекст создан не ключом данных")
+	}
+
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// IsDataKeyCiphertext сообщает, что шифротекст создан ключом данных пользователя,
+// а не ключом провайдера.
+func IsDataKeyCiphertext(ciphertext string) bool {
+	return strings.HasPrefix(ciphertext, dataKeyVersion) || strings.HasPrefix(ciphertext, dataKeyLegacyVersion)
+}
+
+// IsBoundDataKeyCiphertext сообщает, что шифротекст создан ключом данных
+// пользователя и привязан к записи дополнительными данными.
+func IsBoundDataKeyCiphertext(ciphertext string) bool {
+	return strings.HasPrefix(ciphertext, dataKeyVersion)
+}
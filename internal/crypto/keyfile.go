@@ -0,0 +1,152 @@
+// Package crypto содержит функции для шифрования и расшифровки данных.
+package crypto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// keyfileVersion определяет версию формата файла ключей.
+const keyfileVersion = 1
+
+// ErrKeyfilePassphrase возвращается, когда файл ключей не расшифровывается парольной фразой.
+var ErrKeyfilePassphrase = errors.New("неверная парольная фраза файла ключей")
+
+// keyfile описывает файл ключей на диске. Ключи сервера хранятся в поле Sealed,
+// зашифрованные ключом, полученным из парольной фразы с помощью Argon2id.
+type keyfile struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	Salt    string `json:"salt"`
+	Sealed  string `json:"sealed"`
+}
+
+// keyfileKeys описывает расшифрованное содержимое файла ключей.
+type keyfileKeys struct {
+	Active string            `json:"active"`
+	Keys   map[string]string `json:"keys"`
+}
+
+// LoadKeyfile расшифровывает файл ключей парольной фразой и создает из него связку ключей.
+func LoadKeyfile(path, passphrase string) (*Keyring, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file keyfile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("неверный формат файла ключей: %w", err)
+	}
+	if file.Version != keyfileVersion || file.KDF != "argon2id" {
+		return nil, fmt.Errorf("неподдерживаемая версия файла ключей %d", file.Version)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(file.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("неверный формат файла ключей: %w", err)
+	}
+	plaintext, err := OpenBlob(file.Sealed, DeriveVaultKey(passphrase, salt), nil)
+	if err != nil {
+		return nil, ErrKeyfilePassphrase
+	}
+
+	var content keyfileKeys
+	if err := json.Unmarshal(plaintext, &content); err != nil {
+		return nil, fmt.Errorf("неверный формат файла ключей: %w", err)
+	}
+
+	keys := make(map[string][]byte, len(content.Keys))
+	for id, encoded := range content.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("неверный формат ключа %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return newKeyring(content.Active, keys)
+}
+
+// SaveKeyfile шифрует связку ключей парольной фразой и записывает ее в файл.
+// Файл заменяется целиком, поэтому прерванная запись не повреждает прежние ключи.
+func SaveKeyfile(path, passphrase string, keys *Keyring) error {
+	if passphrase == "" {
+		return errors.New("парольная фраза файла ключей не задана")
+	}
+
+	content := keyfileKeys{Active: keys.activeID, Keys: make(map[string]string, len(keys.keys))}
+	for id, key := range keys.keys {
+		content.Keys[id] = base64.StdEncoding.EncodeToString(key)
+	}
+	plaintext, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+
+	salt, err := NewSalt()
+	if err != nil {
+		return err
+	}
+	sealed, err := SealBlob(plaintext, DeriveVaultKey(passphrase, salt), nil)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.MarshalIndent(keyfile{
+		Version: keyfileVersion,
+		KDF:     "argon2id",
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		Sealed:  sealed,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// NewRandomKeyring создает связку из одного случайного ключа id.
+func NewRandomKeyring(id string) (*Keyring, error) {
+	key, err := NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+	return newKeyring(id, map[string][]byte{id: key})
+}
+
+// WithRandomKey возвращает копию связки с новым случайным ключом id, который
+// становится текущим. Прежние ключи остаются для расшифровки до перешифрования.
+func (k *Keyring) WithRandomKey(id string) (*Keyring, error) {
+	if _, exists := k.keys[id]; exists {
+		return nil, fmt.Errorf("ключ %q уже есть в связке", id)
+	}
+
+	key, err := NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string][]byte, len(k.keys)+1)
+	for existing, value := range k.keys {
+		keys[existing] = value
+	}
+	keys[id] = key
+	return newKeyring(id, keys)
+}
@@ -0,0 +1,56 @@
+// Package crypto содержит тесты для файла ключей.
+package crypto
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyfile_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	// Ключ из переменной окружения переносится в файл вместе с новым ключом
+	envKeys, _ := NewKeyring(DefaultKeyID, map[string]string{DefaultKeyID: "env-key"})
+	legacy, _ := SealBlob([]byte("secret"), KeyFromSecret("env-key"), nil)
+
+	keys, err := envKeys.WithRandomKey("2026")
+	if err != nil {
+		t.Fatalf("Ошибка добавления ключа: %v", err)
+	}
+	if err := SaveKeyfile(path, "passphrase", keys); err != nil {
+		t.Fatalf("Ошибка записи файла ключей: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Файл ключей не создан: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Файл ключей должен быть доступен только владельцу, права %v", info.Mode().Perm())
+	}
+
+	loaded, err := LoadKeyfile(path, "passphrase")
+	if err != nil {
+		t.Fatalf("Ошибка чтения файла ключей: %v", err)
+	}
+	if loaded.CurrentKeyID() != "2026" {
+		t.Errorf("Ожидался текущий ключ '2026', получен '%s'", loaded.CurrentKeyID())
+	}
+	if decrypted, err := loaded.Unwrap(legacy, nil); err != nil || string(decrypted) != "secret" {
+		t.Errorf("Ожидалось 'secret', получено %q (ошибка %v)", decrypted, err)
+	}
+
+	wrapped, _ := keys.Wrap([]byte("data key"), nil)
+	if decrypted, err := loaded.Unwrap(wrapped, nil); err != nil || string(decrypted) != "data key" {
+		t.Errorf("Ожидалось 'data key', получено %q (ошибка %v)", decrypted, err)
+	}
+
+	if _, err := LoadKeyfile(path, "wrong"); !errors.Is(err, ErrKeyfilePassphrase) {
+		t.Errorf("Ожидалась ошибка ErrKeyfilePassphrase, получена %v", err)
+	}
+	if _, err := loaded.WithRandomKey("2026"); err == nil {
+		t.Error("Ожидалась ошибка при добавлении существующего ключа")
+	}
+}
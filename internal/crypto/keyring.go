@@ -0,0 +1,153 @@
+// Package crypto содержит функции для шифрования и расшифровки данных.
+package crypto
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultKeyID идентифицирует ключ из CRYPTO_KEY. Этим ключом расшифровываются
+// данные, зашифрованные до появления идентификаторов ключей.
+const DefaultKeyID = "default"
+
+// keyringVersion предваряет шифротекст с идентификатором ключа: v2:<id ключа>:<шифротекст Seal>.
+// Base64 не содержит двоеточий, поэтому такой шифротекст не спутать со старым.
+const keyringVersion = "v2:"
+
+// keyringLegacyVersion предваряет шифротекст с идентификатором ключа, созданный
+// без KDF и дополнительных данных.
+const keyringLegacyVersion = "v1:"
+
+// ErrUnknownKey возвращается, когда шифротекст создан ключом, которого нет в связке.
+var ErrUnknownKey = errors.New("ключ шифрования не найден")
+
+// Keyring хранит ключи шифрования сервера локально и реализует KeyProvider.
+// Новые значения шифруются текущим ключом, остальные ключи используются только
+// для расшифровки, пока данные не перешифрованы.
+type Keyring struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// NewKeyring создает связку ключей из секретов, заданных в переменных окружения.
+// secrets сопоставляет идентификатор ключа с секретом.
+func NewKeyring(activeID string, secrets map[string]string) (*Keyring, error) {
+	keys := make(map[string][]byte, len(secrets))
+	for id, secret := range secrets {
+		if secret == "" {
+			return nil, fmt.Errorf("пустой секрет ключа %q", id)
+		}
+		keys[id] = KeyFromSecret(secret)
+	}
+	return newKeyring(activeID, keys)
+}
+
+// newKeyring создает связку из готовых 256-битных ключей.
+func newKeyring(activeID string, keys map[string][]byte) (*Keyring, error) {
+	for id, key := range keys {
+		if err := validateKeyID(id); err != nil {
+			return nil, err
+		}
+		if len(key) != VaultKeySize {
+			return nil, fmt.Errorf("неверный размер ключа %q", id)
+		}
+	}
+
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("активный ключ %q отсутствует в связке", activeID)
+	}
+	return &Keyring{activeID: activeID, keys: keys}, nil
+}
+
+// validateKeyID проверяет, что идентификатор ключа можно записать в шифротекст.
+func validateKeyID(id string) error {
+	if id == "" || strings.ContainsAny(id, ":,") {
+		return fmt.Errorf("недопустимый идентификатор ключа %q", id)
+	}
+	return nil
+}
+
+// ParseKeys разбирает список ключей вида "id1:секрет1,id2:секрет2".
+func ParseKeys(spec string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, secret, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("ключ %q должен иметь вид id:секрет", entry)
+		}
+		if _, exists := keys[id]; exists {
+			return nil, fmt.Errorf("ключ %q указан дважды", id)
+		}
+		keys[id] = secret
+	}
+	return keys, nil
+}
+
+// CurrentKeyID возвращает идентификатор ключа, которым шифруются новые значения.
+func (k *Keyring) CurrentKeyID() string {
+	return k.activeID
+}
+
+// Wrap шифрует значение текущим ключом, привязывает его к aad и добавляет
+// к шифротексту идентификатор ключа.
+func (k *Keyring) Wrap(plaintext, aad []byte) (string, error) {
+	sealed, err := Seal(plaintext, k.keys[k.activeID], aad)
+	if err != nil {
+		return "", err
+	}
+	return keyringVersion + k.activeID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Unwrap расшифровывает значение ключом, указанным в шифротексте.
+// Шифротекст без идентификатора расшифровывается ключом DefaultKeyID
+// как результат EncryptPassword, а шифротексты старого формата - без проверки aad.
+func (k *Keyring) Unwrap(wrapped string, aad []byte) ([]byte, error) {
+	id, body, bound := splitCiphertext(wrapped)
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return nil, err
+	}
+	if !bound {
+		return openAnyFormat(sealed, key, aad)
+	}
+	return Open(sealed, key, aad)
+}
+
+// ChunkKey возвращает ключ фрагментов файла по идентификатору.
+// Пустой идентификатор соответствует ключу DefaultKeyID.
+func (k *Keyring) ChunkKey(id string) ([]byte, error) {
+	if id == "" {
+		id = DefaultKeyID
+	}
+
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+	return key, nil
+}
+
+// splitCiphertext отделяет идентификатор ключа от шифротекста. bound сообщает,
+// что шифротекст создан Seal и привязан к дополнительным данным.
+func splitCiphertext(ciphertext string) (id, body string, bound bool) {
+	for _, version := range []string{keyringVersion, keyringLegacyVersion} {
+		if rest, ok := strings.CutPrefix(ciphertext, version); ok {
+			if id, body, ok := strings.Cut(rest, ":"); ok {
+				return id, body, version == keyringVersion
+			}
+		}
+	}
+	return DefaultKeyID, ciphertext, false
+}
@@ -0,0 +1,91 @@
+// Package crypto содержит тесты для связки ключей.
+package crypto
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeyring_WrapUnwrap(t *testing.T) {
+	keyring, err := NewKeyring("2025", map[string]string{DefaultKeyID: "old-key", "2025": "new-key"})
+	if err != nil {
+		t.Fatalf("Ошибка создания связки ключей: %v", err)
+	}
+
+	encrypted, err := keyring.Wrap([]byte("secret"), nil)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if !strings.HasPrefix(encrypted, "v2:2025:") || WrappedKeyID(encrypted) != "2025" {
+		t.Errorf("Шифротекст должен содержать идентификатор активного ключа, получен %q", encrypted)
+	}
+	if NeedsRewrap(keyring, encrypted) {
+		t.Error("Шифротекст активного ключа не требует перешифрования")
+	}
+
+	decrypted, err := keyring.Unwrap(encrypted, nil)
+	if err != nil || string(decrypted) != "secret" {
+		t.Errorf("Ожидалось 'secret', получено %q (ошибка %v)", decrypted, err)
+	}
+}
+
+func TestKeyring_LegacyCiphertext(t *testing.T) {
+	legacy, _ := SealBlob([]byte("secret"), KeyFromSecret("old-key"), nil)
+
+	keyring, _ := NewKeyring("2025", map[string]string{DefaultKeyID: "old-key", "2025": "new-key"})
+	if !NeedsRewrap(keyring, legacy) {
+		t.Error("Шифротекст без идентификатора ключа должен требовать перешифрования")
+	}
+
+	decrypted, err := keyring.Unwrap(legacy, nil)
+	if err != nil || string(decrypted) != "secret" {
+		t.Fatalf("Ожидалось 'secret', получено %q (ошибка %v)", decrypted, err)
+	}
+
+	rotated, err := Rewrap(keyring, legacy, nil)
+	if err != nil {
+		t.Fatalf("Ошибка перешифрования: %v", err)
+	}
+	if NeedsRewrap(keyring, rotated) {
+		t.Error("После перешифрования шифротекст должен использовать активный ключ")
+	}
+
+	// После удаления старого ключа перешифрованные данные остаются доступны
+	withoutOld, _ := NewKeyring("2025", map[string]string{"2025": "new-key"})
+	if decrypted, err := withoutOld.Unwrap(rotated, nil); err != nil || string(decrypted) != "secret" {
+		t.Errorf("Ожидалось 'secret', получено %q (ошибка %v)", decrypted, err)
+	}
+	if _, err := withoutOld.Unwrap(legacy, nil); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Ожидалась ошибка ErrUnknownKey, получена %v", err)
+	}
+}
+
+func TestNewKeyring_Invalid(t *testing.T) {
+	if _, err := NewKeyring("missing", map[string]string{"a": "secret"}); err == nil {
+		t.Error("Ожидалась ошибка для отсутствующего активного ключа")
+	}
+	if _, err := NewKeyring("a:b", map[string]string{"a:b": "secret"}); err == nil {
+		t.Error("Ожидалась ошибка для идентификатора с двоеточием")
+	}
+	if _, err := NewKeyring("a", map[string]string{"a": ""}); err == nil {
+		t.Error("Ожидалась ошибка для пустого секрета")
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys("2024:old:secret, 2025:new-secret")
+	if err != nil {
+		t.Fatalf("Ошибка разбора ключей: %v", err)
+	}
+	if keys["2024"] != "old:secret" || keys["2025"] != "new-secret" || len(keys) != 2 {
+		t.Errorf("Неверный результат разбора: %v", keys)
+	}
+
+	if _, err := ParseKeys("no-secret"); err == nil {
+		t.Error("Ожидалась ошибка для ключа без секрета")
+	}
+	if _, err := ParseKeys("a:1,a:2"); err == nil {
+		t.Error("Ожидалась ошибка для повторяющегося ключа")
+	}
+}
@@ -0,0 +1,59 @@
+// Package crypto содержит функции для шифрования и расшифровки данных.
+package crypto
+
+// KeyProvider шифрует ключи данных пользователей и другие секреты сервера ключом
+// шифрования ключей (KEK), которым управляет источник ключей: переменные окружения,
+// файл ключей или внешний KMS. Обработчики работают только с этим интерфейсом,
+// поэтому новый источник ключей не требует их изменения.
+//
+// Wrap возвращает шифротекст вида v2:<идентификатор ключа>:<шифротекст>,
+// по которому WrappedKeyID определяет ключ, а перешифрование находит значения,
+// созданные не текущим ключом или в старом формате. Дополнительные данные aad
+// привязывают шифротекст к его владельцу и назначению.
+type KeyProvider interface {
+	// CurrentKeyID возвращает идентификатор ключа, которым шифруются новые значения.
+	CurrentKeyID() string
+	// Wrap шифрует значение текущим ключом и привязывает его к aad.
+	Wrap(plaintext, aad []byte) (string, error)
+	// Unwrap расшифровывает значение ключом, указанным в шифротексте, и проверяет aad.
+	Unwrap(wrapped string, aad []byte) ([]byte, error)
+}
+
+// ChunkKeyProvider выдает ключи фрагментов файлов, загруженных до появления ключей
+// данных пользователей. Такие фрагменты зашифрованы ключом сервера напрямую, поэтому
+// их можно прочитать, только если источник ключей хранит ключи локально.
+type ChunkKeyProvider interface {
+	// ChunkKey возвращает ключ фрагментов файла по идентификатору ключа.
+	ChunkKey(id string) ([]byte, error)
+}
+
+// WrappedKeyID возвращает идентификатор ключа, которым создан шифротекст.
+// Шифротекст без идентификатора создан ключом DefaultKeyID.
+func WrappedKeyID(wrapped string) string {
+	id, _, _ := splitCiphertext(wrapped)
+	return id
+}
+
+// NeedsRewrap сообщает, что непустой шифротекст создан не текущим ключом провайдера
+// или в старом формате без дополнительных данных.
+func NeedsRewrap(provider KeyProvider, wrapped string) bool {
+	if wrapped == "" {
+		return false
+	}
+	id, _, bound := splitCiphertext(wrapped)
+	return id != provider.CurrentKeyID() || !bound
+}
+
+// Rewrap перешифровывает значение текущим ключом провайдера и привязывает его к aad.
+// Пустая строка и шифротекст текущего ключа возвращаются без изменений.
+func Rewrap(provider KeyProvider, wrapped string, aad []byte) (string, error) {
+	if !NeedsRewrap(provider, wrapped) {
+		return wrapped, nil
+	}
+
+	plaintext, err := provider.Unwrap(wrapped, aad)
+	if err != nil {
+		return "", err
+	}
+	return provider.Wrap(plaintext, aad)
+}
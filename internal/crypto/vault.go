@@ -0,0 +1,140 @@
+// Package crypto содержит функции для шифрования и расшифровки данных.
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Параметры Argon2id для получения ключа хранилища из мастер-пароля.
+const (
+	vaultKDFTime    = 3
+	vaultKDFMemory  = 64 * 1024
+	vaultKDFThreads = 4
+	// VaultKeySize определяет размер ключа хранилища в байтах.
+	VaultKeySize = 32
+	// SaltSize определяет размер соли для получения ключа.
+	SaltSize = 16
+)
+
+// NewSalt генерирует случайную соль для получения ключа.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// DeriveVaultKey получает ключ хранилища из мастер-пароля с помощью Argon2id.
+func DeriveVaultKey(masterPassword string, salt []byte) []byte {
+	return argon2.IDKey([]byte(masterPassword), salt, vaultKDFTime, vaultKDFMemory, vaultKDFThreads, VaultKeySize)
+}
+
+// VaultKeyCheck возвращает проверочное значение ключа хранилища в base64. Сервер хранит его
+// рядом с солью, и клиент по нему убеждается, что ключ получен из верного мастер-пароля.
+func VaultKeyCheck(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("gophkeeper-vault-key-check"))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SealBlob шифрует данные ключом хранилища с использованием AES-256-GCM и привязывает их к aad.
+func SealBlob(plaintext, key, aad []byte) (string, error) {
+	gcm, err := newVaultGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := gcm.Seal(nonce, nonce, plaintext, aad)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// OpenBlob расшифровывает данные, зашифрованные SealBlob с теми же aad.
+func OpenBlob(blob string, key, aad []byte) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(blob)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newVaultGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+// recordBlobVersion предваряет blob записи, привязанный к ней дополнительными данными RecordBlobAAD.
+// Blob без префикса созданы до появления версии формата.
+const recordBlobVersion = "rb2:"
+
+// SealRecordBlob шифрует секретные поля записи recordID типа dataType ключом хранилища
+// и привязывает blob к записи.
+func SealRecordBlob(plaintext, key []byte, recordID, dataType string) (string, error) {
+	blob, err := SealBlob(plaintext, key, RecordBlobAAD(recordID, dataType))
+	if err != nil {
+		return "", err
+	}
+	return recordBlobVersion + blob, nil
+}
+
+// OpenRecordBlob расшифровывает blob записи recordID типа dataType. Blob старого формата,
+// созданные до появления версии, расшифровываются и без привязки к записи.
+func OpenRecordBlob(blob string, key []byte, recordID, dataType string) ([]byte, error) {
+	aad := RecordBlobAAD(recordID, dataType)
+	if body, ok := strings.CutPrefix(blob, recordBlobVersion); ok {
+		return OpenBlob(body, key, aad)
+	}
+
+	plaintext, err := OpenBlob(blob, key, aad)
+	if err != nil {
+		plaintext, err = OpenBlob(blob, key, nil)
+	}
+	return plaintext, err
+}
+
+// IsLegacyRecordBlob сообщает, что blob записи создан в старом формате, который
+// расшифровывается без привязки к записи, и его следует зашифровать заново.
+func IsLegacyRecordBlob(blob string) bool {
+	return blob != "" && !strings.HasPrefix(blob, recordBlobVersion)
+}
+
+// ValidRecordBlob проверяет формат blob записи: base64 с префиксом версии или без него.
+func ValidRecordBlob(blob string) bool {
+	_, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(blob, recordBlobVersion))
+	return err == nil
+}
+
+// newVaultGCM создает AEAD шифр для ключа хранилища.
+func newVaultGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != VaultKeySize {
+		return nil, errors.New("неверный размер ключа хранилища")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
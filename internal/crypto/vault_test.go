@@ -0,0 +1,141 @@
+// Package crypto содержит тесты для шифрования на стороне клиента.
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeriveVaultKey(t *testing.T) {
+	salt, err := NewSalt()
+	if err != nil {
+		t.Fatalf("Ошибка генерации соли: %v", err)
+	}
+
+	key1 := DeriveVaultKey("master", salt)
+	key2 := DeriveVaultKey("master", salt)
+
+	if len(key1) != VaultKeySize {
+		t.Errorf("Ожидался ключ длиной %d, получен %d", VaultKeySize, len(key1))
+	}
+
+	if !bytes.Equal(key1, key2) {
+		t.Error("Ключи для одинакового пароля и соли должны совпадать")
+	}
+
+	otherSalt, _ := NewSalt()
+	if bytes.Equal(key1, DeriveVaultKey("master", otherSalt)) {
+		t.Error("Ключи для разных солей не должны совпадать")
+	}
+}
+
+func TestSealOpenBlob(t *testing.T) {
+	salt, _ := NewSalt()
+	key := DeriveVaultKey("master", salt)
+
+	aad := RecordBlobAAD("record-1", "text")
+	blob, err := SealBlob([]byte("secret payload"), key, aad)
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+
+	plaintext, err := OpenBlob(blob, key, aad)
+	if err != nil {
+		t.Fatalf("Ошибка расшифровки: %v", err)
+	}
+
+	if string(plaintext) != "secret payload" {
+		t.Errorf("Ожидался текст %q, получен %q", "secret payload", plaintext)
+	}
+
+	wrongKey := DeriveVaultKey("wrong", salt)
+	if _, err := OpenBlob(blob, wrongKey, aad); err == nil {
+		t.Error("Расшифровка с неверным ключом должна возвращать ошибку")
+	}
+
+	// Blob нельзя выдать за содержимое другой записи или другого типа
+	if _, err := OpenBlob(blob, key, RecordBlobAAD("record-2", "text")); err == nil {
+		t.Error("Расшифровка blob другой записи должна возвращать ошибку")
+	}
+	if _, err := OpenBlob(blob, key, RecordBlobAAD("record-1", "card")); err == nil {
+		t.Error("Расшифровка blob другого типа должна возвращать ошибку")
+	}
+}
+
+func TestSealOpenRecordBlob(t *testing.T) {
+	salt, _ := NewSalt()
+	key := DeriveVaultKey("master", salt)
+
+	blob, err := SealRecordBlob([]byte("secret payload"), key, "record-1", "text")
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if IsLegacyRecordBlob(blob) || !ValidRecordBlob(blob) {
+		t.Errorf("Ожидался blob текущего формата, получен %q", blob)
+	}
+
+	if plaintext, err := OpenRecordBlob(blob, key, "record-1", "text"); err != nil || string(plaintext) != "secret payload" {
+		t.Errorf("Ожидался текст %q, получен %q (ошибка %v)", "secret payload", plaintext, err)
+	}
+	if _, err := OpenRecordBlob(blob, key, "record-2", "text"); err == nil {
+		t.Error("Blob другой записи не должен расшифровываться")
+	}
+
+	// Без привязки расшифровываются только blob старого формата
+	legacy, _ := SealBlob([]byte("legacy payload"), key, nil)
+	if !IsLegacyRecordBlob(legacy) {
+		t.Error("Blob без версии должен считаться blob старого формата")
+	}
+	if plaintext, err := OpenRecordBlob(legacy, key, "record-2", "text"); err != nil || string(plaintext) != "legacy payload" {
+		t.Errorf("Ожидался текст %q, получен %q (ошибка %v)", "legacy payload", plaintext, err)
+	}
+	if _, err := OpenRecordBlob(recordBlobVersion+legacy, key, "record-2", "text"); err == nil {
+		t.Error("Blob текущего формата без привязки к записи не должен расшифровываться")
+	}
+}
+
+func TestVaultKeyCheck(t *testing.T) {
+	salt, _ := NewSalt()
+	key := DeriveVaultKey("master", salt)
+
+	if VaultKeyCheck(key) != VaultKeyCheck(DeriveVaultKey("master", salt)) {
+		t.Error("Проверочные значения одного ключа должны совпадать")
+	}
+	if VaultKeyCheck(key) == VaultKeyCheck(DeriveVaultKey("wrong", salt)) {
+		t.Error("Проверочные значения разных ключей не должны совпадать")
+	}
+}
+
+func TestSealBlob_InvalidKey(t *testing.T) {
+	if _, err := SealBlob([]byte("data"), []byte("short"), nil); err == nil {
+		t.Error("Шифрование с ключом неверного размера должно возвращать ошибку")
+	}
+}
+
+func TestSealOpenChunk(t *testing.T) {
+	key := KeyFromSecret("server-secret")
+	chunk := []byte("chunk of a keystore")
+
+	sealed, err := SealChunk(chunk, key, ChunkAAD("file-id", 3))
+	if err != nil {
+		t.Fatalf("Ошибка шифрования фрагмента: %v", err)
+	}
+
+	if len(sealed) != len(chunk)+ChunkOverhead {
+		t.Errorf("Ожидался размер %d, получен %d", len(chunk)+ChunkOverhead, len(sealed))
+	}
+
+	opened, err := OpenChunk(sealed, key, ChunkAAD("file-id", 3))
+	if err != nil {
+		t.Fatalf("Ошибка расшифровки фрагмента: %v", err)
+	}
+
+	if string(opened) != string(chunk) {
+		t.Errorf("Ожидалось %q, получено %q", chunk, opened)
+	}
+
+	// Фрагмент, перенесенный на другую позицию, не расшифровывается
+	if _, err := OpenChunk(sealed, key, ChunkAAD("file-id", 4)); err == nil {
+		t.Error("Ожидалась ошибка для фрагмента с другой позицией")
+	}
+}
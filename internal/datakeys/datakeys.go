@@ -0,0 +1,222 @@
+// Package datakeys управляет ключами данных пользователей и командных хранилищ.
+//
+// Секреты каждого пользователя шифруются его собственным случайным ключом данных,
+// а сам ключ хранится в записи пользователя зашифрованным ключом сервера. Записи
+// командного хранилища шифруются ключом данных хранилища, поэтому не зависят от ключей
+// участников. Ротация ключа сервера требует перешифровать только ключи данных,
+// а удаление ключа данных делает данные его владельца нерасшифровываемыми.
+package datakeys
+
+import (
+	"errors"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Service выдает ключи данных пользователей и хранилищ, создавая их при первом обращении.
+type Service struct {
+	users  repository.UserRepositoryInterface
+	vaults repository.VaultRepositoryInterface
+	keys   crypto.KeyProvider
+}
+
+// NewService создает сервис ключей данных. keys шифрует ключи данных в записях
+// пользователей и хранилищ.
+func NewService(users repository.UserRepositoryInterface, vaults repository.VaultRepositoryInterface, keys crypto.KeyProvider) *Service {
+	return &Service{users: users, vaults: vaults, keys: keys}
+}
+
+// KeyProvider возвращает источник ключей сервера.
+func (s *Service) KeyProvider() crypto.KeyProvider {
+	return s.keys
+}
+
+// Key возвращает ключ данных пользователя. Пользователю, зарегистрированному
+// до появления ключей данных, ключ создается при первом обращении.
+func (s *Service) Key(userID uuid.UUID) ([]byte, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DataKey != "" {
+		return crypto.UnwrapDataKey(s.keys, userID.String(), user.DataKey)
+	}
+
+	dataKey, err := crypto.NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := crypto.WrapDataKey(s.keys, userID.String(), dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.users.ReplaceDataKey(userID, "", wrapped)
+	if errors.Is(err, repository.ErrCiphertextChanged) {
+		// Ключ одновременно создан другим запросом, используется он
+		user, err = s.users.GetByID(userID)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.UnwrapDataKey(s.keys, userID.String(), user.DataKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dataKey, nil
+}
+
+// VaultKey возвращает ключ данных хранилища. Хранилищу, созданному до появления
+// ключей хранилищ, ключ создается при первом обращении.
+func (s *Service) VaultKey(vaultID uuid.UUID) ([]byte, error) {
+	vault, err := s.vaults.GetByID(vaultID)
+	if err != nil {
+		return nil, err
+	}
+	if vault.DataKey != "" {
+		return crypto.UnwrapVaultKey(s.keys, vaultID.String(), vault.DataKey)
+	}
+
+	vaultKey, err := crypto.NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := crypto.WrapVaultKey(s.keys, vaultID.String(), vaultKey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.vaults.ReplaceDataKey(vaultID, "", wrapped)
+	if errors.Is(err, repository.ErrCiphertextChanged) {
+		// Ключ одновременно создан другим запросом, используется он
+		vault, err = s.vaults.GetByID(vaultID)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.UnwrapVaultKey(s.keys, vaultID.String(), vault.DataKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return vaultKey, nil
+}
+
+// ForUser возвращает шифрование личных данных пользователя userID.
+// Ключ данных загружается при первом использовании и запоминается.
+func (s *Service) ForUser(userID uuid.UUID) *Keys {
+	return &Keys{service: s, userID: userID}
+}
+
+// ForVault возвращает шифрование записей хранилища vaultID. creatorID указывает
+// создателя записей: записи, зашифрованные до появления ключей хранилищ, расшифровываются
+// его ключом данных.
+func (s *Service) ForVault(vaultID, creatorID uuid.UUID) *Keys {
+	return &Keys{service: s, userID: creatorID, vaultID: &vaultID}
+}
+
+// ForRecord возвращает шифрование записи data: ключ хранилища для записей хранилища
+// и ключ данных владельца для личных записей.
+func (s *Service) ForRecord(data *models.Data) *Keys {
+	if data.VaultID != nil {
+		return s.ForVault(*data.VaultID, data.UserID)
+	}
+	return s.ForUser(data.UserID)
+}
+
+// Keys шифрует и расшифровывает данные одного пользователя или одного хранилища.
+// Используется в рамках одного запроса и не безопасен для параллельного использования.
+type Keys struct {
+	service  *Service
+	userID   uuid.UUID
+	vaultID  *uuid.UUID
+	key      []byte
+	vaultKey []byte
+}
+
+// Encrypt шифрует строку ключом данных пользователя или хранилища и привязывает ее к записи recordID.
+func (k *Keys) Encrypt(recordID uuid.UUID, plaintext string) (string, error) {
+	if k.vaultID != nil {
+		key, err := k.loadVaultKey()
+		if err != nil {
+			return "", err
+		}
+		return crypto.EncryptWithVaultKey(plaintext, key, crypto.VaultRecordAAD(k.vaultID.String(), recordID.String()))
+	}
+
+	key, err := k.dataKey()
+	if err != nil {
+		return "", err
+	}
+	return crypto.EncryptWithDataKey(plaintext, key, k.recordAAD(recordID))
+}
+
+// Decrypt расшифровывает строку записи recordID. Данные, сохраненные до появления
+// ключей данных, расшифровываются ключом сервера, а записи хранилища, сохраненные
+// до появления ключей хранилищ, - ключом данных их создателя.
+func (k *Keys) Decrypt(recordID uuid.UUID, ciphertext string) (string, error) {
+	if crypto.IsVaultKeyCiphertext(ciphertext) {
+		if k.vaultID == nil {
+			return "", errors.New("запись зашифрована ключом хранилища")
+		}
+		key, err := k.loadVaultKey()
+		if err != nil {
+			return "", err
+		}
+		return crypto.DecryptWithVaultKey(ciphertext, key, crypto.VaultRecordAAD(k.vaultID.String(), recordID.String()))
+	}
+
+	if !crypto.IsDataKeyCiphertext(ciphertext) {
+		plaintext, err := k.service.keys.Unwrap(ciphertext, k.recordAAD(recordID))
+		if err != nil {
+			return "", err
+		}
+		return string(plaintext), nil
+	}
+
+	key, err := k.dataKey()
+	if err != nil {
+		return "", err
+	}
+	return crypto.DecryptWithDataKey(ciphertext, key, k.recordAAD(recordID))
+}
+
+// Current сообщает, что шифротекст создан тем ключом, которым Encrypt шифрует
+// новые значения, и привязан к записи.
+func (k *Keys) Current(ciphertext string) bool {
+	if k.vaultID != nil {
+		return crypto.IsVaultKeyCiphertext(ciphertext)
+	}
+	return crypto.IsBoundDataKeyCiphertext(ciphertext)
+}
+
+// recordAAD возвращает дополнительные данные, привязывающие шифротекст к записи пользователя.
+func (k *Keys) recordAAD(recordID uuid.UUID) []byte {
+	return crypto.RecordAAD(k.userID.String(), recordID.String())
+}
+
+// dataKey возвращает ключ данных пользователя, загружая его при первом вызове.
+func (k *Keys) dataKey() ([]byte, error) {
+	if k.key == nil {
+		key, err := k.service.Key(k.userID)
+		if err != nil {
+			return nil, err
+		}
+		k.key = key
+	}
+	return k.key, nil
+}
+
+// loadVaultKey возвращает ключ данных хранилища, загружая его при первом вызове.
+func (k *Keys) loadVaultKey() ([]byte, error) {
+	if k.vaultKey == nil {
+		key, err := k.service.VaultKey(*k.vaultID)
+		if err != nil {
+			return nil, err
+		}
+		k.vaultKey = key
+	}
+	return k.vaultKey, nil
+}
@@ -0,0 +1,138 @@
+// Package datakeys содержит тесты для ключей данных пользователей.
+package datakeys
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/google/uuid"
+)
+
+// newTestService создает сервис с двумя пользователями без ключей данных.
+func newTestService(t *testing.T) (*Service, repository.UserRepositoryInterface, *models.User, *models.User) {
+	service, users, _, alice, bob := newTestVaultService(t)
+	return service, users, alice, bob
+}
+
+// newTestVaultService создает сервис с двумя пользователями и доступом к хранилищам.
+func newTestVaultService(t *testing.T) (*Service, repository.UserRepositoryInterface, repository.VaultRepositoryInterface, *models.User, *models.User) {
+	t.Helper()
+
+	keys, err := crypto.NewKeyring(crypto.DefaultKeyID, map[string]string{crypto.DefaultKeyID: "server-key"})
+	if err != nil {
+		t.Fatalf("Ошибка создания связки ключей: %v", err)
+	}
+
+	memRepo := repository.NewMemoryRepository()
+	users := memRepo.NewUserRepository()
+	alice := &models.User{Username: "alice", Email: "alice@example.com"}
+	bob := &models.User{Username: "bob", Email: "bob@example.com"}
+	users.Create(alice)
+	users.Create(bob)
+
+	vaults := memRepo.NewVaultRepository()
+	return NewService(users, vaults, keys), users, vaults, alice, bob
+}
+
+func TestService_KeyCreatedOnce(t *testing.T) {
+	service, users, alice, bob := newTestService(t)
+
+	first, err := service.Key(alice.ID)
+	if err != nil {
+		t.Fatalf("Ошибка получения ключа: %v", err)
+	}
+	second, _ := service.Key(alice.ID)
+	if !bytes.Equal(first, second) {
+		t.Error("Ключ данных должен создаваться один раз")
+	}
+
+	stored, _ := users.GetByID(alice.ID)
+	if stored.DataKey == "" {
+		t.Error("Ключ данных должен сохраняться в записи пользователя")
+	}
+
+	other, _ := service.Key(bob.ID)
+	if bytes.Equal(first, other) {
+		t.Error("У разных пользователей должны быть разные ключи данных")
+	}
+}
+
+func TestUserKeys_EncryptDecrypt(t *testing.T) {
+	service, _, alice, bob := newTestService(t)
+	recordID := uuid.New()
+
+	encrypted, err := service.ForUser(alice.ID).Encrypt(recordID, "secret")
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if !crypto.IsDataKeyCiphertext(encrypted) {
+		t.Errorf("Ожидался шифротекст ключа данных, получен %q", encrypted)
+	}
+
+	if decrypted, err := service.ForUser(alice.ID).Decrypt(recordID, encrypted); err != nil || decrypted != "secret" {
+		t.Errorf("Ожидалось 'secret', получено %q (ошибка %v)", decrypted, err)
+	}
+	if _, err := service.ForUser(bob.ID).Decrypt(recordID, encrypted); err == nil {
+		t.Error("Данные одного пользователя не должны расшифровываться ключом другого")
+	}
+	if _, err := service.ForUser(alice.ID).Decrypt(uuid.New(), encrypted); err == nil {
+		t.Error("Шифротекст, перенесенный в другую запись, не должен расшифровываться")
+	}
+
+	// Данные, сохраненные до появления ключей данных, расшифровываются ключом сервера
+	legacy, _ := crypto.SealBlob([]byte("legacy"), crypto.KeyFromSecret("server-key"), nil)
+	if decrypted, err := service.ForUser(alice.ID).Decrypt(recordID, legacy); err != nil || decrypted != "legacy" {
+		t.Errorf("Ожидалось 'legacy', получено %q (ошибка %v)", decrypted, err)
+	}
+}
+
+func TestService_CryptoShredding(t *testing.T) {
+	service, users, alice, _ := newTestService(t)
+
+	recordID := uuid.New()
+	encrypted, _ := service.ForUser(alice.ID).Encrypt(recordID, "secret")
+
+	// Удаление ключа данных делает данные пользователя нерасшифровываемыми
+	stored, _ := users.GetByID(alice.ID)
+	if err := users.ReplaceDataKey(alice.ID, stored.DataKey, ""); err != nil {
+		t.Fatalf("Ошибка удаления ключа данных: %v", err)
+	}
+
+	if _, err := service.ForUser(alice.ID).Decrypt(recordID, encrypted); err == nil {
+		t.Error("После удаления ключа данных расшифровка должна завершаться ошибкой")
+	}
+}
+
+func TestService_VaultKeys(t *testing.T) {
+	service, users, vaults, alice, bob := newTestVaultService(t)
+
+	vault := &models.Vault{Name: "Team"}
+	vaults.Create(vault, &models.VaultMember{UserID: alice.ID, Role: models.VaultRoleOwner})
+	record := &models.Data{ID: uuid.New(), UserID: alice.ID, VaultID: &vault.ID}
+
+	encrypted, err := service.ForRecord(record).Encrypt(record.ID, "team secret")
+	if err != nil {
+		t.Fatalf("Ошибка шифрования: %v", err)
+	}
+	if !crypto.IsVaultKeyCiphertext(encrypted) {
+		t.Errorf("Запись хранилища должна шифроваться ключом хранилища, получено %q", encrypted)
+	}
+	if _, err := service.ForUser(alice.ID).Decrypt(record.ID, encrypted); err == nil {
+		t.Error("Запись хранилища не должна расшифровываться личным ключом создателя")
+	}
+
+	// Удаление ключа данных создателя не затрагивает записи хранилища
+	service.Key(alice.ID)
+	stored, _ := users.GetByID(alice.ID)
+	if err := users.ReplaceDataKey(alice.ID, stored.DataKey, ""); err != nil {
+		t.Fatalf("Ошибка удаления ключа данных: %v", err)
+	}
+
+	// Другой участник расшифровывает запись тем же ключом хранилища
+	if decrypted, err := service.ForVault(vault.ID, bob.ID).Decrypt(record.ID, encrypted); err != nil || decrypted != "team secret" {
+		t.Errorf("Ожидалось 'team secret', получено %q (ошибка %v)", decrypted, err)
+	}
+}
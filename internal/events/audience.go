@@ -0,0 +1,65 @@
+// Package events содержит рассылку изменений записей подписчикам.
+package events
+
+import (
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/google/uuid"
+)
+
+// Audience определяет пользователей, которые должны получать события об изменениях записи.
+type Audience interface {
+	Recipients(data *models.Data) ([]uuid.UUID, error)
+}
+
+// ShareLister возвращает доступы к записи; реализуется репозиторием доступов.
+type ShareLister interface {
+	GetByDataID(dataID uuid.UUID) ([]models.Share, error)
+}
+
+// MemberLister возвращает участников хранилища; реализуется репозиторием хранилищ.
+type MemberLister interface {
+	GetMembers(vaultID uuid.UUID) ([]models.VaultMember, error)
+}
+
+// RecordAudience отправляет события всем, у кого есть доступ к записи: участникам
+// хранилища для записей хранилищ, а для личных записей владельцу. Получатели
+// действующих доступов к записи получают события в обоих случаях.
+type RecordAudience struct {
+	shares ShareLister
+	vaults MemberLister
+}
+
+// NewRecordAudience создает получателей событий по доступам shares и участникам хранилищ vaults.
+func NewRecordAudience(shares ShareLister, vaults MemberLister) *RecordAudience {
+	return &RecordAudience{shares: shares, vaults: vaults}
+}
+
+// Recipients возвращает пользователей, которым доступна запись data.
+func (ra *RecordAudience) Recipients(data *models.Data) ([]uuid.UUID, error) {
+	var recipients []uuid.UUID
+	if data.VaultID != nil {
+		members, err := ra.vaults.GetMembers(*data.VaultID)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			recipients = append(recipients, member.UserID)
+		}
+	} else {
+		recipients = append(recipients, data.UserID)
+	}
+
+	shares, err := ra.shares.GetByDataID(data.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range shares {
+		if shares[i].Active(now) {
+			recipients = append(recipients, shares[i].GranteeID)
+		}
+	}
+	return recipients, nil
+}
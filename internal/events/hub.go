@@ -0,0 +1,159 @@
+// Package events содержит рассылку изменений записей подписчикам.
+package events
+
+import (
+	"sync"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/logger"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// subscriberBuffer задает число событий, которые подписчик может не успеть прочитать.
+const subscriberBuffer = 64
+
+// Event описывает изменение записи пользователя.
+// Секретное содержимое записи передается в том же зашифрованном виде, что и в базе данных.
+type Event struct {
+	Action   models.RevisionAction
+	Data     models.Data
+	ClientID string // Устройство, с которого сделано изменение
+}
+
+// Relay пересылает события через шину, общую для нескольких экземпляров сервера.
+// Экземпляр, отправивший событие, получает его из шины так же, как остальные.
+type Relay interface {
+	Send(event Event) error
+}
+
+// Hub рассылает события об изменениях записей подписчикам, которым доступна запись.
+// Пока получатели не заданы через SetAudience, события получает только владелец записи.
+// Нулевой *Hub допустим и не рассылает события.
+type Hub struct {
+	mutex       sync.Mutex
+	subscribers map[uuid.UUID]map[chan Event]struct{}
+	relay       Relay
+	audience    Audience
+}
+
+// NewHub создает новую рассылку событий.
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[uuid.UUID]map[chan Event]struct{})}
+}
+
+// SetRelay направляет события через шину relay. Вызывается до начала рассылки.
+func (h *Hub) SetRelay(relay Relay) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.relay = relay
+}
+
+// SetAudience задает, кому доступны записи. Вызывается до начала рассылки.
+func (h *Hub) SetAudience(audience Audience) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.audience = audience
+}
+
+// Subscribe подписывает на изменения записей пользователя.
+// Канал закрывается при отписке, а также если подписчик не успевает читать события:
+// в этом случае он должен заново загрузить записи и подписаться снова.
+func (h *Hub) Subscribe(userID uuid.UUID) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mutex.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan Event]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mutex.Unlock()
+
+	return ch, func() {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		h.remove(userID, ch)
+	}
+}
+
+// Publish отправляет событие подписчикам, которым доступна запись, не дожидаясь их.
+// Если шина недоступна, событие получают только подписчики этого экземпляра.
+func (h *Hub) Publish(event Event) {
+	if h == nil {
+		return
+	}
+
+	h.mutex.Lock()
+	relay := h.relay
+	h.mutex.Unlock()
+
+	if relay != nil && relay.Send(event) == nil {
+		return
+	}
+	h.deliver(event)
+}
+
+// deliver отправляет событие подписчикам этого экземпляра. Получатели определяются
+// на каждом экземпляре, поэтому события из шины учитывают текущие права доступа.
+func (h *Hub) deliver(event Event) {
+	recipients := h.recipients(&event.Data)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for _, userID := range recipients {
+		for ch := range h.subscribers[userID] {
+			select {
+			case ch <- event:
+			default:
+				h.remove(userID, ch)
+			}
+		}
+	}
+}
+
+// recipients возвращает пользователей, которым доступна запись, без повторов.
+// Если получателей определить не удалось, событие не отправляется никому: права
+// на запись могли измениться, и владелец записи хранилища мог потерять к ней доступ.
+func (h *Hub) recipients(data *models.Data) []uuid.UUID {
+	h.mutex.Lock()
+	audience := h.audience
+	h.mutex.Unlock()
+
+	if audience == nil {
+		return []uuid.UUID{data.UserID}
+	}
+
+	users, err := audience.Recipients(data)
+	if err != nil {
+		logger.Logger.Error("Ошибка определения получателей события", zap.Error(err))
+		return nil
+	}
+
+	seen := make(map[uuid.UUID]bool, len(users))
+	recipients := users[:0]
+	for _, userID := range users {
+		if !seen[userID] {
+			seen[userID] = true
+			recipients = append(recipients, userID)
+		}
+	}
+	return recipients
+}
+
+// remove отписывает канал и закрывает его. Вызывается под блокировкой.
+func (h *Hub) remove(userID uuid.UUID, ch chan Event) {
+	subscribers, ok := h.subscribers[userID]
+	if !ok {
+		return
+	}
+	if _, ok := subscribers[ch]; !ok {
+		return
+	}
+
+	delete(subscribers, ch)
+	close(ch)
+	if len(subscribers) == 0 {
+		delete(h.subscribers, userID)
+	}
+}
@@ -0,0 +1,157 @@
+// Package events содержит тесты для рассылки событий.
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestHub_Publish(t *testing.T) {
+	hub := NewHub()
+	userID := uuid.New()
+
+	events, unsubscribe := hub.Subscribe(userID)
+	other, unsubscribeOther := hub.Subscribe(uuid.New())
+	defer unsubscribeOther()
+
+	hub.Publish(Event{Action: models.RevisionCreate, Data: models.Data{UserID: userID, Name: "Mail"}})
+
+	select {
+	case event := <-events:
+		if event.Action != models.RevisionCreate || event.Data.Name != "Mail" {
+			t.Errorf("Неожиданное событие %+v", event)
+		}
+	default:
+		t.Fatal("Подписчик должен получить событие")
+	}
+
+	select {
+	case event := <-other:
+		t.Errorf("Событие не должно отправляться другому пользователю: %+v", event)
+	default:
+	}
+
+	unsubscribe()
+	if _, ok := <-events; ok {
+		t.Error("Канал должен быть закрыт после отписки")
+	}
+
+	// Повторная отписка не должна приводить к ошибке
+	unsubscribe()
+}
+
+func TestHub_SlowSubscriber(t *testing.T) {
+	hub := NewHub()
+	userID := uuid.New()
+
+	events, unsubscribe := hub.Subscribe(userID)
+	defer unsubscribe()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		hub.Publish(Event{Action: models.RevisionUpdate, Data: models.Data{UserID: userID}})
+	}
+
+	received := 0
+	for range events {
+		received++
+	}
+
+	if received != subscriberBuffer {
+		t.Errorf("Ожидалось %d событий до отключения подписчика, получено %d", subscriberBuffer, received)
+	}
+}
+
+func TestHub_NilPublish(t *testing.T) {
+	var hub *Hub
+	hub.Publish(Event{Action: models.RevisionCreate})
+}
+
+// fakeRelay передает события в hub, как шина, или возвращает ошибку.
+type fakeRelay struct {
+	hub  *Hub
+	err  error
+	sent int
+}
+
+func (r *fakeRelay) Send(event Event) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.sent++
+	r.hub.deliver(event)
+	return nil
+}
+
+func TestHub_Relay(t *testing.T) {
+	hub := NewHub()
+	userID := uuid.New()
+	relay := &fakeRelay{hub: hub}
+	hub.SetRelay(relay)
+
+	events, unsubscribe := hub.Subscribe(userID)
+	defer unsubscribe()
+
+	hub.Publish(Event{Action: models.RevisionUpdate, Data: models.Data{UserID: userID}})
+	if relay.sent != 1 || len(events) != 1 {
+		t.Fatalf("Событие должно пройти через шину один раз: отправлено %d, получено %d", relay.sent, len(events))
+	}
+	<-events
+
+	// Если шина недоступна, событие получают подписчики этого экземпляра
+	relay.err = errors.New("нет соединения")
+	hub.Publish(Event{Action: models.RevisionDelete, Data: models.Data{UserID: userID}})
+	if relay.sent != 1 || len(events) != 1 {
+		t.Errorf("Ожидалась локальная доставка события: отправлено %d, получено %d", relay.sent, len(events))
+	}
+}
+
+// fakeShares и fakeMembers хранят доступы к записям и участников хранилищ.
+type fakeShares map[uuid.UUID][]models.Share
+
+func (f fakeShares) GetByDataID(dataID uuid.UUID) ([]models.Share, error) {
+	return f[dataID], nil
+}
+
+type fakeMembers map[uuid.UUID][]models.VaultMember
+
+func (f fakeMembers) GetMembers(vaultID uuid.UUID) ([]models.VaultMember, error) {
+	return f[vaultID], nil
+}
+
+func TestHub_Audience(t *testing.T) {
+	ownerID, memberID, granteeID, expiredID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	vaultID, personalID := uuid.New(), uuid.New()
+	past := time.Now().Add(-time.Hour)
+
+	hub := NewHub()
+	hub.SetAudience(NewRecordAudience(
+		fakeShares{personalID: {{GranteeID: granteeID}, {GranteeID: expiredID, ExpiresAt: &past}}},
+		fakeMembers{vaultID: {{UserID: ownerID}, {UserID: memberID}}},
+	))
+
+	subscribe := func(userID uuid.UUID) <-chan Event {
+		events, unsubscribe := hub.Subscribe(userID)
+		t.Cleanup(unsubscribe)
+		return events
+	}
+	owner, member, grantee, expired := subscribe(ownerID), subscribe(memberID), subscribe(granteeID), subscribe(expiredID)
+
+	// Событие записи хранилища получают все его участники, в том числе не создававшие запись
+	hub.Publish(Event{Action: models.RevisionCreate, Data: models.Data{ID: uuid.New(), UserID: ownerID, VaultID: &vaultID}})
+	if len(owner) != 1 || len(member) != 1 || len(grantee) != 0 {
+		t.Errorf("Ожидалось событие у участников хранилища: владелец %d, участник %d, получатель %d", len(owner), len(member), len(grantee))
+	}
+	<-owner
+	<-member
+
+	// Событие личной записи получают владелец и получатели действующих доступов
+	hub.Publish(Event{Action: models.RevisionUpdate, Data: models.Data{ID: personalID, UserID: ownerID}})
+	if len(owner) != 1 || len(grantee) != 1 || len(member) != 0 || len(expired) != 0 {
+		t.Errorf("Ожидалось событие у владельца и получателя доступа: владелец %d, получатель %d, участник %d, истекший доступ %d",
+			len(owner), len(grantee), len(member), len(expired))
+	}
+}
@@ -0,0 +1,140 @@
+// Package events содержит рассылку изменений записей подписчикам.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/logger"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// postgresChannel задает канал уведомлений PostgreSQL, через который передаются события.
+const postgresChannel = "gophkeeper_events"
+
+// maxNotifyPayload ограничивает размер уведомления: PostgreSQL не принимает уведомления от 8000 байт.
+const maxNotifyPayload = 7999
+
+// relayRetryDelay задает паузу перед повторным подключением к шине после ошибки.
+const relayRetryDelay = 5 * time.Second
+
+// notification представляет событие в уведомлении PostgreSQL.
+// Зашифрованное содержимое записи в уведомление не входит.
+type notification struct {
+	Action   models.RevisionAction `json:"action"`
+	Data     models.Data           `json:"data"`
+	ClientID string                `json:"client_id"`
+}
+
+// PostgresRelay передает события между экземплярами сервера через LISTEN/NOTIFY PostgreSQL.
+type PostgresRelay struct {
+	db *gorm.DB
+}
+
+// NewPostgresRelay создает шину событий в базе данных db.
+func NewPostgresRelay(db *gorm.DB) *PostgresRelay {
+	return &PostgresRelay{db: db}
+}
+
+// Send отправляет событие всем экземплярам, подписанным на канал.
+func (pr *PostgresRelay) Send(event Event) error {
+	payload, err := encodeNotification(event)
+	if err != nil {
+		return err
+	}
+	return pr.db.Exec("SELECT pg_notify(?, ?)", postgresChannel, string(payload)).Error
+}
+
+// Listen подключает шину к hub и отправляет его подписчикам события из шины до отмены ctx.
+// Пока соединение с шиной потеряно, hub рассылает события только подписчикам этого экземпляра.
+func (pr *PostgresRelay) Listen(ctx context.Context, hub *Hub) {
+	for {
+		err := pr.listen(ctx, hub)
+		hub.SetRelay(nil)
+		if ctx.Err() != nil {
+			return
+		}
+
+		logger.Logger.Warn("Соединение с шиной событий потеряно", zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(relayRetryDelay):
+		}
+	}
+}
+
+// listen подписывается на канал на отдельном соединении и получает уведомления, пока соединение доступно.
+func (pr *PostgresRelay) listen(ctx context.Context, hub *Hub) error {
+	sqlDB, err := pr.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("шина событий поддерживает только драйвер pgx")
+		}
+		pgConn := stdConn.Conn()
+
+		if _, err := pgConn.Exec(ctx, "LISTEN "+postgresChannel); err != nil {
+			return err
+		}
+		// Соединение возвращается в пул, поэтому подписка снимается при выходе
+		defer unlisten(pgConn)
+		hub.SetRelay(pr)
+
+		for {
+			n, err := pgConn.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+
+			var msg notification
+			if err := json.Unmarshal([]byte(n.Payload), &msg); err != nil {
+				logger.Logger.Error("Неверный формат события в шине", zap.Error(err))
+				continue
+			}
+			hub.deliver(Event{Action: msg.Action, Data: msg.Data, ClientID: msg.ClientID})
+		}
+	})
+}
+
+// unlisten снимает подписку на канал. Если соединение уже закрыто, пул его не вернет.
+func unlisten(conn *pgx.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	conn.Exec(ctx, "UNLISTEN "+postgresChannel)
+}
+
+// encodeNotification сериализует событие для уведомления. Если событие не помещается
+// в уведомление, метаданные записи опускаются: подписчик может загрузить запись целиком.
+func encodeNotification(event Event) ([]byte, error) {
+	msg := notification{Action: event.Action, Data: event.Data, ClientID: event.ClientID}
+	payload, err := json.Marshal(msg)
+	if err != nil || len(payload) <= maxNotifyPayload {
+		return payload, err
+	}
+
+	msg.Data.Metadata = ""
+	payload, err = json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > maxNotifyPayload {
+		return nil, errors.New("событие не помещается в уведомление")
+	}
+	return payload, nil
+}
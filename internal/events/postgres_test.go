@@ -0,0 +1,46 @@
+// Package events содержит тесты для шины событий PostgreSQL.
+package events
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestEncodeNotification(t *testing.T) {
+	event := Event{
+		Action:   models.RevisionUpdate,
+		Data:     models.Data{ID: uuid.New(), UserID: uuid.New(), Name: "Mail", Password: "sealed", Metadata: `{"url":"mail.example.com"}`},
+		ClientID: "laptop",
+	}
+
+	payload, err := encodeNotification(event)
+	if err != nil {
+		t.Fatalf("Ошибка сериализации события: %v", err)
+	}
+	var msg notification
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		t.Fatalf("Ошибка разбора уведомления: %v", err)
+	}
+	if msg.Action != event.Action || msg.ClientID != "laptop" || msg.Data.ID != event.Data.ID ||
+		msg.Data.UserID != event.Data.UserID || msg.Data.Metadata != event.Data.Metadata {
+		t.Errorf("Событие изменилось при передаче: %+v", msg)
+	}
+	if strings.Contains(string(payload), "sealed") {
+		t.Error("Зашифрованное содержимое не должно попадать в уведомление")
+	}
+
+	// Большие метаданные опускаются, чтобы событие поместилось в уведомление
+	event.Data.Metadata = strings.Repeat("x", maxNotifyPayload)
+	payload, err = encodeNotification(event)
+	if err != nil || len(payload) > maxNotifyPayload {
+		t.Fatalf("Ожидалось уведомление не длиннее %d байт, получено %d: %v", maxNotifyPayload, len(payload), err)
+	}
+	json.Unmarshal(payload, &msg)
+	if msg.Data.Metadata != "" || msg.Data.Name != "Mail" {
+		t.Errorf("Ожидалось событие без метаданных, получено %+v", msg.Data)
+	}
+}
@@ -0,0 +1,179 @@
+// Package grpcserver содержит gRPC API сервера GophKeeper.
+package grpcserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/events"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/handlers"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// statusCodes сопоставляет HTTP статусы ошибок обработчиков с кодами gRPC.
+var statusCodes = map[int]codes.Code{
+	http.StatusBadRequest:           codes.InvalidArgument,
+	http.StatusUnauthorized:         codes.Unauthenticated,
+	http.StatusForbidden:            codes.PermissionDenied,
+	http.StatusNotFound:             codes.NotFound,
+	http.StatusConflict:             codes.AlreadyExists,
+	http.StatusPreconditionRequired: codes.FailedPrecondition,
+	http.StatusTooManyRequests:      codes.ResourceExhausted,
+	http.StatusInternalServerError:  codes.Internal,
+}
+
+// toStatus преобразует ошибку бизнес-логики в статус gRPC.
+// Конфликт версий возвращается с кодом ABORTED и текущей версией записи в сообщении.
+func toStatus(err error) error {
+	var conflict *handlers.ConflictError
+	if errors.As(err, &conflict) {
+		return status.Errorf(codes.Aborted, "%s, текущая версия %d", conflict.Error(), conflict.Current.Version)
+	}
+
+	var reqErr *handlers.RequestError
+	if errors.As(err, &reqErr) {
+		code, ok := statusCodes[reqErr.Status]
+		if !ok {
+			code = codes.Unknown
+		}
+		return status.Error(code, reqErr.Message)
+	}
+
+	return status.Error(codes.Internal, "Внутренняя ошибка сервера")
+}
+
+// authResponse преобразует ответ аутентификации.
+func authResponse(resp *handlers.AuthResponse) *pb.AuthResponse {
+	return &pb.AuthResponse{
+		Token:             resp.Token,
+		KdfSalt:           resp.KDFSalt,
+		RefreshToken:      resp.RefreshToken,
+		ExpiresAt:         timestamppb.New(resp.ExpiresAt),
+		SessionId:         resp.SessionID,
+		TwoFactorRequired: resp.TwoFactorRequired,
+		ChallengeToken:    resp.ChallengeToken,
+		User: &pb.User{
+			Id:       resp.User.ID,
+			Username: resp.User.Username,
+			Email:    resp.User.Email,
+		},
+	}
+}
+
+// dataRecord преобразует запись без секретных полей.
+func dataRecord(data *models.Data) *pb.DataRecord {
+	return &pb.DataRecord{
+		Id:              data.ID.String(),
+		Type:            string(data.Type),
+		Name:            data.Name,
+		Metadata:        data.Metadata,
+		Login:           data.Login,
+		ClientEncrypted: data.ClientEncrypted,
+		Version:         data.Version,
+		CreatedAt:       timestamppb.New(data.CreatedAt),
+		UpdatedAt:       timestamppb.New(data.UpdatedAt),
+	}
+}
+
+// dataResponseRecord преобразует запись вместе с расшифрованным содержимым.
+func dataResponseRecord(resp *handlers.DataResponse) *pb.DataRecord {
+	record := dataRecord(&resp.Data)
+	record.Password = resp.Password
+	record.Text = resp.Text
+	record.Binary = resp.Binary
+	record.EncryptedPayload = resp.EncryptedPayload
+	if resp.Card != nil {
+		record.Card = &pb.BankCard{
+			Number: resp.Card.Number,
+			Expiry: resp.Card.Expiry,
+			Cvv:    resp.Card.CVV,
+			Holder: resp.Card.Holder,
+		}
+	}
+	return record
+}
+
+// dataEvent преобразует событие изменения записи.
+func dataEvent(event *events.Event) *pb.DataEvent {
+	return &pb.DataEvent{
+		Action:   string(event.Action),
+		Data:     dataRecord(&event.Data),
+		ClientId: event.ClientID,
+	}
+}
+
+// bankCard преобразует реквизиты карты из запроса.
+func bankCard(card *pb.BankCard) *models.BankCard {
+	if card == nil {
+		return nil
+	}
+	return &models.BankCard{
+		Number: card.GetNumber(),
+		Expiry: card.GetExpiry(),
+		CVV:    card.GetCvv(),
+		Holder: card.GetHolder(),
+	}
+}
+
+// parseMetadata разбирает метаданные, переданные JSON строкой.
+func parseMetadata(metadata string) (interface{}, error) {
+	if metadata == "" {
+		return nil, nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(metadata), &value); err != nil {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Метаданные должны быть JSON: %v", err))
+	}
+	return value, nil
+}
+
+// createDataRequest преобразует запрос создания записи.
+func createDataRequest(req *pb.CreateDataRequest) (*handlers.CreateDataRequest, error) {
+	metadata, err := parseMetadata(req.GetMetadata())
+	if err != nil {
+		return nil, err
+	}
+
+	return &handlers.CreateDataRequest{
+		Type:             models.DataType(req.GetType()),
+		Name:             req.GetName(),
+		Login:            req.GetLogin(),
+		Password:         req.GetPassword(),
+		Text:             req.GetText(),
+		Card:             bankCard(req.GetCard()),
+		Binary:           req.GetBinary(),
+		Metadata:         metadata,
+		EncryptedPayload: req.GetEncryptedPayload(),
+	}, nil
+}
+
+// updateDataRequest преобразует запрос обновления записи.
+// Версии записей начинаются с 1, поэтому нулевая версия означает, что она не передана.
+func updateDataRequest(req *pb.UpdateDataRequest) (*handlers.UpdateDataRequest, error) {
+	metadata, err := parseMetadata(req.GetMetadata())
+	if err != nil {
+		return nil, err
+	}
+
+	updateReq := &handlers.UpdateDataRequest{
+		Name:             req.GetName(),
+		Login:            req.GetLogin(),
+		Password:         req.GetPassword(),
+		Text:             req.GetText(),
+		Card:             bankCard(req.GetCard()),
+		Binary:           req.GetBinary(),
+		Metadata:         metadata,
+		EncryptedPayload: req.GetEncryptedPayload(),
+	}
+	if version := req.GetVersion(); version != 0 {
+		updateReq.Version = &version
+	}
+	return updateReq, nil
+}
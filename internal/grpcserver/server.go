@@ -0,0 +1,318 @@
+// Package grpcserver содержит gRPC API сервера GophKeeper.
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/auth"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/events"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/handlers"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/pb"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// clientIDKey содержит идентификатор устройства в метаданных запроса.
+const clientIDKey = "x-client-id"
+
+// watchSessionCheck задает период повторной проверки токена и сессии подписчика Watch.
+var watchSessionCheck = 30 * time.Second
+
+// AuthBackend выполняет регистрацию, вход и проверку сессий; реализуется handlers.AuthHandler.
+type AuthBackend interface {
+	RegisterUser(req handlers.RegisterRequest, info handlers.SessionInfo) (*handlers.AuthResponse, error)
+	LoginUser(req handlers.LoginRequest, info handlers.SessionInfo) (*handlers.AuthResponse, error)
+	VerifyTwoFactor(req handlers.TwoFactorLoginRequest, info handlers.SessionInfo) (*handlers.AuthResponse, error)
+	RefreshSession(refreshToken, ip string) (*handlers.AuthResponse, error)
+	CheckSession(claims *auth.Claims, ip string) error
+}
+
+// DataBackend работает с записями пользователя; реализуется handlers.DataHandler.
+type DataBackend interface {
+	ListData(userID uuid.UUID, dataType models.DataType) ([]models.Data, error)
+	FindData(userID, dataID uuid.UUID) (*handlers.DataResponse, error)
+	Create(userID uuid.UUID, clientID string, req *handlers.CreateDataRequest) (*models.Data, error)
+	Update(userID, dataID uuid.UUID, clientID string, req *handlers.UpdateDataRequest) (*models.Data, error)
+	Delete(userID, dataID uuid.UUID, clientID string) error
+	RecordAccess(userID uuid.UUID, action models.AuditAction, dataID uuid.UUID, info handlers.SessionInfo)
+}
+
+// New создает gRPC сервер с сервисами аутентификации и данных.
+// Бизнес-логика сервисов общая с HTTP обработчиками, а изменения для Watch берутся из hub.
+func New(authBackend AuthBackend, dataBackend DataBackend, hub *events.Hub, jwtSecret string) *grpc.Server {
+	publicMethods := []string{
+		pb.AuthService_Register_FullMethodName,
+		pb.AuthService_Login_FullMethodName,
+		pb.AuthService_VerifyTwoFactor_FullMethodName,
+		pb.AuthService_Refresh_FullMethodName,
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(middleware.UnaryAuthInterceptor(jwtSecret, authBackend, publicMethods...)),
+		grpc.ChainStreamInterceptor(middleware.StreamAuthInterceptor(jwtSecret, authBackend, publicMethods...)),
+	)
+
+	pb.RegisterAuthServiceServer(server, &authService{auth: authBackend})
+	pb.RegisterDataServiceServer(server, &dataService{data: dataBackend, events: hub})
+
+	return server
+}
+
+// authService реализует pb.AuthServiceServer.
+type authService struct {
+	pb.UnimplementedAuthServiceServer
+	auth AuthBackend
+}
+
+// Register регистрирует нового пользователя.
+func (s *authService) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.AuthResponse, error) {
+	resp, err := s.auth.RegisterUser(handlers.RegisterRequest{
+		Username: req.GetUsername(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	}, sessionInfo(ctx, req.GetDeviceName()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return authResponse(resp), nil
+}
+
+// Login выполняет вход пользователя.
+func (s *authService) Login(ctx context.Context, req *pb.LoginRequest) (*pb.AuthResponse, error) {
+	resp, err := s.auth.LoginUser(handlers.LoginRequest{
+		Username: req.GetUsername(),
+		Password: req.GetPassword(),
+	}, sessionInfo(ctx, req.GetDeviceName()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return authResponse(resp), nil
+}
+
+// VerifyTwoFactor завершает вход пользователя с включенной 2FA.
+func (s *authService) VerifyTwoFactor(ctx context.Context, req *pb.VerifyTwoFactorRequest) (*pb.AuthResponse, error) {
+	resp, err := s.auth.VerifyTwoFactor(handlers.TwoFactorLoginRequest{
+		ChallengeToken: req.GetChallengeToken(),
+		Code:           req.GetCode(),
+	}, sessionInfo(ctx, req.GetDeviceName()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return authResponse(resp), nil
+}
+
+// Refresh обменивает токен обновления на новую пару токенов.
+func (s *authService) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.AuthResponse, error) {
+	resp, err := s.auth.RefreshSession(req.GetRefreshToken(), middleware.PeerIP(ctx))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return authResponse(resp), nil
+}
+
+// dataService реализует pb.DataServiceServer.
+type dataService struct {
+	pb.UnimplementedDataServiceServer
+	data   DataBackend
+	events *events.Hub
+}
+
+// ListData возвращает записи пользователя без секретных полей.
+func (s *dataService) ListData(ctx context.Context, req *pb.ListDataRequest) (*pb.ListDataResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.data.ListData(userID, models.DataType(req.GetType()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.ListDataResponse{Data: make([]*pb.DataRecord, 0, len(data))}
+	for i := range data {
+		resp.Data = append(resp.Data, dataRecord(&data[i]))
+	}
+	return resp, nil
+}
+
+// GetData возвращает запись вместе с расшифрованным содержимым.
+func (s *dataService) GetData(ctx context.Context, req *pb.GetDataRequest) (*pb.DataRecord, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dataID, err := parseDataID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.data.FindData(userID, dataID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	s.data.RecordAccess(userID, models.AuditRead, dataID, sessionInfo(ctx, ""))
+	return dataResponseRecord(resp), nil
+}
+
+// CreateData создает запись.
+func (s *dataService) CreateData(ctx context.Context, req *pb.CreateDataRequest) (*pb.DataRecord, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	createReq, err := createDataRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.data.Create(userID, clientIDFromContext(ctx), createReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	s.data.RecordAccess(userID, models.AuditCreate, data.ID, sessionInfo(ctx, ""))
+	return dataRecord(data), nil
+}
+
+// UpdateData обновляет запись, если ее версия совпадает с ожидаемой.
+func (s *dataService) UpdateData(ctx context.Context, req *pb.UpdateDataRequest) (*pb.DataRecord, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dataID, err := parseDataID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	updateReq, err := updateDataRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.data.Update(userID, dataID, clientIDFromContext(ctx), updateReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	s.data.RecordAccess(userID, models.AuditUpdate, dataID, sessionInfo(ctx, ""))
+	return dataRecord(data), nil
+}
+
+// DeleteData перемещает запись в корзину.
+func (s *dataService) DeleteData(ctx context.Context, req *pb.DeleteDataRequest) (*pb.DeleteDataResponse, error) {
+	userID, err := userIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dataID, err := parseDataID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.data.Delete(userID, dataID, clientIDFromContext(ctx)); err != nil {
+		return nil, toStatus(err)
+	}
+	s.data.RecordAccess(userID, models.AuditDelete, dataID, sessionInfo(ctx, ""))
+	return &pb.DeleteDataResponse{}, nil
+}
+
+// Watch отправляет изменения записей пользователя, пока клиент не закроет поток.
+// Если клиент не успевает читать события, поток завершается с кодом UNAVAILABLE,
+// и клиент должен заново загрузить записи и подписаться снова. Когда токен доступа
+// истекает или сессия отзывается, поток завершается с кодом UNAUTHENTICATED.
+func (s *dataService) Watch(req *pb.WatchRequest, stream pb.DataService_WatchServer) error {
+	userID, err := userIDFromContext(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	changes, unsubscribe := s.events.Subscribe(userID)
+	defer unsubscribe()
+
+	// Заголовки отправляются сразу, чтобы клиент знал, что подписка оформлена
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	sessionCheck := time.NewTicker(watchSessionCheck)
+	defer sessionCheck.Stop()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-sessionCheck.C:
+			if err := middleware.CheckStreamContext(stream.Context()); err != nil {
+				return status.Error(codes.Unauthenticated, err.Error())
+			}
+		case event, ok := <-changes:
+			if !ok {
+				return status.Error(codes.Unavailable, "Клиент не успевает получать изменения, подпишитесь заново")
+			}
+			if err := stream.Send(dataEvent(&event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// userIDFromContext возвращает ID пользователя, проверенный interceptor'ом аутентификации.
+func userIDFromContext(ctx context.Context) (uuid.UUID, error) {
+	userID, ok := middleware.UserIDFromContext(ctx)
+	if !ok {
+		return uuid.Nil, status.Error(codes.Unauthenticated, "Пользователь не аутентифицирован")
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "Неверный ID пользователя")
+	}
+	return userUUID, nil
+}
+
+// clientIDFromContext возвращает идентификатор устройства из метаданных x-client-id.
+// Если клиент его не передал, используется user-agent.
+func clientIDFromContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(clientIDKey); len(values) > 0 && values[0] != "" {
+		return values[0]
+	}
+	if values := md.Get("user-agent"); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// sessionInfo собирает сведения об устройстве для новой сессии.
+// Если клиент не передал имя устройства, используется x-client-id или user-agent.
+func sessionInfo(ctx context.Context, deviceName string) handlers.SessionInfo {
+	if deviceName == "" {
+		deviceName = clientIDFromContext(ctx)
+	}
+
+	info := handlers.SessionInfo{DeviceName: deviceName, IP: middleware.PeerIP(ctx)}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("user-agent"); len(values) > 0 {
+		info.UserAgent = values[0]
+	}
+	return info
+}
+
+// parseDataID разбирает ID записи из запроса.
+func parseDataID(id string) (uuid.UUID, error) {
+	dataID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "Неверный ID данных")
+	}
+	return dataID, nil
+}
@@ -0,0 +1,315 @@
+// Package grpcserver содержит тесты для gRPC API.
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/auth"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/events"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/handlers"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/pb"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testJWTSecret = "test-jwt-secret-key"
+
+// fakeAuth выдает токен любому пользователю с паролем password, открывая для него новую сессию.
+// Токен обновления совпадает с ID сессии, а отозванные сессии перечислены в revoked.
+type fakeAuth struct {
+	userID  uuid.UUID
+	mutex   sync.Mutex
+	revoked map[string]bool
+}
+
+// revoke отзывает сессию sessionID.
+func (f *fakeAuth) revoke(sessionID string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.revoked[sessionID] = true
+}
+
+// isRevoked сообщает, отозвана ли сессия sessionID.
+func (f *fakeAuth) isRevoked(sessionID string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.revoked[sessionID]
+}
+
+func (f *fakeAuth) RegisterUser(req handlers.RegisterRequest, info handlers.SessionInfo) (*handlers.AuthResponse, error) {
+	return f.LoginUser(handlers.LoginRequest{Username: req.Username, Password: req.Password}, info)
+}
+
+func (f *fakeAuth) LoginUser(req handlers.LoginRequest, info handlers.SessionInfo) (*handlers.AuthResponse, error) {
+	if req.Password != "password" {
+		return nil, &handlers.RequestError{Status: http.StatusUnauthorized, Message: "Неверные учетные данные"}
+	}
+	return f.issue(req.Username, uuid.NewString())
+}
+
+func (f *fakeAuth) VerifyTwoFactor(req handlers.TwoFactorLoginRequest, info handlers.SessionInfo) (*handlers.AuthResponse, error) {
+	return nil, &handlers.RequestError{Status: http.StatusUnauthorized, Message: "Неверный код"}
+}
+
+func (f *fakeAuth) RefreshSession(refreshToken, ip string) (*handlers.AuthResponse, error) {
+	if f.isRevoked(refreshToken) {
+		return nil, &handlers.RequestError{Status: http.StatusUnauthorized, Message: "Сессия отозвана или истекла"}
+	}
+	return f.issue("user", refreshToken)
+}
+
+func (f *fakeAuth) CheckSession(claims *auth.Claims, ip string) error {
+	if f.isRevoked(claims.SessionID) {
+		return &handlers.RequestError{Status: http.StatusUnauthorized, Message: "Сессия отозвана или истекла"}
+	}
+	return nil
+}
+
+func (f *fakeAuth) issue(username, sessionID string) (*handlers.AuthResponse, error) {
+	token, _ := auth.NewJWTManager(testJWTSecret).GenerateToken(f.userID.String(), username, sessionID)
+	resp := &handlers.AuthResponse{Token: token, RefreshToken: sessionID, SessionID: sessionID}
+	resp.User.ID = f.userID.String()
+	resp.User.Username = username
+	return resp, nil
+}
+
+// fakeData хранит записи в памяти и оповещает hub об изменениях, как handlers.DataHandler.
+type fakeData struct {
+	hub  *events.Hub
+	data map[uuid.UUID]*models.Data
+}
+
+func (f *fakeData) ListData(userID uuid.UUID, dataType models.DataType) ([]models.Data, error) {
+	var result []models.Data
+	for _, data := range f.data {
+		if data.UserID == userID {
+			result = append(result, *data)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeData) FindData(userID, dataID uuid.UUID) (*handlers.DataResponse, error) {
+	data, ok := f.data[dataID]
+	if !ok || data.UserID != userID {
+		return nil, &handlers.RequestError{Status: http.StatusNotFound, Message: "Данные не найдены"}
+	}
+	return &handlers.DataResponse{Data: *data, Password: "secret"}, nil
+}
+
+func (f *fakeData) Create(userID uuid.UUID, clientID string, req *handlers.CreateDataRequest) (*models.Data, error) {
+	data := &models.Data{ID: uuid.New(), UserID: userID, Type: req.Type, Name: req.Name, Login: req.Login, Version: 1}
+	f.data[data.ID] = data
+	f.hub.Publish(events.Event{Action: models.RevisionCreate, Data: *data, ClientID: clientID})
+	return data, nil
+}
+
+func (f *fakeData) Update(userID, dataID uuid.UUID, clientID string, req *handlers.UpdateDataRequest) (*models.Data, error) {
+	data := f.data[dataID]
+	if req.Version == nil {
+		return nil, &handlers.RequestError{Status: http.StatusPreconditionRequired, Message: "необходимо указать версию записи"}
+	}
+	if *req.Version != data.Version {
+		return nil, &handlers.ConflictError{Current: &handlers.DataResponse{Data: *data}}
+	}
+	data.Name = req.Name
+	data.Version++
+	return data, nil
+}
+
+func (f *fakeData) Delete(userID, dataID uuid.UUID, clientID string) error {
+	delete(f.data, dataID)
+	return nil
+}
+
+func (f *fakeData) RecordAccess(userID uuid.UUID, action models.AuditAction, dataID uuid.UUID, info handlers.SessionInfo) {
+}
+
+// newTestClients запускает gRPC сервер в памяти и возвращает клиентов его сервисов.
+func newTestClients(t *testing.T, userID uuid.UUID) (pb.AuthServiceClient, pb.DataServiceClient) {
+	return newTestClientsWithAuth(t, &fakeAuth{userID: userID, revoked: make(map[string]bool)})
+}
+
+// newTestClientsWithAuth запускает gRPC сервер в памяти с заданной аутентификацией.
+func newTestClientsWithAuth(t *testing.T, authBackend *fakeAuth) (pb.AuthServiceClient, pb.DataServiceClient) {
+	hub := events.NewHub()
+	server := New(authBackend, &fakeData{hub: hub, data: make(map[uuid.UUID]*models.Data)}, hub, testJWTSecret)
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Ошибка подключения к gRPC серверу: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewAuthServiceClient(conn), pb.NewDataServiceClient(conn)
+}
+
+// withToken добавляет токен и идентификатор устройства в метаданные запроса.
+func withToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token, clientIDKey, "laptop")
+}
+
+func TestServer_Auth(t *testing.T) {
+	authClient, dataClient := newTestClients(t, uuid.New())
+	ctx := context.Background()
+
+	if _, err := dataClient.ListData(ctx, &pb.ListDataRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Ожидался код %s без токена, получено %v", codes.Unauthenticated, err)
+	}
+
+	if _, err := dataClient.ListData(withToken(ctx, "invalid"), &pb.ListDataRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Ожидался код %s для неверного токена, получено %v", codes.Unauthenticated, err)
+	}
+
+	if _, err := authClient.Login(ctx, &pb.LoginRequest{Username: "user", Password: "wrong"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Ожидался код %s для неверного пароля, получено %v", codes.Unauthenticated, err)
+	}
+
+	resp, err := authClient.Login(ctx, &pb.LoginRequest{Username: "user", Password: "password"})
+	if err != nil {
+		t.Fatalf("Ошибка входа: %v", err)
+	}
+
+	if _, err := dataClient.ListData(withToken(ctx, resp.GetToken()), &pb.ListDataRequest{}); err != nil {
+		t.Errorf("Запрос с токеном должен выполниться: %v", err)
+	}
+}
+
+func TestServer_RefreshAndRevokedSession(t *testing.T) {
+	fake := &fakeAuth{userID: uuid.New(), revoked: make(map[string]bool)}
+	authClient, dataClient := newTestClientsWithAuth(t, fake)
+	ctx := context.Background()
+
+	login, err := authClient.Login(ctx, &pb.LoginRequest{Username: "user", Password: "password", DeviceName: "laptop"})
+	if err != nil {
+		t.Fatalf("Ошибка входа: %v", err)
+	}
+
+	refreshed, err := authClient.Refresh(ctx, &pb.RefreshRequest{RefreshToken: login.GetRefreshToken()})
+	if err != nil {
+		t.Fatalf("Обновление токенов должно выполняться без токена доступа: %v", err)
+	}
+	if refreshed.GetSessionId() != login.GetSessionId() {
+		t.Errorf("Ожидалась сессия %s, получена %s", login.GetSessionId(), refreshed.GetSessionId())
+	}
+
+	fake.revoke(login.GetSessionId())
+
+	if _, err := dataClient.ListData(withToken(ctx, refreshed.GetToken()), &pb.ListDataRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Ожидался код %s для отозванной сессии, получено %v", codes.Unauthenticated, err)
+	}
+
+	if _, err := authClient.Refresh(ctx, &pb.RefreshRequest{RefreshToken: login.GetRefreshToken()}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Ожидался код %s при обновлении отозванной сессии, получено %v", codes.Unauthenticated, err)
+	}
+}
+
+func TestServer_DataAndWatch(t *testing.T) {
+	userID := uuid.New()
+	authClient, dataClient := newTestClients(t, userID)
+
+	login, err := authClient.Login(context.Background(), &pb.LoginRequest{Username: "user", Password: "password"})
+	if err != nil {
+		t.Fatalf("Ошибка входа: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(withToken(context.Background(), login.GetToken()), 5*time.Second)
+	defer cancel()
+
+	watch, err := dataClient.Watch(ctx, &pb.WatchRequest{})
+	if err != nil {
+		t.Fatalf("Ошибка подписки: %v", err)
+	}
+	// Сервер отправляет заголовки после оформления подписки
+	if _, err := watch.Header(); err != nil {
+		t.Fatalf("Ошибка подписки: %v", err)
+	}
+
+	created, err := dataClient.CreateData(ctx, &pb.CreateDataRequest{Type: "login_password", Name: "Mail", Login: "user", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Ошибка создания записи: %v", err)
+	}
+
+	event, err := watch.Recv()
+	if err != nil {
+		t.Fatalf("Ошибка получения события: %v", err)
+	}
+	if event.GetAction() != "create" || event.GetData().GetId() != created.GetId() || event.GetClientId() != "laptop" {
+		t.Errorf("Неожиданное событие %+v", event)
+	}
+
+	record, err := dataClient.GetData(ctx, &pb.GetDataRequest{Id: created.GetId()})
+	if err != nil {
+		t.Fatalf("Ошибка получения записи: %v", err)
+	}
+	if record.GetPassword() != "secret" {
+		t.Errorf("Ожидался расшифрованный пароль, получено %q", record.GetPassword())
+	}
+
+	if _, err := dataClient.UpdateData(ctx, &pb.UpdateDataRequest{Id: created.GetId(), Name: "New"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Ожидался код %s без версии, получено %v", codes.FailedPrecondition, err)
+	}
+
+	if _, err := dataClient.UpdateData(ctx, &pb.UpdateDataRequest{Id: created.GetId(), Version: 5, Name: "New"}); status.Code(err) != codes.Aborted {
+		t.Errorf("Ожидался код %s при конфликте версий, получено %v", codes.Aborted, err)
+	}
+
+	if _, err := dataClient.CreateData(ctx, &pb.CreateDataRequest{Name: "Bad", Metadata: "{"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Ожидался код %s для неверных метаданных, получено %v", codes.InvalidArgument, err)
+	}
+
+	if _, err := dataClient.GetData(ctx, &pb.GetDataRequest{Id: uuid.NewString()}); status.Code(err) != codes.NotFound {
+		t.Errorf("Ожидался код %s, получено %v", codes.NotFound, err)
+	}
+}
+
+func TestServer_WatchRevokedSession(t *testing.T) {
+	interval := watchSessionCheck
+	watchSessionCheck = 10 * time.Millisecond
+	defer func() { watchSessionCheck = interval }()
+
+	fake := &fakeAuth{userID: uuid.New(), revoked: make(map[string]bool)}
+	authClient, dataClient := newTestClientsWithAuth(t, fake)
+
+	login, err := authClient.Login(context.Background(), &pb.LoginRequest{Username: "user", Password: "password"})
+	if err != nil {
+		t.Fatalf("Ошибка входа: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(withToken(context.Background(), login.GetToken()), 5*time.Second)
+	defer cancel()
+
+	watch, err := dataClient.Watch(ctx, &pb.WatchRequest{})
+	if err != nil {
+		t.Fatalf("Ошибка подписки: %v", err)
+	}
+	if _, err := watch.Header(); err != nil {
+		t.Fatalf("Ошибка подписки: %v", err)
+	}
+
+	// Отзыв сессии завершает уже открытый поток
+	fake.revoke(login.GetSessionId())
+	if _, err := watch.Recv(); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Ожидался код %s после отзыва сессии, получено %v", codes.Unauthenticated, err)
+	}
+}
@@ -0,0 +1,141 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Ограничения размера страницы журнала аудита.
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// AuditHandler обрабатывает запросы к журналу аудита.
+type AuditHandler struct {
+	auditRepo repository.AuditRepositoryInterface
+}
+
+// NewAuditHandler создает новый обработчик журнала аудита.
+func NewAuditHandler(repo *repository.Repository) *AuditHandler {
+	return &AuditHandler{auditRepo: repo.NewAuditRepository()}
+}
+
+// AuditPage представляет страницу журнала аудита.
+type AuditPage struct {
+	Events []models.AuditEvent `json:"events"`
+	Total  int64               `json:"total"`
+	Limit  int                 `json:"limit"`
+	Offset int                 `json:"offset"`
+}
+
+// GetAudit возвращает страницу событий журнала аудита пользователя.
+// Размер страницы и смещение задаются параметрами limit и offset.
+func (ah *AuditHandler) GetAudit(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+		return
+	}
+
+	limit, offset, err := pageParams(c, defaultAuditLimit, maxAuditLimit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	page, err := ah.ListEvents(userUUID, limit, offset)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// ListEvents возвращает страницу событий пользователя, начиная с самых новых.
+func (ah *AuditHandler) ListEvents(userID uuid.UUID, limit, offset int) (*AuditPage, error) {
+	events, total, err := ah.auditRepo.GetByActorID(userID, limit, offset)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения журнала аудита")
+	}
+	if events == nil {
+		events = []models.AuditEvent{}
+	}
+
+	return &AuditPage{Events: events, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// pageParams разбирает параметры limit и offset запроса. Если limit не задан,
+// используется defaultLimit, больше maxLimit он быть не может.
+func pageParams(c *gin.Context, defaultLimit, maxLimit int) (int, int, error) {
+	limit, err := queryLimit(c, defaultLimit, maxLimit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	offset := 0
+	if value := c.Query("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, 0, newRequestError(http.StatusBadRequest, "Неверное смещение")
+		}
+		offset = n
+	}
+
+	return limit, offset, nil
+}
+
+// queryLimit разбирает параметр limit запроса. Если он не задан, возвращается
+// defaultLimit, больше maxLimit он быть не может.
+func queryLimit(c *gin.Context, defaultLimit, maxLimit int) (int, error) {
+	value := c.Query("limit")
+	if value == "" {
+		return defaultLimit, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, newRequestError(http.StatusBadRequest, "Неверный размер страницы")
+	}
+	return min(n, maxLimit), nil
+}
+
+// auditEvent создает событие журнала аудита для пользователя actorID с устройства info.
+func auditEvent(action models.AuditAction, actorID uuid.UUID, info SessionInfo) *models.AuditEvent {
+	return &models.AuditEvent{
+		ActorID:   &actorID,
+		Action:    action,
+		IP:        info.IP,
+		UserAgent: info.UserAgent,
+	}
+}
+
+// RecordAccess записывает в журнал аудита действие пользователя userID с записью dataID.
+func (dh *DataHandler) RecordAccess(userID uuid.UUID, action models.AuditAction, dataID uuid.UUID, info SessionInfo) {
+	event := auditEvent(action, userID, info)
+	event.DataID = &dataID
+	dh.audit.Record(event)
+}
+
+// RecordShare записывает в журнал аудита выдачу или отзыв доступа share к записи.
+func (dh *DataHandler) RecordShare(userID uuid.UUID, action models.AuditAction, share *ShareResponse, info SessionInfo) {
+	event := auditEvent(action, userID, info)
+	event.DataID = &share.DataID
+	event.Details = fmt.Sprintf("%s: %s", share.Username, share.Permission)
+	dh.audit.Record(event)
+}
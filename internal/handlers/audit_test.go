@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/audit"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/google/uuid"
+)
+
+func TestAuditHandler_RecordsDataAccess(t *testing.T) {
+	handler, memRepo, userID := setupTestDataHandler(t)
+	auditRepo := memRepo.NewAuditRepository()
+	handler.audit = audit.NewRecorder(auditRepo, nil, 0)
+	ah := &AuditHandler{auditRepo: auditRepo}
+
+	router := newShareRouter(handler, userID)
+	router.GET("/audit", ah.GetAudit)
+
+	w := serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Mail", Login: "user", Password: "secret"})
+	var created models.Data
+	json.Unmarshal(w.Body.Bytes(), &created)
+	path := "/data/" + created.ID.String()
+
+	serveJSON(router, "GET", path, "laptop", nil)
+	serveJSON(router, "DELETE", path, "laptop", nil)
+
+	// Неудачные запросы в журнал не попадают
+	serveJSON(router, "GET", "/data/"+uuid.New().String(), "laptop", nil)
+
+	w = serveJSON(router, "GET", "/audit?limit=2", "laptop", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var page AuditPage
+	json.Unmarshal(w.Body.Bytes(), &page)
+	if page.Total != 3 || len(page.Events) != 2 {
+		t.Fatalf("Ожидалось 3 события и страница из 2, получено %d и %d", page.Total, len(page.Events))
+	}
+	if page.Events[0].Action != models.AuditDelete || page.Events[1].Action != models.AuditRead {
+		t.Errorf("Ожидались события delete и read, получены %s и %s", page.Events[0].Action, page.Events[1].Action)
+	}
+	if page.Events[0].DataID == nil || *page.Events[0].DataID != created.ID {
+		t.Errorf("Событие должно ссылаться на запись %s", created.ID)
+	}
+
+	w = serveJSON(router, "GET", "/audit?offset=2", "laptop", nil)
+	json.Unmarshal(w.Body.Bytes(), &page)
+	if len(page.Events) != 1 || page.Events[0].Action != models.AuditCreate {
+		t.Errorf("Последняя страница должна содержать событие create, получено %+v", page.Events)
+	}
+
+	if w := serveJSON(router, "GET", "/audit?limit=0", "laptop", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestAuditHandler_RecordsLoginAttempts(t *testing.T) {
+	handler := setupTestAuthHandler(t)
+	auditRepo := repository.NewMemoryRepository().NewAuditRepository()
+	handler.audit = audit.NewRecorder(auditRepo, nil, 0)
+
+	info := SessionInfo{IP: "10.0.0.1", UserAgent: "test-agent"}
+	resp, err := handler.RegisterUser(RegisterRequest{Username: "alice", Email: "alice@example.com", Password: "password123"}, info)
+	if err != nil {
+		t.Fatalf("Ошибка регистрации: %v", err)
+	}
+	userID := uuid.MustParse(resp.User.ID)
+
+	if _, err := handler.LoginUser(LoginRequest{Username: "alice", Password: "wrong"}, info); err == nil {
+		t.Fatal("Вход с неверным паролем должен завершиться ошибкой")
+	}
+	if _, err := handler.LoginUser(LoginRequest{Username: "alice", Password: "password123"}, info); err != nil {
+		t.Fatalf("Ошибка входа: %v", err)
+	}
+
+	events, total, _ := auditRepo.GetByActorID(userID, 10, 0)
+	if total != 3 {
+		t.Fatalf("Ожидалось 3 события, получено %d", total)
+	}
+	want := []models.AuditAction{models.AuditLogin, models.AuditLoginFailed, models.AuditRegister}
+	for i, action := range want {
+		if events[i].Action != action {
+			t.Errorf("Событие %d: ожидалось %s, получено %s", i, action, events[i].Action)
+		}
+	}
+	if events[0].IP != "10.0.0.1" || events[0].UserAgent != "test-agent" {
+		t.Errorf("Событие должно содержать IP и User-Agent клиента, получено %q и %q", events[0].IP, events[0].UserAgent)
+	}
+}
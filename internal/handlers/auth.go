@@ -2,45 +2,79 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"errors"
 	"net/http"
+	"time"
 
+	"github.com/AlexeySalamakhin/GophKeeper/internal/audit"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/auth"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // AuthHandler обрабатывает запросы аутентификации.
 type AuthHandler struct {
-	userRepo  repository.UserRepositoryInterface
-	jwtSecret string
+	userRepo    repository.UserRepositoryInterface
+	sessionRepo repository.SessionRepositoryInterface
+	jwtSecret   string
+	// keys шифрует секреты одноразовых паролей и ключи данных пользователей.
+	keys       crypto.KeyProvider
+	refreshTTL time.Duration
+	audit      *audit.Recorder
 }
 
 // NewAuthHandler создает новый обработчик аутентификации.
-func NewAuthHandler(repo *repository.Repository, jwtSecret string) *AuthHandler {
+// refreshTTL задает срок действия токена обновления, а значит и неактивной сессии.
+// Регистрации и попытки входа записываются в журнал аудита recorder.
+func NewAuthHandler(repo *repository.Repository, jwtSecret string, keys crypto.KeyProvider, refreshTTL time.Duration, recorder *audit.Recorder) *AuthHandler {
 	return &AuthHandler{
-		userRepo:  repo.NewUserRepository(),
-		jwtSecret: jwtSecret,
+		userRepo:    repo.NewUserRepository(),
+		sessionRepo: repo.NewSessionRepository(),
+		jwtSecret:   jwtSecret,
+		keys:        keys,
+		refreshTTL:  refreshTTL,
+		audit:       recorder,
 	}
 }
 
 // RegisterRequest представляет запрос регистрации.
 type RegisterRequest struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Username   string `json:"username"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	DeviceName string `json:"device_name,omitempty"`
 }
 
 // LoginRequest представляет запрос входа.
 type LoginRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	DeviceName string `json:"device_name,omitempty"`
 }
 
 // AuthResponse представляет ответ аутентификации.
 type AuthResponse struct {
+	// Token содержит короткоживущий токен доступа.
 	Token string `json:"token"`
-	User  struct {
+	// ExpiresAt содержит время истечения токена доступа.
+	ExpiresAt time.Time `json:"expires_at"`
+	// RefreshToken обменивается на новую пару токенов через /refresh и действует один раз.
+	RefreshToken string `json:"refresh_token"`
+	SessionID    string `json:"session_id"`
+	// TwoFactorRequired означает, что пароль верен, но для входа нужен одноразовый пароль.
+	// Токены доступа в этом случае не выдаются, а ChallengeToken передается в /login/2fa.
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+	// KDFSalt содержит соль в base64, из которой клиент вместе с мастер-паролем
+	// получает ключ хранилища для сквозного шифрования.
+	KDFSalt string `json:"kdf_salt"`
+	// KDFCheck содержит проверочное значение ключа хранилища; пусто, пока клиент его не сохранил.
+	KDFCheck string `json:"kdf_check,omitempty"`
+	User     struct {
 		ID       string `json:"id"`
 		Username string `json:"username"`
 		Email    string `json:"email"`
@@ -55,59 +89,62 @@ func (ah *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	if req.Username == "" || req.Email == "" || req.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Все поля обязательны"})
+	response, err := ah.RegisterUser(req, requestSessionInfo(c, req.DeviceName))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
+	c.JSON(http.StatusCreated, response)
+}
+
+// RegisterUser регистрирует нового пользователя и открывает для него сессию.
+func (ah *AuthHandler) RegisterUser(req RegisterRequest, info SessionInfo) (*AuthResponse, error) {
+	if req.Username == "" || req.Email == "" || req.Password == "" {
+		return nil, newRequestError(http.StatusBadRequest, "Все поля обязательны")
+	}
+
 	if _, err := ah.userRepo.GetByUsername(req.Username); err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Пользователь с таким именем уже существует"})
-		return
+		return nil, newRequestError(http.StatusConflict, "Пользователь с таким именем уже существует")
 	}
 
 	if _, err := ah.userRepo.GetByEmail(req.Email); err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Пользователь с таким email уже существует"})
-		return
+		return nil, newRequestError(http.StatusConflict, "Пользователь с таким email уже существует")
 	}
 
 	hashedPassword, err := auth.HashPassword(req.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка обработки пароля"})
-		return
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка обработки пароля")
+	}
+
+	salt, err := crypto.NewSalt()
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка генерации соли")
 	}
 
+	// ID назначается до шифрования ключа данных, потому что ключ привязан к пользователю
 	user := &models.User{
+		ID:       uuid.New(),
 		Username: req.Username,
 		Email:    req.Email,
 		Password: hashedPassword,
+		KDFSalt:  base64.StdEncoding.EncodeToString(salt),
 	}
 
-	if err := ah.userRepo.Create(user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка создания пользователя"})
-		return
-	}
-
-	jwtManager := auth.NewJWTManager(ah.jwtSecret)
-	token, err := jwtManager.GenerateToken(user.ID.String(), user.Username)
+	dataKey, err := crypto.NewDataKey()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка генерации токена"})
-		return
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка генерации ключа данных")
+	}
+	if user.DataKey, err = crypto.WrapDataKey(ah.keys, user.ID.String(), dataKey); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка шифрования ключа данных")
 	}
 
-	response := AuthResponse{
-		Token: token,
-		User: struct {
-			ID       string `json:"id"`
-			Username string `json:"username"`
-			Email    string `json:"email"`
-		}{
-			ID:       user.ID.String(),
-			Username: user.Username,
-			Email:    user.Email,
-		},
+	if err := ah.userRepo.Create(user); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка создания пользователя")
 	}
+	ah.audit.Record(auditEvent(models.AuditRegister, user.ID, info))
 
-	c.JSON(http.StatusCreated, response)
+	return ah.openSession(user, info)
 }
 
 // Login обрабатывает вход пользователя.
@@ -118,41 +155,128 @@ func (ah *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	if req.Username == "" || req.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Имя пользователя и пароль обязательны"})
+	response, err := ah.LoginUser(req, requestSessionInfo(c, req.DeviceName))
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
+	c.JSON(http.StatusOK, response)
+}
+
+// LoginUser проверяет учетные данные пользователя и открывает для него сессию.
+// Если у пользователя включена 2FA, вместо сессии выдается токен второго шага входа.
+func (ah *AuthHandler) LoginUser(req LoginRequest, info SessionInfo) (*AuthResponse, error) {
+	if req.Username == "" || req.Password == "" {
+		return nil, newRequestError(http.StatusBadRequest, "Имя пользователя и пароль обязательны")
+	}
+
 	user, err := ah.userRepo.GetByUsername(req.Username)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверные учетные данные"})
-		return
+		ah.audit.Record(&models.AuditEvent{
+			Action:    models.AuditLoginFailed,
+			Username:  req.Username,
+			Details:   "неизвестный пользователь",
+			IP:        info.IP,
+			UserAgent: info.UserAgent,
+		})
+		return nil, newRequestError(http.StatusUnauthorized, "Неверные учетные данные")
 	}
 
 	if !auth.CheckPasswordHash(req.Password, user.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверные учетные данные"})
-		return
+		ah.recordLogin(models.AuditLoginFailed, user, info, "неверный пароль")
+		return nil, newRequestError(http.StatusUnauthorized, "Неверные учетные данные")
+	}
+
+	// Пользователи, зарегистрированные до появления сквозного шифрования, получают соль при входе
+	if user.KDFSalt == "" {
+		salt, err := crypto.NewSalt()
+		if err != nil {
+			return nil, newRequestError(http.StatusInternalServerError, "Ошибка генерации соли")
+		}
+		user.KDFSalt = base64.StdEncoding.EncodeToString(salt)
+		if err := ah.userRepo.Update(user); err != nil {
+			return nil, newRequestError(http.StatusInternalServerError, "Ошибка обновления пользователя")
+		}
 	}
 
+	if user.TOTPEnabled {
+		return ah.twoFactorChallenge(user)
+	}
+
+	ah.recordLogin(models.AuditLogin, user, info, "")
+	return ah.openSession(user, info)
+}
+
+// recordLogin записывает в журнал аудита попытку входа пользователя user.
+func (ah *AuthHandler) recordLogin(action models.AuditAction, user *models.User, info SessionInfo, details string) {
+	event := auditEvent(action, user.ID, info)
+	event.Username = user.Username
+	event.Details = details
+	ah.audit.Record(event)
+}
+
+// newAuthResponse выдает пользователю токен доступа для сессии и формирует ответ аутентификации.
+func (ah *AuthHandler) newAuthResponse(user *models.User, session *models.Session, refreshToken string) (*AuthResponse, error) {
 	jwtManager := auth.NewJWTManager(ah.jwtSecret)
-	token, err := jwtManager.GenerateToken(user.ID.String(), user.Username)
+	token, err := jwtManager.GenerateToken(user.ID.String(), user.Username, session.ID.String())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка генерации токена"})
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка генерации токена")
+	}
+
+	response := &AuthResponse{
+		Token:        token,
+		ExpiresAt:    time.Now().Add(auth.AccessTokenTTL),
+		RefreshToken: refreshToken,
+		SessionID:    session.ID.String(),
+		KDFSalt:      user.KDFSalt,
+		KDFCheck:     user.KDFCheck,
+	}
+	response.User.ID = user.ID.String()
+	response.User.Username = user.Username
+	response.User.Email = user.Email
+
+	return response, nil
+}
+
+// KDFCheckRequest представляет запрос сохранения проверочного значения ключа хранилища.
+type KDFCheckRequest struct {
+	KDFCheck string `json:"kdf_check"`
+}
+
+// PutKDFCheck сохраняет проверочное значение ключа хранилища пользователя.
+func (ah *AuthHandler) PutKDFCheck(c *gin.Context) {
+	userID, _, ok := sessionClaims(c)
+	if !ok {
 		return
 	}
 
-	response := AuthResponse{
-		Token: token,
-		User: struct {
-			ID       string `json:"id"`
-			Username string `json:"username"`
-			Email    string `json:"email"`
-		}{
-			ID:       user.ID.String(),
-			Username: user.Username,
-			Email:    user.Email,
-		},
+	var req KDFCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	if err := ah.SaveKDFCheck(userID, req.KDFCheck); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SaveKDFCheck сохраняет проверочное значение ключа хранилища, полученного из мастер-пароля.
+// Значение задается один раз, чтобы клиент не мог заменить его ключом из другого пароля.
+func (ah *AuthHandler) SaveKDFCheck(userID uuid.UUID, check string) error {
+	if raw, err := base64.StdEncoding.DecodeString(check); err != nil || len(raw) == 0 {
+		return newRequestError(http.StatusBadRequest, "Проверочное значение должно быть непустой строкой base64")
+	}
+
+	if err := ah.userRepo.SetKDFCheck(userID, check); err != nil {
+		if errors.Is(err, repository.ErrKDFCheckSet) {
+			return newRequestError(http.StatusConflict, "Проверочное значение мастер-пароля уже задано")
+		}
+		return newRequestError(http.StatusInternalServerError, "Ошибка обновления пользователя")
+	}
+	return nil
 }
@@ -7,8 +7,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/AlexeySalamakhin/GophKeeper/internal/auth"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
 	"github.com/gin-gonic/gin"
@@ -118,8 +120,11 @@ func setupTestAuthHandler(t *testing.T) *AuthHandler {
 	memRepo := repository.NewMemoryRepository()
 
 	handler := &AuthHandler{
-		userRepo:  memRepo.NewUserRepository(),
-		jwtSecret: "test-secret-key",
+		userRepo:    memRepo.NewUserRepository(),
+		sessionRepo: memRepo.NewSessionRepository(),
+		jwtSecret:   "test-secret-key",
+		keys:        newTestKeyring(t, "test-encryption-key"),
+		refreshTTL:  time.Hour,
 	}
 
 	return handler
@@ -160,6 +165,15 @@ func TestAuthHandler_Register_Success(t *testing.T) {
 	if response.User.Username != "newuser" {
 		t.Errorf("Ожидался username %s, получен %s", "newuser", response.User.Username)
 	}
+
+	if response.KDFSalt == "" {
+		t.Error("Соль для получения ключа хранилища не должна быть пустой")
+	}
+
+	user, _ := handler.userRepo.GetByUsername("newuser")
+	if _, err := crypto.UnwrapDataKey(handler.keys, user.ID.String(), user.DataKey); err != nil {
+		t.Errorf("При регистрации должен создаваться ключ данных: %v", err)
+	}
 }
 
 func TestAuthHandler_Register_DuplicateUsername(t *testing.T) {
@@ -285,6 +299,11 @@ func TestAuthHandler_Login_Success(t *testing.T) {
 	if response.User.Username != "testuser" {
 		t.Errorf("Ожидался username %s, получен %s", "testuser", response.User.Username)
 	}
+
+	// Пользователь без соли получает ее при первом входе
+	if response.KDFSalt == "" {
+		t.Error("Соль для получения ключа хранилища не должна быть пустой")
+	}
 }
 
 func TestAuthHandler_Login_InvalidCredentials(t *testing.T) {
@@ -345,3 +364,32 @@ func TestAuthHandler_Login_UserNotFound(t *testing.T) {
 		t.Errorf("Ожидался статус %d, получен %d", http.StatusUnauthorized, w.Code)
 	}
 }
+
+func TestAuthHandler_PutKDFCheck(t *testing.T) {
+	handler := setupTestAuthHandler(t)
+	router := newSessionRouter(handler)
+
+	login := loginTestUser(t, handler, router, "laptop")
+	if login.KDFCheck != "" {
+		t.Fatalf("Проверочное значение не должно быть задано, получено %q", login.KDFCheck)
+	}
+
+	if w := serveWithToken(router, "PUT", "/kdf-check", login.Token, KDFCheckRequest{KDFCheck: "not base64"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+
+	check := crypto.VaultKeyCheck(make([]byte, crypto.VaultKeySize))
+	if w := serveWithToken(router, "PUT", "/kdf-check", login.Token, KDFCheckRequest{KDFCheck: check}); w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+
+	// Заданное значение нельзя заменить значением из другого мастер-пароля
+	other := crypto.VaultKeyCheck(bytes.Repeat([]byte{1}, crypto.VaultKeySize))
+	if w := serveWithToken(router, "PUT", "/kdf-check", login.Token, KDFCheckRequest{KDFCheck: other}); w.Code != http.StatusConflict {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusConflict, w.Code)
+	}
+
+	if next := loginTestUser(t, handler, router, "phone"); next.KDFCheck != check {
+		t.Errorf("Ожидалось проверочное значение %q при входе, получено %q", check, next.KDFCheck)
+	}
+}
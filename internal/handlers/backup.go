@@ -0,0 +1,542 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/backup"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/datakeys"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Режимы восстановления резервной копии.
+const (
+	// RestoreMerge добавляет записи копии, которых нет в хранилище, и возвращает
+	// из корзины удаленные. Существующие записи не изменяются.
+	RestoreMerge = "merge"
+	// RestoreReplace приводит хранилище к состоянию копии: записи копии перезаписываются,
+	// а записи, которых в копии нет, перемещаются в корзину.
+	RestoreReplace = "replace"
+)
+
+// RestoreBackupRequest представляет запрос восстановления резервной копии.
+type RestoreBackupRequest struct {
+	Mode     string          `json:"mode"`
+	Snapshot backup.Snapshot `json:"snapshot"`
+}
+
+// RestoreUpload описывает файл из копии, содержимое которого клиент должен загрузить
+// заново. FolderID - папка хранилища, соответствующая папке файла в копии.
+type RestoreUpload struct {
+	ID       uuid.UUID  `json:"id"`
+	FolderID *uuid.UUID `json:"folder_id,omitempty"`
+}
+
+// RestoreBackupResponse представляет результат восстановления резервной копии.
+type RestoreBackupResponse struct {
+	Created int             `json:"created"`
+	Updated int             `json:"updated"`
+	Skipped int             `json:"skipped"`
+	Trashed int             `json:"trashed"`
+	Uploads []RestoreUpload `json:"uploads"`
+
+	// Созданные и перезаписанные записи для журнала аудита
+	created []uuid.UUID
+	updated []uuid.UUID
+}
+
+// ExportBackup возвращает снимок личного хранилища пользователя для резервной копии.
+func (dh *DataHandler) ExportBackup(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+
+	snapshot, err := dh.Backup(userUUID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	info := requestSessionInfo(c, "")
+	for _, record := range snapshot.Records {
+		dh.RecordAccess(userUUID, models.AuditRead, record.ID, info)
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// Backup собирает снимок личных записей пользователя userID с расшифрованными секретами,
+// метками и историей изменений. Записи командных хранилищ и записи, открытые другими
+// пользователями, в снимок не входят.
+func (dh *DataHandler) Backup(userID uuid.UUID) (*backup.Snapshot, error) {
+	if _, err := dh.userRepo.GetByID(userID); err != nil {
+		return nil, newRequestError(http.StatusUnauthorized, "Пользователь не найден")
+	}
+
+	data, err := dh.dataRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения данных")
+	}
+	folders, err := dh.folderRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения папок")
+	}
+
+	ids := make([]uuid.UUID, 0, len(data))
+	for _, d := range data {
+		ids = append(ids, d.ID)
+	}
+	tags, err := dh.tagRepo.GetNamesByDataIDs(ids)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения меток")
+	}
+
+	snapshot := &backup.Snapshot{
+		Folders: make([]backup.Folder, 0, len(folders)),
+		Records: make([]backup.Record, 0, len(data)),
+	}
+	for _, f := range folders {
+		snapshot.Folders = append(snapshot.Folders, backup.Folder{ID: f.ID, ParentID: f.ParentID, Name: f.Name})
+	}
+
+	keys := dh.dataKeys.ForUser(userID)
+	slices.SortFunc(data, func(a, b models.Data) int { return strings.Compare(a.ID.String(), b.ID.String()) })
+	for i := range data {
+		resp, err := dh.openPayload(&data[i], keys)
+		if err != nil {
+			return nil, newRequestError(http.StatusInternalServerError, "Ошибка расшифровки данных")
+		}
+		record := backup.Record{
+			ID:        data[i].ID,
+			Content:   backupContent(resp),
+			FolderID:  data[i].FolderID,
+			Tags:      tags[data[i].ID],
+			Favorite:  data[i].Favorite,
+			Version:   data[i].Version,
+			CreatedAt: data[i].CreatedAt,
+			UpdatedAt: data[i].UpdatedAt,
+		}
+
+		// История файлов не переносится: содержимое файла загружается заново
+		if data[i].Type != models.DataTypeFile {
+			if record.Revisions, err = dh.backupRevisions(&data[i], keys); err != nil {
+				return nil, err
+			}
+		}
+		snapshot.Records = append(snapshot.Records, record)
+	}
+
+	return snapshot, nil
+}
+
+// backupRevisions возвращает расшифрованную историю записи data от старых ревизий к новым.
+func (dh *DataHandler) backupRevisions(data *models.Data, keys *datakeys.Keys) ([]backup.Revision, error) {
+	revisions, err := dh.revisionRepo.GetByDataID(data.ID)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения истории изменений")
+	}
+
+	result := make([]backup.Revision, 0, len(revisions))
+	for i := len(revisions) - 1; i >= 0; i-- {
+		revision := &revisions[i]
+		snapshot := &models.Data{ID: data.ID, UserID: data.UserID, Version: revision.Version}
+		revision.Apply(snapshot)
+		resp, err := dh.openPayload(snapshot, keys)
+		if err != nil {
+			return nil, newRequestError(http.StatusInternalServerError, "Ошибка расшифровки данных")
+		}
+		result = append(result, backup.Revision{
+			Version:   revision.Version,
+			Action:    revision.Action,
+			ClientID:  revision.ClientID,
+			Content:   backupContent(resp),
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// backupContent переносит расшифрованное содержимое записи в снимок.
+func backupContent(resp *DataResponse) backup.Content {
+	return backup.Content{
+		Type:             resp.Type,
+		Name:             resp.Name,
+		Metadata:         resp.Metadata,
+		Login:            resp.Login,
+		Password:         resp.Password,
+		Text:             resp.Text,
+		Card:             resp.Card,
+		Binary:           resp.Binary,
+		EncryptedPayload: resp.EncryptedPayload,
+	}
+}
+
+// RestoreBackup восстанавливает личное хранилище пользователя из снимка резервной копии.
+func (dh *DataHandler) RestoreBackup(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+
+	var req RestoreBackupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	resp, err := dh.Restore(userUUID, requestClientID(c), req.Mode, &req.Snapshot)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	info := requestSessionInfo(c, "")
+	for _, id := range resp.created {
+		dh.RecordAccess(userUUID, models.AuditCreate, id, info)
+	}
+	for _, id := range resp.updated {
+		dh.RecordAccess(userUUID, models.AuditUpdate, id, info)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Restore восстанавливает записи снимка snapshot в хранилище пользователя userID
+// с устройства clientID в режиме mode. Записи сохраняют ID, даты создания и историю
+// изменений из копии; ID, занятый чужой записью, заменяется новым. Восстановление
+// добавляет в историю каждой записи ревизию restore. Папки и записи восстанавливаются
+// в одной транзакции: при ошибке хранилище остается в прежнем состоянии.
+func (dh *DataHandler) Restore(userID uuid.UUID, clientID, mode string, snapshot *backup.Snapshot) (*RestoreBackupResponse, error) {
+	if mode != RestoreMerge && mode != RestoreReplace {
+		return nil, newRequestError(http.StatusBadRequest, "Режим восстановления должен быть merge или replace")
+	}
+	if _, err := dh.userRepo.GetByID(userID); err != nil {
+		return nil, newRequestError(http.StatusUnauthorized, "Пользователь не найден")
+	}
+	for i := range snapshot.Records {
+		if err := checkBackupRecord(&snapshot.Records[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var resp *RestoreBackupResponse
+	err := dh.transaction(func(tx *DataHandler) error {
+		var err error
+		resp, err = tx.restoreSnapshot(userID, clientID, mode, snapshot)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// restoreSnapshot восстанавливает папки и записи проверенного снимка snapshot.
+func (dh *DataHandler) restoreSnapshot(userID uuid.UUID, clientID, mode string, snapshot *backup.Snapshot) (*RestoreBackupResponse, error) {
+	folderIDs, err := dh.restoreFolders(userID, snapshot.Folders)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &RestoreBackupResponse{Uploads: []RestoreUpload{}}
+	if mode == RestoreReplace {
+		if resp.Trashed, err = dh.trashMissing(userID, clientID, snapshot.Records); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range snapshot.Records {
+		record := &snapshot.Records[i]
+		var folderID *uuid.UUID
+		if record.FolderID != nil {
+			if id, ok := folderIDs[*record.FolderID]; ok {
+				folderID = &id
+			}
+		}
+
+		current, deleted := dh.restoreTarget(userID, record.ID)
+		switch {
+		case record.Type == models.DataTypeFile:
+			// Содержимое файла загружает клиент, живой файл хранилища сохраняется
+			if current != nil && !deleted {
+				resp.Skipped++
+			} else {
+				resp.Uploads = append(resp.Uploads, RestoreUpload{ID: record.ID, FolderID: folderID})
+			}
+		case current == nil:
+			id, err := dh.createFromBackup(userID, clientID, record, folderID)
+			if err != nil {
+				return nil, err
+			}
+			resp.Created++
+			resp.created = append(resp.created, id)
+		case !deleted && mode == RestoreMerge:
+			resp.Skipped++
+		default:
+			if deleted {
+				if err := dh.dataRepo.Restore(current.ID); err != nil {
+					return nil, newRequestError(http.StatusInternalServerError, "Ошибка восстановления данных")
+				}
+			}
+			if err := dh.overwriteFromBackup(clientID, current, record, folderID); err != nil {
+				return nil, err
+			}
+			resp.Updated++
+			resp.updated = append(resp.updated, current.ID)
+		}
+	}
+
+	return resp, nil
+}
+
+// checkBackupRecord проверяет запись снимка до начала восстановления,
+// чтобы некорректная копия не была восстановлена частично.
+func checkBackupRecord(record *backup.Record) error {
+	if record.ID == uuid.Nil || record.Name == "" || !record.Type.IsValid() {
+		return newRequestError(http.StatusBadRequest, "Снимок содержит некорректную запись")
+	}
+	if _, err := tagNames(record.Tags); err != nil {
+		return err
+	}
+	if record.Type == models.DataTypeFile {
+		return nil
+	}
+	if record.EncryptedPayload != "" {
+		if err := validateClientPayload(record.EncryptedPayload, false); err != nil {
+			return newRequestError(http.StatusBadRequest, err.Error())
+		}
+		return nil
+	}
+	if err := backupPayload(&record.Content).Validate(); err != nil {
+		return newRequestError(http.StatusBadRequest, "Запись "+record.Name+": "+err.Error())
+	}
+	return nil
+}
+
+// backupPayload собирает типизированное содержимое записи из снимка.
+func backupPayload(content *backup.Content) payload {
+	return newPayload(content.Type, &CreateDataRequest{
+		Login:    content.Login,
+		Password: content.Password,
+		Text:     content.Text,
+		Card:     content.Card,
+		Binary:   content.Binary,
+	})
+}
+
+// restoreFolders находит или создает папки снимка в хранилище пользователя и возвращает
+// соответствие ID папок копии и хранилища. Папки сопоставляются по пути от корня.
+func (dh *DataHandler) restoreFolders(userID uuid.UUID, folders []backup.Folder) (map[uuid.UUID]uuid.UUID, error) {
+	existing, err := dh.folderRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения папок")
+	}
+
+	byID := make(map[uuid.UUID]backup.Folder, len(folders))
+	for _, f := range folders {
+		byID[f.ID] = f
+	}
+	result := make(map[uuid.UUID]uuid.UUID, len(folders))
+
+	var resolve func(f backup.Folder, depth int) (uuid.UUID, error)
+	resolve = func(f backup.Folder, depth int) (uuid.UUID, error) {
+		if id, ok := result[f.ID]; ok {
+			return id, nil
+		}
+		// Глубина ограничена числом папок на случай зацикленного дерева в копии
+		if depth > len(folders) {
+			return uuid.Nil, newRequestError(http.StatusBadRequest, "Снимок содержит зацикленное дерево папок")
+		}
+
+		var parentID *uuid.UUID
+		if f.ParentID != nil {
+			if parent, ok := byID[*f.ParentID]; ok {
+				id, err := resolve(parent, depth+1)
+				if err != nil {
+					return uuid.Nil, err
+				}
+				parentID = &id
+			}
+		}
+
+		for _, e := range existing {
+			if e.Name == f.Name && sameParent(e.ParentID, parentID) {
+				result[f.ID] = e.ID
+				return e.ID, nil
+			}
+		}
+
+		folder := &models.Folder{UserID: userID, ParentID: parentID, Name: f.Name}
+		if err := checkFolder(existing, folder); err != nil {
+			return uuid.Nil, err
+		}
+		if err := dh.folderRepo.Create(folder); err != nil {
+			return uuid.Nil, newRequestError(http.StatusInternalServerError, "Ошибка создания папки")
+		}
+		existing = append(existing, *folder)
+		result[f.ID] = folder.ID
+		return folder.ID, nil
+	}
+
+	for _, f := range folders {
+		if _, err := resolve(f, 0); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// trashMissing перемещает в корзину личные записи пользователя, которых нет в records,
+// и возвращает их количество.
+func (dh *DataHandler) trashMissing(userID uuid.UUID, clientID string, records []backup.Record) (int, error) {
+	inBackup := make(map[uuid.UUID]bool, len(records))
+	for _, record := range records {
+		inBackup[record.ID] = true
+	}
+
+	data, err := dh.dataRepo.GetByUserID(userID)
+	if err != nil {
+		return 0, newRequestError(http.StatusInternalServerError, "Ошибка получения данных")
+	}
+	trashed := 0
+	for _, d := range data {
+		if inBackup[d.ID] {
+			continue
+		}
+		if err := dh.Delete(userID, d.ID, clientID); err != nil {
+			return trashed, err
+		}
+		trashed++
+	}
+	return trashed, nil
+}
+
+// restoreTarget возвращает личную запись пользователя с ID записи копии
+// и признак того, что она находится в корзине. Если ID свободен или занят чужой
+// записью, возвращается nil.
+func (dh *DataHandler) restoreTarget(userID, id uuid.UUID) (*models.Data, bool) {
+	if data, err := dh.dataRepo.GetByID(id); err == nil {
+		if data.UserID == userID && data.VaultID == nil {
+			return data, false
+		}
+		return nil, false
+	}
+	if data, err := dh.dataRepo.GetDeletedByID(id); err == nil && data.UserID == userID && data.VaultID == nil {
+		return data, true
+	}
+	return nil, false
+}
+
+// restoreID возвращает ID для новой записи из копии: исходный, если он свободен.
+func (dh *DataHandler) restoreID(id uuid.UUID) uuid.UUID {
+	if dh.idTaken(id) {
+		return uuid.New()
+	}
+	return id
+}
+
+// createFromBackup создает запись из копии вместе с ее историей изменений
+// и возвращает ID созданной записи.
+func (dh *DataHandler) createFromBackup(userID uuid.UUID, clientID string, record *backup.Record, folderID *uuid.UUID) (uuid.UUID, error) {
+	id := dh.restoreID(record.ID)
+	// Содержимое, зашифрованное на клиенте, привязано к исходному ID и под другим не расшифруется
+	if id != record.ID && record.EncryptedPayload != "" {
+		return uuid.Nil, newRequestError(http.StatusConflict, "Запись "+record.Name+" зашифрована на клиенте для ID, который занят другой записью")
+	}
+
+	for i := range record.Revisions {
+		rev := &record.Revisions[i]
+		snapshot := &models.Data{ID: id, UserID: userID, Version: rev.Version, Type: rev.Type, Name: rev.Name, Metadata: rev.Metadata}
+		if err := dh.sealBackupContent(snapshot, &rev.Content); err != nil {
+			return uuid.Nil, err
+		}
+		revision := models.NewRevision(snapshot, rev.Action, rev.ClientID)
+		revision.CreatedAt = rev.CreatedAt
+		if err := dh.revisionRepo.Create(revision); err != nil {
+			return uuid.Nil, newRequestError(http.StatusInternalServerError, "Ошибка сохранения истории изменений")
+		}
+	}
+
+	// Восстановление - новое изменение записи, поэтому версия больше версии из копии
+	data := &models.Data{
+		ID:        id,
+		UserID:    userID,
+		FolderID:  folderID,
+		Favorite:  record.Favorite,
+		Type:      record.Type,
+		Name:      record.Name,
+		Metadata:  record.Metadata,
+		Version:   record.Version + 1,
+		CreatedAt: record.CreatedAt,
+	}
+	if err := dh.sealBackupContent(data, &record.Content); err != nil {
+		return uuid.Nil, err
+	}
+	if err := dh.dataRepo.Create(data); err != nil {
+		return uuid.Nil, newRequestError(http.StatusInternalServerError, "Ошибка создания данных")
+	}
+	return id, dh.finishRestore(clientID, data, record.Tags)
+}
+
+// overwriteFromBackup заменяет содержимое записи data содержимым записи копии.
+func (dh *DataHandler) overwriteFromBackup(clientID string, data *models.Data, record *backup.Record, folderID *uuid.UUID) error {
+	expectedVersion := data.Version
+	data.Type = record.Type
+	data.Name = record.Name
+	data.Metadata = record.Metadata
+	data.FolderID = folderID
+	data.Favorite = record.Favorite
+	data.DeletedAt.Valid = false
+	if err := dh.sealBackupContent(data, &record.Content); err != nil {
+		return err
+	}
+
+	if err := dh.dataRepo.UpdateWithVersion(data, expectedVersion); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			if current, err := dh.dataRepo.GetByID(data.ID); err == nil {
+				return dh.conflictError(current)
+			}
+		}
+		return newRequestError(http.StatusInternalServerError, "Ошибка обновления данных")
+	}
+	return dh.finishRestore(clientID, data, record.Tags)
+}
+
+// finishRestore назначает восстановленной записи метки и сохраняет ревизию restore.
+func (dh *DataHandler) finishRestore(clientID string, data *models.Data, tags []string) error {
+	names, err := tagNames(tags)
+	if err != nil {
+		return err
+	}
+	if err := dh.setTags(data, names); err != nil {
+		return err
+	}
+	if err := dh.recordRevision(clientID, data, models.RevisionRestore); err != nil {
+		return newRequestError(http.StatusInternalServerError, "Ошибка сохранения истории изменений")
+	}
+	return nil
+}
+
+// sealBackupContent шифрует секреты содержимого копии ключом владельца записи data.
+// Содержимое, зашифрованное на клиенте, сохраняется как есть.
+func (dh *DataHandler) sealBackupContent(data *models.Data, content *backup.Content) error {
+	data.Login, data.Password, data.Payload = "", "", ""
+	data.ClientEncrypted = content.EncryptedPayload != ""
+	if data.ClientEncrypted {
+		data.Payload = content.EncryptedPayload
+		return nil
+	}
+	if content.Type == models.DataTypeFile {
+		return nil
+	}
+	if err := dh.sealPayload(data, backupPayload(content)); err != nil {
+		return newRequestError(http.StatusInternalServerError, "Ошибка шифрования данных")
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/backup"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/google/uuid"
+)
+
+// fillBackupVault создает у пользователя записи разных типов с папкой, метками, избранным
+// и историей изменений.
+func fillBackupVault(t *testing.T, handler *DataHandler, userID uuid.UUID) {
+	t.Helper()
+	work, err := handler.AddFolder(userID, &FolderRequest{Name: "Work"})
+	if err != nil {
+		t.Fatalf("Ошибка создания папки: %v", err)
+	}
+	mail, err := handler.AddFolder(userID, &FolderRequest{Name: "Mail", ParentID: &work.ID})
+	if err != nil {
+		t.Fatalf("Ошибка создания папки: %v", err)
+	}
+
+	gmail, err := handler.Create(userID, "laptop", &CreateDataRequest{Name: "Gmail", Login: "user", Password: "first", FolderID: &mail.ID, Tags: []string{"mail"}, Favorite: true})
+	if err != nil {
+		t.Fatalf("Ошибка создания записи: %v", err)
+	}
+	if _, err := handler.Update(userID, gmail.ID, "phone", &UpdateDataRequest{Version: &gmail.Version, Password: "second"}); err != nil {
+		t.Fatalf("Ошибка обновления записи: %v", err)
+	}
+	for _, req := range []CreateDataRequest{
+		{Type: models.DataTypeBankCard, Name: "Visa", Card: &models.BankCard{Number: "4111111111111111", Holder: "USER", Expiry: "12/30", CVV: "123"}},
+		{Type: models.DataTypeText, Name: "Notes", EncryptedPayload: "c2VhbGVk"},
+	} {
+		if _, err := handler.Create(userID, "laptop", &req); err != nil {
+			t.Fatalf("Ошибка создания записи: %v", err)
+		}
+	}
+	if err := handler.dataRepo.Create(&models.Data{UserID: userID, Type: models.DataTypeFile, Name: "photo.jpg"}); err != nil {
+		t.Fatalf("Ошибка создания файла: %v", err)
+	}
+}
+
+// archiveSnapshot записывает снимок в зашифрованную резервную копию и читает его обратно.
+func archiveSnapshot(t *testing.T, snapshot *backup.Snapshot) *backup.Snapshot {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := backup.NewWriter(&buf, "backup-password", snapshot)
+	if err != nil {
+		t.Fatalf("Ошибка создания резервной копии: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Ошибка завершения резервной копии: %v", err)
+	}
+
+	r, err := backup.NewReader(&buf, "backup-password")
+	if err != nil {
+		t.Fatalf("Ошибка чтения резервной копии: %v", err)
+	}
+	return &r.Snapshot
+}
+
+// backupRecords возвращает записи снимка по названию.
+func backupRecords(snapshot *backup.Snapshot) map[string]backup.Record {
+	records := make(map[string]backup.Record, len(snapshot.Records))
+	for _, record := range snapshot.Records {
+		records[record.Name] = record
+	}
+	return records
+}
+
+// folderPath возвращает путь папки folderID в снимке.
+func folderPath(snapshot *backup.Snapshot, folderID *uuid.UUID) string {
+	path := ""
+	for folderID != nil {
+		for _, f := range snapshot.Folders {
+			if f.ID == *folderID {
+				path = "/" + f.Name + path
+				folderID = f.ParentID
+				break
+			}
+		}
+	}
+	return path
+}
+
+// assertRestored проверяет, что записи restored совпадают с записями original
+// и к истории каждой добавлена ревизия восстановления.
+func assertRestored(t *testing.T, original, restored *backup.Snapshot) {
+	t.Helper()
+	got := backupRecords(restored)
+	for name, want := range backupRecords(original) {
+		if want.Type == models.DataTypeFile {
+			continue
+		}
+		record, ok := got[name]
+		if !ok {
+			t.Errorf("Запись %s не восстановлена", name)
+			continue
+		}
+		if !reflect.DeepEqual(record.Content, want.Content) || !reflect.DeepEqual(record.Tags, want.Tags) || record.Favorite != want.Favorite {
+			t.Errorf("Запись %s восстановлена с изменениями: %+v, ожидалось %+v", name, record, want)
+		}
+		if path := folderPath(restored, record.FolderID); path != folderPath(original, want.FolderID) {
+			t.Errorf("Запись %s восстановлена в папку %q", name, path)
+		}
+		if !record.CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("Запись %s потеряла дату создания", name)
+		}
+
+		last := len(record.Revisions) - 1
+		if last != len(want.Revisions) || record.Revisions[last].Action != models.RevisionRestore {
+			t.Errorf("Запись %s: ожидалась история из копии и ревизия restore, получено %+v", name, record.Revisions)
+			continue
+		}
+		for i, revision := range want.Revisions {
+			if !reflect.DeepEqual(record.Revisions[i].Content, revision.Content) || record.Revisions[i].ClientID != revision.ClientID {
+				t.Errorf("Запись %s: ревизия %d восстановлена с изменениями", name, i)
+			}
+		}
+	}
+}
+
+func TestDataHandler_BackupRoundTrip(t *testing.T) {
+	source, _, sourceUser := setupTestDataHandler(t)
+	fillBackupVault(t, source, sourceUser)
+
+	original, err := source.Backup(sourceUser)
+	if err != nil {
+		t.Fatalf("Ошибка создания снимка: %v", err)
+	}
+	if gmail := backupRecords(original)["Gmail"]; gmail.Password != "second" || len(gmail.Revisions) != 2 || gmail.Revisions[0].Password != "first" {
+		t.Fatalf("Снимок должен содержать расшифрованную запись с историей, получено %+v", gmail)
+	}
+	snapshot := archiveSnapshot(t, original)
+
+	target, _, targetUser := setupTestDataHandler(t)
+	resp, err := target.Restore(targetUser, "desktop", RestoreMerge, snapshot)
+	if err != nil {
+		t.Fatalf("Ошибка восстановления: %v", err)
+	}
+	if resp.Created != 3 || resp.Updated != 0 || len(resp.Uploads) != 1 {
+		t.Errorf("Ожидалось 3 созданные записи и 1 файл для загрузки, получено %+v", resp)
+	}
+	restored, _ := target.Backup(targetUser)
+	assertRestored(t, original, restored)
+
+	// Повторное слияние не изменяет существующие записи
+	if resp, _ := target.Restore(targetUser, "desktop", RestoreMerge, snapshot); resp.Created != 0 || resp.Skipped != 3 {
+		t.Errorf("Повторное слияние: ожидалось 3 пропущенные записи, получено %+v", resp)
+	}
+}
+
+func TestDataHandler_RestoreReplace(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+	fillBackupVault(t, handler, userID)
+	original, _ := handler.Backup(userID)
+	snapshot := archiveSnapshot(t, original)
+
+	records := backupRecords(original)
+	gmail := records["Gmail"]
+	if _, err := handler.Update(userID, gmail.ID, "laptop", &UpdateDataRequest{Version: &gmail.Version, Password: "changed"}); err != nil {
+		t.Fatalf("Ошибка обновления записи: %v", err)
+	}
+	if err := handler.Delete(userID, records["Visa"].ID, "laptop"); err != nil {
+		t.Fatalf("Ошибка удаления записи: %v", err)
+	}
+	if _, err := handler.Create(userID, "laptop", &CreateDataRequest{Name: "Extra", Login: "extra", Password: "extra"}); err != nil {
+		t.Fatalf("Ошибка создания записи: %v", err)
+	}
+
+	resp, err := handler.Restore(userID, "desktop", RestoreReplace, snapshot)
+	if err != nil {
+		t.Fatalf("Ошибка восстановления: %v", err)
+	}
+	if resp.Updated != 3 || resp.Trashed != 1 || resp.Skipped != 1 || len(resp.Uploads) != 0 {
+		t.Errorf("Ожидалось 3 перезаписанные, 1 удаленная и 1 сохраненный файл, получено %+v", resp)
+	}
+
+	restored, _ := handler.Backup(userID)
+	got := backupRecords(restored)
+	if _, ok := got["Extra"]; ok || len(got) != len(records) {
+		t.Errorf("После замены хранилище должно совпадать с копией, получено %v", got)
+	}
+	if got["Gmail"].ID != records["Gmail"].ID || got["Gmail"].Password != "second" {
+		t.Errorf("Запись Gmail должна вернуться к состоянию копии, получено %+v", got["Gmail"])
+	}
+	if got["Visa"].ID != records["Visa"].ID || !reflect.DeepEqual(got["Visa"].Card, records["Visa"].Card) {
+		t.Errorf("Запись Visa должна вернуться из корзины, получено %+v", got["Visa"])
+	}
+}
+
+func TestDataHandler_RestoreForeignIDs(t *testing.T) {
+	handler, memRepo, userID := setupTestDataHandler(t)
+	fillBackupVault(t, handler, userID)
+	original, _ := handler.Backup(userID)
+
+	// ID записей копии заняты записями первого пользователя
+	otherID := uuid.New()
+	memRepo.NewUserRepository().Create(&models.User{ID: otherID, Username: "other", Email: "other@example.com"})
+
+	// Содержимое, зашифрованное на клиенте, привязано к ID и не переносится под новый
+	var reqErr *RequestError
+	if _, err := handler.Restore(otherID, "desktop", RestoreMerge, original); !errors.As(err, &reqErr) || reqErr.Status != http.StatusConflict {
+		t.Fatalf("Ожидался конфликт для записи, зашифрованной на клиенте, получено %v", err)
+	}
+	records := original.Records[:0]
+	for _, record := range original.Records {
+		if record.EncryptedPayload == "" {
+			records = append(records, record)
+		}
+	}
+	original.Records = records
+
+	resp, err := handler.Restore(otherID, "desktop", RestoreMerge, original)
+	if err != nil {
+		t.Fatalf("Ошибка восстановления: %v", err)
+	}
+	if resp.Created != 2 {
+		t.Errorf("Ожидалось 3 созданные записи, получено %+v", resp)
+	}
+
+	restored, _ := handler.Backup(otherID)
+	for _, record := range restored.Records {
+		if _, err := handler.FindData(userID, record.ID); err == nil {
+			t.Errorf("Запись %s восстановлена с ID чужой записи", record.Name)
+		}
+	}
+	if mine, _ := handler.Backup(userID); !reflect.DeepEqual(backupRecords(mine)["Gmail"].Content, backupRecords(original)["Gmail"].Content) {
+		t.Error("Восстановление не должно изменять записи другого пользователя")
+	}
+}
+
+func TestDataHandler_RestoreInvalid(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+
+	if _, err := handler.Restore(userID, "desktop", "overwrite", &backup.Snapshot{}); err == nil {
+		t.Error("Ожидалась ошибка для неизвестного режима")
+	}
+	snapshot := &backup.Snapshot{Records: []backup.Record{
+		{ID: uuid.New(), Content: backup.Content{Type: models.DataTypeText, Name: "Note", Text: "text"}},
+		{ID: uuid.New(), Content: backup.Content{Type: models.DataTypeBankCard, Name: "Card", Card: &models.BankCard{Number: "1"}}},
+	}}
+	if _, err := handler.Restore(userID, "desktop", RestoreMerge, snapshot); err == nil {
+		t.Error("Ожидалась ошибка для некорректной записи")
+	}
+	// Некорректная копия не восстанавливается частично
+	if data, _ := handler.dataRepo.GetByUserID(userID); len(data) != 0 {
+		t.Errorf("Ожидалось пустое хранилище, получено %d записей", len(data))
+	}
+}
+
+func TestDataHandler_RestoreAtomic(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+	fillBackupVault(t, handler, userID)
+	snapshot, _ := handler.Backup(userID)
+	snapshot.Folders = append(snapshot.Folders, backup.Folder{ID: uuid.New(), Name: "Archive"})
+
+	extra, err := handler.Create(userID, "laptop", &CreateDataRequest{Name: "Extra", Login: "extra", Password: "extra"})
+	if err != nil {
+		t.Fatalf("Ошибка создания записи: %v", err)
+	}
+	folders, _ := handler.folderRepo.GetByUserID(userID)
+
+	// Перезапись записей копии завершается ошибкой после переноса Extra в корзину и создания папки
+	repo := handler.dataRepo
+	handler.dataRepo = failingUpdateRepo{repo}
+	_, err = handler.Restore(userID, "desktop", RestoreReplace, snapshot)
+	handler.dataRepo = repo
+	if err == nil {
+		t.Fatal("Ожидалась ошибка восстановления")
+	}
+
+	if _, err := repo.GetByID(extra.ID); err != nil {
+		t.Errorf("Запись Extra не должна попасть в корзину после неудачного восстановления: %v", err)
+	}
+	if got, _ := handler.folderRepo.GetByUserID(userID); len(got) != len(folders) {
+		t.Errorf("Папки неудачного восстановления не должны сохраняться: было %d, стало %d", len(folders), len(got))
+	}
+}
@@ -0,0 +1,217 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/events"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxBatchOperations ограничивает количество операций в одном пакете.
+const maxBatchOperations = 1000
+
+// BatchOperation представляет операцию пакета: создание записи с полями Create,
+// обновление записи ID с полями Update или удаление записи ID.
+type BatchOperation struct {
+	Action models.RevisionAction `json:"action"`
+	ID     uuid.UUID             `json:"id"`
+	Create *CreateDataRequest    `json:"create"`
+	Update *UpdateDataRequest    `json:"update"`
+}
+
+// BatchRequest представляет запрос пакета операций.
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchResult представляет результат операции пакета. Status содержит HTTP статус,
+// который вернул бы отдельный запрос с этой операцией. При конфликте версий Current
+// содержит текущую копию записи.
+type BatchResult struct {
+	Index   int           `json:"index"`
+	Status  int           `json:"status"`
+	Data    *models.Data  `json:"data,omitempty"`
+	Error   string        `json:"error,omitempty"`
+	Current *DataResponse `json:"current,omitempty"`
+}
+
+// BatchResponse представляет ответ на запрос пакета операций.
+// Committed сообщает, применены ли операции: пакет применяется целиком или не применяется совсем.
+type BatchResponse struct {
+	Committed bool          `json:"committed"`
+	Results   []BatchResult `json:"results"`
+
+	// Статус ответа для отмененного пакета - статус операции, из-за которой он отменен
+	status int
+}
+
+// BatchData выполняет пакет операций создания, обновления и удаления записей атомарно.
+func (dh *DataHandler) BatchData(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+
+	var req BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	resp, err := dh.Batch(userUUID, requestClientID(c), req.Operations)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !resp.Committed {
+		c.JSON(resp.status, resp)
+		return
+	}
+
+	info := requestSessionInfo(c, "")
+	for i, result := range resp.Results {
+		switch req.Operations[i].Action {
+		case models.RevisionCreate:
+			dh.RecordAccess(userUUID, models.AuditCreate, result.Data.ID, info)
+		case models.RevisionUpdate:
+			dh.RecordAccess(userUUID, models.AuditUpdate, result.Data.ID, info)
+		case models.RevisionDelete:
+			dh.RecordAccess(userUUID, models.AuditDelete, req.Operations[i].ID, info)
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Batch выполняет операции пользователя userID с устройства clientID в одной транзакции,
+// по порядку. Если одна из операций завершается ошибкой, изменения всех операций
+// отменяются, а подписчики не получают событий.
+func (dh *DataHandler) Batch(userID uuid.UUID, clientID string, operations []BatchOperation) (*BatchResponse, error) {
+	if len(operations) == 0 {
+		return nil, newRequestError(http.StatusBadRequest, "Список операций пуст")
+	}
+	if len(operations) > maxBatchOperations {
+		return nil, newRequestError(http.StatusBadRequest, fmt.Sprintf("Пакет может содержать не больше %d операций", maxBatchOperations))
+	}
+	if _, err := dh.userRepo.GetByID(userID); err != nil {
+		return nil, newRequestError(http.StatusUnauthorized, "Пользователь не найден")
+	}
+
+	resp := &BatchResponse{Results: make([]BatchResult, len(operations))}
+	for i := range resp.Results {
+		resp.Results[i].Index = i
+	}
+
+	failed := -1
+	err := dh.transaction(func(tx *DataHandler) error {
+		for i := range operations {
+			result := &resp.Results[i]
+			if err := tx.applyOperation(userID, clientID, &operations[i], result); err != nil {
+				failed = i
+				setBatchError(result, err)
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if failed < 0 {
+			return nil, newRequestError(http.StatusInternalServerError, "Ошибка сохранения изменений")
+		}
+		for i := range resp.Results {
+			if i != failed {
+				resp.Results[i] = BatchResult{
+					Index:  i,
+					Status: http.StatusFailedDependency,
+					Error:  fmt.Sprintf("Операция отменена из-за ошибки в операции %d", failed),
+				}
+			}
+		}
+		resp.status = resp.Results[failed].Status
+		return resp, nil
+	}
+
+	resp.Committed = true
+	return resp, nil
+}
+
+// transaction выполняет fn с копией обработчика, работающей в транзакции базы данных.
+// События об изменениях рассылаются только после фиксации транзакции.
+func (dh *DataHandler) transaction(fn func(tx *DataHandler) error) error {
+	var pending []events.Event
+	err := dh.dataRepo.Transaction(func(uow *repository.UnitOfWork) error {
+		return fn(dh.inTransaction(uow, &pending))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, event := range pending {
+		dh.events.Publish(event)
+	}
+	return nil
+}
+
+// inTransaction возвращает копию обработчика, которая изменяет записи, ревизии, метки и папки
+// через репозитории транзакции uow и откладывает события в pending.
+func (dh *DataHandler) inTransaction(uow *repository.UnitOfWork, pending *[]events.Event) *DataHandler {
+	tx := *dh
+	tx.dataRepo = uow.Data
+	tx.revisionRepo = uow.Revisions
+	tx.tagRepo = uow.Tags
+	tx.folderRepo = uow.Folders
+	tx.pending = pending
+	return &tx
+}
+
+// applyOperation выполняет операцию пакета и заполняет ее результат.
+func (dh *DataHandler) applyOperation(userID uuid.UUID, clientID string, op *BatchOperation, result *BatchResult) error {
+	switch op.Action {
+	case models.RevisionCreate:
+		if op.Create == nil {
+			return newRequestError(http.StatusBadRequest, "Для создания записи нужно поле create")
+		}
+		data, err := dh.create(userID, clientID, op.Create)
+		if err != nil {
+			return err
+		}
+		result.Status, result.Data = http.StatusCreated, data
+	case models.RevisionUpdate:
+		if op.Update == nil {
+			return newRequestError(http.StatusBadRequest, "Для обновления записи нужно поле update")
+		}
+		data, err := dh.Update(userID, op.ID, clientID, op.Update)
+		if err != nil {
+			return err
+		}
+		result.Status, result.Data = http.StatusOK, data
+	case models.RevisionDelete:
+		if err := dh.Delete(userID, op.ID, clientID); err != nil {
+			return err
+		}
+		result.Status = http.StatusNoContent
+	default:
+		return newRequestError(http.StatusBadRequest, "Операция должна быть create, update или delete")
+	}
+	return nil
+}
+
+// setBatchError заполняет результат операции, завершившейся ошибкой err.
+func setBatchError(result *BatchResult, err error) {
+	var conflict *ConflictError
+	var reqErr *RequestError
+	switch {
+	case errors.As(err, &conflict):
+		result.Status, result.Error, result.Current = http.StatusConflict, conflict.Error(), conflict.Current
+	case errors.As(err, &reqErr):
+		result.Status, result.Error = reqErr.Status, reqErr.Message
+	default:
+		result.Status, result.Error = http.StatusInternalServerError, "Внутренняя ошибка сервера"
+	}
+}
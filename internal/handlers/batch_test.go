@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/events"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+)
+
+func TestDataHandler_BatchData(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+	handler.events = events.NewHub()
+	router := newOrganizeRouter(handler, userID)
+	router.POST("/data/batch", handler.BatchData)
+
+	mail, _ := handler.Create(userID, "laptop", &CreateDataRequest{Name: "Mail", Login: "user", Password: "first"})
+	bank, _ := handler.Create(userID, "laptop", &CreateDataRequest{Name: "Bank", Login: "user", Password: "secret"})
+	changes, unsubscribe := handler.events.Subscribe(userID)
+	defer unsubscribe()
+
+	w := serveJSON(router, "POST", "/data/batch", "phone", BatchRequest{Operations: []BatchOperation{
+		{Action: models.RevisionCreate, Create: &CreateDataRequest{Name: "Wi-Fi", Type: models.DataTypeText, Text: "password"}},
+		{Action: models.RevisionUpdate, ID: mail.ID, Update: &UpdateDataRequest{Version: &mail.Version, Password: "second"}},
+		{Action: models.RevisionDelete, ID: bank.ID},
+	}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp BatchResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if !resp.Committed || len(resp.Results) != 3 || resp.Results[0].Status != http.StatusCreated ||
+		resp.Results[1].Status != http.StatusOK || resp.Results[2].Status != http.StatusNoContent {
+		t.Fatalf("Неожиданный результат пакета: %+v", resp)
+	}
+	if updated, err := handler.FindData(userID, mail.ID); err != nil || updated.Password != "second" || updated.Version != 2 {
+		t.Errorf("Запись Mail должна быть обновлена, получено %+v, %v", updated, err)
+	}
+	if _, err := handler.FindData(userID, bank.ID); err == nil {
+		t.Error("Запись Bank должна быть удалена")
+	}
+	if len(changes) != 3 {
+		t.Errorf("Ожидалось 3 события после фиксации пакета, получено %d", len(changes))
+	}
+}
+
+func TestDataHandler_BatchData_Rollback(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+	handler.events = events.NewHub()
+	router := newOrganizeRouter(handler, userID)
+	router.POST("/data/batch", handler.BatchData)
+
+	mail, _ := handler.Create(userID, "laptop", &CreateDataRequest{Name: "Mail", Login: "user", Password: "first", Tags: []string{"mail"}})
+	stale := mail.Version
+	mail, _ = handler.Update(userID, mail.ID, "laptop", &UpdateDataRequest{Version: &stale, Password: "second"})
+	changes, unsubscribe := handler.events.Subscribe(userID)
+	defer unsubscribe()
+
+	tests := []struct {
+		name       string
+		operations []BatchOperation
+		status     int
+	}{
+		{
+			name: "конфликт версий",
+			operations: []BatchOperation{
+				{Action: models.RevisionCreate, Create: &CreateDataRequest{Name: "Wi-Fi", Type: models.DataTypeText, Text: "password", Tags: []string{"home"}}},
+				{Action: models.RevisionUpdate, ID: mail.ID, Update: &UpdateDataRequest{Version: &mail.Version, Name: "Renamed"}},
+				{Action: models.RevisionUpdate, ID: mail.ID, Update: &UpdateDataRequest{Version: &stale, Password: "third"}},
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name: "неизвестная операция",
+			operations: []BatchOperation{
+				{Action: models.RevisionDelete, ID: mail.ID},
+				{Action: models.RevisionRestore, ID: mail.ID},
+			},
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveJSON(router, "POST", "/data/batch", "phone", BatchRequest{Operations: tt.operations})
+			if w.Code != tt.status {
+				t.Fatalf("Ожидался статус %d, получен %d: %s", tt.status, w.Code, w.Body.String())
+			}
+
+			var resp BatchResponse
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			last := len(tt.operations) - 1
+			if resp.Committed || resp.Results[last].Status != tt.status || resp.Results[0].Status != http.StatusFailedDependency {
+				t.Errorf("Неожиданный результат пакета: %+v", resp)
+			}
+
+			// Изменения всех операций отменены, а подписчики о них не узнали
+			current, err := handler.FindData(userID, mail.ID)
+			if err != nil || current.Name != "Mail" || current.Version != mail.Version {
+				t.Errorf("Запись Mail не должна измениться, получено %+v, %v", current, err)
+			}
+			if names := listNames(t, router, "/data"); len(names) != 1 {
+				t.Errorf("Ожидалась только запись Mail, получено %v", names)
+			}
+			if history, _ := handler.revisionRepo.GetByDataID(mail.ID); len(history) != 2 {
+				t.Errorf("Ожидалось 2 ревизии записи Mail, получено %d", len(history))
+			}
+			if tags, _ := handler.tagRepo.GetByUserID(userID); len(tags) != 1 {
+				t.Errorf("Ожидалась только метка mail, получено %+v", tags)
+			}
+			if len(changes) != 0 {
+				t.Errorf("Отмененный пакет не должен рассылать события, получено %d", len(changes))
+			}
+		})
+	}
+
+	if w := serveJSON(router, "POST", "/data/batch", "phone", BatchRequest{}); w.Code != http.StatusBadRequest {
+		t.Errorf("Пустой пакет: ожидался статус %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+}
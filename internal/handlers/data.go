@@ -2,9 +2,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
+	"github.com/AlexeySalamakhin/GophKeeper/internal/audit"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/datakeys"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/events"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
@@ -14,37 +19,84 @@ import (
 
 // DataHandler обрабатывает запросы для работы с данными.
 type DataHandler struct {
-	dataRepo      repository.DataRepositoryInterface
-	userRepo      repository.UserRepositoryInterface
-	encryptionKey string
+	dataRepo     repository.DataRepositoryInterface
+	userRepo     repository.UserRepositoryInterface
+	revisionRepo repository.RevisionRepositoryInterface
+	shareRepo    repository.ShareRepositoryInterface
+	tagRepo      repository.TagRepositoryInterface
+	folderRepo   repository.FolderRepositoryInterface
+	authz        *middleware.Authorizer
+	events       *events.Hub
+	audit        *audit.Recorder
+	dataKeys     *datakeys.Service
+	// pending накапливает события внутри транзакции до ее фиксации
+	pending *[]events.Event
 }
 
 // NewDataHandler создает новый обработчик данных.
-func NewDataHandler(repo *repository.Repository, encryptionKey string) *DataHandler {
+// Секреты шифруются ключами данных пользователей и хранилищ, которые защищены ключом сервера из keys.
+// Об изменениях записей обработчик оповещает подписчиков hub, а чтение и изменение
+// записей фиксирует в журнале аудита recorder.
+func NewDataHandler(repo *repository.Repository, keys crypto.KeyProvider, hub *events.Hub, recorder *audit.Recorder) *DataHandler {
+	userRepo := repo.NewUserRepository()
+	vaultRepo := repo.NewVaultRepository()
 	return &DataHandler{
-		dataRepo:      repo.NewDataRepository(),
-		userRepo:      repo.NewUserRepository(),
-		encryptionKey: encryptionKey,
+		dataRepo:     repo.NewDataRepository(),
+		userRepo:     userRepo,
+		revisionRepo: repo.NewRevisionRepository(),
+		shareRepo:    repo.NewShareRepository(),
+		tagRepo:      repo.NewTagRepository(),
+		folderRepo:   repo.NewFolderRepository(),
+		authz:        middleware.NewAuthorizer(vaultRepo),
+		events:       hub,
+		audit:        recorder,
+		dataKeys:     datakeys.NewService(userRepo, vaultRepo, keys),
 	}
 }
 
 // CreateDataRequest представляет запрос создания данных.
+// Если VaultID задан, запись создается в командном хранилище, иначе ее можно сразу
+// положить в папку FolderID, отметить метками Tags и добавить в избранное.
 type CreateDataRequest struct {
-	Name     string      `json:"name"`
-	Login    string      `json:"login"`
-	Password string      `json:"password"`
-	Metadata interface{} `json:"metadata"`
+	// ID задает ID новой записи. Клиент передает его, когда привязывает к ID содержимое,
+	// зашифрованное на своей стороне; без него ID назначает сервер.
+	ID       *uuid.UUID       `json:"id"`
+	VaultID  *uuid.UUID       `json:"vault_id"`
+	FolderID *uuid.UUID       `json:"folder_id"`
+	Tags     []string         `json:"tags"`
+	Favorite bool             `json:"favorite"`
+	Type     models.DataType  `json:"type"`
+	Name     string           `json:"name"`
+	Login    string           `json:"login"`
+	Password string           `json:"password"`
+	Text     string           `json:"text"`
+	Card     *models.BankCard `json:"card"`
+	Binary   []byte           `json:"binary"`
+	Metadata interface{}      `json:"metadata"`
+	// EncryptedPayload содержит секретные поля, зашифрованные на клиенте.
+	// Если он задан, сервер сохраняет его как есть и не принимает открытые секретные поля.
+	EncryptedPayload string `json:"encrypted_payload"`
 }
 
 // UpdateDataRequest представляет запрос обновления данных.
+// Тип записи не изменяется, секретные поля применяются в соответствии с ним.
+// Ожидаемая версия записи передается в поле version или в заголовке If-Match.
 type UpdateDataRequest struct {
-	Name     string      `json:"name"`
-	Login    string      `json:"login"`
-	Password string      `json:"password"`
-	Metadata interface{} `json:"metadata"`
+	Version  *int64           `json:"version"`
+	Name     string           `json:"name"`
+	Login    string           `json:"login"`
+	Password string           `json:"password"`
+	Text     string           `json:"text"`
+	Card     *models.BankCard `json:"card"`
+	Binary   []byte           `json:"binary"`
+	Metadata interface{}      `json:"metadata"`
+	// EncryptedPayload содержит секретные поля, зашифрованные на клиенте.
+	// Если он задан, сервер сохраняет его как есть и не принимает открытые секретные поля.
+	EncryptedPayload string `json:"encrypted_payload"`
 }
 
-// GetData возвращает все данные пользователя.
+// GetData возвращает страницу данных пользователя и записей, открытых ему другими пользователями.
+// Ссылка на следующую страницу передается в заголовке Link с rel="next".
 func (dh *DataHandler) GetData(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
 	if !ok {
@@ -58,15 +110,49 @@ func (dh *DataHandler) GetData(c *gin.Context) {
 		return
 	}
 
-	data, err := dh.dataRepo.GetByUserID(userUUID)
+	query, err := dataQuery(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения данных"})
+		respondError(c, err)
 		return
 	}
 
+	data, next, err := dh.ListPage(userUUID, query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if next != nil {
+		c.Header("Link", nextPageLink(c, next))
+	}
 	c.JSON(http.StatusOK, data)
 }
 
+// ListData возвращает записи пользователя указанного типа или всех типов, если тип пуст.
+// Действующие записи других пользователей, открытые ему, возвращаются с отметкой Shared.
+func (dh *DataHandler) ListData(userID uuid.UUID, dataType models.DataType) ([]models.Data, error) {
+	var data []models.Data
+	var err error
+	if dataType != "" {
+		if !dataType.IsValid() {
+			return nil, newRequestError(http.StatusBadRequest, "Неизвестный тип данных")
+		}
+		data, err = dh.dataRepo.GetByUserIDAndType(userID, dataType)
+	} else {
+		data, err = dh.dataRepo.GetByUserID(userID)
+	}
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения данных")
+	}
+
+	shared, err := dh.sharedData(userID, dataType)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения данных")
+	}
+
+	return append(data, shared...), nil
+}
+
 // GetDataByID возвращает данные по ID.
 func (dh *DataHandler) GetDataByID(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
@@ -88,18 +174,44 @@ func (dh *DataHandler) GetDataByID(c *gin.Context) {
 		return
 	}
 
-	data, err := dh.dataRepo.GetByID(dataID)
+	resp, err := dh.FindData(userUUID, dataID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Данные не найдены"})
+		respondError(c, err)
 		return
 	}
+	dh.RecordAccess(userUUID, models.AuditRead, dataID, requestSessionInfo(c, ""))
 
-	if data.UserID != userUUID {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Данные не найдены"})
-		return
+	c.Header("ETag", formatETag(resp.Version))
+	c.JSON(http.StatusOK, resp)
+}
+
+// FindData возвращает запись пользователя вместе с расшифрованным содержимым.
+// Запись хранилища возвращается любому его участнику, а запись другого пользователя -
+// если она открыта пользователю, с отметкой Shared.
+func (dh *DataHandler) FindData(userID, dataID uuid.UUID) (*DataResponse, error) {
+	data, err := dh.dataRepo.GetByID(dataID)
+	if err != nil {
+		return nil, newRequestError(http.StatusNotFound, "Данные не найдены")
+	}
+	if err := dh.authz.AuthorizeData(data, userID, models.VaultRoleViewer); err != nil {
+		share, err := dh.sharedAccess(userID, data)
+		if err != nil {
+			return nil, err
+		}
+		dh.markShared(data, share)
 	}
+	page := []models.Data{*data}
+	if err := dh.attachTags(userID, page); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения меток")
+	}
+	data = &page[0]
 
-	c.JSON(http.StatusOK, data)
+	resp, err := dh.openPayload(data, dh.dataKeys.ForRecord(data))
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка расшифровки данных")
+	}
+
+	return resp, nil
 }
 
 // CreateData создает новые данные.
@@ -116,52 +228,148 @@ func (dh *DataHandler) CreateData(c *gin.Context) {
 		return
 	}
 
-	if _, err := dh.userRepo.GetByID(userUUID); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не найден"})
-		return
-	}
-
 	var req CreateDataRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
 		return
 	}
 
-	if req.Name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Название обязательно"})
+	data, err := dh.Create(userUUID, requestClientID(c), &req)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
+	dh.RecordAccess(userUUID, models.AuditCreate, data.ID, requestSessionInfo(c, ""))
+
+	c.Header("ETag", formatETag(data.Version))
+	c.JSON(http.StatusCreated, data)
+}
 
-	var encryptedPassword string
-	if req.Password != "" {
+// Create создает запись пользователя с устройства clientID.
+// Создать запись в хранилище может участник с ролью не ниже editor.
+func (dh *DataHandler) Create(userID uuid.UUID, clientID string, req *CreateDataRequest) (*models.Data, error) {
+	// Запись сохраняется вместе с метками и ревизией или не сохраняется совсем
+	var data *models.Data
+	err := dh.transaction(func(tx *DataHandler) error {
 		var err error
-		encryptedPassword, err = crypto.EncryptPassword(req.Password, dh.encryptionKey)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка шифрования пароля"})
-			return
+		data, err = tx.create(userID, clientID, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// create создает запись пользователя с устройства clientID. Вызывается внутри транзакции.
+func (dh *DataHandler) create(userID uuid.UUID, clientID string, req *CreateDataRequest) (*models.Data, error) {
+	if _, err := dh.userRepo.GetByID(userID); err != nil {
+		return nil, newRequestError(http.StatusUnauthorized, "Пользователь не найден")
+	}
+
+	if req.VaultID != nil {
+		if err := dh.authz.AuthorizeVault(*req.VaultID, userID, models.VaultRoleEditor); err != nil {
+			return nil, vaultAccessError(err)
+		}
+		if req.EncryptedPayload != "" {
+			return nil, newRequestError(http.StatusBadRequest, "Записи хранилища шифруются на сервере, чтобы их могли читать все участники")
+		}
+		if req.FolderID != nil || len(req.Tags) > 0 || req.Favorite {
+			return nil, newRequestError(http.StatusBadRequest, "Папки, метки и избранное доступны только для личных записей")
 		}
 	}
+	if req.FolderID != nil {
+		if folder, err := dh.folderRepo.GetByID(*req.FolderID); err != nil || folder.UserID != userID {
+			return nil, newRequestError(http.StatusNotFound, "Папка не найдена")
+		}
+	}
+	tags, err := tagNames(req.Tags)
+	if err != nil {
+		return nil, err
+	}
 
+	if req.Name == "" {
+		return nil, newRequestError(http.StatusBadRequest, "Название обязательно")
+	}
+
+	dataType := req.Type
+	if dataType == "" {
+		dataType = models.DataTypeLoginPassword
+	}
+	if !dataType.IsValid() {
+		return nil, newRequestError(http.StatusBadRequest, "Неизвестный тип данных")
+	}
+	if dataType == models.DataTypeFile {
+		return nil, newRequestError(http.StatusBadRequest, "Файлы загружаются через /api/v1/files")
+	}
+
+	// ID назначается до шифрования, потому что шифротекст привязан к записи
+	id := uuid.New()
+	if req.ID != nil {
+		if *req.ID == uuid.Nil {
+			return nil, newRequestError(http.StatusBadRequest, "Неверный ID записи")
+		}
+		if dh.idTaken(*req.ID) {
+			return nil, newRequestError(http.StatusConflict, "Запись с таким ID уже существует")
+		}
+		id = *req.ID
+	}
 	data := &models.Data{
-		UserID:   userUUID,
+		ID:       id,
+		UserID:   userID,
+		VaultID:  req.VaultID,
+		FolderID: req.FolderID,
+		Favorite: req.Favorite,
+		Type:     dataType,
 		Name:     req.Name,
-		Login:    req.Login,
-		Password: encryptedPassword,
+	}
+
+	if req.EncryptedPayload != "" {
+		if err := validateClientPayload(req.EncryptedPayload, req.hasPlainSecrets()); err != nil {
+			return nil, newRequestError(http.StatusBadRequest, err.Error())
+		}
+		data.Payload = req.EncryptedPayload
+		data.ClientEncrypted = true
+	} else {
+		payload := newPayload(dataType, req)
+		if err := payload.Validate(); err != nil {
+			return nil, newRequestError(http.StatusBadRequest, err.Error())
+		}
+
+		if err := dh.sealPayload(data, payload); err != nil {
+			return nil, newRequestError(http.StatusInternalServerError, "Ошибка шифрования данных")
+		}
 	}
 
 	if req.Metadata != nil {
 		if err := data.SetMetadata(req.Metadata); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка обработки метаданных"})
-			return
+			return nil, newRequestError(http.StatusInternalServerError, "Ошибка обработки метаданных")
 		}
 	}
 
 	if err := dh.dataRepo.Create(data); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка создания данных"})
-		return
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка создания данных")
+	}
+	if len(tags) > 0 {
+		if err := dh.setTags(data, tags); err != nil {
+			return nil, err
+		}
 	}
 
-	c.JSON(http.StatusCreated, data)
+	if err := dh.recordRevision(clientID, data, models.RevisionCreate); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка сохранения истории изменений")
+	}
+
+	return data, nil
+}
+
+// idTaken проверяет, занят ли ID записью, в том числе удаленной в корзину.
+func (dh *DataHandler) idTaken(id uuid.UUID) bool {
+	if _, err := dh.dataRepo.GetByID(id); err == nil {
+		return true
+	}
+	_, err := dh.dataRepo.GetDeletedByID(id)
+	return err == nil
 }
 
 // UpdateData обновляет существующие данные.
@@ -185,50 +393,150 @@ func (dh *DataHandler) UpdateData(c *gin.Context) {
 		return
 	}
 
-	if err := dh.dataRepo.CheckUserOwnership(dataID, userUUID); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Данные не найдены"})
-		return
-	}
-
 	var req UpdateDataRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
 		return
 	}
 
-	data, err := dh.dataRepo.GetByID(dataID)
+	if req.Version == nil {
+		if req.Version, err = ifMatchVersion(c); err != nil {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	data, err := dh.Update(userUUID, dataID, requestClientID(c), &req)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Данные не найдены"})
+		respondError(c, err)
 		return
 	}
+	dh.RecordAccess(userUUID, models.AuditUpdate, dataID, requestSessionInfo(c, ""))
 
-	if req.Name != "" {
-		data.Name = req.Name
+	c.Header("ETag", formatETag(data.Version))
+	c.JSON(http.StatusOK, data)
+}
+
+// Update обновляет запись пользователя с устройства clientID, если ее текущая версия
+// совпадает с req.Version. Иначе возвращает *ConflictError. Запись хранилища может
+// обновить участник с ролью не ниже editor, а запись другого пользователя - получатель
+// доступа с правом записи.
+func (dh *DataHandler) Update(userID, dataID uuid.UUID, clientID string, req *UpdateDataRequest) (*models.Data, error) {
+	data, err := dh.dataRepo.GetByID(dataID)
+	if err != nil {
+		return nil, newRequestError(http.StatusNotFound, "Данные не найдены")
 	}
-	if req.Login != "" {
-		data.Login = req.Login
+
+	share, err := dh.writeAccess(userID, data)
+	if err != nil {
+		return nil, err
+	}
+	if (share != nil || data.VaultID != nil) && req.EncryptedPayload != "" {
+		return nil, newRequestError(http.StatusBadRequest, "Общую запись нельзя зашифровать на клиенте")
 	}
-	if req.Password != "" {
-		encryptedPassword, err := crypto.EncryptPassword(req.Password, dh.encryptionKey)
+	if req.EncryptedPayload != "" {
+		shared, err := dh.hasActiveShares(dataID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка шифрования пароля"})
-			return
+			return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения доступов")
+		}
+		if shared {
+			// Получатели доступа не смогут расшифровать запись, зашифрованную на клиенте
+			return nil, newRequestError(http.StatusConflict, "Запись открыта другим пользователям, закройте доступ, чтобы зашифровать ее на клиенте")
+		}
+	}
+
+	if req.Version == nil {
+		return nil, newRequestError(http.StatusPreconditionRequired, "необходимо указать версию записи в поле version или заголовке If-Match")
+	}
+	expectedVersion := *req.Version
+
+	if data.Version != expectedVersion {
+		return nil, dh.conflictError(data)
+	}
+
+	if req.Name != "" {
+		data.Name = req.Name
+	}
+	if req.EncryptedPayload != "" || data.ClientEncrypted {
+		if req.hasPlainSecrets() {
+			return nil, newRequestError(http.StatusBadRequest, "Запись зашифрована на клиенте, открытые секретные поля не принимаются")
+		}
+		if req.EncryptedPayload != "" {
+			if err := validateClientPayload(req.EncryptedPayload, false); err != nil {
+				return nil, newRequestError(http.StatusBadRequest, err.Error())
+			}
+			data.Login = ""
+			data.Password = ""
+			data.Payload = req.EncryptedPayload
+			data.ClientEncrypted = true
+		}
+	} else if payload := updatePayload(data, req); payload != nil {
+		if err := payload.Validate(); err != nil {
+			return nil, newRequestError(http.StatusBadRequest, err.Error())
+		}
+		if err := dh.sealPayload(data, payload); err != nil {
+			return nil, newRequestError(http.StatusInternalServerError, "Ошибка шифрования данных")
 		}
-		data.Password = encryptedPassword
 	}
 	if req.Metadata != nil {
 		if err := data.SetMetadata(req.Metadata); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка обработки метаданных"})
-			return
+			return nil, newRequestError(http.StatusInternalServerError, "Ошибка обработки метаданных")
 		}
 	}
 
-	if err := dh.dataRepo.Update(data); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка обновления данных"})
-		return
+	if err := dh.dataRepo.UpdateWithVersion(data, expectedVersion); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			if current, err := dh.dataRepo.GetByID(dataID); err == nil {
+				return nil, dh.conflictError(current)
+			}
+		}
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка обновления данных")
 	}
 
-	c.JSON(http.StatusOK, data)
+	if err := dh.recordRevision(clientID, data, models.RevisionUpdate); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка сохранения истории изменений")
+	}
+
+	if share != nil {
+		dh.markShared(data, share)
+	}
+	return data, nil
+}
+
+// writeAccess проверяет, что пользователь может изменять запись. Для чужой записи
+// возвращает доступ, по которому она открыта пользователю, для своей записи
+// и записи хранилища - nil.
+func (dh *DataHandler) writeAccess(userID uuid.UUID, data *models.Data) (*models.Share, error) {
+	if err := dh.authz.AuthorizeData(data, userID, models.VaultRoleEditor); err == nil {
+		return nil, nil
+	}
+	if data.VaultID != nil {
+		return nil, newRequestError(http.StatusForbidden, "Недостаточно прав в хранилище")
+	}
+
+	share, err := dh.shareRepo.GetActive(data.ID, userID, time.Now())
+	if err != nil {
+		return nil, newRequestError(http.StatusForbidden, "Данные не найдены")
+	}
+	if !share.CanWrite() {
+		return nil, newRequestError(http.StatusForbidden, "Запись открыта только для чтения")
+	}
+	return share, nil
+}
+
+// hasActiveShares проверяет, что запись dataID открыта другим пользователям.
+func (dh *DataHandler) hasActiveShares(dataID uuid.UUID) (bool, error) {
+	shares, err := dh.shareRepo.GetByDataID(dataID)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	for i := range shares {
+		if shares[i].Active(now) {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 // DeleteData удаляет данные.
@@ -252,15 +560,42 @@ func (dh *DataHandler) DeleteData(c *gin.Context) {
 		return
 	}
 
-	if err := dh.dataRepo.CheckUserOwnership(dataID, userUUID); err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Данные не найдены"})
+	if err := dh.Delete(userUUID, dataID, requestClientID(c)); err != nil {
+		respondError(c, err)
 		return
 	}
+	dh.RecordAccess(userUUID, models.AuditDelete, dataID, requestSessionInfo(c, ""))
+
+	c.Data(http.StatusNoContent, "application/json", nil)
+}
+
+// Delete перемещает запись пользователя в корзину с устройства clientID.
+// Запись хранилища может удалить участник с ролью не ниже editor.
+func (dh *DataHandler) Delete(userID, dataID uuid.UUID, clientID string) error {
+	data, err := dh.dataRepo.GetByID(dataID)
+	if err != nil {
+		return newRequestError(http.StatusForbidden, "Данные не найдены")
+	}
+
+	if err := dh.authz.AuthorizeData(data, userID, models.VaultRoleEditor); err != nil {
+		return newRequestError(http.StatusForbidden, "Данные не найдены")
+	}
 
 	if err := dh.dataRepo.Delete(dataID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка удаления данных"})
-		return
+		return newRequestError(http.StatusInternalServerError, "Ошибка удаления данных")
 	}
 
-	c.Data(http.StatusNoContent, "application/json", nil)
+	if err := dh.recordRevision(clientID, data, models.RevisionDelete); err != nil {
+		return newRequestError(http.StatusInternalServerError, "Ошибка сохранения истории изменений")
+	}
+
+	return nil
+}
+
+// vaultAccessError преобразует ошибку проверки роли в хранилище в ответ клиенту.
+func vaultAccessError(err error) error {
+	if errors.Is(err, middleware.ErrNotVaultMember) {
+		return newRequestError(http.StatusNotFound, "Хранилище не найдено")
+	}
+	return newRequestError(http.StatusForbidden, "Недостаточно прав в хранилище")
 }
@@ -3,18 +3,33 @@ package handlers
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/AlexeySalamakhin/GophKeeper/internal/auth"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/datakeys"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// newTestKeyring создает связку из одного ключа default с секретом secret.
+func newTestKeyring(t *testing.T, secret string) *crypto.Keyring {
+	keys, err := crypto.NewKeyring(crypto.DefaultKeyID, map[string]string{crypto.DefaultKeyID: secret})
+	if err != nil {
+		t.Fatalf("Ошибка создания связки ключей: %v", err)
+	}
+	return keys
+}
+
 // setupTestDataHandler создает DataHandler с memory repository для тестов.
 func setupTestDataHandler(t *testing.T) (*DataHandler, *repository.MemoryRepository, uuid.UUID) {
 	gin.SetMode(gin.TestMode)
@@ -36,7 +51,12 @@ func setupTestDataHandler(t *testing.T) (*DataHandler, *repository.MemoryReposit
 	handler := &DataHandler{
 		dataRepo:      memRepo.NewDataRepository(),
 		userRepo:      userRepo,
-		encryptionKey: "test-encryption-key-32-chars!!",
+		revisionRepo:  memRepo.NewRevisionRepository(),
+		shareRepo:     memRepo.NewShareRepository(),
+		tagRepo:       memRepo.NewTagRepository(),
+		folderRepo:    memRepo.NewFolderRepository(),
+		authz:         middleware.NewAuthorizer(memRepo.NewVaultRepository()),
+		dataKeys:      datakeys.NewService(userRepo, memRepo.NewVaultRepository(), newTestKeyring(t, "test-encryption-key-32-chars!!")),
 	}
 	
 	return handler, memRepo, userID
@@ -117,11 +137,17 @@ func TestDataHandler_GetDataByID_Success(t *testing.T) {
 	
 	// Создаем тестовые данные
 	dataRepo := handler.dataRepo.(*repository.MemoryDataRepository)
+	dataID := uuid.New()
+	encryptedPassword, err := crypto.EncryptPassword("secret", "test-encryption-key-32-chars!!", crypto.RecordAAD(userID.String(), dataID.String()))
+	if err != nil {
+		t.Fatalf("Ошибка шифрования пароля: %v", err)
+	}
 	testData := &models.Data{
+		ID:       dataID,
 		UserID:   userID,
 		Name:     "Test Data",
 		Login:    "testlogin",
-		Password: "encrypted",
+		Password: encryptedPassword,
 	}
 	dataRepo.Create(testData)
 	
@@ -143,6 +169,15 @@ func TestDataHandler_GetDataByID_Success(t *testing.T) {
 	if response.Name != "Test Data" {
 		t.Errorf("Ожидалось название %s, получено %s", "Test Data", response.Name)
 	}
+
+	var decrypted DataResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &decrypted); err != nil {
+		t.Fatalf("Ошибка парсинга ответа: %v", err)
+	}
+
+	if decrypted.Password != "secret" {
+		t.Errorf("Ожидался пароль %s, получен %s", "secret", decrypted.Password)
+	}
 }
 
 func TestDataHandler_GetDataByID_NotFound(t *testing.T) {
@@ -265,6 +300,7 @@ func TestDataHandler_UpdateData_Success(t *testing.T) {
 	dataRepo.Create(testData)
 	
 	req := UpdateDataRequest{
+		Version:  &testData.Version,
 		Name:     "Updated Name",
 		Login:    "updatedlogin",
 		Password: "newpassword",
@@ -385,3 +421,490 @@ func TestDataHandler_DeleteData_InvalidID(t *testing.T) {
 		t.Errorf("Ожидался статус %d, получен %d", http.StatusBadRequest, w.Code)
 	}
 }
+
+func TestDataHandler_CreateData_TypedPayloads(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+
+	tests := []struct {
+		name       string
+		req        CreateDataRequest
+		wantStatus int
+	}{
+		{
+			name:       "текстовая заметка",
+			req:        CreateDataRequest{Type: models.DataTypeText, Name: "Note", Text: "secret note"},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "пустая заметка",
+			req:        CreateDataRequest{Type: models.DataTypeText, Name: "Note"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "банковская карта",
+			req: CreateDataRequest{Type: models.DataTypeBankCard, Name: "Card", Card: &models.BankCard{
+				Number: "4111 1111 1111 1111", Expiry: "12/30", CVV: "123", Holder: "IVAN IVANOV",
+			}},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "неверный номер карты",
+			req: CreateDataRequest{Type: models.DataTypeBankCard, Name: "Card", Card: &models.BankCard{
+				Number: "4111111111111112", Expiry: "12/30", CVV: "123",
+			}},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "бинарные данные",
+			req:        CreateDataRequest{Type: models.DataTypeBinary, Name: "Key", Binary: []byte{0x00, 0x01, 0x02}},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "неизвестный тип",
+			req:        CreateDataRequest{Type: "unknown", Name: "Unknown"},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonData, _ := json.Marshal(tt.req)
+			c, w := createAuthenticatedContext(userID)
+			c.Request = httptest.NewRequest("POST", "/api/v1/data", bytes.NewBuffer(jsonData))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			handler.CreateData(c)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Ожидался статус %d, получен %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestDataHandler_CreateAndGetBankCard(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+
+	card := &models.BankCard{Number: "5555555555554444", Expiry: "01/29", CVV: "321", Holder: "TEST"}
+	jsonData, _ := json.Marshal(CreateDataRequest{Type: models.DataTypeBankCard, Name: "Card", Card: card})
+	c, w := createAuthenticatedContext(userID)
+	c.Request = httptest.NewRequest("POST", "/api/v1/data", bytes.NewBuffer(jsonData))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.CreateData(c)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusCreated, w.Code)
+	}
+
+	var created models.Data
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Ошибка парсинга ответа: %v", err)
+	}
+
+	stored, _ := handler.dataRepo.GetByID(created.ID)
+	if stored.Payload == "" || bytes.Contains([]byte(stored.Payload), []byte(card.Number)) {
+		t.Error("Данные карты должны храниться в зашифрованном виде")
+	}
+
+	c, w = createAuthenticatedContext(userID)
+	c.Request = httptest.NewRequest("GET", "/api/v1/data/"+created.ID.String(), nil)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: created.ID.String()}}
+
+	handler.GetDataByID(c)
+
+	var response DataResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Ошибка парсинга ответа: %v", err)
+	}
+
+	if response.Type != models.DataTypeBankCard {
+		t.Errorf("Ожидался тип %s, получен %s", models.DataTypeBankCard, response.Type)
+	}
+
+	if response.Card == nil || response.Card.Number != card.Number || response.Card.CVV != card.CVV {
+		t.Errorf("Ожидалась карта %+v, получена %+v", card, response.Card)
+	}
+}
+
+func TestDataHandler_GetData_FilterByType(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+
+	dataRepo := handler.dataRepo.(*repository.MemoryDataRepository)
+	dataRepo.Create(&models.Data{UserID: userID, Type: models.DataTypeLoginPassword, Name: "Login"})
+	dataRepo.Create(&models.Data{UserID: userID, Type: models.DataTypeText, Name: "Note"})
+
+	c, w := createAuthenticatedContext(userID)
+	c.Request = httptest.NewRequest("GET", "/api/v1/data?type=text", nil)
+
+	handler.GetData(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	}
+
+	var response []models.Data
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Ошибка парсинга ответа: %v", err)
+	}
+
+	if len(response) != 1 || response[0].Name != "Note" {
+		t.Errorf("Ожидалась одна текстовая запись, получено %+v", response)
+	}
+}
+
+func TestDataHandler_UpdateData_TextNote(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+
+	dataRepo := handler.dataRepo.(*repository.MemoryDataRepository)
+	testData := &models.Data{UserID: userID, Type: models.DataTypeText, Name: "Note"}
+	dataRepo.Create(testData)
+
+	jsonData, _ := json.Marshal(UpdateDataRequest{Text: "updated note"})
+	c, w := createAuthenticatedContext(userID)
+	c.Request = httptest.NewRequest("PUT", "/api/v1/data/"+testData.ID.String(), bytes.NewBuffer(jsonData))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("If-Match", `"1"`)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: testData.ID.String()}}
+
+	handler.UpdateData(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	}
+
+	stored, _ := dataRepo.GetByID(testData.ID)
+	text, err := handler.dataKeys.ForUser(stored.UserID).Decrypt(stored.ID, stored.Payload)
+	if err != nil {
+		t.Fatalf("Ошибка расшифровки заметки: %v", err)
+	}
+
+	if text != "updated note" {
+		t.Errorf("Ожидался текст %s, получен %s", "updated note", text)
+	}
+}
+
+func TestDataHandler_ClientEncryptedPayload(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+
+	blob := base64.StdEncoding.EncodeToString([]byte("opaque-ciphertext"))
+	jsonData, _ := json.Marshal(CreateDataRequest{Type: models.DataTypeText, Name: "Secret", EncryptedPayload: blob})
+	c, w := createAuthenticatedContext(userID)
+	c.Request = httptest.NewRequest("POST", "/api/v1/data", bytes.NewBuffer(jsonData))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.CreateData(c)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var created models.Data
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Ошибка парсинга ответа: %v", err)
+	}
+
+	if !created.ClientEncrypted {
+		t.Error("Запись должна быть помечена как зашифрованная на клиенте")
+	}
+
+	c, w = createAuthenticatedContext(userID)
+	c.Request = httptest.NewRequest("GET", "/api/v1/data/"+created.ID.String(), nil)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: created.ID.String()}}
+
+	handler.GetDataByID(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	}
+
+	var response DataResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Ошибка парсинга ответа: %v", err)
+	}
+
+	if response.EncryptedPayload != blob {
+		t.Errorf("Ожидался неизмененный blob %s, получен %s", blob, response.EncryptedPayload)
+	}
+
+	// Открытые секретные поля для такой записи не принимаются
+	jsonData, _ = json.Marshal(UpdateDataRequest{Version: &created.Version, Text: "plaintext"})
+	c, w = createAuthenticatedContext(userID)
+	c.Request = httptest.NewRequest("PUT", "/api/v1/data/"+created.ID.String(), bytes.NewBuffer(jsonData))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{gin.Param{Key: "id", Value: created.ID.String()}}
+
+	handler.UpdateData(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestDataHandler_CreateData_MixedPayload(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+
+	jsonData, _ := json.Marshal(CreateDataRequest{
+		Name:             "Mixed",
+		Password:         "plaintext",
+		EncryptedPayload: base64.StdEncoding.EncodeToString([]byte("blob")),
+	})
+	c, w := createAuthenticatedContext(userID)
+	c.Request = httptest.NewRequest("POST", "/api/v1/data", bytes.NewBuffer(jsonData))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	handler.CreateData(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestDataHandler_Create_ClientID(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+
+	id := uuid.New()
+	data, err := handler.Create(userID, "laptop", &CreateDataRequest{ID: &id, Type: models.DataTypeText, Name: "Note", EncryptedPayload: "c2VhbGVk"})
+	if err != nil {
+		t.Fatalf("Ошибка создания записи: %v", err)
+	}
+	if data.ID != id {
+		t.Errorf("Ожидался ID %s, получен %s", id, data.ID)
+	}
+
+	// ID, занятый записью в корзине, повторно не выдается
+	handler.dataRepo.Delete(id)
+	var reqErr *RequestError
+	_, err = handler.Create(userID, "laptop", &CreateDataRequest{ID: &id, Type: models.DataTypeText, Name: "Copy", EncryptedPayload: "c2VhbGVk"})
+	if !errors.As(err, &reqErr) || reqErr.Status != http.StatusConflict {
+		t.Errorf("Ожидался конфликт для занятого ID, получено %v", err)
+	}
+}
+
+func TestDataHandler_GetChanges(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+
+	dataRepo := handler.dataRepo.(*repository.MemoryDataRepository)
+	kept := &models.Data{UserID: userID, Type: models.DataTypeLoginPassword, Name: "Kept"}
+	removed := &models.Data{UserID: userID, Type: models.DataTypeLoginPassword, Name: "Removed"}
+	dataRepo.Create(kept)
+	dataRepo.Create(removed)
+	dataRepo.Delete(removed.ID)
+
+	c, w := createAuthenticatedContext(userID)
+	c.Request = httptest.NewRequest("GET", "/api/v1/data/changes", nil)
+
+	handler.GetChanges(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	}
+
+	var response ChangesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Ошибка парсинга ответа: %v", err)
+	}
+
+	if len(response.Changes) != 2 {
+		t.Fatalf("Ожидалось 2 изменения, получено %d", len(response.Changes))
+	}
+
+	for _, change := range response.Changes {
+		if change.ID == removed.ID && !change.Deleted {
+			t.Error("Удаленная запись должна быть помечена как удаленная")
+		}
+		if change.ID == kept.ID && change.Deleted {
+			t.Error("Существующая запись не должна быть помечена как удаленная")
+		}
+	}
+
+	// Повторный запрос с полученным временем не возвращает старые изменения
+	c, w = createAuthenticatedContext(userID)
+	c.Request = httptest.NewRequest("GET", "/api/v1/data/changes?since="+response.ServerTime.Format(time.RFC3339Nano), nil)
+
+	handler.GetChanges(c)
+
+	var next ChangesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &next); err != nil {
+		t.Fatalf("Ошибка парсинга ответа: %v", err)
+	}
+
+	if len(next.Changes) != 0 {
+		t.Errorf("Ожидалось 0 изменений, получено %d", len(next.Changes))
+	}
+}
+
+func TestDataHandler_GetChanges_InvalidSince(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+
+	c, w := createAuthenticatedContext(userID)
+	c.Request = httptest.NewRequest("GET", "/api/v1/data/changes?since=yesterday", nil)
+
+	handler.GetChanges(c)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestDataHandler_GetChanges_VaultAndPurged(t *testing.T) {
+	handler, memRepo, ownerID := setupTestDataHandler(t)
+	dataRepo := handler.dataRepo.(*repository.MemoryDataRepository)
+	vaultRepo := memRepo.NewVaultRepository()
+	memberID := uuid.New()
+
+	vault := &models.Vault{Name: "Team"}
+	vaultRepo.Create(vault, &models.VaultMember{UserID: ownerID, Role: models.VaultRoleOwner})
+	vaultRepo.SaveMember(&models.VaultMember{VaultID: vault.ID, UserID: memberID, Role: models.VaultRoleViewer})
+
+	shared := &models.Data{ID: uuid.New(), UserID: ownerID, VaultID: &vault.ID, Type: models.DataTypeText, Name: "Team note"}
+	if err := handler.sealPayload(shared, &models.TextNote{Text: "note"}); err != nil {
+		t.Fatalf("Ошибка шифрования данных: %v", err)
+	}
+	dataRepo.Create(shared)
+	purged := &models.Data{UserID: ownerID, VaultID: &vault.ID, Type: models.DataTypeLoginPassword, Name: "Old"}
+	dataRepo.Create(purged)
+
+	changes := func(since time.Time) ChangesResponse {
+		c, w := createAuthenticatedContext(memberID)
+		c.Request = httptest.NewRequest("GET", "/api/v1/data/changes?since="+since.Format(time.RFC3339Nano), nil)
+		handler.GetChanges(c)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var response ChangesResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response
+	}
+
+	// Запись хранилища, созданная другим участником, расшифровывается его ключом
+	first := changes(time.Time{})
+	if len(first.Changes) != 2 {
+		t.Fatalf("Ожидалось 2 изменения, получено %d", len(first.Changes))
+	}
+	for _, change := range first.Changes {
+		if change.ID == shared.ID && change.Text != "note" {
+			t.Error("Запись хранилища должна возвращаться с содержимым")
+		}
+	}
+
+	dataRepo.Delete(purged.ID)
+	dataRepo.Purge(purged.ID)
+
+	next := changes(first.ServerTime)
+	if len(next.Changes) != 1 || next.Changes[0].ID != purged.ID || !next.Changes[0].Deleted {
+		t.Fatalf("Окончательно удаленная запись должна вернуться как удаленная, получено %+v", next.Changes)
+	}
+}
+
+func TestDataHandler_UpdateData_VersionConflict(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+
+	dataRepo := handler.dataRepo.(*repository.MemoryDataRepository)
+	testData := &models.Data{ID: uuid.New(), UserID: userID, Type: models.DataTypeText, Name: "Note"}
+	if err := handler.sealPayload(testData, &models.TextNote{Text: "note"}); err != nil {
+		t.Fatalf("Ошибка шифрования данных: %v", err)
+	}
+	dataRepo.Create(testData)
+
+	update := func(req UpdateDataRequest, ifMatch string) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(req)
+		c, w := createAuthenticatedContext(userID)
+		c.Request = httptest.NewRequest("PUT", "/api/v1/data/"+testData.ID.String(), bytes.NewBuffer(jsonData))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			c.Request.Header.Set("If-Match", ifMatch)
+		}
+		c.Params = gin.Params{gin.Param{Key: "id", Value: testData.ID.String()}}
+		handler.UpdateData(c)
+		return w
+	}
+
+	// Без версии обновление отклоняется
+	if w := update(UpdateDataRequest{Name: "No version"}, ""); w.Code != http.StatusPreconditionRequired {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusPreconditionRequired, w.Code)
+	}
+
+	// Первое устройство обновляет запись версии 1
+	w := update(UpdateDataRequest{Name: "First"}, `"1"`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	}
+	if etag := w.Header().Get("ETag"); etag != `"2"` {
+		t.Errorf("Ожидался ETag %s, получен %s", `"2"`, etag)
+	}
+
+	// Второе устройство пытается обновить ту же запись с устаревшей версией
+	staleVersion := int64(1)
+	w = update(UpdateDataRequest{Version: &staleVersion, Name: "Second"}, "")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusConflict, w.Code)
+	}
+
+	var conflict ConflictResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &conflict); err != nil {
+		t.Fatalf("Ошибка парсинга ответа: %v", err)
+	}
+
+	if conflict.Current == nil || conflict.Current.Name != "First" || conflict.Current.Version != 2 {
+		t.Errorf("Ожидалась текущая копия версии 2, получено %+v", conflict.Current)
+	}
+
+	stored, _ := dataRepo.GetByID(testData.ID)
+	if stored.Name != "First" {
+		t.Errorf("Запись не должна быть перезаписана, получено название %s", stored.Name)
+	}
+}
+
+// failingRevisionRepo отклоняет сохранение ревизий внутри транзакций.
+type failingRevisionRepo struct {
+	repository.DataRepositoryInterface
+}
+
+func (r failingRevisionRepo) Transaction(fn func(uow *repository.UnitOfWork) error) error {
+	return r.DataRepositoryInterface.Transaction(func(uow *repository.UnitOfWork) error {
+		uow.Revisions = failingRevisions{uow.Revisions}
+		return fn(uow)
+	})
+}
+
+// failingRevisions отклоняет сохранение ревизий.
+type failingRevisions struct {
+	repository.RevisionRepositoryInterface
+}
+
+func (r failingRevisions) Create(*models.DataRevision) error {
+	return errors.New("сбой базы данных")
+}
+
+func TestDataHandler_Create_Atomic(t *testing.T) {
+	handler, memRepo, userID := setupTestDataHandler(t)
+	router := newShareRouter(handler, userID)
+
+	repo := handler.dataRepo
+	handler.dataRepo = failingRevisionRepo{repo}
+	w := serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Mail", Login: "user", Password: "secret", Tags: []string{"work"}})
+	handler.dataRepo = repo
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusInternalServerError, w.Code, w.Body.String())
+	}
+
+	// Запись без ревизии не должна сохраняться вместе с метками
+	if records, _ := repo.GetByUserID(userID); len(records) != 0 {
+		t.Errorf("Запись не должна сохраняться, получено %d", len(records))
+	}
+	if tags, _ := memRepo.NewTagRepository().GetByUserID(userID); len(tags) != 0 {
+		t.Errorf("Метки не должны сохраняться, получено %+v", tags)
+	}
+}
+
+func TestDataHandler_UpdateData_NotFound(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+	router := newShareRouter(handler, userID)
+
+	version := int64(1)
+	w := serveJSON(router, "PUT", "/data/"+uuid.New().String(), "laptop", UpdateDataRequest{Name: "Mail", Version: &version})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался статус %d, получен %d: %s", http.StatusNotFound, w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,58 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestError описывает ошибку обработки запроса, не зависящую от транспорта.
+// Status содержит HTTP статус ответа; gRPC сервер сопоставляет его с кодом gRPC.
+type RequestError struct {
+	Status  int
+	Message string
+}
+
+// Error возвращает текст ошибки.
+func (e *RequestError) Error() string {
+	return e.Message
+}
+
+// newRequestError создает ошибку обработки запроса.
+func newRequestError(status int, message string) *RequestError {
+	return &RequestError{Status: status, Message: message}
+}
+
+// ConflictError возвращается при несовпадении версии записи
+// и содержит ее текущую копию на сервере.
+type ConflictError struct {
+	Current *DataResponse
+}
+
+// Error возвращает текст ошибки.
+func (e *ConflictError) Error() string {
+	return "Запись была изменена на другом устройстве"
+}
+
+// respondError отправляет ответ с ошибкой, возвращенной бизнес-логикой обработчика.
+func respondError(c *gin.Context, err error) {
+	var conflict *ConflictError
+	if errors.As(err, &conflict) {
+		c.Header("ETag", formatETag(conflict.Current.Version))
+		c.JSON(http.StatusConflict, ConflictResponse{
+			Error:   conflict.Error(),
+			Current: conflict.Current,
+		})
+		return
+	}
+
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		c.JSON(reqErr.Status, gin.H{"error": reqErr.Message})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Внутренняя ошибка сервера"})
+}
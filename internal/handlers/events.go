@@ -0,0 +1,79 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// eventsHeartbeat задает период комментариев, которые не дают прокси закрыть поток без событий.
+// С тем же периодом повторно проверяются токен и сессия подписчика.
+var eventsHeartbeat = 30 * time.Second
+
+// EventResponse представляет событие об изменении записи в потоке.
+type EventResponse struct {
+	Action   models.RevisionAction `json:"action"`
+	Data     models.Data           `json:"data"`
+	ClientID string                `json:"client_id"`
+}
+
+// StreamEvents отправляет изменения доступных пользователю записей, в том числе записей
+// его хранилищ и открытых ему записей, в формате Server-Sent Events,
+// пока клиент не закроет соединение. Тип события совпадает с действием: create, update
+// или delete, а данные содержат запись без секретного содержимого. Если клиент не успевает
+// читать события, сервер отправляет событие reset и закрывает поток: клиент должен
+// заново загрузить записи и подписаться снова. Когда токен доступа истекает или сессия
+// отзывается, сервер отправляет событие unauthorized и закрывает поток.
+func (dh *DataHandler) StreamEvents(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+	if dh.events == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Рассылка изменений недоступна"})
+		return
+	}
+
+	changes, unsubscribe := dh.events.Subscribe(userUUID)
+	defer unsubscribe()
+
+	// Поток не ограничен по времени, в отличие от остальных ответов сервера
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	// Заголовки отправляются сразу, чтобы клиент знал, что подписка оформлена
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if err := middleware.CheckStreamSession(c); err != nil {
+				c.SSEvent("unauthorized", gin.H{"error": err.Error()})
+				c.Writer.Flush()
+				return
+			}
+			io.WriteString(c.Writer, ": ping\n\n")
+		case event, ok := <-changes:
+			if !ok {
+				c.SSEvent("reset", gin.H{"error": "Клиент не успевает получать изменения, подпишитесь заново"})
+				c.Writer.Flush()
+				return
+			}
+			c.SSEvent(string(event.Action), EventResponse{Action: event.Action, Data: event.Data, ClientID: event.ClientID})
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/auth"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/events"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// readEvent читает из потока следующее событие, пропуская комментарии.
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var name, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Ошибка чтения потока событий: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && name != "":
+			return name, data
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+}
+
+func TestDataHandler_StreamEvents(t *testing.T) {
+	handler, memRepo, userID := setupTestDataHandler(t)
+	handler.events = events.NewHub()
+	router := newOrganizeRouter(handler, userID)
+	router.GET("/events", handler.StreamEvents)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Ошибка подписки на изменения: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Ожидался поток событий, получен статус %d и тип %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// Изменения записей другого пользователя в поток не попадают
+	otherID := uuid.New()
+	memRepo.NewUserRepository().Create(&models.User{ID: otherID, Username: "other", Email: "other@example.com"})
+	if _, err := handler.Create(otherID, "laptop", &CreateDataRequest{Name: "Other", Login: "other", Password: "other"}); err != nil {
+		t.Fatalf("Ошибка создания записи: %v", err)
+	}
+
+	mail, err := handler.Create(userID, "laptop", &CreateDataRequest{Name: "Mail", Login: "user", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Ошибка создания записи: %v", err)
+	}
+	if err := handler.Delete(userID, mail.ID, "phone"); err != nil {
+		t.Fatalf("Ошибка удаления записи: %v", err)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	for _, want := range []EventResponse{
+		{Action: models.RevisionCreate, ClientID: "laptop"},
+		{Action: models.RevisionDelete, ClientID: "phone"},
+	} {
+		name, data := readEvent(t, reader)
+		var event EventResponse
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("Неверный формат события %q: %v", data, err)
+		}
+		if name != string(want.Action) || event.Action != want.Action || event.ClientID != want.ClientID || event.Data.ID != mail.ID {
+			t.Errorf("Ожидалось событие %s записи %s с устройства %s, получено %s: %+v", want.Action, mail.ID, want.ClientID, name, event)
+		}
+		if strings.Contains(data, "secret") {
+			t.Errorf("Событие не должно содержать секреты: %s", data)
+		}
+	}
+}
+
+// revocableSessions считает сессию активной, пока она не отозвана.
+type revocableSessions struct {
+	revoked atomic.Bool
+}
+
+func (s *revocableSessions) CheckSession(claims *auth.Claims, ip string) error {
+	if s.revoked.Load() {
+		return newRequestError(http.StatusUnauthorized, "Сессия отозвана или истекла")
+	}
+	return nil
+}
+
+func TestDataHandler_StreamEvents_RevokedSession(t *testing.T) {
+	heartbeat := eventsHeartbeat
+	eventsHeartbeat = 10 * time.Millisecond
+	defer func() { eventsHeartbeat = heartbeat }()
+
+	handler, _, userID := setupTestDataHandler(t)
+	handler.events = events.NewHub()
+	sessions := &revocableSessions{}
+	router := gin.New()
+	router.GET("/events", middleware.AuthMiddleware("test-secret", sessions), handler.StreamEvents)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	token, _ := auth.NewJWTManager("test-secret").GenerateToken(userID.String(), "user", uuid.NewString())
+	req, _ := http.NewRequest("GET", server.URL+"/events", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Ошибка подписки на изменения: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, resp.StatusCode)
+	}
+
+	// Отзыв сессии закрывает уже открытый поток
+	sessions.revoked.Store(true)
+	reader := bufio.NewReader(resp.Body)
+	if name, _ := readEvent(t, reader); name != "unauthorized" {
+		t.Errorf("Ожидалось событие unauthorized, получено %s", name)
+	}
+	if _, err := reader.ReadString('\n'); !errors.Is(err, io.EOF) {
+		t.Errorf("Поток должен быть закрыт, получено %v", err)
+	}
+}
+
+func TestDataHandler_VaultEvents(t *testing.T) {
+	vh, dh, ownerID, member := setupTestVaultHandler(t)
+	hub := events.NewHub()
+	hub.SetAudience(events.NewRecordAudience(dh.shareRepo, vh.vaultRepo))
+	dh.events = hub
+	owner := newVaultRouter(vh, dh, ownerID)
+
+	w := serveJSON(owner, "POST", "/vaults", "laptop", CreateVaultRequest{Name: "Team"})
+	var vault VaultResponse
+	json.Unmarshal(w.Body.Bytes(), &vault)
+	w = serveJSON(owner, "PUT", "/vaults/"+vault.ID.String()+"/members", "laptop", VaultMemberRequest{Username: "member", Role: models.VaultRoleViewer})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	changes, unsubscribe := hub.Subscribe(member.ID)
+	defer unsubscribe()
+	outsider, unsubscribeOutsider := hub.Subscribe(uuid.New())
+	defer unsubscribeOutsider()
+
+	// Изменение записи хранилища получает второй участник, а не только создатель записи
+	created, err := dh.Create(ownerID, "laptop", &CreateDataRequest{VaultID: &vault.ID, Name: "DB", Login: "root", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Ошибка создания записи: %v", err)
+	}
+
+	select {
+	case event := <-changes:
+		if event.Action != models.RevisionCreate || event.Data.ID != created.ID {
+			t.Errorf("Ожидалось событие создания записи %s, получено %+v", created.ID, event)
+		}
+	default:
+		t.Fatal("Участник хранилища должен получить событие")
+	}
+	if len(outsider) != 0 {
+		t.Error("Событие не должно отправляться пользователю без доступа к хранилищу")
+	}
+}
@@ -0,0 +1,405 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/audit"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/blobstore"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/datakeys"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/events"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Ограничения размера фрагмента файла.
+const (
+	DefaultChunkSize int64 = 1 << 20
+	MinChunkSize     int64 = 1 << 10
+	MaxChunkSize     int64 = 8 << 20
+)
+
+// DefaultMaxFileSize задает наибольший размер файла, если он не указан в конфигурации.
+const DefaultMaxFileSize int64 = 1 << 30
+
+// FileHandler обрабатывает загрузку и скачивание файлов фрагментами.
+type FileHandler struct {
+	dataRepo       repository.DataRepositoryInterface
+	attachmentRepo repository.AttachmentRepositoryInterface
+	blobs          blobstore.BlobStore
+	events         *events.Hub
+	dataKeys       *datakeys.Service
+	audit          *audit.Recorder
+	maxFileSize    int64
+}
+
+// NewFileHandler создает новый обработчик файлов. maxFileSize ограничивает размер
+// загружаемого файла; при нулевом значении используется DefaultMaxFileSize.
+func NewFileHandler(repo *repository.Repository, blobs blobstore.BlobStore, keys crypto.KeyProvider, hub *events.Hub, recorder *audit.Recorder, maxFileSize int64) *FileHandler {
+	if maxFileSize <= 0 {
+		maxFileSize = DefaultMaxFileSize
+	}
+	return &FileHandler{
+		dataRepo:       repo.NewDataRepository(),
+		attachmentRepo: repo.NewAttachmentRepository(),
+		blobs:          blobs,
+		events:         hub,
+		dataKeys:       datakeys.NewService(repo.NewUserRepository(), repo.NewVaultRepository(), keys),
+		audit:          recorder,
+		maxFileSize:    maxFileSize,
+	}
+}
+
+// CreateFileRequest представляет запрос начала загрузки файла.
+type CreateFileRequest struct {
+	Name      string      `json:"name"`
+	Size      int64       `json:"size"`
+	ChunkSize int64       `json:"chunk_size"`
+	Metadata  interface{} `json:"metadata"`
+	// ClientEncrypted сообщает, что клиент шифрует каждый фрагмент сам.
+	// Size и ChunkSize в этом случае учитывают накладные расходы шифрования.
+	ClientEncrypted bool `json:"client_encrypted"`
+}
+
+// FileStatus описывает состояние загрузки файла.
+type FileStatus struct {
+	models.Attachment
+	Name       string `json:"name"`
+	ChunkCount int    `json:"chunk_count"`
+	// Uploaded содержит номера уже загруженных фрагментов для возобновления загрузки.
+	Uploaded []int `json:"uploaded"`
+}
+
+// CreateFile создает запись типа file и начинает загрузку ее содержимого.
+func (fh *FileHandler) CreateFile(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+		return
+	}
+
+	var req CreateFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Название обязательно"})
+		return
+	}
+	if req.Size < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Размер файла не может быть отрицательным"})
+		return
+	}
+	if req.Size > fh.maxFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Размер файла превышает допустимый: " + strconv.FormatInt(fh.maxFileSize, 10) + " байт"})
+		return
+	}
+	if req.ChunkSize == 0 {
+		req.ChunkSize = DefaultChunkSize
+	}
+	if req.ChunkSize < MinChunkSize || req.ChunkSize > MaxChunkSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Размер фрагмента должен быть от 1 КиБ до 8 МиБ"})
+		return
+	}
+
+	data := &models.Data{
+		UserID: userUUID,
+		Type:   models.DataTypeFile,
+		Name:   req.Name,
+	}
+	if req.Metadata != nil {
+		if err := data.SetMetadata(req.Metadata); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка обработки метаданных"})
+			return
+		}
+	}
+
+	attachment := &models.Attachment{
+		UserID:          userUUID,
+		Size:            req.Size,
+		ChunkSize:       req.ChunkSize,
+		ClientEncrypted: req.ClientEncrypted,
+		UserKey:         true,
+	}
+	clientID := requestClientID(c)
+
+	// Запись, описание файла и ревизия сохраняются вместе, чтобы не оставить запись без файла
+	err = fh.dataRepo.Transaction(func(uow *repository.UnitOfWork) error {
+		if err := uow.Data.Create(data); err != nil {
+			return newRequestError(http.StatusInternalServerError, "Ошибка создания данных")
+		}
+
+		attachment.DataID = data.ID
+		if err := uow.Attachments.Create(attachment); err != nil {
+			return newRequestError(http.StatusInternalServerError, "Ошибка создания файла")
+		}
+
+		if err := uow.Revisions.Create(models.NewRevision(data, models.RevisionCreate, clientID)); err != nil {
+			return newRequestError(http.StatusInternalServerError, "Ошибка сохранения истории изменений")
+		}
+		return nil
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	fh.events.Publish(events.Event{Action: models.RevisionCreate, Data: *data, ClientID: clientID})
+
+	c.JSON(http.StatusCreated, fileStatus(data, attachment, nil))
+}
+
+// GetFile возвращает состояние загрузки файла.
+func (fh *FileHandler) GetFile(c *gin.Context) {
+	data, attachment, ok := fh.findFile(c)
+	if !ok {
+		return
+	}
+
+	chunks, err := fh.attachmentRepo.GetChunks(data.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения файла"})
+		return
+	}
+
+	c.JSON(http.StatusOK, fileStatus(data, attachment, chunks))
+}
+
+// UploadChunk сохраняет фрагмент файла. Тело запроса содержит байты фрагмента.
+// Повторная загрузка фрагмента заменяет его, что позволяет возобновить прерванную загрузку.
+func (fh *FileHandler) UploadChunk(c *gin.Context) {
+	data, attachment, ok := fh.findFile(c)
+	if !ok {
+		return
+	}
+
+	if attachment.Complete {
+		c.JSON(http.StatusConflict, gin.H{"error": "Загрузка файла уже завершена"})
+		return
+	}
+
+	index, ok := chunkIndex(c, attachment)
+	if !ok {
+		return
+	}
+
+	expected := attachment.ChunkLength(index)
+	chunk, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, expected))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Фрагмент больше ожидаемого размера"})
+		return
+	}
+	if int64(len(chunk)) != expected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Размер фрагмента не совпадает с ожидаемым: " + strconv.FormatInt(expected, 10)})
+		return
+	}
+
+	key, err := fh.attachmentKey(attachment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка шифрования данных"})
+		return
+	}
+
+	sealed, err := crypto.SealChunk(chunk, key, crypto.ChunkAAD(data.ID.String(), index))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка шифрования данных"})
+		return
+	}
+
+	if err := fh.blobs.Put(c.Request.Context(), chunkKey(data.ID, index), bytes.NewReader(sealed)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения фрагмента"})
+		return
+	}
+
+	if err := fh.attachmentRepo.SaveChunk(&models.AttachmentChunk{DataID: data.ID, Index: index, Size: expected}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения фрагмента"})
+		return
+	}
+
+	c.Data(http.StatusNoContent, "application/json", nil)
+}
+
+// CompleteFile завершает загрузку файла после проверки, что все фрагменты получены.
+func (fh *FileHandler) CompleteFile(c *gin.Context) {
+	data, attachment, ok := fh.findFile(c)
+	if !ok {
+		return
+	}
+
+	chunks, err := fh.attachmentRepo.GetChunks(data.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения файла"})
+		return
+	}
+
+	if len(chunks) != attachment.ChunkCount() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "Загружены не все фрагменты файла",
+			"status": fileStatus(data, attachment, chunks),
+		})
+		return
+	}
+
+	if !attachment.Complete {
+		if err := fh.attachmentRepo.MarkComplete(data.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка завершения загрузки"})
+			return
+		}
+		attachment.Complete = true
+	}
+
+	c.JSON(http.StatusOK, fileStatus(data, attachment, chunks))
+}
+
+// DownloadChunk возвращает расшифрованный фрагмент файла.
+// Фрагменты, зашифрованные на клиенте, возвращаются в том виде, в котором были загружены.
+func (fh *FileHandler) DownloadChunk(c *gin.Context) {
+	data, attachment, ok := fh.findFile(c)
+	if !ok {
+		return
+	}
+
+	if !attachment.Complete {
+		c.JSON(http.StatusConflict, gin.H{"error": "Загрузка файла не завершена"})
+		return
+	}
+
+	index, ok := chunkIndex(c, attachment)
+	if !ok {
+		return
+	}
+
+	r, err := fh.blobs.Get(c.Request.Context(), chunkKey(data.ID, index))
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Фрагмент не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка чтения фрагмента"})
+		return
+	}
+	defer r.Close()
+
+	sealed, err := io.ReadAll(r)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка чтения фрагмента"})
+		return
+	}
+
+	key, err := fh.attachmentKey(attachment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка расшифровки данных"})
+		return
+	}
+
+	chunk, err := crypto.OpenChunk(sealed, key, crypto.ChunkAAD(data.ID.String(), index))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка расшифровки данных"})
+		return
+	}
+
+	// Скачивание файла начинается с первого фрагмента, поэтому в журнал попадает
+	// одно событие на скачивание, а не по событию на каждый фрагмент
+	if index == 0 {
+		event := auditEvent(models.AuditRead, data.UserID, requestSessionInfo(c, ""))
+		event.DataID = &data.ID
+		fh.audit.Record(event)
+	}
+
+	c.Data(http.StatusOK, "application/octet-stream", chunk)
+}
+
+// attachmentKey возвращает ключ, которым зашифрованы фрагменты файла.
+func (fh *FileHandler) attachmentKey(attachment *models.Attachment) ([]byte, error) {
+	if attachment.UserKey {
+		return fh.dataKeys.Key(attachment.UserID)
+	}
+	// Файлы, загруженные до появления ключей данных, зашифрованы ключом сервера напрямую
+	chunkKeys, ok := fh.dataKeys.KeyProvider().(crypto.ChunkKeyProvider)
+	if !ok {
+		return nil, errors.New("источник ключей не выдает ключи фрагментов файлов")
+	}
+	return chunkKeys.ChunkKey(attachment.KeyID)
+}
+
+// findFile находит запись типа file пользователя и описание ее содержимого.
+// При ошибке отправляет ответ и возвращает false.
+func (fh *FileHandler) findFile(c *gin.Context) (*models.Data, *models.Attachment, bool) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+		return nil, nil, false
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+		return nil, nil, false
+	}
+
+	dataID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID данных"})
+		return nil, nil, false
+	}
+
+	data, err := fh.dataRepo.GetByID(dataID)
+	if err != nil || data.UserID != userUUID || data.Type != models.DataTypeFile {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Файл не найден"})
+		return nil, nil, false
+	}
+
+	attachment, err := fh.attachmentRepo.GetByDataID(dataID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Файл не найден"})
+		return nil, nil, false
+	}
+
+	return data, attachment, true
+}
+
+// chunkIndex извлекает номер фрагмента из параметров запроса.
+// При ошибке отправляет ответ и возвращает false.
+func chunkIndex(c *gin.Context, attachment *models.Attachment) (int, bool) {
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 || index >= attachment.ChunkCount() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный номер фрагмента"})
+		return 0, false
+	}
+	return index, true
+}
+
+// chunkKey возвращает ключ фрагмента файла в хранилище.
+func chunkKey(dataID uuid.UUID, index int) string {
+	return dataID.String() + "/" + strconv.Itoa(index)
+}
+
+// fileStatus формирует описание состояния загрузки файла.
+func fileStatus(data *models.Data, attachment *models.Attachment, chunks []models.AttachmentChunk) FileStatus {
+	uploaded := make([]int, 0, len(chunks))
+	for _, chunk := range chunks {
+		uploaded = append(uploaded, chunk.Index)
+	}
+
+	return FileStatus{
+		Attachment: *attachment,
+		Name:       data.Name,
+		ChunkCount: attachment.ChunkCount(),
+		Uploaded:   uploaded,
+	}
+}
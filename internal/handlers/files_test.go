@@ -0,0 +1,180 @@
+// Package handlers содержит тесты для загрузки файлов.
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/audit"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/blobstore"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// newFileRouter создает роутер с обработчиками файлов для пользователя userID.
+func newFileRouter(t *testing.T, userID uuid.UUID) (*gin.Engine, *FileHandler) {
+	dataHandler, memRepo, _ := setupTestDataHandler(t)
+	blobs, err := blobstore.NewFilesystem(t.TempDir())
+	if err != nil {
+		t.Fatalf("Ошибка создания хранилища: %v", err)
+	}
+
+	// Ключ данных создается для пользователя при первой загрузке фрагмента
+	memRepo.NewUserRepository().Create(&models.User{ID: userID, Username: userID.String(), Email: userID.String() + "@example.com"})
+
+	handler := &FileHandler{
+		dataRepo:       memRepo.NewDataRepository(),
+		attachmentRepo: memRepo.NewAttachmentRepository(),
+		blobs:          blobs,
+		dataKeys:       dataHandler.dataKeys,
+		maxFileSize:    DefaultMaxFileSize,
+	}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID.String())
+		c.Next()
+	})
+	router.POST("/files", handler.CreateFile)
+	router.GET("/files/:id", handler.GetFile)
+	router.PUT("/files/:id/chunks/:index", handler.UploadChunk)
+	router.GET("/files/:id/chunks/:index", handler.DownloadChunk)
+	router.POST("/files/:id/complete", handler.CompleteFile)
+	return router, handler
+}
+
+// putChunk загружает фрагмент файла.
+func putChunk(router *gin.Engine, id string, index int, chunk []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("PUT", "/files/"+id+"/chunks/"+strconv.Itoa(index), bytes.NewReader(chunk))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestFileHandler_UploadDownload(t *testing.T) {
+	userID := uuid.New()
+	router, handler := newFileRouter(t, userID)
+	auditRepo := repository.NewMemoryRepository().NewAuditRepository()
+	handler.audit = audit.NewRecorder(auditRepo, nil, 0)
+
+	content := bytes.Repeat([]byte("0123456789"), 250)
+	w := serveJSON(router, "POST", "/files", "laptop", CreateFileRequest{
+		Name:      "keystore.jks",
+		Size:      int64(len(content)),
+		ChunkSize: MinChunkSize,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var status FileStatus
+	json.Unmarshal(w.Body.Bytes(), &status)
+	if status.ChunkCount != 3 {
+		t.Fatalf("Ожидалось 3 фрагмента, получено %d", status.ChunkCount)
+	}
+	id := status.DataID.String()
+
+	chunk := func(index int) []byte {
+		end := min(int64(index+1)*MinChunkSize, int64(len(content)))
+		return content[int64(index)*MinChunkSize : end]
+	}
+
+	// Фрагмент неверного размера отклоняется
+	if w := putChunk(router, id, 0, chunk(2)); w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+
+	// Фрагменты загружаются в произвольном порядке
+	for _, index := range []int{2, 0} {
+		if w := putChunk(router, id, index, chunk(index)); w.Code != http.StatusNoContent {
+			t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+		}
+	}
+
+	if w := serveJSON(router, "POST", "/files/"+id+"/complete", "laptop", nil); w.Code != http.StatusConflict {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusConflict, w.Code)
+	}
+
+	w = serveJSON(router, "GET", "/files/"+id, "laptop", nil)
+	json.Unmarshal(w.Body.Bytes(), &status)
+	if len(status.Uploaded) != 2 || status.Uploaded[0] != 0 || status.Uploaded[1] != 2 {
+		t.Errorf("Ожидались загруженные фрагменты [0 2], получено %v", status.Uploaded)
+	}
+
+	// Возобновление загрузки с недостающего фрагмента
+	putChunk(router, id, 1, chunk(1))
+	if w := serveJSON(router, "POST", "/files/"+id+"/complete", "laptop", nil); w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var downloaded []byte
+	for index := 0; index < status.ChunkCount; index++ {
+		w := serveJSON(router, "GET", "/files/"+id+"/chunks/"+strconv.Itoa(index), "laptop", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+		}
+		downloaded = append(downloaded, w.Body.Bytes()...)
+	}
+
+	if !bytes.Equal(downloaded, content) {
+		t.Error("Скачанный файл не совпадает с загруженным")
+	}
+
+	// Скачивание файла отмечается в журнале аудита один раз
+	events, _, _ := auditRepo.GetByActorID(userID, 10, 0)
+	if len(events) != 1 || events[0].Action != models.AuditRead || events[0].DataID == nil || *events[0].DataID != status.DataID {
+		t.Errorf("Ожидалось одно событие read для файла %s, получено %+v", id, events)
+	}
+
+	// Фрагменты хранятся в зашифрованном виде
+	r, err := handler.blobs.Get(t.Context(), id+"/0")
+	if err != nil {
+		t.Fatalf("Ошибка чтения фрагмента: %v", err)
+	}
+	defer r.Close()
+	var stored bytes.Buffer
+	stored.ReadFrom(r)
+	if bytes.Contains(stored.Bytes(), chunk(0)[:32]) {
+		t.Error("Фрагмент не должен храниться в открытом виде")
+	}
+}
+
+func TestFileHandler_OtherUser(t *testing.T) {
+	router, handler := newFileRouter(t, uuid.New())
+
+	w := serveJSON(router, "POST", "/files", "laptop", CreateFileRequest{Name: "key.pem", Size: 10})
+	var status FileStatus
+	json.Unmarshal(w.Body.Bytes(), &status)
+
+	otherRouter := gin.New()
+	otherRouter.Use(func(c *gin.Context) {
+		c.Set("user_id", uuid.New().String())
+		c.Next()
+	})
+	otherRouter.GET("/files/:id", handler.GetFile)
+
+	if w := serveJSON(otherRouter, "GET", "/files/"+status.DataID.String(), "laptop", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestFileHandler_MaxFileSize(t *testing.T) {
+	router, handler := newFileRouter(t, uuid.New())
+	handler.maxFileSize = 4 * MinChunkSize
+
+	w := serveJSON(router, "POST", "/files", "laptop", CreateFileRequest{Name: "backup.tar", Size: handler.maxFileSize + 1})
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+	}
+
+	w = serveJSON(router, "POST", "/files", "laptop", CreateFileRequest{Name: "backup.tar", Size: handler.maxFileSize})
+	if w.Code != http.StatusCreated {
+		t.Errorf("Ожидался статус %d, получен %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+}
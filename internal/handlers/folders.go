@@ -0,0 +1,358 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/events"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FolderRequest представляет запрос создания папки или ее изменения.
+// Папка без ParentID находится в корне.
+type FolderRequest struct {
+	Name     string     `json:"name"`
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+// MoveDataRequest представляет запрос переноса записи в папку.
+// Пустой FolderID переносит запись в корень.
+type MoveDataRequest struct {
+	FolderID *uuid.UUID `json:"folder_id"`
+}
+
+// FavoriteRequest представляет запрос добавления записи в избранное или удаления из него.
+type FavoriteRequest struct {
+	Favorite bool `json:"favorite"`
+}
+
+// GetFolders возвращает все папки пользователя. Дерево строится по полю parent_id.
+func (dh *DataHandler) GetFolders(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+
+	folders, err := dh.folderRepo.GetByUserID(userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения папок"})
+		return
+	}
+	if folders == nil {
+		folders = []models.Folder{}
+	}
+
+	c.JSON(http.StatusOK, folders)
+}
+
+// CreateFolder создает папку пользователя.
+func (dh *DataHandler) CreateFolder(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+
+	var req FolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	folder, err := dh.AddFolder(userUUID, &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, folder)
+}
+
+// AddFolder создает папку пользователя userID в папке req.ParentID или в корне.
+func (dh *DataHandler) AddFolder(userID uuid.UUID, req *FolderRequest) (*models.Folder, error) {
+	folders, err := dh.folderRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения папок")
+	}
+
+	folder := &models.Folder{UserID: userID, Name: strings.TrimSpace(req.Name), ParentID: req.ParentID}
+	if err := checkFolder(folders, folder); err != nil {
+		return nil, err
+	}
+
+	if err := dh.folderRepo.Create(folder); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка создания папки")
+	}
+	return folder, nil
+}
+
+// UpdateFolder переименовывает папку или переносит ее в другую папку вместе с содержимым.
+func (dh *DataHandler) UpdateFolder(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+
+	folderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID папки"})
+		return
+	}
+
+	var req FolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	folder, err := dh.ChangeFolder(userUUID, folderID, &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, folder)
+}
+
+// ChangeFolder задает папке folderID пользователя userID имя и родительскую папку из req.
+// Папку нельзя перенести в нее саму или во вложенную в нее папку.
+func (dh *DataHandler) ChangeFolder(userID, folderID uuid.UUID, req *FolderRequest) (*models.Folder, error) {
+	folders, err := dh.folderRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения папок")
+	}
+
+	var folder *models.Folder
+	for i := range folders {
+		if folders[i].ID == folderID {
+			folder = &folders[i]
+		}
+	}
+	if folder == nil {
+		return nil, newRequestError(http.StatusNotFound, "Папка не найдена")
+	}
+
+	folder.Name = strings.TrimSpace(req.Name)
+	folder.ParentID = req.ParentID
+	if err := checkFolder(folders, folder); err != nil {
+		return nil, err
+	}
+
+	if err := dh.folderRepo.Update(folder); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка изменения папки")
+	}
+	return folder, nil
+}
+
+// DeleteFolder удаляет пустую папку.
+func (dh *DataHandler) DeleteFolder(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+
+	folderID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID папки"})
+		return
+	}
+
+	if err := dh.RemoveFolder(userUUID, folderID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Data(http.StatusNoContent, "application/json", nil)
+}
+
+// RemoveFolder удаляет папку folderID пользователя userID, если в ней нет
+// вложенных папок и записей. Записи в корзине не мешают удалению: восстановленная
+// запись из удаленной папки ссылается на несуществующую папку и показывается клиентом в корне.
+func (dh *DataHandler) RemoveFolder(userID, folderID uuid.UUID) error {
+	folders, err := dh.folderRepo.GetByUserID(userID)
+	if err != nil {
+		return newRequestError(http.StatusInternalServerError, "Ошибка получения папок")
+	}
+
+	found := false
+	for _, folder := range folders {
+		if folder.ID == folderID {
+			found = true
+		}
+		if folder.ParentID != nil && *folder.ParentID == folderID {
+			return newRequestError(http.StatusConflict, "В папке есть вложенные папки")
+		}
+	}
+	if !found {
+		return newRequestError(http.StatusNotFound, "Папка не найдена")
+	}
+
+	data, err := dh.dataRepo.List(userID, repository.DataQuery{FolderID: &folderID, Limit: 1})
+	if err != nil {
+		return newRequestError(http.StatusInternalServerError, "Ошибка получения данных")
+	}
+	if len(data) > 0 {
+		return newRequestError(http.StatusConflict, "В папке есть записи")
+	}
+
+	if err := dh.folderRepo.Delete(folderID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return newRequestError(http.StatusInternalServerError, "Ошибка удаления папки")
+	}
+	return nil
+}
+
+// PutDataFolder переносит запись в папку или в корень.
+func (dh *DataHandler) PutDataFolder(c *gin.Context) {
+	userUUID, dataID, ok := shareParams(c)
+	if !ok {
+		return
+	}
+
+	var req MoveDataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	data, err := dh.MoveData(userUUID, dataID, requestClientID(c), req.FolderID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	dh.RecordAccess(userUUID, models.AuditUpdate, dataID, requestSessionInfo(c, ""))
+
+	c.Header("ETag", formatETag(data.Version))
+	c.JSON(http.StatusOK, data)
+}
+
+// MoveData переносит запись пользователя userID в папку folderID с устройства clientID.
+// Пустой folderID переносит запись в корень.
+func (dh *DataHandler) MoveData(userID, dataID uuid.UUID, clientID string, folderID *uuid.UUID) (*models.Data, error) {
+	if folderID != nil {
+		folder, err := dh.folderRepo.GetByID(*folderID)
+		if err != nil || folder.UserID != userID {
+			return nil, newRequestError(http.StatusNotFound, "Папка не найдена")
+		}
+	}
+
+	return dh.organize(userID, dataID, clientID, func(data *models.Data) error {
+		data.FolderID = folderID
+		return nil
+	})
+}
+
+// PutDataFavorite добавляет запись в избранное или удаляет из него.
+func (dh *DataHandler) PutDataFavorite(c *gin.Context) {
+	userUUID, dataID, ok := shareParams(c)
+	if !ok {
+		return
+	}
+
+	var req FavoriteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	data, err := dh.SetFavorite(userUUID, dataID, requestClientID(c), req.Favorite)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	dh.RecordAccess(userUUID, models.AuditUpdate, dataID, requestSessionInfo(c, ""))
+
+	c.Header("ETag", formatETag(data.Version))
+	c.JSON(http.StatusOK, data)
+}
+
+// SetFavorite отмечает запись пользователя userID как избранную или снимает отметку
+// с устройства clientID.
+func (dh *DataHandler) SetFavorite(userID, dataID uuid.UUID, clientID string, favorite bool) (*models.Data, error) {
+	return dh.organize(userID, dataID, clientID, func(data *models.Data) error {
+		data.Favorite = favorite
+		return nil
+	})
+}
+
+// organize применяет change к личной записи пользователя userID и сохраняет ее с новой
+// версией, чтобы изменение дошло до других устройств. Папки, метки и избранное
+// не меняют содержимое записи, поэтому ревизия в историю не добавляется.
+func (dh *DataHandler) organize(userID, dataID uuid.UUID, clientID string, change func(*models.Data) error) (*models.Data, error) {
+	data, err := dh.ownedData(userID, dataID)
+	if err != nil {
+		return nil, err
+	}
+	if data.VaultID != nil {
+		return nil, newRequestError(http.StatusBadRequest, "Папки, метки и избранное доступны только для личных записей")
+	}
+
+	names, err := dh.tagRepo.GetNamesByDataIDs([]uuid.UUID{data.ID})
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения меток")
+	}
+	data.Tags = names[data.ID]
+
+	expectedVersion := data.Version
+	if err := change(data); err != nil {
+		return nil, err
+	}
+	if err := dh.dataRepo.UpdateWithVersion(data, expectedVersion); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			if current, err := dh.dataRepo.GetByID(dataID); err == nil {
+				return nil, dh.conflictError(current)
+			}
+		}
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка обновления данных")
+	}
+
+	dh.publish(events.Event{Action: models.RevisionUpdate, Data: *data, ClientID: clientID})
+	return data, nil
+}
+
+// checkFolder проверяет имя и родителя папки folder среди папок пользователя folders:
+// родитель должен существовать и не быть самой папкой или вложенной в нее папкой,
+// а имя - не повторяться среди соседних папок.
+func checkFolder(folders []models.Folder, folder *models.Folder) error {
+	if folder.Name == "" {
+		return newRequestError(http.StatusBadRequest, "Название папки обязательно")
+	}
+	if strings.Contains(folder.Name, "/") {
+		return newRequestError(http.StatusBadRequest, "Название папки не может содержать /")
+	}
+
+	parents := make(map[uuid.UUID]*uuid.UUID, len(folders))
+	for _, f := range folders {
+		parents[f.ID] = f.ParentID
+	}
+	if folder.ParentID != nil {
+		if _, ok := parents[*folder.ParentID]; !ok {
+			return newRequestError(http.StatusNotFound, "Родительская папка не найдена")
+		}
+		// Глубина обхода ограничена числом папок на случай поврежденного дерева
+		for id, depth := folder.ParentID, 0; id != nil && depth <= len(folders); id, depth = parents[*id], depth+1 {
+			if *id == folder.ID {
+				return newRequestError(http.StatusBadRequest, "Папку нельзя перенести в нее саму или во вложенную папку")
+			}
+		}
+	}
+
+	for _, f := range folders {
+		if f.ID != folder.ID && f.Name == folder.Name && sameParent(f.ParentID, folder.ParentID) {
+			return newRequestError(http.StatusConflict, "Папка с таким названием уже существует")
+		}
+	}
+	return nil
+}
+
+// sameParent проверяет, что папки лежат в одной родительской папке.
+func sameParent(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+)
+
+func TestDataHandler_Folders(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+	router := newOrganizeRouter(handler, userID)
+
+	var work, servers models.Folder
+	w := serveJSON(router, "POST", "/folders", "laptop", FolderRequest{Name: "Work"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	json.Unmarshal(w.Body.Bytes(), &work)
+	w = serveJSON(router, "POST", "/folders", "laptop", FolderRequest{Name: "Servers", ParentID: &work.ID})
+	json.Unmarshal(w.Body.Bytes(), &servers)
+	if servers.ParentID == nil || *servers.ParentID != work.ID {
+		t.Fatalf("Папка должна быть вложена в Work, получено %+v", servers)
+	}
+
+	if w := serveJSON(router, "POST", "/folders", "laptop", FolderRequest{Name: "Servers", ParentID: &work.ID}); w.Code != http.StatusConflict {
+		t.Errorf("Повторяющееся название: ожидался статус %d, получен %d", http.StatusConflict, w.Code)
+	}
+	if w := serveJSON(router, "POST", "/folders", "laptop", FolderRequest{Name: "Servers"}); w.Code != http.StatusCreated {
+		t.Errorf("Название может повторяться в другой папке: получен статус %d", w.Code)
+	}
+
+	// Папку нельзя перенести во вложенную в нее папку
+	if w := serveJSON(router, "PUT", "/folders/"+work.ID.String(), "laptop", FolderRequest{Name: "Work", ParentID: &servers.ID}); w.Code != http.StatusBadRequest {
+		t.Errorf("Цикл: ожидался статус %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+
+	var mail models.Data
+	w = serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Mail", Login: "user", Password: "secret"})
+	json.Unmarshal(w.Body.Bytes(), &mail)
+	serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Bank", Login: "user", Password: "secret", FolderID: &work.ID})
+
+	w = serveJSON(router, "PUT", "/data/"+mail.ID.String()+"/folder", "laptop", MoveDataRequest{FolderID: &servers.ID})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if names := listNames(t, router, "/data?folder_id="+servers.ID.String()); len(names) != 1 || names[0] != "Mail" {
+		t.Errorf("Ожидалась запись Mail в папке Servers, получено %v", names)
+	}
+
+	// Непустую папку удалить нельзя
+	if w := serveJSON(router, "DELETE", "/folders/"+servers.ID.String(), "laptop", nil); w.Code != http.StatusConflict {
+		t.Errorf("Папка с записью: ожидался статус %d, получен %d", http.StatusConflict, w.Code)
+	}
+	if w := serveJSON(router, "DELETE", "/folders/"+work.ID.String(), "laptop", nil); w.Code != http.StatusConflict {
+		t.Errorf("Папка с вложенной папкой: ожидался статус %d, получен %d", http.StatusConflict, w.Code)
+	}
+
+	var moved models.Data
+	w = serveJSON(router, "PUT", "/data/"+mail.ID.String()+"/folder", "laptop", MoveDataRequest{})
+	json.Unmarshal(w.Body.Bytes(), &moved)
+	if moved.FolderID != nil {
+		t.Errorf("Запись должна быть перенесена в корень, получено %v", moved.FolderID)
+	}
+	if w := serveJSON(router, "DELETE", "/folders/"+servers.ID.String(), "laptop", nil); w.Code != http.StatusNoContent {
+		t.Errorf("Пустая папка: ожидался статус %d, получен %d", http.StatusNoContent, w.Code)
+	}
+
+	var folders []models.Folder
+	w = serveJSON(router, "GET", "/folders", "laptop", nil)
+	json.Unmarshal(w.Body.Bytes(), &folders)
+	if len(folders) != 2 {
+		t.Errorf("Ожидалось 2 папки, получено %+v", folders)
+	}
+}
+
+func TestDataHandler_Favorite(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+	router := newOrganizeRouter(handler, userID)
+
+	var mail models.Data
+	w := serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Mail", Login: "user", Password: "secret"})
+	json.Unmarshal(w.Body.Bytes(), &mail)
+	serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Bank", Login: "user", Password: "secret"})
+
+	w = serveJSON(router, "PUT", "/data/"+mail.ID.String()+"/favorite", "laptop", FavoriteRequest{Favorite: true})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if names := listNames(t, router, "/data?favorite=true"); len(names) != 1 || names[0] != "Mail" {
+		t.Errorf("Ожидалась избранная запись Mail, получено %v", names)
+	}
+
+	serveJSON(router, "PUT", "/data/"+mail.ID.String()+"/favorite", "laptop", FavoriteRequest{Favorite: false})
+	if names := listNames(t, router, "/data?favorite=true"); len(names) != 0 {
+		t.Errorf("Избранных записей не должно остаться, получено %v", names)
+	}
+
+	if w := serveJSON(router, "GET", "/data?favorite=maybe", "laptop", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Неверный фильтр: ожидался статус %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,250 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/events"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ClientIDHeader содержит идентификатор устройства, с которого отправлен запрос.
+const ClientIDHeader = "X-Client-ID"
+
+// RevisionResponse представляет ревизию записи вместе с ее расшифрованным содержимым.
+type RevisionResponse struct {
+	Revision models.DataRevision `json:"revision"`
+	Data     *DataResponse       `json:"data"`
+}
+
+// requestClientID возвращает идентификатор устройства, с которого отправлен запрос.
+// Если клиент не передал заголовок X-Client-ID, используется User-Agent.
+func requestClientID(c *gin.Context) string {
+	if clientID := c.GetHeader(ClientIDHeader); clientID != "" {
+		return clientID
+	}
+	return c.Request.UserAgent()
+}
+
+// recordRevision сохраняет снимок записи после изменения и оповещает подписчиков об изменении.
+func (dh *DataHandler) recordRevision(clientID string, data *models.Data, action models.RevisionAction) error {
+	if err := dh.revisionRepo.Create(models.NewRevision(data, action, clientID)); err != nil {
+		return err
+	}
+
+	dh.publish(events.Event{Action: action, Data: *data, ClientID: clientID})
+	return nil
+}
+
+// publish оповещает подписчиков об изменении записи. Внутри транзакции событие
+// откладывается, чтобы подписчики не узнали об изменениях, которые будут отменены.
+func (dh *DataHandler) publish(event events.Event) {
+	if dh.pending != nil {
+		*dh.pending = append(*dh.pending, event)
+		return
+	}
+	dh.events.Publish(event)
+}
+
+// GetHistory возвращает историю изменений записи, начиная с самой новой ревизии.
+// История доступна и для удаленных записей.
+func (dh *DataHandler) GetHistory(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+		return
+	}
+
+	dataID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID данных"})
+		return
+	}
+
+	if _, err := dh.findRecord(userUUID, dataID, models.VaultRoleViewer, "Данные не найдены"); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	revisions, err := dh.revisionRepo.GetByDataID(dataID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения истории изменений"})
+		return
+	}
+
+	if len(revisions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Данные не найдены"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetRevision возвращает ревизию записи вместе с ее содержимым.
+func (dh *DataHandler) GetRevision(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+		return
+	}
+
+	revision, ok := dh.findRevision(c, userUUID, models.VaultRoleViewer)
+	if !ok {
+		return
+	}
+
+	data := &models.Data{ID: revision.DataID, UserID: revision.UserID, VaultID: revision.VaultID, Version: revision.Version}
+	revision.Apply(data)
+
+	resp, err := dh.openPayload(data, dh.dataKeys.ForRecord(data))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка расшифровки данных"})
+		return
+	}
+
+	c.JSON(http.StatusOK, RevisionResponse{Revision: *revision, Data: resp})
+}
+
+// RestoreRevision восстанавливает содержимое записи из ревизии.
+// Удаленная запись восстанавливается вместе с содержимым. Восстановление
+// сохраняется как новая ревизия, поэтому его тоже можно отменить.
+func (dh *DataHandler) RestoreRevision(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+		return
+	}
+
+	revision, ok := dh.findRevision(c, userUUID, models.VaultRoleEditor)
+	if !ok {
+		return
+	}
+
+	// Запись возвращается из корзины вместе с содержимым ревизии или не изменяется совсем
+	var data *models.Data
+	err = dh.transaction(func(tx *DataHandler) error {
+		data, err = tx.restoreRevision(revision, requestClientID(c))
+		return err
+	})
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header("ETag", formatETag(data.Version))
+	c.JSON(http.StatusOK, data)
+}
+
+// restoreRevision восстанавливает содержимое записи из ревизии с устройства clientID.
+// Удаленная запись возвращается из корзины.
+func (dh *DataHandler) restoreRevision(revision *models.DataRevision, clientID string) (*models.Data, error) {
+	data, err := dh.dataRepo.GetByID(revision.DataID)
+	if err != nil {
+		if err := dh.dataRepo.Restore(revision.DataID); err != nil {
+			return nil, newRequestError(http.StatusNotFound, "Данные не найдены")
+		}
+		if data, err = dh.dataRepo.GetByID(revision.DataID); err != nil {
+			return nil, newRequestError(http.StatusInternalServerError, "Ошибка восстановления данных")
+		}
+	}
+
+	expectedVersion := data.Version
+	revision.Apply(data)
+
+	if err := dh.dataRepo.UpdateWithVersion(data, expectedVersion); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			if current, err := dh.dataRepo.GetByID(revision.DataID); err == nil {
+				return nil, dh.conflictError(current)
+			}
+		}
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка восстановления данных")
+	}
+
+	if err := dh.recordRevision(clientID, data, models.RevisionRestore); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка сохранения истории изменений")
+	}
+	return data, nil
+}
+
+// findRevision находит ревизию из параметров запроса и проверяет, что роль пользователя
+// дает на запись права не ниже required. При ошибке отправляет ответ и возвращает false.
+func (dh *DataHandler) findRevision(c *gin.Context, userID uuid.UUID, required models.VaultRole) (*models.DataRevision, bool) {
+	dataID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID данных"})
+		return nil, false
+	}
+
+	revisionID, err := uuid.Parse(c.Param("revision"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID ревизии"})
+		return nil, false
+	}
+
+	if _, err := dh.findRecord(userID, dataID, required, "Ревизия не найдена"); err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+
+	revision, err := dh.revisionRepo.GetByID(revisionID)
+	if err != nil || revision.DataID != dataID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ревизия не найдена"})
+		return nil, false
+	}
+
+	return revision, true
+}
+
+// findRecord возвращает запись, в том числе удаленную в корзину, если роль пользователя
+// дает на нее права не ниже required. Если запись не найдена, возвращается ошибка 404
+// с сообщением notFound.
+func (dh *DataHandler) findRecord(userID, dataID uuid.UUID, required models.VaultRole, notFound string) (*models.Data, error) {
+	data, err := dh.dataRepo.GetByID(dataID)
+	if err != nil {
+		if data, err = dh.dataRepo.GetDeletedByID(dataID); err != nil {
+			return nil, newRequestError(http.StatusNotFound, notFound)
+		}
+	}
+	if err := dh.authorizeRecord(data, userID, required, notFound); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// authorizeRecord проверяет, что роль пользователя дает на запись права не ниже required.
+// Личная запись доступна только владельцу. Участнику хранилища с недостаточной ролью
+// возвращается ошибка 403, остальным - 404 с сообщением notFound, чтобы не раскрывать запись.
+func (dh *DataHandler) authorizeRecord(data *models.Data, userID uuid.UUID, required models.VaultRole, notFound string) error {
+	if err := dh.authz.AuthorizeData(data, userID, required); err == nil {
+		return nil
+	}
+	if data.VaultID != nil {
+		if _, err := dh.authz.VaultRole(*data.VaultID, userID); err == nil {
+			return newRequestError(http.StatusForbidden, "Недостаточно прав в хранилище")
+		}
+	}
+	return newRequestError(http.StatusNotFound, notFound)
+}
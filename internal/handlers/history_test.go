@@ -0,0 +1,197 @@
+// Package handlers содержит тесты для истории изменений.
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// newHistoryRouter создает роутер с обработчиками данных для пользователя userID.
+func newHistoryRouter(handler *DataHandler, userID uuid.UUID) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", userID.String())
+		c.Next()
+	})
+	router.POST("/data", handler.CreateData)
+	router.PUT("/data/:id", handler.UpdateData)
+	router.DELETE("/data/:id", handler.DeleteData)
+	router.GET("/data/:id/history", handler.GetHistory)
+	router.GET("/data/:id/history/:revision", handler.GetRevision)
+	router.POST("/data/:id/history/:revision/restore", handler.RestoreRevision)
+	return router
+}
+
+// serveJSON выполняет запрос к роутеру от имени устройства clientID.
+func serveJSON(router *gin.Engine, method, path, clientID string, body interface{}) *httptest.ResponseRecorder {
+	var reqBody bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&reqBody).Encode(body)
+	}
+
+	req := httptest.NewRequest(method, path, &reqBody)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(ClientIDHeader, clientID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestDataHandler_HistoryAndRestore(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+	router := newHistoryRouter(handler, userID)
+
+	w := serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Mail", Login: "user", Password: "first"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusCreated, w.Code)
+	}
+	var created models.Data
+	json.Unmarshal(w.Body.Bytes(), &created)
+	path := "/data/" + created.ID.String()
+
+	version := created.Version
+	w = serveJSON(router, "PUT", path, "phone", UpdateDataRequest{Version: &version, Password: "second"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	}
+
+	w = serveJSON(router, "DELETE", path, "phone", nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusNoContent, w.Code)
+	}
+
+	// История удаленной записи остается доступной
+	w = serveJSON(router, "GET", path+"/history", "laptop", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	}
+
+	var history []models.DataRevision
+	json.Unmarshal(w.Body.Bytes(), &history)
+	if len(history) != 3 {
+		t.Fatalf("Ожидалось 3 ревизии, получено %d", len(history))
+	}
+
+	wantActions := []models.RevisionAction{models.RevisionDelete, models.RevisionUpdate, models.RevisionCreate}
+	for i, action := range wantActions {
+		if history[i].Action != action {
+			t.Errorf("Ревизия %d: ожидалось действие %s, получено %s", i, action, history[i].Action)
+		}
+	}
+
+	first := history[2]
+	if first.ClientID != "laptop" || history[1].ClientID != "phone" {
+		t.Errorf("Ожидались устройства laptop и phone, получено %s и %s", first.ClientID, history[1].ClientID)
+	}
+
+	w = serveJSON(router, "GET", path+"/history/"+first.ID.String(), "laptop", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	}
+
+	var revision RevisionResponse
+	json.Unmarshal(w.Body.Bytes(), &revision)
+	if revision.Data == nil || revision.Data.Password != "first" {
+		t.Errorf("Ожидался пароль первой ревизии, получено %+v", revision.Data)
+	}
+
+	// Восстановление удаленной записи из первой ревизии
+	w = serveJSON(router, "POST", path+"/history/"+first.ID.String()+"/restore", "laptop", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	restored, err := handler.dataRepo.GetByID(created.ID)
+	if err != nil {
+		t.Fatalf("Запись должна быть восстановлена: %v", err)
+	}
+
+	resp, _ := handler.openPayload(restored, handler.dataKeys.ForUser(restored.UserID))
+	if resp.Password != "first" || restored.Version != 3 {
+		t.Errorf("Ожидался пароль first и версия 3, получено %s и %d", resp.Password, restored.Version)
+	}
+
+	w = serveJSON(router, "GET", path+"/history", "laptop", nil)
+	json.Unmarshal(w.Body.Bytes(), &history)
+	if len(history) != 4 || history[0].Action != models.RevisionRestore {
+		t.Errorf("Ожидалась ревизия восстановления, получено %+v", history)
+	}
+}
+
+func TestDataHandler_GetHistory_OtherUser(t *testing.T) {
+	handler, memRepo, userID := setupTestDataHandler(t)
+
+	data := &models.Data{UserID: userID, Name: "Mail"}
+	memRepo.NewDataRepository().Create(data)
+	handler.revisionRepo.Create(models.NewRevision(data, models.RevisionCreate, "laptop"))
+
+	router := newHistoryRouter(handler, uuid.New())
+	w := serveJSON(router, "GET", "/data/"+data.ID.String()+"/history", "laptop", nil)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// failingUpdateRepo отклоняет обновления записей, в том числе внутри транзакций.
+type failingUpdateRepo struct {
+	repository.DataRepositoryInterface
+}
+
+func (r failingUpdateRepo) UpdateWithVersion(*models.Data, int64) error {
+	return errors.New("сбой базы данных")
+}
+
+func (r failingUpdateRepo) Transaction(fn func(uow *repository.UnitOfWork) error) error {
+	return r.DataRepositoryInterface.Transaction(func(uow *repository.UnitOfWork) error {
+		uow.Data = failingUpdateRepo{uow.Data}
+		return fn(uow)
+	})
+}
+
+func TestDataHandler_RestoreRevision_Atomic(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+	router := newHistoryRouter(handler, userID)
+
+	w := serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Mail", Login: "user", Password: "first"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created models.Data
+	json.Unmarshal(w.Body.Bytes(), &created)
+	path := "/data/" + created.ID.String()
+	serveJSON(router, "DELETE", path, "laptop", nil)
+
+	var history []models.DataRevision
+	w = serveJSON(router, "GET", path+"/history", "laptop", nil)
+	json.Unmarshal(w.Body.Bytes(), &history)
+	if len(history) != 2 {
+		t.Fatalf("Ожидалось 2 ревизии, получено %d", len(history))
+	}
+
+	repo := handler.dataRepo
+	handler.dataRepo = failingUpdateRepo{repo}
+	w = serveJSON(router, "POST", path+"/history/"+history[1].ID.String()+"/restore", "laptop", nil)
+	handler.dataRepo = repo
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusInternalServerError, w.Code)
+	}
+
+	// Неудачное восстановление не должно возвращать запись из корзины
+	if _, err := repo.GetByID(created.ID); err == nil {
+		t.Error("Запись не должна покидать корзину")
+	}
+	if _, err := repo.GetDeletedByID(created.ID); err != nil {
+		t.Errorf("Запись должна остаться в корзине: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxImportItems ограничивает количество записей в одном запросе импорта.
+const maxImportItems = 5000
+
+// ImportRequest представляет запрос импорта записей.
+type ImportRequest struct {
+	Items []CreateDataRequest `json:"items"`
+}
+
+// ImportResult представляет результат импорта одной записи.
+// Index - номер записи в запросе, ID заполняется для созданной записи, Error - для отклоненной.
+type ImportResult struct {
+	Index int        `json:"index"`
+	ID    *uuid.UUID `json:"id,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
+
+// ImportResponse представляет ответ на запрос импорта.
+type ImportResponse struct {
+	Created int            `json:"created"`
+	Failed  int            `json:"failed"`
+	Results []ImportResult `json:"results"`
+}
+
+// ImportData создает записи пользователя из списка за один запрос.
+// Записи создаются независимо: ошибка в одной из них не отменяет остальные.
+func (dh *DataHandler) ImportData(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	resp, err := dh.Import(userUUID, requestClientID(c), req.Items)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	info := requestSessionInfo(c, "")
+	for _, result := range resp.Results {
+		if result.ID != nil {
+			dh.RecordAccess(userUUID, models.AuditCreate, *result.ID, info)
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Import создает записи пользователя userID из items с устройства clientID и возвращает
+// результат для каждой записи в порядке запроса.
+func (dh *DataHandler) Import(userID uuid.UUID, clientID string, items []CreateDataRequest) (*ImportResponse, error) {
+	if len(items) == 0 {
+		return nil, newRequestError(http.StatusBadRequest, "Список записей пуст")
+	}
+	if len(items) > maxImportItems {
+		return nil, newRequestError(http.StatusBadRequest, fmt.Sprintf("За один запрос можно импортировать не больше %d записей", maxImportItems))
+	}
+	if _, err := dh.userRepo.GetByID(userID); err != nil {
+		return nil, newRequestError(http.StatusUnauthorized, "Пользователь не найден")
+	}
+
+	resp := &ImportResponse{Results: make([]ImportResult, 0, len(items))}
+	for i := range items {
+		result := ImportResult{Index: i}
+		data, err := dh.Create(userID, clientID, &items[i])
+		if err != nil {
+			result.Error = "Ошибка создания данных"
+			var reqErr *RequestError
+			if errors.As(err, &reqErr) {
+				result.Error = reqErr.Message
+			}
+			resp.Failed++
+		} else {
+			result.ID = &data.ID
+			resp.Created++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+)
+
+func TestDataHandler_ImportData(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+	router := newOrganizeRouter(handler, userID)
+	router.POST("/data/import", handler.ImportData)
+
+	folder := serveJSON(router, "POST", "/folders", "laptop", FolderRequest{Name: "Work"})
+	var work models.Folder
+	json.Unmarshal(folder.Body.Bytes(), &work)
+
+	w := serveJSON(router, "POST", "/data/import", "laptop", ImportRequest{Items: []CreateDataRequest{
+		{Name: "Gmail", Login: "user@gmail.com", Password: "secret", FolderID: &work.ID, Tags: []string{"mail"}, Favorite: true},
+		{Name: "Без логина", Password: "secret"},
+		{Type: models.DataTypeText, Name: "Wi-Fi", Text: "password"},
+	}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp ImportResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Created != 2 || resp.Failed != 1 || len(resp.Results) != 3 {
+		t.Fatalf("Ожидалось 2 созданные и 1 отклоненная запись, получено %+v", resp)
+	}
+	if resp.Results[0].ID == nil || resp.Results[1].ID != nil || resp.Results[1].Error == "" || resp.Results[2].ID == nil {
+		t.Errorf("Неожиданные результаты импорта: %+v", resp.Results)
+	}
+
+	// Папка, метки и избранное сохраняются так же, как при создании записи
+	if names := listNames(t, router, "/data?tag=mail&favorite=true&folder_id="+work.ID.String()); len(names) != 1 || names[0] != "Gmail" {
+		t.Errorf("Ожидалась запись Gmail в папке Work, получено %v", names)
+	}
+
+	if w := serveJSON(router, "POST", "/data/import", "laptop", ImportRequest{}); w.Code != http.StatusBadRequest {
+		t.Errorf("Пустой импорт: ожидался статус %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,194 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Ограничения размера страницы списка записей.
+const (
+	defaultDataLimit = 100
+	maxDataLimit     = 1000
+)
+
+// ListPage возвращает страницу личных записей пользователя и открытых ему записей
+// других пользователей, подходящих под запрос, и курсор следующей страницы,
+// если она есть. Открытые записи возвращаются с отметкой Shared.
+func (dh *DataHandler) ListPage(userID uuid.UUID, query repository.DataQuery) ([]models.Data, *repository.DataCursor, error) {
+	if query.Type != "" && !query.Type.IsValid() {
+		return nil, nil, newRequestError(http.StatusBadRequest, "Неизвестный тип данных")
+	}
+	if query.Sort == "" {
+		query.Sort = repository.DataSortName
+	}
+	if !query.Sort.IsValid() {
+		return nil, nil, newRequestError(http.StatusBadRequest, "Неизвестный порядок сортировки")
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultDataLimit
+	}
+
+	// Лишняя запись показывает, что за страницей есть продолжение
+	limit := query.Limit
+	query.Limit++
+	query.IncludeShared = true
+	data, err := dh.dataRepo.List(userID, query)
+	if err != nil {
+		return nil, nil, newRequestError(http.StatusInternalServerError, "Ошибка получения данных")
+	}
+
+	var next *repository.DataCursor
+	if len(data) > limit {
+		data = data[:limit]
+		cursor := repository.CursorFor(query.Sort, &data[limit-1])
+		next = &cursor
+	}
+
+	if err := dh.markSharedPage(userID, data); err != nil {
+		return nil, nil, newRequestError(http.StatusInternalServerError, "Ошибка получения данных")
+	}
+	if err := dh.attachTags(userID, data); err != nil {
+		return nil, nil, newRequestError(http.StatusInternalServerError, "Ошибка получения данных")
+	}
+	if data == nil {
+		data = []models.Data{}
+	}
+	return data, next, nil
+}
+
+// markSharedPage отмечает записи страницы, открытые пользователю другими владельцами.
+func (dh *DataHandler) markSharedPage(userID uuid.UUID, data []models.Data) error {
+	var shares map[uuid.UUID]*models.Share
+	for i := range data {
+		if data[i].UserID == userID {
+			continue
+		}
+		if shares == nil {
+			active, err := dh.shareRepo.GetActiveByGranteeID(userID, time.Now())
+			if err != nil {
+				return err
+			}
+			shares = make(map[uuid.UUID]*models.Share, len(active))
+			for j := range active {
+				shares[active[j].DataID] = &active[j]
+			}
+		}
+		if share, ok := shares[data[i].ID]; ok {
+			dh.markShared(&data[i], share)
+		}
+	}
+	return nil
+}
+
+// dataQuery разбирает фильтры, порядок и курсор списка записей из параметров запроса:
+// type, name_prefix, updated_since (RFC 3339), tag (можно повторять), folder_id,
+// favorite, sort, limit и cursor.
+func dataQuery(c *gin.Context) (repository.DataQuery, error) {
+	query := repository.DataQuery{
+		Type:       models.DataType(c.Query("type")),
+		NamePrefix: c.Query("name_prefix"),
+		Sort:       repository.DataSort(c.Query("sort")),
+	}
+
+	if value := c.Query("updated_since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return query, newRequestError(http.StatusBadRequest, "Неверный формат updated_since, ожидается RFC 3339")
+		}
+		query.UpdatedSince = &since
+	}
+
+	tags, err := tagNames(c.QueryArray("tag"))
+	if err != nil {
+		return query, err
+	}
+	query.Tags = tags
+
+	if value := c.Query("folder_id"); value != "" {
+		folderID, err := uuid.Parse(value)
+		if err != nil {
+			return query, newRequestError(http.StatusBadRequest, "Неверный ID папки")
+		}
+		query.FolderID = &folderID
+	}
+
+	if value := c.Query("favorite"); value != "" {
+		favorite, err := strconv.ParseBool(value)
+		if err != nil {
+			return query, newRequestError(http.StatusBadRequest, "Неверное значение favorite, ожидается true или false")
+		}
+		query.Favorite = favorite
+	}
+
+	limit, err := queryLimit(c, defaultDataLimit, maxDataLimit)
+	if err != nil {
+		return query, err
+	}
+	query.Limit = limit
+
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := decodeCursor(value)
+		if err != nil {
+			return query, newRequestError(http.StatusBadRequest, "Неверный курсор")
+		}
+		query.After = cursor
+	}
+
+	return query, nil
+}
+
+// nextPageLink формирует заголовок Link со ссылкой на страницу после курсора.
+// Остальные параметры запроса сохраняются, чтобы следующая страница продолжала ту же выборку.
+func nextPageLink(c *gin.Context, cursor *repository.DataCursor) string {
+	next := *c.Request.URL
+	params := next.Query()
+	params.Set("cursor", encodeCursor(cursor))
+	next.RawQuery = params.Encode()
+	return fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI())
+}
+
+// pageCursor представляет курсор в том виде, в котором он передается клиенту.
+type pageCursor struct {
+	Name string     `json:"n,omitempty"`
+	Time *time.Time `json:"t,omitempty"`
+	ID   uuid.UUID  `json:"id"`
+}
+
+// encodeCursor кодирует курсор в непрозрачную для клиента строку.
+func encodeCursor(cursor *repository.DataCursor) string {
+	value := pageCursor{Name: cursor.Name, ID: cursor.ID}
+	if !cursor.Time.IsZero() {
+		value.Time = &cursor.Time
+	}
+	raw, _ := json.Marshal(value)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeCursor разбирает курсор, выданный encodeCursor.
+func decodeCursor(s string) (*repository.DataCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var value pageCursor
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+
+	cursor := &repository.DataCursor{Name: value.Name, ID: value.ID}
+	if value.Time != nil {
+		cursor.Time = *value.Time
+	}
+	return cursor, nil
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/google/uuid"
+)
+
+// linkNext извлекает ссылку на следующую страницу из заголовка Link.
+var linkNext = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+func TestDataHandler_GetDataPages(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+	router := newShareRouter(handler, userID)
+
+	for _, name := range []string{"Delta", "alpha", "Charlie", "bravo", "Echo"} {
+		w := serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Type: models.DataTypeText, Name: name, Text: "secret"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Ошибка создания записи: %s", w.Body.String())
+		}
+	}
+
+	// Страницы по две записи собираются в полный список без повторов
+	var names []string
+	path := "/data?limit=2&sort=-name"
+	for pages := 0; path != ""; pages++ {
+		if pages > 3 {
+			t.Fatal("Слишком много страниц")
+		}
+		w := serveJSON(router, "GET", path, "laptop", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var page []models.Data
+		json.Unmarshal(w.Body.Bytes(), &page)
+		for _, data := range page {
+			names = append(names, data.Name)
+		}
+
+		path = ""
+		if m := linkNext.FindStringSubmatch(w.Header().Get("Link")); m != nil {
+			path = m[1]
+		}
+	}
+
+	want := []string{"bravo", "alpha", "Echo", "Delta", "Charlie"}
+	if len(names) != len(want) {
+		t.Fatalf("Ожидалось %v, получено %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("Ожидалось %v, получено %v", want, names)
+		}
+	}
+}
+
+func TestDataHandler_GetDataFilters(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+	router := newShareRouter(handler, userID)
+
+	serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Mail work", Login: "user", Password: "secret"})
+	serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "mail home", Login: "user", Password: "secret"})
+	serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Type: models.DataTypeText, Name: "Mailbox note", Text: "note"})
+
+	since := time.Now().UTC().Format(time.RFC3339Nano)
+	time.Sleep(10 * time.Millisecond)
+	serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Bank", Login: "user", Password: "secret"})
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"name_prefix=MAIL", 3},
+		{"name_prefix=mail&type=login_password", 2},
+		{"updated_since=" + since, 1},
+		{"type=card", 0},
+	}
+	for _, tt := range tests {
+		w := serveJSON(router, "GET", "/data?"+tt.query, "laptop", nil)
+		var page []models.Data
+		json.Unmarshal(w.Body.Bytes(), &page)
+		if w.Code != http.StatusOK || len(page) != tt.want {
+			t.Errorf("%s: ожидалось %d записей, получено %d (%d)", tt.query, tt.want, len(page), w.Code)
+		}
+		if w.Header().Get("Link") != "" {
+			t.Errorf("%s: у единственной страницы не должно быть ссылки на следующую", tt.query)
+		}
+	}
+
+	for _, query := range []string{"sort=size", "limit=-1", "cursor=bad", "updated_since=yesterday", "type=unknown"} {
+		if w := serveJSON(router, "GET", "/data?"+query, "laptop", nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: ожидался статус %d, получен %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestDataHandler_GetDataIncludesShared(t *testing.T) {
+	handler, _, ownerID := setupTestDataHandler(t)
+	friend := &models.User{ID: uuid.New(), Username: "friend", Email: "friend@example.com"}
+	handler.userRepo.Create(friend)
+
+	owner := newShareRouter(handler, ownerID)
+	w := serveJSON(owner, "POST", "/data", "laptop", CreateDataRequest{Name: "Shared mail", Login: "user", Password: "secret"})
+	var created models.Data
+	json.Unmarshal(w.Body.Bytes(), &created)
+	serveJSON(owner, "POST", "/data/"+created.ID.String()+"/shares", "laptop", ShareRequest{Username: "friend"})
+
+	w = serveJSON(newShareRouter(handler, friend.ID), "GET", "/data?name_prefix=shared", "phone", nil)
+	var page []models.Data
+	json.Unmarshal(w.Body.Bytes(), &page)
+	if len(page) != 1 || page[0].Shared == nil || page[0].Shared.Owner != "testuser" {
+		t.Errorf("Открытая запись должна попасть в список с отметкой владельца, получено %+v", page)
+	}
+}
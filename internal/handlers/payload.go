@@ -0,0 +1,179 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/datakeys"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+)
+
+// payload описывает типизированное содержимое записи.
+type payload interface {
+	Validate() error
+}
+
+// DataResponse представляет запись данных вместе с расшифрованным содержимым.
+// Для записей, зашифрованных на клиенте, возвращается только EncryptedPayload.
+type DataResponse struct {
+	models.Data
+	Password         string           `json:"password,omitempty"`
+	Text             string           `json:"text,omitempty"`
+	Card             *models.BankCard `json:"card,omitempty"`
+	Binary           []byte           `json:"binary,omitempty"`
+	EncryptedPayload string           `json:"encrypted_payload,omitempty"`
+	Deleted          bool             `json:"deleted,omitempty"`
+}
+
+// hasPlainSecrets проверяет, содержит ли запрос открытые секретные поля.
+func (req *CreateDataRequest) hasPlainSecrets() bool {
+	return req.Login != "" || req.Password != "" || req.Text != "" || req.Card != nil || req.Binary != nil
+}
+
+// hasPlainSecrets проверяет, содержит ли запрос открытые секретные поля.
+func (req *UpdateDataRequest) hasPlainSecrets() bool {
+	return req.Login != "" || req.Password != "" || req.Text != "" || req.Card != nil || req.Binary != nil
+}
+
+// validateClientPayload проверяет blob, зашифрованный на клиенте.
+// Сервер не расшифровывает blob и проверяет только его формат.
+func validateClientPayload(encryptedPayload string, hasPlainSecrets bool) error {
+	if hasPlainSecrets {
+		return errors.New("зашифрованное содержимое не может сочетаться с открытыми секретными полями")
+	}
+	if !crypto.ValidRecordBlob(encryptedPayload) {
+		return errors.New("зашифрованное содержимое должно быть в формате base64")
+	}
+	return nil
+}
+
+// newPayload собирает типизированное содержимое записи из полей запроса.
+func newPayload(dataType models.DataType, req *CreateDataRequest) payload {
+	switch dataType {
+	case models.DataTypeText:
+		return &models.TextNote{Text: req.Text}
+	case models.DataTypeBankCard:
+		if req.Card == nil {
+			return &models.BankCard{}
+		}
+		return req.Card
+	case models.DataTypeBinary:
+		return &models.Binary{Data: req.Binary}
+	default:
+		return &models.LoginPassword{Login: req.Login, Password: req.Password}
+	}
+}
+
+// updatePayload возвращает новое содержимое записи из запроса обновления
+// или nil, если секретные поля не изменяются.
+func updatePayload(data *models.Data, req *UpdateDataRequest) payload {
+	switch data.Type {
+	case models.DataTypeText:
+		if req.Text != "" {
+			return &models.TextNote{Text: req.Text}
+		}
+	case models.DataTypeBankCard:
+		if req.Card != nil {
+			return req.Card
+		}
+	case models.DataTypeBinary:
+		if req.Binary != nil {
+			return &models.Binary{Data: req.Binary}
+		}
+	case models.DataTypeFile:
+		// Содержимое файла заменяется только повторной загрузкой
+	default:
+		if req.Login != "" {
+			data.Login = req.Login
+		}
+		if req.Password != "" {
+			return &models.LoginPassword{Login: data.Login, Password: req.Password}
+		}
+	}
+	return nil
+}
+
+// sealPayload шифрует секретные поля содержимого ключом данных владельца записи
+// или ее хранилища и сохраняет их в записи.
+func (dh *DataHandler) sealPayload(data *models.Data, p payload) error {
+	keys := dh.dataKeys.ForRecord(data)
+
+	var plaintext string
+	switch v := p.(type) {
+	case *models.LoginPassword:
+		data.Login = v.Login
+		if v.Password == "" {
+			return nil
+		}
+		encryptedPassword, err := keys.Encrypt(data.ID, v.Password)
+		if err != nil {
+			return err
+		}
+		data.Password = encryptedPassword
+		return nil
+	case *models.TextNote:
+		plaintext = v.Text
+	case *models.BankCard:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		plaintext = string(raw)
+	case *models.Binary:
+		plaintext = base64.StdEncoding.EncodeToString(v.Data)
+	}
+
+	encrypted, err := keys.Encrypt(data.ID, plaintext)
+	if err != nil {
+		return err
+	}
+	data.Payload = encrypted
+	return nil
+}
+
+// openPayload расшифровывает содержимое записи ключами ее владельца или хранилища.
+func (dh *DataHandler) openPayload(data *models.Data, keys *datakeys.Keys) (*DataResponse, error) {
+	resp := &DataResponse{Data: *data}
+
+	if data.ClientEncrypted {
+		resp.EncryptedPayload = data.Payload
+		return resp, nil
+	}
+
+	switch data.Type {
+	case models.DataTypeText, models.DataTypeBankCard, models.DataTypeBinary:
+		plaintext, err := keys.Decrypt(data.ID, data.Payload)
+		if err != nil {
+			return nil, err
+		}
+		switch data.Type {
+		case models.DataTypeText:
+			resp.Text = plaintext
+		case models.DataTypeBankCard:
+			var card models.BankCard
+			if err := json.Unmarshal([]byte(plaintext), &card); err != nil {
+				return nil, err
+			}
+			resp.Card = &card
+		case models.DataTypeBinary:
+			raw, err := base64.StdEncoding.DecodeString(plaintext)
+			if err != nil {
+				return nil, err
+			}
+			resp.Binary = raw
+		}
+	default:
+		if data.Password != "" {
+			password, err := keys.Decrypt(data.ID, data.Password)
+			if err != nil {
+				return nil, err
+			}
+			resp.Password = password
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,85 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Ограничения количества результатов поиска.
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+// SearchResult представляет найденную запись и ее релевантность.
+type SearchResult struct {
+	models.Data
+	Rank float64 `json:"rank"`
+}
+
+// SearchData ищет записи пользователя по названию, логину и метаданным.
+// Поисковый запрос передается в параметре q, количество результатов - в limit.
+func (dh *DataHandler) SearchData(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+		return
+	}
+
+	limit, err := queryLimit(c, defaultSearchLimit, maxSearchLimit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	results, err := dh.Search(userUUID, c.Query("q"), limit)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// Search возвращает личные записи пользователя, открытые ему записи и записи его хранилищ,
+// подходящие под поисковый запрос, в порядке убывания релевантности. Секретные поля зашифрованы
+// и в поиске не участвуют.
+func (dh *DataHandler) Search(userID uuid.UUID, query string, limit int) ([]SearchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, newRequestError(http.StatusBadRequest, "Поисковый запрос обязателен")
+	}
+
+	matches, err := dh.dataRepo.Search(userID, query, limit)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка поиска данных")
+	}
+
+	data := make([]models.Data, 0, len(matches))
+	for _, match := range matches {
+		data = append(data, match.Data)
+	}
+	if err := dh.markSharedPage(userID, data); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка поиска данных")
+	}
+	if err := dh.attachTags(userID, data); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка поиска данных")
+	}
+
+	results := make([]SearchResult, 0, len(matches))
+	for i, match := range matches {
+		results = append(results, SearchResult{Data: data[i], Rank: match.Rank})
+	}
+	return results, nil
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestDataHandler_SearchData(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+	router := newShareRouter(handler, userID)
+	router.GET("/data/search", handler.SearchData)
+
+	serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Bank", Login: "mail@example.com", Password: "secret"})
+	serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Mail", Login: "user", Password: "secret"})
+	serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Type: models.DataTypeText, Name: "Note", Text: "mail"})
+
+	w := serveJSON(router, "GET", "/data/search?q=mail", "laptop", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// Секретный текст заметки зашифрован и в поиске не участвует
+	var results []SearchResult
+	json.Unmarshal(w.Body.Bytes(), &results)
+	if len(results) != 2 || results[0].Name != "Mail" || results[1].Name != "Bank" {
+		t.Fatalf("Ожидались записи Mail и Bank, получено %+v", results)
+	}
+	if results[0].Rank <= results[1].Rank {
+		t.Errorf("Совпадение в названии должно быть релевантнее: %v <= %v", results[0].Rank, results[1].Rank)
+	}
+
+	if w := serveJSON(router, "GET", "/data/search?q=mail&limit=1", "laptop", nil); w.Code != http.StatusOK {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	} else if json.Unmarshal(w.Body.Bytes(), &results); len(results) != 1 {
+		t.Errorf("Ожидался 1 результат по лимиту, получено %d", len(results))
+	}
+
+	if w := serveJSON(router, "GET", "/data/search?q=%20", "laptop", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Пустой запрос: ожидался статус %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestDataHandler_SearchVault(t *testing.T) {
+	vh, dh, ownerID, member := setupTestVaultHandler(t)
+	owner := newVaultRouter(vh, dh, ownerID)
+
+	w := serveJSON(owner, "POST", "/vaults", "laptop", CreateVaultRequest{Name: "Team"})
+	var vault VaultResponse
+	json.Unmarshal(w.Body.Bytes(), &vault)
+	serveJSON(owner, "PUT", "/vaults/"+vault.ID.String()+"/members", "laptop", VaultMemberRequest{Username: "member", Role: models.VaultRoleViewer})
+	created, err := dh.Create(ownerID, "laptop", &CreateDataRequest{VaultID: &vault.ID, Name: "Team mail", Login: "root", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Ошибка создания записи: %v", err)
+	}
+
+	// Запись хранилища находят все его участники, включая читателей
+	for _, userID := range []uuid.UUID{ownerID, member.ID} {
+		results, err := dh.Search(userID, "mail", 10)
+		if err != nil {
+			t.Fatalf("Ошибка поиска: %v", err)
+		}
+		if len(results) != 1 || results[0].ID != created.ID {
+			t.Errorf("Ожидалась запись хранилища, получено %+v", results)
+		}
+	}
+
+	if results, _ := dh.Search(uuid.New(), "mail", 10); len(results) != 0 {
+		t.Errorf("Пользователь вне хранилища не должен находить его записи, получено %+v", results)
+	}
+}
@@ -0,0 +1,303 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/auth"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval задает, как часто обновляется время последней активности сессии.
+// Обновление при каждом запросе лишний раз нагружало бы базу.
+const sessionTouchInterval = time.Minute
+
+// SessionInfo описывает устройство, с которого открывается сессия.
+type SessionInfo struct {
+	DeviceName string
+	IP         string
+	UserAgent  string
+}
+
+// requestSessionInfo собирает сведения об устройстве из запроса.
+// Если клиент не передал имя устройства, используется X-Client-ID или User-Agent.
+func requestSessionInfo(c *gin.Context, deviceName string) SessionInfo {
+	if deviceName == "" {
+		deviceName = requestClientID(c)
+	}
+	return SessionInfo{
+		DeviceName: deviceName,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+}
+
+// RefreshRequest представляет запрос обновления токенов.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// SessionResponse представляет сессию в списке сессий пользователя.
+type SessionResponse struct {
+	models.Session
+	// Current отмечает сессию, токеном которой выполнен запрос.
+	Current bool `json:"current"`
+}
+
+// openSession создает сессию пользователя и выдает для нее пару токенов.
+func (ah *AuthHandler) openSession(user *models.User, info SessionInfo) (*AuthResponse, error) {
+	refreshToken, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка генерации токена")
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		UserID:           user.ID,
+		DeviceName:       info.DeviceName,
+		IP:               info.IP,
+		UserAgent:        info.UserAgent,
+		RefreshTokenHash: hash,
+		CreatedAt:        now,
+		LastSeenAt:       now,
+		ExpiresAt:        now.Add(ah.refreshTTL),
+	}
+	if err := ah.sessionRepo.Create(session); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка создания сессии")
+	}
+
+	return ah.newAuthResponse(user, session, refreshToken)
+}
+
+// Refresh обменивает токен обновления на новую пару токенов.
+func (ah *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	response, err := ah.RefreshSession(req.RefreshToken, c.ClientIP())
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RefreshSession заменяет токен обновления сессии новым и выдает новый токен доступа.
+// Каждый токен обновления действует один раз: повторное предъявление уже замененного токена
+// означает, что он мог быть похищен, поэтому сессия отзывается целиком.
+func (ah *AuthHandler) RefreshSession(refreshToken, ip string) (*AuthResponse, error) {
+	if refreshToken == "" {
+		return nil, newRequestError(http.StatusBadRequest, "Токен обновления обязателен")
+	}
+
+	hash := auth.HashRefreshToken(refreshToken)
+	session, err := ah.sessionRepo.GetByRefreshTokenHash(hash)
+	if err != nil {
+		return nil, newRequestError(http.StatusUnauthorized, "Неверный токен обновления")
+	}
+
+	now := time.Now()
+	if session.RefreshTokenHash != hash {
+		return nil, ah.revokeReusedSession(session.ID, now)
+	}
+	if !session.Active(now) {
+		return nil, newRequestError(http.StatusUnauthorized, "Сессия отозвана или истекла")
+	}
+
+	user, err := ah.userRepo.GetByID(session.UserID)
+	if err != nil {
+		return nil, newRequestError(http.StatusUnauthorized, "Пользователь не найден")
+	}
+
+	newToken, newHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка генерации токена")
+	}
+
+	session.PreviousTokenHash = hash
+	session.RefreshTokenHash = newHash
+	session.ExpiresAt = now.Add(ah.refreshTTL)
+	session.LastSeenAt = now
+	session.IP = ip
+
+	if err := ah.sessionRepo.Rotate(session, hash); err != nil {
+		// Токен успел заменить параллельный запрос, то есть он предъявлен повторно
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ah.revokeReusedSession(session.ID, now)
+		}
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка обновления сессии")
+	}
+
+	return ah.newAuthResponse(user, session, newToken)
+}
+
+// revokeReusedSession отзывает сессию, токен обновления которой предъявлен повторно.
+func (ah *AuthHandler) revokeReusedSession(sessionID uuid.UUID, now time.Time) error {
+	if err := ah.sessionRepo.Revoke(sessionID, now); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return newRequestError(http.StatusInternalServerError, "Ошибка отзыва сессии")
+	}
+	return newRequestError(http.StatusUnauthorized, "Токен обновления уже использован, сессия отозвана")
+}
+
+// CheckSession проверяет, что сессия токена доступа не отозвана и не истекла.
+// Заодно обновляются IP и время последней активности сессии.
+func (ah *AuthHandler) CheckSession(claims *auth.Claims, ip string) error {
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return newRequestError(http.StatusUnauthorized, "Токен не привязан к сессии")
+	}
+
+	session, err := ah.sessionRepo.GetByID(sessionID)
+	if err != nil {
+		return newRequestError(http.StatusUnauthorized, "Сессия не найдена")
+	}
+
+	now := time.Now()
+	if session.UserID.String() != claims.UserID || !session.Active(now) {
+		return newRequestError(http.StatusUnauthorized, "Сессия отозвана или истекла")
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval || session.IP != ip {
+		if err := ah.sessionRepo.Touch(session.ID, ip, now); err != nil {
+			return newRequestError(http.StatusInternalServerError, "Ошибка обновления сессии")
+		}
+	}
+
+	return nil
+}
+
+// Logout завершает текущую сессию.
+func (ah *AuthHandler) Logout(c *gin.Context) {
+	userID, claims, ok := sessionClaims(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID сессии"})
+		return
+	}
+
+	if err := ah.RevokeSession(userID, sessionID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Data(http.StatusNoContent, "application/json", nil)
+}
+
+// GetSessions возвращает действующие сессии пользователя.
+func (ah *AuthHandler) GetSessions(c *gin.Context) {
+	userID, claims, ok := sessionClaims(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := ah.ListSessions(userID, claims.SessionID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// ListSessions возвращает действующие сессии пользователя, отмечая текущую.
+func (ah *AuthHandler) ListSessions(userID uuid.UUID, currentSessionID string) ([]SessionResponse, error) {
+	sessions, err := ah.sessionRepo.GetActiveByUserID(userID, time.Now())
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения сессий")
+	}
+
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, SessionResponse{
+			Session: session,
+			Current: session.ID.String() == currentSessionID,
+		})
+	}
+	return response, nil
+}
+
+// DeleteSession отзывает сессию пользователя по ID.
+func (ah *AuthHandler) DeleteSession(c *gin.Context) {
+	userID, _, ok := sessionClaims(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID сессии"})
+		return
+	}
+
+	if err := ah.RevokeSession(userID, sessionID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Data(http.StatusNoContent, "application/json", nil)
+}
+
+// RevokeSession отзывает сессию пользователя. Выданные для нее токены доступа
+// перестают приниматься сразу, а токен обновления больше нельзя обменять.
+func (ah *AuthHandler) RevokeSession(userID, sessionID uuid.UUID) error {
+	session, err := ah.sessionRepo.GetByID(sessionID)
+	if err != nil || session.UserID != userID || session.RevokedAt != nil {
+		return newRequestError(http.StatusNotFound, "Сессия не найдена")
+	}
+
+	if err := ah.sessionRepo.Revoke(sessionID, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return newRequestError(http.StatusNotFound, "Сессия не найдена")
+		}
+		return newRequestError(http.StatusInternalServerError, "Ошибка отзыва сессии")
+	}
+	return nil
+}
+
+// DeleteSessions отзывает все сессии пользователя, включая текущую.
+func (ah *AuthHandler) DeleteSessions(c *gin.Context) {
+	userID, _, ok := sessionClaims(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := ah.sessionRepo.RevokeByUserID(userID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка отзыва сессий"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
+
+// sessionClaims возвращает ID пользователя и claims токена, проверенного AuthMiddleware.
+// При ошибке ответ уже отправлен.
+func sessionClaims(c *gin.Context) (uuid.UUID, *auth.Claims, bool) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+		return uuid.Nil, nil, false
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+		return uuid.Nil, nil, false
+	}
+
+	return userID, claims, true
+}
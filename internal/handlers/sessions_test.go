@@ -0,0 +1,193 @@
+// Package handlers содержит тесты для сессий пользователей.
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/auth"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// newSessionRouter создает роутер с маршрутами аутентификации и сессий, как в сервере.
+func newSessionRouter(handler *AuthHandler) *gin.Engine {
+	router := gin.New()
+	router.POST("/login", handler.Login)
+	router.POST("/login/2fa", handler.LoginTwoFactor)
+	router.POST("/refresh", handler.Refresh)
+
+	protected := router.Group("/")
+	protected.Use(middleware.AuthMiddleware(handler.jwtSecret, handler))
+	protected.POST("/logout", handler.Logout)
+	protected.GET("/sessions", handler.GetSessions)
+	protected.DELETE("/sessions", handler.DeleteSessions)
+	protected.DELETE("/sessions/:id", handler.DeleteSession)
+	protected.POST("/2fa/enable", handler.EnableTwoFactor)
+	protected.POST("/2fa/confirm", handler.ConfirmTwoFactor)
+	protected.POST("/2fa/disable", handler.DisableTwoFactor)
+	protected.PUT("/kdf-check", handler.PutKDFCheck)
+	return router
+}
+
+// serveWithToken выполняет запрос к роутеру с токеном доступа.
+func serveWithToken(router *gin.Engine, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var reqBody bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&reqBody).Encode(body)
+	}
+
+	req := httptest.NewRequest(method, path, &reqBody)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// loginTestUser создает пользователя и выполняет вход с устройства deviceName.
+func loginTestUser(t *testing.T, handler *AuthHandler, router *gin.Engine, deviceName string) AuthResponse {
+	t.Helper()
+
+	if _, err := handler.userRepo.GetByUsername("testuser"); err != nil {
+		hashedPassword, _ := auth.HashPassword("password123")
+		handler.userRepo.Create(&models.User{Username: "testuser", Email: "test@example.com", Password: hashedPassword})
+	}
+
+	w := serveWithToken(router, "POST", "/login", "", LoginRequest{Username: "testuser", Password: "password123", DeviceName: deviceName})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ошибка входа: статус %d, ответ %s", w.Code, w.Body.String())
+	}
+
+	var response AuthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Ошибка парсинга ответа: %v", err)
+	}
+	if response.RefreshToken == "" || response.SessionID == "" {
+		t.Fatalf("Ожидались токен обновления и ID сессии, получено %+v", response)
+	}
+	return response
+}
+
+func TestAuthHandler_RefreshRotation(t *testing.T) {
+	handler := setupTestAuthHandler(t)
+	router := newSessionRouter(handler)
+	login := loginTestUser(t, handler, router, "laptop")
+
+	w := serveWithToken(router, "POST", "/refresh", "", RefreshRequest{RefreshToken: login.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	}
+	var refreshed AuthResponse
+	json.Unmarshal(w.Body.Bytes(), &refreshed)
+
+	if refreshed.SessionID != login.SessionID {
+		t.Errorf("Обновление должно сохранять сессию %s, получена %s", login.SessionID, refreshed.SessionID)
+	}
+	if refreshed.RefreshToken == login.RefreshToken {
+		t.Error("Токен обновления должен заменяться при каждом обновлении")
+	}
+
+	if w := serveWithToken(router, "GET", "/sessions", refreshed.Token, nil); w.Code != http.StatusOK {
+		t.Errorf("Новый токен доступа должен приниматься, получен статус %d", w.Code)
+	}
+
+	// Повторное использование замененного токена отзывает сессию
+	w = serveWithToken(router, "POST", "/refresh", "", RefreshRequest{RefreshToken: login.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Ожидался статус %d при повторном использовании токена, получен %d", http.StatusUnauthorized, w.Code)
+	}
+
+	w = serveWithToken(router, "POST", "/refresh", "", RefreshRequest{RefreshToken: refreshed.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Ожидался статус %d для отозванной сессии, получен %d", http.StatusUnauthorized, w.Code)
+	}
+
+	if w := serveWithToken(router, "GET", "/sessions", refreshed.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Токен доступа отозванной сессии должен отклоняться, получен статус %d", w.Code)
+	}
+}
+
+func TestAuthHandler_Refresh_InvalidToken(t *testing.T) {
+	handler := setupTestAuthHandler(t)
+	router := newSessionRouter(handler)
+
+	if w := serveWithToken(router, "POST", "/refresh", "", RefreshRequest{}); w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался статус %d без токена, получен %d", http.StatusBadRequest, w.Code)
+	}
+
+	if w := serveWithToken(router, "POST", "/refresh", "", RefreshRequest{RefreshToken: "unknown"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Ожидался статус %d для неизвестного токена, получен %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestAuthHandler_SessionsListAndRevoke(t *testing.T) {
+	handler := setupTestAuthHandler(t)
+	router := newSessionRouter(handler)
+	laptop := loginTestUser(t, handler, router, "laptop")
+	phone := loginTestUser(t, handler, router, "phone")
+
+	w := serveWithToken(router, "GET", "/sessions", laptop.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	}
+	var sessions []SessionResponse
+	json.Unmarshal(w.Body.Bytes(), &sessions)
+	if len(sessions) != 2 {
+		t.Fatalf("Ожидалось 2 сессии, получено %d", len(sessions))
+	}
+	for _, session := range sessions {
+		if session.Current != (session.ID.String() == laptop.SessionID) {
+			t.Errorf("Неверная отметка текущей сессии у %s (%s)", session.ID, session.DeviceName)
+		}
+	}
+
+	if w := serveWithToken(router, "DELETE", "/sessions/"+phone.SessionID, laptop.Token, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusNoContent, w.Code)
+	}
+	if w := serveWithToken(router, "GET", "/sessions", phone.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Токен отозванной сессии должен отклоняться, получен статус %d", w.Code)
+	}
+	if w := serveWithToken(router, "DELETE", "/sessions/"+phone.SessionID, laptop.Token, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался статус %d для уже отозванной сессии, получен %d", http.StatusNotFound, w.Code)
+	}
+
+	if w := serveWithToken(router, "POST", "/logout", laptop.Token, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusNoContent, w.Code)
+	}
+	if w := serveWithToken(router, "GET", "/sessions", laptop.Token, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("После выхода токен должен отклоняться, получен статус %d", w.Code)
+	}
+}
+
+func TestAuthHandler_RevokeAllSessions(t *testing.T) {
+	handler := setupTestAuthHandler(t)
+	router := newSessionRouter(handler)
+	laptop := loginTestUser(t, handler, router, "laptop")
+	phone := loginTestUser(t, handler, router, "phone")
+
+	w := serveWithToken(router, "DELETE", "/sessions", laptop.Token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	}
+	var response struct {
+		Revoked int64 `json:"revoked"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Revoked != 2 {
+		t.Errorf("Ожидалось 2 отозванные сессии, получено %d", response.Revoked)
+	}
+
+	for _, login := range []AuthResponse{laptop, phone} {
+		if w := serveWithToken(router, "POST", "/refresh", "", RefreshRequest{RefreshToken: login.RefreshToken}); w.Code != http.StatusUnauthorized {
+			t.Errorf("Ожидался статус %d для отозванной сессии, получен %d", http.StatusUnauthorized, w.Code)
+		}
+	}
+}
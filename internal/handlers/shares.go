@@ -0,0 +1,275 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShareRequest представляет запрос открытия записи другому пользователю.
+// Если права не указаны, запись открывается только для чтения.
+type ShareRequest struct {
+	Username   string                 `json:"username"`
+	Permission models.SharePermission `json:"permission"`
+	ExpiresAt  *time.Time             `json:"expires_at"`
+}
+
+// ShareResponse представляет доступ к записи вместе с именем получателя.
+type ShareResponse struct {
+	models.Share
+	Username string `json:"username"`
+}
+
+// ShareData открывает запись другому пользователю.
+func (dh *DataHandler) ShareData(c *gin.Context) {
+	userUUID, dataID, ok := shareParams(c)
+	if !ok {
+		return
+	}
+
+	var req ShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	share, err := dh.Share(userUUID, dataID, &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	dh.RecordShare(userUUID, models.AuditShare, share, requestSessionInfo(c, ""))
+
+	c.JSON(http.StatusCreated, share)
+}
+
+// Share открывает запись владельца ownerID пользователю из запроса. Повторный
+// запрос для того же пользователя заменяет права и срок доступа.
+func (dh *DataHandler) Share(ownerID, dataID uuid.UUID, req *ShareRequest) (*ShareResponse, error) {
+	data, err := dh.ownedData(ownerID, dataID)
+	if err != nil {
+		return nil, err
+	}
+	if data.VaultID != nil {
+		return nil, newRequestError(http.StatusBadRequest, "Записи хранилища доступны его участникам, откройте доступ к хранилищу")
+	}
+	if data.ClientEncrypted {
+		return nil, newRequestError(http.StatusBadRequest, "Запись зашифрована на клиенте, сервер не может открыть ее другому пользователю")
+	}
+	if data.Type == models.DataTypeFile {
+		return nil, newRequestError(http.StatusBadRequest, "Файлы нельзя открыть другому пользователю")
+	}
+
+	permission := req.Permission
+	if permission == "" {
+		permission = models.SharePermissionRead
+	}
+	if !permission.IsValid() {
+		return nil, newRequestError(http.StatusBadRequest, "Права доступа должны быть read или write")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, newRequestError(http.StatusBadRequest, "Срок доступа должен быть в будущем")
+	}
+
+	if req.Username == "" {
+		return nil, newRequestError(http.StatusBadRequest, "Имя пользователя обязательно")
+	}
+	grantee, err := dh.userRepo.GetByUsername(req.Username)
+	if err != nil {
+		return nil, newRequestError(http.StatusNotFound, "Пользователь не найден")
+	}
+	if grantee.ID == ownerID {
+		return nil, newRequestError(http.StatusBadRequest, "Нельзя открыть запись самому себе")
+	}
+
+	share := &models.Share{
+		DataID:     dataID,
+		OwnerID:    ownerID,
+		GranteeID:  grantee.ID,
+		Permission: permission,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if err := dh.shareRepo.Save(share); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка сохранения доступа")
+	}
+
+	return &ShareResponse{Share: *share, Username: grantee.Username}, nil
+}
+
+// GetShares возвращает доступы к записи, выданные ее владельцем.
+func (dh *DataHandler) GetShares(c *gin.Context) {
+	userUUID, dataID, ok := shareParams(c)
+	if !ok {
+		return
+	}
+
+	shares, err := dh.ListShares(userUUID, dataID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, shares)
+}
+
+// ListShares возвращает доступы к записи владельца ownerID, включая истекшие.
+func (dh *DataHandler) ListShares(ownerID, dataID uuid.UUID) ([]ShareResponse, error) {
+	if _, err := dh.ownedData(ownerID, dataID); err != nil {
+		return nil, err
+	}
+
+	shares, err := dh.shareRepo.GetByDataID(dataID)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения доступов")
+	}
+
+	result := make([]ShareResponse, 0, len(shares))
+	for _, share := range shares {
+		resp := ShareResponse{Share: share}
+		if grantee, err := dh.userRepo.GetByID(share.GranteeID); err == nil {
+			resp.Username = grantee.Username
+		}
+		result = append(result, resp)
+	}
+	return result, nil
+}
+
+// DeleteShare отзывает доступ к записи.
+func (dh *DataHandler) DeleteShare(c *gin.Context) {
+	userUUID, dataID, ok := shareParams(c)
+	if !ok {
+		return
+	}
+
+	shareID, err := uuid.Parse(c.Param("share"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID доступа"})
+		return
+	}
+
+	share, err := dh.RevokeShare(userUUID, dataID, shareID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	dh.RecordShare(userUUID, models.AuditUnshare, share, requestSessionInfo(c, ""))
+
+	c.Data(http.StatusNoContent, "application/json", nil)
+}
+
+// RevokeShare отзывает доступ shareID к записи владельца ownerID и возвращает отозванный доступ.
+func (dh *DataHandler) RevokeShare(ownerID, dataID, shareID uuid.UUID) (*ShareResponse, error) {
+	if _, err := dh.ownedData(ownerID, dataID); err != nil {
+		return nil, err
+	}
+
+	shares, err := dh.shareRepo.GetByDataID(dataID)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения доступов")
+	}
+
+	for _, share := range shares {
+		if share.ID != shareID {
+			continue
+		}
+		if err := dh.shareRepo.Delete(shareID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newRequestError(http.StatusInternalServerError, "Ошибка отзыва доступа")
+		}
+		resp := &ShareResponse{Share: share}
+		if grantee, err := dh.userRepo.GetByID(share.GranteeID); err == nil {
+			resp.Username = grantee.Username
+		}
+		return resp, nil
+	}
+	return nil, newRequestError(http.StatusNotFound, "Доступ не найден")
+}
+
+// ownedData возвращает запись, принадлежащую пользователю ownerID.
+// Открытые пользователю чужие записи не подходят: управлять доступом может только владелец.
+func (dh *DataHandler) ownedData(ownerID, dataID uuid.UUID) (*models.Data, error) {
+	data, err := dh.dataRepo.GetByID(dataID)
+	if err != nil || data.UserID != ownerID {
+		return nil, newRequestError(http.StatusNotFound, "Данные не найдены")
+	}
+	return data, nil
+}
+
+// sharedAccess возвращает действующий доступ пользователя userID к чужой записи data.
+func (dh *DataHandler) sharedAccess(userID uuid.UUID, data *models.Data) (*models.Share, error) {
+	share, err := dh.shareRepo.GetActive(data.ID, userID, time.Now())
+	if err != nil {
+		return nil, newRequestError(http.StatusNotFound, "Данные не найдены")
+	}
+	return share, nil
+}
+
+// markShared отмечает запись как открытую пользователю ее владельцем по доступу share.
+func (dh *DataHandler) markShared(data *models.Data, share *models.Share) {
+	access := &models.SharedAccess{Permission: share.Permission, ExpiresAt: share.ExpiresAt}
+	if owner, err := dh.userRepo.GetByID(data.UserID); err == nil {
+		access.Owner = owner.Username
+	}
+	data.Shared = access
+}
+
+// sharedData возвращает действующие записи других пользователей, открытые
+// пользователю userID, указанного типа или всех типов, если тип пуст.
+func (dh *DataHandler) sharedData(userID uuid.UUID, dataType models.DataType) ([]models.Data, error) {
+	shares, err := dh.shareRepo.GetActiveByGranteeID(userID, time.Now())
+	if err != nil || len(shares) == 0 {
+		return nil, err
+	}
+
+	byData := make(map[uuid.UUID]*models.Share, len(shares))
+	ids := make([]uuid.UUID, 0, len(shares))
+	for i := range shares {
+		byData[shares[i].DataID] = &shares[i]
+		ids = append(ids, shares[i].DataID)
+	}
+
+	data, err := dh.dataRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Data, 0, len(data))
+	for _, d := range data {
+		if dataType != "" && d.Type != dataType {
+			continue
+		}
+		dh.markShared(&d, byData[d.ID])
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+// shareParams извлекает пользователя и ID записи из запроса управления доступом.
+// При ошибке отправляет ответ и возвращает false.
+func shareParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	dataID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID данных"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userUUID, dataID, true
+}
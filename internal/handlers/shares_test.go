@@ -0,0 +1,161 @@
+// Package handlers содержит тесты для совместного доступа к записям.
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// newShareRouter создает роутер с обработчиками данных и доступов для пользователя userID.
+func newShareRouter(handler *DataHandler, userID uuid.UUID) *gin.Engine {
+	router := newHistoryRouter(handler, userID)
+	router.GET("/data", handler.GetData)
+	router.GET("/data/:id", handler.GetDataByID)
+	router.GET("/data/:id/shares", handler.GetShares)
+	router.POST("/data/:id/shares", handler.ShareData)
+	router.DELETE("/data/:id/shares/:share", handler.DeleteShare)
+	return router
+}
+
+func TestDataHandler_ShareAndRevoke(t *testing.T) {
+	handler, _, ownerID := setupTestDataHandler(t)
+	friend := &models.User{ID: uuid.New(), Username: "friend", Email: "friend@example.com"}
+	handler.userRepo.Create(friend)
+
+	owner := newShareRouter(handler, ownerID)
+	guest := newShareRouter(handler, friend.ID)
+
+	w := serveJSON(owner, "POST", "/data", "laptop", CreateDataRequest{Name: "Mail", Login: "user", Password: "secret"})
+	var created models.Data
+	json.Unmarshal(w.Body.Bytes(), &created)
+	path := "/data/" + created.ID.String()
+
+	// Без доступа чужая запись не видна
+	if w := serveJSON(guest, "GET", path, "phone", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusNotFound, w.Code)
+	}
+
+	w = serveJSON(owner, "POST", path+"/shares", "laptop", ShareRequest{Username: "friend"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var share ShareResponse
+	json.Unmarshal(w.Body.Bytes(), &share)
+	if share.Permission != models.SharePermissionRead || share.Username != "friend" {
+		t.Errorf("Ожидался доступ на чтение для friend, получено %+v", share)
+	}
+
+	w = serveJSON(guest, "GET", path, "phone", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	}
+	var resp DataResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Password != "secret" || resp.Shared == nil || resp.Shared.Owner != "testuser" {
+		t.Errorf("Ожидалась расшифрованная запись с отметкой владельца testuser, получено %+v", resp)
+	}
+
+	w = serveJSON(guest, "GET", "/data", "phone", nil)
+	var list []models.Data
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list) != 1 || list[0].Shared == nil {
+		t.Errorf("Ожидалась одна открытая запись в списке, получено %+v", list)
+	}
+
+	// Доступ только для чтения не позволяет изменять запись
+	version := created.Version
+	if w := serveJSON(guest, "PUT", path, "phone", UpdateDataRequest{Version: &version, Password: "changed"}); w.Code != http.StatusForbidden {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusForbidden, w.Code)
+	}
+
+	serveJSON(owner, "POST", path+"/shares", "laptop", ShareRequest{Username: "friend", Permission: models.SharePermissionWrite})
+	if w := serveJSON(guest, "PUT", path, "phone", UpdateDataRequest{Version: &version, Password: "changed"}); w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// Изменение получателя шифруется ключом владельца
+	found, err := handler.FindData(ownerID, created.ID)
+	if err != nil || found.Password != "changed" {
+		t.Errorf("Владелец должен видеть изменение получателя, получено %+v: %v", found, err)
+	}
+
+	// Управлять доступом может только владелец
+	if w := serveJSON(guest, "GET", path+"/shares", "phone", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusNotFound, w.Code)
+	}
+
+	w = serveJSON(owner, "GET", path+"/shares", "laptop", nil)
+	var shares []ShareResponse
+	json.Unmarshal(w.Body.Bytes(), &shares)
+	if len(shares) != 1 || shares[0].Permission != models.SharePermissionWrite {
+		t.Fatalf("Ожидался один доступ на запись, получено %+v", shares)
+	}
+
+	if w := serveJSON(owner, "DELETE", path+"/shares/"+shares[0].ID.String(), "laptop", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusNoContent, w.Code)
+	}
+	if w := serveJSON(guest, "GET", path, "phone", nil); w.Code != http.StatusNotFound {
+		t.Errorf("После отзыва доступа ожидался статус %d, получен %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestDataHandler_Share_Validation(t *testing.T) {
+	handler, _, ownerID := setupTestDataHandler(t)
+	router := newShareRouter(handler, ownerID)
+
+	w := serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Mail", Login: "user", Password: "secret"})
+	var created models.Data
+	json.Unmarshal(w.Body.Bytes(), &created)
+	path := "/data/" + created.ID.String() + "/shares"
+
+	tests := []struct {
+		name string
+		req  ShareRequest
+		want int
+	}{
+		{"самому себе", ShareRequest{Username: "testuser"}, http.StatusBadRequest},
+		{"неизвестный пользователь", ShareRequest{Username: "nobody"}, http.StatusNotFound},
+		{"неизвестные права", ShareRequest{Username: "testuser", Permission: "admin"}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serveJSON(router, "POST", path, "laptop", tt.req); w.Code != tt.want {
+				t.Errorf("Ожидался статус %d, получен %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestDataHandler_Share_ClientEncryptedUpdate(t *testing.T) {
+	handler, _, ownerID := setupTestDataHandler(t)
+	friend := &models.User{ID: uuid.New(), Username: "friend", Email: "friend@example.com"}
+	handler.userRepo.Create(friend)
+	router := newShareRouter(handler, ownerID)
+
+	w := serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Type: models.DataTypeText, Name: "Note", Text: "secret"})
+	var created models.Data
+	json.Unmarshal(w.Body.Bytes(), &created)
+	path := "/data/" + created.ID.String()
+
+	w = serveJSON(router, "POST", path+"/shares", "laptop", ShareRequest{Username: "friend"})
+	var share ShareResponse
+	json.Unmarshal(w.Body.Bytes(), &share)
+
+	// Открытую запись нельзя зашифровать на клиенте: получатель не сможет ее прочитать
+	version := created.Version
+	update := UpdateDataRequest{Version: &version, EncryptedPayload: "Y2xpZW50LWJsb2I="}
+	if w := serveJSON(router, "PUT", path, "laptop", update); w.Code != http.StatusConflict {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+
+	serveJSON(router, "DELETE", path+"/shares/"+share.ID.String(), "laptop", nil)
+	if w := serveJSON(router, "PUT", path, "laptop", update); w.Code != http.StatusOK {
+		t.Errorf("После отзыва доступа ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,87 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ChangesResponse представляет ответ со списком изменений для синхронизации.
+type ChangesResponse struct {
+	Changes []DataResponse `json:"changes"`
+	// ServerTime передается клиентом в параметре since при следующей синхронизации.
+	ServerTime time.Time `json:"server_time"`
+}
+
+// GetChanges возвращает личные записи пользователя и записи его хранилищ, измененные или
+// удаленные после момента since. Окончательно удаленные записи возвращаются как удаленные
+// с одними идентификаторами.
+func (dh *DataHandler) GetChanges(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+		return
+	}
+
+	var since time.Time
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		since, err = time.Parse(time.RFC3339Nano, sinceStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Параметр since должен быть в формате RFC 3339"})
+			return
+		}
+	}
+
+	// Время фиксируется до запроса, чтобы изменения, сделанные во время выборки,
+	// попали в следующую синхронизацию.
+	serverTime := time.Now().UTC()
+
+	data, err := dh.dataRepo.GetChangedSince(userUUID, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения изменений"})
+		return
+	}
+	if err := dh.attachTags(userUUID, data); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения изменений"})
+		return
+	}
+
+	tombstones, err := dh.dataRepo.GetPurgedSince(userUUID, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения изменений"})
+		return
+	}
+
+	changes := make([]DataResponse, 0, len(data)+len(tombstones))
+	for i := range data {
+		if data[i].DeletedAt.Valid {
+			changes = append(changes, DataResponse{Data: data[i], Deleted: true})
+			continue
+		}
+
+		// Записи хранилищ зашифрованы ключом создавшего их участника
+		resp, err := dh.openPayload(&data[i], dh.dataKeys.ForRecord(&data[i]))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка расшифровки данных"})
+			return
+		}
+		changes = append(changes, *resp)
+	}
+	for _, tombstone := range tombstones {
+		purged := models.Data{ID: tombstone.DataID, UserID: tombstone.UserID, VaultID: tombstone.VaultID, UpdatedAt: tombstone.PurgedAt}
+		changes = append(changes, DataResponse{Data: purged, Deleted: true})
+	}
+
+	c.JSON(http.StatusOK, ChangesResponse{Changes: changes, ServerTime: serverTime})
+}
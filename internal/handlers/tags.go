@@ -0,0 +1,283 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxTagLength ограничивает длину имени метки в символах.
+const maxTagLength = 64
+
+// TagRequest представляет запрос создания или переименования метки.
+type TagRequest struct {
+	Name string `json:"name"`
+}
+
+// DataTagsRequest представляет запрос замены меток записи.
+// Метки, которых у пользователя еще нет, создаются.
+type DataTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// GetTags возвращает метки пользователя.
+func (dh *DataHandler) GetTags(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+
+	tags, err := dh.tagRepo.GetByUserID(userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения меток"})
+		return
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// CreateTag создает метку пользователя.
+func (dh *DataHandler) CreateTag(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	tag, err := dh.AddTag(userUUID, req.Name)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tag)
+}
+
+// AddTag создает метку name пользователя userID.
+func (dh *DataHandler) AddTag(userID uuid.UUID, name string) (*models.Tag, error) {
+	name, err := tagName(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dh.tagRepo.GetByName(userID, name); err == nil {
+		return nil, newRequestError(http.StatusConflict, "Метка с таким именем уже существует")
+	}
+
+	tag := &models.Tag{UserID: userID, Name: name}
+	if err := dh.tagRepo.Create(tag); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка создания метки")
+	}
+	return tag, nil
+}
+
+// UpdateTag переименовывает метку. Новое имя сразу видно у всех записей с этой меткой.
+func (dh *DataHandler) UpdateTag(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+
+	tagID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID метки"})
+		return
+	}
+
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	tag, err := dh.RenameTag(userUUID, tagID, req.Name)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// RenameTag переименовывает метку tagID пользователя userID.
+func (dh *DataHandler) RenameTag(userID, tagID uuid.UUID, name string) (*models.Tag, error) {
+	tag, err := dh.ownedTag(userID, tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err = tagName(name)
+	if err != nil {
+		return nil, err
+	}
+	if existing, err := dh.tagRepo.GetByName(userID, name); err == nil && existing.ID != tagID {
+		return nil, newRequestError(http.StatusConflict, "Метка с таким именем уже существует")
+	}
+
+	tag.Name = name
+	if err := dh.tagRepo.Update(tag); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка переименования метки")
+	}
+	return tag, nil
+}
+
+// DeleteTag удаляет метку и снимает ее со всех записей.
+func (dh *DataHandler) DeleteTag(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+
+	tagID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID метки"})
+		return
+	}
+
+	if _, err := dh.ownedTag(userUUID, tagID); err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := dh.tagRepo.Delete(tagID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка удаления метки"})
+		return
+	}
+
+	c.Data(http.StatusNoContent, "application/json", nil)
+}
+
+// PutDataTags заменяет метки записи.
+func (dh *DataHandler) PutDataTags(c *gin.Context) {
+	userUUID, dataID, ok := shareParams(c)
+	if !ok {
+		return
+	}
+
+	var req DataTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	data, err := dh.TagData(userUUID, dataID, requestClientID(c), req.Tags)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	dh.RecordAccess(userUUID, models.AuditUpdate, dataID, requestSessionInfo(c, ""))
+
+	c.Header("ETag", formatETag(data.Version))
+	c.JSON(http.StatusOK, data)
+}
+
+// TagData заменяет метки записи пользователя userID на names с устройства clientID.
+// Метки, которых у пользователя еще нет, создаются.
+func (dh *DataHandler) TagData(userID, dataID uuid.UUID, clientID string, names []string) (*models.Data, error) {
+	names, err := tagNames(names)
+	if err != nil {
+		return nil, err
+	}
+
+	return dh.organize(userID, dataID, clientID, func(data *models.Data) error {
+		return dh.setTags(data, names)
+	})
+}
+
+// setTags назначает записи метки ее владельца с именами names, создавая недостающие.
+func (dh *DataHandler) setTags(data *models.Data, names []string) error {
+	ids := make([]uuid.UUID, 0, len(names))
+	for _, name := range names {
+		tag, err := dh.tagRepo.GetByName(data.UserID, name)
+		if err != nil {
+			tag = &models.Tag{UserID: data.UserID, Name: name}
+			if err := dh.tagRepo.Create(tag); err != nil {
+				return newRequestError(http.StatusInternalServerError, "Ошибка создания метки")
+			}
+		}
+		ids = append(ids, tag.ID)
+	}
+
+	if err := dh.tagRepo.SetDataTags(data.ID, ids); err != nil {
+		return newRequestError(http.StatusInternalServerError, "Ошибка сохранения меток")
+	}
+	data.Tags = slices.Sorted(slices.Values(names))
+	return nil
+}
+
+// attachTags заполняет имена меток у записей, принадлежащих пользователю userID.
+// Метки чужих записей принадлежат их владельцам и не показываются.
+func (dh *DataHandler) attachTags(userID uuid.UUID, data []models.Data) error {
+	ids := make([]uuid.UUID, 0, len(data))
+	for i := range data {
+		if data[i].UserID == userID && data[i].VaultID == nil {
+			ids = append(ids, data[i].ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	names, err := dh.tagRepo.GetNamesByDataIDs(ids)
+	if err != nil {
+		return err
+	}
+	for i := range data {
+		if data[i].UserID == userID && data[i].VaultID == nil {
+			data[i].Tags = names[data[i].ID]
+		}
+	}
+	return nil
+}
+
+// ownedTag возвращает метку tagID, принадлежащую пользователю userID.
+func (dh *DataHandler) ownedTag(userID, tagID uuid.UUID) (*models.Tag, error) {
+	tag, err := dh.tagRepo.GetByID(tagID)
+	if err != nil || tag.UserID != userID {
+		return nil, newRequestError(http.StatusNotFound, "Метка не найдена")
+	}
+	return tag, nil
+}
+
+// tagName проверяет имя метки и возвращает его без начальных и конечных пробелов.
+func tagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", newRequestError(http.StatusBadRequest, "Имя метки обязательно")
+	}
+	if utf8.RuneCountInString(name) > maxTagLength {
+		return "", newRequestError(http.StatusBadRequest, "Имя метки не должно быть длиннее 64 символов")
+	}
+	return name, nil
+}
+
+// tagNames проверяет имена меток и возвращает их без повторов в исходном порядке.
+func tagNames(names []string) ([]string, error) {
+	result := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name, err := tagName(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
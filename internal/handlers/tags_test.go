@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// newOrganizeRouter создает роутер с обработчиками меток, папок и избранного.
+func newOrganizeRouter(handler *DataHandler, userID uuid.UUID) *gin.Engine {
+	router := newShareRouter(handler, userID)
+	router.PUT("/data/:id/tags", handler.PutDataTags)
+	router.PUT("/data/:id/folder", handler.PutDataFolder)
+	router.PUT("/data/:id/favorite", handler.PutDataFavorite)
+	router.GET("/tags", handler.GetTags)
+	router.POST("/tags", handler.CreateTag)
+	router.PUT("/tags/:id", handler.UpdateTag)
+	router.DELETE("/tags/:id", handler.DeleteTag)
+	router.GET("/folders", handler.GetFolders)
+	router.POST("/folders", handler.CreateFolder)
+	router.PUT("/folders/:id", handler.UpdateFolder)
+	router.DELETE("/folders/:id", handler.DeleteFolder)
+	return router
+}
+
+// listNames возвращает названия записей из ответа GET /data.
+func listNames(t *testing.T, router *gin.Engine, path string) []string {
+	t.Helper()
+	w := serveJSON(router, "GET", path, "laptop", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var page []models.Data
+	json.Unmarshal(w.Body.Bytes(), &page)
+	names := []string{}
+	for _, data := range page {
+		names = append(names, data.Name)
+	}
+	return names
+}
+
+func TestDataHandler_TagData(t *testing.T) {
+	handler, _, userID := setupTestDataHandler(t)
+	router := newOrganizeRouter(handler, userID)
+
+	var mail, bank models.Data
+	w := serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Mail", Login: "user", Password: "secret", Tags: []string{"work"}})
+	json.Unmarshal(w.Body.Bytes(), &mail)
+	w = serveJSON(router, "POST", "/data", "laptop", CreateDataRequest{Name: "Bank", Login: "user", Password: "secret"})
+	json.Unmarshal(w.Body.Bytes(), &bank)
+
+	// Метки заменяются целиком, повторы отбрасываются, новые метки создаются
+	w = serveJSON(router, "PUT", "/data/"+mail.ID.String()+"/tags", "laptop", DataTagsRequest{Tags: []string{" personal ", "work", "personal"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var tagged models.Data
+	json.Unmarshal(w.Body.Bytes(), &tagged)
+	if len(tagged.Tags) != 2 || tagged.Tags[0] != "personal" || tagged.Tags[1] != "work" {
+		t.Errorf("Ожидались метки [personal work], получено %v", tagged.Tags)
+	}
+	if tagged.Version != mail.Version+1 {
+		t.Errorf("Изменение меток должно увеличивать версию записи: %d", tagged.Version)
+	}
+	serveJSON(router, "PUT", "/data/"+bank.ID.String()+"/tags", "laptop", DataTagsRequest{Tags: []string{"work"}})
+
+	if names := listNames(t, router, "/data?tag=work"); len(names) != 2 {
+		t.Errorf("Ожидались 2 записи с меткой work, получено %v", names)
+	}
+	if names := listNames(t, router, "/data?tag=work&tag=personal"); len(names) != 1 || names[0] != "Mail" {
+		t.Errorf("Ожидалась запись Mail с обеими метками, получено %v", names)
+	}
+	if names := listNames(t, router, "/data?tag=unknown"); len(names) != 0 {
+		t.Errorf("Неизвестная метка не должна находить записи, получено %v", names)
+	}
+
+	// Переименование метки видно у всех записей
+	var tags []models.Tag
+	w = serveJSON(router, "GET", "/tags", "laptop", nil)
+	json.Unmarshal(w.Body.Bytes(), &tags)
+	if len(tags) != 2 || tags[0].Name != "personal" || tags[1].Name != "work" {
+		t.Fatalf("Ожидались метки personal и work, получено %+v", tags)
+	}
+	if w := serveJSON(router, "PUT", "/tags/"+tags[1].ID.String(), "laptop", TagRequest{Name: "personal"}); w.Code != http.StatusConflict {
+		t.Errorf("Повторяющееся имя: ожидался статус %d, получен %d", http.StatusConflict, w.Code)
+	}
+	serveJSON(router, "PUT", "/tags/"+tags[1].ID.String(), "laptop", TagRequest{Name: "job"})
+	if names := listNames(t, router, "/data?tag=job"); len(names) != 2 {
+		t.Errorf("Ожидались 2 записи с переименованной меткой, получено %v", names)
+	}
+
+	// Удаление метки снимает ее с записей
+	if w := serveJSON(router, "DELETE", "/tags/"+tags[0].ID.String(), "laptop", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusNoContent, w.Code)
+	}
+	resp, err := handler.FindData(userID, mail.ID)
+	if err != nil {
+		t.Fatalf("Ошибка получения записи: %v", err)
+	}
+	if len(resp.Tags) != 1 || resp.Tags[0] != "job" {
+		t.Errorf("Ожидалась метка job, получено %v", resp.Tags)
+	}
+
+	if w := serveJSON(router, "POST", "/tags", "laptop", TagRequest{Name: "  "}); w.Code != http.StatusBadRequest {
+		t.Errorf("Пустое имя: ожидался статус %d, получен %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestDataHandler_TagData_OnlyOwner(t *testing.T) {
+	handler, _, ownerID := setupTestDataHandler(t)
+	friend := &models.User{ID: uuid.New(), Username: "friend", Email: "friend@example.com"}
+	handler.userRepo.Create(friend)
+
+	data, err := handler.Create(ownerID, "laptop", &CreateDataRequest{Name: "Mail", Login: "user", Password: "secret"})
+	if err != nil {
+		t.Fatalf("Ошибка создания записи: %v", err)
+	}
+	if _, err := handler.Share(ownerID, data.ID, &ShareRequest{Username: "friend", Permission: models.SharePermissionWrite}); err != nil {
+		t.Fatalf("Ошибка открытия записи: %v", err)
+	}
+
+	// Получатель может изменять запись, но не раскладывать ее по своим меткам
+	if _, err := handler.TagData(friend.ID, data.ID, "phone", []string{"work"}); err == nil {
+		t.Error("Получатель доступа не должен назначать метки чужой записи")
+	}
+}
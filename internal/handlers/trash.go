@@ -0,0 +1,122 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// TrashItem представляет удаленную запись в корзине.
+type TrashItem struct {
+	models.Data
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// GetTrash возвращает удаленные личные записи пользователя и записи хранилищ, в которых он участвует.
+func (dh *DataHandler) GetTrash(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+		return
+	}
+
+	data, err := dh.dataRepo.GetDeletedByUserID(userUUID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения корзины"})
+		return
+	}
+
+	items := make([]TrashItem, 0, len(data))
+	for _, d := range data {
+		items = append(items, TrashItem{Data: d, DeletedAt: d.DeletedAt.Time})
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// RestoreTrash восстанавливает запись из корзины.
+func (dh *DataHandler) RestoreTrash(c *gin.Context) {
+	data, ok := dh.findTrashItem(c)
+	if !ok {
+		return
+	}
+
+	if err := dh.dataRepo.Restore(data.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка восстановления данных"})
+		return
+	}
+
+	restored, err := dh.dataRepo.GetByID(data.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка восстановления данных"})
+		return
+	}
+
+	if err := dh.recordRevision(requestClientID(c), restored, models.RevisionRestore); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения истории изменений"})
+		return
+	}
+
+	c.Header("ETag", formatETag(restored.Version))
+	c.JSON(http.StatusOK, restored)
+}
+
+// PurgeTrash окончательно удаляет запись из корзины вместе с ее историей изменений.
+func (dh *DataHandler) PurgeTrash(c *gin.Context) {
+	data, ok := dh.findTrashItem(c)
+	if !ok {
+		return
+	}
+
+	if err := dh.dataRepo.Purge(data.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка удаления данных"})
+		return
+	}
+
+	c.Data(http.StatusNoContent, "application/json", nil)
+}
+
+// findTrashItem находит удаленную запись из параметров запроса. Восстановить или удалить
+// запись хранилища может участник с ролью не ниже editor. При ошибке отправляет ответ и возвращает false.
+func (dh *DataHandler) findTrashItem(c *gin.Context) (*models.Data, bool) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+		return nil, false
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+		return nil, false
+	}
+
+	dataID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID данных"})
+		return nil, false
+	}
+
+	data, err := dh.dataRepo.GetDeletedByID(dataID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Данные не найдены в корзине"})
+		return nil, false
+	}
+	if err := dh.authorizeRecord(data, userUUID, models.VaultRoleEditor, "Данные не найдены в корзине"); err != nil {
+		respondError(c, err)
+		return nil, false
+	}
+
+	return data, true
+}
@@ -0,0 +1,89 @@
+// Package handlers содержит тесты для корзины.
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// newTrashRouter создает роутер с обработчиками корзины для пользователя userID.
+func newTrashRouter(handler *DataHandler, userID uuid.UUID) *gin.Engine {
+	router := newHistoryRouter(handler, userID)
+	router.GET("/trash", handler.GetTrash)
+	router.POST("/trash/:id/restore", handler.RestoreTrash)
+	router.DELETE("/trash/:id", handler.PurgeTrash)
+	return router
+}
+
+func TestDataHandler_TrashRestore(t *testing.T) {
+	handler, memRepo, userID := setupTestDataHandler(t)
+	router := newTrashRouter(handler, userID)
+
+	data := &models.Data{UserID: userID, Name: "Mail"}
+	memRepo.NewDataRepository().Create(data)
+
+	w := serveJSON(router, "DELETE", "/data/"+data.ID.String(), "laptop", nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusNoContent, w.Code)
+	}
+
+	w = serveJSON(router, "GET", "/trash", "laptop", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	}
+
+	var trash []TrashItem
+	json.Unmarshal(w.Body.Bytes(), &trash)
+	if len(trash) != 1 || trash[0].ID != data.ID || trash[0].DeletedAt.IsZero() {
+		t.Fatalf("Ожидалась удаленная запись в корзине, получено %+v", trash)
+	}
+
+	w = serveJSON(router, "POST", "/trash/"+data.ID.String()+"/restore", "laptop", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusOK, w.Code)
+	}
+
+	if _, err := handler.dataRepo.GetByID(data.ID); err != nil {
+		t.Errorf("Запись должна быть восстановлена: %v", err)
+	}
+
+	revisions, _ := handler.revisionRepo.GetByDataID(data.ID)
+	if len(revisions) == 0 || revisions[0].Action != models.RevisionRestore {
+		t.Errorf("Ожидалась ревизия восстановления, получено %+v", revisions)
+	}
+
+	// Активную запись нельзя восстановить из корзины
+	w = serveJSON(router, "POST", "/trash/"+data.ID.String()+"/restore", "laptop", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestDataHandler_TrashPurge(t *testing.T) {
+	handler, memRepo, userID := setupTestDataHandler(t)
+
+	dataRepo := memRepo.NewDataRepository()
+	data := &models.Data{UserID: userID, Name: "Mail"}
+	dataRepo.Create(data)
+	dataRepo.Delete(data.ID)
+
+	// Чужую запись нельзя удалить окончательно
+	w := serveJSON(newTrashRouter(handler, uuid.New()), "DELETE", "/trash/"+data.ID.String(), "laptop", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusNotFound, w.Code)
+	}
+
+	w = serveJSON(newTrashRouter(handler, userID), "DELETE", "/trash/"+data.ID.String(), "laptop", nil)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusNoContent, w.Code)
+	}
+
+	if _, err := dataRepo.GetDeletedByID(data.ID); err == nil {
+		t.Error("Запись должна быть удалена окончательно")
+	}
+}
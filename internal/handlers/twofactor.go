@@ -0,0 +1,381 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/auth"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// totpIssuer отображается в приложении-аутентификаторе рядом с именем пользователя.
+	totpIssuer = "GophKeeper"
+	// recoveryCodeCount задает число кодов восстановления, выдаваемых при включении 2FA.
+	recoveryCodeCount = 10
+	// maxTOTPFailures задает число неверных кодов подряд, после которого проверка кодов блокируется.
+	maxTOTPFailures = 5
+	// totpLockout задает время блокировки проверки кодов после серии неверных кодов.
+	// Повторный ввод пароля блокировку не снимает.
+	totpLockout = 15 * time.Minute
+)
+
+// TwoFactorLoginRequest представляет второй шаг входа.
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	// Code содержит одноразовый пароль из приложения или код восстановления.
+	Code       string `json:"code"`
+	DeviceName string `json:"device_name,omitempty"`
+}
+
+// TwoFactorCodeRequest представляет запрос с одноразовым паролем.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// TwoFactorSetupResponse содержит секрет для подключения приложения-аутентификатора.
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// RecoveryCodesResponse содержит одноразовые коды восстановления.
+// Сервер хранит только их хеши, поэтому коды показываются один раз.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// twoFactorChallenge завершает первый шаг входа пользователя с включенной 2FA.
+// Вместо токенов доступа выдается токен, который обменивается на них вместе с одноразовым паролем.
+func (ah *AuthHandler) twoFactorChallenge(user *models.User) (*AuthResponse, error) {
+	challenge, err := auth.NewJWTManager(ah.jwtSecret).GenerateChallengeToken(user.ID.String(), user.Username)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка генерации токена")
+	}
+
+	response := &AuthResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    challenge,
+	}
+	response.User.ID = user.ID.String()
+	response.User.Username = user.Username
+	return response, nil
+}
+
+// LoginTwoFactor обрабатывает второй шаг входа.
+func (ah *AuthHandler) LoginTwoFactor(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	response, err := ah.VerifyTwoFactor(req, requestSessionInfo(c, req.DeviceName))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// VerifyTwoFactor проверяет одноразовый пароль или код восстановления и открывает сессию.
+func (ah *AuthHandler) VerifyTwoFactor(req TwoFactorLoginRequest, info SessionInfo) (*AuthResponse, error) {
+	if req.ChallengeToken == "" || req.Code == "" {
+		return nil, newRequestError(http.StatusBadRequest, "Токен входа и код обязательны")
+	}
+
+	claims, err := auth.NewJWTManager(ah.jwtSecret).ValidateChallengeToken(req.ChallengeToken)
+	if err != nil {
+		return nil, newRequestError(http.StatusUnauthorized, "Неверный или истекший токен входа")
+	}
+
+	user, err := ah.findUser(claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.TOTPEnabled {
+		return nil, newRequestError(http.StatusUnauthorized, "Двухфакторная аутентификация не включена")
+	}
+
+	if err := ah.checkSecondFactor(user, req.Code); err != nil {
+		ah.recordLogin(models.AuditLoginFailed, user, info, "неверный код второго фактора")
+		return nil, err
+	}
+
+	ah.recordLogin(models.AuditLogin, user, info, "")
+	return ah.openSession(user, info)
+}
+
+// EnableTwoFactor начинает подключение двухфакторной аутентификации.
+func (ah *AuthHandler) EnableTwoFactor(c *gin.Context) {
+	userID, _, ok := sessionClaims(c)
+	if !ok {
+		return
+	}
+
+	response, err := ah.BeginTwoFactor(userID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// BeginTwoFactor генерирует новый секрет одноразовых паролей.
+// Секрет начинает действовать только после подтверждения кодом из приложения.
+func (ah *AuthHandler) BeginTwoFactor(userID uuid.UUID) (*TwoFactorSetupResponse, error) {
+	user, err := ah.findUser(userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, newRequestError(http.StatusConflict, "Двухфакторная аутентификация уже включена")
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка генерации секрета")
+	}
+
+	encrypted, err := ah.keys.Wrap([]byte(secret), crypto.TOTPSecretAAD(user.ID.String()))
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка шифрования секрета")
+	}
+
+	user.TOTPSecret = encrypted
+	if err := ah.userRepo.Update(user); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка обновления пользователя")
+	}
+
+	return &TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(totpIssuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor завершает подключение двухфакторной аутентификации.
+func (ah *AuthHandler) ConfirmTwoFactor(c *gin.Context) {
+	userID, _, ok := sessionClaims(c)
+	if !ok {
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	response, err := ah.ActivateTwoFactor(userID, req.Code)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ActivateTwoFactor включает двухфакторную аутентификацию, если код из приложения верен,
+// и выдает коды восстановления.
+func (ah *AuthHandler) ActivateTwoFactor(userID uuid.UUID, code string) (*RecoveryCodesResponse, error) {
+	user, err := ah.findUser(userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		return nil, newRequestError(http.StatusConflict, "Двухфакторная аутентификация уже включена")
+	}
+	if user.TOTPSecret == "" {
+		return nil, newRequestError(http.StatusBadRequest, "Сначала получите секрет через /2fa/enable")
+	}
+
+	secret, err := ah.openTOTPSecret(user)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка расшифровки секрета")
+	}
+
+	step, ok := auth.ValidateTOTP(secret, code, time.Now(), 0)
+	if !ok {
+		return nil, newRequestError(http.StatusBadRequest, "Неверный код")
+	}
+
+	codes, err := auth.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка генерации кодов восстановления")
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, recoveryCode := range codes {
+		hashes = append(hashes, auth.HashRecoveryCode(recoveryCode))
+	}
+
+	user.TOTPEnabled = true
+	user.TOTPLastStep = step
+	user.TOTPFailures = 0
+	user.SetRecoveryCodeHashes(hashes)
+	if err := ah.userRepo.Update(user); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка обновления пользователя")
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor отключает двухфакторную аутентификацию.
+func (ah *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	userID, _, ok := sessionClaims(c)
+	if !ok {
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	if err := ah.DeactivateTwoFactor(userID, req.Code); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Data(http.StatusNoContent, "application/json", nil)
+}
+
+// DeactivateTwoFactor отключает двухфакторную аутентификацию после проверки
+// одноразового пароля или кода восстановления.
+func (ah *AuthHandler) DeactivateTwoFactor(userID uuid.UUID, code string) error {
+	user, err := ah.findUser(userID.String())
+	if err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled {
+		return newRequestError(http.StatusBadRequest, "Двухфакторная аутентификация не включена")
+	}
+
+	if err := ah.checkSecondFactor(user, code); err != nil {
+		return err
+	}
+
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	user.SetRecoveryCodeHashes(nil)
+	if err := ah.userRepo.Update(user); err != nil {
+		return newRequestError(http.StatusInternalServerError, "Ошибка обновления пользователя")
+	}
+	return nil
+}
+
+// checkSecondFactor проверяет одноразовый пароль или код восстановления и сохраняет результат:
+// принятый пароль запоминается, использованный код восстановления удаляется,
+// а неверные попытки подсчитываются. Счетчик сбрасывается только принятым кодом.
+// Код, принятый параллельным запросом, считается неверным.
+func (ah *AuthHandler) checkSecondFactor(user *models.User, code string) error {
+	now := time.Now()
+	if user.TOTPLockedUntil != nil && now.Before(*user.TOTPLockedUntil) {
+		return newRequestError(http.StatusTooManyRequests, "Слишком много неверных кодов, попробуйте позже")
+	}
+
+	ok, err := ah.acceptSecondFactor(user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ah.rejectSecondFactor(user.ID, now)
+	}
+
+	user.TOTPFailures = 0
+	user.TOTPLockedUntil = nil
+	return nil
+}
+
+// rejectSecondFactor учитывает неверный код пользователя userID. После maxTOTPFailures
+// неверных кодов подряд проверка кодов блокируется на totpLockout.
+func (ah *AuthHandler) rejectSecondFactor(userID uuid.UUID, now time.Time) error {
+	failures, err := ah.userRepo.AddTOTPFailure(userID)
+	if err != nil {
+		return newRequestError(http.StatusInternalServerError, "Ошибка обновления пользователя")
+	}
+	if failures >= maxTOTPFailures {
+		if err := ah.userRepo.LockTOTP(userID, now.Add(totpLockout)); err != nil {
+			return newRequestError(http.StatusInternalServerError, "Ошибка обновления пользователя")
+		}
+	}
+	return newRequestError(http.StatusUnauthorized, "Неверный код")
+}
+
+// acceptSecondFactor сверяет код с секретом и кодами восстановления пользователя
+// и условно сохраняет принятый код, чтобы его нельзя было использовать повторно.
+func (ah *AuthHandler) acceptSecondFactor(user *models.User, code string) (bool, error) {
+	secret, err := ah.openTOTPSecret(user)
+	if err != nil {
+		return false, newRequestError(http.StatusInternalServerError, "Ошибка расшифровки секрета")
+	}
+
+	if step, ok := auth.ValidateTOTP(secret, code, time.Now(), user.TOTPLastStep); ok {
+		if err := ah.userRepo.UseTOTPStep(user.ID, step); err != nil {
+			return ah.codeUsed(err)
+		}
+		user.TOTPLastStep = step
+		return true, nil
+	}
+
+	hash := auth.HashRecoveryCode(code)
+	hashes := user.RecoveryCodeHashes()
+	for i, stored := range hashes {
+		if stored == hash {
+			var remaining models.User
+			remaining.SetRecoveryCodeHashes(append(hashes[:i], hashes[i+1:]...))
+			if err := ah.userRepo.UseRecoveryCode(user.ID, user.RecoveryCodes, remaining.RecoveryCodes); err != nil {
+				return ah.codeUsed(err)
+			}
+			user.RecoveryCodes = remaining.RecoveryCodes
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// codeUsed обрабатывает ошибку сохранения принятого кода: код, уже использованный
+// параллельным запросом, отклоняется как неверный.
+func (ah *AuthHandler) codeUsed(err error) (bool, error) {
+	if errors.Is(err, repository.ErrCodeUsed) {
+		return false, nil
+	}
+	return false, newRequestError(http.StatusInternalServerError, "Ошибка обновления пользователя")
+}
+
+// findUser возвращает пользователя по ID из токена.
+func (ah *AuthHandler) findUser(userID string) (*models.User, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, newRequestError(http.StatusBadRequest, "Неверный ID пользователя")
+	}
+
+	user, err := ah.userRepo.GetByID(id)
+	if err != nil {
+		return nil, newRequestError(http.StatusUnauthorized, "Пользователь не найден")
+	}
+	return user, nil
+}
+
+// openTOTPSecret расшифровывает секрет одноразовых паролей пользователя.
+func (ah *AuthHandler) openTOTPSecret(user *models.User) (string, error) {
+	secret, err := ah.keys.Unwrap(user.TOTPSecret, crypto.TOTPSecretAAD(user.ID.String()))
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
@@ -0,0 +1,189 @@
+// Package handlers содержит тесты для двухфакторной аутентификации.
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// enableTwoFactor подключает 2FA и возвращает секрет и коды восстановления.
+func enableTwoFactor(t *testing.T, router *gin.Engine, token string) (string, []string) {
+	t.Helper()
+
+	w := serveWithToken(router, "POST", "/2fa/enable", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ошибка подключения 2FA: статус %d, ответ %s", w.Code, w.Body.String())
+	}
+	var setup TwoFactorSetupResponse
+	json.Unmarshal(w.Body.Bytes(), &setup)
+	if setup.Secret == "" || setup.ProvisioningURI == "" {
+		t.Fatalf("Ожидались секрет и URI, получено %+v", setup)
+	}
+
+	// Неверный код не подключает 2FA
+	if w := serveWithToken(router, "POST", "/2fa/confirm", token, TwoFactorCodeRequest{Code: "000000x"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Ожидался статус %d для неверного кода, получен %d", http.StatusBadRequest, w.Code)
+	}
+
+	code, _ := auth.TOTPCode(setup.Secret, time.Now())
+	w = serveWithToken(router, "POST", "/2fa/confirm", token, TwoFactorCodeRequest{Code: code})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ошибка подтверждения 2FA: статус %d, ответ %s", w.Code, w.Body.String())
+	}
+	var recovery RecoveryCodesResponse
+	json.Unmarshal(w.Body.Bytes(), &recovery)
+	if len(recovery.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("Ожидалось %d кодов восстановления, получено %d", recoveryCodeCount, len(recovery.RecoveryCodes))
+	}
+
+	return setup.Secret, recovery.RecoveryCodes
+}
+
+// loginPasswordStep выполняет первый шаг входа пользователя с 2FA.
+func loginPasswordStep(t *testing.T, router *gin.Engine) string {
+	t.Helper()
+
+	w := serveWithToken(router, "POST", "/login", "", LoginRequest{Username: "testuser", Password: "password123"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ошибка входа: статус %d", w.Code)
+	}
+	var response AuthResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if !response.TwoFactorRequired || response.ChallengeToken == "" || response.Token != "" {
+		t.Fatalf("Ожидался токен второго шага без токена доступа, получено %+v", response)
+	}
+	return response.ChallengeToken
+}
+
+func TestAuthHandler_TwoFactorLogin(t *testing.T) {
+	handler := setupTestAuthHandler(t)
+	router := newSessionRouter(handler)
+	login := loginTestUser(t, handler, router, "laptop")
+	_, recoveryCodes := enableTwoFactor(t, router, login.Token)
+
+	challenge := loginPasswordStep(t, router)
+
+	// Токен второго шага не дает доступа к защищенным маршрутам
+	if w := serveWithToken(router, "GET", "/sessions", challenge, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Ожидался статус %d для токена второго шага, получен %d", http.StatusUnauthorized, w.Code)
+	}
+
+	w := serveWithToken(router, "POST", "/login/2fa", "", TwoFactorLoginRequest{ChallengeToken: challenge, Code: "123456"})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Ожидался статус %d для неверного кода, получен %d", http.StatusUnauthorized, w.Code)
+	}
+
+	w = serveWithToken(router, "POST", "/login/2fa", "", TwoFactorLoginRequest{ChallengeToken: challenge, Code: recoveryCodes[0]})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d для кода восстановления, получен %d", http.StatusOK, w.Code)
+	}
+	var response AuthResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Token == "" || response.RefreshToken == "" {
+		t.Error("После второго шага должны выдаваться токены")
+	}
+
+	// Код восстановления одноразовый
+	w = serveWithToken(router, "POST", "/login/2fa", "", TwoFactorLoginRequest{ChallengeToken: challenge, Code: recoveryCodes[0]})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Ожидался статус %d для использованного кода, получен %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestAuthHandler_TwoFactorLockout(t *testing.T) {
+	handler := setupTestAuthHandler(t)
+	router := newSessionRouter(handler)
+	login := loginTestUser(t, handler, router, "laptop")
+	secret, _ := enableTwoFactor(t, router, login.Token)
+
+	// Повторный ввод пароля между неверными кодами не сбрасывает счетчик
+	for i := 0; i < maxTOTPFailures; i++ {
+		challenge := loginPasswordStep(t, router)
+		w := serveWithToken(router, "POST", "/login/2fa", "", TwoFactorLoginRequest{ChallengeToken: challenge, Code: "bad"})
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("Ожидался статус %d для неверного кода, получен %d", http.StatusUnauthorized, w.Code)
+		}
+	}
+
+	code, _ := auth.TOTPCode(secret, time.Now().Add(30*time.Second))
+	challenge := loginPasswordStep(t, router)
+	w := serveWithToken(router, "POST", "/login/2fa", "", TwoFactorLoginRequest{ChallengeToken: challenge, Code: code})
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Ожидался статус %d после серии неверных кодов, получен %d", http.StatusTooManyRequests, w.Code)
+	}
+
+	// Блокировка снимается по истечении времени
+	user, _ := handler.userRepo.GetByUsername("testuser")
+	expired := time.Now().Add(-time.Second)
+	user.TOTPLockedUntil = &expired
+	handler.userRepo.Update(user)
+
+	w = serveWithToken(router, "POST", "/login/2fa", "", TwoFactorLoginRequest{ChallengeToken: challenge, Code: code})
+	if w.Code != http.StatusOK {
+		t.Errorf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if user, _ := handler.userRepo.GetByUsername("testuser"); user.TOTPFailures != 0 || user.TOTPLockedUntil != nil {
+		t.Errorf("Принятый код должен сбрасывать счетчик и блокировку, получено %d и %v", user.TOTPFailures, user.TOTPLockedUntil)
+	}
+}
+
+func TestAuthHandler_TwoFactorDisable(t *testing.T) {
+	handler := setupTestAuthHandler(t)
+	router := newSessionRouter(handler)
+	login := loginTestUser(t, handler, router, "laptop")
+	secret, _ := enableTwoFactor(t, router, login.Token)
+
+	if w := serveWithToken(router, "POST", "/2fa/enable", login.Token, nil); w.Code != http.StatusConflict {
+		t.Errorf("Ожидался статус %d при повторном подключении, получен %d", http.StatusConflict, w.Code)
+	}
+
+	code, _ := auth.TOTPCode(secret, time.Now().Add(30*time.Second))
+	if w := serveWithToken(router, "POST", "/2fa/disable", login.Token, TwoFactorCodeRequest{Code: code}); w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+	}
+
+	w := serveWithToken(router, "POST", "/login", "", LoginRequest{Username: "testuser", Password: "password123"})
+	var response AuthResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.TwoFactorRequired || response.Token == "" {
+		t.Errorf("После отключения 2FA вход должен выполняться по паролю, получено %+v", response)
+	}
+}
+
+func TestAuthHandler_TwoFactorReplay(t *testing.T) {
+	handler := setupTestAuthHandler(t)
+	router := newSessionRouter(handler)
+	login := loginTestUser(t, handler, router, "laptop")
+	secret, recoveryCodes := enableTwoFactor(t, router, login.Token)
+
+	// Параллельные запросы читают пользователя до того, как любой из них сохранит код
+	stored, _ := handler.userRepo.GetByUsername("testuser")
+	first, second := *stored, *stored
+
+	code, _ := auth.TOTPCode(secret, time.Now().Add(30*time.Second))
+	if err := handler.checkSecondFactor(&first, code); err != nil {
+		t.Fatalf("Ожидалось, что код будет принят: %v", err)
+	}
+	if err := handler.checkSecondFactor(&second, code); err == nil {
+		t.Error("Одноразовый пароль не должен приниматься повторно")
+	}
+
+	stored, _ = handler.userRepo.GetByUsername("testuser")
+	first, second = *stored, *stored
+
+	if err := handler.checkSecondFactor(&first, recoveryCodes[0]); err != nil {
+		t.Fatalf("Ожидалось, что код восстановления будет принят: %v", err)
+	}
+	if err := handler.checkSecondFactor(&second, recoveryCodes[0]); err == nil {
+		t.Error("Код восстановления не должен приниматься повторно")
+	}
+
+	if user, _ := handler.userRepo.GetByUsername("testuser"); len(user.RecoveryCodeHashes()) != recoveryCodeCount-1 {
+		t.Errorf("Ожидалось %d кодов восстановления, получено %d", recoveryCodeCount-1, len(user.RecoveryCodeHashes()))
+	}
+}
@@ -0,0 +1,371 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// VaultHandler обрабатывает запросы для работы с командными хранилищами.
+// Роль пользователя в хранилище проверяется middleware.Authorizer.RequireVaultRole
+// до вызова обработчиков, кроме создания и списка хранилищ.
+type VaultHandler struct {
+	vaultRepo repository.VaultRepositoryInterface
+	userRepo  repository.UserRepositoryInterface
+	dataRepo  repository.DataRepositoryInterface
+}
+
+// NewVaultHandler создает новый обработчик хранилищ.
+func NewVaultHandler(repo *repository.Repository) *VaultHandler {
+	return &VaultHandler{
+		vaultRepo: repo.NewVaultRepository(),
+		userRepo:  repo.NewUserRepository(),
+		dataRepo:  repo.NewDataRepository(),
+	}
+}
+
+// CreateVaultRequest представляет запрос создания хранилища.
+type CreateVaultRequest struct {
+	Name string `json:"name"`
+}
+
+// VaultMemberRequest представляет запрос добавления участника или изменения его роли.
+type VaultMemberRequest struct {
+	Username string           `json:"username"`
+	Role     models.VaultRole `json:"role"`
+}
+
+// VaultResponse представляет хранилище вместе с ролью пользователя в нем.
+// Участники возвращаются только при запросе одного хранилища.
+type VaultResponse struct {
+	models.Vault
+	Role    models.VaultRole      `json:"role"`
+	Members []VaultMemberResponse `json:"members,omitempty"`
+}
+
+// VaultMemberResponse представляет участника хранилища вместе с его именем пользователя.
+type VaultMemberResponse struct {
+	models.VaultMember
+	Username string `json:"username"`
+}
+
+// CreateVault создает хранилище, владельцем которого становится пользователь.
+func (vh *VaultHandler) CreateVault(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateVaultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	vault, err := vh.Create(userUUID, req.Name)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, vault)
+}
+
+// Create создает хранилище name с владельцем userID.
+func (vh *VaultHandler) Create(userID uuid.UUID, name string) (*VaultResponse, error) {
+	if name == "" {
+		return nil, newRequestError(http.StatusBadRequest, "Название хранилища обязательно")
+	}
+
+	vault := &models.Vault{Name: name}
+	owner := &models.VaultMember{UserID: userID, Role: models.VaultRoleOwner}
+	if err := vh.vaultRepo.Create(vault, owner); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка создания хранилища")
+	}
+
+	return &VaultResponse{Vault: *vault, Role: models.VaultRoleOwner}, nil
+}
+
+// GetVaults возвращает хранилища, в которых участвует пользователь.
+func (vh *VaultHandler) GetVaults(c *gin.Context) {
+	userUUID, ok := vaultUserID(c)
+	if !ok {
+		return
+	}
+
+	vaults, err := vh.ListVaults(userUUID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, vaults)
+}
+
+// ListVaults возвращает хранилища пользователя userID вместе с его ролями.
+func (vh *VaultHandler) ListVaults(userID uuid.UUID) ([]VaultResponse, error) {
+	memberships, err := vh.vaultRepo.GetMemberships(userID)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения хранилищ")
+	}
+
+	result := make([]VaultResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		vault, err := vh.vaultRepo.GetByID(membership.VaultID)
+		if err != nil {
+			continue
+		}
+		result = append(result, VaultResponse{Vault: *vault, Role: membership.Role})
+	}
+	return result, nil
+}
+
+// GetVault возвращает хранилище вместе с его участниками.
+func (vh *VaultHandler) GetVault(c *gin.Context) {
+	vaultID, role, ok := vaultParams(c)
+	if !ok {
+		return
+	}
+
+	vault, err := vh.vaultRepo.GetByID(vaultID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Хранилище не найдено"})
+		return
+	}
+
+	members, err := vh.members(vaultID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, VaultResponse{Vault: *vault, Role: role, Members: members})
+}
+
+// DeleteVault удаляет пустое хранилище. Записи хранилища нужно предварительно удалить.
+func (vh *VaultHandler) DeleteVault(c *gin.Context) {
+	vaultID, _, ok := vaultParams(c)
+	if !ok {
+		return
+	}
+
+	data, err := vh.dataRepo.GetByVaultID(vaultID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения данных хранилища"})
+		return
+	}
+	if len(data) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "В хранилище есть записи, удалите их перед удалением хранилища"})
+		return
+	}
+
+	if err := vh.vaultRepo.Delete(vaultID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка удаления хранилища"})
+		return
+	}
+
+	c.Data(http.StatusNoContent, "application/json", nil)
+}
+
+// GetVaultData возвращает записи хранилища без секретного содержимого.
+func (vh *VaultHandler) GetVaultData(c *gin.Context) {
+	vaultID, _, ok := vaultParams(c)
+	if !ok {
+		return
+	}
+
+	data, err := vh.dataRepo.GetByVaultID(vaultID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения данных хранилища"})
+		return
+	}
+	if data == nil {
+		data = []models.Data{}
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+// PutVaultMember добавляет участника хранилища или изменяет его роль.
+func (vh *VaultHandler) PutVaultMember(c *gin.Context) {
+	vaultID, role, ok := vaultParams(c)
+	if !ok {
+		return
+	}
+
+	var req VaultMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат JSON"})
+		return
+	}
+
+	member, err := vh.SetMember(vaultID, role, &req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// SetMember назначает пользователю из запроса роль в хранилище от имени участника
+// с ролью callerRole. Назначать и изменять владельцев может только владелец,
+// последнего владельца нельзя понизить.
+func (vh *VaultHandler) SetMember(vaultID uuid.UUID, callerRole models.VaultRole, req *VaultMemberRequest) (*VaultMemberResponse, error) {
+	if !req.Role.IsValid() {
+		return nil, newRequestError(http.StatusBadRequest, "Роль должна быть owner, admin, editor или viewer")
+	}
+
+	user, err := vh.userRepo.GetByUsername(req.Username)
+	if err != nil {
+		return nil, newRequestError(http.StatusNotFound, "Пользователь не найден")
+	}
+
+	current, err := vh.vaultRepo.GetMember(vaultID, user.ID)
+	if err == nil && current.Role == req.Role {
+		return &VaultMemberResponse{VaultMember: *current, Username: user.Username}, nil
+	}
+	changesOwner := req.Role == models.VaultRoleOwner || (err == nil && current.Role == models.VaultRoleOwner)
+	if changesOwner && callerRole != models.VaultRoleOwner {
+		return nil, newRequestError(http.StatusForbidden, "Назначать и изменять владельцев может только владелец")
+	}
+	if err == nil && current.Role == models.VaultRoleOwner {
+		if err := vh.keepOwner(vaultID); err != nil {
+			return nil, err
+		}
+	}
+
+	member := &models.VaultMember{VaultID: vaultID, UserID: user.ID, Role: req.Role}
+	if err := vh.vaultRepo.SaveMember(member); err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка сохранения участника")
+	}
+
+	return &VaultMemberResponse{VaultMember: *member, Username: user.Username}, nil
+}
+
+// DeleteVaultMember исключает участника из хранилища.
+func (vh *VaultHandler) DeleteVaultMember(c *gin.Context) {
+	vaultID, role, ok := vaultParams(c)
+	if !ok {
+		return
+	}
+
+	userUUID, _ := vaultUserID(c)
+	memberID, err := uuid.Parse(c.Param("user"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+		return
+	}
+
+	if err := vh.RemoveMember(vaultID, userUUID, role, memberID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Data(http.StatusNoContent, "application/json", nil)
+}
+
+// RemoveMember исключает memberID из хранилища от имени участника callerID с ролью
+// callerRole. Любой участник может выйти из хранилища сам, исключать других может
+// администратор, а владельцев - только владелец. Последний владелец не может уйти.
+func (vh *VaultHandler) RemoveMember(vaultID, callerID uuid.UUID, callerRole models.VaultRole, memberID uuid.UUID) error {
+	member, err := vh.vaultRepo.GetMember(vaultID, memberID)
+	if err != nil {
+		return newRequestError(http.StatusNotFound, "Участник не найден")
+	}
+
+	if memberID != callerID {
+		if !callerRole.Allows(models.VaultRoleAdmin) {
+			return newRequestError(http.StatusForbidden, "Недостаточно прав в хранилище")
+		}
+		if member.Role == models.VaultRoleOwner && callerRole != models.VaultRoleOwner {
+			return newRequestError(http.StatusForbidden, "Назначать и изменять владельцев может только владелец")
+		}
+	}
+	if member.Role == models.VaultRoleOwner {
+		if err := vh.keepOwner(vaultID); err != nil {
+			return err
+		}
+	}
+
+	if err := vh.vaultRepo.DeleteMember(vaultID, memberID); err != nil {
+		return newRequestError(http.StatusInternalServerError, "Ошибка исключения участника")
+	}
+	return nil
+}
+
+// keepOwner проверяет, что после понижения или ухода одного из владельцев
+// у хранилища останется хотя бы один владелец.
+func (vh *VaultHandler) keepOwner(vaultID uuid.UUID) error {
+	members, err := vh.vaultRepo.GetMembers(vaultID)
+	if err != nil {
+		return newRequestError(http.StatusInternalServerError, "Ошибка получения участников")
+	}
+
+	owners := 0
+	for _, member := range members {
+		if member.Role == models.VaultRoleOwner {
+			owners++
+		}
+	}
+	if owners <= 1 {
+		return newRequestError(http.StatusConflict, "У хранилища должен остаться хотя бы один владелец")
+	}
+	return nil
+}
+
+// members возвращает участников хранилища вместе с их именами пользователей.
+func (vh *VaultHandler) members(vaultID uuid.UUID) ([]VaultMemberResponse, error) {
+	members, err := vh.vaultRepo.GetMembers(vaultID)
+	if err != nil {
+		return nil, newRequestError(http.StatusInternalServerError, "Ошибка получения участников")
+	}
+
+	result := make([]VaultMemberResponse, 0, len(members))
+	for _, member := range members {
+		resp := VaultMemberResponse{VaultMember: member}
+		if user, err := vh.userRepo.GetByID(member.UserID); err == nil {
+			resp.Username = user.Username
+		}
+		result = append(result, resp)
+	}
+	return result, nil
+}
+
+// vaultUserID извлекает ID пользователя из контекста. При ошибке отправляет ответ и возвращает false.
+func vaultUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+		return uuid.Nil, false
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
+
+// vaultParams извлекает ID хранилища и роль пользователя, проверенную middleware.
+// При ошибке отправляет ответ и возвращает false.
+func vaultParams(c *gin.Context) (uuid.UUID, models.VaultRole, bool) {
+	vaultID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID хранилища"})
+		return uuid.Nil, "", false
+	}
+
+	role, ok := middleware.GetVaultRole(c)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Недостаточно прав в хранилище"})
+		return uuid.Nil, "", false
+	}
+	return vaultID, role, true
+}
@@ -0,0 +1,200 @@
+// Package handlers содержит тесты для командных хранилищ.
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// newVaultRouter создает роутер с обработчиками хранилищ и данных для пользователя userID.
+func newVaultRouter(vh *VaultHandler, dh *DataHandler, userID uuid.UUID) *gin.Engine {
+	authz := dh.authz
+	router := newShareRouter(dh, userID)
+	router.POST("/vaults", vh.CreateVault)
+	router.GET("/vaults", vh.GetVaults)
+	router.GET("/vaults/:id", authz.RequireVaultRole(models.VaultRoleViewer), vh.GetVault)
+	router.DELETE("/vaults/:id", authz.RequireVaultRole(models.VaultRoleOwner), vh.DeleteVault)
+	router.GET("/vaults/:id/data", authz.RequireVaultRole(models.VaultRoleViewer), vh.GetVaultData)
+	router.PUT("/vaults/:id/members", authz.RequireVaultRole(models.VaultRoleAdmin), vh.PutVaultMember)
+	router.DELETE("/vaults/:id/members/:user", authz.RequireVaultRole(models.VaultRoleViewer), vh.DeleteVaultMember)
+	return router
+}
+
+// setupTestVaultHandler создает обработчик хранилищ на том же репозитории, что и обработчик данных.
+func setupTestVaultHandler(t *testing.T) (*VaultHandler, *DataHandler, uuid.UUID, *models.User) {
+	dh, memRepo, ownerID := setupTestDataHandler(t)
+	vaultRepo := memRepo.NewVaultRepository()
+	dh.authz = middleware.NewAuthorizer(vaultRepo)
+
+	member := &models.User{ID: uuid.New(), Username: "member", Email: "member@example.com"}
+	dh.userRepo.Create(member)
+
+	vh := &VaultHandler{
+		vaultRepo: vaultRepo,
+		userRepo:  dh.userRepo,
+		dataRepo:  dh.dataRepo,
+	}
+	return vh, dh, ownerID, member
+}
+
+func TestVaultHandler_Roles(t *testing.T) {
+	vh, dh, ownerID, member := setupTestVaultHandler(t)
+	owner := newVaultRouter(vh, dh, ownerID)
+	guest := newVaultRouter(vh, dh, member.ID)
+
+	w := serveJSON(owner, "POST", "/vaults", "laptop", CreateVaultRequest{Name: "Team"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusCreated, w.Code)
+	}
+	var vault VaultResponse
+	json.Unmarshal(w.Body.Bytes(), &vault)
+	vaultPath := "/vaults/" + vault.ID.String()
+
+	w = serveJSON(owner, "POST", "/data", "laptop", CreateDataRequest{VaultID: &vault.ID, Name: "DB", Login: "root", Password: "secret"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created models.Data
+	json.Unmarshal(w.Body.Bytes(), &created)
+	dataPath := "/data/" + created.ID.String()
+
+	// Записи хранилища не попадают в список личных записей
+	w = serveJSON(owner, "GET", "/data", "laptop", nil)
+	var personal []models.Data
+	json.Unmarshal(w.Body.Bytes(), &personal)
+	if len(personal) != 0 {
+		t.Errorf("Запись хранилища не должна быть в личном списке, получено %d", len(personal))
+	}
+
+	if w := serveJSON(guest, "GET", dataPath, "phone", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("Не участнику ожидался статус %d, получен %d", http.StatusNotFound, w.Code)
+	}
+
+	w = serveJSON(owner, "PUT", vaultPath+"/members", "laptop", VaultMemberRequest{Username: "member", Role: models.VaultRoleViewer})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w = serveJSON(guest, "GET", dataPath, "phone", nil)
+	var resp DataResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Password != "secret" {
+		t.Fatalf("Участник должен читать запись хранилища, статус %d, получено %+v", w.Code, resp)
+	}
+
+	version := created.Version
+	if w := serveJSON(guest, "PUT", dataPath, "phone", UpdateDataRequest{Version: &version, Password: "changed"}); w.Code != http.StatusForbidden {
+		t.Errorf("Читателю ожидался статус %d, получен %d", http.StatusForbidden, w.Code)
+	}
+	if w := serveJSON(guest, "PUT", vaultPath+"/members", "phone", VaultMemberRequest{Username: "member", Role: models.VaultRoleOwner}); w.Code != http.StatusForbidden {
+		t.Errorf("Читатель не должен управлять участниками, получен статус %d", w.Code)
+	}
+
+	serveJSON(owner, "PUT", vaultPath+"/members", "laptop", VaultMemberRequest{Username: "member", Role: models.VaultRoleEditor})
+	if w := serveJSON(guest, "PUT", dataPath, "phone", UpdateDataRequest{Version: &version, Password: "changed"}); w.Code != http.StatusOK {
+		t.Fatalf("Редактору ожидался статус %d, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w = serveJSON(guest, "GET", vaultPath+"/data", "phone", nil)
+	var vaultData []models.Data
+	json.Unmarshal(w.Body.Bytes(), &vaultData)
+	if len(vaultData) != 1 || vaultData[0].ID != created.ID {
+		t.Errorf("Ожидалась одна запись хранилища, получено %+v", vaultData)
+	}
+
+	// Последний владелец не может покинуть хранилище, а непустое хранилище нельзя удалить
+	if w := serveJSON(owner, "DELETE", vaultPath+"/members/"+ownerID.String(), "laptop", nil); w.Code != http.StatusConflict {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusConflict, w.Code)
+	}
+	if w := serveJSON(owner, "DELETE", vaultPath, "laptop", nil); w.Code != http.StatusConflict {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusConflict, w.Code)
+	}
+
+	// Участник может выйти сам и теряет доступ к записям
+	if w := serveJSON(guest, "DELETE", vaultPath+"/members/"+member.ID.String(), "phone", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusNoContent, w.Code)
+	}
+	if w := serveJSON(guest, "GET", dataPath, "phone", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Бывшему участнику ожидался статус %d, получен %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestVaultHandler_HistoryAndTrashRoles(t *testing.T) {
+	vh, dh, ownerID, member := setupTestVaultHandler(t)
+	owner := newVaultRouter(vh, dh, ownerID)
+	guest := newVaultRouter(vh, dh, member.ID)
+	for _, router := range []*gin.Engine{owner, guest} {
+		router.GET("/trash", dh.GetTrash)
+		router.POST("/trash/:id/restore", dh.RestoreTrash)
+		router.DELETE("/trash/:id", dh.PurgeTrash)
+	}
+
+	var vault VaultResponse
+	json.Unmarshal(serveJSON(owner, "POST", "/vaults", "laptop", CreateVaultRequest{Name: "Team"}).Body.Bytes(), &vault)
+	vaultPath := "/vaults/" + vault.ID.String()
+	serveJSON(owner, "PUT", vaultPath+"/members", "laptop", VaultMemberRequest{Username: "member", Role: models.VaultRoleEditor})
+
+	// Запись создает участник, но ее историю видят все участники хранилища
+	var created models.Data
+	json.Unmarshal(serveJSON(guest, "POST", "/data", "phone", CreateDataRequest{VaultID: &vault.ID, Name: "DB", Login: "root", Password: "first"}).Body.Bytes(), &created)
+	dataPath := "/data/" + created.ID.String()
+	serveJSON(guest, "PUT", dataPath, "phone", UpdateDataRequest{Version: &created.Version, Password: "second"})
+
+	w := serveJSON(owner, "GET", dataPath+"/history", "laptop", nil)
+	var history []models.DataRevision
+	json.Unmarshal(w.Body.Bytes(), &history)
+	if w.Code != http.StatusOK || len(history) != 2 {
+		t.Fatalf("Владелец хранилища должен видеть историю записи участника, статус %d, получено %d ревизий", w.Code, len(history))
+	}
+	revisionPath := dataPath + "/history/" + history[1].ID.String()
+
+	// Читатель видит историю, но не может откатить запись и управлять корзиной
+	serveJSON(owner, "PUT", vaultPath+"/members", "laptop", VaultMemberRequest{Username: "member", Role: models.VaultRoleViewer})
+	if w := serveJSON(guest, "GET", revisionPath, "phone", nil); w.Code != http.StatusOK {
+		t.Errorf("Читателю ожидался статус %d для ревизии, получен %d", http.StatusOK, w.Code)
+	}
+	if w := serveJSON(guest, "POST", revisionPath+"/restore", "phone", nil); w.Code != http.StatusForbidden {
+		t.Errorf("Читателю ожидался статус %d для отката, получен %d", http.StatusForbidden, w.Code)
+	}
+
+	if w := serveJSON(owner, "DELETE", dataPath, "laptop", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("Ожидался статус %d, получен %d", http.StatusNoContent, w.Code)
+	}
+	trashPath := "/trash/" + created.ID.String()
+	if names := listNames(t, guest, "/trash"); len(names) != 1 {
+		t.Errorf("Участник должен видеть корзину хранилища, получено %v", names)
+	}
+	if w := serveJSON(guest, "POST", trashPath+"/restore", "phone", nil); w.Code != http.StatusForbidden {
+		t.Errorf("Читателю ожидался статус %d для восстановления, получен %d", http.StatusForbidden, w.Code)
+	}
+	if w := serveJSON(guest, "DELETE", trashPath, "phone", nil); w.Code != http.StatusForbidden {
+		t.Errorf("Читателю ожидался статус %d для удаления, получен %d", http.StatusForbidden, w.Code)
+	}
+
+	// Бывший участник теряет доступ к истории и корзине, хотя создал запись
+	serveJSON(owner, "DELETE", vaultPath+"/members/"+member.ID.String(), "laptop", nil)
+	for _, req := range []struct{ method, path string }{
+		{"GET", dataPath + "/history"},
+		{"GET", revisionPath},
+		{"POST", revisionPath + "/restore"},
+		{"POST", trashPath + "/restore"},
+		{"DELETE", trashPath},
+	} {
+		if w := serveJSON(guest, req.method, req.path, "phone", nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s %s: бывшему участнику ожидался статус %d, получен %d", req.method, req.path, http.StatusNotFound, w.Code)
+		}
+	}
+	if names := listNames(t, guest, "/trash"); len(names) != 0 {
+		t.Errorf("Бывший участник не должен видеть корзину хранилища, получено %v", names)
+	}
+
+	if w := serveJSON(owner, "POST", trashPath+"/restore", "laptop", nil); w.Code != http.StatusOK {
+		t.Errorf("Владельцу ожидался статус %d для восстановления, получен %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+}
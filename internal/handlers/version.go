@@ -0,0 +1,53 @@
+// Package handlers содержит HTTP обработчики.
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ConflictResponse возвращается при несовпадении версии записи
+// и содержит ее текущую копию на сервере.
+type ConflictResponse struct {
+	Error   string        `json:"error"`
+	Current *DataResponse `json:"current"`
+}
+
+// formatETag формирует значение ETag для версии записи.
+func formatETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseETag извлекает версию записи из значения If-Match.
+func parseETag(value string) (int64, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+	return strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+}
+
+// ifMatchVersion возвращает версию записи из заголовка If-Match или nil, если заголовок не передан.
+func ifMatchVersion(c *gin.Context) (*int64, error) {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		return nil, nil
+	}
+
+	version, err := parseETag(ifMatch)
+	if err != nil {
+		return nil, errors.New("неверный формат заголовка If-Match")
+	}
+	return &version, nil
+}
+
+// conflictError возвращает ошибку конфликта версий с текущей копией записи на сервере.
+func (dh *DataHandler) conflictError(current *models.Data) error {
+	resp, err := dh.openPayload(current, dh.dataKeys.ForRecord(current))
+	if err != nil {
+		return newRequestError(http.StatusInternalServerError, "Ошибка расшифровки данных")
+	}
+	return &ConflictError{Current: resp}
+}
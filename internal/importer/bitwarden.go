@@ -0,0 +1,137 @@
+// Package importer разбирает экспорты других менеджеров паролей в записи GophKeeper.
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Типы записей Bitwarden.
+const (
+	bitwardenLogin      = 1
+	bitwardenSecureNote = 2
+	bitwardenCard       = 3
+	bitwardenIdentity   = 4
+)
+
+// bitwardenExport представляет JSON экспорт Bitwarden.
+// Экспорт организации вместо папок содержит коллекции.
+type bitwardenExport struct {
+	Encrypted   bool              `json:"encrypted"`
+	Folders     []bitwardenFolder `json:"folders"`
+	Collections []bitwardenFolder `json:"collections"`
+	Items       []bitwardenItem   `json:"items"`
+}
+
+// bitwardenFolder представляет папку или коллекцию Bitwarden.
+// Вложенные папки задаются именем с разделителем /.
+type bitwardenFolder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// bitwardenItem представляет запись Bitwarden.
+type bitwardenItem struct {
+	Type          int      `json:"type"`
+	Name          string   `json:"name"`
+	Notes         string   `json:"notes"`
+	Favorite      bool     `json:"favorite"`
+	FolderID      string   `json:"folderId"`
+	CollectionIDs []string `json:"collectionIds"`
+	Fields        []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"fields"`
+	Login *struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		TOTP     string `json:"totp"`
+		URIs     []struct {
+			URI string `json:"uri"`
+		} `json:"uris"`
+	} `json:"login"`
+	Card *struct {
+		CardholderName string `json:"cardholderName"`
+		Brand          string `json:"brand"`
+		Number         string `json:"number"`
+		ExpMonth       string `json:"expMonth"`
+		ExpYear        string `json:"expYear"`
+		Code           string `json:"code"`
+	} `json:"card"`
+	Identity map[string]interface{} `json:"identity"`
+}
+
+// bitwardenIdentityFields задает порядок полей личных данных в текстовой заметке.
+var bitwardenIdentityFields = []string{
+	"title", "firstName", "middleName", "lastName", "company", "email", "phone",
+	"address1", "address2", "address3", "city", "state", "postalCode", "country",
+	"ssn", "passportNumber", "licenseNumber", "username",
+}
+
+// parseBitwarden разбирает JSON экспорт Bitwarden.
+func parseBitwarden(data []byte) ([]Item, error) {
+	var export bitwardenExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("ошибка чтения экспорта Bitwarden: %w", err)
+	}
+	if export.Encrypted {
+		return nil, errors.New("зашифрованный экспорт Bitwarden не поддерживается, выполните экспорт в формате JSON без шифрования")
+	}
+
+	folders := make(map[string]string, len(export.Folders)+len(export.Collections))
+	for _, folder := range append(export.Folders, export.Collections...) {
+		folders[folder.ID] = folder.Name
+	}
+
+	items := make([]Item, 0, len(export.Items))
+	for _, entry := range export.Items {
+		metadata := make(map[string]string)
+		for _, field := range entry.Fields {
+			setMeta(metadata, field.Name, field.Value)
+		}
+
+		var item Item
+		switch {
+		case entry.Type == bitwardenLogin && entry.Login != nil:
+			setMeta(metadata, MetaNotes, entry.Notes)
+			setMeta(metadata, MetaTOTP, entry.Login.TOTP)
+			if len(entry.Login.URIs) > 0 {
+				setMeta(metadata, MetaURL, entry.Login.URIs[0].URI)
+			}
+			item = newLogin(entry.Name, entry.Login.Username, entry.Login.Password, metadata)
+		case entry.Type == bitwardenCard && entry.Card != nil:
+			setMeta(metadata, MetaNotes, entry.Notes)
+			setMeta(metadata, "brand", entry.Card.Brand)
+			item = newCard(entry.Name, entry.Card.Number, entry.Card.ExpMonth, entry.Card.ExpYear,
+				entry.Card.Code, entry.Card.CardholderName, metadata)
+		case entry.Type == bitwardenIdentity:
+			item = newNote(entry.Name, identityText(entry.Identity, entry.Notes), metadata)
+		default:
+			item = newNote(entry.Name, entry.Notes, metadata)
+		}
+
+		item.Favorite = entry.Favorite
+		item.Folder = folders[entry.FolderID]
+		if item.Folder == "" && len(entry.CollectionIDs) > 0 {
+			item.Folder = folders[entry.CollectionIDs[0]]
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// identityText собирает текст заметки из личных данных Bitwarden.
+func identityText(identity map[string]interface{}, notes string) string {
+	var lines []string
+	for _, field := range bitwardenIdentityFields {
+		if value, ok := identity[field].(string); ok && value != "" {
+			lines = append(lines, field+": "+value)
+		}
+	}
+	if notes != "" {
+		lines = append(lines, "", notes)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,27 @@
+// Package importer разбирает экспорты других менеджеров паролей в записи GophKeeper.
+package importer
+
+import "errors"
+
+// parseBrowserCSV разбирает CSV экспорт паролей Chrome (name, url, username, password, note)
+// или Firefox (url, username, password и служебные столбцы). В экспорте Firefox нет
+// названий, и записи называются по имени сайта.
+func parseBrowserCSV(data []byte) ([]Item, error) {
+	table, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	if !table.has("url") || !table.has("password") {
+		return nil, errors.New("в CSV экспорте браузера нет столбцов url и password")
+	}
+
+	items := make([]Item, 0, len(table.rows))
+	for _, row := range table.rows {
+		metadata := make(map[string]string)
+		setMeta(metadata, MetaURL, table.value(row, "url"))
+		setMeta(metadata, MetaNotes, table.value(row, "note"))
+
+		items = append(items, newLogin(table.value(row, "name"), table.value(row, "username"), table.value(row, "password"), metadata))
+	}
+	return items, nil
+}
@@ -0,0 +1,201 @@
+// Package importer разбирает экспорты других менеджеров паролей в записи GophKeeper.
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+)
+
+// Format задает формат файла экспорта.
+type Format string
+
+// Поддерживаемые форматы экспорта.
+const (
+	FormatBitwarden   Format = "bitwarden" // JSON экспорт Bitwarden без шифрования
+	FormatOnePassword Format = "1password" // Архив 1PUX или CSV экспорт 1Password
+	FormatKeePass     Format = "keepass"   // XML экспорт KeePass 2
+	FormatChrome      Format = "chrome"    // CSV экспорт паролей Chrome
+	FormatFirefox     Format = "firefox"   // CSV экспорт паролей Firefox
+)
+
+// Formats перечисляет поддерживаемые форматы.
+var Formats = []Format{FormatBitwarden, FormatOnePassword, FormatKeePass, FormatChrome, FormatFirefox}
+
+// Ключи метаданных, которые заполняют парсеры.
+const (
+	MetaURL   = "url"
+	MetaNotes = "notes"
+	MetaTOTP  = "totp"
+)
+
+// Item представляет запись, прочитанную из экспорта.
+// Metadata содержит адрес сайта, заметки и дополнительные поля записи,
+// Folder - путь папки вида Work/Servers, пустой для корня.
+type Item struct {
+	Type     models.DataType
+	Name     string
+	Login    string
+	Password string
+	Text     string
+	Card     *models.BankCard
+	Metadata map[string]string
+	Folder   string
+	Tags     []string
+	Favorite bool
+}
+
+// Parse разбирает содержимое файла экспорта в формате format.
+func Parse(format Format, data []byte) ([]Item, error) {
+	switch format {
+	case FormatBitwarden:
+		return parseBitwarden(data)
+	case FormatOnePassword:
+		if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+			return parseOnePUX(data)
+		}
+		return parseOnePasswordCSV(data)
+	case FormatKeePass:
+		return parseKeePass(data)
+	case FormatChrome, FormatFirefox:
+		return parseBrowserCSV(data)
+	}
+	return nil, fmt.Errorf("неизвестный формат %q", format)
+}
+
+// newLogin собирает запись с логином и паролем. Запись без логина сохраняется
+// текстовой заметкой, потому что логин обязателен для записей login_password.
+func newLogin(name, login, password string, metadata map[string]string) Item {
+	if name == "" {
+		name = siteName(metadata[MetaURL])
+	}
+	if login != "" {
+		return Item{Type: models.DataTypeLoginPassword, Name: name, Login: login, Password: password, Metadata: metadata}
+	}
+
+	notes := metadata[MetaNotes]
+	delete(metadata, MetaNotes)
+	text := notes
+	if password != "" {
+		text = strings.TrimSpace("Пароль: " + password + "\n\n" + notes)
+	}
+	return Item{Type: models.DataTypeText, Name: name, Text: text, Metadata: metadata}
+}
+
+// newNote собирает текстовую заметку.
+func newNote(name, text string, metadata map[string]string) Item {
+	if name == "" {
+		name = "Без названия"
+	}
+	return Item{Type: models.DataTypeText, Name: name, Text: text, Metadata: metadata}
+}
+
+// newCard собирает банковскую карту. Срок действия приводится к формату MM/YY.
+func newCard(name, number, month, year, cvv, holder string, metadata map[string]string) Item {
+	if name == "" {
+		name = "Карта"
+	}
+	card := &models.BankCard{
+		Number: strings.ReplaceAll(number, " ", ""),
+		Expiry: cardExpiry(month, year),
+		CVV:    cvv,
+		Holder: holder,
+	}
+	return Item{Type: models.DataTypeBankCard, Name: name, Card: card, Metadata: metadata}
+}
+
+// cardExpiry возвращает срок действия карты в формате MM/YY
+// или пустую строку, если месяц или год не указаны.
+func cardExpiry(month, year string) string {
+	month, year = strings.TrimSpace(month), strings.TrimSpace(year)
+	if month == "" || year == "" {
+		return ""
+	}
+	if len(month) == 1 {
+		month = "0" + month
+	}
+	if len(year) > 2 {
+		year = year[len(year)-2:]
+	}
+	return month + "/" + year
+}
+
+// siteName возвращает имя хоста адреса для записи без названия.
+func siteName(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Hostname() != "" {
+		return strings.TrimPrefix(u.Hostname(), "www.")
+	}
+	if rawURL != "" {
+		return rawURL
+	}
+	return "Без названия"
+}
+
+// setMeta добавляет непустое значение в метаданные.
+func setMeta(metadata map[string]string, key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		metadata[key] = value
+	}
+}
+
+// splitTags разбивает строку меток, разделенных запятыми или точками с запятой.
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// csvTable представляет CSV файл с заголовком.
+type csvTable struct {
+	columns map[string]int
+	rows    [][]string
+}
+
+// readCSV читает CSV файл, первая строка которого содержит имена столбцов.
+// Имена столбцов сравниваются без учета регистра.
+func readCSV(data []byte) (*csvTable, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("файл CSV пуст")
+	}
+
+	table := &csvTable{columns: make(map[string]int), rows: records[1:]}
+	for i, name := range records[0] {
+		table.columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	return table, nil
+}
+
+// value возвращает значение первого из столбцов names, который есть в файле.
+func (t *csvTable) value(row []string, names ...string) string {
+	for _, name := range names {
+		if i, ok := t.columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+	}
+	return ""
+}
+
+// has проверяет, что в файле есть хотя бы один из столбцов names.
+func (t *csvTable) has(names ...string) bool {
+	for _, name := range names {
+		if _, ok := t.columns[name]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+// Package importer содержит тесты для разбора экспортов.
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+)
+
+func TestParse_Bitwarden(t *testing.T) {
+	data := []byte(`{
+		"encrypted": false,
+		"folders": [{"id": "f1", "name": "Work/Mail"}],
+		"items": [
+			{"type": 1, "name": "Gmail", "favorite": true, "folderId": "f1", "notes": "личная почта",
+			 "fields": [{"name": "Секретный вопрос", "value": "кот"}],
+			 "login": {"username": "user@gmail.com", "password": "secret", "totp": "JBSWY3DP", "uris": [{"uri": "https://mail.google.com"}]}},
+			{"type": 2, "name": "Wi-Fi", "notes": "пароль от сети"},
+			{"type": 3, "name": "Visa", "card": {"cardholderName": "IVAN IVANOV", "number": "4111 1111 1111 1111", "expMonth": "3", "expYear": "2029", "code": "123"}},
+			{"type": 4, "name": "Паспорт", "identity": {"firstName": "Иван", "lastName": "Иванов"}}
+		]
+	}`)
+
+	items, err := Parse(FormatBitwarden, data)
+	if err != nil {
+		t.Fatalf("Ошибка разбора: %v", err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("Ожидалось 4 записи, получено %d", len(items))
+	}
+
+	login := items[0]
+	if login.Type != models.DataTypeLoginPassword || login.Login != "user@gmail.com" || login.Password != "secret" ||
+		login.Folder != "Work/Mail" || !login.Favorite {
+		t.Errorf("Неверно разобран логин: %+v", login)
+	}
+	if login.Metadata[MetaURL] != "https://mail.google.com" || login.Metadata[MetaTOTP] != "JBSWY3DP" ||
+		login.Metadata[MetaNotes] != "личная почта" || login.Metadata["Секретный вопрос"] != "кот" {
+		t.Errorf("Неверные метаданные: %v", login.Metadata)
+	}
+	if items[1].Type != models.DataTypeText || items[1].Text != "пароль от сети" {
+		t.Errorf("Неверно разобрана заметка: %+v", items[1])
+	}
+	if card := items[2].Card; card == nil || card.Number != "4111111111111111" || card.Expiry != "03/29" || card.CVV != "123" {
+		t.Errorf("Неверно разобрана карта: %+v", items[2].Card)
+	}
+	if items[3].Type != models.DataTypeText || items[3].Text != "firstName: Иван\nlastName: Иванов" {
+		t.Errorf("Неверно разобраны личные данные: %q", items[3].Text)
+	}
+
+	if _, err := Parse(FormatBitwarden, []byte(`{"encrypted": true}`)); err == nil {
+		t.Error("Ожидалась ошибка для зашифрованного экспорта")
+	}
+}
+
+func TestParse_OnePUX(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	file, _ := archive.Create("export.data")
+	file.Write([]byte(`{"accounts": [{"vaults": [{"attrs": {"name": "Personal"}, "items": [
+		{"favIndex": 1, "state": "active", "categoryUuid": "001",
+		 "overview": {"title": "GitHub", "url": "https://github.com", "tags": ["dev"]},
+		 "details": {"loginFields": [{"designation": "username", "value": "octocat"}, {"designation": "password", "value": "secret"}],
+		  "sections": [{"fields": [{"title": "PIN", "id": "pin", "value": {"concealed": "1234"}}]}]}},
+		{"state": "active", "categoryUuid": "002", "overview": {"title": "Visa"},
+		 "details": {"sections": [{"fields": [
+		  {"id": "ccnum", "value": {"creditCardNumber": "4111111111111111"}},
+		  {"id": "cvv", "value": {"concealed": "123"}},
+		  {"id": "expiry", "value": {"monthYear": 202907}},
+		  {"id": "cardholder", "value": {"string": "IVAN IVANOV"}}]}]}},
+		{"state": "archived", "categoryUuid": "003", "overview": {"title": "Старое"}, "details": {"notesPlain": "архив"}}
+	]}]}]}`))
+	archive.Close()
+
+	items, err := Parse(FormatOnePassword, buf.Bytes())
+	if err != nil {
+		t.Fatalf("Ошибка разбора: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Ожидалось 2 записи без архивной, получено %d", len(items))
+	}
+	if login := items[0]; login.Login != "octocat" || login.Password != "secret" || login.Folder != "Personal" ||
+		!login.Favorite || !slices.Equal(login.Tags, []string{"dev"}) || login.Metadata["PIN"] != "1234" {
+		t.Errorf("Неверно разобран логин: %+v", login)
+	}
+	if card := items[1].Card; card == nil || card.Expiry != "07/29" || card.CVV != "123" || card.Holder != "IVAN IVANOV" {
+		t.Errorf("Неверно разобрана карта: %+v", items[1].Card)
+	}
+}
+
+func TestParse_OnePasswordCSV(t *testing.T) {
+	data := []byte("Title,Url,Username,Password,OTPAuth,Favorite,Archived,Tags,Notes\n" +
+		"GitHub,https://github.com,octocat,secret,,true,false,dev;work,\n" +
+		"Old,,old,old,,false,true,,\n")
+
+	items, err := Parse(FormatOnePassword, data)
+	if err != nil {
+		t.Fatalf("Ошибка разбора: %v", err)
+	}
+	if len(items) != 1 || items[0].Login != "octocat" || !items[0].Favorite || !slices.Equal(items[0].Tags, []string{"dev", "work"}) {
+		t.Errorf("Неверно разобран CSV 1Password: %+v", items)
+	}
+}
+
+func TestParse_KeePass(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="utf-8"?>
+<KeePassFile>
+	<Meta><RecycleBinEnabled>True</RecycleBinEnabled><RecycleBinUUID>bin</RecycleBinUUID></Meta>
+	<Root><Group><UUID>root</UUID><Name>Database</Name>
+		<Entry>
+			<String><Key>Title</Key><Value>Router</Value></String>
+			<String><Key>UserName</Key><Value>admin</Value></String>
+			<String><Key>Password</Key><Value ProtectInMemory="True">secret</Value></String>
+			<String><Key>URL</Key><Value>http://192.168.0.1</Value></String>
+			<Tags>home;network</Tags>
+			<History><Entry><String><Key>Title</Key><Value>Router old</Value></String></Entry></History>
+		</Entry>
+		<Group><UUID>g1</UUID><Name>Servers</Name>
+			<Entry>
+				<String><Key>Title</Key><Value>Backup key</Value></String>
+				<String><Key>Password</Key><Value>passphrase</Value></String>
+				<String><Key>Host</Key><Value>backup.example.com</Value></String>
+			</Entry>
+		</Group>
+		<Group><UUID>bin</UUID><Name>Recycle Bin</Name>
+			<Entry><String><Key>Title</Key><Value>Deleted</Value></String></Entry>
+		</Group>
+	</Group></Root>
+</KeePassFile>`)
+
+	items, err := Parse(FormatKeePass, data)
+	if err != nil {
+		t.Fatalf("Ошибка разбора: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Ожидалось 2 записи без истории и корзины, получено %d: %+v", len(items), items)
+	}
+	if router := items[0]; router.Name != "Router" || router.Login != "admin" || router.Password != "secret" ||
+		router.Folder != "" || !slices.Equal(router.Tags, []string{"home", "network"}) {
+		t.Errorf("Неверно разобрана запись: %+v", router)
+	}
+
+	// Запись без логина сохраняется текстовой заметкой
+	if key := items[1]; key.Type != models.DataTypeText || key.Text != "Пароль: passphrase" ||
+		key.Folder != "Servers" || key.Metadata["Host"] != "backup.example.com" {
+		t.Errorf("Неверно разобрана запись без логина: %+v", key)
+	}
+}
+
+func TestParse_BrowserCSV(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+	}{
+		{"chrome", FormatChrome, "name,url,username,password,note\nGitHub,https://github.com/login,octocat,secret,\n"},
+		{"firefox", FormatFirefox, "\"url\",\"username\",\"password\",\"httpRealm\",\"formActionOrigin\",\"guid\"\n" +
+			"\"https://www.github.com\",\"octocat\",\"secret\",,\"https://github.com\",\"{1}\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := Parse(tt.format, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("Ошибка разбора: %v", err)
+			}
+			if len(items) != 1 || items[0].Login != "octocat" || items[0].Password != "secret" {
+				t.Fatalf("Неверно разобран CSV: %+v", items)
+			}
+			if tt.format == FormatFirefox && items[0].Name != "github.com" {
+				t.Errorf("Запись без названия должна называться по сайту, получено %q", items[0].Name)
+			}
+		})
+	}
+
+	if _, err := Parse(FormatChrome, []byte("a,b\n1,2\n")); err == nil {
+		t.Error("Ожидалась ошибка для CSV без нужных столбцов")
+	}
+}
@@ -0,0 +1,100 @@
+// Package importer разбирает экспорты других менеджеров паролей в записи GophKeeper.
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// keepassFile представляет XML экспорт KeePass 2.
+type keepassFile struct {
+	Meta struct {
+		RecycleBinEnabled string `xml:"RecycleBinEnabled"`
+		RecycleBinUUID    string `xml:"RecycleBinUUID"`
+	} `xml:"Meta"`
+	Root struct {
+		Groups []keepassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+// keepassGroup представляет группу KeePass.
+type keepassGroup struct {
+	UUID    string         `xml:"UUID"`
+	Name    string         `xml:"Name"`
+	Entries []keepassEntry `xml:"Entry"`
+	Groups  []keepassGroup `xml:"Group"`
+}
+
+// keepassEntry представляет запись KeePass. Предыдущие версии записи
+// из History не импортируются.
+type keepassEntry struct {
+	Strings []struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	} `xml:"String"`
+	Tags string `xml:"Tags"`
+}
+
+// parseKeePass разбирает XML экспорт KeePass 2. Группы становятся папками,
+// корневая группа базы и корзина пропускаются.
+func parseKeePass(data []byte) ([]Item, error) {
+	var file keepassFile
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("ошибка чтения экспорта KeePass: %w", err)
+	}
+
+	recycleBin := ""
+	if strings.EqualFold(file.Meta.RecycleBinEnabled, "true") {
+		recycleBin = file.Meta.RecycleBinUUID
+	}
+
+	var items []Item
+	var walk func(group keepassGroup, path string)
+	walk = func(group keepassGroup, path string) {
+		if recycleBin != "" && group.UUID == recycleBin {
+			return
+		}
+		for _, entry := range group.Entries {
+			item := keepassItem(entry)
+			item.Folder = path
+			items = append(items, item)
+		}
+		for _, child := range group.Groups {
+			name := strings.ReplaceAll(child.Name, "/", "-")
+			if path != "" {
+				name = path + "/" + name
+			}
+			walk(child, name)
+		}
+	}
+	for _, group := range file.Root.Groups {
+		walk(group, "")
+	}
+	return items, nil
+}
+
+// keepassItem преобразует запись KeePass. Дополнительные строковые поля
+// сохраняются в метаданных.
+func keepassItem(entry keepassEntry) Item {
+	fields := make(map[string]string)
+	metadata := make(map[string]string)
+	for _, s := range entry.Strings {
+		switch s.Key {
+		case "Title", "UserName", "Password":
+			fields[s.Key] = s.Value
+		case "URL":
+			setMeta(metadata, MetaURL, s.Value)
+		case "Notes":
+			setMeta(metadata, MetaNotes, s.Value)
+		case "otp":
+			setMeta(metadata, MetaTOTP, s.Value)
+		default:
+			setMeta(metadata, s.Key, s.Value)
+		}
+	}
+
+	item := newLogin(fields["Title"], fields["UserName"], fields["Password"], metadata)
+	item.Tags = splitTags(entry.Tags)
+	return item
+}
@@ -0,0 +1,210 @@
+// Package importer разбирает экспорты других менеджеров паролей в записи GophKeeper.
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Категории записей 1Password.
+const (
+	onePasswordLogin      = "001"
+	onePasswordCreditCard = "002"
+	onePasswordSecureNote = "003"
+	onePasswordPassword   = "005"
+)
+
+// onePUXExport представляет файл export.data из архива 1PUX.
+type onePUXExport struct {
+	Accounts []struct {
+		Vaults []struct {
+			Attrs struct {
+				Name string `json:"name"`
+			} `json:"attrs"`
+			Items []onePUXEntry `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+// onePUXEntry представляет запись 1PUX. В ранних версиях формата запись
+// вложена в поле item.
+type onePUXEntry struct {
+	onePUXItem
+	Item *onePUXItem `json:"item"`
+}
+
+// onePUXItem представляет содержимое записи 1PUX.
+type onePUXItem struct {
+	FavIndex     int    `json:"favIndex"`
+	State        string `json:"state"`
+	CategoryUUID string `json:"categoryUuid"`
+	Overview     struct {
+		Title string   `json:"title"`
+		URL   string   `json:"url"`
+		Tags  []string `json:"tags"`
+	} `json:"overview"`
+	Details struct {
+		LoginFields []struct {
+			Designation string `json:"designation"`
+			Value       string `json:"value"`
+		} `json:"loginFields"`
+		NotesPlain string `json:"notesPlain"`
+		Password   string `json:"password"`
+		Sections   []struct {
+			Title  string `json:"title"`
+			Fields []struct {
+				Title string                     `json:"title"`
+				ID    string                     `json:"id"`
+				Value map[string]json.RawMessage `json:"value"`
+			} `json:"fields"`
+		} `json:"sections"`
+	} `json:"details"`
+}
+
+// parseOnePUX разбирает архив 1PUX. Хранилища 1Password становятся папками,
+// записи из архива 1Password пропускаются.
+func parseOnePUX(data []byte) ([]Item, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения архива 1PUX: %w", err)
+	}
+	file, err := archive.Open("export.data")
+	if err != nil {
+		return nil, errors.New("в архиве 1PUX нет файла export.data")
+	}
+	defer file.Close()
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения архива 1PUX: %w", err)
+	}
+
+	var export onePUXExport
+	if err := json.Unmarshal(raw, &export); err != nil {
+		return nil, fmt.Errorf("ошибка чтения экспорта 1Password: %w", err)
+	}
+
+	var items []Item
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			for _, entry := range vault.Items {
+				source := &entry.onePUXItem
+				if entry.Item != nil {
+					source = entry.Item
+				}
+				if source.State == "archived" {
+					continue
+				}
+				item := onePUXItemToItem(source)
+				item.Folder = strings.ReplaceAll(vault.Attrs.Name, "/", "-")
+				items = append(items, item)
+			}
+		}
+	}
+	return items, nil
+}
+
+// onePUXItemToItem преобразует запись 1PUX. Поля разделов, кроме реквизитов карты,
+// сохраняются в метаданных.
+func onePUXItemToItem(source *onePUXItem) Item {
+	metadata := make(map[string]string)
+	setMeta(metadata, MetaURL, source.Overview.URL)
+
+	fields := make(map[string]string)
+	for _, section := range source.Details.Sections {
+		for _, field := range section.Fields {
+			value := onePUXValue(field.Value)
+			fields[field.ID] = value
+			title := field.Title
+			if title == "" {
+				title = field.ID
+			}
+			if source.CategoryUUID != onePasswordCreditCard && title != "" {
+				setMeta(metadata, title, value)
+			}
+		}
+	}
+
+	var login, password string
+	for _, field := range source.Details.LoginFields {
+		switch field.Designation {
+		case "username":
+			login = field.Value
+		case "password":
+			password = field.Value
+		}
+	}
+	if password == "" {
+		password = source.Details.Password
+	}
+
+	var item Item
+	switch source.CategoryUUID {
+	case onePasswordLogin, onePasswordPassword:
+		setMeta(metadata, MetaNotes, source.Details.NotesPlain)
+		item = newLogin(source.Overview.Title, login, password, metadata)
+	case onePasswordCreditCard:
+		setMeta(metadata, MetaNotes, source.Details.NotesPlain)
+		month, year := "", ""
+		if expiry := fields["expiry"]; len(expiry) == 6 {
+			year, month = expiry[:4], expiry[4:]
+		}
+		item = newCard(source.Overview.Title, fields["ccnum"], month, year, fields["cvv"], fields["cardholder"], metadata)
+	default:
+		item = newNote(source.Overview.Title, source.Details.NotesPlain, metadata)
+	}
+
+	item.Tags = source.Overview.Tags
+	item.Favorite = source.FavIndex > 0
+	return item
+}
+
+// onePUXValue возвращает значение поля 1PUX строкой. Значение хранится в объекте
+// с единственным ключом, задающим его тип: string, concealed, monthYear и другие.
+func onePUXValue(value map[string]json.RawMessage) string {
+	for _, raw := range value {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return s
+		}
+		var n json.Number
+		if err := json.Unmarshal(raw, &n); err == nil {
+			return n.String()
+		}
+	}
+	return ""
+}
+
+// parseOnePasswordCSV разбирает CSV экспорт 1Password. Архивные записи пропускаются.
+func parseOnePasswordCSV(data []byte) ([]Item, error) {
+	table, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	if !table.has("title") || !table.has("password") {
+		return nil, errors.New("в CSV экспорте 1Password нет столбцов title и password")
+	}
+
+	items := make([]Item, 0, len(table.rows))
+	for _, row := range table.rows {
+		if archived, _ := strconv.ParseBool(table.value(row, "archived")); archived {
+			continue
+		}
+
+		metadata := make(map[string]string)
+		setMeta(metadata, MetaURL, table.value(row, "url", "website"))
+		setMeta(metadata, MetaNotes, table.value(row, "notes"))
+		setMeta(metadata, MetaTOTP, table.value(row, "otpauth"))
+
+		item := newLogin(table.value(row, "title"), table.value(row, "username"), table.value(row, "password"), metadata)
+		item.Tags = splitTags(table.value(row, "tags"))
+		item.Favorite, _ = strconv.ParseBool(table.value(row, "favorite"))
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -2,15 +2,24 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/AlexeySalamakhin/GophKeeper/internal/auth"
 	"github.com/gin-gonic/gin"
 )
 
+// SessionChecker проверяет, что сессия токена доступа не отозвана; реализуется handlers.AuthHandler.
+type SessionChecker interface {
+	CheckSession(claims *auth.Claims, ip string) error
+}
+
 // AuthMiddleware создает middleware для проверки JWT токенов.
-func AuthMiddleware(secretKey string) gin.HandlerFunc {
+// Кроме подписи и срока действия токена проверяется его сессия, поэтому токены
+// отозванных сессий отклоняются, не дожидаясь своего истечения.
+func AuthMiddleware(secretKey string, sessions SessionChecker) gin.HandlerFunc {
 	jwtManager := auth.NewJWTManager(secretKey)
 
 	return func(c *gin.Context) {
@@ -37,14 +46,41 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 			return
 		}
 
+		if err := sessions.CheckSession(claims, c.ClientIP()); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("claims", claims)
+		c.Set("sessions", sessions)
 
 		c.Next()
 	}
 }
 
+// CheckStreamSession повторно проверяет срок действия токена и его сессию для
+// долгоживущих ответов: поток закрывается, как только токен истек или сессия отозвана.
+func CheckStreamSession(c *gin.Context) error {
+	claims, _ := GetClaims(c)
+	sessions, _ := c.Get("sessions")
+	checker, _ := sessions.(SessionChecker)
+	return checkSession(claims, checker, c.ClientIP())
+}
+
+// checkSession проверяет, что токен с claims не истек, а его сессия активна.
+func checkSession(claims *auth.Claims, sessions SessionChecker, ip string) error {
+	if claims == nil || sessions == nil {
+		return errors.New("Запрос не аутентифицирован")
+	}
+	if claims.ExpiresAt != nil && !time.Now().Before(claims.ExpiresAt.Time) {
+		return errors.New("Срок действия токена истек")
+	}
+	return sessions.CheckSession(claims, ip)
+}
+
 // GetUserID извлекает ID пользователя из контекста Gin.
 func GetUserID(c *gin.Context) (string, bool) {
 	userID, ok := c.Get("user_id")
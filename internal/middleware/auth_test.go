@@ -2,23 +2,38 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/AlexeySalamakhin/GophKeeper/internal/auth"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
+// stubSessions считает действующими все сессии, кроме перечисленных в revoked.
+type stubSessions struct {
+	revoked map[string]bool
+}
+
+func (s stubSessions) CheckSession(claims *auth.Claims, ip string) error {
+	if s.revoked[claims.SessionID] {
+		return errors.New("Сессия отозвана или истекла")
+	}
+	return nil
+}
+
 func TestAuthMiddleware_ValidToken(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	jwtManager := auth.NewJWTManager("test-secret")
-	token, err := jwtManager.GenerateToken("test-user-id", "testuser")
+	token, err := jwtManager.GenerateToken("test-user-id", "testuser", "test-session-id")
 	if err != nil {
 		t.Fatalf("Ошибка генерации токена: %v", err)
 	}
 
-	middleware := AuthMiddleware("test-secret")
+	middleware := AuthMiddleware("test-secret", stubSessions{})
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -64,9 +79,36 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 	}
 }
 
+func TestAuthMiddleware_RevokedSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager := auth.NewJWTManager("test-secret")
+	token, err := jwtManager.GenerateToken("test-user-id", "testuser", "revoked-session-id")
+	if err != nil {
+		t.Fatalf("Ошибка генерации токена: %v", err)
+	}
+
+	middleware := AuthMiddleware("test-secret", stubSessions{revoked: map[string]bool{"revoked-session-id": true}})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	c.Request = httptest.NewRequest("GET", "/test", nil)
+	c.Request.Header.Set("Authorization", "Bearer "+token)
+
+	middleware(c)
+
+	if !c.IsAborted() {
+		t.Error("Запрос с токеном отозванной сессии должен быть прерван")
+	}
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Ожидался статус %d, получен %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
 func TestAuthMiddleware_NoToken(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	middleware := AuthMiddleware("test-secret")
+	middleware := AuthMiddleware("test-secret", stubSessions{})
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -82,7 +124,7 @@ func TestAuthMiddleware_NoToken(t *testing.T) {
 
 func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	middleware := AuthMiddleware("test-secret")
+	middleware := AuthMiddleware("test-secret", stubSessions{})
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -99,7 +141,7 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 
 func TestAuthMiddleware_WrongFormat(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	middleware := AuthMiddleware("test-secret")
+	middleware := AuthMiddleware("test-secret", stubSessions{})
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -190,3 +232,32 @@ func TestGetClaims(t *testing.T) {
 		t.Errorf("Ожидался UserID в claims %s, получен %s", "test-user-id", claims.UserID)
 	}
 }
+
+func TestCheckStreamSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/events", nil)
+
+	if err := CheckStreamSession(c); err == nil {
+		t.Error("Поток без аутентификации должен закрываться")
+	}
+
+	claims := &auth.Claims{SessionID: "test-session-id"}
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+	c.Set("claims", claims)
+	c.Set("sessions", SessionChecker(stubSessions{revoked: map[string]bool{"revoked-session-id": true}}))
+	if err := CheckStreamSession(c); err != nil {
+		t.Errorf("Действующий токен не должен закрывать поток: %v", err)
+	}
+
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Second))
+	if err := CheckStreamSession(c); err == nil {
+		t.Error("Истекший токен должен закрывать поток")
+	}
+
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+	claims.SessionID = "revoked-session-id"
+	if err := CheckStreamSession(c); err == nil {
+		t.Error("Отозванная сессия должна закрывать поток")
+	}
+}
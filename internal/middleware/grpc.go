@@ -0,0 +1,146 @@
+// Package middleware содержит HTTP middleware.
+package middleware
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// claimsKey является ключом claims в контексте gRPC запроса.
+type claimsKey struct{}
+
+// sessionsKey является ключом проверки сессий в контексте потокового gRPC запроса.
+type sessionsKey struct{}
+
+// UnaryAuthInterceptor создает gRPC interceptor для проверки JWT токенов и их сессий,
+// аналогичный AuthMiddleware. Методы из publicMethods вызываются без токена.
+func UnaryAuthInterceptor(secretKey string, sessions SessionChecker, publicMethods ...string) grpc.UnaryServerInterceptor {
+	jwtManager := auth.NewJWTManager(secretKey)
+	public := methodSet(publicMethods)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if public[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		claims, err := authenticate(ctx, jwtManager, sessions)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(context.WithValue(ctx, claimsKey{}, claims), req)
+	}
+}
+
+// StreamAuthInterceptor создает gRPC interceptor для проверки JWT токенов в потоковых методах.
+func StreamAuthInterceptor(secretKey string, sessions SessionChecker, publicMethods ...string) grpc.StreamServerInterceptor {
+	jwtManager := auth.NewJWTManager(secretKey)
+	public := methodSet(publicMethods)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if public[info.FullMethod] {
+			return handler(srv, ss)
+		}
+
+		claims, err := authenticate(ss.Context(), jwtManager, sessions)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.WithValue(ss.Context(), claimsKey{}, claims)
+		return handler(srv, &authenticatedStream{
+			ServerStream: ss,
+			ctx:          context.WithValue(ctx, sessionsKey{}, sessions),
+		})
+	}
+}
+
+// ClaimsFromContext извлекает claims из контекста gRPC запроса.
+func ClaimsFromContext(ctx context.Context) (*auth.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*auth.Claims)
+	return claims, ok
+}
+
+// CheckStreamContext повторно проверяет срок действия токена и сессию потокового
+// gRPC запроса, аналогично CheckStreamSession.
+func CheckStreamContext(ctx context.Context) error {
+	claims, _ := ClaimsFromContext(ctx)
+	sessions, _ := ctx.Value(sessionsKey{}).(SessionChecker)
+	return checkSession(claims, sessions, PeerIP(ctx))
+}
+
+// UserIDFromContext извлекает ID пользователя из контекста gRPC запроса.
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	return claims.UserID, true
+}
+
+// authenticate проверяет токен из метаданных authorization и его сессию.
+func authenticate(ctx context.Context, jwtManager *auth.JWTManager, sessions SessionChecker) (*auth.Claims, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "Отсутствует заголовок Authorization")
+	}
+
+	parts := strings.Split(values[0], " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, status.Error(codes.Unauthenticated, "Неверный формат заголовка Authorization")
+	}
+
+	claims, err := jwtManager.ValidateToken(parts[1])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "Неверный токен")
+	}
+
+	if err := sessions.CheckSession(claims, PeerIP(ctx)); err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return claims, nil
+}
+
+// PeerIP возвращает IP адрес клиента gRPC запроса.
+func PeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// methodSet собирает множество полных имен gRPC методов.
+func methodSet(methods []string) map[string]bool {
+	set := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		set[method] = true
+	}
+	return set
+}
+
+// authenticatedStream подменяет контекст потока контекстом с claims.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context возвращает контекст потока с claims.
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
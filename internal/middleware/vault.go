@@ -0,0 +1,123 @@
+// Package middleware содержит HTTP middleware.
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ErrNotVaultMember возвращается, когда пользователь не участвует в хранилище.
+var ErrNotVaultMember = errors.New("пользователь не участвует в хранилище")
+
+// ErrForbidden возвращается, когда прав пользователя недостаточно для действия.
+var ErrForbidden = errors.New("недостаточно прав")
+
+// VaultMembers возвращает участника хранилища; реализуется репозиторием хранилищ.
+type VaultMembers interface {
+	GetMember(vaultID, userID uuid.UUID) (*models.VaultMember, error)
+}
+
+// Authorizer проверяет права пользователя на записи и командные хранилища.
+// Личная запись доступна только ее владельцу, запись хранилища - участникам
+// хранилища с достаточной ролью.
+type Authorizer struct {
+	members VaultMembers
+}
+
+// NewAuthorizer создает проверку прав по участникам хранилищ из members.
+func NewAuthorizer(members VaultMembers) *Authorizer {
+	return &Authorizer{members: members}
+}
+
+// VaultRole возвращает роль пользователя в хранилище или ErrNotVaultMember.
+func (a *Authorizer) VaultRole(vaultID, userID uuid.UUID) (models.VaultRole, error) {
+	member, err := a.members.GetMember(vaultID, userID)
+	if err != nil {
+		return "", ErrNotVaultMember
+	}
+	return member.Role, nil
+}
+
+// AuthorizeVault проверяет, что роль пользователя в хранилище дает не меньше прав, чем required.
+func (a *Authorizer) AuthorizeVault(vaultID, userID uuid.UUID, required models.VaultRole) error {
+	role, err := a.VaultRole(vaultID, userID)
+	if err != nil {
+		return err
+	}
+	if !role.Allows(required) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// AuthorizeData проверяет права пользователя на запись. Для записи хранилища
+// требуется роль не ниже required, личная запись доступна только владельцу.
+func (a *Authorizer) AuthorizeData(data *models.Data, userID uuid.UUID, required models.VaultRole) error {
+	if data.VaultID != nil {
+		if err := a.AuthorizeVault(*data.VaultID, userID, required); err != nil {
+			return ErrForbidden
+		}
+		return nil
+	}
+	if data.UserID != userID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// RequireVaultRole создает middleware, которое пропускает запрос к хранилищу из
+// параметра пути id, только если роль пользователя в нем не ниже required.
+// Роль пользователя сохраняется в контексте и доступна через GetVaultRole.
+func (a *Authorizer) RequireVaultRole(required models.VaultRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := GetUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Пользователь не аутентифицирован"})
+			c.Abort()
+			return
+		}
+
+		userUUID, err := uuid.Parse(userID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пользователя"})
+			c.Abort()
+			return
+		}
+
+		vaultID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID хранилища"})
+			c.Abort()
+			return
+		}
+
+		role, err := a.VaultRole(vaultID, userUUID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Хранилище не найдено"})
+			c.Abort()
+			return
+		}
+		if !role.Allows(required) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Недостаточно прав в хранилище"})
+			c.Abort()
+			return
+		}
+
+		c.Set("vault_role", role)
+		c.Next()
+	}
+}
+
+// GetVaultRole извлекает роль пользователя в хранилище, проверенную RequireVaultRole.
+func GetVaultRole(c *gin.Context) (models.VaultRole, bool) {
+	role, exists := c.Get("vault_role")
+	if !exists {
+		return "", false
+	}
+	r, ok := role.(models.VaultRole)
+	return r, ok
+}
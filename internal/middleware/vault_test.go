@@ -0,0 +1,96 @@
+// Package middleware содержит тесты для проверки прав в хранилищах.
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// stubMembers хранит роли участников одного хранилища.
+type stubMembers map[uuid.UUID]models.VaultRole
+
+func (s stubMembers) GetMember(vaultID, userID uuid.UUID) (*models.VaultMember, error) {
+	role, ok := s[userID]
+	if !ok {
+		return nil, errors.New("участник не найден")
+	}
+	return &models.VaultMember{VaultID: vaultID, UserID: userID, Role: role}, nil
+}
+
+func TestAuthorizer_AuthorizeData(t *testing.T) {
+	owner, editor, viewer := uuid.New(), uuid.New(), uuid.New()
+	authz := NewAuthorizer(stubMembers{editor: models.VaultRoleEditor, viewer: models.VaultRoleViewer})
+	vaultID := uuid.New()
+
+	personal := &models.Data{UserID: owner}
+	shared := &models.Data{UserID: owner, VaultID: &vaultID}
+
+	tests := []struct {
+		name     string
+		data     *models.Data
+		userID   uuid.UUID
+		required models.VaultRole
+		wantErr  bool
+	}{
+		{"владелец личной записи", personal, owner, models.VaultRoleEditor, false},
+		{"чужая личная запись", personal, editor, models.VaultRoleViewer, true},
+		{"редактор изменяет запись хранилища", shared, editor, models.VaultRoleEditor, false},
+		{"читатель изменяет запись хранилища", shared, viewer, models.VaultRoleEditor, true},
+		{"читатель читает запись хранилища", shared, viewer, models.VaultRoleViewer, false},
+		{"создатель записи вне хранилища", shared, owner, models.VaultRoleViewer, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authz.AuthorizeData(tt.data, tt.userID, tt.required)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Ожидалась ошибка: %v, получено %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAuthorizer_RequireVaultRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	admin, viewer := uuid.New(), uuid.New()
+	authz := NewAuthorizer(stubMembers{admin: models.VaultRoleAdmin, viewer: models.VaultRoleViewer})
+	vaultID := uuid.New()
+
+	tests := []struct {
+		name   string
+		userID uuid.UUID
+		want   int
+	}{
+		{"достаточная роль", admin, http.StatusOK},
+		{"недостаточная роль", viewer, http.StatusForbidden},
+		{"не участник", uuid.New(), http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user_id", tt.userID.String())
+				c.Next()
+			})
+			router.PUT("/vaults/:id/members", authz.RequireVaultRole(models.VaultRoleAdmin), func(c *gin.Context) {
+				if role, _ := GetVaultRole(c); role != models.VaultRoleAdmin {
+					t.Errorf("Ожидалась роль admin в контексте, получено %q", role)
+				}
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("PUT", "/vaults/"+vaultID.String()+"/members", nil))
+			if w.Code != tt.want {
+				t.Errorf("Ожидался статус %d, получен %d", tt.want, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package models содержит модели данных приложения.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment описывает содержимое записи типа file, загружаемое фрагментами.
+// Фрагменты хранятся в BlobStore, а в базе данных только сведения о них.
+// Если ClientEncrypted установлен, каждый фрагмент дополнительно зашифрован на клиенте,
+// и ChunkSize и Size учитывают накладные расходы клиентского шифрования.
+type Attachment struct {
+	DataID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	UserID          uuid.UUID `json:"-" gorm:"type:uuid;not null;index"`
+	Size            int64     `json:"size" gorm:"not null"`       // Размер загружаемого файла в байтах
+	ChunkSize       int64     `json:"chunk_size" gorm:"not null"` // Размер всех фрагментов, кроме последнего
+	ClientEncrypted bool      `json:"client_encrypted"`           // Фрагменты зашифрованы на клиенте
+	KeyID           string    `json:"-"`                          // Ключ сервера, которым зашифрованы фрагменты; пустой для ключа default
+	UserKey         bool      `json:"-"`                          // Фрагменты зашифрованы ключом данных пользователя, а не ключом сервера
+	Complete        bool      `json:"complete"`                   // Все фрагменты загружены
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName возвращает имя таблицы для модели Attachment.
+func (Attachment) TableName() string {
+	return "attachments"
+}
+
+// ChunkCount возвращает число фрагментов файла.
+func (a *Attachment) ChunkCount() int {
+	if a.ChunkSize <= 0 {
+		return 0
+	}
+	return int((a.Size + a.ChunkSize - 1) / a.ChunkSize)
+}
+
+// ChunkLength возвращает ожидаемый размер фрагмента с указанным номером.
+func (a *Attachment) ChunkLength(index int) int64 {
+	if index == a.ChunkCount()-1 {
+		return a.Size - int64(index)*a.ChunkSize
+	}
+	return a.ChunkSize
+}
+
+// AttachmentChunk описывает загруженный фрагмент файла.
+type AttachmentChunk struct {
+	DataID    uuid.UUID `json:"-" gorm:"type:uuid;primary_key"`
+	Index     int       `json:"index" gorm:"column:chunk_index;primary_key;autoIncrement:false"`
+	Size      int64     `json:"size" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName возвращает имя таблицы для модели AttachmentChunk.
+func (AttachmentChunk) TableName() string {
+	return "attachment_chunks"
+}
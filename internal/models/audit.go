@@ -0,0 +1,81 @@
+// Package models содержит модели данных приложения.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditAction определяет действие, записанное в журнал аудита.
+type AuditAction string
+
+// Действия, которые записываются в журнал аудита.
+const (
+	AuditRegister    AuditAction = "register"
+	AuditLogin       AuditAction = "login"
+	AuditLoginFailed AuditAction = "login_failed"
+	AuditRead        AuditAction = "read"
+	AuditCreate      AuditAction = "create"
+	AuditUpdate      AuditAction = "update"
+	AuditDelete      AuditAction = "delete"
+	AuditShare       AuditAction = "share"
+	AuditUnshare     AuditAction = "unshare"
+)
+
+// AuditEvent представляет запись журнала аудита: кто, откуда и с какой записью
+// выполнил действие. Журнал только дополняется, записи не изменяются и не удаляются.
+// События образуют цепочку: каждое содержит хеш предыдущего, поэтому изменение или
+// удаление события нарушает хеши всех последующих.
+type AuditEvent struct {
+	ID        uuid.UUID   `json:"id" gorm:"type:uuid;primary_key"`
+	Seq       int64       `json:"seq" gorm:"not null;uniqueIndex"`           // Номер события в цепочке, начиная с 1
+	ActorID   *uuid.UUID  `json:"actor_id,omitempty" gorm:"type:uuid;index"` // Пусто для неудачного входа под неизвестным именем
+	Username  string      `json:"username,omitempty"`                        // Имя, под которым выполнялся вход
+	Action    AuditAction `json:"action" gorm:"not null;index"`
+	DataID    *uuid.UUID  `json:"data_id,omitempty" gorm:"type:uuid;index"`
+	Details   string      `json:"details,omitempty"`
+	IP        string      `json:"ip"`
+	UserAgent string      `json:"user_agent"`
+	CreatedAt time.Time   `json:"created_at" gorm:"index"`
+	PrevHash  string      `json:"prev_hash"` // Хеш предыдущего события, пустой у первого
+	Hash      string      `json:"hash" gorm:"not null"`
+}
+
+// TableName возвращает имя таблицы для модели AuditEvent.
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+// BeforeCreate выполняется перед созданием записи журнала.
+func (e *AuditEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// AuditCheckpoint представляет подписанную ключом сервера контрольную точку цепочки
+// журнала аудита. Без ключа сервера нельзя пересчитать цепочку так, чтобы она
+// совпала с контрольными точками.
+type AuditCheckpoint struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	Seq       int64     `json:"seq" gorm:"not null;uniqueIndex"` // Номер события, на котором поставлена точка
+	Hash      string    `json:"hash" gorm:"not null"`            // Хеш этого события
+	Signature string    `json:"signature" gorm:"not null"`       // Номер и хеш, зашифрованные ключом сервера
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName возвращает имя таблицы для модели AuditCheckpoint.
+func (AuditCheckpoint) TableName() string {
+	return "audit_checkpoints"
+}
+
+// BeforeCreate выполняется перед созданием контрольной точки.
+func (c *AuditCheckpoint) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
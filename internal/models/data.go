@@ -9,17 +9,55 @@ import (
 	"gorm.io/gorm"
 )
 
+// DataType определяет тип хранимых данных.
+type DataType string
+
+// Поддерживаемые типы данных.
+const (
+	DataTypeLoginPassword DataType = "login_password"
+	DataTypeText          DataType = "text"
+	DataTypeBankCard      DataType = "card"
+	DataTypeBinary        DataType = "binary"
+	DataTypeFile          DataType = "file" // Содержимое загружается фрагментами, см. Attachment
+)
+
+// IsValid проверяет, что тип данных поддерживается.
+func (t DataType) IsValid() bool {
+	switch t {
+	case DataTypeLoginPassword, DataTypeText, DataTypeBankCard, DataTypeBinary, DataTypeFile:
+		return true
+	}
+	return false
+}
+
 // Data представляет приватные данные пользователя.
+// Если ClientEncrypted установлен, Payload содержит blob, зашифрованный на клиенте
+// ключом хранилища, и сервер хранит его, не расшифровывая.
+// Version увеличивается при каждом изменении и используется для обнаружения конфликтов.
+// Запись с заполненным VaultID принадлежит командному хранилищу: она шифруется ключом
+// данных хранилища, а доступ к ней определяется ролью в хранилище. UserID указывает
+// создавшего ее пользователя.
 type Data struct {
-	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
-	UserID    uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
-	Name      string         `json:"name" gorm:"not null"`
-	Metadata  string         `json:"metadata"`          // JSON строка с метаданными
-	Login     string         `json:"login"`             // Логин
-	Password  string         `json:"-" gorm:"not null"` // Зашифрованный пароль
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
+	UserID          uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
+	VaultID         *uuid.UUID     `json:"vault_id,omitempty" gorm:"type:uuid;index"` // Командное хранилище записи, пусто для личных записей
+	Type            DataType       `json:"type" gorm:"not null;default:login_password;index"`
+	Name            string         `json:"name" gorm:"not null"`
+	Metadata        string         `json:"metadata"`                                   // JSON строка с метаданными
+	Login           string         `json:"login"`                                      // Логин
+	Password        string         `json:"-" gorm:"not null"`                          // Зашифрованный пароль
+	Payload         string         `json:"-"`                                          // Зашифрованное содержимое для типов, отличных от login_password
+	ClientEncrypted bool           `json:"client_encrypted"`                           // Содержимое зашифровано на клиенте
+	FolderID        *uuid.UUID     `json:"folder_id,omitempty" gorm:"type:uuid;index"` // Папка записи, пусто для записей в корне
+	Favorite        bool           `json:"favorite" gorm:"not null;default:false;index"`
+	Version         int64          `json:"version" gorm:"not null;default:1"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+	// Shared заполняется для записей, открытых пользователю другим владельцем.
+	Shared *SharedAccess `json:"shared,omitempty" gorm:"-"`
+	// Tags заполняется именами меток записи при выдаче ее владельцу.
+	Tags []string `json:"tags,omitempty" gorm:"-"`
 }
 
 // TableName возвращает имя таблицы для модели Data.
@@ -32,6 +70,9 @@ func (d *Data) BeforeCreate(tx *gorm.DB) error {
 	if d.ID == uuid.Nil {
 		d.ID = uuid.New()
 	}
+	if d.Version == 0 {
+		d.Version = 1
+	}
 	return nil
 }
 
@@ -49,3 +90,22 @@ func (d *Data) SetMetadata(metadata interface{}) error {
 func (d *Data) GetMetadata(target interface{}) error {
 	return json.Unmarshal([]byte(d.Metadata), target)
 }
+
+// DataTombstone фиксирует окончательное удаление записи из корзины, чтобы клиенты
+// удалили ее локальную копию при следующей синхронизации.
+type DataTombstone struct {
+	DataID   uuid.UUID  `json:"data_id" gorm:"type:uuid;primary_key"`
+	UserID   uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	VaultID  *uuid.UUID `json:"vault_id,omitempty" gorm:"type:uuid;index"`
+	PurgedAt time.Time  `json:"purged_at" gorm:"not null;index"`
+}
+
+// TableName возвращает имя таблицы для модели DataTombstone.
+func (DataTombstone) TableName() string {
+	return "data_tombstones"
+}
+
+// NewTombstone создает отметку об окончательном удалении записи.
+func NewTombstone(data *Data, purgedAt time.Time) *DataTombstone {
+	return &DataTombstone{DataID: data.ID, UserID: data.UserID, VaultID: data.VaultID, PurgedAt: purgedAt}
+}
@@ -0,0 +1,66 @@
+// Package models содержит модели данных приложения.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Tag представляет метку пользователя. Метка может быть назначена любому числу
+// личных записей пользователя, а запись может иметь любое число меток.
+type Tag struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	UserID    uuid.UUID `json:"-" gorm:"type:uuid;not null;uniqueIndex:idx_tags_user_name"`
+	Name      string    `json:"name" gorm:"not null;uniqueIndex:idx_tags_user_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName возвращает имя таблицы для модели Tag.
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// BeforeCreate выполняется перед созданием метки.
+func (t *Tag) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// DataTag связывает запись с назначенной ей меткой.
+type DataTag struct {
+	DataID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TagID  uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+}
+
+// TableName возвращает имя таблицы для модели DataTag.
+func (DataTag) TableName() string {
+	return "data_tags"
+}
+
+// Folder представляет папку пользователя. Папки образуют дерево: папка без ParentID
+// находится в корне, а запись лежит не более чем в одной папке.
+type Folder struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	UserID    uuid.UUID  `json:"-" gorm:"type:uuid;not null;index"`
+	ParentID  *uuid.UUID `json:"parent_id,omitempty" gorm:"type:uuid;index"` // Пусто для папок в корне
+	Name      string     `json:"name" gorm:"not null"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName возвращает имя таблицы для модели Folder.
+func (Folder) TableName() string {
+	return "folders"
+}
+
+// BeforeCreate выполняется перед созданием папки.
+func (f *Folder) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Package models содержит модели данных приложения.
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// MaxBinarySize ограничивает размер бинарных данных, хранимых внутри записи.
+const MaxBinarySize = 10 << 20
+
+// LoginPassword представляет пару логин/пароль.
+type LoginPassword struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+// Validate проверяет корректность пары логин/пароль.
+func (lp *LoginPassword) Validate() error {
+	if lp.Login == "" {
+		return errors.New("логин обязателен")
+	}
+	return nil
+}
+
+// TextNote представляет произвольную текстовую заметку.
+type TextNote struct {
+	Text string `json:"text"`
+}
+
+// Validate проверяет корректность текстовой заметки.
+func (tn *TextNote) Validate() error {
+	if tn.Text == "" {
+		return errors.New("текст заметки обязателен")
+	}
+	return nil
+}
+
+// BankCard представляет данные банковской карты.
+type BankCard struct {
+	Number string `json:"number"`
+	Expiry string `json:"expiry"` // Срок действия в формате MM/YY
+	CVV    string `json:"cvv"`
+	Holder string `json:"holder"`
+}
+
+// Validate проверяет корректность данных банковской карты.
+func (bc *BankCard) Validate() error {
+	number := strings.ReplaceAll(bc.Number, " ", "")
+	if len(number) < 12 || len(number) > 19 || !isDigits(number) {
+		return errors.New("номер карты должен содержать от 12 до 19 цифр")
+	}
+	if !luhnValid(number) {
+		return errors.New("неверный номер карты")
+	}
+
+	parts := strings.Split(bc.Expiry, "/")
+	if len(parts) != 2 || len(parts[0]) != 2 || len(parts[1]) != 2 || !isDigits(parts[0]) || !isDigits(parts[1]) {
+		return errors.New("срок действия должен быть в формате MM/YY")
+	}
+	if month, _ := strconv.Atoi(parts[0]); month < 1 || month > 12 {
+		return errors.New("неверный месяц срока действия")
+	}
+
+	if (len(bc.CVV) != 3 && len(bc.CVV) != 4) || !isDigits(bc.CVV) {
+		return errors.New("CVV должен содержать 3 или 4 цифры")
+	}
+	return nil
+}
+
+// Binary представляет произвольные бинарные данные.
+type Binary struct {
+	Data []byte `json:"data"`
+}
+
+// Validate проверяет корректность бинарных данных.
+func (b *Binary) Validate() error {
+	if len(b.Data) == 0 {
+		return errors.New("бинарные данные не должны быть пустыми")
+	}
+	if len(b.Data) > MaxBinarySize {
+		return errors.New("бинарные данные превышают допустимый размер")
+	}
+	return nil
+}
+
+// isDigits проверяет, что строка состоит только из цифр.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// luhnValid проверяет контрольную сумму номера карты по алгоритму Луна.
+func luhnValid(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
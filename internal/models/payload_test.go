@@ -0,0 +1,75 @@
+// Package models содержит тесты для типизированного содержимого записей.
+package models
+
+import "testing"
+
+func TestBankCard_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		card    BankCard
+		wantErr bool
+	}{
+		{"валидная карта", BankCard{Number: "4111 1111 1111 1111", Expiry: "12/30", CVV: "123"}, false},
+		{"четырехзначный CVV", BankCard{Number: "378282246310005", Expiry: "01/27", CVV: "1234"}, false},
+		{"неверная контрольная сумма", BankCard{Number: "4111111111111112", Expiry: "12/30", CVV: "123"}, true},
+		{"слишком короткий номер", BankCard{Number: "4111", Expiry: "12/30", CVV: "123"}, true},
+		{"неверный месяц", BankCard{Number: "4111111111111111", Expiry: "13/30", CVV: "123"}, true},
+		{"неверный формат срока", BankCard{Number: "4111111111111111", Expiry: "1230", CVV: "123"}, true},
+		{"неверный CVV", BankCard{Number: "4111111111111111", Expiry: "12/30", CVV: "12a"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.card.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() ошибка = %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoginPassword_Validate(t *testing.T) {
+	if err := (&LoginPassword{Login: "user"}).Validate(); err != nil {
+		t.Errorf("Неожиданная ошибка: %v", err)
+	}
+
+	if err := (&LoginPassword{}).Validate(); err == nil {
+		t.Error("Ожидалась ошибка для пустого логина")
+	}
+}
+
+func TestTextNote_Validate(t *testing.T) {
+	if err := (&TextNote{Text: "note"}).Validate(); err != nil {
+		t.Errorf("Неожиданная ошибка: %v", err)
+	}
+
+	if err := (&TextNote{}).Validate(); err == nil {
+		t.Error("Ожидалась ошибка для пустой заметки")
+	}
+}
+
+func TestBinary_Validate(t *testing.T) {
+	if err := (&Binary{Data: []byte{1}}).Validate(); err != nil {
+		t.Errorf("Неожиданная ошибка: %v", err)
+	}
+
+	if err := (&Binary{}).Validate(); err == nil {
+		t.Error("Ожидалась ошибка для пустых данных")
+	}
+
+	if err := (&Binary{Data: make([]byte, MaxBinarySize+1)}).Validate(); err == nil {
+		t.Error("Ожидалась ошибка для слишком больших данных")
+	}
+}
+
+func TestDataType_IsValid(t *testing.T) {
+	for _, dataType := range []DataType{DataTypeLoginPassword, DataTypeText, DataTypeBankCard, DataTypeBinary} {
+		if !dataType.IsValid() {
+			t.Errorf("Тип %s должен быть валидным", dataType)
+		}
+	}
+
+	if DataType("unknown").IsValid() {
+		t.Error("Неизвестный тип не должен быть валидным")
+	}
+}
@@ -0,0 +1,84 @@
+// Package models содержит модели данных приложения.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RevisionAction определяет изменение, после которого сохранена ревизия.
+type RevisionAction string
+
+// Поддерживаемые действия с записью.
+const (
+	RevisionCreate  RevisionAction = "create"
+	RevisionUpdate  RevisionAction = "update"
+	RevisionDelete  RevisionAction = "delete"
+	RevisionRestore RevisionAction = "restore"
+)
+
+// DataRevision представляет неизменяемый снимок записи данных после очередного изменения.
+// Секретное содержимое хранится в том же зашифрованном виде, что и в записи.
+type DataRevision struct {
+	ID              uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
+	DataID          uuid.UUID      `json:"data_id" gorm:"type:uuid;not null;index"`
+	UserID          uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
+	VaultID         *uuid.UUID     `json:"vault_id,omitempty" gorm:"type:uuid"` // Командное хранилище записи на момент ревизии
+	Version         int64          `json:"version" gorm:"not null"`
+	Action          RevisionAction `json:"action" gorm:"not null"`
+	ClientID        string         `json:"client_id"` // Устройство, с которого сделано изменение
+	Type            DataType       `json:"type" gorm:"not null"`
+	Name            string         `json:"name" gorm:"not null"`
+	Metadata        string         `json:"metadata"`
+	Login           string         `json:"login"`
+	Password        string         `json:"-"`
+	Payload         string         `json:"-"`
+	ClientEncrypted bool           `json:"client_encrypted"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+// TableName возвращает имя таблицы для модели DataRevision.
+func (DataRevision) TableName() string {
+	return "data_revisions"
+}
+
+// BeforeCreate выполняется перед созданием ревизии.
+func (r *DataRevision) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewRevision создает снимок текущего состояния записи.
+func NewRevision(data *Data, action RevisionAction, clientID string) *DataRevision {
+	return &DataRevision{
+		DataID:          data.ID,
+		UserID:          data.UserID,
+		VaultID:         data.VaultID,
+		Version:         data.Version,
+		Action:          action,
+		ClientID:        clientID,
+		Type:            data.Type,
+		Name:            data.Name,
+		Metadata:        data.Metadata,
+		Login:           data.Login,
+		Password:        data.Password,
+		Payload:         data.Payload,
+		ClientEncrypted: data.ClientEncrypted,
+	}
+}
+
+// Apply переносит содержимое ревизии в запись данных.
+// ID, владелец и версия записи не изменяются.
+func (r *DataRevision) Apply(data *Data) {
+	data.Type = r.Type
+	data.Name = r.Name
+	data.Metadata = r.Metadata
+	data.Login = r.Login
+	data.Password = r.Password
+	data.Payload = r.Payload
+	data.ClientEncrypted = r.ClientEncrypted
+}
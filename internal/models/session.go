@@ -0,0 +1,45 @@
+// Package models содержит модели данных приложения.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session представляет сессию пользователя на одном устройстве.
+// Короткоживущие токены доступа выдаются для сессии и перестают приниматься после ее отзыва.
+// Токен обновления хранится только в виде хеша и заменяется при каждом обновлении;
+// хеш предыдущего токена сохраняется, чтобы обнаружить его повторное использование.
+type Session struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	UserID            uuid.UUID  `json:"-" gorm:"type:uuid;not null;index"`
+	DeviceName        string     `json:"device_name"`
+	IP                string     `json:"ip"`
+	UserAgent         string     `json:"user_agent"`
+	RefreshTokenHash  string     `json:"-" gorm:"not null;uniqueIndex"`
+	PreviousTokenHash string     `json:"-" gorm:"index"`
+	CreatedAt         time.Time  `json:"created_at"`
+	LastSeenAt        time.Time  `json:"last_seen_at"`
+	ExpiresAt         time.Time  `json:"expires_at"` // Срок действия токена обновления
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+}
+
+// TableName возвращает имя таблицы для модели Session.
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// BeforeCreate выполняется перед созданием сессии.
+func (s *Session) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// Active проверяет, что сессия не отозвана и не истекла к моменту now.
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
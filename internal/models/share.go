@@ -0,0 +1,67 @@
+// Package models содержит модели данных приложения.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SharePermission определяет права пользователя на открытую ему запись.
+type SharePermission string
+
+// Поддерживаемые права доступа к открытой записи.
+const (
+	SharePermissionRead  SharePermission = "read"
+	SharePermissionWrite SharePermission = "write"
+)
+
+// IsValid проверяет, что права доступа поддерживаются.
+func (p SharePermission) IsValid() bool {
+	return p == SharePermissionRead || p == SharePermissionWrite
+}
+
+// Share открывает запись владельца другому пользователю.
+// Запись остается зашифрованной ключом данных владельца: сервер расшифровывает ее
+// для получателя так же, как для самого владельца. Для пары запись-получатель
+// хранится не более одного доступа, повторная выдача заменяет права и срок.
+type Share struct {
+	ID         uuid.UUID       `json:"id" gorm:"type:uuid;primary_key"`
+	DataID     uuid.UUID       `json:"data_id" gorm:"type:uuid;not null;uniqueIndex:idx_shares_data_grantee"`
+	OwnerID    uuid.UUID       `json:"-" gorm:"type:uuid;not null;index"`
+	GranteeID  uuid.UUID       `json:"grantee_id" gorm:"type:uuid;not null;uniqueIndex:idx_shares_data_grantee;index"`
+	Permission SharePermission `json:"permission" gorm:"not null"`
+	ExpiresAt  *time.Time      `json:"expires_at,omitempty"` // Пустое значение означает бессрочный доступ
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// TableName возвращает имя таблицы для модели Share.
+func (Share) TableName() string {
+	return "shares"
+}
+
+// BeforeCreate выполняется перед созданием доступа.
+func (s *Share) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// Active проверяет, что доступ не истек к моменту now.
+func (s *Share) Active(now time.Time) bool {
+	return s.ExpiresAt == nil || now.Before(*s.ExpiresAt)
+}
+
+// CanWrite проверяет, что получатель может изменять запись.
+func (s *Share) CanWrite() bool {
+	return s.Permission == SharePermissionWrite
+}
+
+// SharedAccess описывает доступ к записи, которую пользователю открыл ее владелец.
+type SharedAccess struct {
+	Owner      string          `json:"owner"` // Имя пользователя владельца
+	Permission SharePermission `json:"permission"`
+	ExpiresAt  *time.Time      `json:"expires_at,omitempty"`
+}
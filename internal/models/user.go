@@ -2,6 +2,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,6 +15,23 @@ type User struct {
 	Username  string         `json:"username" gorm:"uniqueIndex;not null"`
 	Email     string         `json:"email" gorm:"uniqueIndex;not null"`
 	Password  string         `json:"-" gorm:"not null"` // Хеш пароля, не возвращается в JSON
+	KDFSalt   string         `json:"-"`                 // Соль для получения ключа хранилища на клиенте
+	// KDFCheck содержит проверочное значение ключа хранилища, по которому клиент проверяет мастер-пароль.
+	KDFCheck string `json:"-"`
+	// TOTPSecret содержит зашифрованный секрет одноразовых паролей; до подтверждения он не действует.
+	TOTPSecret  string `json:"-"`
+	TOTPEnabled bool   `json:"totp_enabled" gorm:"not null;default:false"`
+	// TOTPLastStep содержит шаг последнего принятого одноразового пароля, чтобы его нельзя было повторить.
+	TOTPLastStep int64 `json:"-"`
+	// TOTPFailures считает неверные одноразовые пароли подряд.
+	TOTPFailures int `json:"-" gorm:"not null;default:0"`
+	// TOTPLockedUntil запрещает проверять одноразовые пароли до указанного момента после серии неверных кодов.
+	TOTPLockedUntil *time.Time `json:"-"`
+	// RecoveryCodes содержит JSON массив хешей неиспользованных кодов восстановления.
+	RecoveryCodes string `json:"-"`
+	// DataKey содержит ключ данных пользователя, зашифрованный ключом сервера.
+	// Без него данные пользователя расшифровать нельзя.
+	DataKey string `json:"-"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -24,6 +42,25 @@ func (User) TableName() string {
 	return "users"
 }
 
+// RecoveryCodeHashes возвращает хеши неиспользованных кодов восстановления.
+func (u *User) RecoveryCodeHashes() []string {
+	var hashes []string
+	if u.RecoveryCodes != "" {
+		json.Unmarshal([]byte(u.RecoveryCodes), &hashes)
+	}
+	return hashes
+}
+
+// SetRecoveryCodeHashes сохраняет хеши кодов восстановления.
+func (u *User) SetRecoveryCodeHashes(hashes []string) {
+	if len(hashes) == 0 {
+		u.RecoveryCodes = ""
+		return
+	}
+	data, _ := json.Marshal(hashes)
+	u.RecoveryCodes = string(data)
+}
+
 // BeforeCreate выполняется перед созданием записи пользователя.
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
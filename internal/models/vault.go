@@ -0,0 +1,83 @@
+// Package models содержит модели данных приложения.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// VaultRole определяет роль участника командного хранилища.
+type VaultRole string
+
+// Роли участников хранилища в порядке убывания прав.
+const (
+	VaultRoleOwner  VaultRole = "owner"  // Все права, включая назначение владельцев и удаление хранилища
+	VaultRoleAdmin  VaultRole = "admin"  // Управление участниками, кроме владельцев
+	VaultRoleEditor VaultRole = "editor" // Создание, изменение и удаление записей
+	VaultRoleViewer VaultRole = "viewer" // Только чтение записей
+)
+
+// rank возвращает уровень прав роли. Неизвестная роль не имеет прав.
+func (r VaultRole) rank() int {
+	switch r {
+	case VaultRoleOwner:
+		return 4
+	case VaultRoleAdmin:
+		return 3
+	case VaultRoleEditor:
+		return 2
+	case VaultRoleViewer:
+		return 1
+	}
+	return 0
+}
+
+// IsValid проверяет, что роль поддерживается.
+func (r VaultRole) IsValid() bool {
+	return r.rank() > 0
+}
+
+// Allows проверяет, что роль дает не меньше прав, чем required.
+func (r VaultRole) Allows(required VaultRole) bool {
+	return r.IsValid() && r.rank() >= required.rank()
+}
+
+// Vault представляет командное хранилище. Записи хранилища доступны его участникам
+// в соответствии с их ролями и шифруются ключом данных хранилища, а не ключами участников,
+// поэтому удаление ключа данных участника не затрагивает записи хранилища.
+type Vault struct {
+	ID   uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
+	Name string    `json:"name" gorm:"not null"`
+	// DataKey содержит ключ данных хранилища, зашифрованный ключом сервера.
+	DataKey   string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName возвращает имя таблицы для модели Vault.
+func (Vault) TableName() string {
+	return "vaults"
+}
+
+// BeforeCreate выполняется перед созданием хранилища.
+func (v *Vault) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
+
+// VaultMember представляет участника хранилища и его роль.
+type VaultMember struct {
+	VaultID   uuid.UUID `json:"vault_id" gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey;index"`
+	Role      VaultRole `json:"role" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName возвращает имя таблицы для модели VaultMember.
+func (VaultMember) TableName() string {
+	return "vault_members"
+}
@@ -0,0 +1,1393 @@
+// gRPC API сервера GophKeeper. Сервисы повторяют REST API /api/v1
+// и используют ту же бизнес-логику.
+//
+// Генерация кода:
+//   protoc --go_out=. --go_opt=module=github.com/AlexeySalamakhin/GophKeeper \
+//          --go-grpc_out=. --go-grpc_opt=module=github.com/AlexeySalamakhin/GophKeeper \
+//          proto/gophkeeper.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
+// source: proto/gophkeeper.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RegisterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Password      string                 `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
+	DeviceName    string                 `protobuf:"bytes,4,opt,name=device_name,json=deviceName,proto3" json:"device_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterRequest) Reset() {
+	*x = RegisterRequest{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRequest) ProtoMessage() {}
+
+func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RegisterRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetDeviceName() string {
+	if x != nil {
+		return x.DeviceName
+	}
+	return ""
+}
+
+type LoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	DeviceName    string                 `protobuf:"bytes,3,opt,name=device_name,json=deviceName,proto3" json:"device_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoginRequest) Reset() {
+	*x = LoginRequest{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginRequest) ProtoMessage() {}
+
+func (x *LoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginRequest.ProtoReflect.Descriptor instead.
+func (*LoginRequest) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LoginRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *LoginRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *LoginRequest) GetDeviceName() string {
+	if x != nil {
+		return x.DeviceName
+	}
+	return ""
+}
+
+type VerifyTwoFactorRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ChallengeToken string                 `protobuf:"bytes,1,opt,name=challenge_token,json=challengeToken,proto3" json:"challenge_token,omitempty"`
+	Code           string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	DeviceName     string                 `protobuf:"bytes,3,opt,name=device_name,json=deviceName,proto3" json:"device_name,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *VerifyTwoFactorRequest) Reset() {
+	*x = VerifyTwoFactorRequest{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyTwoFactorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyTwoFactorRequest) ProtoMessage() {}
+
+func (x *VerifyTwoFactorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyTwoFactorRequest.ProtoReflect.Descriptor instead.
+func (*VerifyTwoFactorRequest) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *VerifyTwoFactorRequest) GetChallengeToken() string {
+	if x != nil {
+		return x.ChallengeToken
+	}
+	return ""
+}
+
+func (x *VerifyTwoFactorRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *VerifyTwoFactorRequest) GetDeviceName() string {
+	if x != nil {
+		return x.DeviceName
+	}
+	return ""
+}
+
+type RefreshRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RefreshToken  string                 `protobuf:"bytes,1,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefreshRequest) Reset() {
+	*x = RefreshRequest{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshRequest) ProtoMessage() {}
+
+func (x *RefreshRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefreshRequest.ProtoReflect.Descriptor instead.
+func (*RefreshRequest) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RefreshRequest) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+type User struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *User) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *User) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *User) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type AuthResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Token string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	// Соль в base64 для получения ключа хранилища из мастер-пароля.
+	KdfSalt string `protobuf:"bytes,2,opt,name=kdf_salt,json=kdfSalt,proto3" json:"kdf_salt,omitempty"`
+	User    *User  `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
+	// Токен обновления действует один раз и заменяется при каждом обновлении.
+	RefreshToken string `protobuf:"bytes,4,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	// Время истечения токена доступа.
+	ExpiresAt         *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	SessionId         string                 `protobuf:"bytes,6,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	TwoFactorRequired bool                   `protobuf:"varint,7,opt,name=two_factor_required,json=twoFactorRequired,proto3" json:"two_factor_required,omitempty"`
+	ChallengeToken    string                 `protobuf:"bytes,8,opt,name=challenge_token,json=challengeToken,proto3" json:"challenge_token,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *AuthResponse) Reset() {
+	*x = AuthResponse{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthResponse) ProtoMessage() {}
+
+func (x *AuthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthResponse.ProtoReflect.Descriptor instead.
+func (*AuthResponse) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AuthResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *AuthResponse) GetKdfSalt() string {
+	if x != nil {
+		return x.KdfSalt
+	}
+	return ""
+}
+
+func (x *AuthResponse) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *AuthResponse) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+func (x *AuthResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *AuthResponse) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *AuthResponse) GetTwoFactorRequired() bool {
+	if x != nil {
+		return x.TwoFactorRequired
+	}
+	return false
+}
+
+func (x *AuthResponse) GetChallengeToken() string {
+	if x != nil {
+		return x.ChallengeToken
+	}
+	return ""
+}
+
+type BankCard struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Number        string                 `protobuf:"bytes,1,opt,name=number,proto3" json:"number,omitempty"`
+	Expiry        string                 `protobuf:"bytes,2,opt,name=expiry,proto3" json:"expiry,omitempty"`
+	Cvv           string                 `protobuf:"bytes,3,opt,name=cvv,proto3" json:"cvv,omitempty"`
+	Holder        string                 `protobuf:"bytes,4,opt,name=holder,proto3" json:"holder,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BankCard) Reset() {
+	*x = BankCard{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BankCard) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BankCard) ProtoMessage() {}
+
+func (x *BankCard) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BankCard.ProtoReflect.Descriptor instead.
+func (*BankCard) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BankCard) GetNumber() string {
+	if x != nil {
+		return x.Number
+	}
+	return ""
+}
+
+func (x *BankCard) GetExpiry() string {
+	if x != nil {
+		return x.Expiry
+	}
+	return ""
+}
+
+func (x *BankCard) GetCvv() string {
+	if x != nil {
+		return x.Cvv
+	}
+	return ""
+}
+
+func (x *BankCard) GetHolder() string {
+	if x != nil {
+		return x.Holder
+	}
+	return ""
+}
+
+// DataRecord представляет запись данных. Секретные поля заполняются только в ответе GetData.
+type DataRecord struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type  string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Name  string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	// JSON строка с метаданными.
+	Metadata         string                 `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Login            string                 `protobuf:"bytes,5,opt,name=login,proto3" json:"login,omitempty"`
+	ClientEncrypted  bool                   `protobuf:"varint,6,opt,name=client_encrypted,json=clientEncrypted,proto3" json:"client_encrypted,omitempty"`
+	Version          int64                  `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt        *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt        *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Password         string                 `protobuf:"bytes,10,opt,name=password,proto3" json:"password,omitempty"`
+	Text             string                 `protobuf:"bytes,11,opt,name=text,proto3" json:"text,omitempty"`
+	Card             *BankCard              `protobuf:"bytes,12,opt,name=card,proto3" json:"card,omitempty"`
+	Binary           []byte                 `protobuf:"bytes,13,opt,name=binary,proto3" json:"binary,omitempty"`
+	EncryptedPayload string                 `protobuf:"bytes,14,opt,name=encrypted_payload,json=encryptedPayload,proto3" json:"encrypted_payload,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *DataRecord) Reset() {
+	*x = DataRecord{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DataRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataRecord) ProtoMessage() {}
+
+func (x *DataRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataRecord.ProtoReflect.Descriptor instead.
+func (*DataRecord) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DataRecord) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DataRecord) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *DataRecord) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DataRecord) GetMetadata() string {
+	if x != nil {
+		return x.Metadata
+	}
+	return ""
+}
+
+func (x *DataRecord) GetLogin() string {
+	if x != nil {
+		return x.Login
+	}
+	return ""
+}
+
+func (x *DataRecord) GetClientEncrypted() bool {
+	if x != nil {
+		return x.ClientEncrypted
+	}
+	return false
+}
+
+func (x *DataRecord) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *DataRecord) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *DataRecord) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *DataRecord) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *DataRecord) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *DataRecord) GetCard() *BankCard {
+	if x != nil {
+		return x.Card
+	}
+	return nil
+}
+
+func (x *DataRecord) GetBinary() []byte {
+	if x != nil {
+		return x.Binary
+	}
+	return nil
+}
+
+func (x *DataRecord) GetEncryptedPayload() string {
+	if x != nil {
+		return x.EncryptedPayload
+	}
+	return ""
+}
+
+type ListDataRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Тип записей; пустое значение возвращает записи всех типов.
+	Type          string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDataRequest) Reset() {
+	*x = ListDataRequest{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDataRequest) ProtoMessage() {}
+
+func (x *ListDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDataRequest.ProtoReflect.Descriptor instead.
+func (*ListDataRequest) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListDataRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type ListDataResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []*DataRecord          `protobuf:"bytes,1,rep,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDataResponse) Reset() {
+	*x = ListDataResponse{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDataResponse) ProtoMessage() {}
+
+func (x *ListDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDataResponse.ProtoReflect.Descriptor instead.
+func (*ListDataResponse) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListDataResponse) GetData() []*DataRecord {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type GetDataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDataRequest) Reset() {
+	*x = GetDataRequest{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDataRequest) ProtoMessage() {}
+
+func (x *GetDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDataRequest.ProtoReflect.Descriptor instead.
+func (*GetDataRequest) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetDataRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type CreateDataRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Name  string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// JSON строка с метаданными.
+	Metadata string    `protobuf:"bytes,3,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Login    string    `protobuf:"bytes,4,opt,name=login,proto3" json:"login,omitempty"`
+	Password string    `protobuf:"bytes,5,opt,name=password,proto3" json:"password,omitempty"`
+	Text     string    `protobuf:"bytes,6,opt,name=text,proto3" json:"text,omitempty"`
+	Card     *BankCard `protobuf:"bytes,7,opt,name=card,proto3" json:"card,omitempty"`
+	Binary   []byte    `protobuf:"bytes,8,opt,name=binary,proto3" json:"binary,omitempty"`
+	// Секретные поля, зашифрованные на клиенте, в base64.
+	EncryptedPayload string `protobuf:"bytes,9,opt,name=encrypted_payload,json=encryptedPayload,proto3" json:"encrypted_payload,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *CreateDataRequest) Reset() {
+	*x = CreateDataRequest{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDataRequest) ProtoMessage() {}
+
+func (x *CreateDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDataRequest.ProtoReflect.Descriptor instead.
+func (*CreateDataRequest) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CreateDataRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CreateDataRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateDataRequest) GetMetadata() string {
+	if x != nil {
+		return x.Metadata
+	}
+	return ""
+}
+
+func (x *CreateDataRequest) GetLogin() string {
+	if x != nil {
+		return x.Login
+	}
+	return ""
+}
+
+func (x *CreateDataRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *CreateDataRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *CreateDataRequest) GetCard() *BankCard {
+	if x != nil {
+		return x.Card
+	}
+	return nil
+}
+
+func (x *CreateDataRequest) GetBinary() []byte {
+	if x != nil {
+		return x.Binary
+	}
+	return nil
+}
+
+func (x *CreateDataRequest) GetEncryptedPayload() string {
+	if x != nil {
+		return x.EncryptedPayload
+	}
+	return ""
+}
+
+type UpdateDataRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Ожидаемая версия записи. При несовпадении возвращается ABORTED.
+	Version int64  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Name    string `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	// JSON строка с метаданными.
+	Metadata string    `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Login    string    `protobuf:"bytes,5,opt,name=login,proto3" json:"login,omitempty"`
+	Password string    `protobuf:"bytes,6,opt,name=password,proto3" json:"password,omitempty"`
+	Text     string    `protobuf:"bytes,7,opt,name=text,proto3" json:"text,omitempty"`
+	Card     *BankCard `protobuf:"bytes,8,opt,name=card,proto3" json:"card,omitempty"`
+	Binary   []byte    `protobuf:"bytes,9,opt,name=binary,proto3" json:"binary,omitempty"`
+	// Секретные поля, зашифрованные на клиенте, в base64.
+	EncryptedPayload string `protobuf:"bytes,10,opt,name=encrypted_payload,json=encryptedPayload,proto3" json:"encrypted_payload,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *UpdateDataRequest) Reset() {
+	*x = UpdateDataRequest{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateDataRequest) ProtoMessage() {}
+
+func (x *UpdateDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateDataRequest.ProtoReflect.Descriptor instead.
+func (*UpdateDataRequest) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UpdateDataRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateDataRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateDataRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateDataRequest) GetMetadata() string {
+	if x != nil {
+		return x.Metadata
+	}
+	return ""
+}
+
+func (x *UpdateDataRequest) GetLogin() string {
+	if x != nil {
+		return x.Login
+	}
+	return ""
+}
+
+func (x *UpdateDataRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *UpdateDataRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *UpdateDataRequest) GetCard() *BankCard {
+	if x != nil {
+		return x.Card
+	}
+	return nil
+}
+
+func (x *UpdateDataRequest) GetBinary() []byte {
+	if x != nil {
+		return x.Binary
+	}
+	return nil
+}
+
+func (x *UpdateDataRequest) GetEncryptedPayload() string {
+	if x != nil {
+		return x.EncryptedPayload
+	}
+	return ""
+}
+
+type DeleteDataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDataRequest) Reset() {
+	*x = DeleteDataRequest{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDataRequest) ProtoMessage() {}
+
+func (x *DeleteDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDataRequest.ProtoReflect.Descriptor instead.
+func (*DeleteDataRequest) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *DeleteDataRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteDataResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteDataResponse) Reset() {
+	*x = DeleteDataResponse{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteDataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteDataResponse) ProtoMessage() {}
+
+func (x *DeleteDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteDataResponse.ProtoReflect.Descriptor instead.
+func (*DeleteDataResponse) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{14}
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{15}
+}
+
+// DataEvent описывает изменение записи. Запись передается без секретных полей.
+type DataEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Действие: create, update, delete или restore.
+	Action string      `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"`
+	Data   *DataRecord `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// Устройство, с которого сделано изменение.
+	ClientId      string `protobuf:"bytes,3,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DataEvent) Reset() {
+	*x = DataEvent{}
+	mi := &file_proto_gophkeeper_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DataEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataEvent) ProtoMessage() {}
+
+func (x *DataEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_gophkeeper_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataEvent.ProtoReflect.Descriptor instead.
+func (*DataEvent) Descriptor() ([]byte, []int) {
+	return file_proto_gophkeeper_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DataEvent) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *DataEvent) GetData() *DataRecord {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *DataEvent) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+var File_proto_gophkeeper_proto protoreflect.FileDescriptor
+
+const file_proto_gophkeeper_proto_rawDesc = "" +
+	"\n" +
+	"\x16proto/gophkeeper.proto\x12\rgophkeeper.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x80\x01\n" +
+	"\x0fRegisterRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x1a\n" +
+	"\bpassword\x18\x03 \x01(\tR\bpassword\x12\x1f\n" +
+	"\vdevice_name\x18\x04 \x01(\tR\n" +
+	"deviceName\"g\n" +
+	"\fLoginRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x1f\n" +
+	"\vdevice_name\x18\x03 \x01(\tR\n" +
+	"deviceName\"v\n" +
+	"\x16VerifyTwoFactorRequest\x12'\n" +
+	"\x0fchallenge_token\x18\x01 \x01(\tR\x0echallengeToken\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12\x1f\n" +
+	"\vdevice_name\x18\x03 \x01(\tR\n" +
+	"deviceName\"5\n" +
+	"\x0eRefreshRequest\x12#\n" +
+	"\rrefresh_token\x18\x01 \x01(\tR\frefreshToken\"H\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\"\xc0\x02\n" +
+	"\fAuthResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x19\n" +
+	"\bkdf_salt\x18\x02 \x01(\tR\akdfSalt\x12'\n" +
+	"\x04user\x18\x03 \x01(\v2\x13.gophkeeper.v1.UserR\x04user\x12#\n" +
+	"\rrefresh_token\x18\x04 \x01(\tR\frefreshToken\x129\n" +
+	"\n" +
+	"expires_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x06 \x01(\tR\tsessionId\x12.\n" +
+	"\x13two_factor_required\x18\a \x01(\bR\x11twoFactorRequired\x12'\n" +
+	"\x0fchallenge_token\x18\b \x01(\tR\x0echallengeToken\"d\n" +
+	"\bBankCard\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\tR\x06number\x12\x16\n" +
+	"\x06expiry\x18\x02 \x01(\tR\x06expiry\x12\x10\n" +
+	"\x03cvv\x18\x03 \x01(\tR\x03cvv\x12\x16\n" +
+	"\x06holder\x18\x04 \x01(\tR\x06holder\"\xd3\x03\n" +
+	"\n" +
+	"DataRecord\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x1a\n" +
+	"\bmetadata\x18\x04 \x01(\tR\bmetadata\x12\x14\n" +
+	"\x05login\x18\x05 \x01(\tR\x05login\x12)\n" +
+	"\x10client_encrypted\x18\x06 \x01(\bR\x0fclientEncrypted\x12\x18\n" +
+	"\aversion\x18\a \x01(\x03R\aversion\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1a\n" +
+	"\bpassword\x18\n" +
+	" \x01(\tR\bpassword\x12\x12\n" +
+	"\x04text\x18\v \x01(\tR\x04text\x12+\n" +
+	"\x04card\x18\f \x01(\v2\x17.gophkeeper.v1.BankCardR\x04card\x12\x16\n" +
+	"\x06binary\x18\r \x01(\fR\x06binary\x12+\n" +
+	"\x11encrypted_payload\x18\x0e \x01(\tR\x10encryptedPayload\"%\n" +
+	"\x0fListDataRequest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\"A\n" +
+	"\x10ListDataResponse\x12-\n" +
+	"\x04data\x18\x01 \x03(\v2\x19.gophkeeper.v1.DataRecordR\x04data\" \n" +
+	"\x0eGetDataRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x8f\x02\n" +
+	"\x11CreateDataRequest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1a\n" +
+	"\bmetadata\x18\x03 \x01(\tR\bmetadata\x12\x14\n" +
+	"\x05login\x18\x04 \x01(\tR\x05login\x12\x1a\n" +
+	"\bpassword\x18\x05 \x01(\tR\bpassword\x12\x12\n" +
+	"\x04text\x18\x06 \x01(\tR\x04text\x12+\n" +
+	"\x04card\x18\a \x01(\v2\x17.gophkeeper.v1.BankCardR\x04card\x12\x16\n" +
+	"\x06binary\x18\b \x01(\fR\x06binary\x12+\n" +
+	"\x11encrypted_payload\x18\t \x01(\tR\x10encryptedPayload\"\xa5\x02\n" +
+	"\x11UpdateDataRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x1a\n" +
+	"\bmetadata\x18\x04 \x01(\tR\bmetadata\x12\x14\n" +
+	"\x05login\x18\x05 \x01(\tR\x05login\x12\x1a\n" +
+	"\bpassword\x18\x06 \x01(\tR\bpassword\x12\x12\n" +
+	"\x04text\x18\a \x01(\tR\x04text\x12+\n" +
+	"\x04card\x18\b \x01(\v2\x17.gophkeeper.v1.BankCardR\x04card\x12\x16\n" +
+	"\x06binary\x18\t \x01(\fR\x06binary\x12+\n" +
+	"\x11encrypted_payload\x18\n" +
+	" \x01(\tR\x10encryptedPayload\"#\n" +
+	"\x11DeleteDataRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x14\n" +
+	"\x12DeleteDataResponse\"\x0e\n" +
+	"\fWatchRequest\"o\n" +
+	"\tDataEvent\x12\x16\n" +
+	"\x06action\x18\x01 \x01(\tR\x06action\x12-\n" +
+	"\x04data\x18\x02 \x01(\v2\x19.gophkeeper.v1.DataRecordR\x04data\x12\x1b\n" +
+	"\tclient_id\x18\x03 \x01(\tR\bclientId2\xb7\x02\n" +
+	"\vAuthService\x12G\n" +
+	"\bRegister\x12\x1e.gophkeeper.v1.RegisterRequest\x1a\x1b.gophkeeper.v1.AuthResponse\x12A\n" +
+	"\x05Login\x12\x1b.gophkeeper.v1.LoginRequest\x1a\x1b.gophkeeper.v1.AuthResponse\x12U\n" +
+	"\x0fVerifyTwoFactor\x12%.gophkeeper.v1.VerifyTwoFactorRequest\x1a\x1b.gophkeeper.v1.AuthResponse\x12E\n" +
+	"\aRefresh\x12\x1d.gophkeeper.v1.RefreshRequest\x1a\x1b.gophkeeper.v1.AuthResponse2\xca\x03\n" +
+	"\vDataService\x12K\n" +
+	"\bListData\x12\x1e.gophkeeper.v1.ListDataRequest\x1a\x1f.gophkeeper.v1.ListDataResponse\x12C\n" +
+	"\aGetData\x12\x1d.gophkeeper.v1.GetDataRequest\x1a\x19.gophkeeper.v1.DataRecord\x12I\n" +
+	"\n" +
+	"CreateData\x12 .gophkeeper.v1.CreateDataRequest\x1a\x19.gophkeeper.v1.DataRecord\x12I\n" +
+	"\n" +
+	"UpdateData\x12 .gophkeeper.v1.UpdateDataRequest\x1a\x19.gophkeeper.v1.DataRecord\x12Q\n" +
+	"\n" +
+	"DeleteData\x12 .gophkeeper.v1.DeleteDataRequest\x1a!.gophkeeper.v1.DeleteDataResponse\x12@\n" +
+	"\x05Watch\x12\x1b.gophkeeper.v1.WatchRequest\x1a\x18.gophkeeper.v1.DataEvent0\x01B7Z5github.com/AlexeySalamakhin/GophKeeper/internal/pb;pbb\x06proto3"
+
+var (
+	file_proto_gophkeeper_proto_rawDescOnce sync.Once
+	file_proto_gophkeeper_proto_rawDescData []byte
+)
+
+func file_proto_gophkeeper_proto_rawDescGZIP() []byte {
+	file_proto_gophkeeper_proto_rawDescOnce.Do(func() {
+		file_proto_gophkeeper_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_gophkeeper_proto_rawDesc), len(file_proto_gophkeeper_proto_rawDesc)))
+	})
+	return file_proto_gophkeeper_proto_rawDescData
+}
+
+var file_proto_gophkeeper_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_proto_gophkeeper_proto_goTypes = []any{
+	(*RegisterRequest)(nil),        // 0: gophkeeper.v1.RegisterRequest
+	(*LoginRequest)(nil),           // 1: gophkeeper.v1.LoginRequest
+	(*VerifyTwoFactorRequest)(nil), // 2: gophkeeper.v1.VerifyTwoFactorRequest
+	(*RefreshRequest)(nil),         // 3: gophkeeper.v1.RefreshRequest
+	(*User)(nil),                   // 4: gophkeeper.v1.User
+	(*AuthResponse)(nil),           // 5: gophkeeper.v1.AuthResponse
+	(*BankCard)(nil),               // 6: gophkeeper.v1.BankCard
+	(*DataRecord)(nil),             // 7: gophkeeper.v1.DataRecord
+	(*ListDataRequest)(nil),        // 8: gophkeeper.v1.ListDataRequest
+	(*ListDataResponse)(nil),       // 9: gophkeeper.v1.ListDataResponse
+	(*GetDataRequest)(nil),         // 10: gophkeeper.v1.GetDataRequest
+	(*CreateDataRequest)(nil),      // 11: gophkeeper.v1.CreateDataRequest
+	(*UpdateDataRequest)(nil),      // 12: gophkeeper.v1.UpdateDataRequest
+	(*DeleteDataRequest)(nil),      // 13: gophkeeper.v1.DeleteDataRequest
+	(*DeleteDataResponse)(nil),     // 14: gophkeeper.v1.DeleteDataResponse
+	(*WatchRequest)(nil),           // 15: gophkeeper.v1.WatchRequest
+	(*DataEvent)(nil),              // 16: gophkeeper.v1.DataEvent
+	(*timestamppb.Timestamp)(nil),  // 17: google.protobuf.Timestamp
+}
+var file_proto_gophkeeper_proto_depIdxs = []int32{
+	4,  // 0: gophkeeper.v1.AuthResponse.user:type_name -> gophkeeper.v1.User
+	17, // 1: gophkeeper.v1.AuthResponse.expires_at:type_name -> google.protobuf.Timestamp
+	17, // 2: gophkeeper.v1.DataRecord.created_at:type_name -> google.protobuf.Timestamp
+	17, // 3: gophkeeper.v1.DataRecord.updated_at:type_name -> google.protobuf.Timestamp
+	6,  // 4: gophkeeper.v1.DataRecord.card:type_name -> gophkeeper.v1.BankCard
+	7,  // 5: gophkeeper.v1.ListDataResponse.data:type_name -> gophkeeper.v1.DataRecord
+	6,  // 6: gophkeeper.v1.CreateDataRequest.card:type_name -> gophkeeper.v1.BankCard
+	6,  // 7: gophkeeper.v1.UpdateDataRequest.card:type_name -> gophkeeper.v1.BankCard
+	7,  // 8: gophkeeper.v1.DataEvent.data:type_name -> gophkeeper.v1.DataRecord
+	0,  // 9: gophkeeper.v1.AuthService.Register:input_type -> gophkeeper.v1.RegisterRequest
+	1,  // 10: gophkeeper.v1.AuthService.Login:input_type -> gophkeeper.v1.LoginRequest
+	2,  // 11: gophkeeper.v1.AuthService.VerifyTwoFactor:input_type -> gophkeeper.v1.VerifyTwoFactorRequest
+	3,  // 12: gophkeeper.v1.AuthService.Refresh:input_type -> gophkeeper.v1.RefreshRequest
+	8,  // 13: gophkeeper.v1.DataService.ListData:input_type -> gophkeeper.v1.ListDataRequest
+	10, // 14: gophkeeper.v1.DataService.GetData:input_type -> gophkeeper.v1.GetDataRequest
+	11, // 15: gophkeeper.v1.DataService.CreateData:input_type -> gophkeeper.v1.CreateDataRequest
+	12, // 16: gophkeeper.v1.DataService.UpdateData:input_type -> gophkeeper.v1.UpdateDataRequest
+	13, // 17: gophkeeper.v1.DataService.DeleteData:input_type -> gophkeeper.v1.DeleteDataRequest
+	15, // 18: gophkeeper.v1.DataService.Watch:input_type -> gophkeeper.v1.WatchRequest
+	5,  // 19: gophkeeper.v1.AuthService.Register:output_type -> gophkeeper.v1.AuthResponse
+	5,  // 20: gophkeeper.v1.AuthService.Login:output_type -> gophkeeper.v1.AuthResponse
+	5,  // 21: gophkeeper.v1.AuthService.VerifyTwoFactor:output_type -> gophkeeper.v1.AuthResponse
+	5,  // 22: gophkeeper.v1.AuthService.Refresh:output_type -> gophkeeper.v1.AuthResponse
+	9,  // 23: gophkeeper.v1.DataService.ListData:output_type -> gophkeeper.v1.ListDataResponse
+	7,  // 24: gophkeeper.v1.DataService.GetData:output_type -> gophkeeper.v1.DataRecord
+	7,  // 25: gophkeeper.v1.DataService.CreateData:output_type -> gophkeeper.v1.DataRecord
+	7,  // 26: gophkeeper.v1.DataService.UpdateData:output_type -> gophkeeper.v1.DataRecord
+	14, // 27: gophkeeper.v1.DataService.DeleteData:output_type -> gophkeeper.v1.DeleteDataResponse
+	16, // 28: gophkeeper.v1.DataService.Watch:output_type -> gophkeeper.v1.DataEvent
+	19, // [19:29] is the sub-list for method output_type
+	9,  // [9:19] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_proto_gophkeeper_proto_init() }
+func file_proto_gophkeeper_proto_init() {
+	if File_proto_gophkeeper_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_gophkeeper_proto_rawDesc), len(file_proto_gophkeeper_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_proto_gophkeeper_proto_goTypes,
+		DependencyIndexes: file_proto_gophkeeper_proto_depIdxs,
+		MessageInfos:      file_proto_gophkeeper_proto_msgTypes,
+	}.Build()
+	File_proto_gophkeeper_proto = out.File
+	file_proto_gophkeeper_proto_goTypes = nil
+	file_proto_gophkeeper_proto_depIdxs = nil
+}
@@ -0,0 +1,20 @@
+// Package repository содержит слой доступа к данным.
+package repository
+
+import "errors"
+
+// ErrVersionConflict возвращается, когда запись была изменена после того,
+// как клиент получил ее версию.
+var ErrVersionConflict = errors.New("версия данных не совпадает")
+
+// ErrCiphertextChanged возвращается при перешифровании, когда зашифрованное значение
+// изменилось после чтения, например, было перезаписано параллельным запросом.
+var ErrCiphertextChanged = errors.New("зашифрованные данные изменились")
+
+// ErrCodeUsed возвращается, когда одноразовый пароль или код восстановления
+// уже принят параллельным запросом.
+var ErrCodeUsed = errors.New("код уже использован")
+
+// ErrKDFCheckSet возвращается, когда проверочное значение ключа хранилища
+// пользователя уже задано и не может быть заменено.
+var ErrKDFCheckSet = errors.New("проверочное значение ключа хранилища уже задано")
@@ -2,6 +2,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
 	"github.com/google/uuid"
 )
@@ -12,15 +14,139 @@ type UserRepositoryInterface interface {
 	GetByUsername(username string) (*models.User, error)
 	GetByEmail(email string) (*models.User, error)
 	GetByID(id uuid.UUID) (*models.User, error)
+	Update(user *models.User) error
+	GetBatchAfter(afterID uuid.UUID, limit int) ([]models.User, error)
+	ReplaceTOTPSecret(id uuid.UUID, current, secret string) error
+	AddTOTPFailure(id uuid.UUID) (int, error)
+	LockTOTP(id uuid.UUID, until time.Time) error
+	UseTOTPStep(id uuid.UUID, step int64) error
+	UseRecoveryCode(id uuid.UUID, current, remaining string) error
+	ReplaceDataKey(id uuid.UUID, current, dataKey string) error
+	SetKDFCheck(id uuid.UUID, check string) error
 }
 
 // DataRepositoryInterface определяет интерфейс для работы с данными.
 type DataRepositoryInterface interface {
 	Create(data *models.Data) error
 	GetByID(id uuid.UUID) (*models.Data, error)
+	GetByIDs(ids []uuid.UUID) ([]models.Data, error)
 	GetByUserID(userID uuid.UUID) ([]models.Data, error)
+	GetByUserIDAndType(userID uuid.UUID, dataType models.DataType) ([]models.Data, error)
+	GetByVaultID(vaultID uuid.UUID) ([]models.Data, error)
+	List(userID uuid.UUID, query DataQuery) ([]models.Data, error)
+	Search(userID uuid.UUID, query string, limit int) ([]DataMatch, error)
 	Update(data *models.Data) error
+	UpdateWithVersion(data *models.Data, expectedVersion int64) error
 	Delete(id uuid.UUID) error
+	Restore(id uuid.UUID) error
+	GetDeletedByID(id uuid.UUID) (*models.Data, error)
+	GetDeletedByUserID(userID uuid.UUID) ([]models.Data, error)
+	Purge(id uuid.UUID) error
+	PurgeDeletedBefore(cutoff time.Time) (int64, error)
 	CheckUserOwnership(dataID, userID uuid.UUID) error
+	GetChangedSince(userID uuid.UUID, since time.Time) ([]models.Data, error)
+	GetPurgedSince(userID uuid.UUID, since time.Time) ([]models.DataTombstone, error)
+	GetBatchAfter(afterID uuid.UUID, limit int) ([]models.Data, error)
+	ReplaceCiphertext(id uuid.UUID, oldPassword, oldPayload, password, payload string) error
+	Transaction(fn func(uow *UnitOfWork) error) error
+}
+
+// UnitOfWork содержит репозитории, изменения через которые применяются вместе.
+// Его передает в fn метод Transaction репозитория данных: если fn возвращает ошибку,
+// все изменения записей, ревизий, меток, папок и описаний файлов, сделанные через эти
+// репозитории, отменяются.
+type UnitOfWork struct {
+	Data        DataRepositoryInterface
+	Revisions   RevisionRepositoryInterface
+	Tags        TagRepositoryInterface
+	Folders     FolderRepositoryInterface
+	Attachments AttachmentRepositoryInterface
+}
+
+// RevisionRepositoryInterface определяет интерфейс для работы с историей изменений данных.
+type RevisionRepositoryInterface interface {
+	Create(revision *models.DataRevision) error
+	GetByID(id uuid.UUID) (*models.DataRevision, error)
+	GetByDataID(dataID uuid.UUID) ([]models.DataRevision, error)
+	GetBatchAfter(afterID uuid.UUID, limit int) ([]models.DataRevision, error)
+	ReplaceCiphertext(id uuid.UUID, oldPassword, oldPayload, password, payload string) error
+}
+
+// AttachmentRepositoryInterface определяет интерфейс для работы с файлами, загружаемыми фрагментами.
+type AttachmentRepositoryInterface interface {
+	Create(attachment *models.Attachment) error
+	GetByDataID(dataID uuid.UUID) (*models.Attachment, error)
+	MarkComplete(dataID uuid.UUID) error
+	SaveChunk(chunk *models.AttachmentChunk) error
+	GetChunks(dataID uuid.UUID) ([]models.AttachmentChunk, error)
+	GetOrphaned() ([]models.Attachment, error)
+	Delete(dataID uuid.UUID) error
+}
+
+// SessionRepositoryInterface определяет интерфейс для работы с сессиями пользователей.
+type SessionRepositoryInterface interface {
+	Create(session *models.Session) error
+	GetByID(id uuid.UUID) (*models.Session, error)
+	GetByRefreshTokenHash(hash string) (*models.Session, error)
+	GetActiveByUserID(userID uuid.UUID, now time.Time) ([]models.Session, error)
+	Rotate(session *models.Session, currentHash string) error
+	Touch(id uuid.UUID, ip string, at time.Time) error
+	Revoke(id uuid.UUID, at time.Time) error
+	RevokeByUserID(userID uuid.UUID, at time.Time) (int64, error)
+}
+
+// ShareRepositoryInterface определяет интерфейс для работы с доступами к записям других пользователей.
+type ShareRepositoryInterface interface {
+	Save(share *models.Share) error
+	GetByDataID(dataID uuid.UUID) ([]models.Share, error)
+	GetActive(dataID, granteeID uuid.UUID, now time.Time) (*models.Share, error)
+	GetActiveByGranteeID(granteeID uuid.UUID, now time.Time) ([]models.Share, error)
+	Delete(id uuid.UUID) error
+}
+
+// VaultRepositoryInterface определяет интерфейс для работы с командными хранилищами и их участниками.
+type VaultRepositoryInterface interface {
+	Create(vault *models.Vault, owner *models.VaultMember) error
+	GetByID(id uuid.UUID) (*models.Vault, error)
+	Delete(id uuid.UUID) error
+	GetMember(vaultID, userID uuid.UUID) (*models.VaultMember, error)
+	GetMembers(vaultID uuid.UUID) ([]models.VaultMember, error)
+	GetMemberships(userID uuid.UUID) ([]models.VaultMember, error)
+	SaveMember(member *models.VaultMember) error
+	DeleteMember(vaultID, userID uuid.UUID) error
+	GetBatchAfter(afterID uuid.UUID, limit int) ([]models.Vault, error)
+	ReplaceDataKey(id uuid.UUID, current, dataKey string) error
 }
 
+// TagRepositoryInterface определяет интерфейс для работы с метками и их назначением записям.
+type TagRepositoryInterface interface {
+	Create(tag *models.Tag) error
+	GetByID(id uuid.UUID) (*models.Tag, error)
+	GetByName(userID uuid.UUID, name string) (*models.Tag, error)
+	GetByUserID(userID uuid.UUID) ([]models.Tag, error)
+	Update(tag *models.Tag) error
+	Delete(id uuid.UUID) error
+	SetDataTags(dataID uuid.UUID, tagIDs []uuid.UUID) error
+	GetNamesByDataIDs(dataIDs []uuid.UUID) (map[uuid.UUID][]string, error)
+}
+
+// FolderRepositoryInterface определяет интерфейс для работы с деревом папок пользователя.
+type FolderRepositoryInterface interface {
+	Create(folder *models.Folder) error
+	GetByID(id uuid.UUID) (*models.Folder, error)
+	GetByUserID(userID uuid.UUID) ([]models.Folder, error)
+	Update(folder *models.Folder) error
+	Delete(id uuid.UUID) error
+}
+
+// AuditRepositoryInterface определяет интерфейс журнала аудита. Журнал только дополняется,
+// заменить можно лишь подпись контрольной точки при смене ключа сервера.
+type AuditRepositoryInterface interface {
+	Create(event *models.AuditEvent) error
+	GetByActorID(actorID uuid.UUID, limit, offset int) ([]models.AuditEvent, int64, error)
+	GetLast() (*models.AuditEvent, error)
+	GetAfter(seq int64, limit int) ([]models.AuditEvent, error)
+	CreateCheckpoint(checkpoint *models.AuditCheckpoint) error
+	GetCheckpoints() ([]models.AuditCheckpoint, error)
+	ReplaceCheckpointSignature(id uuid.UUID, oldSignature, newSignature string) error
+}
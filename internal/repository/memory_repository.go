@@ -2,25 +2,57 @@
 package repository
 
 import (
+	"bytes"
 	"errors"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // MemoryRepository представляет in-memory репозиторий для тестов.
 type MemoryRepository struct {
-	users map[uuid.UUID]*models.User
-	data  map[uuid.UUID]*models.Data
-	mutex sync.RWMutex
+	users     map[uuid.UUID]*models.User
+	data      map[uuid.UUID]*models.Data
+	revisions map[uuid.UUID]*models.DataRevision
+	files     map[uuid.UUID]*models.Attachment
+	chunks    map[uuid.UUID]map[int]models.AttachmentChunk
+	sessions  map[uuid.UUID]*models.Session
+	shares    map[uuid.UUID]*models.Share
+	vaults    map[uuid.UUID]*models.Vault
+	members   map[uuid.UUID]map[uuid.UUID]*models.VaultMember
+	audit     []models.AuditEvent
+	auditCPs  []models.AuditCheckpoint
+	tags      map[uuid.UUID]*models.Tag
+	dataTags  map[uuid.UUID]map[uuid.UUID]bool
+	folders   map[uuid.UUID]*models.Folder
+	purged    map[uuid.UUID]*models.DataTombstone
+	mutex     sync.RWMutex
+	// txMutex выполняет транзакции по одной
+	txMutex sync.Mutex
 }
 
 // NewMemoryRepository создает новый in-memory репозиторий.
 func NewMemoryRepository() *MemoryRepository {
 	return &MemoryRepository{
-		users: make(map[uuid.UUID]*models.User),
-		data:  make(map[uuid.UUID]*models.Data),
+		users:     make(map[uuid.UUID]*models.User),
+		data:      make(map[uuid.UUID]*models.Data),
+		revisions: make(map[uuid.UUID]*models.DataRevision),
+		files:     make(map[uuid.UUID]*models.Attachment),
+		chunks:    make(map[uuid.UUID]map[int]models.AttachmentChunk),
+		sessions:  make(map[uuid.UUID]*models.Session),
+		shares:    make(map[uuid.UUID]*models.Share),
+		vaults:    make(map[uuid.UUID]*models.Vault),
+		members:   make(map[uuid.UUID]map[uuid.UUID]*models.VaultMember),
+		tags:      make(map[uuid.UUID]*models.Tag),
+		dataTags:  make(map[uuid.UUID]map[uuid.UUID]bool),
+		folders:   make(map[uuid.UUID]*models.Folder),
+		purged:    make(map[uuid.UUID]*models.DataTombstone),
 	}
 }
 
@@ -95,9 +127,159 @@ func (mur *MemoryUserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 	return user, nil
 }
 
+// Update обновляет пользователя.
+func (mur *MemoryUserRepository) Update(user *models.User) error {
+	mur.repo.mutex.Lock()
+	defer mur.repo.mutex.Unlock()
+
+	if _, exists := mur.repo.users[user.ID]; !exists {
+		return errors.New("пользователь не найден")
+	}
+
+	mur.repo.users[user.ID] = user
+	return nil
+}
+
+// GetBatchAfter возвращает до limit пользователей с ID больше afterID в порядке возрастания ID.
+func (mur *MemoryUserRepository) GetBatchAfter(afterID uuid.UUID, limit int) ([]models.User, error) {
+	mur.repo.mutex.RLock()
+	defer mur.repo.mutex.RUnlock()
+
+	var users []models.User
+	for id, user := range mur.repo.users {
+		if idLess(afterID, id) {
+			users = append(users, *user)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool { return idLess(users[i].ID, users[j].ID) })
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
+// ReplaceTOTPSecret заменяет зашифрованный секрет одноразовых паролей, если он не изменился.
+func (mur *MemoryUserRepository) ReplaceTOTPSecret(id uuid.UUID, current, secret string) error {
+	mur.repo.mutex.Lock()
+	defer mur.repo.mutex.Unlock()
+
+	user, exists := mur.repo.users[id]
+	if !exists || user.TOTPSecret != current {
+		return ErrCiphertextChanged
+	}
+
+	updated := *user
+	updated.TOTPSecret = secret
+	mur.repo.users[id] = &updated
+	return nil
+}
+
+// AddTOTPFailure увеличивает счетчик неверных одноразовых паролей и возвращает его новое значение.
+func (mur *MemoryUserRepository) AddTOTPFailure(id uuid.UUID) (int, error) {
+	mur.repo.mutex.Lock()
+	defer mur.repo.mutex.Unlock()
+
+	user, exists := mur.repo.users[id]
+	if !exists {
+		return 0, gorm.ErrRecordNotFound
+	}
+
+	updated := *user
+	updated.TOTPFailures++
+	mur.repo.users[id] = &updated
+	return updated.TOTPFailures, nil
+}
+
+// LockTOTP запрещает проверку одноразовых паролей до until и обнуляет счетчик неверных паролей.
+func (mur *MemoryUserRepository) LockTOTP(id uuid.UUID, until time.Time) error {
+	mur.repo.mutex.Lock()
+	defer mur.repo.mutex.Unlock()
+
+	user, exists := mur.repo.users[id]
+	if !exists {
+		return gorm.ErrRecordNotFound
+	}
+
+	updated := *user
+	updated.TOTPFailures = 0
+	updated.TOTPLockedUntil = &until
+	mur.repo.users[id] = &updated
+	return nil
+}
+
+// UseTOTPStep запоминает шаг принятого одноразового пароля, если пароль этого шага еще не принят.
+func (mur *MemoryUserRepository) UseTOTPStep(id uuid.UUID, step int64) error {
+	mur.repo.mutex.Lock()
+	defer mur.repo.mutex.Unlock()
+
+	user, exists := mur.repo.users[id]
+	if !exists || user.TOTPLastStep >= step {
+		return ErrCodeUsed
+	}
+
+	updated := *user
+	updated.TOTPLastStep = step
+	updated.TOTPFailures = 0
+	updated.TOTPLockedUntil = nil
+	mur.repo.users[id] = &updated
+	return nil
+}
+
+// UseRecoveryCode заменяет хеши кодов восстановления, если они не изменились с момента чтения.
+func (mur *MemoryUserRepository) UseRecoveryCode(id uuid.UUID, current, remaining string) error {
+	mur.repo.mutex.Lock()
+	defer mur.repo.mutex.Unlock()
+
+	user, exists := mur.repo.users[id]
+	if !exists || user.RecoveryCodes != current {
+		return ErrCodeUsed
+	}
+
+	updated := *user
+	updated.RecoveryCodes = remaining
+	updated.TOTPFailures = 0
+	updated.TOTPLockedUntil = nil
+	mur.repo.users[id] = &updated
+	return nil
+}
+
+// SetKDFCheck сохраняет проверочное значение ключа хранилища пользователя, если оно еще не задано.
+func (mur *MemoryUserRepository) SetKDFCheck(id uuid.UUID, check string) error {
+	mur.repo.mutex.Lock()
+	defer mur.repo.mutex.Unlock()
+
+	user, exists := mur.repo.users[id]
+	if !exists || user.KDFCheck != "" {
+		return ErrKDFCheckSet
+	}
+
+	updated := *user
+	updated.KDFCheck = check
+	mur.repo.users[id] = &updated
+	return nil
+}
+
+// ReplaceDataKey заменяет зашифрованный ключ данных пользователя, если он не изменился.
+func (mur *MemoryUserRepository) ReplaceDataKey(id uuid.UUID, current, dataKey string) error {
+	mur.repo.mutex.Lock()
+	defer mur.repo.mutex.Unlock()
+
+	user, exists := mur.repo.users[id]
+	if !exists || user.DataKey != current {
+		return ErrCiphertextChanged
+	}
+
+	updated := *user
+	updated.DataKey = dataKey
+	mur.repo.users[id] = &updated
+	return nil
+}
+
 // DataRepository содержит методы для работы с данными.
 type MemoryDataRepository struct {
 	repo *MemoryRepository
+	tx   *undoLog // Журнал отката, если репозиторий работает в транзакции
 }
 
 // NewDataRepository создает новый репозиторий данных.
@@ -114,6 +296,17 @@ func (mdr *MemoryDataRepository) Create(data *models.Data) error {
 		data.ID = uuid.New()
 	}
 
+	if data.Version == 0 {
+		data.Version = 1
+	}
+
+	now := time.Now()
+	if data.CreatedAt.IsZero() {
+		data.CreatedAt = now
+	}
+	data.UpdatedAt = now
+
+	mdr.tx.keepData(mdr.repo, data.ID)
 	mdr.repo.data[data.ID] = data
 	return nil
 }
@@ -124,61 +317,432 @@ func (mdr *MemoryDataRepository) GetByID(id uuid.UUID) (*models.Data, error) {
 	defer mdr.repo.mutex.RUnlock()
 
 	data, exists := mdr.repo.data[id]
-	if !exists {
+	if !exists || data.DeletedAt.Valid {
 		return nil, errors.New("данные не найдены")
 	}
-	return data, nil
+
+	// Возвращается копия, чтобы изменения не попадали в хранилище до Update, как и с базой данных
+	dataCopy := *data
+	return &dataCopy, nil
+}
+
+// GetByIDs возвращает неудаленные данные с указанными ID.
+func (mdr *MemoryDataRepository) GetByIDs(ids []uuid.UUID) ([]models.Data, error) {
+	mdr.repo.mutex.RLock()
+	defer mdr.repo.mutex.RUnlock()
+
+	var result []models.Data
+	for _, id := range ids {
+		if data, exists := mdr.repo.data[id]; exists && !data.DeletedAt.Valid {
+			result = append(result, *data)
+		}
+	}
+	return result, nil
 }
 
-// GetByUserID возвращает все данные пользователя.
+// GetByUserID возвращает все личные данные пользователя.
 func (mdr *MemoryDataRepository) GetByUserID(userID uuid.UUID) ([]models.Data, error) {
 	mdr.repo.mutex.RLock()
 	defer mdr.repo.mutex.RUnlock()
 
 	var userData []models.Data
 	for _, data := range mdr.repo.data {
-		if data.UserID == userID {
+		if data.UserID == userID && data.VaultID == nil && !data.DeletedAt.Valid {
+			userData = append(userData, *data)
+		}
+	}
+	return userData, nil
+}
+
+// List возвращает до query.Limit записей пользователя, подходящих под фильтры запроса,
+// в порядке query.Sort после курсора query.After.
+func (mdr *MemoryDataRepository) List(userID uuid.UUID, query DataQuery) ([]models.Data, error) {
+	mdr.repo.mutex.RLock()
+	defer mdr.repo.mutex.RUnlock()
+
+	shared := make(map[uuid.UUID]bool)
+	if query.IncludeShared {
+		shared = mdr.sharedWith(userID)
+	}
+	tagIDs, ok := mdr.userTagIDs(userID, query.Tags)
+	if !ok {
+		return nil, nil
+	}
+
+	order := query.sortOrder()
+	prefix := strings.ToLower(query.NamePrefix)
+	var result []models.Data
+	for _, data := range mdr.repo.data {
+		if data.DeletedAt.Valid {
+			continue
+		}
+		if !(data.UserID == userID && data.VaultID == nil) && !shared[data.ID] {
+			continue
+		}
+		if query.Type != "" && data.Type != query.Type {
+			continue
+		}
+		if prefix != "" && !strings.HasPrefix(strings.ToLower(data.Name), prefix) {
+			continue
+		}
+		if query.UpdatedSince != nil && !data.UpdatedAt.After(*query.UpdatedSince) {
+			continue
+		}
+		if !mdr.hasTags(data.ID, tagIDs) {
+			continue
+		}
+		if query.FolderID != nil && (data.FolderID == nil || *data.FolderID != *query.FolderID) {
+			continue
+		}
+		if query.Favorite && !data.Favorite {
+			continue
+		}
+		if query.After != nil {
+			c := compareData(order, data, query.After)
+			if (order.desc() && c >= 0) || (!order.desc() && c <= 0) {
+				continue
+			}
+		}
+		result = append(result, *data)
+	}
+
+	slices.SortFunc(result, func(a, b models.Data) int {
+		cursor := CursorFor(order, &b)
+		c := compareData(order, &a, &cursor)
+		if order.desc() {
+			return -c
+		}
+		return c
+	})
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+// Search ищет личные записи пользователя, открытые ему записи и записи его хранилищ,
+// в названии, логине или метаданных которых встречается каждое слово query. Вместо полнотекстового поиска
+// Postgres используется поиск подстроки без учета регистра.
+func (mdr *MemoryDataRepository) Search(userID uuid.UUID, query string, limit int) ([]DataMatch, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	mdr.repo.mutex.RLock()
+	defer mdr.repo.mutex.RUnlock()
+
+	shared := mdr.sharedWith(userID)
+	var result []DataMatch
+	for _, data := range mdr.repo.data {
+		if data.DeletedAt.Valid {
+			continue
+		}
+		if !mdr.accessible(data, userID, shared) {
+			continue
+		}
+		if rank := matchRank(data, terms); rank > 0 {
+			result = append(result, DataMatch{Data: *data, Rank: rank})
+		}
+	}
+
+	slices.SortFunc(result, func(a, b DataMatch) int {
+		if a.Rank != b.Rank {
+			if a.Rank > b.Rank {
+				return -1
+			}
+			return 1
+		}
+		if c := strings.Compare(a.Data.Name, b.Data.Name); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.Data.ID[:], b.Data.ID[:])
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// matchRank возвращает релевантность записи для слов terms или 0, если какое-то слово
+// не найдено. Совпадение в названии весит больше, чем в логине, а в логине - больше, чем в метаданных.
+func matchRank(data *models.Data, terms []string) float64 {
+	name := strings.ToLower(data.Name)
+	login := strings.ToLower(data.Login)
+	metadata := strings.ToLower(data.Metadata)
+
+	var rank float64
+	for _, term := range terms {
+		var termRank float64
+		if strings.Contains(name, term) {
+			termRank += 3
+			if strings.HasPrefix(name, term) {
+				termRank++
+			}
+		}
+		if strings.Contains(login, term) {
+			termRank += 2
+		}
+		if strings.Contains(metadata, term) {
+			termRank++
+		}
+		if termRank == 0 {
+			return 0
+		}
+		rank += termRank
+	}
+	return rank
+}
+
+// userTagIDs возвращает ID меток пользователя с именами names. Если какой-то метки
+// нет, возвращает false. Вызывается под блокировкой репозитория.
+func (mdr *MemoryDataRepository) userTagIDs(userID uuid.UUID, names []string) ([]uuid.UUID, bool) {
+	ids := make([]uuid.UUID, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, tag := range mdr.repo.tags {
+			if tag.UserID == userID && tag.Name == name {
+				ids = append(ids, tag.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return ids, true
+}
+
+// hasTags проверяет, что записи назначены все метки tagIDs. Вызывается под блокировкой репозитория.
+func (mdr *MemoryDataRepository) hasTags(dataID uuid.UUID, tagIDs []uuid.UUID) bool {
+	for _, id := range tagIDs {
+		if !mdr.repo.dataTags[dataID][id] {
+			return false
+		}
+	}
+	return true
+}
+
+// sharedWith возвращает ID записей, открытых пользователю действующими доступами.
+// Вызывается под блокировкой репозитория.
+func (mdr *MemoryDataRepository) sharedWith(userID uuid.UUID) map[uuid.UUID]bool {
+	shared := make(map[uuid.UUID]bool)
+	now := time.Now()
+	for _, share := range mdr.repo.shares {
+		if share.GranteeID == userID && share.Active(now) {
+			shared[share.DataID] = true
+		}
+	}
+	return shared
+}
+
+// accessible проверяет, доступна ли запись пользователю: запись хранилища - его участникам,
+// личная запись - владельцу и пользователям, которым она открыта, из shared.
+// Вызывается под блокировкой репозитория.
+func (mdr *MemoryDataRepository) accessible(data *models.Data, userID uuid.UUID, shared map[uuid.UUID]bool) bool {
+	if data.VaultID != nil {
+		_, member := mdr.repo.members[*data.VaultID][userID]
+		return member
+	}
+	return data.UserID == userID || shared[data.ID]
+}
+
+// GetByUserIDAndType возвращает личные данные пользователя указанного типа.
+func (mdr *MemoryDataRepository) GetByUserIDAndType(userID uuid.UUID, dataType models.DataType) ([]models.Data, error) {
+	mdr.repo.mutex.RLock()
+	defer mdr.repo.mutex.RUnlock()
+
+	var userData []models.Data
+	for _, data := range mdr.repo.data {
+		if data.UserID == userID && data.VaultID == nil && data.Type == dataType && !data.DeletedAt.Valid {
 			userData = append(userData, *data)
 		}
 	}
 	return userData, nil
 }
 
-// Update обновляет данные.
+// GetByVaultID возвращает данные командного хранилища.
+func (mdr *MemoryDataRepository) GetByVaultID(vaultID uuid.UUID) ([]models.Data, error) {
+	mdr.repo.mutex.RLock()
+	defer mdr.repo.mutex.RUnlock()
+
+	var vaultData []models.Data
+	for _, data := range mdr.repo.data {
+		if data.VaultID != nil && *data.VaultID == vaultID && !data.DeletedAt.Valid {
+			vaultData = append(vaultData, *data)
+		}
+	}
+
+	sort.Slice(vaultData, func(i, j int) bool {
+		return vaultData[i].Name < vaultData[j].Name
+	})
+	return vaultData, nil
+}
+
+// Update обновляет данные и увеличивает их версию.
 func (mdr *MemoryDataRepository) Update(data *models.Data) error {
 	mdr.repo.mutex.Lock()
 	defer mdr.repo.mutex.Unlock()
 
-	_, exists := mdr.repo.data[data.ID]
-	if !exists {
+	existing, exists := mdr.repo.data[data.ID]
+	if !exists || existing.DeletedAt.Valid {
 		return errors.New("данные не найдены")
 	}
 
-	mdr.repo.data[data.ID] = data
+	data.Version = existing.Version + 1
+	data.UpdatedAt = time.Now()
+	stored := *data
+	mdr.tx.keepData(mdr.repo, data.ID)
+	mdr.repo.data[data.ID] = &stored
+	return nil
+}
+
+// UpdateWithVersion обновляет данные, только если их текущая версия совпадает с ожидаемой.
+func (mdr *MemoryDataRepository) UpdateWithVersion(data *models.Data, expectedVersion int64) error {
+	mdr.repo.mutex.Lock()
+	defer mdr.repo.mutex.Unlock()
+
+	existing, exists := mdr.repo.data[data.ID]
+	if !exists || existing.DeletedAt.Valid {
+		return errors.New("данные не найдены")
+	}
+	if existing.Version != expectedVersion {
+		return ErrVersionConflict
+	}
+
+	data.Version = expectedVersion + 1
+	data.UpdatedAt = time.Now()
+	stored := *data
+	mdr.tx.keepData(mdr.repo, data.ID)
+	mdr.repo.data[data.ID] = &stored
 	return nil
 }
 
-// Delete удаляет данные.
+// Delete выполняет мягкое удаление данных, как и GORM для модели с DeletedAt.
 func (mdr *MemoryDataRepository) Delete(id uuid.UUID) error {
 	mdr.repo.mutex.Lock()
 	defer mdr.repo.mutex.Unlock()
 
-	_, exists := mdr.repo.data[id]
-	if !exists {
+	data, exists := mdr.repo.data[id]
+	if !exists || data.DeletedAt.Valid {
 		return errors.New("данные не найдены")
 	}
 
-	delete(mdr.repo.data, id)
+	mdr.tx.keepData(mdr.repo, id)
+	data.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+// Restore восстанавливает удаленные данные.
+func (mdr *MemoryDataRepository) Restore(id uuid.UUID) error {
+	mdr.repo.mutex.Lock()
+	defer mdr.repo.mutex.Unlock()
+
+	data, exists := mdr.repo.data[id]
+	if !exists || !data.DeletedAt.Valid {
+		return errors.New("удаленные данные не найдены")
+	}
+
+	mdr.tx.keepData(mdr.repo, id)
+	data.DeletedAt = gorm.DeletedAt{}
+	data.UpdatedAt = time.Now()
+	return nil
+}
+
+// GetDeletedByID возвращает удаленные данные по ID.
+func (mdr *MemoryDataRepository) GetDeletedByID(id uuid.UUID) (*models.Data, error) {
+	mdr.repo.mutex.RLock()
+	defer mdr.repo.mutex.RUnlock()
+
+	data, exists := mdr.repo.data[id]
+	if !exists || !data.DeletedAt.Valid {
+		return nil, errors.New("удаленные данные не найдены")
+	}
+
+	result := *data
+	return &result, nil
+}
+
+// GetDeletedByUserID возвращает удаленные личные данные пользователя и данные хранилищ,
+// в которых он участвует, начиная с последних удаленных.
+func (mdr *MemoryDataRepository) GetDeletedByUserID(userID uuid.UUID) ([]models.Data, error) {
+	mdr.repo.mutex.RLock()
+	defer mdr.repo.mutex.RUnlock()
+
+	var result []models.Data
+	for _, data := range mdr.repo.data {
+		if data.DeletedAt.Valid && mdr.accessible(data, userID, nil) {
+			result = append(result, *data)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].DeletedAt.Time.After(result[j].DeletedAt.Time)
+	})
+	return result, nil
+}
+
+// Purge окончательно удаляет удаленные данные вместе с их историей изменений и доступами.
+func (mdr *MemoryDataRepository) Purge(id uuid.UUID) error {
+	mdr.repo.mutex.Lock()
+	defer mdr.repo.mutex.Unlock()
+
+	data, exists := mdr.repo.data[id]
+	if !exists || !data.DeletedAt.Valid {
+		return errors.New("удаленные данные не найдены")
+	}
+
+	mdr.repo.purge(id, mdr.tx)
 	return nil
 }
 
+// PurgeDeletedBefore окончательно удаляет данные, удаленные раньше cutoff,
+// вместе с их историей изменений и доступами. Возвращает число удаленных записей.
+func (mdr *MemoryDataRepository) PurgeDeletedBefore(cutoff time.Time) (int64, error) {
+	mdr.repo.mutex.Lock()
+	defer mdr.repo.mutex.Unlock()
+
+	var purged int64
+	for id, data := range mdr.repo.data {
+		if data.DeletedAt.Valid && data.DeletedAt.Time.Before(cutoff) {
+			mdr.repo.purge(id, mdr.tx)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// purge удаляет запись, ее ревизии, доступы и метки, оставляя отметку об удалении,
+// и сохраняет прежние значения в журнал отката tx. Вызывается под блокировкой.
+func (mr *MemoryRepository) purge(id uuid.UUID, tx *undoLog) {
+	tx.keepData(mr, id)
+	tx.keepDataTags(mr, id)
+	tx.keepTombstone(mr, id)
+	mr.purged[id] = models.NewTombstone(mr.data[id], time.Now())
+	delete(mr.data, id)
+	delete(mr.dataTags, id)
+	for revisionID, revision := range mr.revisions {
+		if revision.DataID == id {
+			tx.keepRevision(mr, revisionID)
+			delete(mr.revisions, revisionID)
+		}
+	}
+	for shareID, share := range mr.shares {
+		if share.DataID == id {
+			tx.keepShare(mr, shareID)
+			delete(mr.shares, shareID)
+		}
+	}
+}
+
 // CheckUserOwnership проверяет, принадлежат ли данные пользователю.
 func (mdr *MemoryDataRepository) CheckUserOwnership(dataID, userID uuid.UUID) error {
 	mdr.repo.mutex.RLock()
 	defer mdr.repo.mutex.RUnlock()
 
 	data, exists := mdr.repo.data[dataID]
-	if !exists {
+	if !exists || data.DeletedAt.Valid {
 		return errors.New("данные не найдены")
 	}
 
@@ -188,3 +752,1268 @@ func (mdr *MemoryDataRepository) CheckUserOwnership(dataID, userID uuid.UUID) er
 
 	return nil
 }
+
+// GetChangedSince возвращает личные данные пользователя и данные хранилищ, в которых он участвует,
+// измененные или удаленные в корзину после указанного момента.
+func (mdr *MemoryDataRepository) GetChangedSince(userID uuid.UUID, since time.Time) ([]models.Data, error) {
+	mdr.repo.mutex.RLock()
+	defer mdr.repo.mutex.RUnlock()
+
+	var changed []models.Data
+	for _, data := range mdr.repo.data {
+		if !mdr.accessible(data, userID, nil) {
+			continue
+		}
+		if data.UpdatedAt.After(since) || (data.DeletedAt.Valid && data.DeletedAt.Time.After(since)) {
+			changed = append(changed, *data)
+		}
+	}
+
+	sort.Slice(changed, func(i, j int) bool {
+		return changed[i].UpdatedAt.Before(changed[j].UpdatedAt)
+	})
+	return changed, nil
+}
+
+// GetPurgedSince возвращает отметки об окончательном удалении личных записей пользователя
+// и записей хранилищ, в которых он участвует, сделанные после указанного момента.
+func (mdr *MemoryDataRepository) GetPurgedSince(userID uuid.UUID, since time.Time) ([]models.DataTombstone, error) {
+	mdr.repo.mutex.RLock()
+	defer mdr.repo.mutex.RUnlock()
+
+	var tombstones []models.DataTombstone
+	for _, tombstone := range mdr.repo.purged {
+		if !tombstone.PurgedAt.After(since) {
+			continue
+		}
+		if tombstone.VaultID != nil {
+			if _, member := mdr.repo.members[*tombstone.VaultID][userID]; !member {
+				continue
+			}
+		} else if tombstone.UserID != userID {
+			continue
+		}
+		tombstones = append(tombstones, *tombstone)
+	}
+
+	sort.Slice(tombstones, func(i, j int) bool {
+		return tombstones[i].PurgedAt.Before(tombstones[j].PurgedAt)
+	})
+	return tombstones, nil
+}
+
+// GetBatchAfter возвращает до limit записей с ID больше afterID в порядке возрастания ID,
+// включая удаленные в корзину.
+func (mdr *MemoryDataRepository) GetBatchAfter(afterID uuid.UUID, limit int) ([]models.Data, error) {
+	mdr.repo.mutex.RLock()
+	defer mdr.repo.mutex.RUnlock()
+
+	var batch []models.Data
+	for id, data := range mdr.repo.data {
+		if idLess(afterID, id) {
+			batch = append(batch, *data)
+		}
+	}
+
+	sort.Slice(batch, func(i, j int) bool { return idLess(batch[i].ID, batch[j].ID) })
+	if len(batch) > limit {
+		batch = batch[:limit]
+	}
+	return batch, nil
+}
+
+// ReplaceCiphertext заменяет зашифрованные поля записи, если они не изменились с момента чтения.
+func (mdr *MemoryDataRepository) ReplaceCiphertext(id uuid.UUID, oldPassword, oldPayload, password, payload string) error {
+	mdr.repo.mutex.Lock()
+	defer mdr.repo.mutex.Unlock()
+
+	data, exists := mdr.repo.data[id]
+	if !exists || data.Password != oldPassword || data.Payload != oldPayload {
+		return ErrCiphertextChanged
+	}
+
+	updated := *data
+	updated.Password = password
+	updated.Payload = payload
+	mdr.tx.keepData(mdr.repo, id)
+	mdr.repo.data[id] = &updated
+	return nil
+}
+
+// Transaction выполняет fn атомарно. Транзакции выполняются по одной; если fn возвращает
+// ошибку, записи, ревизии, метки, папки, доступы и описания файлов, измененные через
+// репозитории fn, возвращаются к прежним значениям; сведения о фрагментах файлов не откатываются. Изменения других записей, сделанные в это время вне транзакции,
+// сохраняются.
+func (mdr *MemoryDataRepository) Transaction(fn func(uow *UnitOfWork) error) error {
+	mdr.repo.txMutex.Lock()
+	defer mdr.repo.txMutex.Unlock()
+
+	tx := newUndoLog()
+	err := fn(&UnitOfWork{
+		Data:        &MemoryDataRepository{repo: mdr.repo, tx: tx},
+		Revisions:   &MemoryRevisionRepository{repo: mdr.repo, tx: tx},
+		Tags:        &MemoryTagRepository{repo: mdr.repo, tx: tx},
+		Folders:     &MemoryFolderRepository{repo: mdr.repo, tx: tx},
+		Attachments: &MemoryAttachmentRepository{repo: mdr.repo, tx: tx},
+	})
+	if err != nil {
+		mdr.repo.mutex.Lock()
+		tx.rollback(mdr.repo)
+		mdr.repo.mutex.Unlock()
+	}
+	return err
+}
+
+// undoLog хранит прежние значения ключей, измененных в транзакции. Пустое значение
+// означает, что ключа до транзакции не было. Методы нулевого *undoLog ничего не делают,
+// поэтому репозитории вне транзакции вызывают их без проверок.
+type undoLog struct {
+	data      map[uuid.UUID]*models.Data
+	revisions map[uuid.UUID]*models.DataRevision
+	tags      map[uuid.UUID]*models.Tag
+	dataTags  map[uuid.UUID]map[uuid.UUID]bool
+	shares    map[uuid.UUID]*models.Share
+	folders   map[uuid.UUID]*models.Folder
+	purged    map[uuid.UUID]*models.DataTombstone
+	files     map[uuid.UUID]*models.Attachment
+}
+
+// newUndoLog создает пустой журнал отката.
+func newUndoLog() *undoLog {
+	return &undoLog{
+		data:      make(map[uuid.UUID]*models.Data),
+		revisions: make(map[uuid.UUID]*models.DataRevision),
+		tags:      make(map[uuid.UUID]*models.Tag),
+		dataTags:  make(map[uuid.UUID]map[uuid.UUID]bool),
+		shares:    make(map[uuid.UUID]*models.Share),
+		folders:   make(map[uuid.UUID]*models.Folder),
+		purged:    make(map[uuid.UUID]*models.DataTombstone),
+		files:     make(map[uuid.UUID]*models.Attachment),
+	}
+}
+
+// keepData сохраняет запись id перед изменением. Вызывается под блокировкой.
+func (l *undoLog) keepData(mr *MemoryRepository, id uuid.UUID) {
+	if l != nil {
+		keep(l.data, mr.data, id)
+	}
+}
+
+// keepRevision сохраняет ревизию id перед изменением. Вызывается под блокировкой.
+func (l *undoLog) keepRevision(mr *MemoryRepository, id uuid.UUID) {
+	if l != nil {
+		keep(l.revisions, mr.revisions, id)
+	}
+}
+
+// keepTag сохраняет метку id перед изменением. Вызывается под блокировкой.
+func (l *undoLog) keepTag(mr *MemoryRepository, id uuid.UUID) {
+	if l != nil {
+		keep(l.tags, mr.tags, id)
+	}
+}
+
+// keepShare сохраняет доступ id перед изменением. Вызывается под блокировкой.
+func (l *undoLog) keepShare(mr *MemoryRepository, id uuid.UUID) {
+	if l != nil {
+		keep(l.shares, mr.shares, id)
+	}
+}
+
+// keepFolder сохраняет папку id перед изменением. Вызывается под блокировкой.
+func (l *undoLog) keepFolder(mr *MemoryRepository, id uuid.UUID) {
+	if l != nil {
+		keep(l.folders, mr.folders, id)
+	}
+}
+
+// keepTombstone сохраняет отметку об удалении записи id перед изменением. Вызывается под блокировкой.
+func (l *undoLog) keepTombstone(mr *MemoryRepository, id uuid.UUID) {
+	if l != nil {
+		keep(l.purged, mr.purged, id)
+	}
+}
+
+// keepAttachment сохраняет описание файла записи dataID перед изменением. Вызывается под блокировкой.
+func (l *undoLog) keepAttachment(mr *MemoryRepository, dataID uuid.UUID) {
+	if l != nil {
+		keep(l.files, mr.files, dataID)
+	}
+}
+
+// keepDataTags сохраняет метки записи dataID перед изменением. Вызывается под блокировкой.
+func (l *undoLog) keepDataTags(mr *MemoryRepository, dataID uuid.UUID) {
+	if l == nil {
+		return
+	}
+	if _, kept := l.dataTags[dataID]; kept {
+		return
+	}
+	tagIDs := make(map[uuid.UUID]bool, len(mr.dataTags[dataID]))
+	for id := range mr.dataTags[dataID] {
+		tagIDs[id] = true
+	}
+	l.dataTags[dataID] = tagIDs
+}
+
+// rollback возвращает сохраненные ключи к прежним значениям. Вызывается под блокировкой.
+func (l *undoLog) rollback(mr *MemoryRepository) {
+	restore(mr.data, l.data)
+	restore(mr.revisions, l.revisions)
+	restore(mr.tags, l.tags)
+	restore(mr.shares, l.shares)
+	restore(mr.folders, l.folders)
+	restore(mr.purged, l.purged)
+	restore(mr.files, l.files)
+	for dataID, tagIDs := range l.dataTags {
+		if len(tagIDs) == 0 {
+			delete(mr.dataTags, dataID)
+		} else {
+			mr.dataTags[dataID] = tagIDs
+		}
+	}
+}
+
+// keep сохраняет в log копию значения ключа id словаря m, если ключ еще не сохранен.
+func keep[T any](log, m map[uuid.UUID]*T, id uuid.UUID) {
+	if _, kept := log[id]; kept {
+		return
+	}
+	if value, exists := m[id]; exists {
+		copied := *value
+		log[id] = &copied
+		return
+	}
+	log[id] = nil
+}
+
+// restore возвращает ключи словаря m к значениям из log.
+func restore[T any](m, log map[uuid.UUID]*T) {
+	for id, value := range log {
+		if value == nil {
+			delete(m, id)
+		} else {
+			m[id] = value
+		}
+	}
+}
+
+// MemoryRevisionRepository содержит методы для работы с историей изменений данных.
+type MemoryRevisionRepository struct {
+	repo *MemoryRepository
+	tx   *undoLog
+}
+
+// NewRevisionRepository создает новый репозиторий истории изменений.
+func (mr *MemoryRepository) NewRevisionRepository() *MemoryRevisionRepository {
+	return &MemoryRevisionRepository{repo: mr}
+}
+
+// Create сохраняет ревизию записи.
+func (mrr *MemoryRevisionRepository) Create(revision *models.DataRevision) error {
+	mrr.repo.mutex.Lock()
+	defer mrr.repo.mutex.Unlock()
+
+	if revision.ID == uuid.Nil {
+		revision.ID = uuid.New()
+	}
+	if revision.CreatedAt.IsZero() {
+		revision.CreatedAt = time.Now()
+	}
+
+	stored := *revision
+	mrr.tx.keepRevision(mrr.repo, revision.ID)
+	mrr.repo.revisions[revision.ID] = &stored
+	return nil
+}
+
+// GetByID возвращает ревизию по ID.
+func (mrr *MemoryRevisionRepository) GetByID(id uuid.UUID) (*models.DataRevision, error) {
+	mrr.repo.mutex.RLock()
+	defer mrr.repo.mutex.RUnlock()
+
+	revision, exists := mrr.repo.revisions[id]
+	if !exists {
+		return nil, errors.New("ревизия не найдена")
+	}
+
+	result := *revision
+	return &result, nil
+}
+
+// GetByDataID возвращает ревизии записи, начиная с самой новой.
+func (mrr *MemoryRevisionRepository) GetByDataID(dataID uuid.UUID) ([]models.DataRevision, error) {
+	mrr.repo.mutex.RLock()
+	defer mrr.repo.mutex.RUnlock()
+
+	var result []models.DataRevision
+	for _, revision := range mrr.repo.revisions {
+		if revision.DataID == dataID {
+			result = append(result, *revision)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].Version > result[j].Version
+		}
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// GetBatchAfter возвращает до limit ревизий с ID больше afterID в порядке возрастания ID.
+func (mrr *MemoryRevisionRepository) GetBatchAfter(afterID uuid.UUID, limit int) ([]models.DataRevision, error) {
+	mrr.repo.mutex.RLock()
+	defer mrr.repo.mutex.RUnlock()
+
+	var batch []models.DataRevision
+	for id, revision := range mrr.repo.revisions {
+		if idLess(afterID, id) {
+			batch = append(batch, *revision)
+		}
+	}
+
+	sort.Slice(batch, func(i, j int) bool { return idLess(batch[i].ID, batch[j].ID) })
+	if len(batch) > limit {
+		batch = batch[:limit]
+	}
+	return batch, nil
+}
+
+// ReplaceCiphertext заменяет зашифрованные поля ревизии, если они не изменились с момента чтения.
+func (mrr *MemoryRevisionRepository) ReplaceCiphertext(id uuid.UUID, oldPassword, oldPayload, password, payload string) error {
+	mrr.repo.mutex.Lock()
+	defer mrr.repo.mutex.Unlock()
+
+	revision, exists := mrr.repo.revisions[id]
+	if !exists || revision.Password != oldPassword || revision.Payload != oldPayload {
+		return ErrCiphertextChanged
+	}
+
+	updated := *revision
+	updated.Password = password
+	updated.Payload = payload
+	mrr.tx.keepRevision(mrr.repo, id)
+	mrr.repo.revisions[id] = &updated
+	return nil
+}
+
+// idLess сравнивает UUID побайтно, как PostgreSQL при сортировке по ID.
+func idLess(a, b uuid.UUID) bool {
+	return bytes.Compare(a[:], b[:]) < 0
+}
+
+// MemoryAttachmentRepository содержит методы для работы с файлами, загружаемыми фрагментами.
+type MemoryAttachmentRepository struct {
+	repo *MemoryRepository
+	tx   *undoLog
+}
+
+// NewAttachmentRepository создает новый репозиторий файлов.
+func (mr *MemoryRepository) NewAttachmentRepository() *MemoryAttachmentRepository {
+	return &MemoryAttachmentRepository{repo: mr}
+}
+
+// Create создает описание файла.
+func (mar *MemoryAttachmentRepository) Create(attachment *models.Attachment) error {
+	mar.repo.mutex.Lock()
+	defer mar.repo.mutex.Unlock()
+
+	if _, exists := mar.repo.files[attachment.DataID]; exists {
+		return errors.New("файл уже существует")
+	}
+
+	now := time.Now()
+	attachment.CreatedAt = now
+	attachment.UpdatedAt = now
+
+	mar.tx.keepAttachment(mar.repo, attachment.DataID)
+	stored := *attachment
+	mar.repo.files[attachment.DataID] = &stored
+	return nil
+}
+
+// GetByDataID возвращает описание файла записи.
+func (mar *MemoryAttachmentRepository) GetByDataID(dataID uuid.UUID) (*models.Attachment, error) {
+	mar.repo.mutex.RLock()
+	defer mar.repo.mutex.RUnlock()
+
+	attachment, exists := mar.repo.files[dataID]
+	if !exists {
+		return nil, errors.New("файл не найден")
+	}
+
+	result := *attachment
+	return &result, nil
+}
+
+// MarkComplete отмечает, что все фрагменты файла загружены.
+func (mar *MemoryAttachmentRepository) MarkComplete(dataID uuid.UUID) error {
+	mar.repo.mutex.Lock()
+	defer mar.repo.mutex.Unlock()
+
+	attachment, exists := mar.repo.files[dataID]
+	if !exists {
+		return errors.New("файл не найден")
+	}
+
+	mar.tx.keepAttachment(mar.repo, dataID)
+	attachment.Complete = true
+	attachment.UpdatedAt = time.Now()
+	return nil
+}
+
+// SaveChunk сохраняет сведения о загруженном фрагменте, заменяя ранее загруженный.
+func (mar *MemoryAttachmentRepository) SaveChunk(chunk *models.AttachmentChunk) error {
+	mar.repo.mutex.Lock()
+	defer mar.repo.mutex.Unlock()
+
+	if chunk.CreatedAt.IsZero() {
+		chunk.CreatedAt = time.Now()
+	}
+
+	if mar.repo.chunks[chunk.DataID] == nil {
+		mar.repo.chunks[chunk.DataID] = make(map[int]models.AttachmentChunk)
+	}
+	mar.repo.chunks[chunk.DataID][chunk.Index] = *chunk
+	return nil
+}
+
+// GetChunks возвращает загруженные фрагменты файла по порядку.
+func (mar *MemoryAttachmentRepository) GetChunks(dataID uuid.UUID) ([]models.AttachmentChunk, error) {
+	mar.repo.mutex.RLock()
+	defer mar.repo.mutex.RUnlock()
+
+	var result []models.AttachmentChunk
+	for _, chunk := range mar.repo.chunks[dataID] {
+		result = append(result, chunk)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Index < result[j].Index
+	})
+	return result, nil
+}
+
+// GetOrphaned возвращает файлы, записи которых удалены окончательно.
+func (mar *MemoryAttachmentRepository) GetOrphaned() ([]models.Attachment, error) {
+	mar.repo.mutex.RLock()
+	defer mar.repo.mutex.RUnlock()
+
+	var result []models.Attachment
+	for dataID, attachment := range mar.repo.files {
+		if _, exists := mar.repo.data[dataID]; !exists {
+			result = append(result, *attachment)
+		}
+	}
+	return result, nil
+}
+
+// Delete удаляет описание файла и сведения о его фрагментах.
+func (mar *MemoryAttachmentRepository) Delete(dataID uuid.UUID) error {
+	mar.repo.mutex.Lock()
+	defer mar.repo.mutex.Unlock()
+
+	mar.tx.keepAttachment(mar.repo, dataID)
+	delete(mar.repo.files, dataID)
+	delete(mar.repo.chunks, dataID)
+	return nil
+}
+
+// MemorySessionRepository содержит методы для работы с сессиями пользователей.
+type MemorySessionRepository struct {
+	repo *MemoryRepository
+}
+
+// NewSessionRepository создает новый репозиторий сессий.
+func (mr *MemoryRepository) NewSessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{repo: mr}
+}
+
+// Create создает сессию.
+func (msr *MemorySessionRepository) Create(session *models.Session) error {
+	msr.repo.mutex.Lock()
+	defer msr.repo.mutex.Unlock()
+
+	if session.ID == uuid.Nil {
+		session.ID = uuid.New()
+	}
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+
+	stored := *session
+	msr.repo.sessions[session.ID] = &stored
+	return nil
+}
+
+// GetByID возвращает сессию по ID.
+func (msr *MemorySessionRepository) GetByID(id uuid.UUID) (*models.Session, error) {
+	msr.repo.mutex.RLock()
+	defer msr.repo.mutex.RUnlock()
+
+	session, exists := msr.repo.sessions[id]
+	if !exists {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	result := *session
+	return &result, nil
+}
+
+// GetByRefreshTokenHash возвращает сессию по хешу текущего или предыдущего токена обновления.
+func (msr *MemorySessionRepository) GetByRefreshTokenHash(hash string) (*models.Session, error) {
+	msr.repo.mutex.RLock()
+	defer msr.repo.mutex.RUnlock()
+
+	for _, session := range msr.repo.sessions {
+		if session.RefreshTokenHash == hash || (session.PreviousTokenHash != "" && session.PreviousTokenHash == hash) {
+			result := *session
+			return &result, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// GetActiveByUserID возвращает действующие на момент now сессии пользователя, начиная с последней активной.
+func (msr *MemorySessionRepository) GetActiveByUserID(userID uuid.UUID, now time.Time) ([]models.Session, error) {
+	msr.repo.mutex.RLock()
+	defer msr.repo.mutex.RUnlock()
+
+	var result []models.Session
+	for _, session := range msr.repo.sessions {
+		if session.UserID == userID && session.Active(now) {
+			result = append(result, *session)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeenAt.After(result[j].LastSeenAt)
+	})
+	return result, nil
+}
+
+// Rotate заменяет токен обновления сессии, если текущий токен совпадает с currentHash.
+// Кроме хешей обновляются срок действия, IP и время последней активности.
+// Если сессия отозвана или токен уже заменен параллельным запросом, возвращается gorm.ErrRecordNotFound.
+func (msr *MemorySessionRepository) Rotate(session *models.Session, currentHash string) error {
+	msr.repo.mutex.Lock()
+	defer msr.repo.mutex.Unlock()
+
+	stored, exists := msr.repo.sessions[session.ID]
+	if !exists || stored.RevokedAt != nil || stored.RefreshTokenHash != currentHash {
+		return gorm.ErrRecordNotFound
+	}
+
+	stored.RefreshTokenHash = session.RefreshTokenHash
+	stored.PreviousTokenHash = session.PreviousTokenHash
+	stored.ExpiresAt = session.ExpiresAt
+	stored.LastSeenAt = session.LastSeenAt
+	stored.IP = session.IP
+	return nil
+}
+
+// Touch обновляет IP и время последней активности действующей сессии.
+func (msr *MemorySessionRepository) Touch(id uuid.UUID, ip string, at time.Time) error {
+	msr.repo.mutex.Lock()
+	defer msr.repo.mutex.Unlock()
+
+	if session, exists := msr.repo.sessions[id]; exists && session.RevokedAt == nil {
+		session.IP = ip
+		session.LastSeenAt = at
+	}
+	return nil
+}
+
+// Revoke отзывает сессию.
+func (msr *MemorySessionRepository) Revoke(id uuid.UUID, at time.Time) error {
+	msr.repo.mutex.Lock()
+	defer msr.repo.mutex.Unlock()
+
+	session, exists := msr.repo.sessions[id]
+	if !exists || session.RevokedAt != nil {
+		return gorm.ErrRecordNotFound
+	}
+
+	session.RevokedAt = &at
+	return nil
+}
+
+// RevokeByUserID отзывает все сессии пользователя и возвращает их число.
+func (msr *MemorySessionRepository) RevokeByUserID(userID uuid.UUID, at time.Time) (int64, error) {
+	msr.repo.mutex.Lock()
+	defer msr.repo.mutex.Unlock()
+
+	var revoked int64
+	for _, session := range msr.repo.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			revokedAt := at
+			session.RevokedAt = &revokedAt
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+// MemoryShareRepository представляет in-memory репозиторий доступов к записям.
+type MemoryShareRepository struct {
+	repo *MemoryRepository
+}
+
+// NewShareRepository создает новый репозиторий доступов.
+func (mr *MemoryRepository) NewShareRepository() *MemoryShareRepository {
+	return &MemoryShareRepository{repo: mr}
+}
+
+// Save выдает доступ к записи. Если получатель уже имеет доступ к записи,
+// его права и срок заменяются, а share получает ID и время создания существующего доступа.
+func (msr *MemoryShareRepository) Save(share *models.Share) error {
+	msr.repo.mutex.Lock()
+	defer msr.repo.mutex.Unlock()
+
+	for _, existing := range msr.repo.shares {
+		if existing.DataID == share.DataID && existing.GranteeID == share.GranteeID {
+			share.ID = existing.ID
+			share.CreatedAt = existing.CreatedAt
+			existing.Permission = share.Permission
+			existing.ExpiresAt = share.ExpiresAt
+			return nil
+		}
+	}
+
+	if share.ID == uuid.Nil {
+		share.ID = uuid.New()
+	}
+	if share.CreatedAt.IsZero() {
+		share.CreatedAt = time.Now()
+	}
+
+	stored := *share
+	msr.repo.shares[share.ID] = &stored
+	return nil
+}
+
+// GetByDataID возвращает все доступы к записи, начиная с самого раннего.
+func (msr *MemoryShareRepository) GetByDataID(dataID uuid.UUID) ([]models.Share, error) {
+	msr.repo.mutex.RLock()
+	defer msr.repo.mutex.RUnlock()
+
+	var result []models.Share
+	for _, share := range msr.repo.shares {
+		if share.DataID == dataID {
+			result = append(result, *share)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// GetActive возвращает действующий на момент now доступ получателя к записи.
+func (msr *MemoryShareRepository) GetActive(dataID, granteeID uuid.UUID, now time.Time) (*models.Share, error) {
+	msr.repo.mutex.RLock()
+	defer msr.repo.mutex.RUnlock()
+
+	for _, share := range msr.repo.shares {
+		if share.DataID == dataID && share.GranteeID == granteeID && share.Active(now) {
+			result := *share
+			return &result, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// GetActiveByGranteeID возвращает действующие на момент now доступы получателя.
+func (msr *MemoryShareRepository) GetActiveByGranteeID(granteeID uuid.UUID, now time.Time) ([]models.Share, error) {
+	msr.repo.mutex.RLock()
+	defer msr.repo.mutex.RUnlock()
+
+	var result []models.Share
+	for _, share := range msr.repo.shares {
+		if share.GranteeID == granteeID && share.Active(now) {
+			result = append(result, *share)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// Delete отзывает доступ. Если доступа нет, возвращается gorm.ErrRecordNotFound.
+func (msr *MemoryShareRepository) Delete(id uuid.UUID) error {
+	msr.repo.mutex.Lock()
+	defer msr.repo.mutex.Unlock()
+
+	if _, exists := msr.repo.shares[id]; !exists {
+		return gorm.ErrRecordNotFound
+	}
+	delete(msr.repo.shares, id)
+	return nil
+}
+
+// MemoryVaultRepository представляет in-memory репозиторий командных хранилищ.
+type MemoryVaultRepository struct {
+	repo *MemoryRepository
+}
+
+// NewVaultRepository создает новый репозиторий хранилищ.
+func (mr *MemoryRepository) NewVaultRepository() *MemoryVaultRepository {
+	return &MemoryVaultRepository{repo: mr}
+}
+
+// Create создает хранилище вместе с его первым участником owner.
+func (mvr *MemoryVaultRepository) Create(vault *models.Vault, owner *models.VaultMember) error {
+	mvr.repo.mutex.Lock()
+	defer mvr.repo.mutex.Unlock()
+
+	if vault.ID == uuid.Nil {
+		vault.ID = uuid.New()
+	}
+	now := time.Now()
+	if vault.CreatedAt.IsZero() {
+		vault.CreatedAt = now
+	}
+	vault.UpdatedAt = now
+
+	stored := *vault
+	mvr.repo.vaults[vault.ID] = &stored
+
+	owner.VaultID = vault.ID
+	if owner.CreatedAt.IsZero() {
+		owner.CreatedAt = now
+	}
+	member := *owner
+	mvr.repo.members[vault.ID] = map[uuid.UUID]*models.VaultMember{owner.UserID: &member}
+	return nil
+}
+
+// GetByID возвращает хранилище по ID.
+func (mvr *MemoryVaultRepository) GetByID(id uuid.UUID) (*models.Vault, error) {
+	mvr.repo.mutex.RLock()
+	defer mvr.repo.mutex.RUnlock()
+
+	vault, exists := mvr.repo.vaults[id]
+	if !exists {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	result := *vault
+	return &result, nil
+}
+
+// GetBatchAfter возвращает до limit хранилищ с ID больше afterID в порядке возрастания ID.
+func (mvr *MemoryVaultRepository) GetBatchAfter(afterID uuid.UUID, limit int) ([]models.Vault, error) {
+	mvr.repo.mutex.RLock()
+	defer mvr.repo.mutex.RUnlock()
+
+	var vaults []models.Vault
+	for id, vault := range mvr.repo.vaults {
+		if idLess(afterID, id) {
+			vaults = append(vaults, *vault)
+		}
+	}
+
+	sort.Slice(vaults, func(i, j int) bool { return idLess(vaults[i].ID, vaults[j].ID) })
+	if len(vaults) > limit {
+		vaults = vaults[:limit]
+	}
+	return vaults, nil
+}
+
+// ReplaceDataKey заменяет зашифрованный ключ данных хранилища, если он не изменился.
+func (mvr *MemoryVaultRepository) ReplaceDataKey(id uuid.UUID, current, dataKey string) error {
+	mvr.repo.mutex.Lock()
+	defer mvr.repo.mutex.Unlock()
+
+	vault, exists := mvr.repo.vaults[id]
+	if !exists || vault.DataKey != current {
+		return ErrCiphertextChanged
+	}
+
+	updated := *vault
+	updated.DataKey = dataKey
+	mvr.repo.vaults[id] = &updated
+	return nil
+}
+
+// Delete удаляет хранилище вместе с его участниками.
+func (mvr *MemoryVaultRepository) Delete(id uuid.UUID) error {
+	mvr.repo.mutex.Lock()
+	defer mvr.repo.mutex.Unlock()
+
+	if _, exists := mvr.repo.vaults[id]; !exists {
+		return gorm.ErrRecordNotFound
+	}
+	delete(mvr.repo.vaults, id)
+	delete(mvr.repo.members, id)
+	return nil
+}
+
+// GetMember возвращает участника хранилища.
+func (mvr *MemoryVaultRepository) GetMember(vaultID, userID uuid.UUID) (*models.VaultMember, error) {
+	mvr.repo.mutex.RLock()
+	defer mvr.repo.mutex.RUnlock()
+
+	member, exists := mvr.repo.members[vaultID][userID]
+	if !exists {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	result := *member
+	return &result, nil
+}
+
+// GetMembers возвращает участников хранилища в порядке добавления.
+func (mvr *MemoryVaultRepository) GetMembers(vaultID uuid.UUID) ([]models.VaultMember, error) {
+	mvr.repo.mutex.RLock()
+	defer mvr.repo.mutex.RUnlock()
+
+	var result []models.VaultMember
+	for _, member := range mvr.repo.members[vaultID] {
+		result = append(result, *member)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// GetMemberships возвращает участие пользователя в хранилищах.
+func (mvr *MemoryVaultRepository) GetMemberships(userID uuid.UUID) ([]models.VaultMember, error) {
+	mvr.repo.mutex.RLock()
+	defer mvr.repo.mutex.RUnlock()
+
+	var result []models.VaultMember
+	for _, members := range mvr.repo.members {
+		if member, exists := members[userID]; exists {
+			result = append(result, *member)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// SaveMember добавляет участника хранилища или изменяет его роль.
+func (mvr *MemoryVaultRepository) SaveMember(member *models.VaultMember) error {
+	mvr.repo.mutex.Lock()
+	defer mvr.repo.mutex.Unlock()
+
+	members, exists := mvr.repo.members[member.VaultID]
+	if !exists {
+		return gorm.ErrRecordNotFound
+	}
+
+	if existing, exists := members[member.UserID]; exists {
+		existing.Role = member.Role
+		member.CreatedAt = existing.CreatedAt
+		return nil
+	}
+
+	if member.CreatedAt.IsZero() {
+		member.CreatedAt = time.Now()
+	}
+	stored := *member
+	members[member.UserID] = &stored
+	return nil
+}
+
+// DeleteMember исключает пользователя из хранилища.
+// Если пользователь не участвует в хранилище, возвращается gorm.ErrRecordNotFound.
+func (mvr *MemoryVaultRepository) DeleteMember(vaultID, userID uuid.UUID) error {
+	mvr.repo.mutex.Lock()
+	defer mvr.repo.mutex.Unlock()
+
+	if _, exists := mvr.repo.members[vaultID][userID]; !exists {
+		return gorm.ErrRecordNotFound
+	}
+	delete(mvr.repo.members[vaultID], userID)
+	return nil
+}
+
+// MemoryTagRepository представляет in-memory репозиторий меток.
+type MemoryTagRepository struct {
+	repo *MemoryRepository
+	tx   *undoLog
+}
+
+// NewTagRepository создает новый репозиторий меток.
+func (mr *MemoryRepository) NewTagRepository() *MemoryTagRepository {
+	return &MemoryTagRepository{repo: mr}
+}
+
+// Create создает метку.
+func (mtr *MemoryTagRepository) Create(tag *models.Tag) error {
+	mtr.repo.mutex.Lock()
+	defer mtr.repo.mutex.Unlock()
+
+	for _, existing := range mtr.repo.tags {
+		if existing.UserID == tag.UserID && existing.Name == tag.Name {
+			return errors.New("метка с таким именем уже существует")
+		}
+	}
+
+	if tag.ID == uuid.Nil {
+		tag.ID = uuid.New()
+	}
+	if tag.CreatedAt.IsZero() {
+		tag.CreatedAt = time.Now()
+	}
+
+	stored := *tag
+	mtr.tx.keepTag(mtr.repo, tag.ID)
+	mtr.repo.tags[tag.ID] = &stored
+	return nil
+}
+
+// GetByID возвращает метку по ID.
+func (mtr *MemoryTagRepository) GetByID(id uuid.UUID) (*models.Tag, error) {
+	mtr.repo.mutex.RLock()
+	defer mtr.repo.mutex.RUnlock()
+
+	tag, exists := mtr.repo.tags[id]
+	if !exists {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	result := *tag
+	return &result, nil
+}
+
+// GetByName возвращает метку пользователя по имени.
+func (mtr *MemoryTagRepository) GetByName(userID uuid.UUID, name string) (*models.Tag, error) {
+	mtr.repo.mutex.RLock()
+	defer mtr.repo.mutex.RUnlock()
+
+	for _, tag := range mtr.repo.tags {
+		if tag.UserID == userID && tag.Name == name {
+			result := *tag
+			return &result, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// GetByUserID возвращает метки пользователя по алфавиту.
+func (mtr *MemoryTagRepository) GetByUserID(userID uuid.UUID) ([]models.Tag, error) {
+	mtr.repo.mutex.RLock()
+	defer mtr.repo.mutex.RUnlock()
+
+	var result []models.Tag
+	for _, tag := range mtr.repo.tags {
+		if tag.UserID == userID {
+			result = append(result, *tag)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// Update переименовывает метку.
+func (mtr *MemoryTagRepository) Update(tag *models.Tag) error {
+	mtr.repo.mutex.Lock()
+	defer mtr.repo.mutex.Unlock()
+
+	stored, exists := mtr.repo.tags[tag.ID]
+	if !exists {
+		return gorm.ErrRecordNotFound
+	}
+	for _, existing := range mtr.repo.tags {
+		if existing.ID != tag.ID && existing.UserID == stored.UserID && existing.Name == tag.Name {
+			return errors.New("метка с таким именем уже существует")
+		}
+	}
+
+	mtr.tx.keepTag(mtr.repo, tag.ID)
+	stored.Name = tag.Name
+	return nil
+}
+
+// Delete удаляет метку и снимает ее со всех записей.
+// Если метки нет, возвращается gorm.ErrRecordNotFound.
+func (mtr *MemoryTagRepository) Delete(id uuid.UUID) error {
+	mtr.repo.mutex.Lock()
+	defer mtr.repo.mutex.Unlock()
+
+	if _, exists := mtr.repo.tags[id]; !exists {
+		return gorm.ErrRecordNotFound
+	}
+	mtr.tx.keepTag(mtr.repo, id)
+	delete(mtr.repo.tags, id)
+	for dataID, tagIDs := range mtr.repo.dataTags {
+		if tagIDs[id] {
+			mtr.tx.keepDataTags(mtr.repo, dataID)
+			delete(tagIDs, id)
+		}
+	}
+	return nil
+}
+
+// SetDataTags заменяет метки записи на tagIDs.
+func (mtr *MemoryTagRepository) SetDataTags(dataID uuid.UUID, tagIDs []uuid.UUID) error {
+	mtr.repo.mutex.Lock()
+	defer mtr.repo.mutex.Unlock()
+
+	mtr.tx.keepDataTags(mtr.repo, dataID)
+	if len(tagIDs) == 0 {
+		delete(mtr.repo.dataTags, dataID)
+		return nil
+	}
+
+	set := make(map[uuid.UUID]bool, len(tagIDs))
+	for _, id := range tagIDs {
+		set[id] = true
+	}
+	mtr.repo.dataTags[dataID] = set
+	return nil
+}
+
+// GetNamesByDataIDs возвращает имена меток записей dataIDs по алфавиту.
+// Записи без меток в результат не попадают.
+func (mtr *MemoryTagRepository) GetNamesByDataIDs(dataIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	mtr.repo.mutex.RLock()
+	defer mtr.repo.mutex.RUnlock()
+
+	names := make(map[uuid.UUID][]string)
+	for _, dataID := range dataIDs {
+		for tagID := range mtr.repo.dataTags[dataID] {
+			if tag, exists := mtr.repo.tags[tagID]; exists {
+				names[dataID] = append(names[dataID], tag.Name)
+			}
+		}
+		sort.Strings(names[dataID])
+	}
+	return names, nil
+}
+
+// MemoryFolderRepository представляет in-memory репозиторий папок.
+type MemoryFolderRepository struct {
+	repo *MemoryRepository
+	tx   *undoLog
+}
+
+// NewFolderRepository создает новый репозиторий папок.
+func (mr *MemoryRepository) NewFolderRepository() *MemoryFolderRepository {
+	return &MemoryFolderRepository{repo: mr}
+}
+
+// Create создает папку.
+func (mfr *MemoryFolderRepository) Create(folder *models.Folder) error {
+	mfr.repo.mutex.Lock()
+	defer mfr.repo.mutex.Unlock()
+
+	if folder.ID == uuid.Nil {
+		folder.ID = uuid.New()
+	}
+	now := time.Now()
+	if folder.CreatedAt.IsZero() {
+		folder.CreatedAt = now
+	}
+	folder.UpdatedAt = now
+
+	stored := *folder
+	mfr.tx.keepFolder(mfr.repo, folder.ID)
+	mfr.repo.folders[folder.ID] = &stored
+	return nil
+}
+
+// GetByID возвращает папку по ID.
+func (mfr *MemoryFolderRepository) GetByID(id uuid.UUID) (*models.Folder, error) {
+	mfr.repo.mutex.RLock()
+	defer mfr.repo.mutex.RUnlock()
+
+	folder, exists := mfr.repo.folders[id]
+	if !exists {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	result := *folder
+	return &result, nil
+}
+
+// GetByUserID возвращает все папки пользователя по алфавиту.
+func (mfr *MemoryFolderRepository) GetByUserID(userID uuid.UUID) ([]models.Folder, error) {
+	mfr.repo.mutex.RLock()
+	defer mfr.repo.mutex.RUnlock()
+
+	var result []models.Folder
+	for _, folder := range mfr.repo.folders {
+		if folder.UserID == userID {
+			result = append(result, *folder)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return bytes.Compare(result[i].ID[:], result[j].ID[:]) < 0
+	})
+	return result, nil
+}
+
+// Update переименовывает папку или переносит ее в другую родительскую папку.
+func (mfr *MemoryFolderRepository) Update(folder *models.Folder) error {
+	mfr.repo.mutex.Lock()
+	defer mfr.repo.mutex.Unlock()
+
+	stored, exists := mfr.repo.folders[folder.ID]
+	if !exists {
+		return gorm.ErrRecordNotFound
+	}
+
+	mfr.tx.keepFolder(mfr.repo, folder.ID)
+	folder.UpdatedAt = time.Now()
+	stored.Name = folder.Name
+	stored.ParentID = folder.ParentID
+	stored.UpdatedAt = folder.UpdatedAt
+	return nil
+}
+
+// Delete удаляет папку. Если папки нет, возвращается gorm.ErrRecordNotFound.
+func (mfr *MemoryFolderRepository) Delete(id uuid.UUID) error {
+	mfr.repo.mutex.Lock()
+	defer mfr.repo.mutex.Unlock()
+
+	if _, exists := mfr.repo.folders[id]; !exists {
+		return gorm.ErrRecordNotFound
+	}
+	mfr.tx.keepFolder(mfr.repo, id)
+	delete(mfr.repo.folders, id)
+	return nil
+}
+
+// MemoryAuditRepository представляет in-memory журнал аудита.
+type MemoryAuditRepository struct {
+	repo *MemoryRepository
+}
+
+// NewAuditRepository создает новый репозиторий журнала аудита.
+func (mr *MemoryRepository) NewAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{repo: mr}
+}
+
+// Create добавляет событие в журнал.
+func (mar *MemoryAuditRepository) Create(event *models.AuditEvent) error {
+	mar.repo.mutex.Lock()
+	defer mar.repo.mutex.Unlock()
+
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	for _, existing := range mar.repo.audit {
+		if existing.Seq == event.Seq {
+			return errors.New("событие с таким номером уже существует")
+		}
+	}
+
+	mar.repo.audit = append(mar.repo.audit, *event)
+	return nil
+}
+
+// GetByActorID возвращает страницу событий пользователя, начиная с самого нового,
+// и общее число его событий.
+func (mar *MemoryAuditRepository) GetByActorID(actorID uuid.UUID, limit, offset int) ([]models.AuditEvent, int64, error) {
+	mar.repo.mutex.RLock()
+	defer mar.repo.mutex.RUnlock()
+
+	// События добавляются по возрастанию номера, поэтому обход с конца дает самые новые первыми
+	var matched []models.AuditEvent
+	for i := len(mar.repo.audit) - 1; i >= 0; i-- {
+		event := mar.repo.audit[i]
+		if event.ActorID != nil && *event.ActorID == actorID {
+			matched = append(matched, event)
+		}
+	}
+
+	total := int64(len(matched))
+	if offset >= len(matched) {
+		return nil, total, nil
+	}
+	matched = matched[offset:]
+	if limit < len(matched) {
+		matched = matched[:limit]
+	}
+	return matched, total, nil
+}
+
+// GetLast возвращает последнее событие цепочки или gorm.ErrRecordNotFound, если журнал пуст.
+func (mar *MemoryAuditRepository) GetLast() (*models.AuditEvent, error) {
+	mar.repo.mutex.RLock()
+	defer mar.repo.mutex.RUnlock()
+
+	if len(mar.repo.audit) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	event := mar.repo.audit[len(mar.repo.audit)-1]
+	return &event, nil
+}
+
+// GetAfter возвращает до limit событий с номерами больше seq по возрастанию номера.
+func (mar *MemoryAuditRepository) GetAfter(seq int64, limit int) ([]models.AuditEvent, error) {
+	mar.repo.mutex.RLock()
+	defer mar.repo.mutex.RUnlock()
+
+	var events []models.AuditEvent
+	for _, event := range mar.repo.audit {
+		if event.Seq > seq && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// CreateCheckpoint сохраняет контрольную точку цепочки.
+func (mar *MemoryAuditRepository) CreateCheckpoint(checkpoint *models.AuditCheckpoint) error {
+	mar.repo.mutex.Lock()
+	defer mar.repo.mutex.Unlock()
+
+	if checkpoint.ID == uuid.Nil {
+		checkpoint.ID = uuid.New()
+	}
+	if checkpoint.CreatedAt.IsZero() {
+		checkpoint.CreatedAt = time.Now()
+	}
+
+	mar.repo.auditCPs = append(mar.repo.auditCPs, *checkpoint)
+	return nil
+}
+
+// GetCheckpoints возвращает контрольные точки по возрастанию номера события.
+func (mar *MemoryAuditRepository) GetCheckpoints() ([]models.AuditCheckpoint, error) {
+	mar.repo.mutex.RLock()
+	defer mar.repo.mutex.RUnlock()
+
+	checkpoints := make([]models.AuditCheckpoint, len(mar.repo.auditCPs))
+	copy(checkpoints, mar.repo.auditCPs)
+	return checkpoints, nil
+}
+
+// ReplaceCheckpointSignature заменяет подпись контрольной точки, если она не изменилась после чтения.
+// Если подпись уже другая, возвращает ErrCiphertextChanged.
+func (mar *MemoryAuditRepository) ReplaceCheckpointSignature(id uuid.UUID, oldSignature, newSignature string) error {
+	mar.repo.mutex.Lock()
+	defer mar.repo.mutex.Unlock()
+
+	for i := range mar.repo.auditCPs {
+		if mar.repo.auditCPs[i].ID != id {
+			continue
+		}
+		if mar.repo.auditCPs[i].Signature != oldSignature {
+			return ErrCiphertextChanged
+		}
+		mar.repo.auditCPs[i].Signature = newSignature
+		return nil
+	}
+	return gorm.ErrRecordNotFound
+}
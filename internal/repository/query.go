@@ -0,0 +1,142 @@
+// Package repository содержит слой доступа к данным.
+package repository
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/google/uuid"
+)
+
+// DataSort задает порядок записей в списке. Префикс "-" означает обратный порядок.
+type DataSort string
+
+// Поддерживаемые порядки записей.
+const (
+	DataSortName        DataSort = "name"
+	DataSortNameDesc    DataSort = "-name"
+	DataSortUpdated     DataSort = "updated_at"
+	DataSortUpdatedDesc DataSort = "-updated_at"
+	DataSortCreated     DataSort = "created_at"
+	DataSortCreatedDesc DataSort = "-created_at"
+)
+
+// IsValid проверяет, что порядок поддерживается.
+func (s DataSort) IsValid() bool {
+	switch s {
+	case DataSortName, DataSortNameDesc, DataSortUpdated, DataSortUpdatedDesc, DataSortCreated, DataSortCreatedDesc:
+		return true
+	}
+	return false
+}
+
+// column возвращает колонку, по которой сортируются записи.
+func (s DataSort) column() string {
+	return strings.TrimPrefix(string(s), "-")
+}
+
+// desc сообщает, что записи сортируются по убыванию.
+func (s DataSort) desc() bool {
+	return strings.HasPrefix(string(s), "-")
+}
+
+// DataCursor указывает на последнюю запись страницы: следующая страница начинается
+// после нее. Для сортировки по имени заполняется Name, для сортировки по времени - Time.
+type DataCursor struct {
+	Name string
+	Time time.Time
+	ID   uuid.UUID
+}
+
+// CursorFor возвращает курсор, указывающий на запись data при порядке sort.
+func CursorFor(sort DataSort, data *models.Data) DataCursor {
+	cursor := DataCursor{ID: data.ID}
+	switch sort.column() {
+	case "updated_at":
+		cursor.Time = data.UpdatedAt
+	case "created_at":
+		cursor.Time = data.CreatedAt
+	default:
+		cursor.Name = data.Name
+	}
+	return cursor
+}
+
+// DataQuery описывает выборку личных записей пользователя. Пустые фильтры не применяются.
+// Записи упорядочиваются по Sort, а при равенстве - по ID, поэтому курсор однозначно
+// задает продолжение списка, даже если записи добавляются между запросами.
+type DataQuery struct {
+	Type         models.DataType
+	NamePrefix   string     // Начало названия без учета регистра
+	UpdatedSince *time.Time // Только записи, измененные позже
+	Tags         []string   // Только записи, которым назначены все эти метки пользователя, без повторов
+	FolderID     *uuid.UUID // Только записи, лежащие непосредственно в этой папке
+	Favorite     bool       // Только избранные записи
+	// IncludeShared добавляет действующие записи других пользователей, открытые пользователю.
+	IncludeShared bool
+	Sort          DataSort
+	After         *DataCursor
+	Limit         int
+}
+
+// sortOrder возвращает порядок из запроса или порядок по имени, если он не задан.
+func (q *DataQuery) sortOrder() DataSort {
+	if q.Sort == "" {
+		return DataSortName
+	}
+	return q.Sort
+}
+
+// DataMatch представляет запись, найденную поиском, и ее релевантность.
+type DataMatch struct {
+	Data models.Data
+	Rank float64
+}
+
+// searchVector описывает поисковый документ записи: название важнее логина, логин важнее метаданных.
+// Секретные поля зашифрованы и в поиск не попадают.
+const searchVector = "setweight(to_tsvector('simple', coalesce(name, '')), 'A') || " +
+	"setweight(to_tsvector('simple', coalesce(login, '')), 'B') || " +
+	"setweight(to_tsvector('simple', coalesce(metadata, '')), 'C')"
+
+// searchTerms разбивает поисковый запрос на слова в нижнем регистре.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// prefixTSQuery строит запрос to_tsquery, в котором каждое слово ищется по началу.
+// Запрос собирается только из букв и цифр, поэтому синтаксис tsquery в нем не встречается.
+func prefixTSQuery(query string) string {
+	terms := searchTerms(query)
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+// escapeLike экранирует специальные символы шаблона LIKE.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// compareData сравнивает запись data с курсором при порядке sort без учета направления.
+func compareData(sort DataSort, data *models.Data, cursor *DataCursor) int {
+	var c int
+	switch sort.column() {
+	case "updated_at":
+		c = data.UpdatedAt.Compare(cursor.Time)
+	case "created_at":
+		c = data.CreatedAt.Compare(cursor.Time)
+	default:
+		c = strings.Compare(data.Name, cursor.Name)
+	}
+	if c == 0 {
+		c = bytes.Compare(data.ID[:], cursor.ID[:])
+	}
+	return c
+}
@@ -3,11 +3,16 @@ package repository
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/AlexeySalamakhin/GophKeeper/internal/blobstore"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/events"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
 	"github.com/google/uuid"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repository представляет слой доступа к данным.
@@ -23,10 +28,15 @@ func New(databaseURL string) *Repository {
 	}
 
 	// Автомиграция схемы
-	if err := db.AutoMigrate(&models.User{}, &models.Data{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.Data{}, &models.DataRevision{}, &models.Attachment{}, &models.AttachmentChunk{}, &models.Session{}, &models.Share{}, &models.Vault{}, &models.VaultMember{}, &models.AuditEvent{}, &models.AuditCheckpoint{}, &models.Tag{}, &models.DataTag{}, &models.Folder{}, &models.DataTombstone{}); err != nil {
 		panic("Ошибка миграции базы данных: " + err.Error())
 	}
 
+	// Индекс полнотекстового поиска строится по выражению, которое не описать тегами GORM
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_data_search ON data USING GIN ((" + searchVector + "))").Error; err != nil {
+		panic("Ошибка создания индекса поиска: " + err.Error())
+	}
+
 	return &Repository{db: db}
 }
 
@@ -75,6 +85,117 @@ func (ur *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 	return &user, nil
 }
 
+// Update обновляет пользователя.
+func (ur *UserRepository) Update(user *models.User) error {
+	return ur.db.Save(user).Error
+}
+
+// GetBatchAfter возвращает до limit пользователей с ID больше afterID в порядке возрастания ID.
+func (ur *UserRepository) GetBatchAfter(afterID uuid.UUID, limit int) ([]models.User, error) {
+	var users []models.User
+	err := ur.db.Where("id > ?", afterID).Order("id").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// ReplaceTOTPSecret заменяет зашифрованный секрет одноразовых паролей, если он не изменился
+// с момента чтения. Иначе возвращает ErrCiphertextChanged.
+func (ur *UserRepository) ReplaceTOTPSecret(id uuid.UUID, current, secret string) error {
+	result := ur.db.Model(&models.User{}).
+		Where("id = ? AND totp_secret = ?", id, current).
+		UpdateColumn("totp_secret", secret)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCiphertextChanged
+	}
+	return nil
+}
+
+// AddTOTPFailure атомарно увеличивает счетчик неверных одноразовых паролей
+// и возвращает его новое значение.
+func (ur *UserRepository) AddTOTPFailure(id uuid.UUID) (int, error) {
+	var user models.User
+	result := ur.db.Model(&user).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "totp_failures"}}}).
+		Where("id = ?", id).
+		UpdateColumn("totp_failures", gorm.Expr("totp_failures + 1"))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return user.TOTPFailures, nil
+}
+
+// LockTOTP запрещает проверку одноразовых паролей до until и обнуляет счетчик неверных паролей.
+func (ur *UserRepository) LockTOTP(id uuid.UUID, until time.Time) error {
+	return ur.db.Model(&models.User{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"totp_failures": 0, "totp_locked_until": until}).Error
+}
+
+// UseTOTPStep запоминает шаг принятого одноразового пароля и сбрасывает счетчик неверных паролей,
+// если пароль этого или более позднего шага еще не принят. Иначе возвращает ErrCodeUsed.
+func (ur *UserRepository) UseTOTPStep(id uuid.UUID, step int64) error {
+	result := ur.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		UpdateColumns(map[string]interface{}{"totp_last_step": step, "totp_failures": 0, "totp_locked_until": nil})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCodeUsed
+	}
+	return nil
+}
+
+// UseRecoveryCode заменяет хеши кодов восстановления current на remaining без использованного кода
+// и сбрасывает счетчик неверных паролей. Если коды изменились с момента чтения, возвращает ErrCodeUsed.
+func (ur *UserRepository) UseRecoveryCode(id uuid.UUID, current, remaining string) error {
+	result := ur.db.Model(&models.User{}).
+		Where("id = ? AND recovery_codes = ?", id, current).
+		UpdateColumns(map[string]interface{}{"recovery_codes": remaining, "totp_failures": 0, "totp_locked_until": nil})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCodeUsed
+	}
+	return nil
+}
+
+// SetKDFCheck сохраняет проверочное значение ключа хранилища пользователя, если оно еще не задано.
+// Иначе возвращает ErrKDFCheckSet.
+func (ur *UserRepository) SetKDFCheck(id uuid.UUID, check string) error {
+	result := ur.db.Model(&models.User{}).
+		Where("id = ? AND (kdf_check IS NULL OR kdf_check = '')", id).
+		UpdateColumn("kdf_check", check)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrKDFCheckSet
+	}
+	return nil
+}
+
+// ReplaceDataKey заменяет зашифрованный ключ данных пользователя, если он не изменился
+// с момента чтения. Иначе возвращает ErrCiphertextChanged.
+func (ur *UserRepository) ReplaceDataKey(id uuid.UUID, current, dataKey string) error {
+	result := ur.db.Model(&models.User{}).
+		Where("id = ? AND data_key = ?", id, current).
+		UpdateColumn("data_key", dataKey)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCiphertextChanged
+	}
+	return nil
+}
+
 // DataRepository содержит методы для работы с данными пользователей.
 type DataRepository struct {
 	db *gorm.DB
@@ -100,23 +221,313 @@ func (dr *DataRepository) GetByID(id uuid.UUID) (*models.Data, error) {
 	return &data, nil
 }
 
-// GetByUserID возвращает все данные пользователя.
+// GetByIDs возвращает неудаленные данные с указанными ID.
+func (dr *DataRepository) GetByIDs(ids []uuid.UUID) ([]models.Data, error) {
+	var data []models.Data
+	if len(ids) == 0 {
+		return data, nil
+	}
+	err := dr.db.Where("id IN ?", ids).Find(&data).Error
+	return data, err
+}
+
+// GetByUserID возвращает все личные данные пользователя.
 func (dr *DataRepository) GetByUserID(userID uuid.UUID) ([]models.Data, error) {
 	var data []models.Data
-	err := dr.db.Where("user_id = ?", userID).Find(&data).Error
+	err := dr.db.Where("user_id = ? AND vault_id IS NULL", userID).Find(&data).Error
+	return data, err
+}
+
+// GetByUserIDAndType возвращает личные данные пользователя указанного типа.
+func (dr *DataRepository) GetByUserIDAndType(userID uuid.UUID, dataType models.DataType) ([]models.Data, error) {
+	var data []models.Data
+	err := dr.db.Where("user_id = ? AND vault_id IS NULL AND type = ?", userID, dataType).Find(&data).Error
+	return data, err
+}
+
+// GetByVaultID возвращает данные командного хранилища.
+func (dr *DataRepository) GetByVaultID(vaultID uuid.UUID) ([]models.Data, error) {
+	var data []models.Data
+	err := dr.db.Where("vault_id = ?", vaultID).Order("name").Find(&data).Error
+	return data, err
+}
+
+// List возвращает до query.Limit записей пользователя, подходящих под фильтры запроса,
+// в порядке query.Sort после курсора query.After.
+func (dr *DataRepository) List(userID uuid.UUID, query DataQuery) ([]models.Data, error) {
+	db := dr.visibleTo(userID, query.IncludeShared)
+
+	if query.Type != "" {
+		db = db.Where("type = ?", query.Type)
+	}
+	if query.NamePrefix != "" {
+		db = db.Where("name ILIKE ?", escapeLike(query.NamePrefix)+"%")
+	}
+	if query.UpdatedSince != nil {
+		db = db.Where("updated_at > ?", *query.UpdatedSince)
+	}
+	if len(query.Tags) > 0 {
+		tagged := dr.db.Model(&models.DataTag{}).
+			Select("data_tags.data_id").
+			Joins("JOIN tags ON tags.id = data_tags.tag_id").
+			Where("tags.user_id = ? AND tags.name IN ?", userID, query.Tags).
+			Group("data_tags.data_id").
+			Having("COUNT(DISTINCT tags.id) = ?", len(query.Tags))
+		db = db.Where("id IN (?)", tagged)
+	}
+	if query.FolderID != nil {
+		db = db.Where("folder_id = ?", *query.FolderID)
+	}
+	if query.Favorite {
+		db = db.Where("favorite")
+	}
+
+	// Колонка берется из проверенного порядка, а не из запроса пользователя
+	order := query.sortOrder()
+	column, op, dir := order.column(), ">", "ASC"
+	if order.desc() {
+		op, dir = "<", "DESC"
+	}
+	if query.After != nil {
+		var value interface{} = query.After.Name
+		if column != "name" {
+			value = query.After.Time
+		}
+		db = db.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, op), value, query.After.ID)
+	}
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+
+	var data []models.Data
+	err := db.Order(fmt.Sprintf("%s %s, id %s", column, dir, dir)).Find(&data).Error
 	return data, err
 }
 
-// Update обновляет данные.
+// Search ищет личные записи пользователя, открытые ему записи и записи его хранилищ
+// по словам query в названии, логине и метаданных. Слова ищутся по началу, результаты упорядочены по убыванию релевантности.
+func (dr *DataRepository) Search(userID uuid.UUID, query string, limit int) ([]DataMatch, error) {
+	tsquery := prefixTSQuery(query)
+	if tsquery == "" {
+		return nil, nil
+	}
+
+	var rows []struct {
+		models.Data
+		Rank float64
+	}
+	err := dr.accessibleTo(userID).
+		Model(&models.Data{}).
+		Select("data.*, ts_rank("+searchVector+", to_tsquery('simple', ?)) AS rank", tsquery).
+		Where(searchVector+" @@ to_tsquery('simple', ?)", tsquery).
+		Order("rank DESC, name, id").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]DataMatch, 0, len(rows))
+	for _, row := range rows {
+		matches = append(matches, DataMatch{Data: row.Data, Rank: row.Rank})
+	}
+	return matches, nil
+}
+
+// visibleTo ограничивает выборку личными записями пользователя и, если includeShared
+// установлен, действующими записями других пользователей, открытыми ему.
+func (dr *DataRepository) visibleTo(userID uuid.UUID, includeShared bool) *gorm.DB {
+	if !includeShared {
+		return dr.db.Where("user_id = ? AND vault_id IS NULL", userID)
+	}
+	return dr.db.Where("((user_id = ? AND vault_id IS NULL) OR id IN (?))", userID, dr.sharedWith(userID))
+}
+
+// accessibleTo ограничивает выборку всеми записями, которые может читать пользователь:
+// личными, открытыми ему и записями хранилищ, в которых он участвует с любой ролью.
+func (dr *DataRepository) accessibleTo(userID uuid.UUID) *gorm.DB {
+	return dr.db.Where("((user_id = ? AND vault_id IS NULL) OR id IN (?) OR vault_id IN (?))",
+		userID, dr.sharedWith(userID), dr.memberVaults(userID))
+}
+
+// sharedWith возвращает подзапрос ID записей, открытых пользователю по действующим доступам.
+func (dr *DataRepository) sharedWith(userID uuid.UUID) *gorm.DB {
+	return dr.db.Model(&models.Share{}).
+		Select("data_id").
+		Where("grantee_id = ? AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now())
+}
+
+// memberVaults возвращает подзапрос ID хранилищ, в которых участвует пользователь.
+func (dr *DataRepository) memberVaults(userID uuid.UUID) *gorm.DB {
+	return dr.db.Model(&models.VaultMember{}).Select("vault_id").Where("user_id = ?", userID)
+}
+
+// Update обновляет данные и увеличивает их версию.
 func (dr *DataRepository) Update(data *models.Data) error {
+	data.Version++
 	return dr.db.Save(data).Error
 }
 
+// UpdateWithVersion обновляет данные, только если их версия в базе совпадает с ожидаемой.
+// При несовпадении возвращает ErrVersionConflict.
+func (dr *DataRepository) UpdateWithVersion(data *models.Data, expectedVersion int64) error {
+	data.Version = expectedVersion + 1
+	result := dr.db.Model(&models.Data{}).
+		Where("id = ? AND version = ?", data.ID, expectedVersion).
+		Select("*").
+		Omit("id", "created_at").
+		Updates(data)
+	if result.Error != nil {
+		data.Version = expectedVersion
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		data.Version = expectedVersion
+		return ErrVersionConflict
+	}
+	return nil
+}
+
 // Delete удаляет данные.
 func (dr *DataRepository) Delete(id uuid.UUID) error {
 	return dr.db.Delete(&models.Data{}, id).Error
 }
 
+// Restore восстанавливает удаленные данные.
+func (dr *DataRepository) Restore(id uuid.UUID) error {
+	result := dr.db.Unscoped().Model(&models.Data{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("удаленные данные не найдены")
+	}
+	return nil
+}
+
+// GetDeletedByID возвращает удаленные данные по ID.
+func (dr *DataRepository) GetDeletedByID(id uuid.UUID) (*models.Data, error) {
+	var data models.Data
+	err := dr.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&data).Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// GetDeletedByUserID возвращает удаленные личные данные пользователя и данные хранилищ,
+// в которых он участвует, начиная с последних удаленных.
+func (dr *DataRepository) GetDeletedByUserID(userID uuid.UUID) ([]models.Data, error) {
+	var data []models.Data
+	err := dr.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND ((user_id = ? AND vault_id IS NULL) OR vault_id IN (?))", userID, dr.memberVaults(userID)).
+		Order("deleted_at DESC").
+		Find(&data).Error
+	return data, err
+}
+
+// Purge окончательно удаляет удаленные данные вместе с их историей изменений и доступами
+// и оставляет вместо них отметку об удалении для синхронизации.
+func (dr *DataRepository) Purge(id uuid.UUID) error {
+	return dr.db.Transaction(func(tx *gorm.DB) error {
+		var data models.Data
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&data).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("удаленные данные не найдены")
+			}
+			return err
+		}
+		if err := tx.Unscoped().Delete(&data).Error; err != nil {
+			return err
+		}
+		if err := saveTombstones(tx, []models.Data{data}); err != nil {
+			return err
+		}
+		if err := tx.Where("data_id = ?", id).Delete(&models.Share{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("data_id = ?", id).Delete(&models.DataTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("data_id = ?", id).Delete(&models.DataRevision{}).Error
+	})
+}
+
+// PurgeDeletedBefore окончательно удаляет данные, удаленные раньше cutoff,
+// вместе с их историей изменений и доступами и оставляет вместо них отметки об удалении.
+// Возвращает число удаленных записей.
+func (dr *DataRepository) PurgeDeletedBefore(cutoff time.Time) (int64, error) {
+	var purged int64
+	err := dr.db.Transaction(func(tx *gorm.DB) error {
+		var expired []models.Data
+		err := tx.Unscoped().
+			Select("id", "user_id", "vault_id").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Find(&expired).Error
+		if err != nil || len(expired) == 0 {
+			return err
+		}
+		ids := make([]uuid.UUID, len(expired))
+		for i := range expired {
+			ids[i] = expired[i].ID
+		}
+
+		if err := saveTombstones(tx, expired); err != nil {
+			return err
+		}
+		if err := tx.Where("data_id IN ?", ids).Delete(&models.DataRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("data_id IN ?", ids).Delete(&models.Share{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("data_id IN ?", ids).Delete(&models.DataTag{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&models.Data{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
+
+// saveTombstones сохраняет отметки об окончательном удалении записей в транзакции tx.
+func saveTombstones(tx *gorm.DB, data []models.Data) error {
+	now := time.Now()
+	tombstones := make([]models.DataTombstone, len(data))
+	for i := range data {
+		tombstones[i] = *models.NewTombstone(&data[i], now)
+	}
+	return tx.Save(&tombstones).Error
+}
+
+// GetChangedSince возвращает личные данные пользователя и данные хранилищ, в которых он участвует,
+// измененные или удаленные в корзину после указанного момента.
+// Удаленные записи возвращаются с заполненным DeletedAt.
+func (dr *DataRepository) GetChangedSince(userID uuid.UUID, since time.Time) ([]models.Data, error) {
+	var data []models.Data
+	err := dr.db.Unscoped().
+		Where("((user_id = ? AND vault_id IS NULL) OR vault_id IN (?)) AND (updated_at > ? OR deleted_at > ?)",
+			userID, dr.memberVaults(userID), since, since).
+		Order("updated_at").
+		Find(&data).Error
+	return data, err
+}
+
+// GetPurgedSince возвращает отметки об окончательном удалении личных записей пользователя
+// и записей хранилищ, в которых он участвует, сделанные после указанного момента.
+func (dr *DataRepository) GetPurgedSince(userID uuid.UUID, since time.Time) ([]models.DataTombstone, error) {
+	var tombstones []models.DataTombstone
+	err := dr.db.
+		Where("((user_id = ? AND vault_id IS NULL) OR vault_id IN (?)) AND purged_at > ?",
+			userID, dr.memberVaults(userID), since).
+		Order("purged_at").
+		Find(&tombstones).Error
+	return tombstones, err
+}
+
 // CheckUserOwnership проверяет, принадлежат ли данные пользователю.
 func (dr *DataRepository) CheckUserOwnership(dataID, userID uuid.UUID) error {
 	var count int64
@@ -131,3 +542,670 @@ func (dr *DataRepository) CheckUserOwnership(dataID, userID uuid.UUID) error {
 	}
 	return nil
 }
+
+// GetBatchAfter возвращает до limit записей с ID больше afterID в порядке возрастания ID,
+// включая удаленные в корзину.
+func (dr *DataRepository) GetBatchAfter(afterID uuid.UUID, limit int) ([]models.Data, error) {
+	var data []models.Data
+	err := dr.db.Unscoped().Where("id > ?", afterID).Order("id").Limit(limit).Find(&data).Error
+	return data, err
+}
+
+// ReplaceCiphertext заменяет зашифрованные поля записи, если они не изменились с момента чтения.
+// Версия и время изменения записи сохраняются. Если поля изменились, возвращает ErrCiphertextChanged.
+func (dr *DataRepository) ReplaceCiphertext(id uuid.UUID, oldPassword, oldPayload, password, payload string) error {
+	result := dr.db.Unscoped().Model(&models.Data{}).
+		Where("id = ? AND password = ? AND payload = ?", id, oldPassword, oldPayload).
+		UpdateColumns(map[string]interface{}{"password": password, "payload": payload})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCiphertextChanged
+	}
+	return nil
+}
+
+// Transaction выполняет fn в транзакции базы данных. Транзакция фиксируется,
+// если fn завершается без ошибки, иначе откатывается.
+func (dr *DataRepository) Transaction(fn func(uow *UnitOfWork) error) error {
+	return dr.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&UnitOfWork{
+			Data:        &DataRepository{db: tx},
+			Revisions:   &RevisionRepository{db: tx},
+			Tags:        &TagRepository{db: tx},
+			Folders:     &FolderRepository{db: tx},
+			Attachments: &AttachmentRepository{db: tx},
+		})
+	})
+}
+
+// RevisionRepository содержит методы для работы с историей изменений данных.
+type RevisionRepository struct {
+	db *gorm.DB
+}
+
+// NewRevisionRepository создает новый репозиторий истории изменений.
+func (r *Repository) NewRevisionRepository() *RevisionRepository {
+	return &RevisionRepository{db: r.db}
+}
+
+// Create сохраняет ревизию записи.
+func (rr *RevisionRepository) Create(revision *models.DataRevision) error {
+	return rr.db.Create(revision).Error
+}
+
+// GetByID возвращает ревизию по ID.
+func (rr *RevisionRepository) GetByID(id uuid.UUID) (*models.DataRevision, error) {
+	var revision models.DataRevision
+	err := rr.db.Where("id = ?", id).First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// GetByDataID возвращает ревизии записи, начиная с самой новой.
+func (rr *RevisionRepository) GetByDataID(dataID uuid.UUID) ([]models.DataRevision, error) {
+	var revisions []models.DataRevision
+	err := rr.db.Where("data_id = ?", dataID).Order("created_at DESC, version DESC").Find(&revisions).Error
+	return revisions, err
+}
+
+// GetBatchAfter возвращает до limit ревизий с ID больше afterID в порядке возрастания ID.
+func (rr *RevisionRepository) GetBatchAfter(afterID uuid.UUID, limit int) ([]models.DataRevision, error) {
+	var revisions []models.DataRevision
+	err := rr.db.Where("id > ?", afterID).Order("id").Limit(limit).Find(&revisions).Error
+	return revisions, err
+}
+
+// ReplaceCiphertext заменяет зашифрованные поля ревизии, если они не изменились с момента чтения.
+// Иначе возвращает ErrCiphertextChanged.
+func (rr *RevisionRepository) ReplaceCiphertext(id uuid.UUID, oldPassword, oldPayload, password, payload string) error {
+	result := rr.db.Model(&models.DataRevision{}).
+		Where("id = ? AND password = ? AND payload = ?", id, oldPassword, oldPayload).
+		UpdateColumns(map[string]interface{}{"password": password, "payload": payload})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCiphertextChanged
+	}
+	return nil
+}
+
+// NewBlobStore создает хранилище фрагментов файлов в large objects PostgreSQL.
+func (r *Repository) NewBlobStore() (*blobstore.PostgresStore, error) {
+	return blobstore.NewPostgres(r.db)
+}
+
+// NewEventRelay создает шину событий на LISTEN/NOTIFY PostgreSQL.
+func (r *Repository) NewEventRelay() *events.PostgresRelay {
+	return events.NewPostgresRelay(r.db)
+}
+
+// AttachmentRepository содержит методы для работы с файлами, загружаемыми фрагментами.
+type AttachmentRepository struct {
+	db *gorm.DB
+}
+
+// NewAttachmentRepository создает новый репозиторий файлов.
+func (r *Repository) NewAttachmentRepository() *AttachmentRepository {
+	return &AttachmentRepository{db: r.db}
+}
+
+// Create создает описание файла.
+func (ar *AttachmentRepository) Create(attachment *models.Attachment) error {
+	return ar.db.Create(attachment).Error
+}
+
+// GetByDataID возвращает описание файла записи.
+func (ar *AttachmentRepository) GetByDataID(dataID uuid.UUID) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := ar.db.Where("data_id = ?", dataID).First(&attachment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// MarkComplete отмечает, что все фрагменты файла загружены.
+func (ar *AttachmentRepository) MarkComplete(dataID uuid.UUID) error {
+	return ar.db.Model(&models.Attachment{}).Where("data_id = ?", dataID).Update("complete", true).Error
+}
+
+// SaveChunk сохраняет сведения о загруженном фрагменте, заменяя ранее загруженный.
+func (ar *AttachmentRepository) SaveChunk(chunk *models.AttachmentChunk) error {
+	return ar.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(chunk).Error
+}
+
+// GetChunks возвращает загруженные фрагменты файла по порядку.
+func (ar *AttachmentRepository) GetChunks(dataID uuid.UUID) ([]models.AttachmentChunk, error) {
+	var chunks []models.AttachmentChunk
+	err := ar.db.Where("data_id = ?", dataID).Order("chunk_index").Find(&chunks).Error
+	return chunks, err
+}
+
+// GetOrphaned возвращает файлы, записи которых удалены окончательно.
+func (ar *AttachmentRepository) GetOrphaned() ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := ar.db.
+		Joins("LEFT JOIN data ON data.id = attachments.data_id").
+		Where("data.id IS NULL").
+		Find(&attachments).Error
+	return attachments, err
+}
+
+// Delete удаляет описание файла и сведения о его фрагментах.
+func (ar *AttachmentRepository) Delete(dataID uuid.UUID) error {
+	return ar.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("data_id = ?", dataID).Delete(&models.AttachmentChunk{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("data_id = ?", dataID).Delete(&models.Attachment{}).Error
+	})
+}
+
+// SessionRepository содержит методы для работы с сессиями пользователей.
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository создает новый репозиторий сессий.
+func (r *Repository) NewSessionRepository() *SessionRepository {
+	return &SessionRepository{db: r.db}
+}
+
+// Create создает сессию.
+func (sr *SessionRepository) Create(session *models.Session) error {
+	return sr.db.Create(session).Error
+}
+
+// GetByID возвращает сессию по ID.
+func (sr *SessionRepository) GetByID(id uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	err := sr.db.Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetByRefreshTokenHash возвращает сессию по хешу текущего или предыдущего токена обновления.
+func (sr *SessionRepository) GetByRefreshTokenHash(hash string) (*models.Session, error) {
+	var session models.Session
+	err := sr.db.Where("refresh_token_hash = ? OR previous_token_hash = ?", hash, hash).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetActiveByUserID возвращает действующие на момент now сессии пользователя, начиная с последней активной.
+func (sr *SessionRepository) GetActiveByUserID(userID uuid.UUID, now time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := sr.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
+// Rotate заменяет токен обновления сессии, если текущий токен совпадает с currentHash.
+// Кроме хешей обновляются срок действия, IP и время последней активности.
+// Если сессия отозвана или токен уже заменен параллельным запросом, возвращается gorm.ErrRecordNotFound.
+func (sr *SessionRepository) Rotate(session *models.Session, currentHash string) error {
+	result := sr.db.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, currentHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  session.RefreshTokenHash,
+			"previous_token_hash": session.PreviousTokenHash,
+			"expires_at":          session.ExpiresAt,
+			"last_seen_at":        session.LastSeenAt,
+			"ip":                  session.IP,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Touch обновляет IP и время последней активности действующей сессии.
+func (sr *SessionRepository) Touch(id uuid.UUID, ip string, at time.Time) error {
+	return sr.db.Model(&models.Session{}).Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"ip": ip, "last_seen_at": at}).Error
+}
+
+// Revoke отзывает сессию.
+func (sr *SessionRepository) Revoke(id uuid.UUID, at time.Time) error {
+	result := sr.db.Model(&models.Session{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RevokeByUserID отзывает все сессии пользователя и возвращает их число.
+func (sr *SessionRepository) RevokeByUserID(userID uuid.UUID, at time.Time) (int64, error) {
+	result := sr.db.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID).Update("revoked_at", at)
+	return result.RowsAffected, result.Error
+}
+
+// ShareRepository представляет репозиторий доступов к записям.
+type ShareRepository struct {
+	db *gorm.DB
+}
+
+// NewShareRepository создает новый репозиторий доступов.
+func (r *Repository) NewShareRepository() *ShareRepository {
+	return &ShareRepository{db: r.db}
+}
+
+// Save выдает доступ к записи. Если получатель уже имеет доступ к записи,
+// его права и срок заменяются, а share получает ID и время создания существующего доступа.
+func (sr *ShareRepository) Save(share *models.Share) error {
+	return sr.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.Share
+		err := tx.Where("data_id = ? AND grantee_id = ?", share.DataID, share.GranteeID).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Create(share).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		share.ID = existing.ID
+		share.CreatedAt = existing.CreatedAt
+		return tx.Model(&existing).Updates(map[string]interface{}{
+			"permission": share.Permission,
+			"expires_at": share.ExpiresAt,
+		}).Error
+	})
+}
+
+// GetByDataID возвращает все доступы к записи, начиная с самого раннего.
+func (sr *ShareRepository) GetByDataID(dataID uuid.UUID) ([]models.Share, error) {
+	var shares []models.Share
+	err := sr.db.Where("data_id = ?", dataID).Order("created_at").Find(&shares).Error
+	return shares, err
+}
+
+// GetActive возвращает действующий на момент now доступ получателя к записи.
+func (sr *ShareRepository) GetActive(dataID, granteeID uuid.UUID, now time.Time) (*models.Share, error) {
+	var share models.Share
+	err := sr.db.Where("data_id = ? AND grantee_id = ? AND (expires_at IS NULL OR expires_at > ?)", dataID, granteeID, now).
+		First(&share).Error
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// GetActiveByGranteeID возвращает действующие на момент now доступы получателя.
+func (sr *ShareRepository) GetActiveByGranteeID(granteeID uuid.UUID, now time.Time) ([]models.Share, error) {
+	var shares []models.Share
+	err := sr.db.Where("grantee_id = ? AND (expires_at IS NULL OR expires_at > ?)", granteeID, now).
+		Order("created_at").Find(&shares).Error
+	return shares, err
+}
+
+// Delete отзывает доступ. Если доступа нет, возвращается gorm.ErrRecordNotFound.
+func (sr *ShareRepository) Delete(id uuid.UUID) error {
+	result := sr.db.Where("id = ?", id).Delete(&models.Share{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// VaultRepository представляет репозиторий командных хранилищ и их участников.
+type VaultRepository struct {
+	db *gorm.DB
+}
+
+// NewVaultRepository создает новый репозиторий хранилищ.
+func (r *Repository) NewVaultRepository() *VaultRepository {
+	return &VaultRepository{db: r.db}
+}
+
+// Create создает хранилище вместе с его первым участником owner.
+func (vr *VaultRepository) Create(vault *models.Vault, owner *models.VaultMember) error {
+	return vr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(vault).Error; err != nil {
+			return err
+		}
+		owner.VaultID = vault.ID
+		return tx.Create(owner).Error
+	})
+}
+
+// GetByID возвращает хранилище по ID.
+func (vr *VaultRepository) GetByID(id uuid.UUID) (*models.Vault, error) {
+	var vault models.Vault
+	err := vr.db.Where("id = ?", id).First(&vault).Error
+	if err != nil {
+		return nil, err
+	}
+	return &vault, nil
+}
+
+// GetBatchAfter возвращает до limit хранилищ с ID больше afterID в порядке возрастания ID.
+func (vr *VaultRepository) GetBatchAfter(afterID uuid.UUID, limit int) ([]models.Vault, error) {
+	var vaults []models.Vault
+	err := vr.db.Where("id > ?", afterID).Order("id").Limit(limit).Find(&vaults).Error
+	return vaults, err
+}
+
+// ReplaceDataKey заменяет зашифрованный ключ данных хранилища, если он не изменился
+// с момента чтения. Иначе возвращает ErrCiphertextChanged.
+func (vr *VaultRepository) ReplaceDataKey(id uuid.UUID, current, dataKey string) error {
+	result := vr.db.Model(&models.Vault{}).
+		Where("id = ? AND data_key = ?", id, current).
+		UpdateColumn("data_key", dataKey)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCiphertextChanged
+	}
+	return nil
+}
+
+// Delete удаляет хранилище вместе с его участниками.
+func (vr *VaultRepository) Delete(id uuid.UUID) error {
+	return vr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("vault_id = ?", id).Delete(&models.VaultMember{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", id).Delete(&models.Vault{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// GetMember возвращает участника хранилища.
+func (vr *VaultRepository) GetMember(vaultID, userID uuid.UUID) (*models.VaultMember, error) {
+	var member models.VaultMember
+	err := vr.db.Where("vault_id = ? AND user_id = ?", vaultID, userID).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetMembers возвращает участников хранилища в порядке добавления.
+func (vr *VaultRepository) GetMembers(vaultID uuid.UUID) ([]models.VaultMember, error) {
+	var members []models.VaultMember
+	err := vr.db.Where("vault_id = ?", vaultID).Order("created_at").Find(&members).Error
+	return members, err
+}
+
+// GetMemberships возвращает участие пользователя в хранилищах.
+func (vr *VaultRepository) GetMemberships(userID uuid.UUID) ([]models.VaultMember, error) {
+	var members []models.VaultMember
+	err := vr.db.Where("user_id = ?", userID).Order("created_at").Find(&members).Error
+	return members, err
+}
+
+// SaveMember добавляет участника хранилища или изменяет его роль.
+func (vr *VaultRepository) SaveMember(member *models.VaultMember) error {
+	return vr.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "vault_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(member).Error
+}
+
+// DeleteMember исключает пользователя из хранилища.
+// Если пользователь не участвует в хранилище, возвращается gorm.ErrRecordNotFound.
+func (vr *VaultRepository) DeleteMember(vaultID, userID uuid.UUID) error {
+	result := vr.db.Where("vault_id = ? AND user_id = ?", vaultID, userID).Delete(&models.VaultMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TagRepository представляет репозиторий меток и их назначений записям.
+type TagRepository struct {
+	db *gorm.DB
+}
+
+// NewTagRepository создает новый репозиторий меток.
+func (r *Repository) NewTagRepository() *TagRepository {
+	return &TagRepository{db: r.db}
+}
+
+// Create создает метку.
+func (tr *TagRepository) Create(tag *models.Tag) error {
+	return tr.db.Create(tag).Error
+}
+
+// GetByID возвращает метку по ID.
+func (tr *TagRepository) GetByID(id uuid.UUID) (*models.Tag, error) {
+	var tag models.Tag
+	if err := tr.db.Where("id = ?", id).First(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// GetByName возвращает метку пользователя по имени.
+func (tr *TagRepository) GetByName(userID uuid.UUID, name string) (*models.Tag, error) {
+	var tag models.Tag
+	if err := tr.db.Where("user_id = ? AND name = ?", userID, name).First(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// GetByUserID возвращает метки пользователя по алфавиту.
+func (tr *TagRepository) GetByUserID(userID uuid.UUID) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := tr.db.Where("user_id = ?", userID).Order("name").Find(&tags).Error
+	return tags, err
+}
+
+// Update переименовывает метку.
+func (tr *TagRepository) Update(tag *models.Tag) error {
+	return tr.db.Model(tag).Update("name", tag.Name).Error
+}
+
+// Delete удаляет метку и снимает ее со всех записей.
+// Если метки нет, возвращается gorm.ErrRecordNotFound.
+func (tr *TagRepository) Delete(id uuid.UUID) error {
+	return tr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", id).Delete(&models.DataTag{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", id).Delete(&models.Tag{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// SetDataTags заменяет метки записи на tagIDs.
+func (tr *TagRepository) SetDataTags(dataID uuid.UUID, tagIDs []uuid.UUID) error {
+	return tr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("data_id = ?", dataID).Delete(&models.DataTag{}).Error; err != nil {
+			return err
+		}
+		if len(tagIDs) == 0 {
+			return nil
+		}
+		links := make([]models.DataTag, 0, len(tagIDs))
+		for _, id := range tagIDs {
+			links = append(links, models.DataTag{DataID: dataID, TagID: id})
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+	})
+}
+
+// GetNamesByDataIDs возвращает имена меток записей dataIDs по алфавиту.
+// Записи без меток в результат не попадают.
+func (tr *TagRepository) GetNamesByDataIDs(dataIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	if len(dataIDs) == 0 {
+		return nil, nil
+	}
+
+	var rows []struct {
+		DataID uuid.UUID
+		Name   string
+	}
+	err := tr.db.Model(&models.DataTag{}).
+		Select("data_tags.data_id, tags.name").
+		Joins("JOIN tags ON tags.id = data_tags.tag_id").
+		Where("data_tags.data_id IN ?", dataIDs).
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[uuid.UUID][]string)
+	for _, row := range rows {
+		names[row.DataID] = append(names[row.DataID], row.Name)
+	}
+	return names, nil
+}
+
+// FolderRepository представляет репозиторий папок.
+type FolderRepository struct {
+	db *gorm.DB
+}
+
+// NewFolderRepository создает новый репозиторий папок.
+func (r *Repository) NewFolderRepository() *FolderRepository {
+	return &FolderRepository{db: r.db}
+}
+
+// Create создает папку.
+func (fr *FolderRepository) Create(folder *models.Folder) error {
+	return fr.db.Create(folder).Error
+}
+
+// GetByID возвращает папку по ID.
+func (fr *FolderRepository) GetByID(id uuid.UUID) (*models.Folder, error) {
+	var folder models.Folder
+	if err := fr.db.Where("id = ?", id).First(&folder).Error; err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+// GetByUserID возвращает все папки пользователя по алфавиту.
+func (fr *FolderRepository) GetByUserID(userID uuid.UUID) ([]models.Folder, error) {
+	var folders []models.Folder
+	err := fr.db.Where("user_id = ?", userID).Order("name, id").Find(&folders).Error
+	return folders, err
+}
+
+// Update переименовывает папку или переносит ее в другую родительскую папку.
+func (fr *FolderRepository) Update(folder *models.Folder) error {
+	return fr.db.Model(folder).Select("name", "parent_id", "updated_at").Updates(folder).Error
+}
+
+// Delete удаляет папку. Если папки нет, возвращается gorm.ErrRecordNotFound.
+func (fr *FolderRepository) Delete(id uuid.UUID) error {
+	result := fr.db.Where("id = ?", id).Delete(&models.Folder{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// AuditRepository представляет журнал аудита.
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository создает новый репозиторий журнала аудита.
+func (r *Repository) NewAuditRepository() *AuditRepository {
+	return &AuditRepository{db: r.db}
+}
+
+// Create добавляет событие в журнал.
+func (ar *AuditRepository) Create(event *models.AuditEvent) error {
+	return ar.db.Create(event).Error
+}
+
+// GetByActorID возвращает страницу событий пользователя, начиная с самого нового,
+// и общее число его событий.
+func (ar *AuditRepository) GetByActorID(actorID uuid.UUID, limit, offset int) ([]models.AuditEvent, int64, error) {
+	var total int64
+	if err := ar.db.Model(&models.AuditEvent{}).Where("actor_id = ?", actorID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.AuditEvent
+	err := ar.db.Where("actor_id = ?", actorID).
+		Order("seq DESC").
+		Limit(limit).Offset(offset).
+		Find(&events).Error
+	return events, total, err
+}
+
+// GetLast возвращает последнее событие цепочки или gorm.ErrRecordNotFound, если журнал пуст.
+func (ar *AuditRepository) GetLast() (*models.AuditEvent, error) {
+	var event models.AuditEvent
+	if err := ar.db.Order("seq DESC").First(&event).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// GetAfter возвращает до limit событий с номерами больше seq по возрастанию номера.
+func (ar *AuditRepository) GetAfter(seq int64, limit int) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	err := ar.db.Where("seq > ?", seq).Order("seq").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// CreateCheckpoint сохраняет контрольную точку цепочки.
+func (ar *AuditRepository) CreateCheckpoint(checkpoint *models.AuditCheckpoint) error {
+	return ar.db.Create(checkpoint).Error
+}
+
+// GetCheckpoints возвращает контрольные точки по возрастанию номера события.
+func (ar *AuditRepository) GetCheckpoints() ([]models.AuditCheckpoint, error) {
+	var checkpoints []models.AuditCheckpoint
+	err := ar.db.Order("seq").Find(&checkpoints).Error
+	return checkpoints, err
+}
+
+// ReplaceCheckpointSignature заменяет подпись контрольной точки, если она не изменилась после чтения.
+// Если подпись уже другая, возвращает ErrCiphertextChanged.
+func (ar *AuditRepository) ReplaceCheckpointSignature(id uuid.UUID, oldSignature, newSignature string) error {
+	result := ar.db.Model(&models.AuditCheckpoint{}).
+		Where("id = ? AND signature = ?", id, oldSignature).
+		Update("signature", newSignature)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCiphertextChanged
+	}
+	return nil
+}
@@ -2,10 +2,14 @@
 package repository
 
 import (
+	"errors"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func setupTestDB(t *testing.T) *MemoryRepository {
@@ -378,4 +382,699 @@ func TestDataRepository_Delete_NotFound(t *testing.T) {
 	// Удаление несуществующей записи может не возвращать ошибку в зависимости от реализации
 	// Проверяем, что метод выполняется без паники
 	_ = err
-}
\ No newline at end of file
+}
+func TestDataRepository_GetChangedSince(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	dataRepo := repo.NewDataRepository()
+	userID := uuid.New()
+
+	old := &models.Data{UserID: userID, Name: "Old"}
+	if err := dataRepo.Create(old); err != nil {
+		t.Fatalf("Ошибка создания данных: %v", err)
+	}
+
+	since := time.Now()
+	time.Sleep(time.Millisecond)
+
+	fresh := &models.Data{UserID: userID, Name: "Fresh"}
+	if err := dataRepo.Create(fresh); err != nil {
+		t.Fatalf("Ошибка создания данных: %v", err)
+	}
+	if err := dataRepo.Delete(old.ID); err != nil {
+		t.Fatalf("Ошибка удаления данных: %v", err)
+	}
+
+	changed, err := dataRepo.GetChangedSince(userID, since)
+	if err != nil {
+		t.Fatalf("Ошибка получения изменений: %v", err)
+	}
+
+	if len(changed) != 2 {
+		t.Fatalf("Ожидалось 2 измененные записи, получено %d", len(changed))
+	}
+
+	for _, data := range changed {
+		if data.ID == old.ID && !data.DeletedAt.Valid {
+			t.Error("Удаленная запись должна возвращаться с DeletedAt")
+		}
+	}
+
+	// Удаленные записи не возвращаются обычными методами
+	userData, _ := dataRepo.GetByUserID(userID)
+	if len(userData) != 1 {
+		t.Errorf("Ожидалась 1 запись, получено %d", len(userData))
+	}
+}
+
+func TestDataRepository_GetChangedSince_VaultAndPurged(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	dataRepo := repo.NewDataRepository()
+	vaultRepo := repo.NewVaultRepository()
+	ownerID := uuid.New()
+	memberID := uuid.New()
+
+	vault := &models.Vault{Name: "Team"}
+	vaultRepo.Create(vault, &models.VaultMember{UserID: ownerID, Role: models.VaultRoleOwner})
+	vaultRepo.SaveMember(&models.VaultMember{VaultID: vault.ID, UserID: memberID, Role: models.VaultRoleViewer})
+
+	since := time.Now()
+	time.Sleep(time.Millisecond)
+
+	shared := &models.Data{UserID: ownerID, VaultID: &vault.ID, Name: "DB"}
+	personal := &models.Data{UserID: ownerID, Name: "Mail"}
+	dataRepo.Create(shared)
+	dataRepo.Create(personal)
+
+	changed, err := dataRepo.GetChangedSince(memberID, since)
+	if err != nil {
+		t.Fatalf("Ошибка получения изменений: %v", err)
+	}
+	if len(changed) != 1 || changed[0].ID != shared.ID {
+		t.Fatalf("Участнику должна вернуться только запись хранилища, получено %v", changed)
+	}
+
+	dataRepo.Delete(shared.ID)
+	if err := dataRepo.Purge(shared.ID); err != nil {
+		t.Fatalf("Ошибка окончательного удаления: %v", err)
+	}
+	dataRepo.Delete(personal.ID)
+	if _, err := dataRepo.PurgeDeletedBefore(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("Ошибка очистки корзины: %v", err)
+	}
+
+	// Окончательно удаленные записи видны в изменениях только как отметки об удалении
+	if changed, _ := dataRepo.GetChangedSince(ownerID, since); len(changed) != 0 {
+		t.Errorf("Ожидалось 0 записей, получено %d", len(changed))
+	}
+	purged, err := dataRepo.GetPurgedSince(ownerID, since)
+	if err != nil {
+		t.Fatalf("Ошибка получения удаленных записей: %v", err)
+	}
+	if len(purged) != 2 {
+		t.Errorf("Ожидалось 2 отметки об удалении, получено %d", len(purged))
+	}
+	purged, _ = dataRepo.GetPurgedSince(memberID, since)
+	if len(purged) != 1 || purged[0].DataID != shared.ID {
+		t.Errorf("Участнику должна вернуться только отметка записи хранилища, получено %v", purged)
+	}
+	if purged, _ := dataRepo.GetPurgedSince(memberID, time.Now()); len(purged) != 0 {
+		t.Errorf("Ожидалось 0 отметок после последней синхронизации, получено %d", len(purged))
+	}
+}
+
+func TestDataRepository_UpdateWithVersion(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	dataRepo := repo.NewDataRepository()
+	data := &models.Data{UserID: uuid.New(), Name: "Original"}
+	if err := dataRepo.Create(data); err != nil {
+		t.Fatalf("Ошибка создания данных: %v", err)
+	}
+
+	if data.Version != 1 {
+		t.Fatalf("Ожидалась версия 1, получена %d", data.Version)
+	}
+
+	first, _ := dataRepo.GetByID(data.ID)
+	second, _ := dataRepo.GetByID(data.ID)
+
+	first.Name = "First"
+	if err := dataRepo.UpdateWithVersion(first, 1); err != nil {
+		t.Fatalf("Ошибка обновления данных: %v", err)
+	}
+
+	if first.Version != 2 {
+		t.Errorf("Ожидалась версия 2, получена %d", first.Version)
+	}
+
+	second.Name = "Second"
+	if err := dataRepo.UpdateWithVersion(second, 1); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Ожидалась ошибка ErrVersionConflict, получено %v", err)
+	}
+
+	stored, _ := dataRepo.GetByID(data.ID)
+	if stored.Name != "First" {
+		t.Errorf("Ожидалось название First, получено %s", stored.Name)
+	}
+}
+
+func TestDataRepository_Restore(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	dataRepo := repo.NewDataRepository()
+	data := &models.Data{UserID: uuid.New(), Name: "Deleted"}
+	dataRepo.Create(data)
+
+	if err := dataRepo.Restore(data.ID); err == nil {
+		t.Error("Ожидалась ошибка при восстановлении неудаленных данных")
+	}
+
+	dataRepo.Delete(data.ID)
+	if err := dataRepo.Restore(data.ID); err != nil {
+		t.Fatalf("Ошибка восстановления данных: %v", err)
+	}
+
+	if _, err := dataRepo.GetByID(data.ID); err != nil {
+		t.Errorf("Восстановленные данные должны быть доступны: %v", err)
+	}
+}
+
+func TestRevisionRepository_GetByDataID(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	revisionRepo := repo.NewRevisionRepository()
+	data := &models.Data{ID: uuid.New(), UserID: uuid.New(), Name: "Mail", Version: 1}
+
+	revisionRepo.Create(models.NewRevision(data, models.RevisionCreate, "laptop"))
+	data.Version = 2
+	revisionRepo.Create(models.NewRevision(data, models.RevisionUpdate, "phone"))
+	revisionRepo.Create(models.NewRevision(&models.Data{ID: uuid.New(), UserID: data.UserID}, models.RevisionCreate, ""))
+
+	revisions, err := revisionRepo.GetByDataID(data.ID)
+	if err != nil {
+		t.Fatalf("Ошибка получения ревизий: %v", err)
+	}
+
+	if len(revisions) != 2 {
+		t.Fatalf("Ожидалось 2 ревизии, получено %d", len(revisions))
+	}
+
+	if revisions[0].Version != 2 || revisions[0].ClientID != "phone" {
+		t.Errorf("Ожидалась последняя ревизия первой, получено %+v", revisions[0])
+	}
+
+	revision, err := revisionRepo.GetByID(revisions[1].ID)
+	if err != nil || revision.Action != models.RevisionCreate {
+		t.Errorf("Ожидалась ревизия создания, получено %+v, %v", revision, err)
+	}
+}
+
+func TestDataRepository_Trash(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	dataRepo := repo.NewDataRepository()
+	revisionRepo := repo.NewRevisionRepository()
+	userID := uuid.New()
+
+	data := &models.Data{UserID: userID, Name: "Deleted"}
+	dataRepo.Create(data)
+	revisionRepo.Create(models.NewRevision(data, models.RevisionCreate, ""))
+	dataRepo.Create(&models.Data{UserID: userID, Name: "Active"})
+
+	if err := dataRepo.Purge(data.ID); err == nil {
+		t.Error("Ожидалась ошибка при окончательном удалении неудаленных данных")
+	}
+
+	dataRepo.Delete(data.ID)
+
+	deleted, err := dataRepo.GetDeletedByUserID(userID)
+	if err != nil {
+		t.Fatalf("Ошибка получения корзины: %v", err)
+	}
+
+	if len(deleted) != 1 || deleted[0].ID != data.ID {
+		t.Fatalf("Ожидалась одна удаленная запись, получено %+v", deleted)
+	}
+
+	if err := dataRepo.Purge(data.ID); err != nil {
+		t.Fatalf("Ошибка окончательного удаления: %v", err)
+	}
+
+	if _, err := dataRepo.GetDeletedByID(data.ID); err == nil {
+		t.Error("Запись должна быть удалена окончательно")
+	}
+
+	if revisions, _ := revisionRepo.GetByDataID(data.ID); len(revisions) != 0 {
+		t.Errorf("История изменений должна быть удалена, получено %d ревизий", len(revisions))
+	}
+}
+
+func TestDataRepository_PurgeDeletedBefore(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	dataRepo := repo.NewDataRepository()
+	data := &models.Data{UserID: uuid.New(), Name: "Deleted"}
+	dataRepo.Create(data)
+	dataRepo.Delete(data.ID)
+
+	if purged, _ := dataRepo.PurgeDeletedBefore(time.Now().Add(-time.Hour)); purged != 0 {
+		t.Errorf("Ожидалось 0 удаленных записей, получено %d", purged)
+	}
+
+	if purged, _ := dataRepo.PurgeDeletedBefore(time.Now().Add(time.Second)); purged != 1 {
+		t.Errorf("Ожидалась 1 удаленная запись, получено %d", purged)
+	}
+}
+
+func TestAttachmentRepository_Chunks(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	dataRepo := repo.NewDataRepository()
+	attachmentRepo := repo.NewAttachmentRepository()
+
+	data := &models.Data{UserID: uuid.New(), Type: models.DataTypeFile, Name: "File"}
+	dataRepo.Create(data)
+
+	attachment := &models.Attachment{DataID: data.ID, UserID: data.UserID, Size: 10, ChunkSize: 4}
+	if err := attachmentRepo.Create(attachment); err != nil {
+		t.Fatalf("Ошибка создания файла: %v", err)
+	}
+
+	// Повторная загрузка фрагмента заменяет прежние сведения о нем
+	attachmentRepo.SaveChunk(&models.AttachmentChunk{DataID: data.ID, Index: 1, Size: 4})
+	attachmentRepo.SaveChunk(&models.AttachmentChunk{DataID: data.ID, Index: 0, Size: 4})
+	attachmentRepo.SaveChunk(&models.AttachmentChunk{DataID: data.ID, Index: 1, Size: 4})
+
+	chunks, err := attachmentRepo.GetChunks(data.ID)
+	if err != nil {
+		t.Fatalf("Ошибка получения фрагментов: %v", err)
+	}
+
+	if len(chunks) != 2 || chunks[0].Index != 0 || chunks[1].Index != 1 {
+		t.Errorf("Ожидались фрагменты 0 и 1, получено %+v", chunks)
+	}
+
+	if orphaned, _ := attachmentRepo.GetOrphaned(); len(orphaned) != 0 {
+		t.Errorf("Файл записи не должен считаться брошенным, получено %d", len(orphaned))
+	}
+
+	dataRepo.Delete(data.ID)
+	dataRepo.Purge(data.ID)
+
+	if orphaned, _ := attachmentRepo.GetOrphaned(); len(orphaned) != 1 {
+		t.Errorf("Ожидался 1 брошенный файл, получено %d", len(orphaned))
+	}
+}
+
+func TestSessionRepository_RotateAndRevoke(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	sessionRepo := repo.NewSessionRepository()
+	userID := uuid.New()
+	now := time.Now()
+
+	session := &models.Session{UserID: userID, DeviceName: "laptop", RefreshTokenHash: "first", LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}
+	if err := sessionRepo.Create(session); err != nil {
+		t.Fatalf("Ошибка создания сессии: %v", err)
+	}
+	sessionRepo.Create(&models.Session{UserID: userID, DeviceName: "phone", RefreshTokenHash: "other", LastSeenAt: now, ExpiresAt: now.Add(time.Hour)})
+
+	session.PreviousTokenHash = "first"
+	session.RefreshTokenHash = "second"
+	if err := sessionRepo.Rotate(session, "first"); err != nil {
+		t.Fatalf("Ошибка замены токена: %v", err)
+	}
+
+	// Замена по уже замененному токену не выполняется
+	if err := sessionRepo.Rotate(session, "first"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Ожидалась ошибка gorm.ErrRecordNotFound, получено %v", err)
+	}
+
+	for _, hash := range []string{"first", "second"} {
+		found, err := sessionRepo.GetByRefreshTokenHash(hash)
+		if err != nil || found.ID != session.ID {
+			t.Errorf("Сессия должна находиться по хешу %q: %v", hash, err)
+		}
+	}
+
+	if err := sessionRepo.Revoke(session.ID, now); err != nil {
+		t.Fatalf("Ошибка отзыва сессии: %v", err)
+	}
+
+	active, _ := sessionRepo.GetActiveByUserID(userID, now)
+	if len(active) != 1 || active[0].DeviceName != "phone" {
+		t.Errorf("Ожидалась одна действующая сессия phone, получено %+v", active)
+	}
+
+	if revoked, _ := sessionRepo.RevokeByUserID(userID, now); revoked != 1 {
+		t.Errorf("Ожидалась 1 отозванная сессия, получено %d", revoked)
+	}
+}
+
+func TestShareRepository_SaveAndExpire(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	dataRepo := repo.NewDataRepository()
+	shareRepo := repo.NewShareRepository()
+	ownerID, granteeID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	data := &models.Data{UserID: ownerID, Name: "Shared"}
+	dataRepo.Create(data)
+
+	share := &models.Share{DataID: data.ID, OwnerID: ownerID, GranteeID: granteeID, Permission: models.SharePermissionRead}
+	if err := shareRepo.Save(share); err != nil {
+		t.Fatalf("Ошибка выдачи доступа: %v", err)
+	}
+
+	// Повторная выдача заменяет права и срок существующего доступа
+	expiresAt := now.Add(time.Hour)
+	again := &models.Share{DataID: data.ID, OwnerID: ownerID, GranteeID: granteeID, Permission: models.SharePermissionWrite, ExpiresAt: &expiresAt}
+	if err := shareRepo.Save(again); err != nil {
+		t.Fatalf("Ошибка повторной выдачи доступа: %v", err)
+	}
+	if again.ID != share.ID {
+		t.Errorf("Повторная выдача должна сохранять ID доступа %s, получено %s", share.ID, again.ID)
+	}
+
+	found, err := shareRepo.GetActive(data.ID, granteeID, now)
+	if err != nil || !found.CanWrite() {
+		t.Fatalf("Ожидался действующий доступ на запись, получено %+v: %v", found, err)
+	}
+
+	if _, err := shareRepo.GetActive(data.ID, granteeID, expiresAt.Add(time.Second)); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Истекший доступ не должен действовать, получено %v", err)
+	}
+	if active, _ := shareRepo.GetActiveByGranteeID(granteeID, expiresAt.Add(time.Second)); len(active) != 0 {
+		t.Errorf("Истекший доступ не должен возвращаться, получено %d", len(active))
+	}
+
+	// Доступы удаляются вместе с записью
+	dataRepo.Delete(data.ID)
+	dataRepo.Purge(data.ID)
+	if shares, _ := shareRepo.GetByDataID(data.ID); len(shares) != 0 {
+		t.Errorf("Доступы удаленной записи должны удаляться, получено %d", len(shares))
+	}
+	if err := shareRepo.Delete(share.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Ожидалась ошибка gorm.ErrRecordNotFound, получено %v", err)
+	}
+}
+
+func TestAuditRepository_GetByActorID(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	auditRepo := repo.NewAuditRepository()
+	actorID, otherID := uuid.New(), uuid.New()
+	start := time.Now()
+
+	actions := []models.AuditAction{models.AuditLogin, models.AuditCreate, models.AuditRead}
+	for i, action := range actions {
+		event := &models.AuditEvent{Seq: int64(i + 1), ActorID: &actorID, Action: action, CreatedAt: start.Add(time.Duration(i) * time.Second)}
+		if err := auditRepo.Create(event); err != nil {
+			t.Fatalf("Ошибка записи события: %v", err)
+		}
+	}
+	auditRepo.Create(&models.AuditEvent{Seq: 4, ActorID: &otherID, Action: models.AuditLogin})
+	auditRepo.Create(&models.AuditEvent{Seq: 5, Username: "ghost", Action: models.AuditLoginFailed})
+
+	if err := auditRepo.Create(&models.AuditEvent{Seq: 5, Action: models.AuditLogin}); err == nil {
+		t.Error("Номер события в цепочке должен быть уникальным")
+	}
+	if last, err := auditRepo.GetLast(); err != nil || last.Seq != 5 {
+		t.Errorf("Ожидалось последнее событие 5, получено %+v, %v", last, err)
+	}
+	if after, _ := auditRepo.GetAfter(3, 10); len(after) != 2 || after[0].Seq != 4 {
+		t.Errorf("Ожидались события 4 и 5, получено %+v", after)
+	}
+
+	events, total, err := auditRepo.GetByActorID(actorID, 2, 0)
+	if err != nil {
+		t.Fatalf("Ошибка получения событий: %v", err)
+	}
+	if total != 3 || len(events) != 2 {
+		t.Fatalf("Ожидалось 3 события и страница из 2, получено %d и %d", total, len(events))
+	}
+	if events[0].Action != models.AuditRead || events[1].Action != models.AuditCreate {
+		t.Errorf("События должны идти от новых к старым, получены %s и %s", events[0].Action, events[1].Action)
+	}
+
+	events, _, _ = auditRepo.GetByActorID(actorID, 2, 2)
+	if len(events) != 1 || events[0].Action != models.AuditLogin {
+		t.Errorf("Последняя страница должна содержать событие login, получено %+v", events)
+	}
+}
+
+func TestDataRepository_ListPages(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	dataRepo := repo.NewDataRepository()
+	userID := uuid.New()
+	var since time.Time
+	for i, name := range []string{"b", "a", "c", "a"} {
+		if i == 2 {
+			since = time.Now()
+			time.Sleep(time.Millisecond)
+		}
+		dataRepo.Create(&models.Data{UserID: userID, Name: name})
+	}
+	dataRepo.Create(&models.Data{UserID: uuid.New(), Name: "a"})
+
+	// Записи с одинаковым названием упорядочиваются по ID и не теряются между страницами
+	query := DataQuery{Sort: DataSortName, Limit: 2}
+	var names []string
+	for {
+		page, err := dataRepo.List(userID, query)
+		if err != nil {
+			t.Fatalf("Ошибка получения страницы: %v", err)
+		}
+		for _, data := range page {
+			names = append(names, data.Name)
+		}
+		if len(page) < query.Limit {
+			break
+		}
+		cursor := CursorFor(query.Sort, &page[len(page)-1])
+		query.After = &cursor
+	}
+	if len(names) != 4 || names[0] != "a" || names[1] != "a" || names[2] != "b" || names[3] != "c" {
+		t.Errorf("Ожидались записи a, a, b, c, получено %v", names)
+	}
+
+	recent, _ := dataRepo.List(userID, DataQuery{UpdatedSince: &since, Sort: DataSortUpdatedDesc})
+	if len(recent) != 2 || recent[0].Name != "a" || recent[1].Name != "c" {
+		t.Errorf("Ожидались две последние измененные записи, получено %+v", recent)
+	}
+}
+
+func TestDataRepository_Search(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	dataRepo := repo.NewDataRepository()
+	shareRepo := repo.NewShareRepository()
+	userID, ownerID := uuid.New(), uuid.New()
+
+	dataRepo.Create(&models.Data{UserID: userID, Name: "Work mail", Login: "alice"})
+	dataRepo.Create(&models.Data{UserID: userID, Name: "Bank", Login: "mail@example.com"})
+	dataRepo.Create(&models.Data{UserID: userID, Name: "Forum", Metadata: `{"url":"https://mail.example.com"}`})
+	dataRepo.Create(&models.Data{UserID: userID, Name: "Notes"})
+	shared := &models.Data{UserID: ownerID, Name: "Team mail"}
+	dataRepo.Create(shared)
+	dataRepo.Create(&models.Data{UserID: ownerID, Name: "Private mail"})
+	shareRepo.Save(&models.Share{DataID: shared.ID, OwnerID: ownerID, GranteeID: userID, Permission: models.SharePermissionRead})
+
+	// Совпадение в названии важнее совпадения в логине, а оно важнее метаданных
+	matches, err := dataRepo.Search(userID, "MAIL", 10)
+	if err != nil {
+		t.Fatalf("Ошибка поиска: %v", err)
+	}
+	var names []string
+	for _, match := range matches {
+		names = append(names, match.Data.Name)
+	}
+	want := []string{"Team mail", "Work mail", "Bank", "Forum"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Ожидалось %v, получено %v", want, names)
+	}
+
+	// Каждое слово запроса должно встретиться в записи
+	matches, _ = dataRepo.Search(userID, "work alice", 10)
+	if len(matches) != 1 || matches[0].Data.Name != "Work mail" {
+		t.Errorf("Ожидалась одна запись Work mail, получено %+v", matches)
+	}
+
+	matches, _ = dataRepo.Search(userID, "mail", 2)
+	if len(matches) != 2 {
+		t.Errorf("Ожидалось 2 записи по лимиту, получено %d", len(matches))
+	}
+
+	if matches, _ := dataRepo.Search(userID, " ?! ", 10); len(matches) != 0 {
+		t.Errorf("Запрос без слов не должен ничего находить, получено %d", len(matches))
+	}
+}
+
+func TestPrefixTSQuery(t *testing.T) {
+	tests := map[string]string{
+		"Mail":               "mail:*",
+		"work  mail.example": "work:* & mail:* & example:*",
+		"a&b | !c:*":         "a:* & b:* & c:*",
+		"  ":                 "",
+	}
+	for query, want := range tests {
+		if got := prefixTSQuery(query); got != want {
+			t.Errorf("prefixTSQuery(%q) = %q, ожидалось %q", query, got, want)
+		}
+	}
+}
+
+func TestTagRepository_DataTags(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	dataRepo := repo.NewDataRepository()
+	tagRepo := repo.NewTagRepository()
+	userID := uuid.New()
+
+	work := &models.Tag{UserID: userID, Name: "work"}
+	mail := &models.Tag{UserID: userID, Name: "mail"}
+	tagRepo.Create(work)
+	tagRepo.Create(mail)
+	if err := tagRepo.Create(&models.Tag{UserID: userID, Name: "work"}); err == nil {
+		t.Error("Метка с повторяющимся именем не должна создаваться")
+	}
+
+	first := &models.Data{UserID: userID, Name: "first"}
+	second := &models.Data{UserID: userID, Name: "second", Favorite: true}
+	dataRepo.Create(first)
+	dataRepo.Create(second)
+	tagRepo.SetDataTags(first.ID, []uuid.UUID{work.ID, mail.ID})
+	tagRepo.SetDataTags(second.ID, []uuid.UUID{work.ID})
+
+	names, err := tagRepo.GetNamesByDataIDs([]uuid.UUID{first.ID, second.ID})
+	if err != nil {
+		t.Fatalf("Ошибка получения меток: %v", err)
+	}
+	if !slices.Equal(names[first.ID], []string{"mail", "work"}) || !slices.Equal(names[second.ID], []string{"work"}) {
+		t.Errorf("Неожиданные метки записей: %v", names)
+	}
+
+	both, _ := dataRepo.List(userID, DataQuery{Tags: []string{"work", "mail"}})
+	if len(both) != 1 || both[0].ID != first.ID {
+		t.Errorf("Ожидалась одна запись с обеими метками, получено %+v", both)
+	}
+	favorites, _ := dataRepo.List(userID, DataQuery{Tags: []string{"work"}, Favorite: true})
+	if len(favorites) != 1 || favorites[0].ID != second.ID {
+		t.Errorf("Ожидалась одна избранная запись с меткой work, получено %+v", favorites)
+	}
+
+	// Удаление метки снимает ее с записей
+	if err := tagRepo.Delete(work.ID); err != nil {
+		t.Fatalf("Ошибка удаления метки: %v", err)
+	}
+	names, _ = tagRepo.GetNamesByDataIDs([]uuid.UUID{first.ID, second.ID})
+	if !slices.Equal(names[first.ID], []string{"mail"}) || len(names[second.ID]) != 0 {
+		t.Errorf("Удаленная метка должна быть снята с записей, получено %v", names)
+	}
+	if err := tagRepo.Delete(work.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Ожидалась ошибка gorm.ErrRecordNotFound, получено %v", err)
+	}
+}
+
+func TestDataRepository_Transaction(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	dataRepo := repo.NewDataRepository()
+	existing := &models.Data{UserID: uuid.New(), Name: "Existing"}
+	dataRepo.Create(existing)
+
+	errAbort := errors.New("abort")
+	var created uuid.UUID
+	err := dataRepo.Transaction(func(uow *UnitOfWork) error {
+		data := &models.Data{UserID: existing.UserID, Name: "Created"}
+		if err := uow.Data.Create(data); err != nil {
+			return err
+		}
+		created = data.ID
+		uow.Revisions.Create(models.NewRevision(data, models.RevisionCreate, "laptop"))
+		uow.Attachments.Create(&models.Attachment{DataID: data.ID, UserID: data.UserID, Size: 10, ChunkSize: 1024})
+		tag := &models.Tag{UserID: existing.UserID, Name: "work"}
+		uow.Tags.Create(tag)
+		uow.Tags.SetDataTags(existing.ID, []uuid.UUID{tag.ID})
+		if err := uow.Data.Delete(existing.ID); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Ожидалась ошибка транзакции, получено %v", err)
+	}
+
+	// Откат возвращает записи, ревизии, метки и описания файлов к состоянию до транзакции
+	if _, err := dataRepo.GetByID(created); err == nil {
+		t.Error("Запись, созданная в отмененной транзакции, не должна сохраняться")
+	}
+	if _, err := dataRepo.GetByID(existing.ID); err != nil {
+		t.Errorf("Удаление в отмененной транзакции не должно применяться: %v", err)
+	}
+	if revisions, _ := repo.NewRevisionRepository().GetByDataID(created); len(revisions) != 0 {
+		t.Errorf("Ревизии отмененной транзакции не должны сохраняться: %+v", revisions)
+	}
+	if tags, _ := repo.NewTagRepository().GetByUserID(existing.UserID); len(tags) != 0 {
+		t.Errorf("Метки отмененной транзакции не должны сохраняться: %+v", tags)
+	}
+	if _, err := repo.NewAttachmentRepository().GetByDataID(created); err == nil {
+		t.Error("Описание файла отмененной транзакции не должно сохраняться")
+	}
+
+	err = dataRepo.Transaction(func(uow *UnitOfWork) error {
+		return uow.Data.Create(&models.Data{ID: created, UserID: existing.UserID, Name: "Created"})
+	})
+	if err != nil {
+		t.Fatalf("Ошибка транзакции: %v", err)
+	}
+	if _, err := dataRepo.GetByID(created); err != nil {
+		t.Errorf("Запись зафиксированной транзакции должна сохраняться: %v", err)
+	}
+}
+
+func TestDataRepository_TransactionKeepsConcurrentWrites(t *testing.T) {
+	repo := setupTestDB(t)
+	defer cleanupTestDB(t, repo)
+
+	dataRepo := repo.NewDataRepository()
+	userID := uuid.New()
+	touched := &models.Data{UserID: userID, Name: "Touched"}
+	dataRepo.Create(touched)
+	other := &models.Data{UserID: userID, Name: "Other"}
+	dataRepo.Create(other)
+
+	errAbort := errors.New("abort")
+	var outside *models.Data
+	err := dataRepo.Transaction(func(uow *UnitOfWork) error {
+		update := *touched
+		update.Name = "Changed"
+		if err := uow.Data.UpdateWithVersion(&update, touched.Version); err != nil {
+			return err
+		}
+
+		// Изменения вне транзакции, сделанные до ее отмены, должны сохраниться
+		outside = &models.Data{UserID: userID, Name: "Outside"}
+		if err := dataRepo.Create(outside); err != nil {
+			return err
+		}
+		renamed := *other
+		renamed.Name = "Renamed"
+		if err := dataRepo.Update(&renamed); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Ожидалась ошибка транзакции, получено %v", err)
+	}
+
+	if data, err := dataRepo.GetByID(touched.ID); err != nil || data.Name != "Touched" || data.Version != touched.Version {
+		t.Errorf("Изменение отмененной транзакции должно откатиться, получено %+v, %v", data, err)
+	}
+	if _, err := dataRepo.GetByID(outside.ID); err != nil {
+		t.Errorf("Запись, созданная вне транзакции, должна сохраниться: %v", err)
+	}
+	if data, err := dataRepo.GetByID(other.ID); err != nil || data.Name != "Renamed" {
+		t.Errorf("Изменение вне транзакции должно сохраниться, получено %+v, %v", data, err)
+	}
+}
@@ -0,0 +1,104 @@
+// Package server содержит логику HTTP сервера GophKeeper.
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/blobstore"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/logger"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"go.uber.org/zap"
+)
+
+// defaultJanitorInterval используется, если период проверки корзины не задан.
+const defaultJanitorInterval = time.Hour
+
+// Janitor периодически окончательно удаляет записи, пролежавшие в корзине дольше срока хранения,
+// и фрагменты файлов, записи которых удалены окончательно.
+type Janitor struct {
+	dataRepo       repository.DataRepositoryInterface
+	attachmentRepo repository.AttachmentRepositoryInterface
+	blobs          blobstore.BlobStore
+	retention      time.Duration
+	interval       time.Duration
+}
+
+// NewJanitor создает очистку корзины. Нулевой срок хранения отключает
+// окончательное удаление записей из корзины.
+func NewJanitor(
+	dataRepo repository.DataRepositoryInterface,
+	attachmentRepo repository.AttachmentRepositoryInterface,
+	blobs blobstore.BlobStore,
+	retention, interval time.Duration,
+) *Janitor {
+	if interval <= 0 {
+		interval = defaultJanitorInterval
+	}
+	return &Janitor{
+		dataRepo:       dataRepo,
+		attachmentRepo: attachmentRepo,
+		blobs:          blobs,
+		retention:      retention,
+		interval:       interval,
+	}
+}
+
+// Run выполняет очистку сразу и затем с заданным периодом до отмены контекста.
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeExpired окончательно удаляет записи, удаленные раньше, чем срок хранения назад.
+func (j *Janitor) PurgeExpired() (int64, error) {
+	if j.retention <= 0 {
+		return 0, nil
+	}
+	return j.dataRepo.PurgeDeletedBefore(time.Now().Add(-j.retention))
+}
+
+// SweepOrphanedFiles удаляет фрагменты файлов, записи которых удалены окончательно.
+func (j *Janitor) SweepOrphanedFiles(ctx context.Context) (int, error) {
+	orphaned, err := j.attachmentRepo.GetOrphaned()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, attachment := range orphaned {
+		if err := j.blobs.DeletePrefix(ctx, attachment.DataID.String()); err != nil {
+			return 0, err
+		}
+		if err := j.attachmentRepo.Delete(attachment.DataID); err != nil {
+			return 0, err
+		}
+	}
+	return len(orphaned), nil
+}
+
+// purge выполняет очистку и записывает результат в лог.
+func (j *Janitor) purge(ctx context.Context) {
+	purged, err := j.PurgeExpired()
+	if err != nil {
+		logger.Logger.Error("Ошибка очистки корзины", zap.Error(err))
+	} else if purged > 0 {
+		logger.Logger.Info("Корзина очищена", zap.Int64("purged", purged))
+	}
+
+	swept, err := j.SweepOrphanedFiles(ctx)
+	if err != nil {
+		logger.Logger.Error("Ошибка удаления фрагментов файлов", zap.Error(err))
+	} else if swept > 0 {
+		logger.Logger.Info("Удалены фрагменты файлов", zap.Int("files", swept))
+	}
+}
@@ -0,0 +1,85 @@
+// Package server содержит тесты для очистки корзины.
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/blobstore"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/google/uuid"
+)
+
+func TestJanitor_PurgeExpired(t *testing.T) {
+	memRepo := repository.NewMemoryRepository()
+	dataRepo := memRepo.NewDataRepository()
+	userID := uuid.New()
+
+	kept := &models.Data{UserID: userID, Name: "Active"}
+	expired := &models.Data{UserID: userID, Name: "Expired"}
+	dataRepo.Create(kept)
+	dataRepo.Create(expired)
+	dataRepo.Delete(expired.ID)
+
+	// Пока срок хранения не истек, запись остается в корзине
+	if purged, err := NewJanitor(dataRepo, memRepo.NewAttachmentRepository(), nil, time.Hour, 0).PurgeExpired(); err != nil || purged != 0 {
+		t.Fatalf("Ожидалось 0 удаленных записей, получено %d, %v", purged, err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	purged, err := NewJanitor(dataRepo, memRepo.NewAttachmentRepository(), nil, time.Millisecond, 0).PurgeExpired()
+	if err != nil {
+		t.Fatalf("Ошибка очистки корзины: %v", err)
+	}
+
+	if purged != 1 {
+		t.Errorf("Ожидалась 1 удаленная запись, получено %d", purged)
+	}
+
+	if _, err := dataRepo.GetDeletedByID(expired.ID); err == nil {
+		t.Error("Просроченная запись должна быть удалена окончательно")
+	}
+
+	if _, err := dataRepo.GetByID(kept.ID); err != nil {
+		t.Errorf("Активная запись не должна быть удалена: %v", err)
+	}
+}
+
+func TestJanitor_SweepOrphanedFiles(t *testing.T) {
+	ctx := context.Background()
+	memRepo := repository.NewMemoryRepository()
+	dataRepo := memRepo.NewDataRepository()
+	attachmentRepo := memRepo.NewAttachmentRepository()
+	blobs, _ := blobstore.NewFilesystem(t.TempDir())
+
+	file := &models.Data{UserID: uuid.New(), Type: models.DataTypeFile, Name: "key.pem"}
+	dataRepo.Create(file)
+	attachmentRepo.Create(&models.Attachment{DataID: file.ID, UserID: file.UserID, Size: 1, ChunkSize: 1024})
+	blobs.Put(ctx, file.ID.String()+"/0", strings.NewReader("x"))
+
+	janitor := NewJanitor(dataRepo, attachmentRepo, blobs, time.Hour, 0)
+
+	// Файл в корзине еще можно восстановить, поэтому его фрагменты сохраняются
+	dataRepo.Delete(file.ID)
+	if swept, _ := janitor.SweepOrphanedFiles(ctx); swept != 0 {
+		t.Fatalf("Ожидалось 0 удаленных файлов, получено %d", swept)
+	}
+
+	dataRepo.Purge(file.ID)
+	swept, err := janitor.SweepOrphanedFiles(ctx)
+	if err != nil || swept != 1 {
+		t.Fatalf("Ожидался 1 удаленный файл, получено %d, %v", swept, err)
+	}
+
+	if _, err := blobs.Get(ctx, file.ID.String()+"/0"); !errors.Is(err, blobstore.ErrNotFound) {
+		t.Errorf("Фрагменты файла должны быть удалены, получено %v", err)
+	}
+
+	if _, err := attachmentRepo.GetByDataID(file.ID); err == nil {
+		t.Error("Описание файла должно быть удалено")
+	}
+}
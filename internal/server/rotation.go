@@ -0,0 +1,355 @@
+// Package server содержит логику HTTP сервера GophKeeper.
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/datakeys"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/logger"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DefaultRotationBatchSize используется, если размер пакета перешифрования не задан.
+const DefaultRotationBatchSize = 500
+
+// RotationStats содержит итоги перешифрования.
+type RotationStats struct {
+	Data      int // Перешифровано записей
+	Revisions int // Перешифровано ревизий
+	Users     int // Перешифровано ключей данных и секретов одноразовых паролей пользователей
+	Vaults    int // Перешифровано ключей данных хранилищ
+	// Checkpoints содержит число контрольных точек журнала аудита, подписанных заново.
+	Checkpoints int
+	// Changed содержит число значений, измененных параллельными запросами во время перешифрования.
+	// Их перешифровывает повторный запуск, если они по-прежнему этого требуют.
+	Changed int
+}
+
+// KeyRotator перешифровывает ключи данных и секреты одноразовых паролей пользователей
+// и ключи данных хранилищ текущим ключом сервера, а записи и ревизии, зашифрованные ключом
+// сервера, в старом формате без привязки к записи или, для записей хранилищ, ключом создателя,
+// переводит на ключи данных их владельцев и хранилищ. Значения обрабатываются пакетами по возрастанию ID
+// и заменяются, только если не изменились после чтения, поэтому перешифрование
+// можно выполнять при работающем сервере.
+// Уже перешифрованные значения пропускаются, и прерванный запуск продолжается повторным.
+type KeyRotator struct {
+	dataRepo     repository.DataRepositoryInterface
+	revisionRepo repository.RevisionRepositoryInterface
+	userRepo     repository.UserRepositoryInterface
+	vaultRepo    repository.VaultRepositoryInterface
+	auditRepo    repository.AuditRepositoryInterface
+	keys         crypto.KeyProvider
+	dataKeys     *datakeys.Service
+	batchSize    int
+}
+
+// NewKeyRotator создает перешифрование данных текущим ключом keys.
+func NewKeyRotator(
+	dataRepo repository.DataRepositoryInterface,
+	revisionRepo repository.RevisionRepositoryInterface,
+	userRepo repository.UserRepositoryInterface,
+	vaultRepo repository.VaultRepositoryInterface,
+	auditRepo repository.AuditRepositoryInterface,
+	keys crypto.KeyProvider,
+	batchSize int,
+) *KeyRotator {
+	if batchSize <= 0 {
+		batchSize = DefaultRotationBatchSize
+	}
+	return &KeyRotator{
+		dataRepo:     dataRepo,
+		revisionRepo: revisionRepo,
+		userRepo:     userRepo,
+		vaultRepo:    vaultRepo,
+		auditRepo:    auditRepo,
+		keys:         keys,
+		dataKeys:     datakeys.NewService(userRepo, vaultRepo, keys),
+		batchSize:    batchSize,
+	}
+}
+
+// Run перешифровывает ключи пользователей и хранилищ, записи и их ревизии и подписывает заново
+// контрольные точки журнала аудита.
+// При отмене контекста возвращает итоги уже обработанных пакетов и ошибку контекста.
+func (r *KeyRotator) Run(ctx context.Context) (RotationStats, error) {
+	var stats RotationStats
+	if err := r.rotateUsers(ctx, &stats); err != nil {
+		return stats, err
+	}
+	if err := r.rotateVaults(ctx, &stats); err != nil {
+		return stats, err
+	}
+	if err := r.rotateCheckpoints(ctx, &stats); err != nil {
+		return stats, err
+	}
+	if err := r.rotateData(ctx, &stats); err != nil {
+		return stats, err
+	}
+	err := r.rotateRevisions(ctx, &stats)
+	return stats, err
+}
+
+// rotateData перешифровывает записи, включая удаленные в корзину.
+// Содержимое, зашифрованное на клиенте, сервер не шифровал и не трогает.
+func (r *KeyRotator) rotateData(ctx context.Context, stats *RotationStats) error {
+	after := uuid.Nil
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batch, err := r.dataRepo.GetBatchAfter(after, r.batchSize)
+		if err != nil || len(batch) == 0 {
+			return err
+		}
+
+		owners := make(map[recordOwner]*datakeys.Keys)
+		for _, data := range batch {
+			after = data.ID
+			password, payload, changed, err := rotateFields(r.ownerKeys(owners, data.UserID, data.VaultID), data.ID, data.Password, data.Payload, data.ClientEncrypted)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				continue
+			}
+
+			err = r.dataRepo.ReplaceCiphertext(data.ID, data.Password, data.Payload, password, payload)
+			if errors.Is(err, repository.ErrCiphertextChanged) {
+				stats.Changed++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			stats.Data++
+		}
+		logger.Logger.Info("Записи перешифрованы", zap.Int("rotated", stats.Data), zap.String("after", after.String()))
+	}
+}
+
+// rotateRevisions перешифровывает ревизии записей.
+func (r *KeyRotator) rotateRevisions(ctx context.Context, stats *RotationStats) error {
+	after := uuid.Nil
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batch, err := r.revisionRepo.GetBatchAfter(after, r.batchSize)
+		if err != nil || len(batch) == 0 {
+			return err
+		}
+
+		owners := make(map[recordOwner]*datakeys.Keys)
+		for _, revision := range batch {
+			after = revision.ID
+			password, payload, changed, err := rotateFields(r.ownerKeys(owners, revision.UserID, revision.VaultID), revision.DataID, revision.Password, revision.Payload, revision.ClientEncrypted)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				continue
+			}
+
+			err = r.revisionRepo.ReplaceCiphertext(revision.ID, revision.Password, revision.Payload, password, payload)
+			if errors.Is(err, repository.ErrCiphertextChanged) {
+				stats.Changed++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			stats.Revisions++
+		}
+		logger.Logger.Info("Ревизии перешифрованы", zap.Int("rotated", stats.Revisions), zap.String("after", after.String()))
+	}
+}
+
+// rotateUsers перешифровывает ключи данных и секреты одноразовых паролей пользователей.
+func (r *KeyRotator) rotateUsers(ctx context.Context, stats *RotationStats) error {
+	after := uuid.Nil
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batch, err := r.userRepo.GetBatchAfter(after, r.batchSize)
+		if err != nil || len(batch) == 0 {
+			return err
+		}
+
+		for _, user := range batch {
+			after = user.ID
+			dataKeyRotated, err := r.rotateUserValue(user.DataKey, crypto.DataKeyAAD(user.ID.String()), stats, func(rotated string) error {
+				return r.userRepo.ReplaceDataKey(user.ID, user.DataKey, rotated)
+			})
+			if err != nil {
+				return err
+			}
+			secretRotated, err := r.rotateUserValue(user.TOTPSecret, crypto.TOTPSecretAAD(user.ID.String()), stats, func(rotated string) error {
+				return r.userRepo.ReplaceTOTPSecret(user.ID, user.TOTPSecret, rotated)
+			})
+			if err != nil {
+				return err
+			}
+			if dataKeyRotated || secretRotated {
+				stats.Users++
+			}
+		}
+		logger.Logger.Info("Ключи пользователей перешифрованы", zap.Int("rotated", stats.Users), zap.String("after", after.String()))
+	}
+}
+
+// rotateVaults перешифровывает ключи данных хранилищ.
+func (r *KeyRotator) rotateVaults(ctx context.Context, stats *RotationStats) error {
+	after := uuid.Nil
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batch, err := r.vaultRepo.GetBatchAfter(after, r.batchSize)
+		if err != nil || len(batch) == 0 {
+			return err
+		}
+
+		for _, vault := range batch {
+			after = vault.ID
+			rotated, err := r.rotateUserValue(vault.DataKey, crypto.VaultKeyAAD(vault.ID.String()), stats, func(rotated string) error {
+				return r.vaultRepo.ReplaceDataKey(vault.ID, vault.DataKey, rotated)
+			})
+			if err != nil {
+				return err
+			}
+			if rotated {
+				stats.Vaults++
+			}
+		}
+		logger.Logger.Info("Ключи хранилищ перешифрованы", zap.Int("rotated", stats.Vaults), zap.String("after", after.String()))
+	}
+}
+
+// rotateCheckpoints подписывает текущим ключом контрольные точки журнала аудита,
+// подписанные прежними ключами, чтобы журнал можно было проверить после их удаления.
+// Подпись заменяется, только если прежняя верна.
+func (r *KeyRotator) rotateCheckpoints(ctx context.Context, stats *RotationStats) error {
+	checkpoints, err := r.auditRepo.GetCheckpoints()
+	if err != nil {
+		return err
+	}
+
+	for _, checkpoint := range checkpoints {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !crypto.NeedsRewrap(r.keys, checkpoint.Signature) {
+			continue
+		}
+
+		signature, err := crypto.Rewrap(r.keys, checkpoint.Signature, crypto.AuditCheckpointAAD(checkpoint.Seq))
+		if err != nil {
+			return err
+		}
+
+		err = r.auditRepo.ReplaceCheckpointSignature(checkpoint.ID, checkpoint.Signature, signature)
+		if errors.Is(err, repository.ErrCiphertextChanged) {
+			stats.Changed++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		stats.Checkpoints++
+	}
+	if stats.Checkpoints > 0 {
+		logger.Logger.Info("Контрольные точки журнала аудита подписаны заново", zap.Int("rotated", stats.Checkpoints))
+	}
+	return nil
+}
+
+// rotateUserValue перешифровывает значение из записи пользователя или хранилища текущим ключом,
+// привязывая его к aad, и сохраняет его через replace. Возвращает true, если значение заменено.
+func (r *KeyRotator) rotateUserValue(value string, aad []byte, stats *RotationStats, replace func(string) error) (bool, error) {
+	if !crypto.NeedsRewrap(r.keys, value) {
+		return false, nil
+	}
+
+	rotated, err := crypto.Rewrap(r.keys, value, aad)
+	if err != nil {
+		return false, err
+	}
+
+	err = replace(rotated)
+	if errors.Is(err, repository.ErrCiphertextChanged) {
+		stats.Changed++
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// recordOwner определяет ключи записи: создателя и хранилище, пустое для личных записей.
+// Записи хранилища, созданные до ключей хранилищ, расшифровываются ключом создателя,
+// поэтому шифрование хранилища не делится между записями разных создателей.
+type recordOwner struct {
+	userID  uuid.UUID
+	vaultID uuid.UUID
+}
+
+// ownerKeys возвращает шифрование данных пользователя userID или, для записей хранилища,
+// хранилища vaultID, общее для записей одного пакета.
+func (r *KeyRotator) ownerKeys(owners map[recordOwner]*datakeys.Keys, userID uuid.UUID, vaultID *uuid.UUID) *datakeys.Keys {
+	owner := recordOwner{userID: userID}
+	if vaultID != nil {
+		owner.vaultID = *vaultID
+	}
+
+	keys, ok := owners[owner]
+	if !ok {
+		if vaultID != nil {
+			keys = r.dataKeys.ForVault(*vaultID, userID)
+		} else {
+			keys = r.dataKeys.ForUser(userID)
+		}
+		owners[owner] = keys
+	}
+	return keys
+}
+
+// rotateFields перешифровывает ключом данных владельца или хранилища пароль и содержимое
+// записи recordID или ее ревизии, зашифрованные ключом сервера, без привязки к записи
+// или ключом создателя записи хранилища.
+// changed сообщает, что хотя бы одно из значений перешифровано.
+func rotateFields(owner *datakeys.Keys, recordID uuid.UUID, password, payload string, clientEncrypted bool) (string, string, bool, error) {
+	rotatePassword := password != "" && !owner.Current(password)
+	rotatePayload := !clientEncrypted && payload != "" && !owner.Current(payload)
+	if !rotatePassword && !rotatePayload {
+		return password, payload, false, nil
+	}
+
+	var err error
+	if rotatePassword {
+		if password, err = reencrypt(owner, recordID, password); err != nil {
+			return "", "", false, err
+		}
+	}
+	if rotatePayload {
+		if payload, err = reencrypt(owner, recordID, payload); err != nil {
+			return "", "", false, err
+		}
+	}
+	return password, payload, true, nil
+}
+
+// reencrypt расшифровывает значение записи recordID и шифрует его ключом данных владельца или хранилища.
+func reencrypt(owner *datakeys.Keys, recordID uuid.UUID, ciphertext string) (string, error) {
+	plaintext, err := owner.Decrypt(recordID, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return owner.Encrypt(recordID, plaintext)
+}
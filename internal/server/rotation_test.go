@@ -0,0 +1,136 @@
+// Package server содержит тесты для перешифрования данных.
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/AlexeySalamakhin/GophKeeper/internal/audit"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/datakeys"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/logger"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
+	"github.com/google/uuid"
+)
+
+func TestKeyRotator_Run(t *testing.T) {
+	if err := logger.InitDevelopment(); err != nil {
+		t.Fatalf("Ошибка инициализации logger: %v", err)
+	}
+
+	memRepo := repository.NewMemoryRepository()
+	dataRepo := memRepo.NewDataRepository()
+	revisionRepo := memRepo.NewRevisionRepository()
+	userRepo := memRepo.NewUserRepository()
+	vaultRepo := memRepo.NewVaultRepository()
+	auditRepo := memRepo.NewAuditRepository()
+
+	oldKeys, _ := crypto.NewKeyring(crypto.DefaultKeyID, map[string]string{crypto.DefaultKeyID: "old-key"})
+	newKeys, _ := crypto.NewKeyring("2025", map[string]string{crypto.DefaultKeyID: "old-key", "2025": "new-key"})
+
+	// Контрольная точка журнала аудита подписана старым ключом
+	audit.NewRecorder(auditRepo, oldKeys, 1).Record(&models.AuditEvent{Action: models.AuditLogin})
+
+	// Значения старых форматов: без идентификатора ключа и с ним, но без привязки к записи
+	oldKey := crypto.KeyFromSecret("old-key")
+	legacyPassword, _ := crypto.SealBlob([]byte("legacy"), oldKey, nil)
+	notePayload, _ := crypto.SealBlob([]byte("note"), oldKey, nil)
+	totpSecret, _ := crypto.SealBlob([]byte("JBSWY3DPEHPK3PXP"), oldKey, nil)
+
+	user := &models.User{Username: "testuser", Email: "test@example.com", TOTPSecret: "v1:default:" + totpSecret}
+	userRepo.Create(user)
+
+	// Ключ данных второго пользователя зашифрован старым ключом сервера
+	dataKey, _ := crypto.NewDataKey()
+	other := &models.User{ID: uuid.New(), Username: "other", Email: "other@example.com"}
+	other.DataKey, _ = crypto.WrapDataKey(oldKeys, other.ID.String(), dataKey)
+	userRepo.Create(other)
+	otherPayload, _ := crypto.SealBlob([]byte("other note"), dataKey, nil)
+
+	// Ключ хранилища зашифрован старым ключом сервера, а запись хранилища - ключом создателя
+	vaultKey, _ := crypto.NewDataKey()
+	vault := &models.Vault{ID: uuid.New(), Name: "Team"}
+	vault.DataKey, _ = crypto.WrapVaultKey(oldKeys, vault.ID.String(), vaultKey)
+	vaultRepo.Create(vault, &models.VaultMember{UserID: other.ID, Role: models.VaultRoleOwner})
+	vaultRecord := &models.Data{ID: uuid.New(), UserID: other.ID, VaultID: &vault.ID, Type: models.DataTypeText, Name: "Team note"}
+	vaultRecord.Payload, _ = crypto.EncryptWithDataKey("team note", dataKey, crypto.RecordAAD(other.ID.String(), vaultRecord.ID.String()))
+
+	var records []*models.Data
+	for i := 0; i < 5; i++ {
+		records = append(records, &models.Data{UserID: user.ID, Name: "Login", Password: legacyPassword})
+	}
+	records = append(records,
+		&models.Data{UserID: user.ID, Type: models.DataTypeText, Name: "Note", Payload: "v1:default:" + notePayload},
+		&models.Data{UserID: user.ID, Type: models.DataTypeText, Name: "Client", Payload: "client-blob", ClientEncrypted: true},
+		&models.Data{UserID: other.ID, Type: models.DataTypeText, Name: "Other", Payload: "dk1:" + otherPayload},
+		vaultRecord,
+	)
+	for _, data := range records {
+		dataRepo.Create(data)
+	}
+	dataRepo.Delete(records[0].ID)
+	revisionRepo.Create(models.NewRevision(records[5], models.RevisionCreate, ""))
+
+	stats, err := NewKeyRotator(dataRepo, revisionRepo, userRepo, vaultRepo, auditRepo, newKeys, 2).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Ошибка перешифрования: %v", err)
+	}
+	if stats.Data != 8 || stats.Revisions != 1 || stats.Users != 2 || stats.Vaults != 1 || stats.Checkpoints != 1 || stats.Changed != 0 {
+		t.Errorf("Неверные итоги перешифрования: %+v", stats)
+	}
+
+	// После перешифрования старый ключ больше не нужен
+	onlyNew, _ := crypto.NewKeyring("2025", map[string]string{"2025": "new-key"})
+	dataKeys := datakeys.NewService(userRepo, vaultRepo, onlyNew)
+	batch, _ := dataRepo.GetBatchAfter(uuid.Nil, 100)
+	for _, data := range batch {
+		if data.ClientEncrypted {
+			if data.Payload != "client-blob" {
+				t.Errorf("Содержимое, зашифрованное на клиенте, не должно меняться: %q", data.Payload)
+			}
+			continue
+		}
+		value := data.Password
+		if data.Type == models.DataTypeText {
+			value = data.Payload
+		}
+		if data.VaultID != nil {
+			if !crypto.IsVaultKeyCiphertext(value) {
+				t.Errorf("Запись %s должна быть зашифрована ключом хранилища", data.Name)
+			}
+		} else if !crypto.IsBoundDataKeyCiphertext(value) {
+			t.Errorf("Запись %s должна быть зашифрована ключом данных пользователя с привязкой к записи", data.Name)
+		}
+		if _, err := dataKeys.ForRecord(&data).Decrypt(data.ID, value); err != nil {
+			t.Errorf("Запись %s не перешифрована: %v", data.Name, err)
+		}
+	}
+
+	stored, _ := userRepo.GetByID(user.ID)
+	if secret, err := onlyNew.Unwrap(stored.TOTPSecret, crypto.TOTPSecretAAD(user.ID.String())); err != nil || string(secret) != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Секрет одноразовых паролей не перешифрован: %q, %v", secret, err)
+	}
+	if result, err := audit.Verify(context.Background(), auditRepo, onlyNew, 0); err != nil || result.Break != nil || result.Checkpoints != 1 {
+		t.Errorf("Контрольная точка журнала аудита не подписана заново: %+v, %v", result, err)
+	}
+
+	// Повторный запуск пропускает уже перешифрованные значения
+	stats, err = NewKeyRotator(dataRepo, revisionRepo, userRepo, vaultRepo, auditRepo, newKeys, 2).Run(context.Background())
+	if err != nil || stats != (RotationStats{}) {
+		t.Errorf("Повторный запуск не должен ничего менять, получено %+v, %v", stats, err)
+	}
+}
+
+func TestKeyRotator_Canceled(t *testing.T) {
+	memRepo := repository.NewMemoryRepository()
+	keys, _ := crypto.NewKeyring(crypto.DefaultKeyID, map[string]string{crypto.DefaultKeyID: "key"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rotator := NewKeyRotator(memRepo.NewDataRepository(), memRepo.NewRevisionRepository(), memRepo.NewUserRepository(), memRepo.NewVaultRepository(), memRepo.NewAuditRepository(), keys, 0)
+	if _, err := rotator.Run(ctx); err != context.Canceled {
+		t.Errorf("Ожидалась ошибка context.Canceled, получена %v", err)
+	}
+}
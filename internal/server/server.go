@@ -6,23 +6,36 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/AlexeySalamakhin/GophKeeper/internal/audit"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/blobstore"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/config"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/crypto"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/events"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/grpcserver"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/handlers"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/logger"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/middleware"
+	"github.com/AlexeySalamakhin/GophKeeper/internal/models"
 	"github.com/AlexeySalamakhin/GophKeeper/internal/repository"
 	"github.com/gin-gonic/gin"
 	_ "github.com/jackc/pgx"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
-// Server представляет HTTP сервер приложения.
+// Server представляет HTTP и gRPC серверы приложения.
 type Server struct {
 	httpServer *http.Server
+	grpcServer *grpc.Server
 	config     *config.Config
 	repo       *repository.Repository
+	blobs      blobstore.BlobStore
+	keys       crypto.KeyProvider
+	events     *events.Hub
+	relay      *events.PostgresRelay
 	router     *gin.Engine
 }
 
@@ -40,25 +53,86 @@ func New() *Server {
 		cfg.Database.SSLMode,
 	)
 
+	keys, err := cfg.Crypto.KeyProvider()
+	if err != nil {
+		panic("Ошибка инициализации ключей шифрования: " + err.Error())
+	}
+
 	repo := repository.New(databaseURL)
 
+	blobs, err := newBlobStore(cfg.Storage, repo)
+	if err != nil {
+		panic("Ошибка инициализации хранилища файлов: " + err.Error())
+	}
+
+	relay, err := newEventRelay(cfg.Events, repo)
+	if err != nil {
+		panic("Ошибка инициализации рассылки изменений: " + err.Error())
+	}
+
 	// Настройка Gin роутера
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 	router.Use(logger.GinLoggerMiddleware())
 	router.Use(logger.GinRecoveryMiddleware())
 
+	// События получают все, кому доступна запись: участники хранилища и получатели доступов
+	hub := events.NewHub()
+	hub.SetAudience(events.NewRecordAudience(repo.NewShareRepository(), repo.NewVaultRepository()))
+
 	return &Server{
 		config: cfg,
 		repo:   repo,
+		blobs:  blobs,
+		keys:   keys,
+		events: hub,
+		relay:  relay,
 		router: router,
 	}
 }
 
-// Run запускает HTTP сервер в горутине и возвращает канал ошибок.
+// newBlobStore создает хранилище фрагментов файлов согласно конфигурации.
+func newBlobStore(cfg config.StorageConfig, repo *repository.Repository) (blobstore.BlobStore, error) {
+	switch cfg.Backend {
+	case "filesystem":
+		return blobstore.NewFilesystem(cfg.Path)
+	case "postgres", "":
+		return repo.NewBlobStore()
+	default:
+		return nil, fmt.Errorf("неизвестное хранилище %q", cfg.Backend)
+	}
+}
+
+// newEventRelay создает шину событий согласно конфигурации.
+// Для рассылки в пределах одного экземпляра шина не нужна, и возвращается nil.
+func newEventRelay(cfg config.EventsConfig, repo *repository.Repository) (*events.PostgresRelay, error) {
+	switch cfg.Backend {
+	case "memory", "":
+		return nil, nil
+	case "postgres":
+		return repo.NewEventRelay(), nil
+	default:
+		return nil, fmt.Errorf("неизвестная рассылка изменений %q", cfg.Backend)
+	}
+}
+
+// Run запускает HTTP и gRPC серверы в горутинах и возвращает канал ошибок.
 func (s *Server) Run(ctx context.Context) <-chan error {
 	s.setupRoutes()
 
+	janitor := NewJanitor(
+		s.repo.NewDataRepository(),
+		s.repo.NewAttachmentRepository(),
+		s.blobs,
+		s.config.Trash.Retention,
+		s.config.Trash.JanitorInterval,
+	)
+	go janitor.Run(ctx)
+
+	if s.relay != nil {
+		go s.relay.Listen(ctx, s.events)
+	}
+
 	s.httpServer = &http.Server{
 		Addr:         fmt.Sprintf("%s:%s", s.config.Server.Host, s.config.Server.Port),
 		Handler:      s.router,
@@ -68,45 +142,154 @@ func (s *Server) Run(ctx context.Context) <-chan error {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	errChan := make(chan error, 1)
+	errChan := make(chan error, 2)
 
+	var wg sync.WaitGroup
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		logger.Logger.Info("Сервер запущен",
 			zap.String("address", s.httpServer.Addr),
 		)
 		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			errChan <- err
 		}
+	}()
+
+	if s.grpcServer != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.serveGRPC(); err != nil {
+				errChan <- err
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
 		close(errChan)
 	}()
 
 	return errChan
 }
 
+// serveGRPC принимает gRPC соединения на отдельном порту.
+func (s *Server) serveGRPC() error {
+	addr := fmt.Sprintf("%s:%s", s.config.Server.Host, s.config.Server.GRPCPort)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.Info("gRPC сервер запущен",
+		zap.String("address", addr),
+	)
+	return s.grpcServer.Serve(listener)
+}
+
 // Shutdown выполняет graceful shutdown сервера.
 func (s *Server) Shutdown(ctx context.Context) error {
 	logger.Logger.Info("Завершение работы сервера...")
+	if s.grpcServer != nil {
+		s.stopGRPC(ctx)
+	}
 	return s.httpServer.Shutdown(ctx)
 }
 
+// stopGRPC дожидается завершения gRPC запросов, но не дольше ctx.
+// Потоки Watch не завершаются сами, поэтому по истечении ctx они закрываются принудительно.
+func (s *Server) stopGRPC(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+	}
+}
+
 // setupRoutes настраивает маршруты HTTP сервера.
 func (s *Server) setupRoutes() {
-	authHandler := handlers.NewAuthHandler(s.repo, s.config.JWT.Secret)
-	dataHandler := handlers.NewDataHandler(s.repo, s.config.Crypto.Key)
+	recorder := audit.NewRecorder(s.repo.NewAuditRepository(), s.keys, s.config.Audit.CheckpointInterval)
+	authHandler := handlers.NewAuthHandler(s.repo, s.config.JWT.Secret, s.keys, s.config.JWT.RefreshTTL, recorder)
+	dataHandler := handlers.NewDataHandler(s.repo, s.keys, s.events, recorder)
+	fileHandler := handlers.NewFileHandler(s.repo, s.blobs, s.keys, s.events, recorder, s.config.Storage.MaxFileSize)
+	vaultHandler := handlers.NewVaultHandler(s.repo)
+	auditHandler := handlers.NewAuditHandler(s.repo)
+	authz := middleware.NewAuthorizer(s.repo.NewVaultRepository())
+
+	if s.config.Server.GRPCPort != "" {
+		s.grpcServer = grpcserver.New(authHandler, dataHandler, s.events, s.config.JWT.Secret)
+	}
 
 	api := s.router.Group("/api/v1")
 	{
 		api.POST("/register", authHandler.Register)
 		api.POST("/login", authHandler.Login)
+		api.POST("/login/2fa", authHandler.LoginTwoFactor)
+		api.POST("/refresh", authHandler.Refresh)
 
 		protected := api.Group("/")
-		protected.Use(middleware.AuthMiddleware(s.config.JWT.Secret))
+		protected.Use(middleware.AuthMiddleware(s.config.JWT.Secret, authHandler))
 		{
+			protected.POST("/logout", authHandler.Logout)
+			protected.GET("/sessions", authHandler.GetSessions)
+			protected.DELETE("/sessions", authHandler.DeleteSessions)
+			protected.DELETE("/sessions/:id", authHandler.DeleteSession)
+			protected.POST("/2fa/enable", authHandler.EnableTwoFactor)
+			protected.POST("/2fa/confirm", authHandler.ConfirmTwoFactor)
+			protected.POST("/2fa/disable", authHandler.DisableTwoFactor)
+			protected.PUT("/kdf-check", authHandler.PutKDFCheck)
 			protected.GET("/data", dataHandler.GetData)
+			protected.GET("/data/changes", dataHandler.GetChanges)
+			protected.GET("/events", dataHandler.StreamEvents)
+			protected.GET("/data/search", dataHandler.SearchData)
 			protected.GET("/data/:id", dataHandler.GetDataByID)
 			protected.POST("/data", dataHandler.CreateData)
+			protected.POST("/data/import", dataHandler.ImportData)
+			protected.POST("/data/batch", dataHandler.BatchData)
 			protected.PUT("/data/:id", dataHandler.UpdateData)
 			protected.DELETE("/data/:id", dataHandler.DeleteData)
+			protected.GET("/data/:id/history", dataHandler.GetHistory)
+			protected.GET("/data/:id/history/:revision", dataHandler.GetRevision)
+			protected.POST("/data/:id/history/:revision/restore", dataHandler.RestoreRevision)
+			protected.GET("/data/:id/shares", dataHandler.GetShares)
+			protected.POST("/data/:id/shares", dataHandler.ShareData)
+			protected.DELETE("/data/:id/shares/:share", dataHandler.DeleteShare)
+			protected.PUT("/data/:id/tags", dataHandler.PutDataTags)
+			protected.PUT("/data/:id/folder", dataHandler.PutDataFolder)
+			protected.PUT("/data/:id/favorite", dataHandler.PutDataFavorite)
+			protected.GET("/tags", dataHandler.GetTags)
+			protected.POST("/tags", dataHandler.CreateTag)
+			protected.PUT("/tags/:id", dataHandler.UpdateTag)
+			protected.DELETE("/tags/:id", dataHandler.DeleteTag)
+			protected.GET("/folders", dataHandler.GetFolders)
+			protected.POST("/folders", dataHandler.CreateFolder)
+			protected.PUT("/folders/:id", dataHandler.UpdateFolder)
+			protected.DELETE("/folders/:id", dataHandler.DeleteFolder)
+			protected.POST("/files", fileHandler.CreateFile)
+			protected.GET("/files/:id", fileHandler.GetFile)
+			protected.PUT("/files/:id/chunks/:index", fileHandler.UploadChunk)
+			protected.GET("/files/:id/chunks/:index", fileHandler.DownloadChunk)
+			protected.POST("/files/:id/complete", fileHandler.CompleteFile)
+			protected.GET("/trash", dataHandler.GetTrash)
+			protected.POST("/trash/:id/restore", dataHandler.RestoreTrash)
+			protected.DELETE("/trash/:id", dataHandler.PurgeTrash)
+			protected.GET("/backup", dataHandler.ExportBackup)
+			protected.POST("/backup/restore", dataHandler.RestoreBackup)
+			protected.GET("/audit", auditHandler.GetAudit)
+			protected.POST("/vaults", vaultHandler.CreateVault)
+			protected.GET("/vaults", vaultHandler.GetVaults)
+			protected.GET("/vaults/:id", authz.RequireVaultRole(models.VaultRoleViewer), vaultHandler.GetVault)
+			protected.DELETE("/vaults/:id", authz.RequireVaultRole(models.VaultRoleOwner), vaultHandler.DeleteVault)
+			protected.GET("/vaults/:id/data", authz.RequireVaultRole(models.VaultRoleViewer), vaultHandler.GetVaultData)
+			protected.PUT("/vaults/:id/members", authz.RequireVaultRole(models.VaultRoleAdmin), vaultHandler.PutVaultMember)
+			protected.DELETE("/vaults/:id/members/:user", authz.RequireVaultRole(models.VaultRoleViewer), vaultHandler.DeleteVaultMember)
 		}
 	}
 
@@ -114,3 +297,22 @@ func (s *Server) setupRoutes() {
 		c.JSON(http.StatusOK, gin.H{"status": "OK"})
 	})
 }
+
+// RotateKeys перешифровывает данные, сохраненные неактивными ключами, активным ключом шифрования.
+func (s *Server) RotateKeys(ctx context.Context, batchSize int) (RotationStats, error) {
+	rotator := NewKeyRotator(
+		s.repo.NewDataRepository(),
+		s.repo.NewRevisionRepository(),
+		s.repo.NewUserRepository(),
+		s.repo.NewVaultRepository(),
+		s.repo.NewAuditRepository(),
+		s.keys,
+		batchSize,
+	)
+	return rotator.Run(ctx)
+}
+
+// VerifyAudit проверяет цепочку журнала аудита и подписи контрольных точек.
+func (s *Server) VerifyAudit(ctx context.Context, batchSize int) (audit.VerifyResult, error) {
+	return audit.Verify(ctx, s.repo.NewAuditRepository(), s.keys, batchSize)
+}